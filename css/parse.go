@@ -87,6 +87,8 @@ type Parser struct {
 
 	data        []byte
 	tt          TokenType
+	offset      int // start offset of the last popped token
+	dataOffset  int // start offset of data
 	keepWS      bool
 	prevWS      bool
 	prevEnd     bool
@@ -128,11 +130,13 @@ func (p *Parser) Next() (GrammarType, TokenType, []byte) {
 	p.err = ""
 
 	if p.prevEnd {
+		// the right brace was the last popped token
 		p.tt, p.data = RightBraceToken, endBytes
 		p.prevEnd = false
 	} else {
 		p.tt, p.data = p.popToken(true)
 	}
+	p.dataOffset = p.offset
 	gt := p.state[len(p.state)-1](p)
 	return gt, p.tt, p.data
 }
@@ -142,6 +146,11 @@ func (p *Parser) Offset() int {
 	return p.l.r.Offset()
 }
 
+// StartOffset returns the offset at which the current Grammar starts, such as the position of the at-keyword of an at-rule, of the first token of a selector, or of the property name of a declaration. It can be used to map serialized grammar back to the input, for example for source maps.
+func (p *Parser) StartOffset() int {
+	return p.dataOffset
+}
+
 // Values returns a slice of Tokens for the last Grammar. Only AtRuleGrammar, BeginAtRuleGrammar, BeginRulesetGrammar and Declaration will return the at-rule components, ruleset selector and declaration values respectively.
 func (p *Parser) Values() []Token {
 	return p.buf
@@ -162,6 +171,7 @@ func (p *Parser) popToken(allowComment bool) (TokenType, []byte) {
 		}
 		tt, data = p.l.Next()
 	}
+	p.offset = p.l.r.Offset() - len(data)
 	return tt, data
 }
 
@@ -191,9 +201,11 @@ func (p *Parser) parseStylesheet() GrammarType {
 func (p *Parser) parseDeclarationList() GrammarType {
 	if p.tt == CommentToken {
 		p.tt, p.data = p.popToken(false)
+		p.dataOffset = p.offset
 	}
 	for p.tt == SemicolonToken {
 		p.tt, p.data = p.popToken(false)
+		p.dataOffset = p.offset
 	}
 
 	// IE hack: *color:red;
@@ -296,6 +308,7 @@ func (p *Parser) parseAtRuleRuleList() GrammarType {
 func (p *Parser) parseAtRuleDeclarationList() GrammarType {
 	for p.tt == SemicolonToken {
 		p.tt, p.data = p.popToken(false)
+		p.dataOffset = p.offset
 	}
 	if p.tt == RightBraceToken || p.tt == ErrorToken {
 		p.state = p.state[:len(p.state)-1]
@@ -368,6 +381,7 @@ func (p *Parser) parseQualifiedRule() GrammarType {
 func (p *Parser) parseQualifiedRuleDeclarationList() GrammarType {
 	for p.tt == SemicolonToken {
 		p.tt, p.data = p.popToken(false)
+		p.dataOffset = p.offset
 	}
 	if p.tt == RightBraceToken || p.tt == ErrorToken {
 		p.state = p.state[:len(p.state)-1]
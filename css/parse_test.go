@@ -204,6 +204,20 @@ func TestParseOffset(t *testing.T) {
 	test.T(t, z.Offset(), 26) // }
 }
 
+func TestParseStartOffset(t *testing.T) {
+	p := NewParser(parse.NewInputString("/* c */ @import 'a';\na, b > c { color : red ;; margin:0 }\n@media print{x{y:z}}"), false)
+	var offsets []int
+	for {
+		gt, _, _ := p.Next()
+		if gt == ErrorGrammar {
+			break
+		}
+		offsets = append(offsets, p.StartOffset())
+	}
+	// comment, at-rule, a, b > c, color, margin, }, @media, x, y, }, }
+	test.T(t, fmt.Sprint(offsets), "[0 8 21 24 32 47 56 58 71 73 76 77]")
+}
+
 ////////////////////////////////////////////////////////////////
 
 type Obj struct{}
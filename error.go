@@ -11,6 +11,7 @@ type Error struct {
 	Message string
 	Line    int
 	Column  int
+	Offset  int
 	Context string
 }
 
@@ -24,6 +25,7 @@ func NewError(r io.Reader, offset int, message string, a ...interface{}) *Error
 		Message: message,
 		Line:    line,
 		Column:  column,
+		Offset:  offset,
 		Context: context,
 	}
 }
@@ -13,6 +13,7 @@ func TestError(t *testing.T) {
 	line, column, context := err.Position()
 	test.T(t, line, 1, "line")
 	test.T(t, column, 4, "column")
+	test.T(t, err.Offset, 3, "offset")
 	test.T(t, "\n"+context, "\n    1: buffer\n          ^", "context")
 
 	test.T(t, err.Error(), "message on line 1 and column 4\n    1: buffer\n          ^", "error")
@@ -26,6 +27,7 @@ func TestErrorLexer(t *testing.T) {
 	line, column, context := err.Position()
 	test.T(t, line, 1, "line")
 	test.T(t, column, 4, "column")
+	test.T(t, err.Offset, 3, "offset")
 	test.T(t, "\n"+context, "\n    1: buffer\n          ^", "context")
 
 	test.T(t, err.Error(), "message on line 1 and column 4\n    1: buffer\n          ^", "error")
@@ -16,8 +16,10 @@ type JSONer interface {
 
 // AST is the full ECMAScript abstract syntax tree.
 type AST struct {
-	Comments  [][]byte // first comments in file
-	BlockStmt          // module
+	Comments   [][]byte       // first comments in file
+	CommentMap CommentMap     // comments attached to nodes, excluding the first comments
+	Errors     []*parse.Error // syntax errors when parsing with ParseOptions.Recover
+	BlockStmt                 // module
 }
 
 func (ast *AST) String() string {
@@ -67,12 +69,30 @@ func (decl DeclType) String() string {
 	return "Invalid(" + strconv.Itoa(int(decl)) + ")"
 }
 
-// Var is a variable, where Decl is the type of declaration and can be var|function for function scoped variables, let|const|class for block scoped variables.
+// Var is an occurrence of a variable, where Decl is the type of declaration and can be var|function for function scoped variables, let|const|class for block scoped variables. All occurrences of a variable share its data, while each has its own Span. The scopes hold the first occurrence of each variable.
 type Var struct {
-	Data []byte
-	Link *Var // is set when merging variable uses, as in:  {a} {var a}  where the first links to the second, only used for undeclared variables
-	Uses uint16
-	Decl DeclType
+	*varData
+	Span
+}
+
+type varData struct {
+	Data  []byte
+	Link  *Var // is set when merging variable uses, as in:  {a} {var a}  where the first links to the second, only used for undeclared variables
+	Uses  uint16
+	Decl  DeclType
+	first *Var // occurrence that is held by the scopes
+}
+
+// NewVar returns a new variable without a position.
+func NewVar(data []byte, decl DeclType) *Var {
+	v := &Var{&varData{data, nil, 0, decl, nil}, Span{}}
+	v.first = v
+	return v
+}
+
+// occurrence returns a new occurrence of the variable at the given span.
+func (v *Var) occurrence(span Span) *Var {
+	return &Var{v.varData, span}
 }
 
 // Name returns the variable name.
@@ -144,8 +164,10 @@ func (s Scope) String() string {
 	return "Scope{Declared: " + s.Declared.String() + ", Undeclared: " + s.Undeclared.String() + "}"
 }
 
-// Declare declares a new variable.
+// Declare declares a new variable. Names with unicode escape sequences are decoded, see DecodeIdentifier.
 func (s *Scope) Declare(decl DeclType, name []byte) (*Var, bool) {
+	name = DecodeIdentifier(name)
+
 	// refer to new variable for previously undeclared symbols in the current and lower scopes
 	// this happens in `{ a = 5; } var a` where both a's refer to the same variable
 	curScope := s
@@ -192,7 +214,7 @@ func (s *Scope) Declare(decl DeclType, name []byte) (*Var, bool) {
 	}
 	if v == nil {
 		// add variable to the context list and to the scope
-		v = &Var{name, nil, 0, decl}
+		v = NewVar(name, decl)
 	} else {
 		v.Decl = decl
 	}
@@ -205,8 +227,10 @@ func (s *Scope) Declare(decl DeclType, name []byte) (*Var, bool) {
 	return v, true
 }
 
-// Use increments the usage of a variable.
+// Use increments the usage of a variable. Names with unicode escape sequences are decoded, see DecodeIdentifier.
 func (s *Scope) Use(name []byte) *Var {
+	name = DecodeIdentifier(name)
+
 	// check if variable is declared in the current scope
 	v := s.findDeclared(name, false)
 	if v == nil {
@@ -214,7 +238,7 @@ func (s *Scope) Use(name []byte) *Var {
 		v = s.findUndeclared(name)
 		if v == nil {
 			// add variable to the context list and to the scope's undeclared
-			v = &Var{name, nil, 0, NoDecl}
+			v = NewVar(name, NoDecl)
 			s.Undeclared = append(s.Undeclared, v)
 		}
 	}
@@ -333,10 +357,25 @@ func (s *Scope) Unscope() {
 
 ////////////////////////////////////////////////////////////////
 
+// Span is the byte range of a node in the input, where End is exclusive.
+type Span struct {
+	Start, End int
+}
+
+// Offsets returns the start and end byte offsets of the node in the input.
+func (span Span) Offsets() (int, int) {
+	return span.Start, span.End
+}
+
+func (span *Span) setSpan(start, end int) {
+	span.Start, span.End = start, end
+}
+
 // INode is an interface for AST nodes
 type INode interface {
 	String() string
 	JS() string
+	Offsets() (int, int)
 }
 
 // IStmt is a dummy interface for statements.
@@ -363,6 +402,7 @@ type IExpr interface {
 type BlockStmt struct {
 	List []IStmt
 	Scope
+	Span
 }
 
 func (n BlockStmt) String() string {
@@ -390,6 +430,7 @@ func (n BlockStmt) JS() string {
 
 // EmptyStmt is an empty statement.
 type EmptyStmt struct {
+	Span
 }
 
 func (n EmptyStmt) String() string {
@@ -404,6 +445,7 @@ func (n EmptyStmt) JS() string {
 // ExprStmt is an expression statement.
 type ExprStmt struct {
 	Value IExpr
+	Span
 }
 
 func (n ExprStmt) String() string {
@@ -424,6 +466,7 @@ type IfStmt struct {
 	Cond IExpr
 	Body IStmt
 	Else IStmt // can be nil
+	Span
 }
 
 func (n IfStmt) String() string {
@@ -458,6 +501,7 @@ func (n IfStmt) JS() string {
 type DoWhileStmt struct {
 	Cond IExpr
 	Body IStmt
+	Span
 }
 
 func (n DoWhileStmt) String() string {
@@ -480,6 +524,7 @@ func (n DoWhileStmt) JS() string {
 type WhileStmt struct {
 	Cond IExpr
 	Body IStmt
+	Span
 }
 
 func (n WhileStmt) String() string {
@@ -501,6 +546,7 @@ type ForStmt struct {
 	Cond IExpr // can be nil
 	Post IExpr // can be nil
 	Body *BlockStmt
+	Span
 }
 
 func (n ForStmt) String() string {
@@ -543,6 +589,7 @@ type ForInStmt struct {
 	Init  IExpr
 	Value IExpr
 	Body  *BlockStmt
+	Span
 }
 
 func (n ForInStmt) String() string {
@@ -560,6 +607,7 @@ type ForOfStmt struct {
 	Init  IExpr
 	Value IExpr
 	Body  *BlockStmt
+	Span
 }
 
 func (n ForOfStmt) String() string {
@@ -584,6 +632,7 @@ type CaseClause struct {
 	TokenType
 	Cond IExpr // can be nil
 	List []IStmt
+	Span
 }
 
 func (n CaseClause) String() string {
@@ -617,6 +666,7 @@ type SwitchStmt struct {
 	Init IExpr
 	List []CaseClause
 	Scope
+	Span
 }
 
 func (n SwitchStmt) String() string {
@@ -640,6 +690,7 @@ func (n SwitchStmt) JS() string {
 type BranchStmt struct {
 	Type  TokenType
 	Label []byte // can be nil
+	Span
 }
 
 func (n BranchStmt) String() string {
@@ -662,6 +713,7 @@ func (n BranchStmt) JS() string {
 // ReturnStmt is a return statement.
 type ReturnStmt struct {
 	Value IExpr // can be nil
+	Span
 }
 
 func (n ReturnStmt) String() string {
@@ -685,6 +737,7 @@ func (n ReturnStmt) JS() string {
 type WithStmt struct {
 	Cond IExpr
 	Body IStmt
+	Span
 }
 
 func (n WithStmt) String() string {
@@ -700,6 +753,7 @@ func (n WithStmt) JS() string {
 type LabelledStmt struct {
 	Label []byte
 	Value IStmt
+	Span
 }
 
 func (n LabelledStmt) String() string {
@@ -714,6 +768,7 @@ func (n LabelledStmt) JS() string {
 // ThrowStmt is a throw statement.
 type ThrowStmt struct {
 	Value IExpr
+	Span
 }
 
 func (n ThrowStmt) String() string {
@@ -731,6 +786,7 @@ type TryStmt struct {
 	Binding IBinding   // can be nil
 	Catch   *BlockStmt // can be nil
 	Finally *BlockStmt // can be nil
+	Span
 }
 
 func (n TryStmt) String() string {
@@ -766,6 +822,7 @@ func (n TryStmt) JS() string {
 
 // DebuggerStmt is a debugger statement.
 type DebuggerStmt struct {
+	Span
 }
 
 func (n DebuggerStmt) String() string {
@@ -781,6 +838,7 @@ func (n DebuggerStmt) JS() string {
 type Alias struct {
 	Name    []byte // can be nil
 	Binding []byte // can be nil
+	Span
 }
 
 func (alias Alias) String() string {
@@ -796,11 +854,44 @@ func (alias Alias) JS() string {
 	return alias.String()
 }
 
+// ImportAttribute is an attribute of the module of an import or export statement, as in with { type: "json" }.
+type ImportAttribute struct {
+	Key   []byte // identifier name or string literal
+	Value []byte // string literal
+	Span
+}
+
+func (n ImportAttribute) String() string {
+	return string(n.Key) + ": " + string(n.Value)
+}
+
+// JS converts the node back to valid JavaScript
+func (n ImportAttribute) JS() string {
+	return n.String()
+}
+
+// importAttributesString returns the with clause of the import attributes, or an empty string if there are none.
+func importAttributesString(list []ImportAttribute) string {
+	if len(list) == 0 {
+		return ""
+	}
+	s := " with {"
+	for i, item := range list {
+		if i != 0 {
+			s += ","
+		}
+		s += " " + item.String()
+	}
+	return s + " }"
+}
+
 // ImportStmt is an import statement.
 type ImportStmt struct {
-	List    []Alias
-	Default []byte // can be nil
-	Module  []byte
+	List       []Alias
+	Default    []byte // can be nil
+	Module     []byte
+	Attributes []ImportAttribute
+	Span
 }
 
 func (n ImportStmt) String() string {
@@ -828,7 +919,7 @@ func (n ImportStmt) String() string {
 	if n.Default != nil || len(n.List) != 0 {
 		s += " from"
 	}
-	return s + " " + string(n.Module) + ")"
+	return s + " " + string(n.Module) + importAttributesString(n.Attributes) + ")"
 }
 
 // JS converts the node back to valid JavaScript
@@ -857,15 +948,17 @@ func (n ImportStmt) JS() string {
 	if n.Default != nil || len(n.List) != 0 {
 		s += " from"
 	}
-	return s + " " + string(n.Module)
+	return s + " " + string(n.Module) + importAttributesString(n.Attributes)
 }
 
 // ExportStmt is an export statement.
 type ExportStmt struct {
-	List    []Alias
-	Module  []byte // can be nil
-	Default bool
-	Decl    IExpr
+	List       []Alias
+	Module     []byte // can be nil
+	Attributes []ImportAttribute
+	Default    bool
+	Decl       IExpr
+	Span
 }
 
 func (n ExportStmt) String() string {
@@ -890,7 +983,7 @@ func (n ExportStmt) String() string {
 		s += " }"
 	}
 	if n.Module != nil {
-		s += " from " + string(n.Module)
+		s += " from " + string(n.Module) + importAttributesString(n.Attributes)
 	}
 	return s + ")"
 }
@@ -918,7 +1011,7 @@ func (n ExportStmt) JS() string {
 		s += " }"
 	}
 	if n.Module != nil {
-		s += " from " + string(n.Module)
+		s += " from " + string(n.Module) + importAttributesString(n.Attributes)
 	}
 	return s
 }
@@ -926,6 +1019,7 @@ func (n ExportStmt) JS() string {
 // DirectivePrologueStmt is a string literal at the beginning of a function or module (usually "use strict").
 type DirectivePrologueStmt struct {
 	Value []byte
+	Span
 }
 
 func (n DirectivePrologueStmt) String() string {
@@ -964,6 +1058,7 @@ func (n DirectivePrologueStmt) stmtNode() {}
 type PropertyName struct {
 	Literal  LiteralExpr
 	Computed IExpr // can be nil
+	Span
 }
 
 // IsSet returns true is PropertyName is not nil.
@@ -1001,6 +1096,7 @@ func (n PropertyName) JS() string {
 type BindingArray struct {
 	List []BindingElement
 	Rest IBinding // can be nil
+	Span
 }
 
 func (n BindingArray) String() string {
@@ -1042,6 +1138,7 @@ func (n BindingArray) JS() string {
 type BindingObjectItem struct {
 	Key   *PropertyName // can be nil
 	Value BindingElement
+	Span
 }
 
 func (n BindingObjectItem) String() string {
@@ -1069,6 +1166,7 @@ func (n BindingObjectItem) JS() string {
 type BindingObject struct {
 	List []BindingObjectItem
 	Rest *Var // can be nil
+	Span
 }
 
 func (n BindingObject) String() string {
@@ -1120,6 +1218,7 @@ func (n BindingObject) JS() string {
 type BindingElement struct {
 	Binding IBinding // can be nil (in case of ellision)
 	Default IExpr    // can be nil
+	Span
 }
 
 func (n BindingElement) String() string {
@@ -1151,14 +1250,20 @@ func (n BindingObject) bindingNode() {}
 
 ////////////////////////////////////////////////////////////////
 
-// VarDecl is a variable statement or lexical declaration.
+// VarDecl is a variable statement or lexical declaration, where TokenType is VarToken, LetToken, ConstToken, or UsingToken.
 type VarDecl struct {
 	TokenType
-	List []BindingElement
+	Await bool // await using declaration
+	List  []BindingElement
+	Span
 }
 
 func (n VarDecl) String() string {
-	s := "Decl(" + n.TokenType.String()
+	s := "Decl("
+	if n.Await {
+		s += "await "
+	}
+	s += n.TokenType.String()
 	for _, item := range n.List {
 		s += " " + item.String()
 	}
@@ -1168,6 +1273,9 @@ func (n VarDecl) String() string {
 // JS converts the node back to valid JavaScript
 func (n VarDecl) JS() string {
 	s := n.TokenType.String()
+	if n.Await {
+		s = "await " + s
+	}
 	for i, item := range n.List {
 		if i != 0 {
 			s += ","
@@ -1181,6 +1289,7 @@ func (n VarDecl) JS() string {
 type Params struct {
 	List []BindingElement
 	Rest IBinding // can be nil
+	Span
 }
 
 func (n Params) String() string {
@@ -1225,6 +1334,7 @@ type FuncDecl struct {
 	Name      *Var // can be nil
 	Params    Params
 	Body      BlockStmt
+	Span
 }
 
 func (n FuncDecl) String() string {
@@ -1262,18 +1372,20 @@ func (n FuncDecl) JS() string {
 
 // MethodDecl is a method definition in a class declaration.
 type MethodDecl struct {
-	Static    bool
-	Async     bool
-	Generator bool
-	Get       bool
-	Set       bool
-	Name      PropertyName
-	Params    Params
-	Body      BlockStmt
+	Decorators []IExpr
+	Static     bool
+	Async      bool
+	Generator  bool
+	Get        bool
+	Set        bool
+	Name       PropertyName
+	Params     Params
+	Body       BlockStmt
+	Span
 }
 
 func (n MethodDecl) String() string {
-	s := ""
+	s := decoratorsString(n.Decorators, false)
 	if n.Static {
 		s += " static"
 	}
@@ -1295,7 +1407,7 @@ func (n MethodDecl) String() string {
 
 // JS converts the node back to valid JavaScript
 func (n MethodDecl) JS() string {
-	s := ""
+	s := decoratorsString(n.Decorators, true)
 	if n.Static {
 		s += " static"
 	}
@@ -1315,39 +1427,83 @@ func (n MethodDecl) JS() string {
 	return s[1:]
 }
 
-// FieldDefinition is a field definition in a class declaration.
+// FieldDefinition is a field definition in a class declaration, or a static initialization block if Body is set. Static blocks are field definitions so that they keep their order with respect to the static fields, which are evaluated in order.
 type FieldDefinition struct {
-	Name PropertyName
-	Init IExpr
+	Decorators []IExpr
+	Static     bool
+	Accessor   bool         // auto-accessor
+	Name       PropertyName // not set for a static block
+	Init       IExpr        // can be nil
+	Body       *BlockStmt   // static block, can be nil
+	Span
 }
 
 func (n FieldDefinition) String() string {
-	s := "Definition(" + n.Name.String()
+	s := decoratorsString(n.Decorators, false)
+	if n.Static {
+		s += " static"
+	}
+	if n.Body != nil {
+		return "Definition(" + s[1:] + " " + n.Body.String() + ")"
+	}
+	if n.Accessor {
+		s += " accessor"
+	}
+	s += " " + n.Name.String()
 	if n.Init != nil {
 		s += " = " + n.Init.String()
 	}
-	return s + ")"
+	return "Definition(" + s[1:] + ")"
 }
 
 // JS converts the node back to valid JavaScript
 func (n FieldDefinition) JS() string {
-	s := n.Name.String()
+	s := decoratorsString(n.Decorators, true)
+	if n.Static {
+		s += " static"
+	}
+	if n.Body != nil {
+		return s[1:] + " " + n.Body.JS()
+	}
+	if n.Accessor {
+		s += " accessor"
+	}
+	s += " " + n.Name.String()
 	if n.Init != nil {
 		s += " = " + n.Init.JS()
 	}
+	return s[1:]
+}
+
+// decoratorsString returns the decorators, each preceded by a space.
+func decoratorsString(list []IExpr, js bool) string {
+	s := ""
+	for _, item := range list {
+		if js {
+			s += " @" + item.JS()
+		} else {
+			s += " @" + item.String()
+		}
+	}
 	return s
 }
 
 // ClassDecl is a class declaration.
 type ClassDecl struct {
+	Decorators  []IExpr
 	Name        *Var  // can be nil
 	Extends     IExpr // can be nil
 	Definitions []FieldDefinition
 	Methods     []*MethodDecl
+	Span
 }
 
 func (n ClassDecl) String() string {
-	s := "Decl(class"
+	s := "Decl("
+	for _, item := range n.Decorators {
+		s += "@" + item.String() + " "
+	}
+	s += "class"
 	if n.Name != nil {
 		s += " " + string(n.Name.Data)
 	}
@@ -1365,7 +1521,11 @@ func (n ClassDecl) String() string {
 
 // JS converts the node back to valid JavaScript
 func (n ClassDecl) JS() string {
-	s := "class"
+	s := ""
+	for _, item := range n.Decorators {
+		s += "@" + item.JS() + " "
+	}
+	s += "class"
 	if n.Name != nil {
 		s += " " + string(n.Name.Data)
 	}
@@ -1373,11 +1533,16 @@ func (n ClassDecl) JS() string {
 		s += " extends " + n.Extends.JS()
 	}
 	s += " { "
-	for _, item := range n.Definitions {
-		s += item.JS() + "; "
-	}
-	for _, item := range n.Methods {
-		s += item.JS() + "; "
+	// definitions and methods are interleaved in source order
+	i, j := 0, 0
+	for i < len(n.Definitions) || j < len(n.Methods) {
+		if j == len(n.Methods) || i < len(n.Definitions) && n.Definitions[i].Start <= n.Methods[j].Start {
+			s += n.Definitions[i].JS() + "; "
+			i++
+		} else {
+			s += n.Methods[j].JS() + "; "
+			j++
+		}
 	}
 	return s + "}"
 }
@@ -1397,6 +1562,7 @@ func (n MethodDecl) exprNode() {} // not a real IExpr, used for ObjectExpression
 type LiteralExpr struct {
 	TokenType
 	Data []byte
+	Span
 }
 
 func (n LiteralExpr) String() string {
@@ -1431,6 +1597,7 @@ func (n LiteralExpr) JSON(buf *bytes.Buffer) error {
 type Element struct {
 	Value  IExpr // can be nil
 	Spread bool
+	Span
 }
 
 func (n Element) String() string {
@@ -1459,6 +1626,7 @@ func (n Element) JS() string {
 // ArrayExpr is an array literal.
 type ArrayExpr struct {
 	List []Element
+	Span
 }
 
 func (n ArrayExpr) String() string {
@@ -1529,6 +1697,7 @@ type Property struct {
 	Spread bool
 	Value  IExpr
 	Init   IExpr // can be nil
+	Span
 }
 
 func (n Property) String() string {
@@ -1589,6 +1758,7 @@ func (n Property) JSON(buf *bytes.Buffer) error {
 // ObjectExpr is an object literal.
 type ObjectExpr struct {
 	List []Property
+	Span
 }
 
 func (n ObjectExpr) String() string {
@@ -1633,6 +1803,7 @@ func (n ObjectExpr) JSON(buf *bytes.Buffer) error {
 type TemplatePart struct {
 	Value []byte
 	Expr  IExpr
+	Span
 }
 
 func (n TemplatePart) String() string {
@@ -1650,6 +1821,7 @@ type TemplateExpr struct {
 	List []TemplatePart
 	Tail []byte
 	Prec OpPrec
+	Span
 }
 
 func (n TemplateExpr) String() string {
@@ -1678,6 +1850,7 @@ func (n TemplateExpr) JS() string {
 // GroupExpr is a parenthesized expression.
 type GroupExpr struct {
 	X IExpr
+	Span
 }
 
 func (n GroupExpr) String() string {
@@ -1694,6 +1867,7 @@ type IndexExpr struct {
 	X    IExpr
 	Y    IExpr
 	Prec OpPrec
+	Span
 }
 
 func (n IndexExpr) String() string {
@@ -1710,6 +1884,7 @@ type DotExpr struct {
 	X    IExpr
 	Y    LiteralExpr
 	Prec OpPrec
+	Span
 }
 
 func (n DotExpr) String() string {
@@ -1723,6 +1898,7 @@ func (n DotExpr) JS() string {
 
 // NewTargetExpr is a new target meta property.
 type NewTargetExpr struct {
+	Span
 }
 
 func (n NewTargetExpr) String() string {
@@ -1736,6 +1912,7 @@ func (n NewTargetExpr) JS() string {
 
 // ImportMetaExpr is a import meta meta property.
 type ImportMetaExpr struct {
+	Span
 }
 
 func (n ImportMetaExpr) String() string {
@@ -1750,6 +1927,7 @@ func (n ImportMetaExpr) JS() string {
 type Arg struct {
 	Value IExpr
 	Rest  bool
+	Span
 }
 
 func (n Arg) String() string {
@@ -1772,6 +1950,7 @@ func (n Arg) JS() string {
 // Args is a list of arguments as used by new and call expressions.
 type Args struct {
 	List []Arg
+	Span
 }
 
 func (n Args) String() string {
@@ -1801,6 +1980,7 @@ func (n Args) JS() string {
 type NewExpr struct {
 	X    IExpr
 	Args *Args // can be nil
+	Span
 }
 
 func (n NewExpr) String() string {
@@ -1824,6 +2004,7 @@ func (n NewExpr) JS() string {
 type CallExpr struct {
 	X    IExpr
 	Args Args
+	Span
 }
 
 func (n CallExpr) String() string {
@@ -1839,6 +2020,7 @@ func (n CallExpr) JS() string {
 type OptChainExpr struct {
 	X IExpr
 	Y IExpr // can be CallExpr, IndexExpr, LiteralExpr, or TemplateExpr
+	Span
 }
 
 func (n OptChainExpr) String() string {
@@ -1870,6 +2052,7 @@ func (n OptChainExpr) JS() string {
 type UnaryExpr struct {
 	Op TokenType
 	X  IExpr
+	Span
 }
 
 func (n UnaryExpr) String() string {
@@ -1905,6 +2088,7 @@ func (n UnaryExpr) JSON(buf *bytes.Buffer) error {
 type BinaryExpr struct {
 	Op   TokenType
 	X, Y IExpr
+	Span
 }
 
 func (n BinaryExpr) String() string {
@@ -1922,6 +2106,7 @@ func (n BinaryExpr) JS() string {
 // CondExpr is a conditional expression.
 type CondExpr struct {
 	Cond, X, Y IExpr
+	Span
 }
 
 func (n CondExpr) String() string {
@@ -1937,6 +2122,7 @@ func (n CondExpr) JS() string {
 type YieldExpr struct {
 	Generator bool
 	X         IExpr // can be nil
+	Span
 }
 
 func (n YieldExpr) String() string {
@@ -1967,6 +2153,7 @@ type ArrowFunc struct {
 	Async  bool
 	Params Params
 	Body   BlockStmt
+	Span
 }
 
 func (n ArrowFunc) String() string {
@@ -0,0 +1,610 @@
+package js
+
+import (
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/tdewolff/parse/v2"
+)
+
+// BundleFormat is the output format of Bundle.
+type BundleFormat int
+
+// BundleFormat values.
+const (
+	ModuleBundle BundleFormat = iota // ES module with the exports of the first entry module
+	ScriptBundle                     // script that wraps the modules in a function expression, which cannot import external modules
+)
+
+// BundleOptions are the options for Bundle.
+type BundleOptions struct {
+	Format BundleFormat
+	Name   string // variable of a script bundle that holds the namespace object of the first entry module, which is not kept if empty
+}
+
+// Bundle combines the modules of the graph into a single AST using scope hoisting, renaming top-level declarations whose names conflict. Modules in the CommonJS format cannot be bundled, and the ASTs of the graph are modified.
+func Bundle(g *ModuleGraph, o BundleOptions) (*AST, error) {
+	b := &bundler{
+		infos:     map[*Module]*ScopeInfo{},
+		imports:   map[*Module]map[string]bundleKey{},
+		importers: map[bundleKey][]bundleImporter{},
+		nested:    map[*Module]map[string]bool{},
+		globals:   map[string]bool{"Object": true, "Promise": true, "Symbol": true}, // used by the generated code
+		names:     map[bundleKey]string{},
+		taken:     map[string]bool{},
+	}
+	if len(g.Entries) == 0 {
+		return &AST{}, nil
+	}
+	visited := map[*Module]bool{}
+	for _, entry := range g.Entries {
+		b.visit(entry, visited)
+	}
+	for i := 0; i < len(b.order); i++ {
+		for _, dep := range b.order[i].Deps {
+			if dep.Kind == DynamicDependency && !dep.Module.External {
+				b.visit(dep.Module, visited)
+			}
+		}
+	}
+
+	if err := b.analyze(); err != nil {
+		return nil, err
+	}
+	entry := g.Entries[0]
+	var exports []Alias
+	if o.Format == ModuleBundle {
+		names, _ := entry.ExportNames()
+		for _, name := range names {
+			key, err := b.resolve(entry, name)
+			if err != nil {
+				return nil, err
+			}
+			b.use(key, bundleImporter{local: name})
+			exports = append(exports, Alias{Name: []byte(name), Binding: []byte(name)})
+		}
+	} else if o.Name != "" {
+		b.use(bundleKey{entry, "*"}, bundleImporter{local: o.Name})
+	}
+	if o.Format == ScriptBundle && 0 < len(b.externals) {
+		return nil, fmt.Errorf("cannot import external module %s in a script bundle", b.externals[0].Path)
+	}
+
+	b.assignNames()
+	list := []IStmt{}
+	stars := externalStars(entry, map[*Module]bool{})
+	for _, ext := range b.externals {
+		imports := b.externalImports(ext)
+		if len(imports) == 0 && (o.Format != ModuleBundle || !containsModule(stars, ext)) {
+			imports = append(imports, &ImportStmt{Module: EncodeString([]byte(ext.Path))})
+		}
+		list = append(list, imports...)
+	}
+	for _, key := range b.keys {
+		if !key.m.External && key.name == "*" {
+			stmt, err := b.namespace(key.m)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, stmt)
+		}
+	}
+	for _, m := range b.order {
+		stmts, err := b.hoist(m)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, stmts...)
+	}
+
+	if o.Format == ModuleBundle {
+		for i, alias := range exports {
+			key, _ := b.resolve(entry, string(alias.Binding))
+			name, err := b.name(key)
+			if err != nil {
+				return nil, err
+			}
+			if name != string(alias.Binding) {
+				exports[i].Name = []byte(name)
+			} else {
+				exports[i].Name = nil
+			}
+		}
+		if 0 < len(exports) {
+			list = append(list, &ExportStmt{List: exports})
+		}
+		for _, ext := range stars {
+			list = append(list, &ExportStmt{List: []Alias{{Binding: []byte("*")}}, Module: EncodeString([]byte(ext.Path))})
+		}
+		return &AST{BlockStmt: BlockStmt{List: list}}, nil
+	}
+
+	js := "(function () { 'use strict'; })()"
+	if o.Name != "" {
+		js = "var " + o.Name + " = " + js
+		list = append(list, parseStmts("return "+b.names[bundleKey{entry, "*"}])...)
+	}
+	stmts := parseStmts(js)
+	var call *CallExpr
+	if varDecl, ok := stmts[0].(*VarDecl); ok {
+		call = varDecl.List[0].Default.(*CallExpr)
+	} else {
+		call = stmts[0].(*ExprStmt).Value.(*CallExpr)
+	}
+	body := &call.X.(*GroupExpr).X.(*FuncDecl).Body
+	body.List = append(body.List, list...)
+	return &AST{BlockStmt: BlockStmt{List: stmts}}, nil
+}
+
+// bundleKey identifies a binding of the bundle.
+type bundleKey struct {
+	m    *Module
+	name string // local name of a top-level binding, default for an anonymous default export, or * for the namespace object; the imported name for external modules
+}
+
+// bundleImporter is a module that refers to a binding by a local name, or an export of the bundle when the module is nil.
+type bundleImporter struct {
+	m     *Module
+	local string
+}
+
+type bundler struct {
+	order     []*Module // modules in order of evaluation
+	externals []*Module // external modules that are imported statically
+	infos     map[*Module]*ScopeInfo
+	imports   map[*Module]map[string]bundleKey // bindings of the imports of each module by local name
+	importers map[bundleKey][]bundleImporter
+	keys      []bundleKey                 // namespace objects and bindings of external modules, in order of use
+	nested    map[*Module]map[string]bool // names declared in nested scopes
+	globals   map[string]bool             // names of undeclared variables that are not imports
+	names     map[bundleKey]string
+	taken     map[string]bool
+}
+
+// visit appends the module to the evaluation order after its static dependencies, where modules in a cycle are evaluated before the module that imports them first.
+func (b *bundler) visit(m *Module, visited map[*Module]bool) {
+	if m == nil || visited[m] {
+		return
+	}
+	visited[m] = true
+	if m.External {
+		b.externals = append(b.externals, m)
+		return
+	}
+	for _, dep := range m.Deps {
+		if dep.Kind == ImportDependency || dep.Kind == ExportDependency {
+			b.visit(dep.Module, visited)
+		}
+	}
+	b.order = append(b.order, m)
+}
+
+// analyze resolves the imports of all modules and finds the names that are declared in nested scopes or that are undeclared.
+func (b *bundler) analyze() error {
+	importers := []bundleImporter{}
+	for _, m := range b.order {
+		if m.Format == CommonJSFormat || m.Format == MixedFormat {
+			return fmt.Errorf("cannot bundle CommonJS module %s", m.Path)
+		}
+		b.imports[m] = map[string]bundleKey{}
+		deps := map[Span]*Dependency{}
+		for _, dep := range m.Deps {
+			deps[dep.Span] = dep
+		}
+		for _, item := range m.AST.BlockStmt.List {
+			stmt, ok := item.(*ImportStmt)
+			if !ok {
+				continue
+			}
+			dep := deps[stmt.Span]
+			if stmt.Default != nil {
+				if err := b.addImport(m, dep.Module, "default", string(stmt.Default)); err != nil {
+					return err
+				}
+				importers = append(importers, bundleImporter{m, string(stmt.Default)})
+			}
+			for _, alias := range stmt.List {
+				if alias.Binding == nil {
+					continue
+				}
+				name := alias.Binding
+				if alias.Name != nil {
+					name = alias.Name
+				}
+				if err := b.addImport(m, dep.Module, string(name), string(alias.Binding)); err != nil {
+					return err
+				}
+				importers = append(importers, bundleImporter{m, string(alias.Binding)})
+			}
+		}
+
+		info := NewScopeInfo(m.AST)
+		b.infos[m] = info
+		b.nested[m] = map[string]bool{}
+		def := defaultDeclName(m)
+		for _, v := range info.Vars() {
+			if _, s := info.Declaration(v); s == nil {
+				if _, ok := b.imports[m][string(v.Data)]; !ok && (def == nil || string(v.Data) != string(def.Data)) {
+					b.globals[string(v.Data)] = true
+				}
+			} else if s != &m.AST.BlockStmt.Scope {
+				b.nested[m][string(v.Data)] = true
+			}
+		}
+	}
+
+	// imports that are exported again resolve to the binding of the imported module
+	for _, importer := range importers {
+		key, err := b.binding(b.imports[importer.m][importer.local])
+		if err != nil {
+			return fmt.Errorf("%s: %w", importer.m.Path, err)
+		}
+		b.imports[importer.m][importer.local] = key
+		b.use(key, importer)
+	}
+	for _, m := range b.order {
+		for _, dep := range m.Deps {
+			if dep.Kind == DynamicDependency && !dep.Module.External {
+				b.use(bundleKey{dep.Module, "*"}, bundleImporter{})
+			}
+		}
+	}
+	return nil
+}
+
+func (b *bundler) addImport(m, dep *Module, name, local string) error {
+	if dep.External || name == "*" {
+		b.imports[m][local] = bundleKey{dep, name}
+		return nil
+	}
+	chain, binding, ok := dep.ResolveExport(name)
+	if !ok {
+		return fmt.Errorf("%s: %s does not export %s", m.Path, dep.Path, name)
+	}
+	b.imports[m][local] = bundleKey{chain[len(chain)-1], binding}
+	return nil
+}
+
+// resolve returns the binding of the exported name of the module.
+func (b *bundler) resolve(m *Module, name string) (bundleKey, error) {
+	if m.External || name == "*" {
+		return bundleKey{m, name}, nil
+	}
+	chain, local, ok := m.ResolveExport(name)
+	if !ok {
+		return bundleKey{}, fmt.Errorf("%s does not export %s", m.Path, name)
+	}
+	return b.binding(bundleKey{chain[len(chain)-1], local})
+}
+
+// binding follows the imports of local names that are exported by the module to the binding they import.
+func (b *bundler) binding(key bundleKey) (bundleKey, error) {
+	visited := map[bundleKey]bool{}
+	for !key.m.External && key.name != "*" {
+		next, ok := b.imports[key.m][key.name]
+		if !ok {
+			break
+		} else if visited[key] {
+			return bundleKey{}, fmt.Errorf("circular import of %s in %s", key.name, key.m.Path)
+		}
+		visited[key] = true
+		key = next
+	}
+	return key, nil
+}
+
+// use records the importer of a binding, and the bindings of the exports of namespace objects.
+func (b *bundler) use(key bundleKey, importer bundleImporter) {
+	if _, ok := b.importers[key]; !ok && (key.m.External || key.name == "*") {
+		b.keys = append(b.keys, key)
+		b.importers[key] = nil
+		if !key.m.External {
+			names, _ := key.m.ExportNames()
+			for _, name := range names {
+				if export, err := b.resolve(key.m, name); err == nil {
+					b.use(export, bundleImporter{})
+				}
+			}
+		}
+	}
+	if importer.local != "" {
+		b.importers[key] = append(b.importers[key], importer)
+	}
+}
+
+// assignNames assigns the names of all bindings, keeping the original names if possible.
+func (b *bundler) assignNames() {
+	for _, m := range b.order {
+		for _, v := range m.AST.BlockStmt.Scope.Declared {
+			b.assignName(bundleKey{m, string(v.Data)}, string(v.Data))
+		}
+		for _, export := range m.Exports {
+			if export.Name == "default" && export.Local == "default" {
+				b.assignName(bundleKey{m, "default"}, moduleBase(m.Path)+"_default")
+			} else if export.Name == "default" && export.Local != "" {
+				b.assignName(bundleKey{m, export.Local}, export.Local) // named function or class declaration
+			}
+		}
+	}
+	for _, key := range b.keys {
+		name := moduleBase(key.m.Path)
+		if importers := b.importers[key]; 0 < len(importers) && importers[0].m != nil {
+			name = importers[0].local
+		} else if key.m.External && key.name != "*" && key.name != "default" && isIdentifier(key.name) {
+			name = key.name
+		} else if key.m.External && key.name == "default" {
+			name += "_default"
+		} else if !key.m.External {
+			name += "_ns"
+		}
+		b.assignName(key, name)
+	}
+}
+
+// name returns the assigned name of a binding.
+func (b *bundler) name(key bundleKey) (string, error) {
+	name, ok := b.names[key]
+	if !ok {
+		return "", fmt.Errorf("%s: no name assigned to binding %s", key.m.Path, key.name)
+	}
+	return name, nil
+}
+
+func (b *bundler) assignName(key bundleKey, name string) {
+	if _, ok := b.names[key]; ok {
+		return
+	}
+	orig := name
+	for i := 1; !b.isFree(key, orig, name); i++ {
+		name = orig + "$" + strconv.Itoa(i)
+	}
+	b.names[key] = name
+	b.taken[name] = true
+}
+
+// isFree returns true if the name can be given to the binding. Besides not conflicting with other bindings and undeclared variables, the binding must not be shadowed by declarations of nested scopes where it is referred to by a different name.
+func (b *bundler) isFree(key bundleKey, orig, name string) bool {
+	if b.taken[name] || b.globals[name] || !isIdentifier(name) {
+		return false
+	} else if !key.m.External && name != orig && b.nested[key.m][name] {
+		return false
+	}
+	for _, importer := range b.importers[key] {
+		if importer.m != nil && importer.local != name && b.nested[importer.m][name] {
+			return false
+		}
+	}
+	return true
+}
+
+// externalImports returns the import statements of the bindings of an external module, where the default and named imports are combined.
+func (b *bundler) externalImports(m *Module) []IStmt {
+	module := EncodeString([]byte(m.Path))
+	named := &ImportStmt{Module: module}
+	imports := []IStmt{}
+	for _, key := range b.keys {
+		if key.m != m {
+			continue
+		}
+		name := []byte(b.names[key])
+		if key.name == "default" {
+			named.Default = name
+		} else if key.name == "*" {
+			imports = append(imports, &ImportStmt{List: []Alias{{Name: []byte("*"), Binding: name}}, Module: module})
+		} else if key.name == string(name) {
+			named.List = append(named.List, Alias{Binding: name})
+		} else {
+			named.List = append(named.List, Alias{Name: []byte(key.name), Binding: name})
+		}
+	}
+	if named.Default != nil || 0 < len(named.List) {
+		imports = append([]IStmt{named}, imports...)
+	}
+	return imports
+}
+
+// namespace returns the declaration of the namespace object of the module, whose getters keep the exports live.
+func (b *bundler) namespace(m *Module) (IStmt, error) {
+	sb := strings.Builder{}
+	sb.WriteString("var " + b.names[bundleKey{m, "*"}] + " = Object.freeze({__proto__: null, [Symbol.toStringTag]: 'Module'")
+	names, _ := m.ExportNames()
+	for _, name := range names {
+		key, err := b.resolve(m, name)
+		if err != nil {
+			continue
+		}
+		local, err := b.name(key)
+		if err != nil {
+			return nil, err
+		}
+		if !isIdentifier(name) {
+			name = string(EncodeString([]byte(name)))
+		}
+		sb.WriteString(", get " + name + "() { return " + local + " }")
+	}
+	sb.WriteString("})")
+	return parseStmts(sb.String())[0], nil
+}
+
+// hoist renames the top-level bindings and imports of the module, and returns its statements without imports and exports.
+func (b *bundler) hoist(m *Module) ([]IStmt, error) {
+	info := b.infos[m]
+	renames := map[*Var]string{}
+	for _, v := range m.AST.BlockStmt.Scope.Declared {
+		name, err := b.name(bundleKey{m, string(v.Data)})
+		if err != nil {
+			return nil, err
+		}
+		renames[v] = name
+	}
+	def := defaultDeclName(m)
+	if def != nil {
+		name, err := b.name(bundleKey{m, string(def.Data)})
+		if err != nil {
+			return nil, err
+		}
+		renames[resolveVar(def)] = name
+	}
+	for _, v := range info.Vars() {
+		if _, s := info.Declaration(v); s == nil {
+			key, ok := b.imports[m][string(v.Data)]
+			if !ok && def != nil && string(v.Data) == string(def.Data) {
+				key, ok = bundleKey{m, string(def.Data)}, true
+			}
+			if ok {
+				name, err := b.name(key)
+				if err != nil {
+					return nil, err
+				}
+				renames[v] = name
+			}
+		}
+	}
+	for v, name := range renames {
+		if name != string(v.Data) {
+			info.rename(v, []byte(name))
+		}
+	}
+
+	dynamic := map[Span]*Dependency{}
+	for _, dep := range m.Deps {
+		if dep.Kind == DynamicDependency && !dep.Module.External {
+			dynamic[dep.Span] = dep
+		}
+	}
+	if 0 < len(dynamic) {
+		Rewrite(&dynamicImportRewriter{b, dynamic}, m.AST)
+	}
+
+	list := []IStmt{}
+	for _, item := range m.AST.BlockStmt.List {
+		switch stmt := item.(type) {
+		case *DirectivePrologueStmt, *ImportStmt:
+			continue
+		case *ExportStmt:
+			if stmt.Decl == nil {
+				continue
+			} else if !stmt.Default {
+				list = append(list, stmt.Decl.(IStmt))
+				continue
+			}
+
+			if def != nil {
+				list = append(list, stmt.Decl.(IStmt))
+				continue
+			}
+			name, err := b.name(bundleKey{m, "default"})
+			if err != nil {
+				return nil, err
+			}
+			switch decl := stmt.Decl.(type) {
+			case *FuncDecl:
+				decl.Name = NewVar([]byte(name), FunctionDecl)
+				decl.Name.Uses = 1
+				list = append(list, decl)
+			case *ClassDecl:
+				decl.Name = NewVar([]byte(name), LexicalDecl)
+				decl.Name.Uses = 1
+				list = append(list, decl)
+			default:
+				v := NewVar([]byte(name), VariableDecl)
+				v.Uses = 1
+				list = append(list, &VarDecl{TokenType: VarToken, List: []BindingElement{{Binding: v, Default: decl}}})
+			}
+		default:
+			list = append(list, stmt)
+		}
+	}
+	return list, nil
+}
+
+// defaultDeclName returns the name of the function or class declaration that is exported as default, if any. The parser declares it in the scope of the function or class and not in the module scope, so that uses in the module scope are undeclared.
+func defaultDeclName(m *Module) *Var {
+	for _, item := range m.AST.BlockStmt.List {
+		if stmt, ok := item.(*ExportStmt); ok && stmt.Default {
+			switch decl := stmt.Decl.(type) {
+			case *FuncDecl:
+				return decl.Name
+			case *ClassDecl:
+				return decl.Name
+			}
+		}
+	}
+	return nil
+}
+
+type dynamicImportRewriter struct {
+	b       *bundler
+	dynamic map[Span]*Dependency
+}
+
+func (r *dynamicImportRewriter) Enter(c *Cursor) IRewriter {
+	if call, ok := c.Node().(*CallExpr); ok {
+		if dep, ok := r.dynamic[call.Span]; ok {
+			ns := r.b.names[bundleKey{dep.Module, "*"}]
+			c.Replace(parseStmts("Promise.resolve().then(() => " + ns + ")")[0].(*ExprStmt).Value)
+			return nil
+		}
+	}
+	return r
+}
+
+func (r *dynamicImportRewriter) Exit(c *Cursor) {}
+
+// externalStars returns the external modules whose exports are re-exported by the module using export * from, directly or indirectly.
+func externalStars(m *Module, visited map[*Module]bool) []*Module {
+	if visited[m] {
+		return nil
+	}
+	visited[m] = true
+	var externals []*Module
+	for _, star := range m.Stars {
+		if star.Module == nil || visited[star.Module] {
+			continue
+		} else if star.Module.External {
+			visited[star.Module] = true
+			externals = append(externals, star.Module)
+		} else {
+			externals = append(externals, externalStars(star.Module, visited)...)
+		}
+	}
+	return externals
+}
+
+func containsModule(modules []*Module, m *Module) bool {
+	for _, module := range modules {
+		if module == m {
+			return true
+		}
+	}
+	return false
+}
+
+// moduleBase returns an identifier based on the file name of the module path.
+func moduleBase(p string) string {
+	base := path.Base(p)
+	if i := strings.IndexByte(base, '.'); 0 < i {
+		base = base[:i]
+	}
+	b := []byte(base)
+	for i, c := range b {
+		if !('a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || c == '_' || c == '$') {
+			b[i] = '_'
+		}
+	}
+	if len(b) == 0 || '0' <= b[0] && b[0] <= '9' {
+		b = append([]byte{'_'}, b...)
+	}
+	return string(b)
+}
+
+// parseStmts parses generated code, which must be valid.
+func parseStmts(js string) []IStmt {
+	ast, err := Parse(parse.NewInputString(js))
+	if err != nil {
+		panic("js: invalid generated code: " + err.Error())
+	}
+	return ast.BlockStmt.List
+}
@@ -0,0 +1,77 @@
+package js
+
+import (
+	"testing"
+
+	"github.com/tdewolff/test"
+)
+
+func TestBundle(t *testing.T) {
+	var tests = []struct {
+		files    MemoryFiles
+		expected string
+	}{
+		{MemoryFiles{"main.js": "import { a } from './a'; const b = 2; export { a, b as c }", "a.js": "const b = 1; export const a = b"}, `const b = 1; const a = b; const b$1 = 2; export { a , b$1 as c }; `},
+		{MemoryFiles{"main.js": "import { count, inc } from './counter'; inc(); log(count)", "counter.js": "export let count = 0; export function inc() { count++ }"}, `let count = 0; function inc () { count++; }; inc(); log(count); `},
+		{MemoryFiles{"main.js": "import * as ns from './a'; log(ns.x, ns)", "a.js": "export var x = 1; export default 2; export { x as y }"}, `var ns = Object.freeze({__proto__: null, [Symbol.toStringTag]: 'Module', get default () { return a_default; }, get x () { return x; }, get y () { return x; }}); var x = 1; var a_default = 2; log(ns.x, ns); `},
+		{MemoryFiles{"main.js": "import f, { default as g } from './a'; import C from './c'; f(g, C)", "a.js": "export default 1 + 2", "c.js": "export default class { }"}, `var a_default = 1 + 2; class c_default { }; a_default(a_default, c_default); `},
+		{MemoryFiles{"main.js": "import { a } from './a'; export const b = () => a", "a.js": "import { b } from './main'; export function a() { return b() }"}, `function a () { return b(); }; const b = () => { return a; }; export { b }; `},
+		{MemoryFiles{"main.js": "import('./lazy').then(m => m.x)", "lazy.js": "export const x = 1"}, `var lazy_ns = Object.freeze({__proto__: null, [Symbol.toStringTag]: 'Module', get x () { return x; }}); Promise.resolve().then(() => { return lazy_ns; }).then((m) => { return m.x; }); const x = 1; `},
+		{MemoryFiles{"main.js": "import React, { useState as s } from 'react'; import './a'; export * from 'b'; s(React)", "a.js": "import * as r from 'react'; import 'side'; r.x()"}, `import React , { useState as s } from "react"; import * as r from "react"; import "side"; r.x(); s(React); export * from "b"; `},
+		{MemoryFiles{"main.js": "import { a } from './a'; function f(b) { return a + b }", "a.js": "const b = 1; export { b as a }"}, `const b$1 = 1; function f (b) { return b$1 + b; }; `},
+		{MemoryFiles{"main.js": "import { x } from './a'; let y = x; export { y as x }", "a.js": "export let x = 1; function f() { let y; return y }"}, `let x = 1; function f () { let y; return y; }; let y = x; export { y as x }; `},
+		{MemoryFiles{"main.js": "import x from './a'; log(x)", "a.js": "export default function f() { return f }"}, `function f () { return f; }; log(f); `},
+		{MemoryFiles{"main.js": "import x from './a'; log(x)", "a.js": "export default class C { }"}, `class C { }; log(C); `},
+		{MemoryFiles{"main.js": "import './b'; import x from './a'; log(x)", "a.js": "export default function f() { return f }; f()", "b.js": "const f = 1"}, `const f = 1; function f$1 () { return f$1; }; f$1(); log(f$1); `},
+		{MemoryFiles{"main.js": "export default function f() { }"}, `function f () { }; export { f as default }; `},
+		{MemoryFiles{"main.js": "import x from './a'; export default x", "a.js": "export default function f() { }"}, `function f () { }; var main_default = f; export { main_default as default }; `},
+		{MemoryFiles{"main.js": "'use strict'; export * from './a'; export * as ns from './a'; export { x as default } from './a'", "a.js": "export let x = 1"}, `var a_ns = Object.freeze({__proto__: null, [Symbol.toStringTag]: 'Module', get x () { return x; }}); let x = 1; export { x as default , a_ns as ns , x }; `},
+	}
+	for _, tt := range tests {
+		t.Run(tt.files["main.js"], func(t *testing.T) {
+			g, err := NewModuleGraph(tt.files, "./main.js")
+			test.Error(t, err)
+			ast, err := Bundle(g, BundleOptions{})
+			test.Error(t, err)
+			test.String(t, ast.JS(), tt.expected)
+		})
+	}
+}
+
+func TestBundleScript(t *testing.T) {
+	files := MemoryFiles{"main.js": "import { a } from './a'; export const b = a + 1", "a.js": "export const a = 1"}
+	g, err := NewModuleGraph(files, "./main.js")
+	test.Error(t, err)
+
+	ast, err := Bundle(g, BundleOptions{Format: ScriptBundle})
+	test.Error(t, err)
+	test.String(t, ast.JS(), `(function () { 'use strict'; const a = 1; const b = a + 1; })(); `)
+
+	ast, err = Bundle(g, BundleOptions{Format: ScriptBundle, Name: "lib"})
+	test.Error(t, err)
+	test.String(t, ast.JS(), `var lib = (function () { 'use strict'; var main_ns = Object.freeze({__proto__: null, [Symbol.toStringTag]: 'Module', get b () { return b; }}); const a = 1; const b = a + 1; return main_ns; })(); `)
+}
+
+func TestBundleError(t *testing.T) {
+	var tests = []struct {
+		files  MemoryFiles
+		format BundleFormat
+		err    string
+	}{
+		{MemoryFiles{"main.js": "import { b } from './a'", "a.js": "export const a = 1"}, ModuleBundle, "main.js: a.js does not export b"},
+		{MemoryFiles{"main.js": "import { a } from './a'; export { a }", "a.js": "import { a } from './main'; export { a }"}, ModuleBundle, "a.js: circular import of a in main.js"},
+		{MemoryFiles{"main.js": "import 'ext'"}, ScriptBundle, "cannot import external module ext in a script bundle"},
+		{MemoryFiles{"main.js": "import a from './a'", "a.js": "module.exports = 1"}, ModuleBundle, "cannot bundle CommonJS module a.js"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.err, func(t *testing.T) {
+			g, err := NewModuleGraph(tt.files, "./main.js")
+			test.Error(t, err)
+			_, err = Bundle(g, BundleOptions{Format: tt.format})
+			test.That(t, err != nil)
+			if err != nil {
+				test.String(t, err.Error(), tt.err)
+			}
+		})
+	}
+}
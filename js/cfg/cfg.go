@@ -0,0 +1,701 @@
+// Package cfg builds control-flow graphs of JavaScript functions and module bodies as parsed by the js package.
+package cfg
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/tdewolff/parse/v2/js"
+)
+
+// BlockKind is the reason a block was created.
+type BlockKind int
+
+// BlockKind values.
+const (
+	EntryBlock       BlockKind = iota // start of the body
+	ExitBlock                         // end of the body, reached by return statements and by falling off its end
+	EndBlock                          // falling off the end of the body, which precedes the exit block
+	UnreachableBlock                  // statements after a return, throw, break, or continue statement
+	IfThenBlock
+	IfElseBlock
+	IfDoneBlock
+	LoopHeadBlock // condition of a while, do-while, or for statement, or the next value of a for-in or for-of statement
+	LoopBodyBlock
+	LoopPostBlock // update of a for statement
+	LoopDoneBlock
+	SwitchCaseBlock // test of a case clause
+	SwitchBodyBlock // statements of a case clause
+	SwitchDoneBlock
+	TryBodyBlock
+	CatchBlock
+	FinallyBlock
+	TryDoneBlock
+	LabelDoneBlock // end of a labelled statement that is not a loop
+	CondBlock      // operand that is evaluated depending on a logical or conditional operator
+	CondDoneBlock
+)
+
+var blockKindNames = []string{"entry", "exit", "end", "unreachable", "if.then", "if.else", "if.done", "loop.head", "loop.body", "loop.post", "loop.done", "switch.case", "switch.body", "switch.done", "try.body", "catch", "finally", "try.done", "label.done", "cond", "cond.done"}
+
+func (k BlockKind) String() string {
+	if 0 <= k && int(k) < len(blockKindNames) {
+		return blockKindNames[k]
+	}
+	return "Invalid(" + strconv.Itoa(int(k)) + ")"
+}
+
+// Block is a basic block, which is a sequence of nodes that are evaluated in order without jumps.
+type Block struct {
+	Index int
+	Kind  BlockKind
+	Stmt  js.IStmt // statement that created the block, or nil
+
+	// Nodes are statements without nested statements, such as variable declarations and return statements, and expressions, such as conditions and the expression of expression statements. Function declarations are nodes at their position although they are hoisted. Nested functions and classes are single nodes.
+	Nodes []js.INode
+
+	// Succs are the successor blocks. A block that ends in a condition has the successor for a truthy condition first and for a falsy condition second, and for the ?? operator the successor for a value that is not null or undefined first.
+	Succs []*Block
+	Preds []*Block
+	Live  bool // reachable from the entry block
+}
+
+func (b *Block) String() string {
+	return "b" + strconv.Itoa(b.Index)
+}
+
+// Graph is the control-flow graph of a body.
+type Graph struct {
+	Blocks []*Block // in order of creation
+	Entry  *Block
+	Exit   *Block
+	End    *Block // falling off the end of the body, which is live if not all paths return or throw
+
+	stmts map[js.IStmt]*Block
+}
+
+// New returns the control-flow graph of the body of a *js.AST, *js.FuncDecl, *js.MethodDecl, or *js.ArrowFunc, and returns nil for other nodes. Conditions and expressions of expression statements are split into blocks at the logical operators &&, ||, and ??, the conditional operator, and for conditions at the ! operator. Constant conditions, as evaluated by js.Eval, only have the edge of their value. Blocks inside a try statement have an edge to its catch clause or finally block, since any node may throw. A finally block is built once and continues to all destinations of the jumps that pass through it.
+func New(n js.INode) *Graph {
+	var body *js.BlockStmt
+	switch n := n.(type) {
+	case *js.AST:
+		body = &n.BlockStmt
+	case *js.FuncDecl:
+		body = &n.Body
+	case *js.MethodDecl:
+		body = &n.Body
+	case *js.ArrowFunc:
+		body = &n.Body
+	default:
+		return nil
+	}
+
+	g := &Graph{
+		stmts: map[js.IStmt]*Block{},
+	}
+	b := &builder{g: g}
+	g.Entry = b.newBlock(EntryBlock, nil)
+	g.Exit = &Block{Kind: ExitBlock}
+	b.current = g.Entry
+	b.stmts(body.List)
+	g.End = b.newBlock(EndBlock, nil)
+	b.jump(g.End)
+	b.current = g.End
+	b.jump(g.Exit)
+	g.Exit.Index = len(g.Blocks)
+	g.Blocks = append(g.Blocks, g.Exit)
+
+	g.Entry.live()
+	return g
+}
+
+func (b *Block) live() {
+	if !b.Live {
+		b.Live = true
+		for _, succ := range b.Succs {
+			succ.live()
+		}
+	}
+}
+
+// Block returns the block in which the statement starts, which is where the statement is reached. It returns nil if the statement is not part of the graph, such as a statement of a nested function.
+func (g *Graph) Block(stmt js.IStmt) *Block {
+	return g.stmts[stmt]
+}
+
+// Reachable returns true if the statement can be reached from the entry block.
+func (g *Graph) Reachable(stmt js.IStmt) bool {
+	if b := g.stmts[stmt]; b != nil {
+		return b.Live
+	}
+	return false
+}
+
+// ReversePostorder returns the live blocks in reverse postorder, where blocks precede their successors except for back edges of loops, as used for forward data-flow analysis.
+func (g *Graph) ReversePostorder() []*Block {
+	visited := make([]bool, len(g.Blocks))
+	order := []*Block{}
+	var visit func(*Block)
+	visit = func(b *Block) {
+		visited[b.Index] = true
+		for _, succ := range b.Succs {
+			if !visited[succ.Index] {
+				visit(succ)
+			}
+		}
+		order = append(order, b)
+	}
+	visit(g.Entry)
+	for i, j := 0, len(order)-1; i < j; i, j = i+1, j-1 {
+		order[i], order[j] = order[j], order[i]
+	}
+	return order
+}
+
+// String returns the blocks on separate lines with their kind, the JavaScript of their nodes, and their successors, where unreachable blocks are marked with an exclamation mark.
+func (g *Graph) String() string {
+	sb := strings.Builder{}
+	for _, b := range g.Blocks {
+		sb.WriteString(b.String())
+		if !b.Live {
+			sb.WriteString("!")
+		}
+		sb.WriteString(" ")
+		sb.WriteString(b.Kind.String())
+		sb.WriteString(":")
+		for i, n := range b.Nodes {
+			if i != 0 {
+				sb.WriteString(";")
+			}
+			sb.WriteString(" ")
+			sb.WriteString(n.JS())
+		}
+		if 0 < len(b.Succs) {
+			sb.WriteString(" ->")
+			for _, succ := range b.Succs {
+				sb.WriteString(" ")
+				sb.WriteString(succ.String())
+			}
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+////////////////////////////////////////////////////////////////
+
+// target is a statement that can be the target of a break or continue statement.
+type target struct {
+	labels        [][]byte
+	breakBlock    *Block
+	continueBlock *Block // nil for statements that are not loops
+	unlabelled    bool   // target of unlabelled break statements, which are loops and switch statements
+	tries         int    // number of enclosing try statements
+}
+
+// tryStmt is an enclosing try statement.
+type tryStmt struct {
+	catch   *Block   // nil if there is no catch clause or when not in the try block
+	finally *finally // nil if there is no finally block or when in the finally block
+	blocks  []*Block // blocks in the try block or catch clause that may throw
+}
+
+// finally is a finally block and the destinations of the jumps that pass through it.
+type finally struct {
+	entry  *Block
+	normal bool   // reached by normal completion of the try block or catch clause
+	exits  []exit // destinations of jumps
+}
+
+type exit struct {
+	target *Block // nil for a throw
+	tries  int
+}
+
+type builder struct {
+	g       *Graph
+	current *Block
+	targets []*target
+	tries   []*tryStmt
+	labels  [][]byte // labels of the current statement
+}
+
+func (b *builder) newBlock(kind BlockKind, stmt js.IStmt) *Block {
+	block := &Block{
+		Index: len(b.g.Blocks),
+		Kind:  kind,
+		Stmt:  stmt,
+	}
+	b.g.Blocks = append(b.g.Blocks, block)
+	if 0 < len(b.tries) && kind != CatchBlock {
+		t := b.tries[len(b.tries)-1]
+		t.blocks = append(t.blocks, block)
+	}
+	return block
+}
+
+func addEdge(from, to *Block) {
+	for _, succ := range from.Succs {
+		if succ == to {
+			return
+		}
+	}
+	from.Succs = append(from.Succs, to)
+	to.Preds = append(to.Preds, from)
+}
+
+func (b *builder) add(n js.INode) {
+	b.current.Nodes = append(b.current.Nodes, n)
+}
+
+// dead returns true if the current block has no predecessors, such as the block after a return statement.
+func (b *builder) dead() bool {
+	return b.current != b.g.Entry && len(b.current.Preds) == 0
+}
+
+// jumpTo adds an edge from the current block to the target, which is a block outside of the enclosing try statements beyond the first tries. Jumps out of a try statement with a finally block go to the finally block instead.
+func (b *builder) jumpTo(to *Block, tries int) {
+	if b.dead() {
+		addEdge(b.current, to)
+		return
+	}
+	for i := len(b.tries) - 1; tries <= i; i-- {
+		if f := b.tries[i].finally; f != nil {
+			addEdge(b.current, f.entry)
+			f.exits = append(f.exits, exit{to, tries})
+			return
+		}
+	}
+	addEdge(b.current, to)
+}
+
+// jump adds an edge to a block within the same try statement.
+func (b *builder) jump(to *Block) {
+	addEdge(b.current, to)
+}
+
+// throw adds an edge to the catch clause or finally block of the innermost try statement.
+func (b *builder) throw() {
+	if b.dead() {
+		return
+	}
+	for i := len(b.tries) - 1; 0 <= i; i-- {
+		if t := b.tries[i]; t.catch != nil {
+			addEdge(b.current, t.catch)
+			return
+		} else if t.finally != nil {
+			addEdge(b.current, t.finally.entry)
+			t.finally.exits = append(t.finally.exits, exit{nil, i})
+			return
+		}
+	}
+}
+
+// unreachable starts a new block after a jump.
+func (b *builder) unreachable(stmt js.IStmt) {
+	b.current = b.newBlock(UnreachableBlock, stmt)
+}
+
+func (b *builder) stmts(list []js.IStmt) {
+	for _, stmt := range list {
+		b.stmt(stmt)
+	}
+}
+
+func (b *builder) stmt(stmt js.IStmt) {
+	b.g.stmts[stmt] = b.current
+	labels := b.labels
+	b.labels = nil
+
+	switch n := stmt.(type) {
+	case *js.BlockStmt:
+		b.stmts(n.List)
+	case *js.EmptyStmt:
+	case *js.ExprStmt:
+		b.value(n.Value, stmt)
+	case *js.IfStmt:
+		then := b.newBlock(IfThenBlock, stmt)
+		done := b.newBlock(IfDoneBlock, stmt)
+		els := done
+		if n.Else != nil {
+			els = b.newBlock(IfElseBlock, stmt)
+		}
+		b.cond(n.Cond, then, els, stmt)
+		b.current = then
+		b.stmt(n.Body)
+		b.jump(done)
+		if n.Else != nil {
+			b.current = els
+			b.stmt(n.Else)
+			b.jump(done)
+		}
+		b.current = done
+	case *js.WhileStmt:
+		head := b.newBlock(LoopHeadBlock, stmt)
+		body := b.newBlock(LoopBodyBlock, stmt)
+		done := b.newBlock(LoopDoneBlock, stmt)
+		b.jump(head)
+		b.current = head
+		b.cond(n.Cond, body, done, stmt)
+		b.loop(labels, n.Body, body, head, done)
+		b.jump(head)
+		b.current = done
+	case *js.DoWhileStmt:
+		body := b.newBlock(LoopBodyBlock, stmt)
+		head := b.newBlock(LoopHeadBlock, stmt)
+		done := b.newBlock(LoopDoneBlock, stmt)
+		b.jump(body)
+		b.loop(labels, n.Body, body, head, done)
+		b.jump(head)
+		b.current = head
+		b.cond(n.Cond, body, done, stmt)
+		b.current = done
+	case *js.ForStmt:
+		if n.Init != nil {
+			b.add(n.Init)
+		}
+		head := b.newBlock(LoopHeadBlock, stmt)
+		body := b.newBlock(LoopBodyBlock, stmt)
+		post := b.newBlock(LoopPostBlock, stmt)
+		done := b.newBlock(LoopDoneBlock, stmt)
+		b.jump(head)
+		b.current = head
+		if n.Cond != nil {
+			b.cond(n.Cond, body, done, stmt)
+		} else {
+			b.jump(body)
+		}
+		b.loop(labels, n.Body, body, post, done)
+		b.jump(post)
+		b.current = post
+		if n.Post != nil {
+			b.add(n.Post)
+		}
+		b.jump(head)
+		b.current = done
+	case *js.ForInStmt:
+		b.forInOf(labels, stmt, n.Init, n.Value, n.Body)
+	case *js.ForOfStmt:
+		b.forInOf(labels, stmt, n.Init, n.Value, n.Body)
+	case *js.SwitchStmt:
+		b.add(n.Init)
+		done := b.newBlock(SwitchDoneBlock, stmt)
+		bodies := make([]*Block, len(n.List))
+		for i := range n.List {
+			bodies[i] = b.newBlock(SwitchBodyBlock, stmt)
+		}
+		next := done
+		for i, clause := range n.List {
+			if clause.Cond == nil {
+				next = bodies[i]
+			}
+		}
+		for i, clause := range n.List {
+			if clause.Cond != nil {
+				test := b.newBlock(SwitchCaseBlock, stmt)
+				b.jump(test)
+				b.current = test
+				b.add(clause.Cond)
+				b.jump(bodies[i])
+			}
+		}
+		b.jump(next) // default clause or no matching case
+
+		b.targets = append(b.targets, &target{labels: labels, breakBlock: done, unlabelled: true, tries: len(b.tries)})
+		for i, clause := range n.List {
+			b.current = bodies[i]
+			b.stmts(clause.List)
+			if i+1 < len(n.List) {
+				b.jump(bodies[i+1]) // fall through
+			} else {
+				b.jump(done)
+			}
+		}
+		b.targets = b.targets[:len(b.targets)-1]
+		b.current = done
+	case *js.BranchStmt:
+		b.add(stmt)
+		if t := b.target(n); t != nil {
+			if n.Type == js.ContinueToken {
+				b.jumpTo(t.continueBlock, t.tries)
+			} else {
+				b.jumpTo(t.breakBlock, t.tries)
+			}
+		}
+		b.unreachable(stmt)
+	case *js.ReturnStmt:
+		b.add(stmt)
+		b.jumpTo(b.g.Exit, 0)
+		b.unreachable(stmt)
+	case *js.ThrowStmt:
+		b.add(stmt)
+		b.throw()
+		b.unreachable(stmt)
+	case *js.WithStmt:
+		b.add(n.Cond)
+		b.stmt(n.Body)
+	case *js.LabelledStmt:
+		switch n.Value.(type) {
+		case *js.WhileStmt, *js.DoWhileStmt, *js.ForStmt, *js.ForInStmt, *js.ForOfStmt, *js.SwitchStmt, *js.LabelledStmt:
+			b.labels = append(labels, n.Label)
+			b.stmt(n.Value)
+		default:
+			done := b.newBlock(LabelDoneBlock, stmt)
+			b.targets = append(b.targets, &target{labels: append(labels, n.Label), breakBlock: done, tries: len(b.tries)})
+			b.stmt(n.Value)
+			b.targets = b.targets[:len(b.targets)-1]
+			b.jump(done)
+			b.current = done
+		}
+	case *js.TryStmt:
+		b.try(n)
+	default:
+		// statements without nested statements, such as declarations and the debugger statement
+		b.add(stmt)
+	}
+}
+
+// loop builds the body of a loop, where continue statements go to cont and break statements go to done.
+func (b *builder) loop(labels [][]byte, stmt js.IStmt, body, cont, done *Block) {
+	b.targets = append(b.targets, &target{labels: labels, breakBlock: done, continueBlock: cont, unlabelled: true, tries: len(b.tries)})
+	b.current = body
+	b.stmt(stmt)
+	b.targets = b.targets[:len(b.targets)-1]
+}
+
+func (b *builder) forInOf(labels [][]byte, stmt js.IStmt, init, value js.IExpr, body *js.BlockStmt) {
+	b.add(value)
+	head := b.newBlock(LoopHeadBlock, stmt)
+	bodyBlock := b.newBlock(LoopBodyBlock, stmt)
+	done := b.newBlock(LoopDoneBlock, stmt)
+	b.jump(head)
+	b.current = head
+	b.jump(bodyBlock)
+	b.jump(done)
+	b.current = bodyBlock
+	b.add(init) // assignment of the next value
+	b.loop(labels, body, b.current, head, done)
+	b.jump(head)
+	b.current = done
+}
+
+// target returns the target of a break or continue statement, or nil if it has none.
+func (b *builder) target(n *js.BranchStmt) *target {
+	for i := len(b.targets) - 1; 0 <= i; i-- {
+		t := b.targets[i]
+		if n.Type == js.ContinueToken && t.continueBlock == nil {
+			continue
+		}
+		if n.Label == nil {
+			if t.unlabelled {
+				return t
+			}
+			continue
+		}
+		for _, label := range t.labels {
+			if string(label) == string(n.Label) {
+				return t
+			}
+		}
+	}
+	return nil
+}
+
+func (b *builder) try(n *js.TryStmt) {
+	t := &tryStmt{}
+	done := b.newBlock(TryDoneBlock, n)
+	if n.Catch != nil {
+		t.catch = b.newBlock(CatchBlock, n)
+	}
+	if n.Finally != nil {
+		t.finally = &finally{entry: b.newBlock(FinallyBlock, n)}
+	}
+
+	// try block
+	b.tries = append(b.tries, t)
+	body := b.newBlock(TryBodyBlock, n)
+	b.jump(body)
+	b.current = body
+	b.stmt(n.Body)
+	b.complete(t, done)
+	b.tries = b.tries[:len(b.tries)-1]
+
+	// edges of exceptions in the try block
+	for _, block := range t.blocks {
+		if len(block.Nodes) == 0 || len(block.Preds) == 0 {
+			continue // cannot throw
+		}
+		b.current = block
+		if t.catch != nil {
+			b.jump(t.catch)
+		} else {
+			b.throwFinally(t)
+		}
+	}
+
+	// catch clause
+	if n.Catch != nil {
+		catch := t.catch
+		t.catch = nil
+		t.blocks = nil
+		b.tries = append(b.tries, t)
+		b.current = catch
+		if n.Binding != nil {
+			b.add(n.Binding)
+		}
+		b.stmt(n.Catch)
+		b.complete(t, done)
+		b.tries = b.tries[:len(b.tries)-1]
+
+		// edges of exceptions in the catch clause
+		for _, block := range append([]*Block{catch}, t.blocks...) {
+			if len(block.Nodes) == 0 || len(block.Preds) == 0 {
+				continue // cannot throw
+			}
+			b.current = block
+			if t.finally != nil {
+				b.throwFinally(t)
+			} else {
+				b.throw()
+			}
+		}
+	}
+
+	// finally block
+	if f := t.finally; f != nil {
+		t.finally = nil
+		b.current = f.entry
+		b.stmt(n.Finally)
+		if f.normal {
+			b.jump(done)
+		}
+		seen := map[exit]bool{}
+		for _, e := range f.exits {
+			if seen[e] {
+				continue
+			}
+			seen[e] = true
+			if e.target == nil {
+				b.throw()
+			} else {
+				b.jumpTo(e.target, e.tries)
+			}
+		}
+	}
+	b.current = done
+}
+
+// complete adds the edge of the normal completion of the try block or catch clause.
+func (b *builder) complete(t *tryStmt, done *Block) {
+	if b.dead() {
+		return
+	} else if t.finally != nil {
+		addEdge(b.current, t.finally.entry)
+		t.finally.normal = true
+	} else {
+		b.jump(done)
+	}
+}
+
+// throwFinally adds an edge of an exception from the current block to the finally block, after which the exception is thrown again.
+func (b *builder) throwFinally(t *tryStmt) {
+	addEdge(b.current, t.finally.entry)
+	t.finally.exits = append(t.finally.exits, exit{nil, len(b.tries)})
+}
+
+// cond builds a condition that continues to then if it is truthy and to els otherwise.
+func (b *builder) cond(e js.IExpr, then, els *Block, stmt js.IStmt) {
+	switch n := e.(type) {
+	case *js.GroupExpr:
+		b.cond(n.X, then, els, stmt)
+		return
+	case *js.UnaryExpr:
+		if n.Op == js.NotToken {
+			b.cond(n.X, els, then, stmt)
+			return
+		}
+	case *js.BinaryExpr:
+		switch n.Op {
+		case js.AndToken:
+			y := b.newBlock(CondBlock, stmt)
+			b.cond(n.X, y, els, stmt)
+			b.current = y
+			b.cond(n.Y, then, els, stmt)
+			return
+		case js.OrToken:
+			y := b.newBlock(CondBlock, stmt)
+			b.cond(n.X, then, y, stmt)
+			b.current = y
+			b.cond(n.Y, then, els, stmt)
+			return
+		}
+	case *js.CondExpr:
+		x := b.newBlock(CondBlock, stmt)
+		y := b.newBlock(CondBlock, stmt)
+		b.cond(n.Cond, x, y, stmt)
+		b.current = x
+		b.cond(n.X, then, els, stmt)
+		b.current = y
+		b.cond(n.Y, then, els, stmt)
+		return
+	}
+
+	b.add(e)
+	if v, ok := js.Eval(e); ok {
+		if v.Truthy() {
+			b.jump(then)
+		} else {
+			b.jump(els)
+		}
+	} else {
+		b.jump(then)
+		b.jump(els)
+	}
+}
+
+// value builds an expression whose value is not used as a condition.
+func (b *builder) value(e js.IExpr, stmt js.IStmt) {
+	switch n := e.(type) {
+	case *js.GroupExpr:
+		b.value(n.X, stmt)
+		return
+	case *js.BinaryExpr:
+		switch n.Op {
+		case js.AndToken, js.OrToken, js.NullishToken:
+			y := b.newBlock(CondBlock, stmt)
+			done := b.newBlock(CondDoneBlock, stmt)
+			if n.Op == js.AndToken {
+				b.cond(n.X, y, done, stmt)
+			} else if n.Op == js.OrToken {
+				b.cond(n.X, done, y, stmt)
+			} else {
+				b.add(n.X)
+				b.jump(done)
+				b.jump(y)
+			}
+			b.current = y
+			b.value(n.Y, stmt)
+			b.jump(done)
+			b.current = done
+			return
+		case js.CommaToken:
+			b.value(n.X, stmt)
+			b.value(n.Y, stmt)
+			return
+		}
+	case *js.CondExpr:
+		x := b.newBlock(CondBlock, stmt)
+		y := b.newBlock(CondBlock, stmt)
+		done := b.newBlock(CondDoneBlock, stmt)
+		b.cond(n.Cond, x, y, stmt)
+		b.current = x
+		b.value(n.X, stmt)
+		b.jump(done)
+		b.current = y
+		b.value(n.Y, stmt)
+		b.jump(done)
+		b.current = done
+		return
+	}
+	b.add(e)
+}
@@ -0,0 +1,116 @@
+package cfg
+
+import (
+	"testing"
+
+	"github.com/tdewolff/parse/v2"
+	"github.com/tdewolff/parse/v2/js"
+	"github.com/tdewolff/test"
+)
+
+func TestGraph(t *testing.T) {
+	var tests = []struct {
+		js       string
+		expected string
+	}{
+		{"", "b0 entry: -> b1\nb1 end: -> b2\nb2 exit:\n"},
+		{"a(); if (b) c(); else d(); e()", "b0 entry: a(); b -> b1 b3\nb1 if.then: c() -> b2\nb2 if.done: e() -> b4\nb3 if.else: d() -> b2\nb4 end: -> b5\nb5 exit:\n"},
+		{"if (true) a(); else b()", "b0 entry: true -> b1\nb1 if.then: a() -> b2\nb2 if.done: -> b4\nb3! if.else: b() -> b2\nb4 end: -> b5\nb5 exit:\n"},
+		{"if (!a || b) c()", "b0 entry: a -> b3 b1\nb1 if.then: c() -> b2\nb2 if.done: -> b4\nb3 cond: b -> b1 b2\nb4 end: -> b5\nb5 exit:\n"},
+		{"if (a ? b : c) d()", "b0 entry: a -> b3 b4\nb1 if.then: d() -> b2\nb2 if.done: -> b5\nb3 cond: b -> b1 b2\nb4 cond: c -> b1 b2\nb5 end: -> b6\nb6 exit:\n"},
+		{"while (a && !b) { if (c) break; d() } e()", "b0 entry: -> b1\nb1 loop.head: a -> b4 b3\nb2 loop.body: c -> b5 b6\nb3 loop.done: e() -> b8\nb4 cond: b -> b3 b2\nb5 if.then: break -> b3\nb6 if.done: d() -> b1\nb7! unreachable: -> b6\nb8 end: -> b9\nb9 exit:\n"},
+		{"do { if (a) continue; b() } while (c)", "b0 entry: -> b1\nb1 loop.body: a -> b4 b5\nb2 loop.head: c -> b1 b3\nb3 loop.done: -> b7\nb4 if.then: continue -> b2\nb5 if.done: b() -> b2\nb6! unreachable: -> b5\nb7 end: -> b8\nb8 exit:\n"},
+		{"for (let i = 0; i < n; i++) { if (a) continue; b() }", "b0 entry: let i = 0 -> b1\nb1 loop.head: i < n -> b2 b4\nb2 loop.body: a -> b5 b6\nb3 loop.post: i++ -> b1\nb4 loop.done: -> b8\nb5 if.then: continue -> b3\nb6 if.done: b() -> b3\nb7! unreachable: -> b6\nb8 end: -> b9\nb9 exit:\n"},
+		{"for (;;) a(); b()", "b0 entry: -> b1\nb1 loop.head: -> b2\nb2 loop.body: a() -> b3\nb3 loop.post: -> b1\nb4! loop.done: b() -> b5\nb5! end: -> b6\nb6! exit:\n"},
+		{"for (const x in y) a(x)", "b0 entry: y -> b1\nb1 loop.head: -> b2 b3\nb2 loop.body: const x; a(x) -> b1\nb3 loop.done: -> b4\nb4 end: -> b5\nb5 exit:\n"},
+		{"L: for (x of y) { for (;;) { continue L } }", "b0 entry: y -> b1\nb1 loop.head: -> b2 b3\nb2 loop.body: x -> b4\nb3 loop.done: -> b9\nb4 loop.head: -> b5\nb5 loop.body: continue L -> b1\nb6! loop.post: -> b4\nb7! loop.done: -> b1\nb8! unreachable: -> b6\nb9 end: -> b10\nb10 exit:\n"},
+		{"L: { a(); break L; b() } c()", "b0 entry: a(); break L -> b1\nb1 label.done: c() -> b3\nb2! unreachable: b() -> b1\nb3 end: -> b4\nb4 exit:\n"},
+		{"switch (x) { case 1: a(); case 2: b(); break; default: c() } d()", "b0 entry: x -> b5\nb1 switch.done: d() -> b8\nb2 switch.body: a() -> b3\nb3 switch.body: b(); break -> b1\nb4 switch.body: c() -> b1\nb5 switch.case: 1 -> b2 b6\nb6 switch.case: 2 -> b3 b4\nb7! unreachable: -> b4\nb8 end: -> b9\nb9 exit:\n"},
+		{"switch (x) { case 1: a() }", "b0 entry: x -> b3\nb1 switch.done: -> b4\nb2 switch.body: a() -> b1\nb3 switch.case: 1 -> b2 b1\nb4 end: -> b5\nb5 exit:\n"},
+		{"try { a() } catch (e) { b() } c()", "b0 entry: -> b3\nb1 try.done: c() -> b4\nb2 catch: e; b() -> b1\nb3 try.body: a() -> b1 b2\nb4 end: -> b5\nb5 exit:\n"},
+		{"try { a(); return } catch (e) { b() } finally { c() } d()", "b0 entry: -> b4\nb1 try.done: d() -> b6\nb2 catch: e; b() -> b3\nb3 finally: c() -> b1 b7\nb4 try.body: a(); return -> b3 b2\nb5! unreachable:\nb6 end: -> b7\nb7 exit:\n"},
+		{"try { return } finally { a() } b()", "b0 entry: -> b3\nb1! try.done: b() -> b5\nb2 finally: a() -> b6\nb3 try.body: return -> b2\nb4! unreachable:\nb5! end: -> b6\nb6 exit:\n"},
+		{"for (;;) { try { break } finally { a() } }", "b0 entry: -> b1\nb1 loop.head: -> b2\nb2 loop.body: -> b7\nb3! loop.post: -> b1\nb4 loop.done: -> b9\nb5! try.done: -> b3\nb6 finally: a() -> b4\nb7 try.body: break -> b6\nb8! unreachable:\nb9 end: -> b10\nb10 exit:\n"},
+		{"try { try { throw a } finally { b() } } catch (e) {}", "b0 entry: -> b3\nb1 try.done: -> b8\nb2 catch: e -> b1\nb3 try.body: -> b6\nb4! try.done:\nb5 finally: b() -> b2\nb6 try.body: throw a -> b5\nb7! unreachable:\nb8 end: -> b9\nb9 exit:\n"},
+		{"throw a; b()", "b0 entry: throw a\nb1! unreachable: b() -> b2\nb2! end: -> b3\nb3! exit:\n"},
+		{"a && b(); x ? y() : z(); c ?? d()", "b0 entry: a -> b1 b2\nb1 cond: b() -> b2\nb2 cond.done: x -> b3 b4\nb3 cond: y() -> b5\nb4 cond: z() -> b5\nb5 cond.done: c -> b7 b6\nb6 cond: d() -> b7\nb7 cond.done: -> b8\nb8 end: -> b9\nb9 exit:\n"},
+		{"a(), b || c()", "b0 entry: a(); b -> b2 b1\nb1 cond: c() -> b2\nb2 cond.done: -> b3\nb3 end: -> b4\nb4 exit:\n"},
+		{"function f() { return 1 }", "b0 entry: function f () { return 1; } -> b1\nb1 end: -> b2\nb2 exit:\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.js, func(t *testing.T) {
+			ast, err := js.Parse(parse.NewInputString(tt.js))
+			if err != nil {
+				t.Fatal(err)
+			}
+			test.String(t, New(ast).String(), tt.expected)
+		})
+	}
+}
+
+func TestGraphFunc(t *testing.T) {
+	var tests = []struct {
+		js  string
+		end bool
+	}{
+		{"function f() {}", true},
+		{"function f() { return 1 }", false},
+		{"function f() { if (a) return 1; else return 2 }", false},
+		{"function f() { if (a) return 1 }", true},
+		{"function f() { while (true) { if (a) return 1 } }", false},
+		{"function f() { while (true) { if (a) break } }", true},
+		{"function f() { switch (a) { case 1: return 1; default: throw b } }", false},
+		{"function f() { switch (a) { case 1: return 1 } }", true},
+		{"function f() { try { return a() } catch (e) { return b } }", false},
+		{"function f() { try { a() } finally { return b } }", false},
+		{"function f() { try { return a() } finally { b() } }", false},
+		{"function f() { try { a() } catch (e) {} finally { b() } }", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.js, func(t *testing.T) {
+			ast, err := js.Parse(parse.NewInputString(tt.js))
+			if err != nil {
+				t.Fatal(err)
+			}
+			g := New(ast.List[0])
+			test.That(t, g.End.Live == tt.end, "end of function must be", map[bool]string{true: "reachable", false: "unreachable"}[tt.end])
+		})
+	}
+
+	ast, err := js.Parse(parse.NewInputString("x => { return; a() }"))
+	test.Error(t, err)
+	arrow := ast.List[0].(*js.ExprStmt).Value.(*js.ArrowFunc)
+	g := New(arrow)
+	test.That(t, g.Reachable(arrow.Body.List[0]))
+	test.That(t, !g.Reachable(arrow.Body.List[1]))
+	test.That(t, !g.Reachable(ast.List[0]), "statement outside of the function")
+	test.That(t, New(arrow.Body.List[0]) == nil)
+}
+
+func TestReversePostorder(t *testing.T) {
+	ast, err := js.Parse(parse.NewInputString("while (a) { if (b) c(); d() } return; e()"))
+	test.Error(t, err)
+	g := New(ast)
+
+	order := g.ReversePostorder()
+	test.That(t, order[0] == g.Entry)
+	index := map[*Block]int{}
+	for i, b := range order {
+		test.That(t, b.Live, "only live blocks")
+		index[b] = i
+	}
+	for _, b := range order {
+		for _, succ := range b.Succs {
+			if b.Kind != LoopBodyBlock && b.Kind != IfDoneBlock {
+				test.That(t, index[b] < index[succ], "block", b, "must precede", succ)
+			}
+		}
+	}
+	test.T(t, len(order), 7)
+}
+
+func TestBlockKind(t *testing.T) {
+	test.T(t, EntryBlock.String(), "entry")
+	test.T(t, CondDoneBlock.String(), "cond.done")
+	test.T(t, BlockKind(100).String(), "Invalid(100)")
+}
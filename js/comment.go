@@ -0,0 +1,122 @@
+package js
+
+import (
+	"bytes"
+)
+
+// Comment is a comment in the input, including its delimiters.
+type Comment struct {
+	Data    []byte
+	OwnLine bool // there is a line terminator (or the start or end of the input) both before and after the comment
+	Span
+}
+
+// IsLine returns true for single-line comments, which must be followed by a line terminator.
+func (c Comment) IsLine() bool {
+	return !bytes.HasPrefix(c.Data, []byte("/*"))
+}
+
+// IsImportant returns true for comments that must be preserved when minifying, such as /*! ... */ or comments containing @license or @preserve.
+func (c Comment) IsImportant() bool {
+	return 2 < len(c.Data) && c.Data[2] == '!' || bytes.Contains(c.Data, []byte("@license")) || bytes.Contains(c.Data, []byte("@preserve"))
+}
+
+// Comments are the comments attached to a node.
+type Comments struct {
+	Leading  []Comment // before the node
+	Trailing []Comment // after the node, either on the same line or at the end of the enclosing block
+	Inner    []Comment // inside the node but not before or after any of its children, such as in an empty block
+}
+
+// CommentMap maps nodes to their attached comments. Comments are attached to statements, expressions including each occurrence of a variable, properties, class members, case clauses, arguments, array elements, and binding elements. A comment is a leading comment of the node that follows it, unless it follows a node on the same line and is followed by a line terminator or the end of the enclosing node, in which case it is a trailing comment of that node.
+type CommentMap map[INode]*Comments
+
+func (m CommentMap) get(n INode) *Comments {
+	c, ok := m[n]
+	if !ok {
+		c = &Comments{}
+		m[n] = c
+	}
+	return c
+}
+
+type commentAttacher struct {
+	src      []byte
+	comments []Comment
+	i        int
+	m        CommentMap
+}
+
+// attachComments attaches each comment to the nearest node as a leading, trailing, or inner comment.
+func attachComments(src []byte, root *BlockStmt, comments []Comment) CommentMap {
+	for i, c := range comments {
+		before := bytes.TrimRight(src[:c.Start], " \t\f\v")
+		after := bytes.TrimLeft(src[c.End:], " \t\f\v")
+		comments[i].OwnLine = (len(before) == 0 || isNewline(before[len(before)-1])) && (len(after) == 0 || isNewline(after[0]))
+	}
+	a := &commentAttacher{
+		src:      src,
+		comments: comments,
+		m:        CommentMap{},
+	}
+	a.attach(root, len(src))
+	return a.m
+}
+
+func isNewline(c byte) bool {
+	return c == '\n' || c == '\r'
+}
+
+func hasNewline(b []byte) bool {
+	return bytes.IndexAny(b, "\n\r  ") != -1
+}
+
+func (a *commentAttacher) attach(parent INode, end int) {
+	var prev INode
+	prevEnd := 0
+	for _, child := range commentable(nil, parent) {
+		start, childEnd := child.Offsets()
+		for a.i < len(a.comments) && a.comments[a.i].End <= start {
+			c := a.comments[a.i]
+			if prev != nil && !hasNewline(a.src[prevEnd:c.Start]) && hasNewline(a.src[c.End:start]) {
+				a.m.get(prev).Trailing = append(a.m.get(prev).Trailing, c)
+			} else {
+				a.m.get(child).Leading = append(a.m.get(child).Leading, c)
+			}
+			a.i++
+		}
+		a.attach(child, childEnd)
+		prev, prevEnd = child, childEnd
+	}
+	for a.i < len(a.comments) && a.comments[a.i].End <= end {
+		if prev != nil && len(bytes.Trim(a.src[prevEnd:a.comments[a.i].Start], " \t\n\r\f\v,;")) == 0 {
+			a.m.get(prev).Trailing = append(a.m.get(prev).Trailing, a.comments[a.i])
+			prevEnd = a.comments[a.i].End
+		} else {
+			a.m.get(parent).Inner = append(a.m.get(parent).Inner, a.comments[a.i])
+		}
+		a.i++
+	}
+}
+
+// commentable appends the nearest descendants of n that can have comments attached.
+func commentable(list []INode, n INode) []INode {
+	for _, child := range Children(n) {
+		if _, end := child.Offsets(); end == 0 {
+			continue
+		}
+		switch child.(type) {
+		case *LiteralExpr:
+			switch n.(type) {
+			case *DotExpr, *PropertyName:
+				continue
+			}
+			list = append(list, child)
+		case *Property, *CaseClause, *FieldDefinition, *MethodDecl, *Arg, *Element, *BindingElement, IStmt, IExpr:
+			list = append(list, child)
+		default:
+			list = commentable(list, child)
+		}
+	}
+	return list
+}
@@ -0,0 +1,758 @@
+package js
+
+import (
+	"bytes"
+)
+
+// DeadCodeOptions are the options for RemoveDeadCode.
+type DeadCodeOptions struct {
+	Module bool // top-level declarations and imports are local to the module and are removed when unused, unless exported
+}
+
+// RemoveDeadCode removes unreachable statements and statements without side effects, and for modules also the unused top-level declarations and import bindings. Calls annotated with /*#__PURE__*/ are considered to have no side effects.
+func RemoveDeadCode(ast *AST, o DeadCodeOptions) {
+	d := &deadCode{
+		DeadCodeOptions: o,
+		top:             &ast.BlockStmt,
+		pure:            pureAnnotations(ast),
+		unused:          map[*BindingElement]bool{},
+	}
+	for d.changed = true; d.changed; {
+		d.changed = false
+		d.analyze(ast)
+		Rewrite(d, ast)
+	}
+}
+
+type deadCode struct {
+	DeadCodeOptions
+	top     *BlockStmt
+	pure    map[int]bool    // start offsets of expressions with a PURE annotation
+	imports map[string]bool // local names of import bindings
+	used    map[string]bool // names of used undeclared variables and imports, and local names of exports
+	unused  map[*BindingElement]bool
+	changed bool
+}
+
+// pureAnnotations returns the start offsets of the nodes that have a leading PURE comment. Comments are attached to the outermost node, such as an ExprStmt or BinaryExpr, so that the annotated call is the call that starts at the same offset. The first comments of the file precede the first statement.
+func pureAnnotations(ast *AST) map[int]bool {
+	pure := map[int]bool{}
+	for n, comments := range ast.CommentMap {
+		for _, c := range comments.Leading {
+			if isPureComment(c.Data) {
+				start, _ := n.Offsets()
+				pure[start] = true
+			}
+		}
+	}
+	if 0 < len(ast.BlockStmt.List) {
+		for _, c := range ast.Comments {
+			if isPureComment(c) {
+				start, _ := ast.BlockStmt.List[0].Offsets()
+				pure[start] = true
+			}
+		}
+	}
+	return pure
+}
+
+func isPureComment(c []byte) bool {
+	return bytes.Contains(c, []byte("#__PURE__")) || bytes.Contains(c, []byte("@__PURE__"))
+}
+
+func (d *deadCode) analyze(ast *AST) {
+	d.imports = map[string]bool{}
+	d.used = map[string]bool{}
+	info := NewScopeInfo(ast)
+	for _, v := range info.Vars() {
+		if _, s := info.Declaration(v); s == nil {
+			d.used[string(v.Data)] = true
+		}
+	}
+	for _, item := range ast.BlockStmt.List {
+		switch stmt := item.(type) {
+		case *ImportStmt:
+			if stmt.Default != nil {
+				d.imports[string(stmt.Default)] = true
+			}
+			for _, alias := range stmt.List {
+				if alias.Binding != nil {
+					d.imports[string(alias.Binding)] = true
+				}
+			}
+		case *ExportStmt:
+			if stmt.Module == nil {
+				for _, alias := range stmt.List {
+					if alias.Name != nil {
+						d.used[string(alias.Name)] = true
+					} else if alias.Binding != nil {
+						d.used[string(alias.Binding)] = true
+					}
+				}
+			}
+		}
+	}
+}
+
+func (d *deadCode) Enter(c *Cursor) IRewriter {
+	switch n := c.Node().(type) {
+	case *BindingElement:
+		if d.unused[n] {
+			delete(d.unused, n)
+			d.remove(c)
+			return nil
+		}
+		return d
+	case IStmt:
+		if list := stmtList(c.Parent()); 0 < c.Index() && c.Index() <= len(list) && isUnreachable(list[:c.Index()]) {
+			return d.removeUnreachable(c, n)
+		} else if d.Module && c.Parent() == d.top && !d.used["eval"] {
+			if !d.removeUnused(c, n) {
+				return nil
+			}
+		}
+	}
+
+	switch n := c.Node().(type) {
+	case *IfStmt:
+		if cond, ok := Eval(n.Cond); ok {
+			if cond.Truthy() {
+				d.removeBranch(c, n.Body, n.Else)
+			} else {
+				d.removeBranch(c, n.Else, n.Body)
+			}
+		}
+	case *WhileStmt:
+		if cond, ok := Eval(n.Cond); ok && !cond.Truthy() {
+			d.removeBranch(c, nil, n.Body)
+		}
+	case *ExprStmt:
+		if d.isPure(n.Value) {
+			d.remove(c)
+			return nil
+		}
+	}
+	return d
+}
+
+func (d *deadCode) Exit(c *Cursor) {}
+
+func (d *deadCode) remove(c *Cursor) {
+	c.Delete()
+	d.changed = true
+}
+
+// removeUnreachable removes a statement that is never executed, but keeps function declarations and the variables declared with var.
+func (d *deadCode) removeUnreachable(c *Cursor, stmt IStmt) IRewriter {
+	switch stmt := stmt.(type) {
+	case *FuncDecl:
+		return d
+	case *ClassDecl:
+		if stmt.Name != nil && 1 < resolveVar(stmt.Name).Uses {
+			return d // used in a hoisted function
+		}
+	case *VarDecl:
+		if stmt.TokenType != VarToken {
+			for _, v := range bindingVars(nil, stmtBindings(stmt)...) {
+				if 1 < resolveVar(v).Uses {
+					return d // used in a hoisted function
+				}
+			}
+		} else if isHoistedDecl(stmt) {
+			return nil
+		}
+	}
+
+	vars, ok := hoistedVars(nil, stmt)
+	if !ok {
+		return d
+	} else if len(vars) == 0 {
+		d.remove(c)
+	} else {
+		c.Replace(hoistedDecl(vars))
+		d.changed = true
+	}
+	return nil
+}
+
+// removeBranch replaces an if or while statement by the branch that is executed, which may be nil. The variables declared with var in the removed branch are declared before the statement, which is only possible for statements in a list.
+func (d *deadCode) removeBranch(c *Cursor, live, dead IStmt) {
+	if _, ok := live.(*FuncDecl); ok {
+		return // function declarations in if statements are only allowed in sloppy mode
+	}
+	var vars []*Var
+	if dead != nil {
+		var ok bool
+		if vars, ok = hoistedVars(nil, dead); !ok || 0 < len(vars) && c.Index() < 0 {
+			return
+		}
+	}
+	if 0 < len(vars) {
+		c.InsertBefore(hoistedDecl(vars))
+	}
+	if live != nil {
+		c.Replace(live)
+	} else {
+		c.Delete()
+	}
+	d.changed = true
+}
+
+// removeUnused removes the unused top-level declarations without side effects of a module, and returns false if the statement was removed.
+func (d *deadCode) removeUnused(c *Cursor, stmt IStmt) bool {
+	switch stmt := stmt.(type) {
+	case *VarDecl:
+		unused := []*BindingElement{}
+		for i, item := range stmt.List {
+			if v, ok := item.Binding.(*Var); ok && d.isUnused(v) && d.isPure(item.Default) {
+				unused = append(unused, &stmt.List[i])
+			}
+		}
+		if len(unused) == len(stmt.List) {
+			d.remove(c)
+			return false
+		}
+		for _, item := range unused {
+			d.unused[item] = true
+		}
+	case *FuncDecl:
+		if stmt.Name != nil && d.isUnused(stmt.Name) {
+			d.remove(c)
+			return false
+		}
+	case *ClassDecl:
+		if stmt.Name != nil && d.isUnused(stmt.Name) && d.isPureClass(stmt) {
+			d.remove(c)
+			return false
+		}
+	case *ImportStmt:
+		importStmt := &ImportStmt{Module: stmt.Module, Span: stmt.Span}
+		if stmt.Default != nil && d.used[string(stmt.Default)] {
+			importStmt.Default = stmt.Default
+		}
+		n := 0
+		for _, alias := range stmt.List {
+			if alias.Binding != nil {
+				n++
+				if d.used[string(alias.Binding)] {
+					importStmt.List = append(importStmt.List, alias)
+				}
+			}
+		}
+		if (importStmt.Default == nil) != (stmt.Default == nil) || len(importStmt.List) != n {
+			c.Replace(importStmt)
+			d.changed = true
+		}
+	}
+	return true
+}
+
+// isUnused returns true if the variable is only used by its declaration.
+func (d *deadCode) isUnused(v *Var) bool {
+	v = resolveVar(v)
+	return v.Uses <= 1 && !d.used[string(v.Data)]
+}
+
+// isPure returns true if evaluating the expression has no side effects, including throwing an error.
+func (d *deadCode) isPure(e IExpr) bool {
+	switch e := e.(type) {
+	case nil, *LiteralExpr, *FuncDecl, *ArrowFunc:
+		return true
+	case *Var:
+		v := resolveVar(e)
+		if v.Decl != NoDecl {
+			return true
+		}
+		switch string(v.Data) {
+		case "undefined", "NaN", "Infinity":
+			return true
+		}
+		return d.imports[string(v.Data)]
+	case *ClassDecl:
+		return d.isPureClass(e)
+	case *GroupExpr:
+		return d.isPure(e.X)
+	case *ArrayExpr:
+		for _, item := range e.List {
+			if item.Spread || !d.isPure(item.Value) {
+				return false
+			}
+		}
+		return true
+	case *ObjectExpr:
+		for _, item := range e.List {
+			if item.Spread || item.Name != nil && !isPureKey(*item.Name) || !d.isPure(item.Value) || !d.isPure(item.Init) {
+				return false
+			}
+		}
+		return true
+	case *TemplateExpr:
+		if e.Tag == nil {
+			for _, item := range e.List {
+				if _, ok := Eval(item.Expr); !ok {
+					return false
+				}
+			}
+			return true
+		}
+	case *UnaryExpr:
+		switch e.Op {
+		case TypeofToken:
+			if _, ok := e.X.(*Var); ok {
+				return true
+			}
+			return d.isPure(e.X)
+		case NotToken, VoidToken:
+			return d.isPure(e.X)
+		}
+		_, ok := Eval(e)
+		return ok
+	case *BinaryExpr:
+		switch e.Op {
+		case AndToken, OrToken, NullishToken, CommaToken, EqEqEqToken, NotEqEqToken:
+			return d.isPure(e.X) && d.isPure(e.Y)
+		}
+		_, ok := Eval(e)
+		return ok
+	case *CondExpr:
+		return d.isPure(e.Cond) && d.isPure(e.X) && d.isPure(e.Y)
+	case *CallExpr:
+		start, _ := e.Offsets()
+		return d.pure[start] && d.isPureCallee(e.X) && d.isPureArgs(e.Args)
+	case *NewExpr:
+		start, _ := e.Offsets()
+		return d.pure[start] && d.isPureCallee(e.X) && (e.Args == nil || d.isPureArgs(*e.Args))
+	}
+	return false
+}
+
+// isPureCallee returns true for the callee of a call with a PURE annotation that has no side effects, where variables and property accesses are assumed to be without side effects.
+func (d *deadCode) isPureCallee(e IExpr) bool {
+	switch e := e.(type) {
+	case *Var:
+		return true
+	case *DotExpr:
+		return d.isPureCallee(e.X)
+	}
+	return d.isPure(e)
+}
+
+func (d *deadCode) isPureArgs(args Args) bool {
+	for _, arg := range args.List {
+		if arg.Rest || !d.isPure(arg.Value) {
+			return false
+		}
+	}
+	return true
+}
+
+// isPureClass returns true if the class definition has no side effects. All field initializers must be without side effects, and the class cannot have decorators or static blocks, which run when the class is defined.
+func (d *deadCode) isPureClass(n *ClassDecl) bool {
+	if len(n.Decorators) != 0 || !d.isPure(n.Extends) {
+		return false
+	}
+	for _, def := range n.Definitions {
+		if len(def.Decorators) != 0 || def.Body != nil || !isPureKey(def.Name) || !d.isPure(def.Init) {
+			return false
+		}
+	}
+	for _, method := range n.Methods {
+		if len(method.Decorators) != 0 || !isPureKey(method.Name) {
+			return false
+		}
+	}
+	return true
+}
+
+// isPureKey returns true if the property name is not computed or is computed from a constant, as converting other values to a property key may call toString.
+func isPureKey(name PropertyName) bool {
+	if !name.IsComputed() {
+		return true
+	}
+	_, ok := Eval(name.Computed)
+	return ok
+}
+
+// stmtList returns the statement list that contains the children of the node.
+func stmtList(n INode) []IStmt {
+	switch n := n.(type) {
+	case *BlockStmt:
+		return n.List
+	case *CaseClause:
+		return n.List
+	}
+	return nil
+}
+
+// isUnreachable returns true if the statements after the list are never executed.
+func isUnreachable(list []IStmt) bool {
+	for _, stmt := range list {
+		switch stmt.(type) {
+		case *ReturnStmt, *ThrowStmt, *BranchStmt:
+			return true
+		}
+	}
+	return false
+}
+
+// hoistedVars appends the variables declared with var in the statement, excluding those of nested functions. It returns false if the statement contains a function declaration, which may be hoisted as well.
+func hoistedVars(vars []*Var, stmt IStmt) ([]*Var, bool) {
+	ok := true
+	switch stmt := stmt.(type) {
+	case *FuncDecl:
+		return vars, false
+	case *VarDecl:
+		if stmt.TokenType == VarToken {
+			vars = bindingVars(vars, stmtBindings(stmt)...)
+		}
+	case *BlockStmt:
+		for _, item := range stmt.List {
+			if vars, ok = hoistedVars(vars, item); !ok {
+				break
+			}
+		}
+	case *IfStmt:
+		if vars, ok = hoistedVars(vars, stmt.Body); ok && stmt.Else != nil {
+			vars, ok = hoistedVars(vars, stmt.Else)
+		}
+	case *DoWhileStmt:
+		vars, ok = hoistedVars(vars, stmt.Body)
+	case *WhileStmt:
+		vars, ok = hoistedVars(vars, stmt.Body)
+	case *WithStmt:
+		vars, ok = hoistedVars(vars, stmt.Body)
+	case *LabelledStmt:
+		vars, ok = hoistedVars(vars, stmt.Value)
+	case *ForStmt:
+		if varDecl, isVarDecl := stmt.Init.(*VarDecl); isVarDecl {
+			vars, _ = hoistedVars(vars, varDecl)
+		}
+		vars, ok = hoistedVars(vars, stmt.Body)
+	case *ForInStmt:
+		if varDecl, isVarDecl := stmt.Init.(*VarDecl); isVarDecl {
+			vars, _ = hoistedVars(vars, varDecl)
+		}
+		vars, ok = hoistedVars(vars, stmt.Body)
+	case *ForOfStmt:
+		if varDecl, isVarDecl := stmt.Init.(*VarDecl); isVarDecl {
+			vars, _ = hoistedVars(vars, varDecl)
+		}
+		vars, ok = hoistedVars(vars, stmt.Body)
+	case *SwitchStmt:
+		for _, clause := range stmt.List {
+			for _, item := range clause.List {
+				if vars, ok = hoistedVars(vars, item); !ok {
+					return vars, false
+				}
+			}
+		}
+	case *TryStmt:
+		for _, block := range []*BlockStmt{stmt.Body, stmt.Catch, stmt.Finally} {
+			if block != nil {
+				if vars, ok = hoistedVars(vars, block); !ok {
+					break
+				}
+			}
+		}
+	}
+	return vars, ok
+}
+
+// isHoistedDecl returns true if the declaration only declares variables, as returned by hoistedDecl.
+func isHoistedDecl(varDecl *VarDecl) bool {
+	for _, item := range varDecl.List {
+		if _, ok := item.Binding.(*Var); !ok || item.Default != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// hoistedDecl returns a var declaration of the variables without initializers.
+func hoistedDecl(vars []*Var) *VarDecl {
+	varDecl := &VarDecl{TokenType: VarToken}
+	seen := map[*Var]bool{}
+	for _, v := range vars {
+		if !seen[resolveVar(v)] {
+			seen[resolveVar(v)] = true
+			varDecl.List = append(varDecl.List, BindingElement{Binding: v})
+		}
+	}
+	return varDecl
+}
+
+func stmtBindings(varDecl *VarDecl) []IBinding {
+	bindings := make([]IBinding, 0, len(varDecl.List))
+	for _, item := range varDecl.List {
+		bindings = append(bindings, item.Binding)
+	}
+	return bindings
+}
+
+// bindingVars appends the variables that are bound by the bindings.
+func bindingVars(vars []*Var, bindings ...IBinding) []*Var {
+	for _, binding := range bindings {
+		switch binding := binding.(type) {
+		case *Var:
+			vars = append(vars, binding)
+		case *BindingArray:
+			for _, item := range binding.List {
+				vars = bindingVars(vars, item.Binding)
+			}
+			vars = bindingVars(vars, binding.Rest)
+		case *BindingObject:
+			for _, item := range binding.List {
+				vars = bindingVars(vars, item.Value.Binding)
+			}
+			if binding.Rest != nil {
+				vars = append(vars, binding.Rest)
+			}
+		}
+	}
+	return vars
+}
+
+// TreeShake removes the exports that no module of the graph imports, except those of the entry modules, and then removes dead code as in RemoveDeadCode. The dependencies and exports of the graph are not updated.
+func TreeShake(g *ModuleGraph) {
+	modules := []*Module{}
+	for _, m := range g.Modules {
+		if !m.External {
+			modules = append(modules, m)
+		}
+	}
+	for {
+		for _, m := range modules {
+			RemoveDeadCode(m.AST, DeadCodeOptions{Module: true})
+		}
+
+		all := map[*Module]bool{} // modules whose exports are all used
+		for _, entry := range g.Entries {
+			all[entry] = true
+		}
+		used := map[*Module]map[string]bool{}
+		for _, m := range modules {
+			used[m] = map[string]bool{}
+		}
+		for _, m := range modules {
+			if !usedImports(m, used, all) {
+				return // dynamic import of an unknown module
+			}
+		}
+		for changed := true; changed; {
+			changed = false
+			for _, m := range modules {
+				if usedReexports(m, all[m], used[m], used, all) {
+					changed = true
+				}
+			}
+		}
+
+		changed := false
+		for _, m := range modules {
+			if !all[m] {
+				r := &exportRemover{top: &m.AST.BlockStmt, used: used[m]}
+				Rewrite(r, m.AST)
+				changed = changed || r.changed
+			}
+		}
+		if !changed {
+			return
+		}
+	}
+}
+
+// moduleName returns the module specifier of an import or export statement.
+func moduleName(b []byte) string {
+	name, err := DecodeString(b)
+	if err != nil {
+		return string(b)
+	}
+	return string(name)
+}
+
+// resolvedModules returns the modules of the dependencies of a module by their specifier.
+func resolvedModules(m *Module) map[string]*Module {
+	modules := map[string]*Module{}
+	for _, dep := range m.Deps {
+		if dep.Module != nil {
+			modules[dep.Specifier] = dep.Module
+		}
+	}
+	return modules
+}
+
+// usedImports records the imported names of the modules that are imported by the module, and returns false if a module is imported dynamically with a specifier that is not a string literal.
+func usedImports(m *Module, used map[*Module]map[string]bool, all map[*Module]bool) bool {
+	modules := resolvedModules(m)
+	add := func(module *Module, name string) {
+		if used[module] == nil {
+			used[module] = map[string]bool{}
+		}
+		used[module][name] = true
+	}
+	for _, item := range m.AST.BlockStmt.List {
+		switch stmt := item.(type) {
+		case *ImportStmt:
+			module := modules[moduleName(stmt.Module)]
+			if stmt.Default != nil {
+				add(module, "default")
+			}
+			for _, alias := range stmt.List {
+				if alias.Binding == nil {
+					continue
+				} else if isStar(alias.Name) {
+					all[module] = true
+				} else if alias.Name != nil {
+					add(module, string(alias.Name))
+				} else {
+					add(module, string(alias.Binding))
+				}
+			}
+		case *ExportStmt:
+			if stmt.Module != nil && len(stmt.List) == 1 && isStar(stmt.List[0].Binding) {
+				all[modules[moduleName(stmt.Module)]] = true // export * from
+			}
+		}
+	}
+
+	v := &dynamicImportVisitor{modules: modules, all: all, ok: true}
+	Walk(v, m.AST)
+	return v.ok
+}
+
+func isStar(b []byte) bool {
+	return len(b) == 1 && b[0] == '*'
+}
+
+type dynamicImportVisitor struct {
+	modules map[string]*Module
+	all     map[*Module]bool
+	ok      bool
+}
+
+func (v *dynamicImportVisitor) Enter(n INode) IVisitor {
+	if call, ok := n.(*CallExpr); ok {
+		if lit, ok := call.X.(*LiteralExpr); ok && lit.TokenType == ImportToken {
+			if len(call.Args.List) == 0 {
+				v.ok = false
+			} else if specifier, ok := stringLiteral(call.Args.List[0].Value); !ok {
+				v.ok = false
+			} else {
+				v.all[v.modules[specifier]] = true
+			}
+		}
+	}
+	return v
+}
+
+func (v *dynamicImportVisitor) Exit(n INode) {}
+
+// usedReexports records the names of the modules that are re-exported by the module and that are used by importers, and returns true if new names were recorded.
+func usedReexports(m *Module, all bool, exports map[string]bool, used map[*Module]map[string]bool, allUsed map[*Module]bool) bool {
+	modules := resolvedModules(m)
+	changed := false
+	for _, item := range m.AST.BlockStmt.List {
+		stmt, ok := item.(*ExportStmt)
+		if !ok || stmt.Module == nil {
+			continue
+		}
+		module := modules[moduleName(stmt.Module)]
+		for _, alias := range stmt.List {
+			if alias.Binding == nil || isStar(alias.Binding) || !all && !exports[string(alias.Binding)] {
+				continue // export * from is handled by usedImports
+			} else if isStar(alias.Name) {
+				if !allUsed[module] {
+					allUsed[module] = true
+					changed = true
+				}
+			} else {
+				name := alias.Binding
+				if alias.Name != nil {
+					name = alias.Name
+				}
+				if used[module] == nil {
+					used[module] = map[string]bool{}
+				}
+				if !used[module][string(name)] {
+					used[module][string(name)] = true
+					changed = true
+				}
+			}
+		}
+	}
+	return changed
+}
+
+// exportRemover removes the top-level exports whose exported names are not used.
+type exportRemover struct {
+	top     *BlockStmt
+	used    map[string]bool
+	changed bool
+}
+
+func (r *exportRemover) Enter(c *Cursor) IRewriter {
+	if c.Node() == r.top {
+		return r
+	} else if _, ok := c.Node().(*AST); ok {
+		return r
+	}
+
+	stmt, ok := c.Node().(*ExportStmt)
+	if !ok {
+		return nil
+	}
+	if stmt.Decl == nil {
+		list := []Alias{}
+		for _, alias := range stmt.List {
+			if alias.Binding == nil {
+				continue
+			} else if isStar(alias.Binding) || r.used[string(alias.Binding)] {
+				list = append(list, alias)
+			}
+		}
+		if len(list) == 0 && stmt.Module != nil {
+			c.Replace(&ImportStmt{Module: stmt.Module, Span: stmt.Span}) // keep side effects of the module
+		} else if len(list) == 0 {
+			c.Delete()
+		} else if len(list) < len(stmt.List) {
+			c.Replace(&ExportStmt{List: list, Module: stmt.Module, Span: stmt.Span})
+		} else {
+			return nil
+		}
+	} else if stmt.Default {
+		if r.used["default"] {
+			return nil
+		}
+		if funcDecl, ok := stmt.Decl.(*FuncDecl); ok && funcDecl.Name != nil {
+			c.Replace(funcDecl)
+		} else if classDecl, ok := stmt.Decl.(*ClassDecl); ok && classDecl.Name != nil {
+			c.Replace(classDecl)
+		} else if _, ok := stmt.Decl.(*GroupExpr); ok {
+			c.Replace(&ExprStmt{Value: stmt.Decl})
+		} else {
+			c.Replace(&ExprStmt{Value: &GroupExpr{X: stmt.Decl}}) // expression statements cannot start with function, class, or {
+		}
+	} else {
+		var vars []*Var
+		switch decl := stmt.Decl.(type) {
+		case *VarDecl:
+			vars = bindingVars(nil, stmtBindings(decl)...)
+		case *FuncDecl:
+			vars = append(vars, decl.Name)
+		case *ClassDecl:
+			vars = append(vars, decl.Name)
+		default:
+			return nil
+		}
+		for _, v := range vars {
+			if r.used[string(v.Data)] {
+				return nil
+			}
+		}
+		c.Replace(stmt.Decl.(IStmt))
+	}
+	r.changed = true
+	return nil
+}
+
+func (r *exportRemover) Exit(c *Cursor) {}
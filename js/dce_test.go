@@ -0,0 +1,153 @@
+package js
+
+import (
+	"testing"
+
+	"github.com/tdewolff/parse/v2"
+	"github.com/tdewolff/test"
+)
+
+func TestRemoveDeadCode(t *testing.T) {
+	var tests = []struct {
+		js       string
+		module   bool
+		expected string
+	}{
+		{"function f() { return 1; a(); b() }", false, "function f () { return 1; }; "},
+		{"function f() { throw a; var b = 1, [c] = d; let e = 2 }", false, "function f () { throw a; var b, c; }; "},
+		{"function f() { return g(); function g() {} }", false, "function f () { return g(); function g () { }; }; "},
+		{"function f() { return; { var a = 1 } }", false, "function f () { return; var a; }; "},
+		{"while (a) { break; b() }", false, "while (a) { break; }; "},
+		{"switch (a) { case 1: b(); break; c() }", false, "switch (a) { case 1: b(); break; }; "},
+		{"if (false) a(); else b()", false, "b(); "},
+		{"if (!0) a(); else b()", false, "a(); "},
+		{"if ('production' !== 'production') { a() }", false, ""},
+		{"if (false) { var a = 1 }", false, "var a; "},
+		{"if (false) { function f() {} }", false, "if (false) { function f () { }; }; "},
+		{"while (0) a()", false, ""},
+		{"if (a) b()", false, "if (a) { b() }; "},
+		{"1; a; 'b' + 1; (function() {}); /*#__PURE__*/ f(1); /* @__PURE__ */ new A(); x()", false, "a; x(); "},
+		{"/*#__PURE__*/ f(a()); f(); b.c; undeclared", false, "f(a()); f(); b.c; undeclared; "},
+		{"/*#__PURE__*/ new F(); /*#__PURE__*/ f(); g()", false, "g(); "},
+		{"/* a */ /*@__PURE__*/ f(); var b = /*#__PURE__*/ g(b())", false, "var b = g(b()); "},
+		{"var a = 1; function f() {}", false, "var a = 1; function f () { }; "},
+		{"var a = 1, b = c(), d = 2; function f() {} class A {} a; export { d }", true, "var b = c(), d = 2; export { d }; "},
+		{"var a = /*#__PURE__*/ f(), b = a; let c = [1, {d: 2}]; const e = () => c", true, ""},
+		{"function f() { return g() } function g() {} export default f", true, "function f () { return g(); }; function g () { }; export default f; "},
+		{"class A extends B {} class C { [d()] = 1 } class D { e = f() }", true, "class A extends B { }; class C { [d()] = 1; }; class D { e = f(); }; "},
+		{"class A { static {} } @b class C {} class D { @e f } class G { static h = 1 }", true, "class A { static { }; }; @b class C { }; class D { @e f; }; "},
+		{"import a, { b, c as d } from 'x'; import * as e from 'y'; import f from 'z'; a(d, f)", true, "import a , { c as d } from 'x'; import 'y'; import f from 'z'; a(d, f); "},
+		{"import { a } from 'x'; var b = a; export { b }", true, "import { a } from 'x'; var b = a; export { b }; "},
+		{"var a = 1; eval('a')", true, "var a = 1; eval('a'); "},
+		{"var a = 1, b = 2; export function f() { { return () => a } }", true, "var a = 1; export function f () { { return () => { return a; }; }; }; "},
+		{"function f() { return g(); function g() { return a } let a = 1 }", false, "function f () { return g(); function g () { return a; }; let a = 1; }; "},
+	}
+	for _, tt := range tests {
+		t.Run(tt.js, func(t *testing.T) {
+			o := ParseOptions{}
+			if tt.module {
+				o.SourceType = ModuleSource
+			}
+			ast, err := ParseWithOptions(parse.NewInputString(tt.js), o)
+			if err != nil {
+				t.Fatal(err)
+			}
+			RemoveDeadCode(ast, DeadCodeOptions{Module: tt.module})
+			test.String(t, ast.JS(), tt.expected)
+		})
+	}
+}
+
+func TestTreeShake(t *testing.T) {
+	var tests = []struct {
+		files    MemoryFiles
+		expected map[string]string
+	}{
+		{
+			MemoryFiles{
+				"main.js": "import { a } from './lib'; a()",
+				"lib.js":  "export function a() { return b() } export function b() {} export var c = 1; export default function() {} function d() {} export { d }",
+			},
+			map[string]string{
+				"main.js": "import { a } from './lib'; a(); ",
+				"lib.js":  "export function a () { return b(); }; function b () { }; ",
+			},
+		},
+		{
+			MemoryFiles{
+				"main.js":  "import { a as x, b } from './lib'; x(b)",
+				"lib.js":   "export { a, c } from './util'; export { b } from './other'; export * from './star'",
+				"util.js":  "export const a = 1, c = 2",
+				"other.js": "export let b = 1, d = 2",
+				"star.js":  "export const e = f()",
+			},
+			map[string]string{
+				"main.js":  "import { a as x , b } from './lib'; x(b); ",
+				"lib.js":   "export { a } from './util'; export { b } from './other'; export * from './star'; ",
+				"util.js":  "export const a = 1, c = 2; ",
+				"other.js": "export let b = 1, d = 2; ",
+				"star.js":  "export const e = f(); ",
+			},
+		},
+		{
+			MemoryFiles{
+				"main.js":  "import * as ns from './lib'; import './side'; import './other'; import('./dyn'); ns.a()",
+				"lib.js":   "export const a = 1, b = 2",
+				"side.js":  "export const c = 1; d()",
+				"dyn.js":   "export const e = 1",
+				"other.js": "import { f } from './side'",
+			},
+			map[string]string{
+				"main.js":  "import * as ns from './lib'; import './side'; import './other'; import('./dyn'); ns.a(); ",
+				"lib.js":   "export const a = 1, b = 2; ",
+				"side.js":  "d(); ",
+				"dyn.js":   "export const e = 1; ",
+				"other.js": "import './side'; ",
+			},
+		},
+		{
+			MemoryFiles{
+				"main.js": "export { default } from './lib'",
+				"lib.js":  "export default a(); export const b = 1",
+			},
+			map[string]string{
+				"main.js": "export { default } from './lib'; ",
+				"lib.js":  "export default a(); ",
+			},
+		},
+		{
+			MemoryFiles{
+				"main.js": "import { b } from './lib'; b()",
+				"lib.js":  "export default (a(), 1); export const b = 1; export { b as c }",
+			},
+			map[string]string{
+				"main.js": "import { b } from './lib'; b(); ",
+				"lib.js":  "(a() , 1); export const b = 1; ",
+			},
+		},
+		{
+			MemoryFiles{
+				"main.js":  "import { a } from './lib'; import './sub/x'; a()",
+				"sub/x.js": "import { b } from '../lib.js'; b()",
+				"lib.js":   "export const a = 1; export const b = 2; export const c = 3",
+			},
+			map[string]string{
+				"main.js":  "import { a } from './lib'; import './sub/x'; a(); ",
+				"sub/x.js": "import { b } from '../lib.js'; b(); ",
+				"lib.js":   "export const a = 1; export const b = 2; ",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.files["main.js"], func(t *testing.T) {
+			g, err := NewModuleGraph(tt.files, "./main.js")
+			if err != nil {
+				t.Fatal(err)
+			}
+			TreeShake(g)
+			for path, expected := range tt.expected {
+				test.String(t, g.Module(path).AST.JS(), expected, path)
+			}
+		})
+	}
+}
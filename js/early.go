@@ -0,0 +1,430 @@
+package js
+
+import (
+	"bytes"
+)
+
+// Early errors are syntax errors that the specification requires to be reported before evaluation, but that the grammar does not exclude. These are mostly restrictions of strict mode code, which is code in a class or in a function or script with a "use strict" directive.
+
+// stmtLabel is a label of a labelled statement.
+type stmtLabel struct {
+	name []byte
+	loop bool // labels an iteration statement
+}
+
+// funcContext holds the expressions that are allowed by the enclosing function, method, class field, or class static block, which arrow functions inherit.
+type funcContext struct {
+	newTarget bool // new.target, in all functions
+	superProp bool // super properties, in methods, class fields, and class static blocks
+	superCall bool // super calls, in constructors of derived classes
+}
+
+// checkSuper fails for a super call outside of constructors of derived classes, and for a super property outside of methods.
+func (p *Parser) checkSuper(call bool, offset int) bool {
+	if call && !p.fn.superCall {
+		p.failAt(offset, "super call not allowed outside of a derived class constructor")
+		return false
+	} else if !call && !p.fn.superProp {
+		p.failAt(offset, "super property not allowed outside of a method")
+		return false
+	}
+	return true
+}
+
+// classBody holds the constructor and the private names that are declared and referenced in a class body. References are checked at the end of the class body, as private names may be used before they are declared.
+type classBody struct {
+	parent      *classBody
+	derived     bool // class has an extends clause
+	constructor bool
+	names       map[string]privateKind
+	refs        []privateRef
+}
+
+// privateKind is the kind of a private class member, where a getter and a setter may share a private name.
+type privateKind uint8
+
+// privateKind values.
+const (
+	privateGetter privateKind = 1 << iota
+	privateSetter
+	privateStatic
+)
+
+// privateRef is a reference to a private name at the given offset.
+type privateRef struct {
+	name   []byte
+	offset int
+}
+
+// declareConstructor fails for a duplicate constructor in a class body.
+func (p *Parser) declareConstructor(offset int) bool {
+	if p.class.constructor {
+		p.failAt(offset, "duplicate constructor in class body")
+		return false
+	}
+	p.class.constructor = true
+	return true
+}
+
+// declarePrivateName fails for duplicate private names in a class body, except for a getter and a setter that are both static or both non-static.
+func (p *Parser) declarePrivateName(name []byte, offset int, kind privateKind) bool {
+	if prev, ok := p.class.names[string(name)]; ok {
+		accessors := (prev|kind)&^privateStatic == privateGetter|privateSetter
+		if !accessors || prev&kind&(privateGetter|privateSetter) != 0 || prev&privateStatic != kind&privateStatic {
+			p.failAt(offset, "private name %s has already been declared", string(name))
+			return false
+		}
+		kind |= prev
+	}
+	p.class.names[string(name)] = kind
+	return true
+}
+
+// usePrivateName adds a reference to a private name, which fails outside of a class body.
+func (p *Parser) usePrivateName(name []byte, offset int) bool {
+	if p.class == nil {
+		p.failAt(offset, "private name %s not allowed outside of a class body", string(name))
+		return false
+	}
+	p.class.refs = append(p.class.refs, privateRef{name, offset})
+	return true
+}
+
+// exitClassBody fails for references to private names that are not declared by the class body or an enclosing class body.
+func (p *Parser) exitClassBody() {
+	class := p.class
+	p.class = class.parent
+	for _, ref := range class.refs {
+		if _, ok := class.names[string(ref.name)]; ok {
+			continue
+		} else if class.parent == nil {
+			p.failAt(ref.offset, "private name %s is not declared in an enclosing class", string(ref.name))
+			return
+		}
+		class.parent.refs = append(class.parent.refs, ref)
+	}
+}
+
+// checkImportBindings fails for bindings of the import statement that have already been imported by the module.
+func (p *Parser) checkImportBindings(importStmt *ImportStmt, bindings map[string]bool) bool {
+	check := func(binding []byte, offset int) bool {
+		if bindings[string(binding)] {
+			p.failAt(offset, "identifier %s has already been declared", string(binding))
+			return false
+		}
+		bindings[string(binding)] = true
+		return true
+	}
+	if importStmt.Default != nil && !check(importStmt.Default, importStmt.Start) {
+		return false
+	}
+	for _, alias := range importStmt.List {
+		if alias.Binding != nil && !check(alias.Binding, alias.Start) {
+			return false
+		}
+	}
+	return true
+}
+
+// checkExportNames fails for names of the export statement that have already been exported by the module.
+func (p *Parser) checkExportNames(exportStmt *ExportStmt, names map[string]bool) bool {
+	check := func(name []byte, offset int) bool {
+		if names[string(name)] {
+			p.failAt(offset, "duplicate export %s", string(name))
+			return false
+		}
+		names[string(name)] = true
+		return true
+	}
+	if exportStmt.Default {
+		return check([]byte("default"), exportStmt.Start)
+	}
+	for _, alias := range exportStmt.List {
+		if alias.Binding != nil && (alias.Name != nil || !bytes.Equal(alias.Binding, []byte("*"))) && !check(alias.Binding, alias.Start) {
+			return false
+		}
+	}
+	var vars []*Var
+	switch decl := exportStmt.Decl.(type) {
+	case *VarDecl:
+		for _, item := range decl.List {
+			vars = bindingVars(vars, item.Binding)
+		}
+	case *FuncDecl:
+		vars = append(vars, decl.Name)
+	case *ClassDecl:
+		vars = append(vars, decl.Name)
+	}
+	for _, v := range vars {
+		if v != nil && !check(v.Data, v.Start) {
+			return false
+		}
+	}
+	return true
+}
+
+// isUseStrict returns true if the directive is a "use strict" directive, which may not contain escape sequences or line continuations.
+func isUseStrict(directive []byte) bool {
+	return len(directive) == 12 && string(directive[1:11]) == "use strict"
+}
+
+// hasUseStrict returns true if the directive prologue of the statements contains a "use strict" directive.
+func hasUseStrict(list []IStmt) bool {
+	for _, item := range list {
+		directive, ok := item.(*DirectivePrologueStmt)
+		if !ok {
+			break
+		} else if isUseStrict(directive.Value) {
+			return true
+		}
+	}
+	return false
+}
+
+// octalEscape returns the offset of the first octal escape sequence, \8, or \9 in a string literal, or -1 otherwise.
+func octalEscape(data []byte) int {
+	for i := 0; i+1 < len(data); i++ {
+		if data[i] == '\\' {
+			i++
+			if c := data[i]; '1' <= c && c <= '9' || c == '0' && i+1 < len(data) && '0' <= data[i+1] && data[i+1] <= '9' {
+				return i - 1
+			}
+		}
+	}
+	return -1
+}
+
+// checkStrictString fails for string literals with octal escape sequences in strict mode code, legacy octal numbers are rejected by the lexer.
+func (p *Parser) checkStrictString() bool {
+	if p.strict {
+		if i := octalEscape(p.data); i != -1 {
+			p.failAt(p.pos+i, "octal escape sequence not allowed in strict mode")
+			return false
+		}
+	}
+	return true
+}
+
+// checkDirectives fails for octal escape sequences in directives that precede a "use strict" directive, as those were parsed before the code was known to be strict.
+func (p *Parser) checkDirectives(list []IStmt) {
+	if !hasUseStrict(list) {
+		return
+	}
+	for _, item := range list {
+		directive, ok := item.(*DirectivePrologueStmt)
+		if !ok || isUseStrict(directive.Value) {
+			break
+		} else if i := octalEscape(directive.Value); i != -1 {
+			p.failAt(directive.Start+i, "octal escape sequence not allowed in strict mode")
+			return
+		}
+	}
+}
+
+// parseFuncBody parses the statements of a function body, which has its own directive prologue and labels. Duplicate parameter names are an error when unique is set, for arrow functions and methods, or when the function is strict or has non-simple parameters.
+func (p *Parser) parseFuncBody(in string, params *Params, unique bool) (list []IStmt) {
+	parentStrict, parentLabels, parentLoops, parentSwitches := p.strict, p.labels, p.loops, p.switches
+	parentStaticBlock := p.staticBlock
+	p.labels, p.loops, p.switches, p.staticBlock = nil, 0, 0, false
+	p.allowDirectivePrologue = true
+	list = p.parseStmtList(in)
+	if p.err == nil {
+		p.checkDirectives(list)
+		p.checkParams(params, list, unique)
+	}
+	p.strict, p.labels, p.loops, p.switches = parentStrict, parentLabels, parentLoops, parentSwitches
+	p.staticBlock = parentStaticBlock
+	return
+}
+
+// checkParams fails for duplicate parameter names and for a "use strict" directive in a function with non-simple parameters, which are parameters with default values, rest parameters, or destructuring patterns. Parameter names are checked again for strict mode, as they were parsed before the directive prologue of the body.
+func (p *Parser) checkParams(params *Params, body []IStmt, unique bool) {
+	simple := params.Rest == nil
+	for _, item := range params.List {
+		if _, ok := item.Binding.(*Var); !ok || item.Default != nil {
+			simple = false
+		}
+	}
+	if !simple && hasUseStrict(body) {
+		for _, item := range body {
+			if directive := item.(*DirectivePrologueStmt); isUseStrict(directive.Value) {
+				p.failAt(directive.Start, "\"use strict\" not allowed in function with non-simple parameters")
+				return
+			}
+		}
+	}
+	if !unique && !p.strict && simple {
+		return
+	}
+
+	names := map[*Var]bool{}
+	var check func(IBinding, int) bool
+	check = func(ibinding IBinding, start int) bool {
+		switch binding := ibinding.(type) {
+		case *Var:
+			if p.strict && !p.checkStrictIdentifier(binding.Data, start, true) {
+				return false
+			} else if names[binding.first] {
+				p.failAt(start, "duplicate parameter name %s", string(binding.Data))
+				return false
+			}
+			names[binding.first] = true
+		case *BindingArray:
+			for _, item := range binding.List {
+				if !check(item.Binding, item.Start) {
+					return false
+				}
+			}
+			return check(binding.Rest, binding.End)
+		case *BindingObject:
+			for _, item := range binding.List {
+				if !check(item.Value.Binding, item.Value.Start) {
+					return false
+				}
+			}
+			if binding.Rest != nil {
+				return check(binding.Rest, binding.End)
+			}
+		}
+		return true
+	}
+	for _, item := range params.List {
+		if !check(item.Binding, item.Start) {
+			return
+		}
+	}
+	check(params.Rest, params.End)
+}
+
+// isStrictReservedWord returns true for the identifiers that are reserved words in strict mode code.
+func isStrictReservedWord(name string) bool {
+	switch name {
+	case "implements", "interface", "let", "package", "private", "protected", "public", "static", "yield":
+		return true
+	}
+	return false
+}
+
+// checkStrictIdentifier fails for reserved words of strict mode code that are used as identifiers, and for eval and arguments that are used as binding names when binding is set. It must only be called for strict mode code.
+func (p *Parser) checkStrictIdentifier(name []byte, start int, binding bool) bool {
+	name = DecodeIdentifier(name)
+	if isStrictReservedWord(string(name)) {
+		p.failAt(start, "reserved word %s not allowed in strict mode", string(name))
+		return false
+	} else if binding && (string(name) == "eval" || string(name) == "arguments") {
+		p.failAt(start, "%s not allowed as binding name in strict mode", string(name))
+		return false
+	}
+	return true
+}
+
+// checkClassInitIdentifier fails for arguments in class static blocks and field initializers, and for await in class static blocks, including within arrow functions.
+func (p *Parser) checkClassInitIdentifier(name []byte, start int) bool {
+	name = DecodeIdentifier(name)
+	if string(name) == "arguments" {
+		p.failAt(start, "arguments not allowed in %s", p.classInit)
+		return false
+	} else if string(name) == "await" && p.classInit == "class static block" {
+		p.failAt(start, "await not allowed as identifier in class static block")
+		return false
+	}
+	return true
+}
+
+// checkAssignTarget fails for eval and arguments as the target of an assignment, increment, or decrement in strict mode code. The targets of destructuring patterns are checked as well when pattern is set.
+func (p *Parser) checkAssignTarget(target IExpr, start int, pattern bool) bool {
+	if !p.strict {
+		return true
+	}
+	switch target := target.(type) {
+	case *GroupExpr:
+		return p.checkAssignTarget(target.X, start, false)
+	case *Var:
+		if name := string(target.Data); name == "eval" || name == "arguments" {
+			p.failAt(start, "assignment to %s not allowed in strict mode", name)
+			return false
+		}
+	case *BinaryExpr:
+		if pattern && target.Op == EqToken {
+			return p.checkAssignTarget(target.X, start, true) // default value
+		}
+	case *ArrayExpr:
+		if pattern {
+			for _, item := range target.List {
+				if item.Value != nil && !p.checkAssignTarget(item.Value, start, true) {
+					return false
+				}
+			}
+		}
+	case *ObjectExpr:
+		if pattern {
+			for _, item := range target.List {
+				if !p.checkAssignTarget(item.Value, start, true) {
+					return false
+				}
+			}
+		}
+	}
+	return true
+}
+
+// markLoopLabels marks the labels directly before an iteration statement, so that continue statements can refer to them.
+func (p *Parser) markLoopLabels(labelled int) {
+	for i := len(p.labels) - labelled; i < len(p.labels); i++ {
+		p.labels[i].loop = true
+	}
+}
+
+// findLabel returns the enclosing label with the given name.
+func (p *Parser) findLabel(name []byte) (stmtLabel, bool) {
+	for i := len(p.labels) - 1; 0 <= i; i-- {
+		if bytes.Equal(p.labels[i].name, name) {
+			return p.labels[i], true
+		}
+	}
+	return stmtLabel{}, false
+}
+
+// groupedVar returns the variable of an identifier expression, which may be parenthesized.
+func groupedVar(expr IExpr) (*Var, bool) {
+	for {
+		group, ok := expr.(*GroupExpr)
+		if !ok {
+			break
+		}
+		expr = group.X
+	}
+	v, ok := expr.(*Var)
+	return v, ok
+}
+
+// isProto returns true if the property name is __proto__, as an identifier or string.
+func isProto(name *PropertyName) bool {
+	return !name.IsComputed() && (name.Literal.TokenType == IdentifierToken || name.Literal.TokenType == StringToken) && string(name.Literal.Data) == "__proto__"
+}
+
+// asPattern marks an object or array literal as an assignment pattern, for which duplicate __proto__ properties are allowed.
+func (p *Parser) asPattern(expr IExpr) {
+	switch expr.(type) {
+	case *ObjectExpr, *ArrayExpr:
+		start, end := expr.Offsets()
+		protoDups := p.protoDups[:0:0]
+		for _, offset := range p.protoDups {
+			if offset < start || end <= offset {
+				protoDups = append(protoDups, offset)
+			}
+		}
+		p.protoDups = protoDups
+	}
+}
+
+// checkProtoDups fails for duplicate __proto__ properties after start in object literals that are not assignment patterns, and is called at the end of each statement.
+func (p *Parser) checkProtoDups(start int) {
+	for i, offset := range p.protoDups {
+		if start <= offset {
+			p.protoDups = p.protoDups[:i]
+			p.failAt(offset, "duplicate __proto__ property in object literal")
+			return
+		}
+	}
+}
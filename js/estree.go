@@ -0,0 +1,2051 @@
+package js
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"unicode/utf8"
+)
+
+// ESTreeOptions are the options for MarshalESTree and UnmarshalESTree.
+type ESTreeOptions struct {
+	Source []byte // source code of the AST, which is required by MarshalESTree to locate nodes and to convert offsets between bytes and UTF-16 code units as used by JavaScript parsers
+	Module bool   // source type is module instead of script, only used by MarshalESTree where programs with import or export declarations are always modules
+}
+
+// MarshalESTree converts the AST to ESTree JSON as produced by acorn and espree. Each node has the start, end, and range properties with its offsets, and the loc property with its lines and columns. Offsets and columns are counted in UTF-16 code units. The source code of the AST must be given, since the AST does not store the positions of all tokens, and an error is returned when a token of the AST cannot be found in the source code. Parentheses are not part of ESTree and are removed. Regular expression and BigInt literals have a null value. JSX nodes cannot be converted.
+func MarshalESTree(ast *AST, o ESTreeOptions) ([]byte, error) {
+	if o.Source == nil {
+		return nil, fmt.Errorf("source code is required to compute offsets")
+	}
+	m := &estreeMarshaler{src: o.Source, lines: newESTreeLines(o.Source)}
+
+	body := m.stmts(ast.BlockStmt.List)
+	sourceType := "script"
+	if o.Module || isModule(ast) {
+		sourceType = "module"
+	}
+	program := m.object("Program", 0, len(o.Source), esField{"body", body}, esField{"sourceType", sourceType})
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	buf := &bytes.Buffer{}
+	if err := writeESTree(buf, program); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// isModule returns true if the program has import or export declarations, which are only allowed in modules.
+func isModule(ast *AST) bool {
+	for _, item := range ast.List {
+		switch item.(type) {
+		case *ImportStmt, *ExportStmt:
+			return true
+		}
+	}
+	return false
+}
+
+type esField struct {
+	Key   string
+	Value interface{}
+}
+
+// esObject is a JSON object that keeps the order of its fields.
+type esObject []esField
+
+func writeESTree(buf *bytes.Buffer, v interface{}) error {
+	switch v := v.(type) {
+	case esObject:
+		if v == nil {
+			buf.WriteString("null")
+			return nil
+		}
+		buf.WriteByte('{')
+		for i, field := range v {
+			if i != 0 {
+				buf.WriteByte(',')
+			}
+			buf.WriteString(strconv.Quote(field.Key))
+			buf.WriteByte(':')
+			if err := writeESTree(buf, field.Value); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+	case []interface{}:
+		buf.WriteByte('[')
+		for i, item := range v {
+			if i != 0 {
+				buf.WriteByte(',')
+			}
+			if err := writeESTree(buf, item); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+	default:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		buf.Write(b)
+	}
+	return nil
+}
+
+// estreeLines converts byte offsets to lines, columns, and offsets in UTF-16 code units, and back.
+type estreeLines struct {
+	src    []byte
+	starts []int // byte offsets of the line starts
+	units  []int // offsets of the line starts in UTF-16 code units
+}
+
+func newESTreeLines(src []byte) *estreeLines {
+	l := &estreeLines{
+		src:    src,
+		starts: []int{0},
+		units:  []int{0},
+	}
+	units := 0
+	for i := 0; i < len(src); {
+		r, n := utf8.DecodeRune(src[i:])
+		i += n
+		units += utf16Len(r)
+		if r == '\n' || r == '\r' && (i == len(src) || src[i] != '\n') || r == '\u2028' || r == '\u2029' {
+			l.starts = append(l.starts, i)
+			l.units = append(l.units, units)
+		}
+	}
+	return l
+}
+
+func utf16Len(r rune) int {
+	if 0x10000 <= r {
+		return 2
+	}
+	return 1
+}
+
+// position returns the line (starting at 1), the column, and the offset in UTF-16 code units of a byte offset.
+func (l *estreeLines) position(offset int) (int, int, int) {
+	if len(l.src) < offset {
+		offset = len(l.src)
+	}
+	line := sort.SearchInts(l.starts, offset+1) - 1
+	column := 0
+	for i := l.starts[line]; i < offset; {
+		r, n := utf8.DecodeRune(l.src[i:])
+		i += n
+		column += utf16Len(r)
+	}
+	return line + 1, column, l.units[line] + column
+}
+
+// offset returns the byte offset of an offset in UTF-16 code units.
+func (l *estreeLines) offset(units int) int {
+	if units < 0 {
+		return 0
+	}
+	line := sort.SearchInts(l.units, units+1) - 1
+	i, u := l.starts[line], l.units[line]
+	for u < units && i < len(l.src) {
+		r, n := utf8.DecodeRune(l.src[i:])
+		i += n
+		u += utf16Len(r)
+	}
+	return i
+}
+
+////////////////////////////////////////////////////////////////
+
+type estreeMarshaler struct {
+	src   []byte
+	lines *estreeLines
+	pos   int // offset in the source after the last converted node, from where identifiers are searched
+	err   error
+}
+
+// object returns a node with its location and fields.
+func (m *estreeMarshaler) object(typ string, start, end int, fields ...esField) esObject {
+	obj := make(esObject, 0, 5+len(fields))
+	obj = append(obj, esField{"type", typ})
+	startLine, startColumn, startOffset := m.lines.position(start)
+	endLine, endColumn, endOffset := m.lines.position(end)
+	loc := esObject{
+		{"start", esObject{{"line", startLine}, {"column", startColumn}}},
+		{"end", esObject{{"line", endLine}, {"column", endColumn}}},
+	}
+	obj = append(obj, esField{"start", startOffset}, esField{"end", endOffset}, esField{"loc", loc})
+	obj = append(obj, esField{"range", []interface{}{startOffset, endOffset}})
+	return append(obj, fields...)
+}
+
+func (m *estreeMarshaler) unsupported(n INode) interface{} {
+	if m.err == nil {
+		m.err = fmt.Errorf("unsupported node %T", n)
+	}
+	return nil
+}
+
+// enter moves the search position to the start of a node.
+func (m *estreeMarshaler) enter(start, end int) {
+	if start != 0 || end != 0 {
+		m.pos = start
+	}
+}
+
+// exit moves the search position to the end of a node.
+func (m *estreeMarshaler) exit(start, end int) {
+	if start != 0 || end != 0 {
+		m.pos = end
+	}
+}
+
+// find returns the offset of the next occurrence of an identifier, keyword, punctuator, or string literal in the source after the search position, skipping comments and other string literals. It returns -1 if it is not found.
+func (m *estreeMarshaler) find(tok []byte) int {
+	src := m.src
+	for i := m.pos; i < len(src); {
+		c := src[i]
+		if c == '/' && i+1 < len(src) && src[i+1] == '/' {
+			for i < len(src) && src[i] != '\n' && src[i] != '\r' {
+				i++
+			}
+		} else if c == '/' && i+1 < len(src) && src[i+1] == '*' {
+			if end := bytes.Index(src[i+2:], []byte("*/")); end != -1 {
+				i += end + 4
+			} else {
+				i = len(src)
+			}
+		} else if isIdentifierByte(c) {
+			j := i + 1
+			for j < len(src) && isIdentifierByte(src[j]) {
+				j++
+			}
+			if bytes.Equal(src[i:j], tok) {
+				return i
+			}
+			i = j
+		} else if c == '"' || c == '\'' {
+			if bytes.HasPrefix(src[i:], tok) {
+				return i
+			}
+			for i++; i < len(src) && src[i] != c && src[i] != '\n'; i++ {
+				if src[i] == '\\' {
+					i++
+				}
+			}
+			i++
+		} else if bytes.HasPrefix(src[i:], tok) {
+			return i
+		} else {
+			i++
+		}
+	}
+	return -1
+}
+
+// token returns the span of the next occurrence of the token in the source. It fails and returns the fallback span if it is not found, which happens when the source does not belong to the AST.
+func (m *estreeMarshaler) token(tok []byte, fallback Span) (int, int) {
+	if i := m.find(tok); i != -1 {
+		m.pos = i + len(tok)
+		return i, i + len(tok)
+	}
+	if m.err == nil {
+		m.err = fmt.Errorf("cannot find %s after offset %d in source code", tok, m.pos)
+	}
+	return fallback.Start, fallback.End
+}
+
+// identifier returns an Identifier at the next occurrence of the name.
+func (m *estreeMarshaler) identifier(name []byte, fallback Span) esObject {
+	start, end := m.token(name, fallback)
+	return m.object("Identifier", start, end, esField{"name", string(DecodeIdentifier(name))})
+}
+
+// variable returns an Identifier at the span of the variable, or at the next occurrence of its name for variables without a span.
+func (m *estreeMarshaler) variable(v *Var) esObject {
+	if v.End == 0 {
+		return m.identifier(v.Data, v.Span)
+	}
+	m.pos = v.End
+	return m.object("Identifier", v.Start, v.End, esField{"name", string(DecodeIdentifier(v.Data))})
+}
+
+// moduleExportName returns an Identifier or a string Literal of an imported or exported name.
+func (m *estreeMarshaler) moduleExportName(name []byte, fallback Span) esObject {
+	if 0 < len(name) && (name[0] == '"' || name[0] == '\'') {
+		start, end := m.token(name, fallback)
+		return m.stringLiteral(name, start, end)
+	}
+	return m.identifier(name, fallback)
+}
+
+func (m *estreeMarshaler) stringLiteral(raw []byte, start, end int) esObject {
+	var value interface{}
+	if s, err := DecodeString(raw); err == nil {
+		value = string(s)
+	}
+	return m.object("Literal", start, end, esField{"value", value}, esField{"raw", string(raw)})
+}
+
+func (m *estreeMarshaler) stmts(list []IStmt) []interface{} {
+	stmts := make([]interface{}, 0, len(list))
+	for _, item := range list {
+		stmts = append(stmts, m.stmt(item))
+	}
+	return stmts
+}
+
+func (m *estreeMarshaler) stmt(stmt IStmt) interface{} {
+	start, end := stmt.Offsets()
+	m.enter(start, end)
+	obj := m.stmtObject(stmt, start, end)
+	m.exit(start, end)
+	return obj
+}
+
+// body returns the body of a for statement, which the parser always puts in a block statement.
+func (m *estreeMarshaler) body(n *BlockStmt) interface{} {
+	if len(n.List) == 1 {
+		if start, end := n.List[0].Offsets(); start == n.Start && end == n.End {
+			return m.stmt(n.List[0])
+		}
+	}
+	return m.stmt(n)
+}
+
+func (m *estreeMarshaler) stmtObject(stmt IStmt, start, end int) interface{} {
+	switch n := stmt.(type) {
+	case *BlockStmt:
+		return m.object("BlockStatement", start, end, esField{"body", m.stmts(n.List)})
+	case *EmptyStmt:
+		return m.object("EmptyStatement", start, end)
+	case *ExprStmt:
+		return m.object("ExpressionStatement", start, end, esField{"expression", m.expr(n.Value)})
+	case *IfStmt:
+		test := m.expr(n.Cond)
+		consequent := m.stmt(n.Body)
+		var alternate interface{}
+		if n.Else != nil {
+			alternate = m.stmt(n.Else)
+		}
+		return m.object("IfStatement", start, end, esField{"test", test}, esField{"consequent", consequent}, esField{"alternate", alternate})
+	case *DoWhileStmt:
+		body := m.stmt(n.Body)
+		test := m.expr(n.Cond)
+		return m.object("DoWhileStatement", start, end, esField{"body", body}, esField{"test", test})
+	case *WhileStmt:
+		test := m.expr(n.Cond)
+		body := m.stmt(n.Body)
+		return m.object("WhileStatement", start, end, esField{"test", test}, esField{"body", body})
+	case *ForStmt:
+		var init interface{}
+		if varDecl, ok := n.Init.(*VarDecl); ok {
+			init = m.stmt(varDecl)
+		} else {
+			init = m.expr(n.Init)
+		}
+		test := m.expr(n.Cond)
+		update := m.expr(n.Post)
+		body := m.body(n.Body)
+		return m.object("ForStatement", start, end, esField{"init", init}, esField{"test", test}, esField{"update", update}, esField{"body", body})
+	case *ForInStmt:
+		left := m.forLeft(n.Init)
+		right := m.expr(n.Value)
+		body := m.body(n.Body)
+		return m.object("ForInStatement", start, end, esField{"left", left}, esField{"right", right}, esField{"body", body})
+	case *ForOfStmt:
+		left := m.forLeft(n.Init)
+		right := m.expr(n.Value)
+		body := m.body(n.Body)
+		return m.object("ForOfStatement", start, end, esField{"await", n.Await}, esField{"left", left}, esField{"right", right}, esField{"body", body})
+	case *SwitchStmt:
+		discriminant := m.expr(n.Init)
+		cases := []interface{}{}
+		for _, clause := range n.List {
+			m.enter(clause.Start, clause.End)
+			test := m.expr(clause.Cond)
+			consequent := m.stmts(clause.List)
+			cases = append(cases, m.object("SwitchCase", clause.Start, clause.End, esField{"consequent", consequent}, esField{"test", test}))
+			m.exit(clause.Start, clause.End)
+		}
+		return m.object("SwitchStatement", start, end, esField{"discriminant", discriminant}, esField{"cases", cases})
+	case *BranchStmt:
+		typ := "BreakStatement"
+		if n.Type == ContinueToken {
+			typ = "ContinueStatement"
+		}
+		var label interface{}
+		if n.Label != nil {
+			m.token([]byte(n.Type.String()), Span{})
+			label = m.identifier(n.Label, n.Span)
+		}
+		return m.object(typ, start, end, esField{"label", label})
+	case *ReturnStmt:
+		return m.object("ReturnStatement", start, end, esField{"argument", m.expr(n.Value)})
+	case *WithStmt:
+		object := m.expr(n.Cond)
+		body := m.stmt(n.Body)
+		return m.object("WithStatement", start, end, esField{"object", object}, esField{"body", body})
+	case *LabelledStmt:
+		label := m.identifier(n.Label, n.Span)
+		body := m.stmt(n.Value)
+		return m.object("LabeledStatement", start, end, esField{"body", body}, esField{"label", label})
+	case *ThrowStmt:
+		return m.object("ThrowStatement", start, end, esField{"argument", m.expr(n.Value)})
+	case *TryStmt:
+		block := m.stmt(n.Body)
+		var handler, finalizer interface{}
+		if n.Catch != nil {
+			catchStart, _ := m.token([]byte("catch"), n.Catch.Span)
+			var param interface{}
+			if n.Binding != nil {
+				param = m.binding(n.Binding)
+			}
+			body := m.stmt(n.Catch)
+			handler = m.object("CatchClause", catchStart, n.Catch.End, esField{"param", param}, esField{"body", body})
+		}
+		if n.Finally != nil {
+			finalizer = m.stmt(n.Finally)
+		}
+		return m.object("TryStatement", start, end, esField{"block", block}, esField{"handler", handler}, esField{"finalizer", finalizer})
+	case *DebuggerStmt:
+		return m.object("DebuggerStatement", start, end)
+	case *ImportStmt:
+		return m.importDecl(n, start, end)
+	case *ExportStmt:
+		return m.exportDecl(n, start, end)
+	case *DirectivePrologueStmt:
+		expression := m.stringLiteral(n.Value, start, start+len(n.Value))
+		directive := ""
+		if 2 <= len(n.Value) {
+			directive = string(n.Value[1 : len(n.Value)-1])
+		}
+		return m.object("ExpressionStatement", start, end, esField{"expression", expression}, esField{"directive", directive})
+	case *VarDecl:
+		declarations := []interface{}{}
+		for _, item := range n.List {
+			m.enter(item.Start, item.End)
+			id := m.binding(item.Binding)
+			init := m.expr(item.Default)
+			declarations = append(declarations, m.object("VariableDeclarator", item.Start, item.End, esField{"id", id}, esField{"init", init}))
+			m.exit(item.Start, item.End)
+		}
+		kind := n.TokenType.String()
+		if n.Await {
+			kind = "await " + kind
+		}
+		return m.object("VariableDeclaration", start, end, esField{"declarations", declarations}, esField{"kind", kind})
+	case *FuncDecl:
+		return m.function("FunctionDeclaration", n, start, end)
+	case *ClassDecl:
+		return m.class("ClassDeclaration", n, start, end)
+	}
+	return m.unsupported(stmt)
+}
+
+func (m *estreeMarshaler) forLeft(init IExpr) interface{} {
+	if varDecl, ok := init.(*VarDecl); ok {
+		return m.stmt(varDecl)
+	}
+	return m.pattern(init)
+}
+
+func (m *estreeMarshaler) importDecl(n *ImportStmt, start, end int) interface{} {
+	specifiers := []interface{}{}
+	if n.Default != nil {
+		localStart, localEnd := m.token(n.Default, n.Span)
+		local := m.object("Identifier", localStart, localEnd, esField{"name", string(DecodeIdentifier(n.Default))})
+		specifiers = append(specifiers, m.object("ImportDefaultSpecifier", localStart, localEnd, esField{"local", local}))
+	}
+	for _, alias := range n.List {
+		if alias.Binding == nil {
+			continue
+		}
+		m.enter(alias.Start, alias.End)
+		if isStar(alias.Name) {
+			local := m.identifier(alias.Binding, alias.Span)
+			specifiers = append(specifiers, m.object("ImportNamespaceSpecifier", alias.Start, alias.End, esField{"local", local}))
+		} else if alias.Name != nil {
+			imported := m.moduleExportName(alias.Name, alias.Span)
+			local := m.identifier(alias.Binding, alias.Span)
+			specifiers = append(specifiers, m.object("ImportSpecifier", alias.Start, alias.End, esField{"imported", imported}, esField{"local", local}))
+		} else {
+			local := m.identifier(alias.Binding, alias.Span)
+			specifiers = append(specifiers, m.object("ImportSpecifier", alias.Start, alias.End, esField{"imported", local}, esField{"local", local}))
+		}
+		m.exit(alias.Start, alias.End)
+	}
+	obj := m.object("ImportDeclaration", start, end, esField{"specifiers", specifiers}, esField{"source", m.source(n.Module, end)})
+	return m.importAttributes(obj, n.Attributes)
+}
+
+// importAttributes adds the ImportAttributes of an import or export statement, which are only set when the statement has a with clause.
+func (m *estreeMarshaler) importAttributes(obj esObject, list []ImportAttribute) esObject {
+	if len(list) == 0 {
+		return obj
+	}
+	attributes := []interface{}{}
+	for _, item := range list {
+		m.enter(item.Start, item.End)
+		key := m.moduleExportName(item.Key, Span{item.Start, item.Start + len(item.Key)})
+		valueStart, valueEnd := m.token(item.Value, Span{item.End - len(item.Value), item.End})
+		value := m.stringLiteral(item.Value, valueStart, valueEnd)
+		attributes = append(attributes, m.object("ImportAttribute", item.Start, item.End, esField{"key", key}, esField{"value", value}))
+		m.exit(item.Start, item.End)
+	}
+	return append(obj, esField{"attributes", attributes})
+}
+
+// source returns the string Literal of a module specifier at the end of a statement.
+func (m *estreeMarshaler) source(module []byte, end int) esObject {
+	start, end := m.token(module, Span{end - len(module), end})
+	return m.stringLiteral(module, start, end)
+}
+
+func (m *estreeMarshaler) exportDecl(n *ExportStmt, start, end int) interface{} {
+	if n.Default {
+		var declaration interface{}
+		switch decl := n.Decl.(type) {
+		case *FuncDecl:
+			declaration = m.stmt(decl)
+		case *ClassDecl:
+			declaration = m.stmt(decl)
+		default:
+			declaration = m.expr(decl)
+		}
+		return m.object("ExportDefaultDeclaration", start, end, esField{"declaration", declaration})
+	} else if n.Decl != nil {
+		declaration := m.stmt(n.Decl.(IStmt))
+		return m.object("ExportNamedDeclaration", start, end, esField{"declaration", declaration}, esField{"specifiers", []interface{}{}}, esField{"source", nil})
+	}
+
+	for _, alias := range n.List {
+		if isStar(alias.Binding) && alias.Name == nil {
+			obj := m.object("ExportAllDeclaration", start, end, esField{"exported", nil}, esField{"source", m.source(n.Module, end)})
+			return m.importAttributes(obj, n.Attributes)
+		} else if isStar(alias.Name) {
+			m.enter(alias.Start, alias.End)
+			exported := m.moduleExportName(alias.Binding, alias.Span)
+			obj := m.object("ExportAllDeclaration", start, end, esField{"exported", exported}, esField{"source", m.source(n.Module, end)})
+			return m.importAttributes(obj, n.Attributes)
+		}
+	}
+	specifiers := []interface{}{}
+	for _, alias := range n.List {
+		if alias.Binding == nil {
+			continue
+		}
+		m.enter(alias.Start, alias.End)
+		var local, exported interface{}
+		if alias.Name != nil {
+			local = m.moduleExportName(alias.Name, alias.Span)
+			exported = m.moduleExportName(alias.Binding, alias.Span)
+		} else {
+			local = m.moduleExportName(alias.Binding, alias.Span)
+			exported = local
+		}
+		specifiers = append(specifiers, m.object("ExportSpecifier", alias.Start, alias.End, esField{"local", local}, esField{"exported", exported}))
+		m.exit(alias.Start, alias.End)
+	}
+	var source interface{}
+	if n.Module != nil {
+		source = m.source(n.Module, end)
+	}
+	obj := m.object("ExportNamedDeclaration", start, end, esField{"declaration", nil}, esField{"specifiers", specifiers}, esField{"source", source})
+	return m.importAttributes(obj, n.Attributes)
+}
+
+func (m *estreeMarshaler) function(typ string, n *FuncDecl, start, end int) esObject {
+	var id interface{}
+	if n.Name != nil {
+		m.token([]byte("function"), Span{})
+		id = m.variable(n.Name)
+	}
+	params := m.params(n.Params)
+	body := m.stmt(&n.Body)
+	return m.object(typ, start, end, esField{"id", id}, esField{"expression", false}, esField{"generator", n.Generator}, esField{"async", n.Async}, esField{"params", params}, esField{"body", body})
+}
+
+func (m *estreeMarshaler) params(n Params) []interface{} {
+	params := []interface{}{}
+	for _, item := range n.List {
+		params = append(params, m.bindingElement(item))
+	}
+	if n.Rest != nil {
+		params = append(params, m.rest(n.Rest))
+	}
+	return params
+}
+
+// rest returns a RestElement of a binding.
+func (m *estreeMarshaler) rest(binding IBinding) esObject {
+	start, _ := binding.Offsets()
+	start, _ = m.token([]byte("..."), Span{start, start})
+	argument := m.binding(binding)
+	return m.object("RestElement", start, m.pos, esField{"argument", argument})
+}
+
+func (m *estreeMarshaler) class(typ string, n *ClassDecl, start, end int) esObject {
+	var id, superClass interface{}
+	decorators := m.decorators(n.Decorators)
+	if n.Name != nil {
+		m.token([]byte("class"), Span{})
+		id = m.variable(n.Name)
+	}
+	if n.Extends != nil {
+		superClass = m.expr(n.Extends)
+	}
+	bodyStart, _ := m.token([]byte("{"), Span{start, start})
+
+	// class fields and methods are stored separately
+	body := []interface{}{}
+	i, j := 0, 0
+	for i < len(n.Definitions) || j < len(n.Methods) {
+		if j == len(n.Methods) || i < len(n.Definitions) && n.Definitions[i].Start < n.Methods[j].Start {
+			field := n.Definitions[i]
+			m.enter(field.Start, field.End)
+			if field.Body != nil {
+				body = append(body, m.object("StaticBlock", field.Start, field.End, esField{"body", m.stmts(field.Body.List)}))
+			} else {
+				fieldDecorators := m.decorators(field.Decorators)
+				key, computed := m.propertyName(field.Name)
+				value := m.expr(field.Init)
+				typ := "PropertyDefinition"
+				if field.Accessor {
+					typ = "AccessorProperty"
+				}
+				obj := m.object(typ, field.Start, field.End, esField{"static", field.Static}, esField{"computed", computed}, esField{"key", key}, esField{"value", value})
+				body = append(body, withDecorators(obj, fieldDecorators))
+			}
+			m.exit(field.Start, field.End)
+			i++
+		} else {
+			method := n.Methods[j]
+			m.enter(method.Start, method.End)
+			methodDecorators := m.decorators(method.Decorators)
+			key, computed := m.propertyName(method.Name)
+			kind := "method"
+			if method.Get {
+				kind = "get"
+			} else if method.Set {
+				kind = "set"
+			} else if !method.Static && !computed && propertyNameIs(method.Name, "constructor") {
+				kind = "constructor"
+			}
+			value := m.method(method)
+			obj := m.object("MethodDefinition", method.Start, method.End, esField{"static", method.Static}, esField{"computed", computed}, esField{"key", key}, esField{"kind", kind}, esField{"value", value})
+			body = append(body, withDecorators(obj, methodDecorators))
+			m.exit(method.Start, method.End)
+			j++
+		}
+	}
+	classBody := m.object("ClassBody", bodyStart, end, esField{"body", body})
+	return withDecorators(m.object(typ, start, end, esField{"id", id}, esField{"superClass", superClass}, esField{"body", classBody}), decorators)
+}
+
+// decorators returns the Decorators of a class or class element, where each decorator starts at the @ that precedes its expression.
+func (m *estreeMarshaler) decorators(list []IExpr) []interface{} {
+	decorators := []interface{}{}
+	for _, item := range list {
+		exprStart, exprEnd := item.Offsets()
+		start := exprStart - 1
+		for 0 < start && start < len(m.src) && (m.src[start] == ' ' || m.src[start] == '\t' || m.src[start] == '\n' || m.src[start] == '\r') {
+			start--
+		}
+		expression := m.expr(item)
+		decorators = append(decorators, m.object("Decorator", start, exprEnd, esField{"expression", expression}))
+	}
+	return decorators
+}
+
+// withDecorators adds the decorators to a class or class element, which are omitted if there are none.
+func withDecorators(obj esObject, decorators []interface{}) esObject {
+	if len(decorators) == 0 {
+		return obj
+	}
+	return append(obj, esField{"decorators", decorators})
+}
+
+// method returns the FunctionExpression of a method, which starts at its parameters.
+func (m *estreeMarshaler) method(n *MethodDecl) esObject {
+	params := m.params(n.Params)
+	body := m.stmt(&n.Body)
+	return m.object("FunctionExpression", n.Params.Start, n.Body.End, esField{"id", nil}, esField{"expression", false}, esField{"generator", n.Generator}, esField{"async", n.Async}, esField{"params", params}, esField{"body", body})
+}
+
+func propertyNameIs(n PropertyName, name string) bool {
+	if n.Literal.TokenType == StringToken {
+		s, err := DecodeString(n.Literal.Data)
+		return err == nil && string(s) == name
+	}
+	return string(n.Literal.Data) == name
+}
+
+// propertyName returns the key of a property and whether it is computed.
+func (m *estreeMarshaler) propertyName(n PropertyName) (interface{}, bool) {
+	if n.IsComputed() {
+		return m.expr(n.Computed), true
+	}
+	return m.expr(&n.Literal), false
+}
+
+func (m *estreeMarshaler) binding(binding IBinding) interface{} {
+	if v, ok := binding.(*Var); ok {
+		return m.variable(v)
+	}
+	start, end := binding.Offsets()
+	m.enter(start, end)
+	defer m.exit(start, end)
+	switch n := binding.(type) {
+	case *BindingArray:
+		elements := []interface{}{}
+		for _, item := range n.List {
+			if item.Binding == nil {
+				elements = append(elements, nil)
+			} else {
+				elements = append(elements, m.bindingElement(item))
+			}
+		}
+		if n.Rest != nil {
+			elements = append(elements, m.rest(n.Rest))
+		}
+		return m.object("ArrayPattern", start, end, esField{"elements", elements})
+	case *BindingObject:
+		properties := []interface{}{}
+		for _, item := range n.List {
+			m.enter(item.Start, item.End)
+			var key, value interface{}
+			computed := false
+			shorthand := isShorthandBinding(item)
+			if shorthand {
+				// the key and value are the same node
+				v := item.Value.Binding.(*Var)
+				key = m.variable(v)
+				value = key
+				if item.Value.Default != nil {
+					value = m.object("AssignmentPattern", item.Value.Start, item.Value.End, esField{"left", key}, esField{"right", m.expr(item.Value.Default)})
+				}
+			} else if item.Key != nil {
+				key, computed = m.propertyName(*item.Key)
+				value = m.bindingElement(item.Value)
+			} else {
+				return m.unsupported(n)
+			}
+			properties = append(properties, m.object("Property", item.Start, item.End, esField{"method", false}, esField{"shorthand", shorthand}, esField{"computed", computed}, esField{"key", key}, esField{"value", value}, esField{"kind", "init"}))
+			m.exit(item.Start, item.End)
+		}
+		if n.Rest != nil {
+			properties = append(properties, m.rest(n.Rest))
+		}
+		return m.object("ObjectPattern", start, end, esField{"properties", properties})
+	}
+	return m.unsupported(binding)
+}
+
+func (m *estreeMarshaler) bindingElement(n BindingElement) interface{} {
+	if n.Default == nil {
+		return m.binding(n.Binding)
+	}
+	m.enter(n.Start, n.End)
+	left := m.binding(n.Binding)
+	right := m.expr(n.Default)
+	m.exit(n.Start, n.End)
+	return m.object("AssignmentPattern", n.Start, n.End, esField{"left", left}, esField{"right", right})
+}
+
+// pattern returns the pattern of an assignment target, where array and object literals are converted to patterns.
+func (m *estreeMarshaler) pattern(e IExpr) interface{} {
+	start, end := e.Offsets()
+	switch n := e.(type) {
+	case *GroupExpr:
+		m.enter(start, end)
+		obj := m.pattern(n.X)
+		m.exit(start, end)
+		return obj
+	case *ArrayExpr:
+		m.enter(start, end)
+		defer m.exit(start, end)
+		elements := []interface{}{}
+		for _, item := range n.List {
+			if item.Value == nil {
+				elements = append(elements, nil)
+			} else if item.Spread {
+				m.enter(item.Start, item.End)
+				argument := m.pattern(item.Value)
+				elements = append(elements, m.object("RestElement", item.Start, item.End, esField{"argument", argument}))
+				m.exit(item.Start, item.End)
+			} else {
+				elements = append(elements, m.patternDefault(item.Value))
+			}
+		}
+		return m.object("ArrayPattern", start, end, esField{"elements", elements})
+	case *ObjectExpr:
+		m.enter(start, end)
+		defer m.exit(start, end)
+		properties := []interface{}{}
+		for _, item := range n.List {
+			m.enter(item.Start, item.End)
+			if item.Spread {
+				argument := m.pattern(item.Value)
+				properties = append(properties, m.object("RestElement", item.Start, item.End, esField{"argument", argument}))
+				m.exit(item.Start, item.End)
+				continue
+			}
+			var key, value interface{}
+			computed := false
+			shorthand := isShorthand(item)
+			if shorthand {
+				v := item.Value.(*Var)
+				key = m.variable(v)
+				value = key
+				if item.Init != nil {
+					value = m.object("AssignmentPattern", item.Start, item.End, esField{"left", key}, esField{"right", m.expr(item.Init)})
+				}
+			} else if item.Name != nil && item.Init == nil {
+				key, computed = m.propertyName(*item.Name)
+				value = m.patternDefault(item.Value)
+			} else {
+				return m.unsupported(n)
+			}
+			properties = append(properties, m.object("Property", item.Start, item.End, esField{"method", false}, esField{"shorthand", shorthand}, esField{"computed", computed}, esField{"key", key}, esField{"value", value}, esField{"kind", "init"}))
+			m.exit(item.Start, item.End)
+		}
+		return m.object("ObjectPattern", start, end, esField{"properties", properties})
+	}
+	return m.expr(e)
+}
+
+func (m *estreeMarshaler) patternDefault(e IExpr) interface{} {
+	if n, ok := e.(*BinaryExpr); ok && n.Op == EqToken {
+		m.enter(n.Start, n.End)
+		left := m.pattern(n.X)
+		right := m.expr(n.Y)
+		m.exit(n.Start, n.End)
+		return m.object("AssignmentPattern", n.Start, n.End, esField{"left", left}, esField{"right", right})
+	}
+	return m.pattern(e)
+}
+
+func (m *estreeMarshaler) expr(e IExpr) interface{} {
+	if e == nil {
+		return nil
+	} else if v, ok := e.(*Var); ok {
+		return m.variable(v)
+	}
+	start, end := e.Offsets()
+	m.enter(start, end)
+	defer m.exit(start, end)
+	if isOptionalChain(e) {
+		return m.object("ChainExpression", start, end, esField{"expression", m.chain(e)})
+	}
+	return m.exprObject(e, start, end)
+}
+
+// isOptionalChain returns true if the expression is a member or call expression that contains an optional chain, which is not parenthesized.
+func isOptionalChain(e IExpr) bool {
+	for {
+		switch n := e.(type) {
+		case *OptChainExpr:
+			return true
+		case *DotExpr:
+			e = n.X
+		case *IndexExpr:
+			e = n.X
+		case *CallExpr:
+			e = n.X
+		default:
+			return false
+		}
+	}
+}
+
+// chain returns a member or call expression of an optional chain.
+func (m *estreeMarshaler) chain(e IExpr) interface{} {
+	start, end := e.Offsets()
+	m.enter(start, end)
+	defer m.exit(start, end)
+	switch n := e.(type) {
+	case *OptChainExpr:
+		object := m.chain(n.X)
+		switch y := n.Y.(type) {
+		case *LiteralExpr:
+			property := m.expr(y)
+			return m.object("MemberExpression", start, end, esField{"object", object}, esField{"property", property}, esField{"computed", false}, esField{"optional", true})
+		case *IndexExpr:
+			property := m.expr(y.Y)
+			return m.object("MemberExpression", start, end, esField{"object", object}, esField{"property", property}, esField{"computed", true}, esField{"optional", true})
+		case *CallExpr:
+			arguments := m.args(y.Args)
+			return m.object("CallExpression", start, end, esField{"callee", object}, esField{"arguments", arguments}, esField{"optional", true})
+		}
+		return m.unsupported(n)
+	case *DotExpr:
+		object := m.chain(n.X)
+		property := m.expr(&n.Y)
+		return m.object("MemberExpression", start, end, esField{"object", object}, esField{"property", property}, esField{"computed", false}, esField{"optional", false})
+	case *IndexExpr:
+		object := m.chain(n.X)
+		property := m.expr(n.Y)
+		return m.object("MemberExpression", start, end, esField{"object", object}, esField{"property", property}, esField{"computed", true}, esField{"optional", false})
+	case *CallExpr:
+		callee := m.chain(n.X)
+		arguments := m.args(n.Args)
+		return m.object("CallExpression", start, end, esField{"callee", callee}, esField{"arguments", arguments}, esField{"optional", false})
+	}
+	return m.expr(e)
+}
+
+func (m *estreeMarshaler) args(n Args) []interface{} {
+	args := []interface{}{}
+	for _, arg := range n.List {
+		if arg.Rest {
+			m.enter(arg.Start, arg.End)
+			argument := m.expr(arg.Value)
+			args = append(args, m.object("SpreadElement", arg.Start, arg.End, esField{"argument", argument}))
+			m.exit(arg.Start, arg.End)
+		} else {
+			args = append(args, m.expr(arg.Value))
+		}
+	}
+	return args
+}
+
+func (m *estreeMarshaler) exprObject(e IExpr, start, end int) interface{} {
+	switch n := e.(type) {
+	case *GroupExpr:
+		return m.expr(n.X)
+	case *LiteralExpr:
+		return m.literal(n, start, end)
+	case *ArrayExpr:
+		elements := []interface{}{}
+		for _, item := range n.List {
+			if item.Value == nil {
+				elements = append(elements, nil)
+			} else if item.Spread {
+				m.enter(item.Start, item.End)
+				argument := m.expr(item.Value)
+				elements = append(elements, m.object("SpreadElement", item.Start, item.End, esField{"argument", argument}))
+				m.exit(item.Start, item.End)
+			} else {
+				elements = append(elements, m.expr(item.Value))
+			}
+		}
+		return m.object("ArrayExpression", start, end, esField{"elements", elements})
+	case *ObjectExpr:
+		properties := []interface{}{}
+		for _, item := range n.List {
+			properties = append(properties, m.property(item))
+		}
+		return m.object("ObjectExpression", start, end, esField{"properties", properties})
+	case *TemplateExpr:
+		if n.Tag == nil {
+			return m.template(n, start, end)
+		}
+		tag := m.expr(n.Tag)
+		quasiStart := end - len(n.Tail)
+		if 0 < len(n.List) && (n.List[0].Start != 0 || n.List[0].End != 0) {
+			quasiStart = n.List[0].Start
+		}
+		quasi := m.template(n, quasiStart, end)
+		return m.object("TaggedTemplateExpression", start, end, esField{"tag", tag}, esField{"quasi", quasi})
+	case *DotExpr, *IndexExpr:
+		return m.chain(n)
+	case *NewTargetExpr:
+		meta := m.object("Identifier", start, start+3, esField{"name", "new"})
+		property := m.object("Identifier", end-6, end, esField{"name", "target"})
+		return m.object("MetaProperty", start, end, esField{"meta", meta}, esField{"property", property})
+	case *ImportMetaExpr:
+		meta := m.object("Identifier", start, start+6, esField{"name", "import"})
+		property := m.object("Identifier", end-4, end, esField{"name", "meta"})
+		return m.object("MetaProperty", start, end, esField{"meta", meta}, esField{"property", property})
+	case *NewExpr:
+		callee := m.expr(n.X)
+		arguments := []interface{}{}
+		if n.Args != nil {
+			arguments = m.args(*n.Args)
+		}
+		return m.object("NewExpression", start, end, esField{"callee", callee}, esField{"arguments", arguments})
+	case *CallExpr:
+		if lit, ok := n.X.(*LiteralExpr); ok && lit.TokenType == ImportToken && len(n.Args.List) == 1 {
+			source := m.expr(n.Args.List[0].Value)
+			return m.object("ImportExpression", start, end, esField{"source", source})
+		}
+		return m.chain(n)
+	case *UnaryExpr:
+		argument := m.expr(n.X)
+		switch n.Op {
+		case PreIncrToken, PreDecrToken, PostIncrToken, PostDecrToken:
+			operator := "++"
+			if n.Op == PreDecrToken || n.Op == PostDecrToken {
+				operator = "--"
+			}
+			prefix := n.Op == PreIncrToken || n.Op == PreDecrToken
+			return m.object("UpdateExpression", start, end, esField{"operator", operator}, esField{"prefix", prefix}, esField{"argument", argument})
+		case AwaitToken:
+			return m.object("AwaitExpression", start, end, esField{"argument", argument})
+		}
+		operator := n.Op.String()
+		if n.Op == PosToken {
+			operator = "+"
+		} else if n.Op == NegToken {
+			operator = "-"
+		}
+		return m.object("UnaryExpression", start, end, esField{"operator", operator}, esField{"prefix", true}, esField{"argument", argument})
+	case *BinaryExpr:
+		switch n.Op {
+		case CommaToken:
+			expressions := []interface{}{}
+			m.sequence(n, &expressions)
+			return m.object("SequenceExpression", start, end, esField{"expressions", expressions})
+		case AndToken, OrToken, NullishToken:
+			left := m.expr(n.X)
+			right := m.expr(n.Y)
+			return m.object("LogicalExpression", start, end, esField{"left", left}, esField{"operator", n.Op.String()}, esField{"right", right})
+		}
+		if binaryOpPrec(n.Op) == OpAssign {
+			var left interface{}
+			if n.Op == EqToken {
+				left = m.pattern(n.X)
+			} else {
+				left = m.expr(n.X)
+			}
+			right := m.expr(n.Y)
+			return m.object("AssignmentExpression", start, end, esField{"operator", n.Op.String()}, esField{"left", left}, esField{"right", right})
+		}
+		left := m.expr(n.X)
+		right := m.expr(n.Y)
+		return m.object("BinaryExpression", start, end, esField{"left", left}, esField{"operator", n.Op.String()}, esField{"right", right})
+	case *CondExpr:
+		test := m.expr(n.Cond)
+		consequent := m.expr(n.X)
+		alternate := m.expr(n.Y)
+		return m.object("ConditionalExpression", start, end, esField{"test", test}, esField{"consequent", consequent}, esField{"alternate", alternate})
+	case *YieldExpr:
+		argument := m.expr(n.X)
+		return m.object("YieldExpression", start, end, esField{"delegate", n.Generator}, esField{"argument", argument})
+	case *ArrowFunc:
+		params := m.params(n.Params)
+		var body interface{}
+		expression := false
+		if ret, ok := conciseBody(n); ok {
+			body = m.expr(ret.Value)
+			expression = true
+		} else {
+			body = m.stmt(&n.Body)
+		}
+		return m.object("ArrowFunctionExpression", start, end, esField{"id", nil}, esField{"expression", expression}, esField{"generator", false}, esField{"async", n.Async}, esField{"params", params}, esField{"body", body})
+	case *FuncDecl:
+		return m.function("FunctionExpression", n, start, end)
+	case *ClassDecl:
+		return m.class("ClassExpression", n, start, end)
+	}
+	return m.unsupported(e)
+}
+
+// conciseBody returns the return statement of an arrow function whose body is an expression, which the parser puts in a return statement that has the span of the expression.
+func conciseBody(n *ArrowFunc) (*ReturnStmt, bool) {
+	if len(n.Body.List) == 1 {
+		if ret, ok := n.Body.List[0].(*ReturnStmt); ok && ret.Value != nil {
+			if start, end := ret.Value.Offsets(); ret.Start == start && ret.End == end && (start != 0 || end != 0) {
+				return ret, true
+			}
+		}
+	}
+	return nil, false
+}
+
+func (m *estreeMarshaler) sequence(e IExpr, expressions *[]interface{}) {
+	if n, ok := e.(*BinaryExpr); ok && n.Op == CommaToken {
+		m.sequence(n.X, expressions)
+		m.sequence(n.Y, expressions)
+	} else {
+		*expressions = append(*expressions, m.expr(e))
+	}
+}
+
+func (m *estreeMarshaler) property(n Property) interface{} {
+	m.enter(n.Start, n.End)
+	defer m.exit(n.Start, n.End)
+	if n.Spread {
+		argument := m.expr(n.Value)
+		return m.object("SpreadElement", n.Start, n.End, esField{"argument", argument})
+	} else if isShorthand(n) {
+		v := n.Value.(*Var)
+		key := m.variable(v)
+		return m.object("Property", n.Start, n.End, esField{"method", false}, esField{"shorthand", true}, esField{"computed", false}, esField{"key", key}, esField{"value", key}, esField{"kind", "init"})
+	} else if n.Name == nil || n.Init != nil {
+		if method, ok := n.Value.(*MethodDecl); ok && n.Init == nil {
+			key, computed := m.propertyName(method.Name)
+			kind := "init"
+			if method.Get {
+				kind = "get"
+			} else if method.Set {
+				kind = "set"
+			}
+			value := m.method(method)
+			return m.object("Property", n.Start, n.End, esField{"method", kind == "init"}, esField{"shorthand", false}, esField{"computed", computed}, esField{"key", key}, esField{"value", value}, esField{"kind", kind})
+		}
+		return m.unsupported(n.Value)
+	}
+	key, computed := m.propertyName(*n.Name)
+	value := m.expr(n.Value)
+	return m.object("Property", n.Start, n.End, esField{"method", false}, esField{"shorthand", false}, esField{"computed", computed}, esField{"key", key}, esField{"value", value}, esField{"kind", "init"})
+}
+
+// isShorthand returns true if the property is written as an identifier reference, which is the case if its key is not set or if its key is the name of the variable and directly followed by the end of the property or by its initializer.
+func isShorthand(n Property) bool {
+	v, ok := n.Value.(*Var)
+	return ok && !n.Spread && (n.Name == nil || n.Name.IsIdent(v.Data) && (n.Init != nil || n.Name.End == n.End))
+}
+
+// isShorthandBinding returns true if the property of an object binding pattern is written as a single name binding.
+func isShorthandBinding(n BindingObjectItem) bool {
+	v, ok := n.Value.Binding.(*Var)
+	return ok && (n.Key == nil || n.Key.IsIdent(v.Data) && (n.Key.Start == n.Value.Start || n.Key.End == n.End))
+}
+
+func (m *estreeMarshaler) template(n *TemplateExpr, start, end int) esObject {
+	quasis := []interface{}{}
+	expressions := []interface{}{}
+	pos := start
+	for _, part := range n.List {
+		if part.Start != 0 || part.End != 0 {
+			pos = part.Start
+		}
+		raw := part.Value[1 : len(part.Value)-2]
+		quasis = append(quasis, m.templateElement(part.Value, raw, pos+1, false))
+		m.pos = pos + len(part.Value)
+		expressions = append(expressions, m.expr(part.Expr))
+		pos = m.pos
+	}
+	raw := n.Tail[1 : len(n.Tail)-1]
+	quasis = append(quasis, m.templateElement(n.Tail, raw, end-len(n.Tail)+1, true))
+	return m.object("TemplateLiteral", start, end, esField{"expressions", expressions}, esField{"quasis", quasis})
+}
+
+func (m *estreeMarshaler) templateElement(b, raw []byte, start int, tail bool) esObject {
+	var cooked interface{}
+	if s, err := DecodeTemplate(b); err == nil {
+		cooked = string(s)
+	}
+	value := esObject{{"raw", string(raw)}, {"cooked", cooked}}
+	return m.object("TemplateElement", start, start+len(raw), esField{"value", value}, esField{"tail", tail})
+}
+
+func (m *estreeMarshaler) literal(n *LiteralExpr, start, end int) interface{} {
+	raw := string(n.Data)
+	switch n.TokenType {
+	case ThisToken:
+		return m.object("ThisExpression", start, end)
+	case SuperToken:
+		return m.object("Super", start, end)
+	case NullToken:
+		return m.object("Literal", start, end, esField{"value", nil}, esField{"raw", raw})
+	case TrueToken, FalseToken:
+		return m.object("Literal", start, end, esField{"value", n.TokenType == TrueToken}, esField{"raw", raw})
+	case StringToken:
+		return m.stringLiteral(n.Data, start, end)
+	case DecimalToken, BinaryToken, OctalToken, HexadecimalToken:
+		var value interface{}
+		if v, ok := Eval(n); ok && !math.IsInf(v.Number, 0) && !math.IsNaN(v.Number) {
+			value = v.Number
+		}
+		return m.object("Literal", start, end, esField{"value", value}, esField{"raw", raw})
+	case BigIntToken:
+		return m.object("Literal", start, end, esField{"value", nil}, esField{"raw", raw}, esField{"bigint", raw[:len(raw)-1]})
+	case RegExpToken:
+		i := bytes.LastIndexByte(n.Data, '/')
+		regex := esObject{{"pattern", raw[1:i]}, {"flags", raw[i+1:]}}
+		return m.object("Literal", start, end, esField{"value", nil}, esField{"raw", raw}, esField{"regex", regex})
+	case PrivateIdentifierToken:
+		return m.object("PrivateIdentifier", start, end, esField{"name", raw[1:]})
+	case ImportToken, ErrorToken:
+		return m.unsupported(n)
+	}
+	// property names and keywords used as identifiers
+	return m.object("Identifier", start, end, esField{"name", string(DecodeIdentifier(n.Data))})
+}
+
+////////////////////////////////////////////////////////////////
+
+// UnmarshalESTree converts ESTree JSON to an AST so that it can be printed. The spans of the nodes are set from their range or start and end properties, which are converted from UTF-16 code units to bytes when the source code is given. Literal values take precedence over their raw property if they differ. Variables are not shared between occurrences, and the scopes and comments of the AST are not set.
+func UnmarshalESTree(b []byte, o ESTreeOptions) (*AST, error) {
+	var program map[string]interface{}
+	if err := json.Unmarshal(b, &program); err != nil {
+		return nil, err
+	}
+	u := &estreeUnmarshaler{}
+	if o.Source != nil {
+		u.lines = newESTreeLines(o.Source)
+	}
+	if typ, _ := program["type"].(string); typ != "Program" {
+		return nil, fmt.Errorf("invalid ESTree: expected Program instead of %s", typ)
+	}
+
+	ast := &AST{}
+	ast.BlockStmt.List = u.stmts(program, "body")
+	ast.BlockStmt.Span = u.span(program)
+	if u.err != nil {
+		return nil, u.err
+	}
+	return ast, nil
+}
+
+type estreeUnmarshaler struct {
+	lines *estreeLines
+	err   error
+}
+
+func (u *estreeUnmarshaler) fail(format string, args ...interface{}) {
+	if u.err == nil {
+		u.err = fmt.Errorf("invalid ESTree: "+format, args...)
+	}
+}
+
+func (u *estreeUnmarshaler) unexpected(obj map[string]interface{}) {
+	typ, _ := obj["type"].(string)
+	u.fail("unexpected %s", typ)
+}
+
+// span returns the span of a node from its range, or from its start and end.
+func (u *estreeUnmarshaler) span(obj map[string]interface{}) Span {
+	var start, end float64
+	if r, ok := obj["range"].([]interface{}); ok && len(r) == 2 {
+		start, _ = r[0].(float64)
+		end, _ = r[1].(float64)
+	} else {
+		start, _ = obj["start"].(float64)
+		end, _ = obj["end"].(float64)
+	}
+	if u.lines != nil {
+		return Span{u.lines.offset(int(start)), u.lines.offset(int(end))}
+	}
+	return Span{int(start), int(end)}
+}
+
+func estreeType(obj map[string]interface{}) string {
+	typ, _ := obj["type"].(string)
+	return typ
+}
+
+func estreeString(obj map[string]interface{}, key string) string {
+	s, _ := obj[key].(string)
+	return s
+}
+
+func estreeBool(obj map[string]interface{}, key string) bool {
+	b, _ := obj[key].(bool)
+	return b
+}
+
+// child returns the node of a property, or nil if it is null. It fails if the node is required.
+func (u *estreeUnmarshaler) child(obj map[string]interface{}, key string, required bool) map[string]interface{} {
+	child, ok := obj[key].(map[string]interface{})
+	if !ok && required {
+		u.fail("%s has no %s", estreeType(obj), key)
+	}
+	return child
+}
+
+// list returns the nodes of a property, where null items are nil.
+func (u *estreeUnmarshaler) list(obj map[string]interface{}, key string) []map[string]interface{} {
+	items, ok := obj[key].([]interface{})
+	if !ok {
+		u.fail("%s has no %s", estreeType(obj), key)
+		return nil
+	}
+	list := make([]map[string]interface{}, len(items))
+	for i, item := range items {
+		list[i], _ = item.(map[string]interface{})
+	}
+	return list
+}
+
+func (u *estreeUnmarshaler) stmts(obj map[string]interface{}, key string) []IStmt {
+	list := []IStmt{}
+	for _, item := range u.list(obj, key) {
+		if stmt := u.stmt(item); stmt != nil {
+			list = append(list, stmt)
+		}
+	}
+	return list
+}
+
+func (u *estreeUnmarshaler) optStmt(obj map[string]interface{}, key string) IStmt {
+	if child := u.child(obj, key, false); child != nil {
+		return u.stmt(child)
+	}
+	return nil
+}
+
+func (u *estreeUnmarshaler) block(obj map[string]interface{}) *BlockStmt {
+	if obj == nil {
+		u.fail("missing block statement")
+		return &BlockStmt{}
+	}
+	stmt := u.stmt(obj)
+	if block, ok := stmt.(*BlockStmt); ok {
+		return block
+	} else if stmt == nil {
+		return &BlockStmt{}
+	}
+	start, end := stmt.Offsets()
+	return &BlockStmt{List: []IStmt{stmt}, Span: Span{start, end}}
+}
+
+func (u *estreeUnmarshaler) stmt(obj map[string]interface{}) IStmt {
+	if obj == nil {
+		u.fail("missing statement")
+		return nil
+	}
+	span := u.span(obj)
+	switch estreeType(obj) {
+	case "BlockStatement":
+		return &BlockStmt{List: u.stmts(obj, "body"), Span: span}
+	case "EmptyStatement":
+		return &EmptyStmt{Span: span}
+	case "ExpressionStatement":
+		if directive, ok := obj["directive"].(string); ok {
+			value := []byte(estreeString(u.child(obj, "expression", true), "raw"))
+			if len(value) == 0 {
+				value = EncodeString([]byte(directive))
+			}
+			return &DirectivePrologueStmt{Value: value, Span: span}
+		}
+		return &ExprStmt{Value: u.expr(u.child(obj, "expression", true)), Span: span}
+	case "IfStatement":
+		return &IfStmt{Cond: u.expr(u.child(obj, "test", true)), Body: u.stmt(u.child(obj, "consequent", true)), Else: u.optStmt(obj, "alternate"), Span: span}
+	case "DoWhileStatement":
+		return &DoWhileStmt{Cond: u.expr(u.child(obj, "test", true)), Body: u.stmt(u.child(obj, "body", true)), Span: span}
+	case "WhileStatement":
+		return &WhileStmt{Cond: u.expr(u.child(obj, "test", true)), Body: u.stmt(u.child(obj, "body", true)), Span: span}
+	case "ForStatement":
+		var init IExpr
+		if child := u.child(obj, "init", false); child != nil && estreeType(child) == "VariableDeclaration" {
+			init = u.varDecl(child)
+		} else if child != nil {
+			init = u.expr(child)
+		}
+		return &ForStmt{Init: init, Cond: u.optExpr(obj, "test"), Post: u.optExpr(obj, "update"), Body: u.block(u.child(obj, "body", true)), Span: span}
+	case "ForInStatement":
+		return &ForInStmt{Init: u.forLeft(u.child(obj, "left", true)), Value: u.expr(u.child(obj, "right", true)), Body: u.block(u.child(obj, "body", true)), Span: span}
+	case "ForOfStatement":
+		return &ForOfStmt{Await: estreeBool(obj, "await"), Init: u.forLeft(u.child(obj, "left", true)), Value: u.expr(u.child(obj, "right", true)), Body: u.block(u.child(obj, "body", true)), Span: span}
+	case "SwitchStatement":
+		stmt := &SwitchStmt{Init: u.expr(u.child(obj, "discriminant", true)), Span: span}
+		for _, item := range u.list(obj, "cases") {
+			if item == nil {
+				u.fail("missing switch case")
+				continue
+			}
+			clause := CaseClause{TokenType: CaseToken, Cond: u.optExpr(item, "test"), List: u.stmts(item, "consequent"), Span: u.span(item)}
+			if clause.Cond == nil {
+				clause.TokenType = DefaultToken
+			}
+			stmt.List = append(stmt.List, clause)
+		}
+		return stmt
+	case "BreakStatement", "ContinueStatement":
+		stmt := &BranchStmt{Type: BreakToken, Span: span}
+		if estreeType(obj) == "ContinueStatement" {
+			stmt.Type = ContinueToken
+		}
+		if label := u.child(obj, "label", false); label != nil {
+			stmt.Label = []byte(estreeString(label, "name"))
+		}
+		return stmt
+	case "ReturnStatement":
+		return &ReturnStmt{Value: u.optExpr(obj, "argument"), Span: span}
+	case "WithStatement":
+		return &WithStmt{Cond: u.expr(u.child(obj, "object", true)), Body: u.stmt(u.child(obj, "body", true)), Span: span}
+	case "LabeledStatement":
+		label := estreeString(u.child(obj, "label", true), "name")
+		return &LabelledStmt{Label: []byte(label), Value: u.stmt(u.child(obj, "body", true)), Span: span}
+	case "ThrowStatement":
+		return &ThrowStmt{Value: u.expr(u.child(obj, "argument", true)), Span: span}
+	case "TryStatement":
+		stmt := &TryStmt{Body: u.block(u.child(obj, "block", true)), Span: span}
+		if handler := u.child(obj, "handler", false); handler != nil {
+			if param := u.child(handler, "param", false); param != nil {
+				stmt.Binding = u.binding(param, CatchDecl)
+			}
+			stmt.Catch = u.block(u.child(handler, "body", true))
+		}
+		if finalizer := u.child(obj, "finalizer", false); finalizer != nil {
+			stmt.Finally = u.block(finalizer)
+		}
+		return stmt
+	case "DebuggerStatement":
+		return &DebuggerStmt{Span: span}
+	case "ImportDeclaration":
+		return u.importDecl(obj, span)
+	case "ExportNamedDeclaration", "ExportDefaultDeclaration", "ExportAllDeclaration":
+		return u.exportDecl(obj, span)
+	case "VariableDeclaration":
+		return u.varDecl(obj)
+	case "FunctionDeclaration":
+		return u.function(obj, FunctionDecl)
+	case "ClassDeclaration":
+		return u.class(obj, LexicalDecl)
+	}
+	u.unexpected(obj)
+	return nil
+}
+
+func (u *estreeUnmarshaler) forLeft(obj map[string]interface{}) IExpr {
+	if estreeType(obj) == "VariableDeclaration" {
+		return u.varDecl(obj)
+	}
+	return u.target(obj)
+}
+
+func (u *estreeUnmarshaler) varDecl(obj map[string]interface{}) *VarDecl {
+	decl := &VarDecl{TokenType: VarToken, Span: u.span(obj)}
+	declType := VariableDecl
+	switch estreeString(obj, "kind") {
+	case "let":
+		decl.TokenType, declType = LetToken, LexicalDecl
+	case "const":
+		decl.TokenType, declType = ConstToken, LexicalDecl
+	case "using":
+		decl.TokenType, declType = UsingToken, LexicalDecl
+	case "await using":
+		decl.TokenType, declType = UsingToken, LexicalDecl
+		decl.Await = true
+	}
+	for _, item := range u.list(obj, "declarations") {
+		if item == nil {
+			u.fail("missing variable declarator")
+			continue
+		}
+		decl.List = append(decl.List, BindingElement{Binding: u.binding(u.child(item, "id", true), declType), Default: u.optExpr(item, "init"), Span: u.span(item)})
+	}
+	return decl
+}
+
+// moduleExportName returns an identifier or a string literal including its quotes.
+func (u *estreeUnmarshaler) moduleExportName(obj map[string]interface{}) []byte {
+	if obj == nil {
+		u.fail("missing name")
+		return nil
+	} else if estreeType(obj) == "Literal" {
+		return u.stringLiteral(obj)
+	}
+	return []byte(estreeString(obj, "name"))
+}
+
+func (u *estreeUnmarshaler) stringLiteral(obj map[string]interface{}) []byte {
+	value, ok := obj["value"].(string)
+	if !ok {
+		u.fail("expected string literal")
+		return nil
+	}
+	raw := []byte(estreeString(obj, "raw"))
+	if s, err := DecodeString(raw); err == nil && string(s) == value {
+		return raw
+	}
+	return EncodeString([]byte(value))
+}
+
+// importAttributes returns the import attributes of an import or export declaration, which may be omitted.
+func (u *estreeUnmarshaler) importAttributes(obj map[string]interface{}) []ImportAttribute {
+	if obj["attributes"] == nil {
+		return nil
+	}
+	var attributes []ImportAttribute
+	for _, item := range u.list(obj, "attributes") {
+		if item == nil {
+			u.fail("missing import attribute")
+			continue
+		}
+		key := u.moduleExportName(u.child(item, "key", true))
+		value := u.stringLiteral(u.child(item, "value", true))
+		attributes = append(attributes, ImportAttribute{Key: key, Value: value, Span: u.span(item)})
+	}
+	return attributes
+}
+
+func (u *estreeUnmarshaler) importDecl(obj map[string]interface{}, span Span) IStmt {
+	stmt := &ImportStmt{Module: u.stringLiteral(u.child(obj, "source", true)), Attributes: u.importAttributes(obj), Span: span}
+	for _, item := range u.list(obj, "specifiers") {
+		if item == nil {
+			u.fail("missing import specifier")
+			continue
+		}
+		local := []byte(estreeString(u.child(item, "local", true), "name"))
+		switch estreeType(item) {
+		case "ImportDefaultSpecifier":
+			stmt.Default = local
+		case "ImportNamespaceSpecifier":
+			stmt.List = append(stmt.List, Alias{Name: []byte("*"), Binding: local, Span: u.span(item)})
+		case "ImportSpecifier":
+			alias := Alias{Binding: local, Span: u.span(item)}
+			if imported := u.moduleExportName(u.child(item, "imported", true)); !bytes.Equal(imported, local) {
+				alias.Name = imported
+			}
+			stmt.List = append(stmt.List, alias)
+		default:
+			u.unexpected(item)
+		}
+	}
+	return stmt
+}
+
+func (u *estreeUnmarshaler) exportDecl(obj map[string]interface{}, span Span) IStmt {
+	stmt := &ExportStmt{Span: span}
+	if source := u.child(obj, "source", false); source != nil {
+		stmt.Module = u.stringLiteral(source)
+		stmt.Attributes = u.importAttributes(obj)
+	}
+	switch estreeType(obj) {
+	case "ExportAllDeclaration":
+		if exported := u.child(obj, "exported", false); exported != nil {
+			stmt.List = []Alias{{Name: []byte("*"), Binding: u.moduleExportName(exported)}}
+		} else {
+			stmt.List = []Alias{{Binding: []byte("*")}}
+		}
+	case "ExportDefaultDeclaration":
+		stmt.Default = true
+		decl := u.child(obj, "declaration", true)
+		switch estreeType(decl) {
+		case "FunctionDeclaration":
+			stmt.Decl = u.function(decl, FunctionDecl)
+		case "ClassDeclaration":
+			stmt.Decl = u.class(decl, LexicalDecl)
+		default:
+			stmt.Decl = u.expr(decl)
+		}
+	default:
+		if decl := u.child(obj, "declaration", false); decl != nil {
+			if s, ok := u.stmt(decl).(IExpr); ok {
+				stmt.Decl = s
+			} else {
+				u.unexpected(decl)
+			}
+			return stmt
+		}
+		for _, item := range u.list(obj, "specifiers") {
+			if item == nil {
+				u.fail("missing export specifier")
+				continue
+			}
+			local := u.moduleExportName(u.child(item, "local", true))
+			alias := Alias{Binding: u.moduleExportName(u.child(item, "exported", true)), Span: u.span(item)}
+			if !bytes.Equal(local, alias.Binding) {
+				alias.Name = local
+			}
+			stmt.List = append(stmt.List, alias)
+		}
+	}
+	return stmt
+}
+
+func (u *estreeUnmarshaler) function(obj map[string]interface{}, decl DeclType) *FuncDecl {
+	n := &FuncDecl{Async: estreeBool(obj, "async"), Generator: estreeBool(obj, "generator"), Span: u.span(obj)}
+	if id := u.child(obj, "id", false); id != nil {
+		n.Name = u.identifier(id, decl)
+	}
+	n.Params = u.params(obj)
+	n.Body = *u.block(u.child(obj, "body", true))
+	return n
+}
+
+func (u *estreeUnmarshaler) params(obj map[string]interface{}) Params {
+	params := Params{}
+	list := u.list(obj, "params")
+	for i, item := range list {
+		if item == nil {
+			u.fail("missing parameter")
+		} else if estreeType(item) == "RestElement" && i == len(list)-1 {
+			params.Rest = u.binding(u.child(item, "argument", true), ArgumentDecl)
+		} else {
+			params.List = append(params.List, u.bindingElement(item, ArgumentDecl))
+		}
+	}
+	return params
+}
+
+func (u *estreeUnmarshaler) class(obj map[string]interface{}, decl DeclType) *ClassDecl {
+	n := &ClassDecl{Decorators: u.decorators(obj), Extends: u.optExpr(obj, "superClass"), Span: u.span(obj)}
+	if id := u.child(obj, "id", false); id != nil {
+		n.Name = u.identifier(id, decl)
+	}
+	for _, item := range u.list(u.child(obj, "body", true), "body") {
+		if item == nil {
+			u.fail("missing class element")
+			continue
+		}
+		switch estreeType(item) {
+		case "MethodDefinition":
+			method := u.method(u.child(item, "value", true), u.propertyName(item))
+			method.Decorators = u.decorators(item)
+			method.Static = estreeBool(item, "static")
+			method.Get = estreeString(item, "kind") == "get"
+			method.Set = estreeString(item, "kind") == "set"
+			method.Span = u.span(item)
+			n.Methods = append(n.Methods, method)
+		case "PropertyDefinition", "AccessorProperty":
+			n.Definitions = append(n.Definitions, FieldDefinition{Decorators: u.decorators(item), Static: estreeBool(item, "static"), Accessor: estreeType(item) == "AccessorProperty", Name: u.propertyName(item), Init: u.optExpr(item, "value"), Span: u.span(item)})
+		case "StaticBlock":
+			span := u.span(item)
+			n.Definitions = append(n.Definitions, FieldDefinition{Static: true, Body: &BlockStmt{List: u.stmts(item, "body"), Span: span}, Span: span})
+		default:
+			u.unexpected(item)
+		}
+	}
+	return n
+}
+
+// decorators returns the expressions of the decorators of a class or class element, which may be omitted.
+func (u *estreeUnmarshaler) decorators(obj map[string]interface{}) []IExpr {
+	if obj["decorators"] == nil {
+		return nil
+	}
+	var decorators []IExpr
+	for _, item := range u.list(obj, "decorators") {
+		if item == nil || estreeType(item) != "Decorator" {
+			u.fail("expected decorator")
+			continue
+		}
+		decorators = append(decorators, u.expr(u.child(item, "expression", true)))
+	}
+	return decorators
+}
+
+func (u *estreeUnmarshaler) method(obj map[string]interface{}, name PropertyName) *MethodDecl {
+	if obj == nil {
+		return &MethodDecl{Name: name}
+	}
+	return &MethodDecl{
+		Async:     estreeBool(obj, "async"),
+		Generator: estreeBool(obj, "generator"),
+		Name:      name,
+		Params:    u.params(obj),
+		Body:      *u.block(u.child(obj, "body", true)),
+	}
+}
+
+// propertyName returns the key of a property, method, or class field.
+func (u *estreeUnmarshaler) propertyName(obj map[string]interface{}) PropertyName {
+	key := u.child(obj, "key", true)
+	if key == nil {
+		return PropertyName{}
+	}
+	span := u.span(key)
+	if estreeBool(obj, "computed") {
+		return PropertyName{Computed: u.expr(key), Span: span}
+	}
+	switch estreeType(key) {
+	case "Identifier":
+		return PropertyName{Literal: LiteralExpr{TokenType: IdentifierToken, Data: []byte(estreeString(key, "name")), Span: span}, Span: span}
+	case "PrivateIdentifier":
+		return PropertyName{Literal: LiteralExpr{TokenType: PrivateIdentifierToken, Data: []byte("#" + estreeString(key, "name")), Span: span}, Span: span}
+	case "Literal":
+		if lit, ok := u.expr(key).(*LiteralExpr); ok {
+			return PropertyName{Literal: *lit, Span: span}
+		}
+	}
+	u.unexpected(key)
+	return PropertyName{}
+}
+
+func (u *estreeUnmarshaler) identifier(obj map[string]interface{}, decl DeclType) *Var {
+	if estreeType(obj) != "Identifier" {
+		u.unexpected(obj)
+	}
+	v := NewVar([]byte(estreeString(obj, "name")), decl)
+	v.Uses, v.Span = 1, u.span(obj)
+	return v
+}
+
+func (u *estreeUnmarshaler) binding(obj map[string]interface{}, decl DeclType) IBinding {
+	if obj == nil {
+		u.fail("missing binding")
+		return NewVar(nil, NoDecl)
+	}
+	span := u.span(obj)
+	switch estreeType(obj) {
+	case "Identifier":
+		return u.identifier(obj, decl)
+	case "ArrayPattern":
+		n := &BindingArray{Span: span}
+		list := u.list(obj, "elements")
+		for i, item := range list {
+			if item == nil {
+				n.List = append(n.List, BindingElement{})
+			} else if estreeType(item) == "RestElement" && i == len(list)-1 {
+				n.Rest = u.binding(u.child(item, "argument", true), decl)
+			} else {
+				n.List = append(n.List, u.bindingElement(item, decl))
+			}
+		}
+		return n
+	case "ObjectPattern":
+		n := &BindingObject{Span: span}
+		list := u.list(obj, "properties")
+		for i, item := range list {
+			if item == nil {
+				u.fail("missing property")
+			} else if estreeType(item) == "RestElement" && i == len(list)-1 {
+				n.Rest = u.identifier(u.child(item, "argument", true), decl)
+			} else {
+				value := u.bindingElement(u.child(item, "value", true), decl)
+				if estreeBool(item, "shorthand") {
+					n.List = append(n.List, BindingObjectItem{Value: value, Span: u.span(item)})
+				} else {
+					key := u.propertyName(item)
+					n.List = append(n.List, BindingObjectItem{Key: &key, Value: value, Span: u.span(item)})
+				}
+			}
+		}
+		return n
+	}
+	u.unexpected(obj)
+	return NewVar(nil, NoDecl)
+}
+
+func (u *estreeUnmarshaler) bindingElement(obj map[string]interface{}, decl DeclType) BindingElement {
+	if obj != nil && estreeType(obj) == "AssignmentPattern" {
+		return BindingElement{Binding: u.binding(u.child(obj, "left", true), decl), Default: u.expr(u.child(obj, "right", true)), Span: u.span(obj)}
+	}
+	binding := u.binding(obj, decl)
+	start, end := binding.Offsets()
+	return BindingElement{Binding: binding, Span: Span{start, end}}
+}
+
+// target returns the assignment target of a pattern, where array and object patterns are converted to array and object literals.
+func (u *estreeUnmarshaler) target(obj map[string]interface{}) IExpr {
+	if obj == nil {
+		u.fail("missing assignment target")
+		return NewVar(nil, NoDecl)
+	}
+	span := u.span(obj)
+	switch estreeType(obj) {
+	case "ArrayPattern":
+		n := &ArrayExpr{Span: span}
+		for _, item := range u.list(obj, "elements") {
+			if item == nil {
+				n.List = append(n.List, Element{})
+			} else if estreeType(item) == "RestElement" {
+				n.List = append(n.List, Element{Value: u.target(u.child(item, "argument", true)), Spread: true, Span: u.span(item)})
+			} else {
+				n.List = append(n.List, Element{Value: u.target(item), Span: u.span(item)})
+			}
+		}
+		return n
+	case "ObjectPattern":
+		n := &ObjectExpr{Span: span}
+		for _, item := range u.list(obj, "properties") {
+			if item == nil {
+				u.fail("missing property")
+			} else if estreeType(item) == "RestElement" {
+				n.List = append(n.List, Property{Spread: true, Value: u.target(u.child(item, "argument", true)), Span: u.span(item)})
+			} else if estreeBool(item, "shorthand") {
+				value := u.child(item, "value", true)
+				property := Property{Span: u.span(item)}
+				if estreeType(value) == "AssignmentPattern" {
+					property.Value = u.identifier(u.child(value, "left", true), NoDecl)
+					property.Init = u.expr(u.child(value, "right", true))
+				} else {
+					property.Value = u.identifier(value, NoDecl)
+				}
+				n.List = append(n.List, property)
+			} else {
+				key := u.propertyName(item)
+				n.List = append(n.List, Property{Name: &key, Value: u.target(u.child(item, "value", true)), Span: u.span(item)})
+			}
+		}
+		return n
+	case "AssignmentPattern":
+		return &BinaryExpr{Op: EqToken, X: u.target(u.child(obj, "left", true)), Y: u.expr(u.child(obj, "right", true)), Span: span}
+	}
+	return u.expr(obj)
+}
+
+func (u *estreeUnmarshaler) optExpr(obj map[string]interface{}, key string) IExpr {
+	if child := u.child(obj, key, false); child != nil {
+		return u.expr(child)
+	}
+	return nil
+}
+
+func (u *estreeUnmarshaler) args(obj map[string]interface{}) Args {
+	args := Args{}
+	for _, item := range u.list(obj, "arguments") {
+		if item == nil {
+			u.fail("missing argument")
+		} else if estreeType(item) == "SpreadElement" {
+			args.List = append(args.List, Arg{Value: u.expr(u.child(item, "argument", true)), Rest: true, Span: u.span(item)})
+		} else {
+			value := u.expr(item)
+			start, end := value.Offsets()
+			args.List = append(args.List, Arg{Value: value, Span: Span{start, end}})
+		}
+	}
+	return args
+}
+
+// estreeOperators maps the operators of ESTree to their token types.
+var estreeOperators = map[string]TokenType{}
+
+func init() {
+	for _, tt := range []TokenType{EqToken, EqEqToken, EqEqEqToken, NotToken, NotEqToken, NotEqEqToken, LtToken, LtEqToken, LtLtToken, LtLtEqToken, GtToken, GtEqToken, GtGtToken, GtGtEqToken, GtGtGtToken, GtGtGtEqToken, AddToken, AddEqToken, SubToken, SubEqToken, MulToken, MulEqToken, ExpToken, ExpEqToken, DivToken, DivEqToken, ModToken, ModEqToken, BitAndToken, BitOrToken, BitXorToken, BitNotToken, BitAndEqToken, BitOrEqToken, BitXorEqToken, AndToken, OrToken, NullishToken, AndEqToken, OrEqToken, NullishEqToken, InToken, InstanceofToken, TypeofToken, VoidToken, DeleteToken} {
+		estreeOperators[tt.String()] = tt
+	}
+}
+
+func (u *estreeUnmarshaler) operator(obj map[string]interface{}) TokenType {
+	operator := estreeString(obj, "operator")
+	if tt, ok := estreeOperators[operator]; ok {
+		return tt
+	}
+	u.fail("unknown operator %s", operator)
+	return ErrorToken
+}
+
+func (u *estreeUnmarshaler) expr(obj map[string]interface{}) IExpr {
+	if obj == nil {
+		u.fail("missing expression")
+		return NewVar(nil, NoDecl)
+	}
+	span := u.span(obj)
+	switch estreeType(obj) {
+	case "Identifier":
+		return u.identifier(obj, NoDecl)
+	case "PrivateIdentifier":
+		return &LiteralExpr{TokenType: PrivateIdentifierToken, Data: []byte("#" + estreeString(obj, "name")), Span: span}
+	case "Literal":
+		return u.literal(obj, span)
+	case "ThisExpression":
+		return &LiteralExpr{TokenType: ThisToken, Data: []byte("this"), Span: span}
+	case "Super":
+		return &LiteralExpr{TokenType: SuperToken, Data: []byte("super"), Span: span}
+	case "ParenthesizedExpression":
+		return &GroupExpr{X: u.expr(u.child(obj, "expression", true)), Span: span}
+	case "ChainExpression":
+		return u.expr(u.child(obj, "expression", true))
+	case "ArrayExpression":
+		n := &ArrayExpr{Span: span}
+		for _, item := range u.list(obj, "elements") {
+			if item == nil {
+				n.List = append(n.List, Element{})
+			} else if estreeType(item) == "SpreadElement" {
+				n.List = append(n.List, Element{Value: u.expr(u.child(item, "argument", true)), Spread: true, Span: u.span(item)})
+			} else {
+				n.List = append(n.List, Element{Value: u.expr(item), Span: u.span(item)})
+			}
+		}
+		return n
+	case "ObjectExpression":
+		n := &ObjectExpr{Span: span}
+		for _, item := range u.list(obj, "properties") {
+			if item == nil {
+				u.fail("missing property")
+			} else if estreeType(item) == "SpreadElement" {
+				n.List = append(n.List, Property{Spread: true, Value: u.expr(u.child(item, "argument", true)), Span: u.span(item)})
+			} else if kind := estreeString(item, "kind"); kind == "get" || kind == "set" || estreeBool(item, "method") {
+				method := u.method(u.child(item, "value", true), u.propertyName(item))
+				method.Get, method.Set = kind == "get", kind == "set"
+				method.Span = u.span(item)
+				n.List = append(n.List, Property{Value: method, Span: method.Span})
+			} else if estreeBool(item, "shorthand") {
+				n.List = append(n.List, Property{Value: u.expr(u.child(item, "value", true)), Span: u.span(item)})
+			} else {
+				key := u.propertyName(item)
+				n.List = append(n.List, Property{Name: &key, Value: u.expr(u.child(item, "value", true)), Span: u.span(item)})
+			}
+		}
+		return n
+	case "FunctionExpression":
+		return u.function(obj, ExprDecl)
+	case "ArrowFunctionExpression":
+		n := &ArrowFunc{Async: estreeBool(obj, "async"), Params: u.params(obj), Span: span}
+		if body := u.child(obj, "body", true); estreeType(body) == "BlockStatement" {
+			n.Body = *u.block(body)
+		} else if body != nil {
+			value := u.expr(body)
+			start, end := value.Offsets()
+			n.Body = BlockStmt{List: []IStmt{&ReturnStmt{Value: value, Span: Span{start, end}}}, Span: Span{start, end}}
+		}
+		return n
+	case "ClassExpression":
+		return u.class(obj, ExprDecl)
+	case "TemplateLiteral":
+		return u.template(obj, nil, span)
+	case "TaggedTemplateExpression":
+		tag := u.expr(u.child(obj, "tag", true))
+		return u.template(u.child(obj, "quasi", true), tag, span)
+	case "MemberExpression":
+		object := u.expr(u.child(obj, "object", true))
+		property := u.child(obj, "property", true)
+		var y IExpr
+		if estreeBool(obj, "computed") {
+			y = &IndexExpr{Y: u.expr(property), Prec: OpMember, Span: span}
+		} else if estreeType(property) == "PrivateIdentifier" {
+			y = &LiteralExpr{TokenType: PrivateIdentifierToken, Data: []byte("#" + estreeString(property, "name")), Span: u.span(property)}
+		} else {
+			y = &LiteralExpr{TokenType: IdentifierToken, Data: []byte(estreeString(property, "name")), Span: u.span(property)}
+		}
+		if estreeBool(obj, "optional") {
+			return &OptChainExpr{X: object, Y: y, Span: span}
+		} else if index, ok := y.(*IndexExpr); ok {
+			index.X, index.Prec = object, memberPrec(object)
+			return index
+		}
+		return &DotExpr{X: object, Y: *y.(*LiteralExpr), Prec: memberPrec(object), Span: span}
+	case "CallExpression":
+		callee := u.expr(u.child(obj, "callee", true))
+		args := u.args(obj)
+		if estreeBool(obj, "optional") {
+			return &OptChainExpr{X: callee, Y: &CallExpr{Args: args, Span: span}, Span: span}
+		}
+		return &CallExpr{X: callee, Args: args, Span: span}
+	case "ImportExpression":
+		source := u.expr(u.child(obj, "source", true))
+		start, end := source.Offsets()
+		return &CallExpr{X: &LiteralExpr{TokenType: ImportToken, Data: []byte("import")}, Args: Args{List: []Arg{{Value: source, Span: Span{start, end}}}}, Span: span}
+	case "NewExpression":
+		args := u.args(obj)
+		return &NewExpr{X: u.expr(u.child(obj, "callee", true)), Args: &args, Span: span}
+	case "MetaProperty":
+		if estreeString(u.child(obj, "meta", true), "name") == "new" {
+			return &NewTargetExpr{Span: span}
+		}
+		return &ImportMetaExpr{Span: span}
+	case "UnaryExpression":
+		op := u.operator(obj)
+		if op == AddToken {
+			op = PosToken
+		} else if op == SubToken {
+			op = NegToken
+		}
+		return &UnaryExpr{Op: op, X: u.expr(u.child(obj, "argument", true)), Span: span}
+	case "UpdateExpression":
+		op := PostIncrToken
+		if estreeString(obj, "operator") == "--" {
+			op = PostDecrToken
+		}
+		if estreeBool(obj, "prefix") {
+			op = map[TokenType]TokenType{PostIncrToken: PreIncrToken, PostDecrToken: PreDecrToken}[op]
+		}
+		return &UnaryExpr{Op: op, X: u.expr(u.child(obj, "argument", true)), Span: span}
+	case "AwaitExpression":
+		return &UnaryExpr{Op: AwaitToken, X: u.expr(u.child(obj, "argument", true)), Span: span}
+	case "BinaryExpression", "LogicalExpression":
+		return &BinaryExpr{Op: u.operator(obj), X: u.expr(u.child(obj, "left", true)), Y: u.expr(u.child(obj, "right", true)), Span: span}
+	case "AssignmentExpression":
+		return &BinaryExpr{Op: u.operator(obj), X: u.target(u.child(obj, "left", true)), Y: u.expr(u.child(obj, "right", true)), Span: span}
+	case "SequenceExpression":
+		var n IExpr
+		for _, item := range u.list(obj, "expressions") {
+			if n == nil {
+				n = u.expr(item)
+			} else {
+				n = &BinaryExpr{Op: CommaToken, X: n, Y: u.expr(item), Span: span}
+			}
+		}
+		if n == nil {
+			u.fail("empty sequence expression")
+			return NewVar(nil, NoDecl)
+		}
+		return n
+	case "ConditionalExpression":
+		return &CondExpr{Cond: u.expr(u.child(obj, "test", true)), X: u.expr(u.child(obj, "consequent", true)), Y: u.expr(u.child(obj, "alternate", true)), Span: span}
+	case "YieldExpression":
+		return &YieldExpr{Generator: estreeBool(obj, "delegate"), X: u.optExpr(obj, "argument"), Span: span}
+	}
+	u.unexpected(obj)
+	return NewVar(nil, NoDecl)
+}
+
+func (u *estreeUnmarshaler) literal(obj map[string]interface{}, span Span) IExpr {
+	raw := estreeString(obj, "raw")
+	if regex := u.child(obj, "regex", false); regex != nil {
+		data := "/" + estreeString(regex, "pattern") + "/" + estreeString(regex, "flags")
+		return &LiteralExpr{TokenType: RegExpToken, Data: []byte(data), Span: span}
+	} else if bigint, ok := obj["bigint"].(string); ok {
+		return &LiteralExpr{TokenType: BigIntToken, Data: []byte(bigint + "n"), Span: span}
+	}
+
+	switch value := obj["value"].(type) {
+	case nil:
+		return &LiteralExpr{TokenType: NullToken, Data: []byte("null"), Span: span}
+	case bool:
+		if value {
+			return &LiteralExpr{TokenType: TrueToken, Data: []byte("true"), Span: span}
+		}
+		return &LiteralExpr{TokenType: FalseToken, Data: []byte("false"), Span: span}
+	case string:
+		return &LiteralExpr{TokenType: StringToken, Data: u.stringLiteral(obj), Span: span}
+	case float64:
+		lit := &LiteralExpr{TokenType: DecimalToken, Data: []byte(raw), Span: span}
+		if 1 < len(raw) && raw[0] == '0' {
+			switch raw[1] {
+			case 'b', 'B':
+				lit.TokenType = BinaryToken
+			case 'o', 'O':
+				lit.TokenType = OctalToken
+			case 'x', 'X':
+				lit.TokenType = HexadecimalToken
+			}
+		}
+		if v, ok := Eval(lit); ok && v.Type == NumberValue && v.Number == value {
+			return lit
+		}
+		n := Value{Type: NumberValue, Number: value}.Expr()
+		setSpan(n, span)
+		return n
+	}
+	u.fail("unknown literal %s", raw)
+	return NewVar(nil, NoDecl)
+}
+
+// setSpan sets the span of a literal or of a negated literal.
+func setSpan(n IExpr, span Span) {
+	switch n := n.(type) {
+	case *LiteralExpr:
+		n.Span = span
+	case *UnaryExpr:
+		n.Span = span
+		setSpan(n.X, span)
+	}
+}
+
+func (u *estreeUnmarshaler) template(obj map[string]interface{}, tag IExpr, span Span) IExpr {
+	n := &TemplateExpr{Tag: tag, Prec: OpPrimary, Span: span}
+	if tag != nil {
+		n.Prec = memberPrec(tag)
+	}
+	if obj == nil {
+		return n
+	}
+	quasis := u.list(obj, "quasis")
+	expressions := u.list(obj, "expressions")
+	if len(quasis) != len(expressions)+1 {
+		u.fail("template literal has %d quasis and %d expressions", len(quasis), len(expressions))
+		return n
+	}
+	start := "`"
+	for i, quasi := range quasis {
+		raw := ""
+		if quasi != nil {
+			if value := u.child(quasi, "value", true); value != nil {
+				raw = estreeString(value, "raw")
+			}
+		}
+		if i == len(expressions) {
+			n.Tail = []byte(start + raw + "`")
+		} else {
+			n.List = append(n.List, TemplatePart{Value: []byte(start + raw + "${"), Expr: u.expr(expressions[i])})
+		}
+		start = "}"
+	}
+	return n
+}
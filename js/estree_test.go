@@ -0,0 +1,176 @@
+package js
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/tdewolff/parse/v2"
+	"github.com/tdewolff/test"
+)
+
+func TestMarshalESTree(t *testing.T) {
+	var tests = []struct {
+		js       string
+		expected string
+	}{
+		{"a", `{"type":"Program","start":0,"end":1,"loc":{"start":{"line":1,"column":0},"end":{"line":1,"column":1}},"range":[0,1],"body":[{"type":"ExpressionStatement","start":0,"end":1,"loc":{"start":{"line":1,"column":0},"end":{"line":1,"column":1}},"range":[0,1],"expression":{"type":"Identifier","start":0,"end":1,"loc":{"start":{"line":1,"column":0},"end":{"line":1,"column":1}},"range":[0,1],"name":"a"}}],"sourceType":"script"}`},
+		{"a.b = 1n", `{"type":"Program","start":0,"end":8,"loc":{"start":{"line":1,"column":0},"end":{"line":1,"column":8}},"range":[0,8],"body":[{"type":"ExpressionStatement","start":0,"end":8,"loc":{"start":{"line":1,"column":0},"end":{"line":1,"column":8}},"range":[0,8],"expression":{"type":"AssignmentExpression","start":0,"end":8,"loc":{"start":{"line":1,"column":0},"end":{"line":1,"column":8}},"range":[0,8],"operator":"=","left":{"type":"MemberExpression","start":0,"end":3,"loc":{"start":{"line":1,"column":0},"end":{"line":1,"column":3}},"range":[0,3],"object":{"type":"Identifier","start":0,"end":1,"loc":{"start":{"line":1,"column":0},"end":{"line":1,"column":1}},"range":[0,1],"name":"a"},"property":{"type":"Identifier","start":2,"end":3,"loc":{"start":{"line":1,"column":2},"end":{"line":1,"column":3}},"range":[2,3],"name":"b"},"computed":false,"optional":false},"right":{"type":"Literal","start":6,"end":8,"loc":{"start":{"line":1,"column":6},"end":{"line":1,"column":8}},"range":[6,8],"value":null,"raw":"1n","bigint":"1"}}}],"sourceType":"script"}`},
+		{"x => /r/g", `{"type":"Program","start":0,"end":9,"loc":{"start":{"line":1,"column":0},"end":{"line":1,"column":9}},"range":[0,9],"body":[{"type":"ExpressionStatement","start":0,"end":9,"loc":{"start":{"line":1,"column":0},"end":{"line":1,"column":9}},"range":[0,9],"expression":{"type":"ArrowFunctionExpression","start":0,"end":9,"loc":{"start":{"line":1,"column":0},"end":{"line":1,"column":9}},"range":[0,9],"id":null,"expression":true,"generator":false,"async":false,"params":[{"type":"Identifier","start":0,"end":1,"loc":{"start":{"line":1,"column":0},"end":{"line":1,"column":1}},"range":[0,1],"name":"x"}],"body":{"type":"Literal","start":5,"end":9,"loc":{"start":{"line":1,"column":5},"end":{"line":1,"column":9}},"range":[5,9],"value":null,"raw":"/r/g","regex":{"pattern":"r","flags":"g"}}}}],"sourceType":"script"}`},
+		{"a?.b()", `{"type":"Program","start":0,"end":6,"loc":{"start":{"line":1,"column":0},"end":{"line":1,"column":6}},"range":[0,6],"body":[{"type":"ExpressionStatement","start":0,"end":6,"loc":{"start":{"line":1,"column":0},"end":{"line":1,"column":6}},"range":[0,6],"expression":{"type":"ChainExpression","start":0,"end":6,"loc":{"start":{"line":1,"column":0},"end":{"line":1,"column":6}},"range":[0,6],"expression":{"type":"CallExpression","start":0,"end":6,"loc":{"start":{"line":1,"column":0},"end":{"line":1,"column":6}},"range":[0,6],"callee":{"type":"MemberExpression","start":0,"end":4,"loc":{"start":{"line":1,"column":0},"end":{"line":1,"column":4}},"range":[0,4],"object":{"type":"Identifier","start":0,"end":1,"loc":{"start":{"line":1,"column":0},"end":{"line":1,"column":1}},"range":[0,1],"name":"a"},"property":{"type":"Identifier","start":3,"end":4,"loc":{"start":{"line":1,"column":3},"end":{"line":1,"column":4}},"range":[3,4],"name":"b"},"computed":false,"optional":true},"arguments":[],"optional":false}}}],"sourceType":"script"}`},
+		{"`a${b}`", `{"type":"Program","start":0,"end":7,"loc":{"start":{"line":1,"column":0},"end":{"line":1,"column":7}},"range":[0,7],"body":[{"type":"ExpressionStatement","start":0,"end":7,"loc":{"start":{"line":1,"column":0},"end":{"line":1,"column":7}},"range":[0,7],"expression":{"type":"TemplateLiteral","start":0,"end":7,"loc":{"start":{"line":1,"column":0},"end":{"line":1,"column":7}},"range":[0,7],"expressions":[{"type":"Identifier","start":4,"end":5,"loc":{"start":{"line":1,"column":4},"end":{"line":1,"column":5}},"range":[4,5],"name":"b"}],"quasis":[{"type":"TemplateElement","start":1,"end":2,"loc":{"start":{"line":1,"column":1},"end":{"line":1,"column":2}},"range":[1,2],"value":{"raw":"a","cooked":"a"},"tail":false},{"type":"TemplateElement","start":6,"end":6,"loc":{"start":{"line":1,"column":6},"end":{"line":1,"column":6}},"range":[6,6],"value":{"raw":"","cooked":""},"tail":true}]}}],"sourceType":"script"}`},
+		{"const {c} = d", `{"type":"Program","start":0,"end":13,"loc":{"start":{"line":1,"column":0},"end":{"line":1,"column":13}},"range":[0,13],"body":[{"type":"VariableDeclaration","start":0,"end":13,"loc":{"start":{"line":1,"column":0},"end":{"line":1,"column":13}},"range":[0,13],"declarations":[{"type":"VariableDeclarator","start":6,"end":13,"loc":{"start":{"line":1,"column":6},"end":{"line":1,"column":13}},"range":[6,13],"id":{"type":"ObjectPattern","start":6,"end":9,"loc":{"start":{"line":1,"column":6},"end":{"line":1,"column":9}},"range":[6,9],"properties":[{"type":"Property","start":7,"end":8,"loc":{"start":{"line":1,"column":7},"end":{"line":1,"column":8}},"range":[7,8],"method":false,"shorthand":true,"computed":false,"key":{"type":"Identifier","start":7,"end":8,"loc":{"start":{"line":1,"column":7},"end":{"line":1,"column":8}},"range":[7,8],"name":"c"},"value":{"type":"Identifier","start":7,"end":8,"loc":{"start":{"line":1,"column":7},"end":{"line":1,"column":8}},"range":[7,8],"name":"c"},"kind":"init"}]},"init":{"type":"Identifier","start":12,"end":13,"loc":{"start":{"line":1,"column":12},"end":{"line":1,"column":13}},"range":[12,13],"name":"d"}}],"kind":"const"}],"sourceType":"script"}`},
+		{"@a class B {}", `{"type":"Program","start":0,"end":13,"loc":{"start":{"line":1,"column":0},"end":{"line":1,"column":13}},"range":[0,13],"body":[{"type":"ClassDeclaration","start":0,"end":13,"loc":{"start":{"line":1,"column":0},"end":{"line":1,"column":13}},"range":[0,13],"id":{"type":"Identifier","start":9,"end":10,"loc":{"start":{"line":1,"column":9},"end":{"line":1,"column":10}},"range":[9,10],"name":"B"},"superClass":null,"body":{"type":"ClassBody","start":11,"end":13,"loc":{"start":{"line":1,"column":11},"end":{"line":1,"column":13}},"range":[11,13],"body":[]},"decorators":[{"type":"Decorator","start":0,"end":2,"loc":{"start":{"line":1,"column":0},"end":{"line":1,"column":2}},"range":[0,2],"expression":{"type":"Identifier","start":1,"end":2,"loc":{"start":{"line":1,"column":1},"end":{"line":1,"column":2}},"range":[1,2],"name":"a"}}]}],"sourceType":"script"}`},
+		{"class B { m() {} }", `{"type":"Program","start":0,"end":18,"loc":{"start":{"line":1,"column":0},"end":{"line":1,"column":18}},"range":[0,18],"body":[{"type":"ClassDeclaration","start":0,"end":18,"loc":{"start":{"line":1,"column":0},"end":{"line":1,"column":18}},"range":[0,18],"id":{"type":"Identifier","start":6,"end":7,"loc":{"start":{"line":1,"column":6},"end":{"line":1,"column":7}},"range":[6,7],"name":"B"},"superClass":null,"body":{"type":"ClassBody","start":8,"end":18,"loc":{"start":{"line":1,"column":8},"end":{"line":1,"column":18}},"range":[8,18],"body":[{"type":"MethodDefinition","start":10,"end":16,"loc":{"start":{"line":1,"column":10},"end":{"line":1,"column":16}},"range":[10,16],"static":false,"computed":false,"key":{"type":"Identifier","start":10,"end":11,"loc":{"start":{"line":1,"column":10},"end":{"line":1,"column":11}},"range":[10,11],"name":"m"},"kind":"method","value":{"type":"FunctionExpression","start":11,"end":16,"loc":{"start":{"line":1,"column":11},"end":{"line":1,"column":16}},"range":[11,16],"id":null,"expression":false,"generator":false,"async":false,"params":[],"body":{"type":"BlockStatement","start":14,"end":16,"loc":{"start":{"line":1,"column":14},"end":{"line":1,"column":16}},"range":[14,16],"body":[]}}}]}}],"sourceType":"script"}`},
+		{"class B { accessor a }", `{"type":"Program","start":0,"end":22,"loc":{"start":{"line":1,"column":0},"end":{"line":1,"column":22}},"range":[0,22],"body":[{"type":"ClassDeclaration","start":0,"end":22,"loc":{"start":{"line":1,"column":0},"end":{"line":1,"column":22}},"range":[0,22],"id":{"type":"Identifier","start":6,"end":7,"loc":{"start":{"line":1,"column":6},"end":{"line":1,"column":7}},"range":[6,7],"name":"B"},"superClass":null,"body":{"type":"ClassBody","start":8,"end":22,"loc":{"start":{"line":1,"column":8},"end":{"line":1,"column":22}},"range":[8,22],"body":[{"type":"AccessorProperty","start":10,"end":20,"loc":{"start":{"line":1,"column":10},"end":{"line":1,"column":20}},"range":[10,20],"static":false,"computed":false,"key":{"type":"Identifier","start":19,"end":20,"loc":{"start":{"line":1,"column":19},"end":{"line":1,"column":20}},"range":[19,20],"name":"a"},"value":null}]}}],"sourceType":"script"}`},
+		{"import a from 'b'", `{"type":"Program","start":0,"end":17,"loc":{"start":{"line":1,"column":0},"end":{"line":1,"column":17}},"range":[0,17],"body":[{"type":"ImportDeclaration","start":0,"end":17,"loc":{"start":{"line":1,"column":0},"end":{"line":1,"column":17}},"range":[0,17],"specifiers":[{"type":"ImportDefaultSpecifier","start":7,"end":8,"loc":{"start":{"line":1,"column":7},"end":{"line":1,"column":8}},"range":[7,8],"local":{"type":"Identifier","start":7,"end":8,"loc":{"start":{"line":1,"column":7},"end":{"line":1,"column":8}},"range":[7,8],"name":"a"}}],"source":{"type":"Literal","start":14,"end":17,"loc":{"start":{"line":1,"column":14},"end":{"line":1,"column":17}},"range":[14,17],"value":"b","raw":"'b'"}}],"sourceType":"module"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.js, func(t *testing.T) {
+			ast, err := Parse(parse.NewInputString(tt.js))
+			test.Error(t, err)
+			b, err := MarshalESTree(ast, ESTreeOptions{Source: []byte(tt.js)})
+			test.Error(t, err)
+			test.String(t, string(b), tt.expected)
+		})
+	}
+}
+
+func TestMarshalESTreeLoc(t *testing.T) {
+	src := "'\U0001F600';\r\nfoo(a,\n  a)"
+	ast, err := Parse(parse.NewInputString(src))
+	test.Error(t, err)
+	b, err := MarshalESTree(ast, ESTreeOptions{Source: []byte(src), Module: true})
+	test.Error(t, err)
+
+	var program struct {
+		End        int    `json:"end"`
+		SourceType string `json:"sourceType"`
+		Body       []struct {
+			Range      []int `json:"range"`
+			Expression struct {
+				Arguments []struct {
+					Start int `json:"start"`
+					Loc   struct {
+						Start struct {
+							Line   int `json:"line"`
+							Column int `json:"column"`
+						} `json:"start"`
+					} `json:"loc"`
+				} `json:"arguments"`
+			} `json:"expression"`
+		} `json:"body"`
+	}
+	test.Error(t, json.Unmarshal(b, &program))
+	test.T(t, program.End, 18)
+	test.String(t, program.SourceType, "module")
+	test.T(t, program.Body[0].Range, []int{0, 5})
+	test.T(t, program.Body[1].Range, []int{7, 18})
+	args := program.Body[1].Expression.Arguments
+	test.T(t, args[0].Start, 11)
+	test.T(t, args[0].Loc.Start.Line, 2)
+	test.T(t, args[0].Loc.Start.Column, 4)
+	test.T(t, args[1].Start, 16)
+	test.T(t, args[1].Loc.Start.Line, 3)
+	test.T(t, args[1].Loc.Start.Column, 2)
+}
+
+func TestMarshalESTreeError(t *testing.T) {
+	ast, err := ParseWithOptions(parse.NewInputString("<div/>"), ParseOptions{JSX: true})
+	test.Error(t, err)
+	_, err = MarshalESTree(ast, ESTreeOptions{Source: []byte("<div/>")})
+	test.String(t, err.Error(), "unsupported node *js.JSXElement")
+
+	ast, err = Parse(parse.NewInputString("a: b"))
+	test.Error(t, err)
+	_, err = MarshalESTree(ast, ESTreeOptions{})
+	test.String(t, err.Error(), "source code is required to compute offsets")
+	_, err = MarshalESTree(ast, ESTreeOptions{Source: []byte("c: b")})
+	test.String(t, err.Error(), "cannot find a after offset 0 in source code")
+}
+
+func TestESTreeRoundTrip(t *testing.T) {
+	var tests = []struct {
+		js       string
+		expected string
+	}{
+		{"'use strict'; a = /re/g; b = 10n; c = 0x1F", "'use strict';\na = /re/g;\nb = 10n;\nc = 0x1F;"},
+		{"let {a, b: [c = 2, ...d], ...e} = f", "let {a, b: [c = 2, ...d], ...e} = f;"},
+		{"({a, b = 1} = c); [x, y] = [y, x]", "({a, b = 1} = c);\n[x, y] = [y, x];"},
+		{"o = {m() {}, get g() {}, [k]: 1, ...s, t}", "o = {m() {}, get g() {}, [k]: 1, ...s, t};"},
+		{"for (x of y) z(x); for (var i in o) ;", "for (x of y) {\n\tz(x);\n}\nfor (var i in o) {}"},
+		{"class A extends B { #x = 1; get y() { return this.#x } static *z(a, ...b) {} }", "class A extends B {\n\t#x = 1;\n\tget y() {\n\t\treturn this.#x;\n\t}\n\tstatic *z(a, ...b) {}\n}"},
+		{"a?.b.c(d)?.[e]; new X(1); `a${b}c`; tag`x`", "a?.b.c(d)?.[e];\nnew X(1);\n`a${b}c`;\ntag`x`;"},
+		{"x => x + 1; async () => { await y }", "(x) => x + 1;\nasync () => {\n\tawait y;\n};"},
+		{"import d, {a as b, c} from 'm'; export {b as e}; export * as ns from 'o'; import('p'); import.meta.url", "import d, {a as b, c} from 'm';\nexport {b as e};\nexport * as ns from 'o';\nimport('p');\nimport.meta.url;"},
+		{"switch (a) { case 1: break; default: } try {} catch ({e}) {} finally {}", "switch (a) {\n\tcase 1:\n\t\tbreak;\n\tdefault:\n}\ntry {} catch ({e}) {} finally {}"},
+		{"function* g() { yield; yield* a } x = -1; i++; --i; a ??= b; (a, b) ? c : d", "function* g() {\n\tyield;\n\tyield* a;\n}\nx = -1;\ni++;\n--i;\na ??= b;\n(a, b) ? c : d;"},
+		{"(a + b) * c; (function() {})()", "(a + b) * c;\n(function() {}());"},
+		{"'\U0001F600'; l: while (1) { continue l }", "'\U0001F600';\nl: while (1) {\n\tcontinue l;\n}"},
+		{"@a class A { static b = 1; static { c() } #d; @e.f() m(x) { return #d in x } }", "@a class A {\n\tstatic b = 1;\n\tstatic {\n\t\tc();\n\t}\n\t#d;\n\t@e.f() m(x) {\n\t\treturn #d in x;\n\t}\n}"},
+		{"class A { @a accessor b = 1; static accessor #c }", "class A {\n\t@a accessor b = 1;\n\tstatic accessor #c;\n}"},
+		{"import a from 'b' with { type: 'json' }; export {c} from 'd' with { 'e': 'f' }", "import a from 'b' with { type: 'json' };\nexport {c} from 'd' with { 'e': 'f' };"},
+		{"{ using a = b } async function f() { await using c = d }", "{\n\tusing a = b;\n}\nasync function f() {\n\tawait using c = d;\n}"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.js, func(t *testing.T) {
+			ast, err := Parse(parse.NewInputString(tt.js))
+			test.Error(t, err)
+			b, err := MarshalESTree(ast, ESTreeOptions{Source: []byte(tt.js)})
+			test.Error(t, err)
+			ast, err = UnmarshalESTree(b, ESTreeOptions{Source: []byte(tt.js)})
+			test.Error(t, err)
+
+			buf := &bytes.Buffer{}
+			Print(buf, ast, PrintOptions{})
+			test.String(t, buf.String(), tt.expected)
+		})
+	}
+}
+
+func TestUnmarshalESTree(t *testing.T) {
+	var tests = []struct {
+		estree   string
+		expected string
+	}{
+		// values take precedence over raw
+		{`{"type":"Program","body":[{"type":"ExpressionStatement","expression":{"type":"AssignmentExpression","operator":"=","left":{"type":"Identifier","name":"a"},"right":{"type":"Literal","value":"b","raw":"'a'"}}}]}`, `a = "b";`},
+		{`{"type":"Program","body":[{"type":"ExpressionStatement","expression":{"type":"Literal","value":-2}}]}`, `-2;`},
+		{`{"type":"Program","body":[{"type":"ExpressionStatement","expression":{"type":"Literal","value":255,"raw":"0xff"}}]}`, `0xff;`},
+		{`{"type":"Program","body":[{"type":"ExpressionStatement","directive":"use strict","expression":{"type":"Literal","value":"use strict"}}]}`, `"use strict";`},
+		{`{"type":"Program","body":[{"type":"ExpressionStatement","expression":{"type":"BinaryExpression","operator":"*","left":{"type":"BinaryExpression","operator":"+","left":{"type":"Identifier","name":"a"},"right":{"type":"Identifier","name":"b"}},"right":{"type":"Identifier","name":"c"}}}]}`, `(a + b) * c;`},
+		{`{"type":"Program","body":[{"type":"ExpressionStatement","expression":{"type":"SequenceExpression","expressions":[{"type":"Identifier","name":"a"},{"type":"UnaryExpression","operator":"typeof","prefix":true,"argument":{"type":"Identifier","name":"b"}},{"type":"UnaryExpression","operator":"-","prefix":true,"argument":{"type":"Identifier","name":"c"}}]}}]}`, `a, typeof b, -c;`},
+		{`{"type":"Program","body":[{"type":"ExpressionStatement","expression":{"type":"CallExpression","optional":false,"callee":{"type":"MemberExpression","computed":false,"optional":false,"object":{"type":"CallExpression","optional":false,"callee":{"type":"Identifier","name":"f"},"arguments":[]},"property":{"type":"Identifier","name":"g"}},"arguments":[{"type":"SpreadElement","argument":{"type":"Identifier","name":"x"}}]}}]}`, `f().g(...x);`},
+		{`{"type":"Program","body":[{"type":"ForStatement","init":null,"test":null,"update":null,"body":{"type":"ExpressionStatement","expression":{"type":"Identifier","name":"a"}}}]}`, "for (;;) {\n\ta;\n}"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expected, func(t *testing.T) {
+			ast, err := UnmarshalESTree([]byte(tt.estree), ESTreeOptions{})
+			test.Error(t, err)
+
+			buf := &bytes.Buffer{}
+			Print(buf, ast, PrintOptions{})
+			test.String(t, buf.String(), tt.expected)
+		})
+	}
+}
+
+func TestUnmarshalESTreeError(t *testing.T) {
+	var tests = []struct {
+		estree string
+		err    string
+	}{
+		{`[]`, "json: cannot unmarshal array into Go value of type map[string]interface {}"},
+		{`{"type":"Identifier"}`, "invalid ESTree: expected Program instead of Identifier"},
+		{`{"type":"Program","body":[{"type":"JSXElement"}]}`, "invalid ESTree: unexpected JSXElement"},
+		{`{"type":"Program","body":[{"type":"ReturnStatement","argument":{"type":"BinaryExpression","operator":"<=>"}}]}`, "invalid ESTree: unknown operator <=>"},
+		{`{"type":"Program"}`, "invalid ESTree: Program has no body"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.estree, func(t *testing.T) {
+			_, err := UnmarshalESTree([]byte(tt.estree), ESTreeOptions{})
+			test.That(t, err != nil)
+			test.String(t, err.Error(), tt.err)
+		})
+	}
+}
@@ -0,0 +1,676 @@
+package js
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// ValueType is the type of a Value.
+type ValueType int
+
+// Value types.
+const (
+	UndefinedValue ValueType = iota
+	NullValue
+	BooleanValue
+	NumberValue
+	StringValue
+)
+
+// String returns the string of the value type as returned by typeof.
+func (t ValueType) String() string {
+	switch t {
+	case UndefinedValue:
+		return "undefined"
+	case NullValue:
+		return "object"
+	case BooleanValue:
+		return "boolean"
+	case NumberValue:
+		return "number"
+	case StringValue:
+		return "string"
+	}
+	return "Invalid(" + strconv.Itoa(int(t)) + ")"
+}
+
+// Value is the primitive value of a constant expression. Strings are encoded in UTF-8, where lone surrogates are encoded as in DecodeString.
+type Value struct {
+	Type   ValueType
+	Bool   bool
+	Number float64
+	Str    string
+}
+
+func (v Value) String() string {
+	return v.Expr().JS()
+}
+
+// Truthy returns true if the value converts to true.
+func (v Value) Truthy() bool {
+	switch v.Type {
+	case BooleanValue:
+		return v.Bool
+	case NumberValue:
+		return v.Number != 0 && !math.IsNaN(v.Number)
+	case StringValue:
+		return v.Str != ""
+	}
+	return false
+}
+
+// ToNumber returns the value converted to a number.
+func (v Value) ToNumber() float64 {
+	switch v.Type {
+	case UndefinedValue:
+		return math.NaN()
+	case BooleanValue:
+		if v.Bool {
+			return 1
+		}
+	case NumberValue:
+		return v.Number
+	case StringValue:
+		return stringToNumber(v.Str)
+	}
+	return 0
+}
+
+// ToString returns the value converted to a string.
+func (v Value) ToString() string {
+	switch v.Type {
+	case UndefinedValue:
+		return "undefined"
+	case NullValue:
+		return "null"
+	case BooleanValue:
+		if v.Bool {
+			return "true"
+		}
+		return "false"
+	case NumberValue:
+		return numberToString(v.Number)
+	}
+	return v.Str
+}
+
+// Expr returns an expression that evaluates to the value. Negative numbers, NaN, infinities, and undefined are expressed with operators.
+func (v Value) Expr() IExpr {
+	switch v.Type {
+	case UndefinedValue:
+		return &UnaryExpr{Op: VoidToken, X: &LiteralExpr{TokenType: DecimalToken, Data: []byte("0")}}
+	case NullValue:
+		return &LiteralExpr{TokenType: NullToken, Data: []byte("null")}
+	case BooleanValue:
+		if v.Bool {
+			return &LiteralExpr{TokenType: TrueToken, Data: []byte("true")}
+		}
+		return &LiteralExpr{TokenType: FalseToken, Data: []byte("false")}
+	case NumberValue:
+		if math.IsNaN(v.Number) {
+			return &GroupExpr{X: &BinaryExpr{Op: DivToken, X: &LiteralExpr{TokenType: DecimalToken, Data: []byte("0")}, Y: &LiteralExpr{TokenType: DecimalToken, Data: []byte("0")}}}
+		} else if math.IsInf(v.Number, 0) {
+			x := IExpr(&LiteralExpr{TokenType: DecimalToken, Data: []byte("1")})
+			if v.Number < 0 {
+				x = &UnaryExpr{Op: NegToken, X: x}
+			}
+			return &GroupExpr{X: &BinaryExpr{Op: DivToken, X: x, Y: &LiteralExpr{TokenType: DecimalToken, Data: []byte("0")}}}
+		} else if v.Number < 0 || v.Number == 0 && math.Signbit(v.Number) {
+			return &UnaryExpr{Op: NegToken, X: &LiteralExpr{TokenType: DecimalToken, Data: []byte(numberToString(-v.Number))}}
+		}
+		return &LiteralExpr{TokenType: DecimalToken, Data: []byte(numberToString(v.Number))}
+	}
+	return &LiteralExpr{TokenType: StringToken, Data: EncodeString([]byte(v.Str))}
+}
+
+// Eval evaluates a constant expression and returns its value. Constant expressions are literals (except for regular expressions and BigInts), the undeclared variables undefined, NaN, and Infinity, template literals without tags whose expressions are constant, and the unary, arithmetic, bitwise, relational, equality, logical, and conditional operators applied to constant expressions. Logical and conditional operators only require the operands that are evaluated to be constant. It returns false if the expression is not constant.
+func Eval(e IExpr) (Value, bool) {
+	return evalExpr(e, nil)
+}
+
+// EvalOptions are the options for EvalWithOptions and FoldWithOptions.
+type EvalOptions struct {
+	Defines map[string]Value // values of undeclared variables and their properties by name, such as "DEBUG" or "process.env.NODE_ENV"
+}
+
+// EvalWithOptions evaluates a constant expression as Eval, where the variables and properties of EvalOptions.Defines are constant as well.
+func EvalWithOptions(e IExpr, o EvalOptions) (Value, bool) {
+	return evalExpr(e, o.Defines)
+}
+
+func evalExpr(e IExpr, defines map[string]Value) (Value, bool) {
+	if 0 < len(defines) {
+		if name, ok := definedName(e); ok {
+			if v, ok := defines[name]; ok {
+				return v, true
+			}
+		}
+	}
+	switch e := e.(type) {
+	case *LiteralExpr:
+		switch e.TokenType {
+		case NullToken:
+			return Value{Type: NullValue}, true
+		case TrueToken, FalseToken:
+			return Value{Type: BooleanValue, Bool: e.TokenType == TrueToken}, true
+		case DecimalToken:
+			if f, err := strconv.ParseFloat(string(e.Data), 64); err == nil || isRangeError(err) {
+				return Value{Type: NumberValue, Number: f}, true
+			}
+		case BinaryToken, OctalToken, HexadecimalToken:
+			if f, ok := parseIntegerLiteral(e.Data); ok {
+				return Value{Type: NumberValue, Number: f}, true
+			}
+		case StringToken:
+			if s, err := DecodeString(e.Data); err == nil {
+				return Value{Type: StringValue, Str: string(s)}, true
+			}
+		}
+	case *Var:
+		if v := resolveVar(e); v.Decl == NoDecl {
+			switch string(v.Data) {
+			case "undefined":
+				return Value{Type: UndefinedValue}, true
+			case "NaN":
+				return Value{Type: NumberValue, Number: math.NaN()}, true
+			case "Infinity":
+				return Value{Type: NumberValue, Number: math.Inf(1)}, true
+			}
+		}
+	case *GroupExpr:
+		return evalExpr(e.X, defines)
+	case *TemplateExpr:
+		if e.Tag != nil {
+			break
+		}
+		sb := strings.Builder{}
+		for _, item := range e.List {
+			s, err := DecodeTemplate(item.Value)
+			if err != nil {
+				return Value{}, false
+			}
+			v, ok := evalExpr(item.Expr, defines)
+			if !ok {
+				return Value{}, false
+			}
+			sb.Write(s)
+			sb.WriteString(v.ToString())
+		}
+		s, err := DecodeTemplate(e.Tail)
+		if err != nil {
+			return Value{}, false
+		}
+		sb.Write(s)
+		return Value{Type: StringValue, Str: sb.String()}, true
+	case *UnaryExpr:
+		x, ok := evalExpr(e.X, defines)
+		if !ok {
+			break
+		}
+		switch e.Op {
+		case NotToken:
+			return Value{Type: BooleanValue, Bool: !x.Truthy()}, true
+		case VoidToken:
+			return Value{Type: UndefinedValue}, true
+		case TypeofToken:
+			return Value{Type: StringValue, Str: x.Type.String()}, true
+		case PosToken:
+			return Value{Type: NumberValue, Number: x.ToNumber()}, true
+		case NegToken:
+			return Value{Type: NumberValue, Number: -x.ToNumber()}, true
+		case BitNotToken:
+			return Value{Type: NumberValue, Number: float64(^toInt32(x.ToNumber()))}, true
+		}
+	case *CondExpr:
+		if cond, ok := evalExpr(e.Cond, defines); !ok {
+			break
+		} else if cond.Truthy() {
+			return evalExpr(e.X, defines)
+		}
+		return evalExpr(e.Y, defines)
+	case *BinaryExpr:
+		x, ok := evalExpr(e.X, defines)
+		if !ok {
+			break
+		}
+		switch e.Op {
+		case AndToken:
+			if !x.Truthy() {
+				return x, true
+			}
+			return evalExpr(e.Y, defines)
+		case OrToken:
+			if x.Truthy() {
+				return x, true
+			}
+			return evalExpr(e.Y, defines)
+		case NullishToken:
+			if x.Type != UndefinedValue && x.Type != NullValue {
+				return x, true
+			}
+			return evalExpr(e.Y, defines)
+		}
+		y, ok := evalExpr(e.Y, defines)
+		if !ok {
+			break
+		}
+		return evalBinary(e.Op, x, y)
+	}
+	return Value{}, false
+}
+
+// definedName returns the name of an undeclared variable or of a chain of properties of an undeclared variable, such as process.env.NODE_ENV.
+func definedName(e IExpr) (string, bool) {
+	switch e := e.(type) {
+	case *Var:
+		if v := resolveVar(e); v.Decl == NoDecl {
+			return string(v.Data), true
+		}
+	case *DotExpr:
+		if x, ok := definedName(e.X); ok {
+			return x + "." + string(e.Y.Data), true
+		}
+	}
+	return "", false
+}
+
+func evalBinary(op TokenType, x, y Value) (Value, bool) {
+	switch op {
+	case AddToken:
+		if x.Type == StringValue || y.Type == StringValue {
+			return Value{Type: StringValue, Str: x.ToString() + y.ToString()}, true
+		}
+		return Value{Type: NumberValue, Number: x.ToNumber() + y.ToNumber()}, true
+	case SubToken:
+		return Value{Type: NumberValue, Number: x.ToNumber() - y.ToNumber()}, true
+	case MulToken:
+		return Value{Type: NumberValue, Number: x.ToNumber() * y.ToNumber()}, true
+	case DivToken:
+		return Value{Type: NumberValue, Number: x.ToNumber() / y.ToNumber()}, true
+	case ModToken:
+		return Value{Type: NumberValue, Number: math.Mod(x.ToNumber(), y.ToNumber())}, true
+	case ExpToken:
+		base, exp := x.ToNumber(), y.ToNumber()
+		if math.IsNaN(exp) || math.Abs(base) == 1 && math.IsInf(exp, 0) {
+			return Value{Type: NumberValue, Number: math.NaN()}, true
+		}
+		return Value{Type: NumberValue, Number: math.Pow(base, exp)}, true
+	case BitAndToken:
+		return Value{Type: NumberValue, Number: float64(toInt32(x.ToNumber()) & toInt32(y.ToNumber()))}, true
+	case BitOrToken:
+		return Value{Type: NumberValue, Number: float64(toInt32(x.ToNumber()) | toInt32(y.ToNumber()))}, true
+	case BitXorToken:
+		return Value{Type: NumberValue, Number: float64(toInt32(x.ToNumber()) ^ toInt32(y.ToNumber()))}, true
+	case LtLtToken:
+		return Value{Type: NumberValue, Number: float64(toInt32(x.ToNumber()) << (toUint32(y.ToNumber()) & 31))}, true
+	case GtGtToken:
+		return Value{Type: NumberValue, Number: float64(toInt32(x.ToNumber()) >> (toUint32(y.ToNumber()) & 31))}, true
+	case GtGtGtToken:
+		return Value{Type: NumberValue, Number: float64(toUint32(x.ToNumber()) >> (toUint32(y.ToNumber()) & 31))}, true
+	case EqEqEqToken, NotEqEqToken:
+		return Value{Type: BooleanValue, Bool: strictEquals(x, y) == (op == EqEqEqToken)}, true
+	case EqEqToken, NotEqToken:
+		return Value{Type: BooleanValue, Bool: looseEquals(x, y) == (op == EqEqToken)}, true
+	case LtToken:
+		return Value{Type: BooleanValue, Bool: lessThan(x, y) == 1}, true
+	case GtToken:
+		return Value{Type: BooleanValue, Bool: lessThan(y, x) == 1}, true
+	case LtEqToken:
+		return Value{Type: BooleanValue, Bool: lessThan(y, x) == 0}, true
+	case GtEqToken:
+		return Value{Type: BooleanValue, Bool: lessThan(x, y) == 0}, true
+	}
+	return Value{}, false
+}
+
+func strictEquals(x, y Value) bool {
+	if x.Type != y.Type {
+		return false
+	}
+	switch x.Type {
+	case BooleanValue:
+		return x.Bool == y.Bool
+	case NumberValue:
+		return x.Number == y.Number
+	case StringValue:
+		return x.Str == y.Str
+	}
+	return true
+}
+
+func looseEquals(x, y Value) bool {
+	if x.Type == y.Type {
+		return strictEquals(x, y)
+	}
+	xNullish := x.Type == UndefinedValue || x.Type == NullValue
+	yNullish := y.Type == UndefinedValue || y.Type == NullValue
+	if xNullish || yNullish {
+		return xNullish && yNullish
+	}
+	return x.ToNumber() == y.ToNumber()
+}
+
+// lessThan returns 1 if x < y, 0 if not, and -1 if the comparison is undefined because of NaN.
+func lessThan(x, y Value) int {
+	if x.Type == StringValue && y.Type == StringValue {
+		a, b := toUTF16(x.Str), toUTF16(y.Str)
+		for i := 0; i < len(a) && i < len(b); i++ {
+			if a[i] != b[i] {
+				return boolToInt(a[i] < b[i])
+			}
+		}
+		return boolToInt(len(a) < len(b))
+	}
+	a, b := x.ToNumber(), y.ToNumber()
+	if math.IsNaN(a) || math.IsNaN(b) {
+		return -1
+	}
+	return boolToInt(a < b)
+}
+
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// toUTF16 returns the UTF-16 code units of a string, where lone surrogates are encoded as in DecodeString.
+func toUTF16(s string) []uint16 {
+	units := make([]uint16, 0, len(s))
+	for i := 0; i < len(s); {
+		if i+2 < len(s) && s[i] == 0xED && 0xA0 <= s[i+1] && s[i+1] <= 0xBF {
+			units = append(units, 0xD000|uint16(s[i+1]&0x3F)<<6|uint16(s[i+2]&0x3F))
+			i += 3
+			continue
+		}
+		r, n := utf8.DecodeRuneInString(s[i:])
+		if r1, r2 := utf16.EncodeRune(r); r1 != unicode.ReplacementChar {
+			units = append(units, uint16(r1), uint16(r2))
+		} else {
+			units = append(units, uint16(r))
+		}
+		i += n
+	}
+	return units
+}
+
+func toInt32(f float64) int32 {
+	return int32(toUint32(f))
+}
+
+func toUint32(f float64) uint32 {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return 0
+	}
+	f = math.Mod(math.Trunc(f), 1<<32)
+	if f < 0 {
+		f += 1 << 32
+	}
+	return uint32(f)
+}
+
+func isRangeError(err error) bool {
+	numErr, ok := err.(*strconv.NumError)
+	return ok && numErr.Err == strconv.ErrRange
+}
+
+// parseIntegerLiteral parses a binary, octal, or hexadecimal literal including its prefix, which may be larger than 64 bits.
+func parseIntegerLiteral(b []byte) (float64, bool) {
+	if len(b) < 3 || b[0] != '0' {
+		return 0, false
+	}
+	base := 0.0
+	switch b[1] {
+	case 'b', 'B':
+		base = 2
+	case 'o', 'O':
+		base = 8
+	case 'x', 'X':
+		base = 16
+	default:
+		return 0, false
+	}
+	f := 0.0
+	for _, c := range b[2:] {
+		d := 0.0
+		if '0' <= c && c <= '9' {
+			d = float64(c - '0')
+		} else if 'a' <= c && c <= 'f' {
+			d = float64(c - 'a' + 10)
+		} else if 'A' <= c && c <= 'F' {
+			d = float64(c - 'A' + 10)
+		} else {
+			return 0, false
+		}
+		if base <= d {
+			return 0, false
+		}
+		f = f*base + d
+	}
+	return f, true
+}
+
+// stringToNumber converts a string to a number, which is NaN if it is not a valid numeric string.
+func stringToNumber(s string) float64 {
+	s = strings.TrimFunc(s, func(r rune) bool {
+		return unicode.IsSpace(r) || r == '\uFEFF'
+	})
+	if s == "" {
+		return 0
+	} else if f, ok := parseIntegerLiteral([]byte(s)); ok {
+		return f
+	}
+
+	sign := 1.0
+	t := s
+	if t[0] == '+' || t[0] == '-' {
+		if t[0] == '-' {
+			sign = -1.0
+		}
+		t = t[1:]
+	}
+	if t == "Infinity" {
+		return sign * math.Inf(1)
+	}
+
+	// only allow the decimal syntax of JS, ParseFloat also accepts for example underscores, hexadecimal floats, and inf
+	i, digits := 0, 0
+	for i < len(t) && '0' <= t[i] && t[i] <= '9' {
+		i++
+		digits++
+	}
+	if i < len(t) && t[i] == '.' {
+		i++
+		for i < len(t) && '0' <= t[i] && t[i] <= '9' {
+			i++
+			digits++
+		}
+	}
+	if digits == 0 {
+		return math.NaN()
+	}
+	if i < len(t) && (t[i] == 'e' || t[i] == 'E') {
+		i++
+		if i < len(t) && (t[i] == '+' || t[i] == '-') {
+			i++
+		}
+		if i == len(t) {
+			return math.NaN()
+		}
+		for i < len(t) && '0' <= t[i] && t[i] <= '9' {
+			i++
+		}
+	}
+	if i != len(t) {
+		return math.NaN()
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil && !isRangeError(err) {
+		return math.NaN()
+	}
+	return f
+}
+
+// numberToString converts a number to a string as in Number.prototype.toString, which is the shortest representation that converts back to the same number.
+func numberToString(f float64) string {
+	if math.IsNaN(f) {
+		return "NaN"
+	} else if math.IsInf(f, 1) {
+		return "Infinity"
+	} else if math.IsInf(f, -1) {
+		return "-Infinity"
+	} else if f == 0 {
+		return "0"
+	} else if f < 0 {
+		return "-" + numberToString(-f)
+	}
+
+	// s is formatted as d.ddde±dd
+	s := strconv.FormatFloat(f, 'e', -1, 64)
+	e := strings.IndexByte(s, 'e')
+	digits := strings.Replace(s[:e], ".", "", 1)
+	exp, _ := strconv.Atoi(s[e+1:])
+	k, n := len(digits), exp+1
+	if k <= n && n <= 21 {
+		return digits + strings.Repeat("0", n-k)
+	} else if 0 < n && n <= 21 {
+		return digits[:n] + "." + digits[n:]
+	} else if -6 < n && n <= 0 {
+		return "0." + strings.Repeat("0", -n) + digits
+	}
+
+	s = digits[:1]
+	if 1 < k {
+		s += "." + digits[1:]
+	}
+	if 0 < n-1 {
+		return s + "e+" + strconv.Itoa(n-1)
+	}
+	return s + "e" + strconv.Itoa(n-1)
+}
+
+// Fold replaces constant expressions in the AST by their values as given by Eval. Logical and conditional expressions whose condition is constant are replaced by the operand that is evaluated, even if it is not constant. Expressions that evaluate to NaN or an infinity are left untouched, as are all expressions that are not constant.
+func Fold(ast *AST) {
+	Rewrite(folder{}, ast)
+}
+
+// FoldWithOptions replaces constant expressions in the AST as Fold, where the variables and properties of EvalOptions.Defines are constant as well. They are replaced by their values unless they are assigned to.
+func FoldWithOptions(ast *AST, o EvalOptions) {
+	Rewrite(folder{o.Defines}, ast)
+}
+
+type folder struct {
+	defines map[string]Value
+}
+
+func (f folder) Enter(c *Cursor) IRewriter {
+	return f
+}
+
+func (f folder) Exit(c *Cursor) {
+	var x IExpr
+	switch n := c.Node().(type) {
+	case *GroupExpr:
+		// remove parentheses around literals, but keep them for (1).toString()
+		if lit, ok := n.X.(*LiteralExpr); ok && (lit.TokenType == StringToken || !isDotExprX(c)) {
+			c.Replace(lit)
+		}
+		return
+	case *Var, *DotExpr:
+		if name, ok := definedName(n.(IExpr)); ok && !isAssignTarget(c) {
+			if v, ok := f.defines[name]; ok {
+				c.Replace(v.Expr())
+			}
+		}
+		return
+	case *UnaryExpr, *TemplateExpr:
+		x = n.(IExpr)
+	case *BinaryExpr:
+		x = n
+		if n.Op == AndToken || n.Op == OrToken || n.Op == NullishToken {
+			if cond, ok := evalExpr(n.X, f.defines); ok {
+				if n.Op == AndToken && cond.Truthy() || n.Op == OrToken && !cond.Truthy() || n.Op == NullishToken && (cond.Type == UndefinedValue || cond.Type == NullValue) {
+					x = n.Y
+				} else {
+					x = n.X
+				}
+			}
+		}
+	case *CondExpr:
+		x = n
+		if cond, ok := evalExpr(n.Cond, f.defines); ok {
+			if cond.Truthy() {
+				x = n.X
+			} else {
+				x = n.Y
+			}
+		}
+	default:
+		return
+	}
+
+	if v, ok := evalExpr(x, f.defines); ok {
+		if v.Type == NumberValue && (math.IsNaN(v.Number) || math.IsInf(v.Number, 0)) || isValueExpr(x) {
+			if x != c.Node() {
+				c.Replace(x)
+			}
+			return
+		}
+		c.Replace(v.Expr())
+	} else if x != c.Node() && !isMemberExpr(x) {
+		c.Replace(x)
+	}
+}
+
+// isValueExpr returns true if the expression is of the form returned by Value.Expr for primitive values.
+func isValueExpr(x IExpr) bool {
+	switch x := x.(type) {
+	case *LiteralExpr:
+		return true
+	case *UnaryExpr:
+		if x.Op == NegToken || x.Op == VoidToken {
+			lit, ok := x.X.(*LiteralExpr)
+			return ok && IsNumeric(lit.TokenType)
+		}
+	}
+	return false
+}
+
+// isMemberExpr returns true if the expression would change the this value or make a direct call to eval when it is called, which is why (true && a.b)() cannot be replaced by (a.b)().
+func isMemberExpr(x IExpr) bool {
+	switch x := x.(type) {
+	case *DotExpr, *IndexExpr, *OptChainExpr:
+		return true
+	case *GroupExpr:
+		return isMemberExpr(x.X)
+	case *Var:
+		return string(x.Data) == "eval"
+	}
+	return false
+}
+
+// isAssignTarget returns true if the current node is assigned to, incremented, decremented, or deleted.
+func isAssignTarget(c *Cursor) bool {
+	switch parent := c.Parent().(type) {
+	case *BinaryExpr:
+		switch parent.Op {
+		case EqToken, MulEqToken, DivEqToken, ModEqToken, ExpEqToken, AddEqToken, SubEqToken, LtLtEqToken, GtGtEqToken, GtGtGtEqToken, BitAndEqToken, BitXorEqToken, BitOrEqToken, AndEqToken, OrEqToken, NullishEqToken:
+			return c.Name() == "X"
+		}
+	case *UnaryExpr:
+		return parent.Op == PreIncrToken || parent.Op == PreDecrToken || parent.Op == PostIncrToken || parent.Op == PostDecrToken || parent.Op == DeleteToken
+	case *ForInStmt, *ForOfStmt:
+		return c.Name() == "Init"
+	}
+	return false
+}
+
+func isDotExprX(c *Cursor) bool {
+	_, ok := c.Parent().(*DotExpr)
+	return ok && c.Name() == "X"
+}
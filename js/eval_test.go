@@ -0,0 +1,212 @@
+package js
+
+import (
+	"math"
+	"testing"
+
+	"github.com/tdewolff/parse/v2"
+	"github.com/tdewolff/test"
+)
+
+func TestEval(t *testing.T) {
+	var tests = []struct {
+		js       string
+		expected string
+	}{
+		{"1 + 2 * 3", "7"},
+		{"0x10 + 0b11 + 0o7", "26"},
+		{"7 % -3 - 2 ** 3", "-7"},
+		{"1 / 3", "0.3333333333333333"},
+		{"1e21 + 1e-7", "1e+21"},
+		{"123e-20", "1.23e-18"},
+		{"0.00001 * 1", "0.00001"},
+		{"0.000001 * 10", "0.000009999999999999999"},
+		{"-0", "-0"},
+		{"0 / 0", "(0 / 0)"},
+		{"-1 / 0", "(-1 / 0)"},
+		{"1 ** Infinity", "(0 / 0)"},
+		{"'a' + 1 + 2", `"a12"`},
+		{"1 + 2 + 'a'", `"3a"`},
+		{"'a' + null + undefined + true + 0.5", `"anullundefinedtrue0.5"`},
+		{"'\\x41\\u{42}' + \"'\"", `"AB'"`},
+		{"`a${1 + 1}b${'c'}`", `"a2bc"`},
+		{"`\\n`", `"\n"`},
+		{"'5' * '2'", "10"},
+		{"' 0x1F ' - 0", "31"},
+		{"'1_0' * 1", "(0 / 0)"},
+		{"+'' + +' \\n'", "0"},
+		{"~5 | 1 << 33 ^ -1 >>> 28", "-1"},
+		{"-1 >>> 0", "4294967295"},
+		{"2 ** 32 | 0", "0"},
+		{"typeof 1", `"number"`},
+		{"typeof null", `"object"`},
+		{"typeof void 0", `"undefined"`},
+		{"typeof ''", `"string"`},
+		{"typeof !1", `"boolean"`},
+		{"!''", "true"},
+		{"void 0", "void 0"},
+		{"undefined", "void 0"},
+		{"NaN === NaN", "false"},
+		{"null == undefined", "true"},
+		{"null == 0", "false"},
+		{"'1' == 1", "true"},
+		{"'1' !== 1", "true"},
+		{"'b' > 'a'", "true"},
+		{"'\\u{1F600}' > '\\uFFFF'", "false"},
+		{"'10' < '9'", "true"},
+		{"'10' < 9", "false"},
+		{"NaN <= 1", "false"},
+		{"0 && a", "0"},
+		{"1 && 2", "2"},
+		{"'' || 'b'", `"b"`},
+		{"null ?? 'c'", `"c"`},
+		{"0 ?? a", "0"},
+		{"1 ? 'a' : b", `"a"`},
+		{"(1 + 2)", "3"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.js, func(t *testing.T) {
+			ast, err := Parse(parse.NewInputString(tt.js))
+			if err != nil {
+				t.Fatal(err)
+			}
+			v, ok := Eval(ast.BlockStmt.List[0].(*ExprStmt).Value)
+			test.That(t, ok)
+			test.String(t, v.String(), tt.expected)
+		})
+	}
+
+	// not constant
+	tests = []struct {
+		js       string
+		expected string
+	}{
+		{"a", ""},
+		{"a + 1", ""},
+		{"1 && a", ""},
+		{"var undefined; undefined", ""},
+		{"typeof a", ""},
+		{"void a()", ""},
+		{"1n + 1n", ""},
+		{"/a/ + ''", ""},
+		{"tag`a`", ""},
+		{"`${a}`", ""},
+		{"1 in a", ""},
+		{"a = 1", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.js, func(t *testing.T) {
+			ast, err := Parse(parse.NewInputString(tt.js))
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, ok := Eval(ast.BlockStmt.List[len(ast.BlockStmt.List)-1].(*ExprStmt).Value)
+			test.That(t, !ok)
+		})
+	}
+}
+
+func TestValue(t *testing.T) {
+	test.T(t, Value{Type: StringValue, Str: "0x10"}.ToNumber(), 16.0)
+	test.T(t, Value{Type: StringValue, Str: "-Infinity"}.ToNumber(), math.Inf(-1))
+	test.That(t, math.IsNaN(Value{Type: StringValue, Str: "inf"}.ToNumber()))
+	test.That(t, math.IsNaN(Value{Type: StringValue, Str: "1e"}.ToNumber()))
+	test.That(t, math.IsNaN(Value{}.ToNumber()))
+	test.T(t, Value{Type: NullValue}.ToNumber(), 0.0)
+	test.String(t, Value{Type: NumberValue, Number: 1e100}.ToString(), "1e+100")
+	test.String(t, Value{Type: NumberValue, Number: -1.5e-10}.ToString(), "-1.5e-10")
+	test.String(t, Value{Type: NumberValue, Number: 123456789012345680000}.ToString(), "123456789012345680000")
+	test.That(t, !Value{Type: NumberValue, Number: math.NaN()}.Truthy())
+	test.That(t, Value{Type: StringValue, Str: "0"}.Truthy())
+}
+
+func TestFold(t *testing.T) {
+	var tests = []struct {
+		js       string
+		expected string
+	}{
+		{"a = 1 + 2", "a = 3; "},
+		{"a = 1 + b", "a = 1 + b; "},
+		{"a = b * (2 - 3)", "a = b * (-1); "},
+		{"a = (2 - 3) ** 2", "a = 1; "},
+		{"a = (-(1 + 2)) ** b", "a = (-3) ** b; "},
+		{"a = (1 + 2).toString()", "a = (3).toString(); "},
+		{"a = ('a' + 'b').length", `a = "ab".length; `},
+		{"a = `x${1}y`", `a = "x1y"; `},
+		{"a = tag`x${1 + 1}`", "a = tag`x${2}`; "},
+		{"a = 0 / 0 + b", "a = 0 / 0 + b; "},
+		{"a = true && b", "a = b; "},
+		{"a = false && b()", "a = false; "},
+		{"a = null ?? b", "a = b; "},
+		{"a = 'x' || b", "a = 'x'; "},
+		{"a = !1 ? b : c", "a = c; "},
+		{"(true && b.c)()", "(true && b.c)(); "},
+		{"(true && b?.c)()", "(true && b?.c)(); "},
+		{"(true && (b.c))()", "(true && (b.c))(); "},
+		{"a = b && true", "a = b && true; "},
+		{"a = typeof b === 'undefined'", "a = typeof b === 'undefined'; "},
+		{"a = typeof undefined === 'undefined'", "a = true; "},
+		{"if ('production' === 'production') a()", "if (true) { a() }; "},
+		{"function f(undefined) { return void 0 === undefined }", "function f (undefined) { return void 0 === undefined; }; "},
+	}
+	for _, tt := range tests {
+		t.Run(tt.js, func(t *testing.T) {
+			ast, err := Parse(parse.NewInputString(tt.js))
+			if err != nil {
+				t.Fatal(err)
+			}
+			Fold(ast)
+			test.String(t, ast.JS(), tt.expected)
+		})
+	}
+}
+
+func TestFoldDefines(t *testing.T) {
+	var tests = []struct {
+		js       string
+		expected string
+	}{
+		{"if (process.env.NODE_ENV === 'production') a()", "if (true) { a() }; "},
+		{"a = process.env.NODE_ENV", `a = "production"; `},
+		{"a = DEBUG ? b : c", "a = c; "},
+		{"a = DEBUG.b", "a = false.b; "},
+		{"a = process.env.OTHER + process.env", "a = process.env.OTHER + process.env; "},
+		{"process.env.NODE_ENV = 'test'; DEBUG++; delete DEBUG", "process.env.NODE_ENV = 'test'; DEBUG++; delete DEBUG; "},
+		{"function f(DEBUG) { return DEBUG }", "function f (DEBUG) { return DEBUG; }; "},
+	}
+	o := EvalOptions{Defines: map[string]Value{
+		"process.env.NODE_ENV": {Type: StringValue, Str: "production"},
+		"DEBUG":                {Type: BooleanValue, Bool: false},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.js, func(t *testing.T) {
+			ast, err := Parse(parse.NewInputString(tt.js))
+			if err != nil {
+				t.Fatal(err)
+			}
+			FoldWithOptions(ast, o)
+			test.String(t, ast.JS(), tt.expected)
+		})
+	}
+
+	ast, err := Parse(parse.NewInputString("process.env.NODE_ENV !== 'production'"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	v, ok := EvalWithOptions(ast.BlockStmt.List[0].(*ExprStmt).Value, o)
+	test.That(t, ok)
+	test.String(t, v.String(), "false")
+	_, ok = Eval(ast.BlockStmt.List[0].(*ExprStmt).Value)
+	test.That(t, !ok)
+}
+
+func TestFoldScope(t *testing.T) {
+	ast, err := Parse(parse.NewInputString("function f(b) { return true ? 1 : b }"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	Fold(ast)
+	test.String(t, ast.JS(), "function f (b) { return 1; }; ")
+	b := ast.BlockStmt.List[0].(*FuncDecl).Params.List[0].Binding.(*Var)
+	test.T(t, b.Uses, uint16(1))
+}
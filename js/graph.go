@@ -0,0 +1,736 @@
+package js
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/tdewolff/parse/v2"
+)
+
+// Resolver resolves and loads the modules of a module graph.
+type Resolver interface {
+	// Resolve returns the path of the module that is imported by the specifier from the importer, which is empty for entry modules. It returns an empty path for external modules that are not part of the graph.
+	Resolve(specifier, importer string) (string, error)
+	// Load returns the source of the module at the path.
+	Load(path string) ([]byte, error)
+}
+
+// MemoryFiles is a Resolver for an in-memory file system that maps file paths to their sources, which is useful for testing. Paths are slash-separated and relative to the root, such as src/main.js. Specifiers that start with ./, ../, or / are resolved relative to the directory of the importer, where the extensions .js and /index.js are tried when the file does not exist. Other specifiers are external modules.
+type MemoryFiles map[string]string
+
+// Resolve resolves the specifier relative to the importer.
+func (files MemoryFiles) Resolve(specifier, importer string) (string, error) {
+	if !strings.HasPrefix(specifier, "./") && !strings.HasPrefix(specifier, "../") && !strings.HasPrefix(specifier, "/") {
+		return "", nil
+	}
+	name := path.Join(path.Dir("/"+importer), specifier)[1:]
+	for _, ext := range []string{"", ".js", "/index.js"} {
+		if _, ok := files[name+ext]; ok {
+			return name + ext, nil
+		}
+	}
+	return "", fmt.Errorf("module not found")
+}
+
+// Load returns the source of the file.
+func (files MemoryFiles) Load(path string) ([]byte, error) {
+	if src, ok := files[path]; ok {
+		return []byte(src), nil
+	}
+	return nil, fmt.Errorf("file not found")
+}
+
+// DependencyKind is the kind of dependency of a module on another module.
+type DependencyKind int
+
+// DependencyKind values.
+const (
+	ImportDependency         DependencyKind = iota // import declaration
+	ExportDependency                               // export from declaration
+	DynamicDependency                              // import() call
+	RequireDependency                              // require() call
+	DynamicRequireDependency                       // require() call with a specifier that is not a string
+)
+
+func (kind DependencyKind) String() string {
+	switch kind {
+	case ImportDependency:
+		return "import"
+	case ExportDependency:
+		return "export"
+	case DynamicDependency:
+		return "import()"
+	case RequireDependency:
+		return "require()"
+	case DynamicRequireDependency:
+		return "require(?)"
+	}
+	return "Invalid(" + strconv.Itoa(int(kind)) + ")"
+}
+
+// Dependency is the import of a module by another module.
+type Dependency struct {
+	Kind      DependencyKind
+	Specifier string   // empty for DynamicRequireDependency
+	Module    *Module  // nil for DynamicRequireDependency
+	Names     []string // imported or re-exported names, where * is a namespace import or export * from, empty for import() and for require() calls whose result is unused
+	Span
+}
+
+// Export is a name that is exported by a module, either declared in the module or re-exported from another module.
+type Export struct {
+	Name       string
+	Local      string      // name of the local binding, which is default for anonymous default exports, empty for re-exports and CommonJS exports
+	Dependency *Dependency // dependency that is re-exported from, or nil
+	Import     string      // name exported by the dependency that is re-exported, where * is a namespace
+	Span
+}
+
+// ModuleFormat is the module system that is used by a module.
+type ModuleFormat int
+
+// ModuleFormat values.
+const (
+	ScriptFormat   ModuleFormat = iota // neither imports nor exports
+	ESModuleFormat                     // import and export declarations
+	CommonJSFormat                     // require() calls, or module and exports
+	MixedFormat                        // import or export declarations together with module or exports
+)
+
+func (format ModuleFormat) String() string {
+	switch format {
+	case ScriptFormat:
+		return "script"
+	case ESModuleFormat:
+		return "esm"
+	case CommonJSFormat:
+		return "commonjs"
+	case MixedFormat:
+		return "mixed"
+	}
+	return "Invalid(" + strconv.Itoa(int(format)) + ")"
+}
+
+// Module is a module of a module graph.
+type Module struct {
+	Path           string
+	AST            *AST // nil for external modules
+	Format         ModuleFormat
+	Deps           []*Dependency
+	Exports        []Export      // explicitly exported names in source order
+	Stars          []*Dependency // export * from declarations, or module.exports = require() assignments
+	ESModule       bool          // CommonJS module that is marked by __esModule as compiled from an ES module
+	DynamicExports bool          // CommonJS module with exports whose names are not known, such as spread or computed properties of module.exports or exports
+	External       bool          // module is not part of the graph, its path is the specifier
+}
+
+// AnalyzeModule returns the dependencies and exports of a parsed module, where the modules of the dependencies are not resolved. Besides import and export declarations, it finds the CommonJS patterns require("x"), module.exports = x, exports.x = y, and Object.defineProperty(exports, "x", desc), where assigning an object literal to module.exports exports its properties. Assignments to module.exports export the name default, and module.exports = require("x") re-exports all names of the required module like export * from. The names that are used of a require() call are those of const {x} = require("x") and require("x").x, or * otherwise.
+func AnalyzeModule(path string, ast *AST) *Module {
+	m := &Module{
+		Path: path,
+		AST:  ast,
+	}
+	m.analyze()
+	return m
+}
+
+// ModuleGraph is the graph of modules that are imported, directly or indirectly, by the entry modules.
+type ModuleGraph struct {
+	Entries []*Module
+	Modules []*Module // in order of discovery
+	modules map[string]*Module
+}
+
+// NewModuleGraph resolves, loads, and parses the entry modules and all modules they depend on. Modules are parsed as ES modules, except for CommonJS modules that are parsed as scripts. Dependencies are import and export from declarations, and import() and require() calls with a string literal as specifier.
+func NewModuleGraph(r Resolver, entries ...string) (*ModuleGraph, error) {
+	g := &ModuleGraph{
+		modules: map[string]*Module{},
+	}
+	for _, entry := range entries {
+		path, err := r.Resolve(entry, "")
+		if err != nil {
+			return nil, fmt.Errorf("cannot resolve %s: %w", entry, err)
+		}
+		m, err := g.add(r, path, entry)
+		if err != nil {
+			return nil, err
+		}
+		g.Entries = append(g.Entries, m)
+	}
+
+	for i := 0; i < len(g.Modules); i++ {
+		m := g.Modules[i]
+		for _, dep := range m.Deps {
+			if dep.Kind == DynamicRequireDependency {
+				continue
+			}
+			path, err := r.Resolve(dep.Specifier, m.Path)
+			if err != nil {
+				return nil, fmt.Errorf("%s: cannot resolve %s: %w", m.Path, dep.Specifier, err)
+			}
+			if dep.Module, err = g.add(r, path, dep.Specifier); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return g, nil
+}
+
+// add adds the module at the path to the graph if it was not added before, or an external module if the path is empty.
+func (g *ModuleGraph) add(r Resolver, path, specifier string) (*Module, error) {
+	external := path == ""
+	if external {
+		path = specifier
+	}
+	if m, ok := g.modules[path]; ok {
+		return m, nil
+	}
+
+	m := &Module{
+		Path:     path,
+		External: external,
+	}
+	if !external {
+		src, err := r.Load(path)
+		if err != nil {
+			return nil, fmt.Errorf("cannot load %s: %w", path, err)
+		}
+		if m.AST, err = parseModule(src); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		m.analyze()
+	}
+	g.modules[path] = m
+	g.Modules = append(g.Modules, m)
+	return m, nil
+}
+
+// parseModule parses the source as an ES module, or as a script for CommonJS modules, which are detected when the source has no import or export declarations.
+func parseModule(src []byte) (*AST, error) {
+	ast, err := ParseWithOptions(parse.NewInputBytes(src), ParseOptions{SourceType: ModuleSource})
+	if err == nil && isModule(ast) {
+		return ast, nil
+	}
+	script, scriptErr := ParseWithOptions(parse.NewInputBytes(src), ParseOptions{SourceType: ScriptSource})
+	if scriptErr == nil && AnalyzeModule("", script).Format == CommonJSFormat {
+		return script, nil
+	}
+	return ast, err
+}
+
+// Module returns the module at the path, or nil if it is not part of the graph.
+func (g *ModuleGraph) Module(path string) *Module {
+	return g.modules[path]
+}
+
+// analyze finds the dependencies, exports, and format of the module.
+func (m *Module) analyze() {
+	esm := false
+	for _, item := range m.AST.BlockStmt.List {
+		switch stmt := item.(type) {
+		case *ImportStmt:
+			esm = true
+			dep := &Dependency{Kind: ImportDependency, Specifier: moduleName(stmt.Module), Span: stmt.Span}
+			if stmt.Default != nil {
+				dep.Names = append(dep.Names, "default")
+			}
+			for _, alias := range stmt.List {
+				if alias.Binding == nil {
+					continue
+				} else if alias.Name != nil {
+					dep.Names = append(dep.Names, string(alias.Name))
+				} else {
+					dep.Names = append(dep.Names, string(alias.Binding))
+				}
+			}
+			m.Deps = append(m.Deps, dep)
+		case *ExportStmt:
+			esm = true
+			m.analyzeExport(stmt)
+		}
+	}
+
+	v := &dependencyVisitor{m: m, reexports: map[*CallExpr]bool{}, names: map[*CallExpr][]string{}}
+	Walk(v, m.AST)
+
+	if esm && v.commonJS {
+		m.Format = MixedFormat
+	} else if esm {
+		m.Format = ESModuleFormat
+	} else if v.commonJS || v.require {
+		m.Format = CommonJSFormat
+	}
+}
+
+func (m *Module) analyzeExport(stmt *ExportStmt) {
+	if stmt.Module != nil {
+		dep := &Dependency{Kind: ExportDependency, Specifier: moduleName(stmt.Module), Span: stmt.Span}
+		m.Deps = append(m.Deps, dep)
+		for _, alias := range stmt.List {
+			if alias.Binding == nil {
+				continue
+			} else if isStar(alias.Binding) {
+				dep.Names = append(dep.Names, "*")
+				m.Stars = append(m.Stars, dep)
+			} else if alias.Name != nil {
+				dep.Names = append(dep.Names, string(alias.Name))
+				m.Exports = append(m.Exports, Export{Name: string(alias.Binding), Dependency: dep, Import: string(alias.Name), Span: alias.Span})
+			} else {
+				dep.Names = append(dep.Names, string(alias.Binding))
+				m.Exports = append(m.Exports, Export{Name: string(alias.Binding), Dependency: dep, Import: string(alias.Binding), Span: alias.Span})
+			}
+		}
+		return
+	}
+
+	for _, alias := range stmt.List {
+		if alias.Binding == nil {
+			continue
+		} else if alias.Name != nil {
+			m.Exports = append(m.Exports, Export{Name: string(alias.Binding), Local: string(alias.Name), Span: alias.Span})
+		} else {
+			m.Exports = append(m.Exports, Export{Name: string(alias.Binding), Local: string(alias.Binding), Span: alias.Span})
+		}
+	}
+	if stmt.Default {
+		local := "default"
+		switch decl := stmt.Decl.(type) {
+		case *FuncDecl:
+			if decl.Name != nil {
+				local = string(decl.Name.Data)
+			}
+		case *ClassDecl:
+			if decl.Name != nil {
+				local = string(decl.Name.Data)
+			}
+		}
+		m.Exports = append(m.Exports, Export{Name: "default", Local: local, Span: stmt.Span})
+		return
+	}
+
+	var vars []*Var
+	switch decl := stmt.Decl.(type) {
+	case *VarDecl:
+		vars = bindingVars(nil, stmtBindings(decl)...)
+	case *FuncDecl:
+		vars = append(vars, decl.Name)
+	case *ClassDecl:
+		vars = append(vars, decl.Name)
+	}
+	for _, v := range vars {
+		m.Exports = append(m.Exports, Export{Name: string(v.Data), Local: string(v.Data), Span: stmt.Span})
+	}
+}
+
+type dependencyVisitor struct {
+	m         *Module
+	commonJS  bool                   // module or exports is used
+	require   bool                   // require() is called
+	reexports map[*CallExpr]bool     // require() calls that are assigned to module.exports
+	names     map[*CallExpr][]string // names that are used of require() calls, which is nil if the result is unused
+}
+
+func (v *dependencyVisitor) Enter(n INode) IVisitor {
+	switch n := n.(type) {
+	case *Var:
+		if isFreeVar(n, "module") || isFreeVar(n, "exports") {
+			v.commonJS = true
+		}
+	case *BinaryExpr:
+		if n.Op != EqToken {
+			break
+		} else if isModuleExports(n.X) {
+			v.m.addExport(Export{Name: "default", Span: n.Span})
+			switch y := n.Y.(type) {
+			case *ObjectExpr:
+				for _, property := range y.List {
+					if name, ok := objectPropertyName(property); !ok {
+						v.m.DynamicExports = true
+					} else if name == "__esModule" {
+						v.m.ESModule = true
+					} else {
+						v.m.addExport(Export{Name: name, Span: property.Span})
+					}
+				}
+			case *CallExpr:
+				v.reexports[y] = true
+			}
+		} else if name, ok := exportsProperty(n.X); ok {
+			if name == "__esModule" {
+				v.m.ESModule = true
+			} else {
+				v.m.addExport(Export{Name: name, Span: n.Span})
+			}
+		} else if index, ok := n.X.(*IndexExpr); ok && isExportsObject(index.X) {
+			v.m.DynamicExports = true
+		}
+	case *ExprStmt:
+		if call, ok := n.Value.(*CallExpr); ok {
+			v.names[call] = nil
+		}
+	case *VarDecl:
+		for _, item := range n.List {
+			if call, ok := item.Default.(*CallExpr); ok {
+				if object, ok := item.Binding.(*BindingObject); ok {
+					v.names[call] = bindingObjectNames(object)
+				}
+			}
+		}
+	case *DotExpr, *IndexExpr:
+		if x, name, ok := memberProperty(n.(IExpr)); ok {
+			if call, ok := x.(*CallExpr); ok {
+				v.names[call] = []string{name}
+			}
+		}
+	case *CallExpr:
+		v.enterCall(n)
+	}
+	return v
+}
+
+func (v *dependencyVisitor) enterCall(call *CallExpr) {
+	if dot, ok := call.X.(*DotExpr); ok && isFreeVar(dot.X, "Object") && string(dot.Y.Data) == "defineProperty" && 2 <= len(call.Args.List) && isExportsObject(call.Args.List[0].Value) {
+		if name, ok := stringLiteral(call.Args.List[1].Value); !ok {
+			v.m.DynamicExports = true
+		} else if name == "__esModule" {
+			v.m.ESModule = true
+		} else {
+			v.m.addExport(Export{Name: name, Span: call.Span})
+		}
+		return
+	}
+
+	if len(call.Args.List) != 1 || call.Args.List[0].Rest {
+		return
+	}
+	specifier, ok := stringLiteral(call.Args.List[0].Value)
+	if !ok {
+		if isFreeVar(call.X, "require") {
+			v.m.Deps = append(v.m.Deps, &Dependency{Kind: DynamicRequireDependency, Span: call.Span})
+			v.require = true
+		}
+		return
+	}
+	if lit, ok := call.X.(*LiteralExpr); ok && lit.TokenType == ImportToken {
+		v.m.Deps = append(v.m.Deps, &Dependency{Kind: DynamicDependency, Specifier: specifier, Span: call.Span})
+	} else if isFreeVar(call.X, "require") {
+		dep := &Dependency{Kind: RequireDependency, Specifier: specifier, Span: call.Span}
+		if v.reexports[call] {
+			dep.Names = []string{"*"}
+			v.m.Stars = append(v.m.Stars, dep)
+		} else if names, ok := v.names[call]; ok {
+			dep.Names = names
+		} else {
+			dep.Names = []string{"*"}
+		}
+		v.m.Deps = append(v.m.Deps, dep)
+		v.require = true
+	}
+}
+
+func (v *dependencyVisitor) Exit(n INode) {}
+
+// addExport adds an export unless the name is exported already.
+func (m *Module) addExport(export Export) {
+	for _, prev := range m.Exports {
+		if prev.Name == export.Name {
+			return
+		}
+	}
+	m.Exports = append(m.Exports, export)
+}
+
+// isFreeVar returns true if the expression is an undeclared variable with the given name.
+func isFreeVar(e IExpr, name string) bool {
+	v, ok := e.(*Var)
+	return ok && resolveVar(v).Decl == NoDecl && string(v.Data) == name
+}
+
+// isModuleExports returns true for module.exports.
+func isModuleExports(e IExpr) bool {
+	x, name, ok := memberProperty(e)
+	return ok && name == "exports" && isFreeVar(x, "module")
+}
+
+// isExportsObject returns true for exports and module.exports.
+func isExportsObject(e IExpr) bool {
+	return isFreeVar(e, "exports") || isModuleExports(e)
+}
+
+// exportsProperty returns the name of a property of exports or module.exports.
+func exportsProperty(e IExpr) (string, bool) {
+	x, name, ok := memberProperty(e)
+	if !ok || !isExportsObject(x) {
+		return "", false
+	}
+	return name, true
+}
+
+// memberProperty returns the object and property name of x.name or x["name"].
+func memberProperty(e IExpr) (IExpr, string, bool) {
+	switch e := e.(type) {
+	case *DotExpr:
+		return e.X, string(e.Y.Data), true
+	case *IndexExpr:
+		if name, ok := stringLiteral(e.Y); ok {
+			return e.X, name, true
+		}
+	}
+	return nil, "", false
+}
+
+// objectPropertyName returns the name of a property of an object literal that is not computed.
+func objectPropertyName(property Property) (string, bool) {
+	name := property.Name
+	if property.Spread {
+		return "", false
+	} else if name == nil {
+		switch value := property.Value.(type) {
+		case *Var:
+			return string(value.Data), true
+		case *MethodDecl:
+			name = &value.Name
+		default:
+			return "", false
+		}
+	}
+	return propertyNameString(name)
+}
+
+// propertyNameString returns the name of a property name that is not computed.
+func propertyNameString(name *PropertyName) (string, bool) {
+	if name.IsComputed() {
+		return "", false
+	} else if name.Literal.TokenType == StringToken {
+		return moduleName(name.Literal.Data), true
+	}
+	return string(name.Literal.Data), true
+}
+
+// bindingObjectNames returns the property names of an object pattern, where * stands for a rest element or computed property name.
+func bindingObjectNames(object *BindingObject) []string {
+	names := []string{}
+	for _, item := range object.List {
+		if item.Key == nil {
+			if v, ok := item.Value.Binding.(*Var); ok {
+				names = append(names, string(v.Data))
+			}
+		} else if name, ok := propertyNameString(item.Key); ok {
+			names = append(names, name)
+		} else {
+			names = append(names, "*")
+		}
+	}
+	if object.Rest != nil {
+		names = append(names, "*")
+	}
+	return names
+}
+
+// stringLiteral returns the value of a string literal or of a template literal without substitutions.
+func stringLiteral(e IExpr) (string, bool) {
+	if lit, ok := e.(*LiteralExpr); ok && lit.TokenType == StringToken {
+		return moduleName(lit.Data), true
+	} else if tmpl, ok := e.(*TemplateExpr); ok && tmpl.Tag == nil && len(tmpl.List) == 0 {
+		if s, err := DecodeTemplate(tmpl.Tail); err == nil {
+			return string(s), true
+		}
+	}
+	return "", false
+}
+
+// ResolveExport follows the re-exports of the exported name to the module that declares it. It returns the chain of modules from m to the declaring module and the local name of the binding in the declaring module, which is * for a namespace re-export. Names re-exported by export * from are ambiguous when more than one module exports them. The chain ends at an external module, where the local name is the exported name, only if no other module exports the name.
+func (m *Module) ResolveExport(name string) ([]*Module, string, bool) {
+	return m.resolveExport(name, map[*Module]bool{})
+}
+
+func (m *Module) resolveExport(name string, visited map[*Module]bool) ([]*Module, string, bool) {
+	if visited[m] {
+		return nil, "", false // circular re-export
+	} else if m.External {
+		return []*Module{m}, name, true
+	}
+	visited[m] = true
+	defer delete(visited, m)
+
+	for _, export := range m.Exports {
+		if export.Name != name {
+			continue
+		} else if export.Dependency == nil {
+			return []*Module{m}, export.Local, true
+		} else if dep := export.Dependency.Module; dep == nil {
+			return nil, "", false
+		} else if export.Import == "*" {
+			return []*Module{m, dep}, "*", true
+		} else if chain, local, ok := dep.resolveExport(export.Import, visited); ok {
+			return append([]*Module{m}, chain...), local, true
+		}
+		return nil, "", false
+	}
+
+	if name == "default" {
+		return nil, "", false // not re-exported by export * from
+	}
+	var chain, external []*Module
+	var local string
+	for _, star := range m.Stars {
+		if star.Module == nil {
+			continue
+		}
+		starChain, starLocal, ok := star.Module.resolveExport(name, visited)
+		if !ok {
+			continue
+		} else if starChain[len(starChain)-1].External {
+			if external == nil {
+				external = append([]*Module{m}, starChain...) // only used if no other module exports the name
+			}
+		} else if chain != nil && (chain[len(chain)-1] != starChain[len(starChain)-1] || local != starLocal) {
+			return nil, "", false // ambiguous
+		} else if chain == nil {
+			chain, local = append([]*Module{m}, starChain...), starLocal
+		}
+	}
+	if chain == nil && external != nil {
+		return external, name, true
+	}
+	return chain, local, chain != nil
+}
+
+// ExportNames returns the names that are exported by the module, including the names re-exported by export * from, in sorted order. It returns false if the exports of an external module are re-exported, which are unknown, or if the module or a re-exported module has DynamicExports.
+func (m *Module) ExportNames() ([]string, bool) {
+	names := map[string]bool{}
+	ok := m.exportNames(names, map[*Module]bool{}, true)
+	list := make([]string, 0, len(names))
+	for name := range names {
+		if _, _, exported := m.ResolveExport(name); exported {
+			list = append(list, name)
+		}
+	}
+	sort.Strings(list)
+	return list, ok
+}
+
+func (m *Module) exportNames(names map[string]bool, visited map[*Module]bool, root bool) bool {
+	if m.External {
+		return false
+	} else if visited[m] {
+		return true
+	}
+	visited[m] = true
+
+	ok := !m.DynamicExports
+	for _, export := range m.Exports {
+		if root || export.Name != "default" {
+			names[export.Name] = true
+		}
+	}
+	for _, star := range m.Stars {
+		if star.Module != nil && !star.Module.exportNames(names, visited, false) {
+			ok = false
+		}
+	}
+	return ok
+}
+
+// Cycles returns the cycles of modules that import each other, directly or indirectly, ignoring import() calls as they are evaluated later. Each cycle is a strongly connected component of the graph, ordered by discovery, and cycles are ordered by their first module.
+func (g *ModuleGraph) Cycles() [][]*Module {
+	index := map[*Module]int{}
+	for i, m := range g.Modules {
+		index[m] = i
+	}
+
+	// Tarjan's algorithm
+	n := 0
+	order := map[*Module]int{}
+	low := map[*Module]int{}
+	onStack := map[*Module]bool{}
+	stack := []*Module{}
+	cycles := [][]*Module{}
+	var visit func(*Module)
+	visit = func(m *Module) {
+		order[m], low[m] = n, n
+		n++
+		stack = append(stack, m)
+		onStack[m] = true
+		selfLoop := false
+		for _, dep := range m.Deps {
+			if dep.Kind == DynamicDependency || dep.Module == nil {
+				continue
+			} else if dep.Module == m {
+				selfLoop = true
+			} else if _, ok := order[dep.Module]; !ok {
+				visit(dep.Module)
+				if low[dep.Module] < low[m] {
+					low[m] = low[dep.Module]
+				}
+			} else if onStack[dep.Module] && order[dep.Module] < low[m] {
+				low[m] = order[dep.Module]
+			}
+		}
+		if low[m] == order[m] {
+			i := len(stack) - 1
+			for stack[i] != m {
+				i--
+			}
+			cycle := append([]*Module{}, stack[i:]...)
+			stack = stack[:i]
+			for _, c := range cycle {
+				onStack[c] = false
+			}
+			if 1 < len(cycle) || selfLoop {
+				sort.Slice(cycle, func(i, j int) bool { return index[cycle[i]] < index[cycle[j]] })
+				cycles = append(cycles, cycle)
+			}
+		}
+	}
+	for _, m := range g.Modules {
+		if _, ok := order[m]; !ok {
+			visit(m)
+		}
+	}
+	sort.Slice(cycles, func(i, j int) bool { return index[cycles[i][0]] < index[cycles[j][0]] })
+	return cycles
+}
+
+// MissingExport is a name that is imported or re-exported from a module that does not export it.
+type MissingExport struct {
+	Module     *Module // importing module
+	Dependency *Dependency
+	Name       string
+}
+
+func (e MissingExport) Error() string {
+	return fmt.Sprintf("%s: %s does not export %s", e.Module.Path, e.Dependency.Module.Path, e.Name)
+}
+
+// MissingExports returns the names that are imported or re-exported by import and export from declarations but that are not exported by the imported module, in order of the modules and their dependencies. Names that may be re-exported from external modules are not reported.
+func (g *ModuleGraph) MissingExports() []MissingExport {
+	missing := []MissingExport{}
+	for _, m := range g.Modules {
+		for _, dep := range m.Deps {
+			if dep.Module == nil || dep.Module.External || dep.Kind != ImportDependency && dep.Kind != ExportDependency {
+				continue
+			}
+			for _, name := range dep.Names {
+				if name == "*" {
+					continue
+				} else if _, _, ok := dep.Module.ResolveExport(name); !ok && !dep.Module.mayReexport(name) {
+					missing = append(missing, MissingExport{m, dep, name})
+				}
+			}
+		}
+	}
+	return missing
+}
+
+// mayReexport returns true if the name may be re-exported from an external module by export * from, or may be exported by a module with DynamicExports.
+func (m *Module) mayReexport(name string) bool {
+	if name == "default" {
+		return false
+	}
+	_, ok := m.ExportNames()
+	return !ok
+}
@@ -0,0 +1,268 @@
+package js
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tdewolff/parse/v2"
+	"github.com/tdewolff/test"
+)
+
+func modulePaths(modules []*Module) string {
+	paths := []string{}
+	for _, m := range modules {
+		paths = append(paths, m.Path)
+	}
+	return strings.Join(paths, " ")
+}
+
+func TestModuleGraph(t *testing.T) {
+	r := MemoryFiles{
+		"main.js":       "import a, { b as c } from './lib'; import * as ns from './util/index.js'; export { c }; import('./lazy.js'); const d = require('./cjs')",
+		"lib.js":        "export default function a() {} export const b = 1, [e] = f; export * from 'external'",
+		"util/index.js": "export { b as x, default as y } from '../lib.js'; export * as z from '../lib.js'; import React from 'react'",
+		"lazy.js":       "export var lazy = require(l)",
+		"cjs.js":        "module.exports = 1",
+		"unused.js":     "",
+	}
+	g, err := NewModuleGraph(r, "./main")
+	test.Error(t, err)
+	test.String(t, modulePaths(g.Entries), "main.js")
+	test.String(t, modulePaths(g.Modules), "main.js lib.js util/index.js lazy.js cjs.js external react")
+	test.That(t, g.Module("react").External)
+	test.That(t, g.Module("unused.js") == nil)
+
+	main := g.Module("main.js")
+	test.T(t, len(main.Deps), 4)
+	test.T(t, main.Deps[0].Kind, ImportDependency)
+	test.String(t, main.Deps[0].Specifier, "./lib")
+	test.String(t, main.Deps[0].Module.Path, "lib.js")
+	test.String(t, strings.Join(main.Deps[0].Names, " "), "default b")
+	test.String(t, strings.Join(main.Deps[1].Names, " "), "*")
+	test.T(t, main.Deps[2].Kind, DynamicDependency)
+	test.String(t, main.Deps[2].Module.Path, "lazy.js")
+	test.T(t, main.Deps[3].Kind, RequireDependency)
+	test.String(t, main.Deps[3].Module.Path, "cjs.js")
+	test.T(t, main.Exports, []Export{{Name: "c", Local: "c", Span: Span{83, 84}}})
+
+	lazy := g.Module("lazy.js")
+	test.T(t, len(lazy.Deps), 1)
+	test.T(t, lazy.Deps[0].Kind, DynamicRequireDependency)
+	test.That(t, lazy.Deps[0].Module == nil)
+
+	lib := g.Module("lib.js")
+	test.T(t, len(lib.Exports), 3)
+	test.String(t, lib.Exports[0].Name+"="+lib.Exports[0].Local, "default=a")
+	test.String(t, lib.Exports[2].Name+"="+lib.Exports[2].Local, "e=e")
+	test.T(t, len(lib.Stars), 1)
+
+	util := g.Module("util/index.js")
+	test.T(t, util.Deps[0].Kind, ExportDependency)
+	test.String(t, util.Exports[0].Name+"="+util.Exports[0].Import, "x=b")
+	test.String(t, util.Exports[2].Name+"="+util.Exports[2].Import, "z=*")
+}
+
+func TestModuleGraphError(t *testing.T) {
+	var tests = []struct {
+		files map[string]string
+		err   string
+	}{
+		{map[string]string{"main.js": "import './missing'"}, "main.js: cannot resolve ./missing: module not found"},
+		{map[string]string{"main.js": "var"}, "main.js: unexpected EOF in binding on line 1 and column 4\n    1: var\n          ^"},
+		{map[string]string{}, "cannot resolve ./main: module not found"},
+		{map[string]string{"main.js": "import './lib'; with (a) {}", "lib.js": ""}, "main.js: with statement not allowed in strict mode on line 1 and column 17\n    1: import './lib'; with (a) {}\n                       ^"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.err, func(t *testing.T) {
+			_, err := NewModuleGraph(MemoryFiles(tt.files), "./main")
+			test.That(t, err != nil)
+			if err != nil {
+				test.String(t, err.Error(), tt.err)
+			}
+		})
+	}
+}
+
+func TestModuleGraphResolveExport(t *testing.T) {
+	r := MemoryFiles{
+		"main.js": "export { a as b } from './a'; export * from './c'; export * from './d'; export * as ns from './a'; export default 1",
+		"a.js":    "export * from './b'; export let own = 1",
+		"b.js":    "export { x as a } from './b2'",
+		"b2.js":   "var y; export { y as x }",
+		"c.js":    "export const dup = 1, same = 2; export * from './e'; export default 2",
+		"d.js":    "export const dup = 3; export { same } from './c'",
+		"e.js":    "export * from './main'; export * from 'ext'",
+	}
+	g, err := NewModuleGraph(r, "./main")
+	test.Error(t, err)
+	main := g.Module("main.js")
+
+	var tests = []struct {
+		name  string
+		chain string
+		local string
+	}{
+		{"b", "main.js a.js b.js b2.js", "y"},
+		{"ns", "main.js a.js", "*"},
+		{"default", "main.js", "default"},
+		{"same", "main.js c.js", "same"},
+		{"dup", "", ""}, // ambiguous
+		{"other", "main.js c.js e.js ext", "other"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chain, local, ok := main.ResolveExport(tt.name)
+			test.T(t, ok, tt.chain != "")
+			test.String(t, modulePaths(chain), tt.chain)
+			test.String(t, local, tt.local)
+		})
+	}
+
+	names, ok := g.Module("c.js").ExportNames()
+	test.String(t, strings.Join(names, " "), "b default dup ns same")
+	test.That(t, !ok)
+	names, ok = g.Module("a.js").ExportNames()
+	test.String(t, strings.Join(names, " "), "a own")
+	test.That(t, ok)
+}
+
+func TestModuleGraphCycles(t *testing.T) {
+	r := MemoryFiles{
+		"main.js": "import './a'; import './d'; import('./e')",
+		"a.js":    "import './b'",
+		"b.js":    "export * from './c'",
+		"c.js":    "require('./a')",
+		"d.js":    "import './d'",
+		"e.js":    "import './main'",
+	}
+	g, err := NewModuleGraph(r, "./main")
+	test.Error(t, err)
+	cycles := []string{}
+	for _, cycle := range g.Cycles() {
+		cycles = append(cycles, modulePaths(cycle))
+	}
+	test.String(t, strings.Join(cycles, ", "), "a.js b.js c.js, d.js")
+}
+
+func TestModuleGraphMissingExports(t *testing.T) {
+	r := MemoryFiles{
+		"main.js": "import a, { b, c } from './lib'; export { d, e } from './lib'; import { f } from './star'; import { g } from 'ext'; import { h } from './dyn'; const { i } = require('./lib')",
+		"lib.js":  "export const b = 1; export function d() {}",
+		"star.js": "export * from 'ext'",
+		"dyn.js":  "module.exports = { ...x }",
+	}
+	g, err := NewModuleGraph(r, "./main")
+	test.Error(t, err)
+	missing := []string{}
+	for _, m := range g.MissingExports() {
+		missing = append(missing, m.Error())
+	}
+	test.String(t, strings.Join(missing, ", "), "main.js: lib.js does not export default, main.js: lib.js does not export c, main.js: lib.js does not export e")
+
+	_, ok := g.Module("dyn.js").ExportNames()
+	test.That(t, !ok)
+}
+
+func TestAnalyzeModule(t *testing.T) {
+	var tests = []struct {
+		js       string
+		format   ModuleFormat
+		deps     string
+		exports  string
+		esModule bool
+	}{
+		{"a = 1", ScriptFormat, "", "", false},
+		{"import a from 'a'; export const b = require('b')", ESModuleFormat, "import:a require():b", "b", false},
+		{"const a = require('a'); require(b); require('c', d)", CommonJSFormat, "require():a require(?):", "", false},
+		{"require(`a`); import(`b`); require(`${c}`)", CommonJSFormat, "require():a import():b require(?):", "", false},
+		{"module.exports = function () {}", CommonJSFormat, "", "default", false},
+		{"module.exports = { a, b: 1, 'c': 2, [d]: 3, ...e, f() {} }", CommonJSFormat, "", "default a b c f", false},
+		{"exports.a = 1; exports['b'] = 2; module.exports.c = 3; exports.a = 4; exports[d] = 5", CommonJSFormat, "", "a b c", false},
+		{"Object.defineProperty(exports, '__esModule', { value: true }); Object.defineProperty(exports, 'a', { get: f })", CommonJSFormat, "", "a", true},
+		{"exports.__esModule = true; module.exports = { __esModule: true }", CommonJSFormat, "", "default", true},
+		{"if (typeof module !== 'undefined') { f(module) }", CommonJSFormat, "", "", false},
+		{"let exports = {}, module; exports.a = 1; module.exports = 2; function require() {} require('a')", ScriptFormat, "", "", false},
+		{"export default 1; module.exports.a = 2", MixedFormat, "", "default a", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.js, func(t *testing.T) {
+			ast, err := Parse(parse.NewInputString(tt.js))
+			test.Error(t, err)
+			m := AnalyzeModule("main.js", ast)
+			deps := []string{}
+			for _, dep := range m.Deps {
+				deps = append(deps, dep.Kind.String()+":"+dep.Specifier)
+			}
+			exports := []string{}
+			for _, export := range m.Exports {
+				exports = append(exports, export.Name)
+			}
+			test.T(t, m.Format, tt.format)
+			test.String(t, strings.Join(deps, " "), tt.deps)
+			test.String(t, strings.Join(exports, " "), tt.exports)
+			test.T(t, m.ESModule, tt.esModule)
+		})
+	}
+}
+
+func TestAnalyzeModuleRequire(t *testing.T) {
+	var tests = []struct {
+		js             string
+		names          string
+		dynamicExports bool
+	}{
+		{"require('a'); const b = require('b'); f(require('c'))", "a: b:* c:*", false},
+		{"const { a, b: c, 'd': e, [f]: g, ...h } = require('a'); var { i } = require('i'), j = require('j')", "a:a,b,d,*,* i:i j:*", false},
+		{"require('a').b; require('c')['d'](); require('e')[f]", "a:b c:d e:*", false},
+		{"module.exports = require('a')", "a:*", false},
+		{"module.exports = { a, ...b }", "", true},
+		{"module.exports = { [a]: 1 }; exports.b = 2", "", true},
+		{"exports[a] = 1", "", true},
+		{"Object.defineProperty(exports, a, { value: 1 })", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.js, func(t *testing.T) {
+			ast, err := Parse(parse.NewInputString(tt.js))
+			test.Error(t, err)
+			m := AnalyzeModule("main.js", ast)
+			deps := []string{}
+			for _, dep := range m.Deps {
+				deps = append(deps, dep.Specifier+":"+strings.Join(dep.Names, ","))
+			}
+			test.String(t, strings.Join(deps, " "), tt.names)
+			test.T(t, m.DynamicExports, tt.dynamicExports)
+		})
+	}
+}
+
+func TestModuleGraphCommonJS(t *testing.T) {
+	r := MemoryFiles{
+		"main.js":  "module.exports = require('./lib')",
+		"lib.js":   "exports.a = 1; exports.b = 2; exports.default = 3",
+		"index.js": "import { a } from './main'; import b from './main'",
+	}
+	g, err := NewModuleGraph(r, "./index")
+	test.Error(t, err)
+	main := g.Module("main.js")
+	test.T(t, main.Format, CommonJSFormat)
+	test.T(t, len(main.Stars), 1)
+	test.String(t, main.Stars[0].Module.Path, "lib.js")
+
+	names, ok := main.ExportNames()
+	test.That(t, ok)
+	test.String(t, strings.Join(names, " "), "a b default")
+	chain, _, ok := main.ResolveExport("a")
+	test.That(t, ok)
+	test.String(t, modulePaths(chain), "main.js lib.js")
+	test.T(t, len(g.MissingExports()), 0)
+
+	// ES modules are strict mode code, CommonJS modules are not
+	r = MemoryFiles{
+		"main.js": "import { a } from './lib'; const b = await a",
+		"lib.js":  "with (Math) var a = PI; exports.a = a; var static",
+	}
+	g, err = NewModuleGraph(r, "./main")
+	test.Error(t, err)
+	test.T(t, g.Module("main.js").Format, ESModuleFormat)
+	test.T(t, g.Module("lib.js").Format, CommonJSFormat)
+}
@@ -161,7 +161,7 @@ func TestJS(t *testing.T) {
 		{"x = y.z;", "x = y.z; "},
 
 		// NewTargetExpr
-		{"x = new.target;", "x = new.target; "},
+		{"function f() { x = new.target; }", "function f () { x = new.target; }; "},
 
 		// ImportMetaExpr
 		{"x = import.meta;", "x = import.meta; "},
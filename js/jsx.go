@@ -0,0 +1,499 @@
+package js
+
+import (
+	"bytes"
+	"unicode/utf8"
+)
+
+// JSXName is a JSX element or attribute name that is not a reference to a variable, such as div, data-id, or svg:rect.
+type JSXName struct {
+	Data []byte
+	Span
+}
+
+func (n JSXName) String() string {
+	return string(n.Data)
+}
+
+// JS converts the node back to valid JavaScript
+func (n JSXName) JS() string {
+	return string(n.Data)
+}
+
+// JSXAttribute is an attribute of a JSX element, or a spread attribute {...Value} if Name is nil.
+type JSXAttribute struct {
+	Name  *JSXName // can be nil
+	Value IExpr    // can be nil, LiteralExpr (string), JSXExprContainer, or JSXElement
+	Span
+}
+
+func (n JSXAttribute) String() string {
+	if n.Name == nil {
+		return "{..." + n.Value.String() + "}"
+	} else if n.Value == nil {
+		return n.Name.String()
+	}
+	return n.Name.String() + "=" + n.Value.String()
+}
+
+// JS converts the node back to valid JavaScript
+func (n JSXAttribute) JS() string {
+	if n.Name == nil {
+		return "{..." + n.Value.JS() + "}"
+	} else if n.Value == nil {
+		return n.Name.JS()
+	}
+	return n.Name.JS() + "=" + n.Value.JS()
+}
+
+// JSXExprContainer is a JSX expression container {X}, or a spread child {...X}.
+type JSXExprContainer struct {
+	X      IExpr // can be nil for empty containers such as {/* comment */}
+	Spread bool
+	Span
+}
+
+func (n JSXExprContainer) String() string {
+	s := "{"
+	if n.Spread {
+		s += "..."
+	}
+	if n.X != nil {
+		s += n.X.String()
+	}
+	return s + "}"
+}
+
+// JS converts the node back to valid JavaScript
+func (n JSXExprContainer) JS() string {
+	s := "{"
+	if n.Spread {
+		s += "..."
+	}
+	if n.X != nil {
+		s += n.X.JS()
+	}
+	return s + "}"
+}
+
+// JSXText is the text in between JSX tags.
+type JSXText struct {
+	Data []byte // raw text, see Value
+	Span
+}
+
+func (n JSXText) String() string {
+	return "Text(" + string(n.Data) + ")"
+}
+
+// JS converts the node back to valid JavaScript
+func (n JSXText) JS() string {
+	return string(n.Data)
+}
+
+// Value returns the text as it is passed to the element: lines are trimmed, lines with only whitespace are removed, the remaining lines are joined by a space, and character references are decoded. It returns nil for text that consists of whitespace and line terminators only.
+func (n JSXText) Value() []byte {
+	lines := bytes.Split(bytes.ReplaceAll(n.Data, []byte("\r\n"), []byte("\n")), []byte("\n"))
+	var b []byte
+	for i, line := range lines {
+		if i != 0 {
+			line = bytes.TrimLeft(line, " \t")
+		}
+		if i != len(lines)-1 {
+			line = bytes.TrimRight(line, " \t")
+		}
+		if len(line) != 0 {
+			if b != nil {
+				b = append(b, ' ')
+			}
+			b = append(b, line...)
+		}
+	}
+	if b == nil {
+		return nil
+	}
+	return DecodeJSXEntities(b)
+}
+
+// JSXElement is a JSX element, or a fragment if Name is nil.
+type JSXElement struct {
+	Name        IExpr // can be nil, JSXName, Var, or DotExpr
+	Attrs       []JSXAttribute
+	Children    []IExpr // JSXText, JSXExprContainer, or JSXElement
+	SelfClosing bool
+	Span
+}
+
+func (n JSXElement) String() string {
+	s := "JSX(<"
+	if n.Name != nil {
+		s += n.Name.String()
+	}
+	for _, attr := range n.Attrs {
+		s += " " + attr.String()
+	}
+	if n.SelfClosing {
+		return s + " />)"
+	}
+	s += ">"
+	for _, child := range n.Children {
+		s += child.String()
+	}
+	s += "</"
+	if n.Name != nil {
+		s += n.Name.String()
+	}
+	return s + ">)"
+}
+
+// JS converts the node back to valid JavaScript
+func (n JSXElement) JS() string {
+	s := "<"
+	if n.Name != nil {
+		s += n.Name.JS()
+	}
+	for _, attr := range n.Attrs {
+		s += " " + attr.JS()
+	}
+	if n.SelfClosing {
+		return s + " />"
+	}
+	s += ">"
+	for _, child := range n.Children {
+		s += child.JS()
+	}
+	s += "</"
+	if n.Name != nil {
+		s += n.Name.JS()
+	}
+	return s + ">"
+}
+
+func (n JSXName) exprNode()          {}
+func (n JSXExprContainer) exprNode() {}
+func (n JSXText) exprNode()          {}
+func (n JSXElement) exprNode()       {}
+
+////////////////////////////////////////////////////////////////
+
+// parseJSXElement parses a JSX element or fragment, the current token must be <.
+func (p *Parser) parseJSXElement() (element *JSXElement) {
+	start := p.pos
+	p.next()
+	if element = p.parseJSXTag(start); element != nil {
+		p.next()
+	}
+	return
+}
+
+// parseJSXTag parses a JSX element after its <, and leaves the final > as the current token. The next token is either JSX text or a regular token, which is up to the caller.
+func (p *Parser) parseJSXTag(start int) (element *JSXElement) {
+	element = &JSXElement{}
+	if !p.isGt() {
+		if element.Name = p.parseJSXElementName(); element.Name == nil {
+			return nil
+		}
+		for p.tt != DivToken && !p.isGt() {
+			if p.tt == OpenBraceToken {
+				attrStart := p.pos
+				p.next()
+				if !p.consume("JSX spread attribute", EllipsisToken) {
+					return nil
+				}
+				value := p.parseExpression(OpAssign)
+				if p.tt != CloseBraceToken {
+					p.fail("JSX spread attribute", CloseBraceToken)
+					return nil
+				}
+				p.next()
+				element.Attrs = append(element.Attrs, JSXAttribute{nil, value, p.span(attrStart)})
+				continue
+			}
+
+			attr := JSXAttribute{}
+			attrStart := p.pos
+			if attr.Name = p.parseJSXName(true); attr.Name == nil {
+				return nil
+			}
+			if p.tt == EqToken {
+				if tt, data := p.l.JSXString(); tt == StringToken {
+					attr.Value = &LiteralExpr{StringToken, data, Span{p.l.r.Offset() - len(data), p.l.r.Offset()}}
+					p.next()
+				} else if p.next(); p.tt == OpenBraceToken {
+					containerStart := p.pos
+					p.next()
+					if p.tt == CloseBraceToken {
+						p.fail("JSX attribute", StringToken, OpenBraceToken, LtToken)
+						return nil
+					}
+					x := p.parseExpression(OpAssign)
+					if !p.consume("JSX attribute", CloseBraceToken) {
+						return nil
+					}
+					attr.Value = &JSXExprContainer{X: x, Span: p.span(containerStart)}
+				} else if p.tt == LtToken {
+					element := p.parseJSXElement()
+					if element == nil {
+						return nil
+					}
+					attr.Value = element
+				} else {
+					p.fail("JSX attribute", StringToken, OpenBraceToken, LtToken)
+					return nil
+				}
+			}
+			attr.Span = p.span(attrStart)
+			element.Attrs = append(element.Attrs, attr)
+			if p.tt == ErrorToken {
+				p.fail("JSX element")
+				return nil
+			}
+		}
+		if p.tt == DivToken {
+			p.next()
+			if !p.isGt() {
+				p.fail("JSX element", GtToken)
+				return nil
+			}
+			element.SelfClosing = true
+			element.Span = Span{start, p.pos + 1}
+			return
+		}
+	}
+
+	// children
+	for {
+		textStart := p.l.r.Offset()
+		tt, data := p.l.JSXText()
+		if tt == ErrorToken {
+			p.tt = ErrorToken
+			p.fail("JSX text")
+			return nil
+		} else if len(data) != 0 {
+			element.Children = append(element.Children, &JSXText{data, Span{textStart, textStart + len(data)}})
+		}
+		p.next()
+
+		childStart := p.pos
+		if p.tt == OpenBraceToken {
+			container := &JSXExprContainer{}
+			p.next()
+			if p.tt == EllipsisToken {
+				container.Spread = true
+				p.next()
+			}
+			if p.tt != CloseBraceToken || container.Spread {
+				container.X = p.parseExpression(OpExpr)
+			}
+			if p.tt != CloseBraceToken {
+				p.fail("JSX expression container", CloseBraceToken)
+				return nil
+			}
+			container.Span = Span{childStart, p.pos + 1}
+			element.Children = append(element.Children, container)
+		} else if p.tt == LtToken {
+			p.next()
+			if p.tt == DivToken {
+				// closing tag
+				p.next()
+				var name IExpr
+				if !p.isGt() {
+					if name = p.parseJSXElementName(); name == nil {
+						return nil
+					}
+				}
+				if (name == nil) != (element.Name == nil) || name != nil && name.JS() != element.Name.JS() {
+					expected := ""
+					if element.Name != nil {
+						expected = element.Name.JS()
+					}
+					p.failMessage("expected closing tag </%s> in JSX element", expected)
+					return nil
+				} else if !p.isGt() {
+					p.fail("JSX closing tag", GtToken)
+					return nil
+				}
+				element.Span = Span{start, p.pos + 1}
+				return
+			}
+			child := p.parseJSXTag(childStart)
+			if child == nil {
+				return nil
+			}
+			element.Children = append(element.Children, child)
+		} else {
+			p.fail("JSX element")
+			return nil
+		}
+	}
+}
+
+// parseJSXElementName parses the name of an element, which is a JSXName for intrinsic elements such as div, and an expression referencing a component otherwise.
+func (p *Parser) parseJSXElementName() IExpr {
+	start := p.pos
+	if p.tt != ThisToken && (!IsIdentifierName(p.tt) || p.l.r.Peek(0) != '.') {
+		if IsIdentifierName(p.tt) && p.l.r.Peek(0) != '-' && p.l.r.Peek(0) != ':' && (p.data[0] < 'a' || 'z' < p.data[0]) {
+			v := p.use(p.data, p.pos)
+			p.next()
+			return v
+		}
+		return p.parseJSXName(false)
+	}
+
+	// member expression
+	var x IExpr
+	if p.tt == ThisToken {
+		x = &LiteralExpr{p.tt, p.data, p.tokenSpan()}
+	} else {
+		x = p.use(p.data, p.pos)
+	}
+	p.next()
+	for p.tt == DotToken {
+		p.next()
+		if !IsIdentifierName(p.tt) {
+			p.fail("JSX element name", IdentifierToken)
+			return nil
+		}
+		x = &DotExpr{x, LiteralExpr{IdentifierToken, p.data, p.tokenSpan()}, OpMember, Span{start, p.pos + len(p.data)}}
+		p.next()
+	}
+	return x
+}
+
+// parseJSXName parses an identifier that may contain dashes, optionally followed by a colon and another such identifier.
+func (p *Parser) parseJSXName(isAttr bool) *JSXName {
+	in := "JSX element name"
+	if isAttr {
+		in = "JSX attribute name"
+	}
+	if !IsIdentifierName(p.tt) {
+		p.fail(in, IdentifierToken)
+		return nil
+	}
+	name := &JSXName{Data: p.l.JSXIdentifier(p.data), Span: Span{p.pos, 0}}
+	p.next()
+	if p.tt == ColonToken {
+		p.next()
+		if !IsIdentifierName(p.tt) {
+			p.fail(in, IdentifierToken)
+			return nil
+		}
+		data := p.l.JSXIdentifier(p.data)
+		name.Data = append(append(append([]byte{}, name.Data...), ':'), data...)
+		p.next()
+	}
+	name.End = p.end
+	return name
+}
+
+// isGt returns true if the current token starts with >, in which case the token is shortened to >. Operators such as >= are split since the closing > of a JSX tag may be followed by JSX text, and the closing > of TypeScript type arguments may be followed by another >.
+func (p *Parser) isGt() bool {
+	switch p.tt {
+	case GtToken:
+		return true
+	case GtEqToken, GtGtToken, GtGtEqToken, GtGtGtToken, GtGtGtEqToken:
+		p.l.r.Move(1 - len(p.data))
+		p.l.r.Skip()
+		p.tt, p.data = GtToken, p.data[:1]
+		return true
+	}
+	return false
+}
+
+// DecodeJSXEntities returns the text with its HTML character references, such as &amp; or &#38;, replaced by their UTF-8 encoding. Unknown character references are kept.
+func DecodeJSXEntities(b []byte) []byte {
+	i := bytes.IndexByte(b, '&')
+	if i == -1 {
+		return b
+	}
+
+	dst := append([]byte{}, b[:i]...)
+	for i < len(b) {
+		if b[i] != '&' {
+			dst = append(dst, b[i])
+			i++
+			continue
+		}
+		end := bytes.IndexByte(b[i:], ';')
+		if end == -1 || 10 < end {
+			dst = append(dst, '&')
+			i++
+			continue
+		}
+		ref := b[i+1 : i+end]
+		r := rune(-1)
+		if 1 < len(ref) && ref[0] == '#' {
+			r = 0
+			base, digits := rune(10), ref[1:]
+			if digits[0] == 'x' {
+				base, digits = 16, digits[1:]
+			}
+			for _, c := range digits {
+				d := rune(-1)
+				if '0' <= c && c <= '9' {
+					d = rune(c - '0')
+				} else if base == 16 && 'a' <= c|0x20 && c|0x20 <= 'f' {
+					d = rune(c|0x20-'a') + 10
+				}
+				if d == -1 || utf8.MaxRune < r {
+					r = -1
+					break
+				}
+				r = r*base + d
+			}
+			if len(digits) == 0 || utf8.MaxRune < r {
+				r = -1
+			}
+		} else if c, ok := jsxEntities[string(ref)]; ok {
+			r = c
+		}
+		if r == -1 {
+			dst = append(dst, '&')
+			i++
+			continue
+		}
+		dst = append(dst, string(r)...)
+		i += end + 1
+	}
+	return dst
+}
+
+// jsxEntities are the named character references of HTML 4 supported by JSX.
+var jsxEntities = map[string]rune{
+	"AElig": 0xC6, "Aacute": 0xC1, "Acirc": 0xC2, "Agrave": 0xC0, "Alpha": 0x391, "Aring": 0xC5, "Atilde": 0xC3,
+	"Auml": 0xC4, "Beta": 0x392, "Ccedil": 0xC7, "Chi": 0x3A7, "Dagger": 0x2021, "Delta": 0x394, "ETH": 0xD0,
+	"Eacute": 0xC9, "Ecirc": 0xCA, "Egrave": 0xC8, "Epsilon": 0x395, "Eta": 0x397, "Euml": 0xCB, "Gamma": 0x393,
+	"Iacute": 0xCD, "Icirc": 0xCE, "Igrave": 0xCC, "Iota": 0x399, "Iuml": 0xCF, "Kappa": 0x39A, "Lambda": 0x39B,
+	"Mu": 0x39C, "Ntilde": 0xD1, "Nu": 0x39D, "OElig": 0x152, "Oacute": 0xD3, "Ocirc": 0xD4, "Ograve": 0xD2,
+	"Omega": 0x3A9, "Omicron": 0x39F, "Oslash": 0xD8, "Otilde": 0xD5, "Ouml": 0xD6, "Phi": 0x3A6, "Pi": 0x3A0,
+	"Prime": 0x2033, "Psi": 0x3A8, "Rho": 0x3A1, "Scaron": 0x160, "Sigma": 0x3A3, "THORN": 0xDE, "Tau": 0x3A4,
+	"Theta": 0x398, "Uacute": 0xDA, "Ucirc": 0xDB, "Ugrave": 0xD9, "Upsilon": 0x3A5, "Uuml": 0xDC, "Xi": 0x39E,
+	"Yacute": 0xDD, "Yuml": 0x178, "Zeta": 0x396, "aacute": 0xE1, "acirc": 0xE2, "acute": 0xB4, "aelig": 0xE6,
+	"agrave": 0xE0, "alefsym": 0x2135, "alpha": 0x3B1, "amp": 0x26, "and": 0x2227, "ang": 0x2220, "apos": 0x27,
+	"aring": 0xE5, "asymp": 0x2248, "atilde": 0xE3, "auml": 0xE4, "bdquo": 0x201E, "beta": 0x3B2, "brvbar": 0xA6,
+	"bull": 0x2022, "cap": 0x2229, "ccedil": 0xE7, "cedil": 0xB8, "cent": 0xA2, "chi": 0x3C7, "circ": 0x2C6,
+	"clubs": 0x2663, "cong": 0x2245, "copy": 0xA9, "crarr": 0x21B5, "cup": 0x222A, "curren": 0xA4, "dArr": 0x21D3,
+	"dagger": 0x2020, "darr": 0x2193, "deg": 0xB0, "delta": 0x3B4, "diams": 0x2666, "divide": 0xF7, "eacute": 0xE9,
+	"ecirc": 0xEA, "egrave": 0xE8, "empty": 0x2205, "emsp": 0x2003, "ensp": 0x2002, "epsilon": 0x3B5, "equiv": 0x2261,
+	"eta": 0x3B7, "eth": 0xF0, "euml": 0xEB, "euro": 0x20AC, "exist": 0x2203, "fnof": 0x192, "forall": 0x2200,
+	"frac12": 0xBD, "frac14": 0xBC, "frac34": 0xBE, "frasl": 0x2044, "gamma": 0x3B3, "ge": 0x2265, "gt": 0x3E,
+	"hArr": 0x21D4, "harr": 0x2194, "hearts": 0x2665, "hellip": 0x2026, "iacute": 0xED, "icirc": 0xEE, "iexcl": 0xA1,
+	"igrave": 0xEC, "image": 0x2111, "infin": 0x221E, "int": 0x222B, "iota": 0x3B9, "iquest": 0xBF, "isin": 0x2208,
+	"iuml": 0xEF, "kappa": 0x3BA, "lArr": 0x21D0, "lambda": 0x3BB, "lang": 0x2329, "laquo": 0xAB, "larr": 0x2190,
+	"lceil": 0x2308, "ldquo": 0x201C, "le": 0x2264, "lfloor": 0x230A, "lowast": 0x2217, "loz": 0x25CA, "lrm": 0x200E,
+	"lsaquo": 0x2039, "lsquo": 0x2018, "lt": 0x3C, "macr": 0xAF, "mdash": 0x2014, "micro": 0xB5, "middot": 0xB7,
+	"minus": 0x2212, "mu": 0x3BC, "nabla": 0x2207, "nbsp": 0xA0, "ndash": 0x2013, "ne": 0x2260, "ni": 0x220B, "not": 0xAC,
+	"notin": 0x2209, "nsub": 0x2284, "ntilde": 0xF1, "nu": 0x3BD, "oacute": 0xF3, "ocirc": 0xF4, "oelig": 0x153,
+	"ograve": 0xF2, "oline": 0x203E, "omega": 0x3C9, "omicron": 0x3BF, "oplus": 0x2295, "or": 0x2228, "ordf": 0xAA,
+	"ordm": 0xBA, "oslash": 0xF8, "otilde": 0xF5, "otimes": 0x2297, "ouml": 0xF6, "para": 0xB6, "part": 0x2202,
+	"permil": 0x2030, "perp": 0x22A5, "phi": 0x3C6, "pi": 0x3C0, "piv": 0x3D6, "plusmn": 0xB1, "pound": 0xA3,
+	"prime": 0x2032, "prod": 0x220F, "prop": 0x221D, "psi": 0x3C8, "quot": 0x22, "rArr": 0x21D2, "radic": 0x221A,
+	"rang": 0x232A, "raquo": 0xBB, "rarr": 0x2192, "rceil": 0x2309, "rdquo": 0x201D, "real": 0x211C, "reg": 0xAE,
+	"rfloor": 0x230B, "rho": 0x3C1, "rlm": 0x200F, "rsaquo": 0x203A, "rsquo": 0x2019, "sbquo": 0x201A, "scaron": 0x161,
+	"sdot": 0x22C5, "sect": 0xA7, "shy": 0xAD, "sigma": 0x3C3, "sigmaf": 0x3C2, "sim": 0x223C, "spades": 0x2660,
+	"sub": 0x2282, "sube": 0x2286, "sum": 0x2211, "sup": 0x2283, "sup1": 0xB9, "sup2": 0xB2, "sup3": 0xB3, "supe": 0x2287,
+	"szlig": 0xDF, "tau": 0x3C4, "there4": 0x2234, "theta": 0x3B8, "thetasym": 0x3D1, "thinsp": 0x2009, "thorn": 0xFE,
+	"tilde": 0x2DC, "times": 0xD7, "trade": 0x2122, "uArr": 0x21D1, "uacute": 0xFA, "uarr": 0x2191, "ucirc": 0xFB,
+	"ugrave": 0xF9, "uml": 0xA8, "upsih": 0x3D2, "upsilon": 0x3C5, "uuml": 0xFC, "weierp": 0x2118, "xi": 0x3BE,
+	"yacute": 0xFD, "yen": 0xA5, "yuml": 0xFF, "zeta": 0x3B6, "zwj": 0x200D, "zwnj": 0x200C,
+}
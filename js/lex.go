@@ -39,6 +39,7 @@ type Lexer struct {
 	prevNumericLiteral bool
 	level              int
 	templateLevels     []int
+	noHTMLComments     bool // <!-- and --> do not start comments, such as in modules
 }
 
 // NewLexer returns a new Lexer for a given io.Reader.
@@ -78,6 +79,71 @@ func (l *Lexer) RegExp() (TokenType, []byte) {
 	return ErrorToken, nil
 }
 
+// JSXText parses JSX text up to the next { or <, and should be called after the > of a JSX opening tag or the } of a JSX expression container. It returns ErrorToken for a > or } in the text, or when the input ends.
+func (l *Lexer) JSXText() (TokenType, []byte) {
+	for {
+		c := l.r.Peek(0)
+		if c == '{' || c == '<' {
+			return JSXTextToken, l.r.Shift()
+		} else if c == '>' || c == '}' {
+			l.err = parse.NewErrorLexer(l.r, "unexpected %c", c)
+			return ErrorToken, nil
+		} else if c == 0 && l.r.Err() != nil {
+			return ErrorToken, nil
+		}
+		l.r.Move(1)
+	}
+}
+
+// JSXString parses a JSX attribute string and should be called after the = of a JSX attribute. Contrary to JS strings, it may contain line terminators and it has no escape sequences. It skips whitespace and returns ErrorToken if there is no string.
+func (l *Lexer) JSXString() (TokenType, []byte) {
+	for l.consumeWhitespace() || l.consumeLineTerminator() {
+	}
+	l.r.Skip()
+
+	quote := l.r.Peek(0)
+	if quote != '"' && quote != '\'' {
+		return ErrorToken, nil
+	}
+	l.r.Move(1)
+	for {
+		c := l.r.Peek(0)
+		if c == quote {
+			l.r.Move(1)
+			return StringToken, l.r.Shift()
+		} else if c == 0 && l.r.Err() != nil {
+			l.err = parse.NewErrorLexer(l.r, "unexpected EOF in JSX string")
+			return ErrorToken, nil
+		}
+		l.r.Move(1)
+	}
+}
+
+// JSXIdentifier extends the identifier name that was just returned by Next with dashes and identifier characters, as allowed in JSX element and attribute names.
+func (l *Lexer) JSXIdentifier(data []byte) []byte {
+	if l.r.Peek(0) != '-' {
+		return data
+	}
+	l.r.Move(-len(data))
+	l.r.Skip()
+	l.r.Move(len(data))
+	for {
+		c := l.r.Peek(0)
+		if c == '-' || identifierTable[c] && c != '\\' {
+			l.r.Move(1)
+		} else if 0xC0 <= c {
+			if r, n := l.r.PeekRune(0); r == '\u200C' || r == '\u200D' || unicode.IsOneOf(identifierContinue, r) {
+				l.r.Move(n)
+			} else {
+				break
+			}
+		} else {
+			break
+		}
+	}
+	return l.r.Shift()
+}
+
 // Next returns the next Token. It returns ErrorToken when an error was encountered. Using Err() one can retrieve the error message.
 func (l *Lexer) Next() (TokenType, []byte) {
 	prevLineTerminator := l.prevLineTerminator
@@ -177,7 +243,7 @@ func (l *Lexer) Next() (TokenType, []byte) {
 		l.r.Move(1)
 		return OpenBracketToken, l.r.Shift()
 	case '<', '-':
-		if l.consumeHTMLLikeCommentToken(prevLineTerminator) {
+		if !l.noHTMLComments && l.consumeHTMLLikeCommentToken(prevLineTerminator) {
 			return CommentToken, l.r.Shift()
 		} else if tt := l.consumeOperatorToken(); tt != ErrorToken {
 			return tt, l.r.Shift()
@@ -185,6 +251,9 @@ func (l *Lexer) Next() (TokenType, []byte) {
 	case '`':
 		l.templateLevels = append(l.templateLevels, l.level)
 		return l.consumeTemplateToken(), l.r.Shift()
+	case '@':
+		l.r.Move(1)
+		return AtToken, l.r.Shift()
 	case '#':
 		l.r.Move(1)
 		if l.consumeIdentifierToken() {
@@ -495,12 +564,16 @@ func (l *Lexer) consumeIdentifierToken() bool {
 		} else {
 			return false
 		}
-	} else if !l.consumeUnicodeEscape() {
+	} else if !l.consumeIdentifierEscape(true) {
 		return false
 	}
 	for {
 		c := l.r.Peek(0)
-		if identifierTable[c] {
+		if c == '\\' {
+			if !l.consumeIdentifierEscape(false) {
+				break
+			}
+		} else if identifierTable[c] {
 			l.r.Move(1)
 		} else if 0xC0 <= c {
 			if r, n := l.r.PeekRune(0); r == '\u200C' || r == '\u200D' || unicode.IsOneOf(identifierContinue, r) {
@@ -515,6 +588,20 @@ func (l *Lexer) consumeIdentifierToken() bool {
 	return true
 }
 
+// consumeIdentifierEscape consumes a unicode escape sequence in an identifier, which must encode a character that is allowed at the start or in the rest of an identifier.
+func (l *Lexer) consumeIdentifierEscape(start bool) bool {
+	mark := l.r.Pos()
+	if !l.consumeUnicodeEscape() {
+		return false
+	}
+	r, _ := decodeUnicodeEscape(l.r.Lexeme()[mark:])
+	if r != '$' && (start && r != '_' && !unicode.IsOneOf(identifierStart, r) || !start && r != '\u200C' && r != '\u200D' && !unicode.IsOneOf(identifierContinue, r)) {
+		l.r.Rewind(mark)
+		return false
+	}
+	return true
+}
+
 func (l *Lexer) consumeNumericToken() TokenType {
 	// assume to be on 0 1 2 3 4 5 6 7 8 9 .
 	first := l.r.Peek(0)
@@ -49,7 +49,7 @@ func TestTokens(t *testing.T) {
 		{"x=y-->10\n", TTs{IdentifierToken, EqToken, IdentifierToken, DecrToken, GtToken, DecimalToken, LineTerminatorToken}},
 		{"  /*comment*/ -->nothing\n", TTs{CommentToken, DecrToken, GtToken, IdentifierToken, LineTerminatorToken}},
 		{"1 /*comment\nmultiline*/ -->nothing\n", TTs{DecimalToken, CommentLineTerminatorToken, CommentToken, LineTerminatorToken}},
-		{"$ _\u200C \\u2000 \u200C", TTs{IdentifierToken, IdentifierToken, IdentifierToken, ErrorToken}},
+		{"$ _\u200C \\u2000 \u200C", TTs{IdentifierToken, IdentifierToken, ErrorToken}},
 		{">>>=>>>>=", TTs{GtGtGtEqToken, GtGtGtToken, GtEqToken}},
 		{"1/", TTs{DecimalToken, DivToken}},
 		{"1/=", TTs{DecimalToken, DivEqToken}},
@@ -102,7 +102,10 @@ func TestTokens(t *testing.T) {
 		{"Ø a〉", TTs{IdentifierToken, IdentifierToken, ErrorToken}},
 		{"\u00A0\uFEFF\u2000", TTs{}},
 		{"\u2028\u2029", TTs{LineTerminatorToken}},
-		{"\\u0029ident", TTs{IdentifierToken}},
+		{"\\u0061ident", TTs{IdentifierToken}},
+		{"\\u0029ident", TTs{ErrorToken}},
+		{"a\\u0020", TTs{IdentifierToken, ErrorToken}},
+		{"\\u{1F600}", TTs{ErrorToken}},
 		{"\\u{0029FEF}ident", TTs{IdentifierToken}},
 		{"\\u{}", TTs{ErrorToken}},
 		{"\\ugident", TTs{ErrorToken}},
@@ -239,9 +242,9 @@ func TestOffset(t *testing.T) {
 }
 
 func TestLexerErrors(t *testing.T) {
-	l := NewLexer(parse.NewInputString("@"))
+	l := NewLexer(parse.NewInputString("§"))
 	l.Next()
-	test.T(t, l.Err().(*parse.Error).Message, "unexpected @")
+	test.T(t, l.Err().(*parse.Error).Message, "unexpected §")
 
 	l = NewLexer(parse.NewInputString("\x00"))
 	l.Next()
@@ -0,0 +1,150 @@
+// Package lint checks JavaScript ASTs of the js package with lint rules, which report diagnostics with optional fixes.
+package lint
+
+import (
+	"bytes"
+	"reflect"
+	"sort"
+
+	"github.com/tdewolff/parse/v2"
+	"github.com/tdewolff/parse/v2/js"
+)
+
+// Rule is a lint rule that is called for the nodes of the given types.
+type Rule struct {
+	Name string
+	Doc  string
+
+	// Nodes are the types of nodes that Check is called for, given as nil pointers such as (*js.DebuggerStmt)(nil). Check is called for all nodes if Nodes is empty. Rules that check the whole program use (*js.AST)(nil) to be called once for the root.
+	Nodes []js.INode
+	Check func(c *Context, p *js.Path)
+}
+
+// Edit replaces the source code in Span by Text.
+type Edit struct {
+	js.Span
+	Text string
+}
+
+// Diagnostic is a problem found by a rule, with a fix that consists of zero or more edits.
+type Diagnostic struct {
+	Rule    string
+	Message string
+	js.Span
+	Fix []Edit
+}
+
+// Position returns the line and column of the start of the diagnostic, which are both 1-based.
+func (d Diagnostic) Position(src []byte) (line, col int) {
+	line, col, _ = parse.Position(bytes.NewReader(src), d.Start)
+	return line, col
+}
+
+func (d Diagnostic) String() string {
+	return d.Message + " (" + d.Rule + ")"
+}
+
+// Context is passed to the rules and gives the information of the AST. The scope that contains a node is given by js.Path.Scope.
+type Context struct {
+	AST  *js.AST
+	Info *js.ScopeInfo // resolves the occurrences of variables to their declarations
+
+	rule  *Rule
+	diags []Diagnostic
+}
+
+// Report reports a problem with the node. The edits are the fix of the problem and can be omitted.
+func (c *Context) Report(n js.INode, message string, fix ...Edit) {
+	start, end := n.Offsets()
+	c.diags = append(c.diags, Diagnostic{
+		Rule:    c.rule.Name,
+		Message: message,
+		Span:    js.Span{Start: start, End: end},
+		Fix:     fix,
+	})
+}
+
+// Lint checks the AST with the rules and returns the diagnostics sorted by position.
+func Lint(ast *js.AST, rules []*Rule) []Diagnostic {
+	c := &Context{
+		AST:  ast,
+		Info: js.NewScopeInfo(ast),
+	}
+	v := &visitor{c: c, rules: map[reflect.Type][]*Rule{}}
+	for _, rule := range rules {
+		if len(rule.Nodes) == 0 {
+			v.all = append(v.all, rule)
+		}
+		for _, n := range rule.Nodes {
+			t := reflect.TypeOf(n)
+			v.rules[t] = append(v.rules[t], rule)
+		}
+	}
+	js.WalkPath(v, ast)
+
+	sort.SliceStable(c.diags, func(i, j int) bool {
+		if c.diags[i].Start != c.diags[j].Start {
+			return c.diags[i].Start < c.diags[j].Start
+		}
+		return c.diags[i].End < c.diags[j].End
+	})
+	return c.diags
+}
+
+type visitor struct {
+	c     *Context
+	rules map[reflect.Type][]*Rule
+	all   []*Rule
+}
+
+func (v *visitor) Enter(p *js.Path) js.IPathVisitor {
+	for _, rule := range v.all {
+		v.c.rule = rule
+		rule.Check(v.c, p)
+	}
+	for _, rule := range v.rules[reflect.TypeOf(p.Node)] {
+		v.c.rule = rule
+		rule.Check(v.c, p)
+	}
+	return v
+}
+
+func (v *visitor) Exit(p *js.Path) {
+}
+
+// Fix applies the fixes of the diagnostics to the source code. Fixes that overlap with an earlier fix are skipped, so that Fix may need to be called again after linting the result. It returns the number of fixes that were applied.
+func Fix(src []byte, diags []Diagnostic) ([]byte, int) {
+	// select non-overlapping fixes in order of their diagnostics
+	edits := []Edit{}
+	n := 0
+	for _, d := range diags {
+		if len(d.Fix) == 0 {
+			continue
+		}
+		overlaps := false
+		for _, edit := range d.Fix {
+			for _, prev := range edits {
+				if edit.Start < prev.End && prev.Start < edit.End || edit.Start == prev.Start {
+					overlaps = true
+				}
+			}
+		}
+		if !overlaps {
+			edits = append(edits, d.Fix...)
+			n++
+		}
+	}
+	sort.Slice(edits, func(i, j int) bool {
+		return edits[i].Start < edits[j].Start
+	})
+
+	b := make([]byte, 0, len(src))
+	pos := 0
+	for _, edit := range edits {
+		b = append(b, src[pos:edit.Start]...)
+		b = append(b, edit.Text...)
+		pos = edit.End
+	}
+	b = append(b, src[pos:]...)
+	return b, n
+}
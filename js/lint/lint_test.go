@@ -0,0 +1,107 @@
+package lint
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/tdewolff/parse/v2"
+	"github.com/tdewolff/parse/v2/js"
+	"github.com/tdewolff/test"
+)
+
+func lint(t *testing.T, src string, rules []*Rule) []Diagnostic {
+	ast, err := js.ParseWithOptions(parse.NewInputString(src), js.ParseOptions{SourceType: js.ModuleSource})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return Lint(ast, rules)
+}
+
+func format(src string, diags []Diagnostic) string {
+	sb := strings.Builder{}
+	for _, d := range diags {
+		line, col := d.Position([]byte(src))
+		sb.WriteString(strconv.Itoa(line) + ":" + strconv.Itoa(col) + " " + src[d.Start:d.End] + ": " + d.String() + "\n")
+	}
+	return sb.String()
+}
+
+func TestRules(t *testing.T) {
+	var tests = []struct {
+		rule     *Rule
+		js       string
+		expected string
+	}{
+		{NoUnusedVars, "let a = 1, b = 2; b", "1:5 a: 'a' is declared but never used (no-unused-vars)\n"},
+		{NoUnusedVars, "function f(x) { var y; let z = x; return z }", "1:10 f: 'f' is declared but never used (no-unused-vars)\n1:21 y: 'y' is declared but never used (no-unused-vars)\n"},
+		{NoUnusedVars, "class A {}\nconst {b, c: [d]} = e", "1:7 A: 'A' is declared but never used (no-unused-vars)\n2:8 b: 'b' is declared but never used (no-unused-vars)\n2:15 d: 'd' is declared but never used (no-unused-vars)\n"},
+		{NoUnusedVars, "try {} catch (e) {} (function f(a) {}); (class B {})", ""},
+		{NoUnusedVars, "export {a, b as c}; const a = 1; let b; export function f() { let g } export const [h] = i", "1:67 g: 'g' is declared but never used (no-unused-vars)\n"},
+		{NoUnusedVars, "let a; a = 1", ""},
+		{NoImplicitGlobals, "a = 1; b += 2; c++; for (d in e) ; f; var g; g = 3", "1:1 a: assignment to undeclared variable 'a' (no-implicit-globals)\n1:8 b: assignment to undeclared variable 'b' (no-implicit-globals)\n1:16 c: assignment to undeclared variable 'c' (no-implicit-globals)\n1:26 d: assignment to undeclared variable 'd' (no-implicit-globals)\n"},
+		{NoImplicitGlobals, "function f() { a = 1 } a.b = 2; x = y", "1:16 a: assignment to undeclared variable 'a' (no-implicit-globals)\n1:33 x: assignment to undeclared variable 'x' (no-implicit-globals)\n"},
+		{NoImplicitGlobals, "foo;\nfoo = 1;\nfoo = 2;", "2:1 foo: assignment to undeclared variable 'foo' (no-implicit-globals)\n3:1 foo: assignment to undeclared variable 'foo' (no-implicit-globals)\n"},
+		{NoDebugger, "debugger; if (a) debugger", "1:1 debugger;: unexpected debugger statement (no-debugger)\n1:18 debugger: unexpected debugger statement (no-debugger)\n"},
+		{NoDupeKeys, "({a: 1, 'a': 2, b, get c() {}, set c(v) {}, get c() {}, 1: 0, 0x1: 0, [d]: 0, [d]: 0})", "1:9 'a': 2: duplicate key 'a' (no-dupe-keys)\n1:45 get c() {}: duplicate key 'c' (no-dupe-keys)\n1:63 0x1: 0: duplicate key '1' (no-dupe-keys)\n"},
+		{NoDupeKeys, "({a: 1, get a() {}}); ({a, ...b, a})", "1:9 get a() {}: duplicate key 'a' (no-dupe-keys)\n1:34 a: duplicate key 'a' (no-dupe-keys)\n"},
+		{NoEqNull, "a == null; null != b; a === null; a == undefined", "1:1 a == null: comparison with null using == also matches undefined (no-eq-null)\n1:12 null != b: comparison with null using != also matches undefined (no-eq-null)\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.js, func(t *testing.T) {
+			test.String(t, format(tt.js, lint(t, tt.js, []*Rule{tt.rule})), tt.expected)
+		})
+	}
+}
+
+func TestLint(t *testing.T) {
+	src := "var a = b == null\ndebugger"
+	diags := lint(t, src, Rules)
+	test.String(t, format(src, diags), "1:5 a: 'a' is declared but never used (no-unused-vars)\n1:9 b == null: comparison with null using == also matches undefined (no-eq-null)\n2:1 debugger: unexpected debugger statement (no-debugger)\n")
+
+	paths := []string{}
+	rule := &Rule{
+		Name:  "test",
+		Nodes: []js.INode{(*js.Var)(nil), (*js.DebuggerStmt)(nil)},
+		Check: func(c *Context, p *js.Path) {
+			paths = append(paths, p.String())
+			if _, ok := p.Node.(*js.Var); ok {
+				test.T(t, p.Scope(), &c.AST.BlockStmt.Scope)
+			}
+		},
+	}
+	lint(t, src, []*Rule{rule})
+	test.T(t, paths, []string{"AST.BlockStmt.List[0].List[0].Binding", "AST.BlockStmt.List[0].List[0].Default.X", "AST.BlockStmt.List[1]"})
+}
+
+func TestFix(t *testing.T) {
+	var tests = []struct {
+		js       string
+		expected string
+		n        int
+	}{
+		{"a(); debugger; b()", "a();  b()", 1},
+		{"if (a) debugger\nelse debugger;", "if (a) ;\nelse ;", 2},
+		{"while (a) { debugger }", "while (a) {  }", 1},
+		{"let x = a\ndebugger\n(b)", "let x = a\n;\n(b)", 1},
+		{"a\ndebugger;\n[b] = c", "a\n;\n[b] = c", 1},
+		{"switch (a) { case 1: b\ndebugger\n`c` }", "switch (a) { case 1: b\n;\n`c` }", 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.js, func(t *testing.T) {
+			diags := lint(t, tt.js, Rules)
+			b, n := Fix([]byte(tt.js), diags)
+			test.String(t, string(b), tt.expected)
+			test.T(t, n, tt.n)
+		})
+	}
+
+	diags := []Diagnostic{
+		{Span: js.Span{Start: 0, End: 1}, Fix: []Edit{{js.Span{Start: 0, End: 1}, "x"}}},
+		{Span: js.Span{Start: 0, End: 3}, Fix: []Edit{{js.Span{Start: 0, End: 3}, "y"}}},
+		{Span: js.Span{Start: 2, End: 3}, Fix: []Edit{{js.Span{Start: 2, End: 3}, "z"}, {js.Span{Start: 4, End: 4}, "!"}}},
+	}
+	b, n := Fix([]byte("abcd"), diags)
+	test.String(t, string(b), "xbzd!")
+	test.T(t, n, 2)
+}
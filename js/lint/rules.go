@@ -0,0 +1,236 @@
+package lint
+
+import (
+	"strings"
+
+	"github.com/tdewolff/parse/v2/js"
+)
+
+// Rules are the built-in rules.
+var Rules = []*Rule{NoUnusedVars, NoImplicitGlobals, NoDebugger, NoDupeKeys, NoEqNull}
+
+// NoUnusedVars reports declared variables, functions, and classes that are never used, except for parameters and exports.
+var NoUnusedVars = &Rule{
+	Name:  "no-unused-vars",
+	Doc:   "disallow unused variables",
+	Nodes: []js.INode{(*js.AST)(nil)},
+	Check: func(c *Context, p *js.Path) {
+		exported := exportedVars(c.AST, c.Info)
+		for _, v := range c.Info.Vars() {
+			if v.Decl != js.VariableDecl && v.Decl != js.FunctionDecl && v.Decl != js.LexicalDecl || exported[v] {
+				continue
+			} else if v.Uses <= 1 {
+				c.Report(v, "'"+v.String()+"' is declared but never used")
+			}
+		}
+	},
+}
+
+// exportedVars returns the top-level variables that are exported by an export statement.
+func exportedVars(ast *js.AST, info *js.ScopeInfo) map[*js.Var]bool {
+	exported := map[*js.Var]bool{}
+	for _, stmt := range ast.List {
+		export, ok := stmt.(*js.ExportStmt)
+		if !ok || export.Module != nil {
+			continue
+		}
+		for _, alias := range export.List {
+			name := alias.Name
+			if name == nil {
+				name = alias.Binding
+			}
+			for _, v := range ast.BlockStmt.Scope.Declared {
+				if string(v.Data) == string(name) {
+					exported[v] = true
+				}
+			}
+		}
+		switch decl := export.Decl.(type) {
+		case *js.FuncDecl:
+			if decl.Name != nil {
+				v, _ := info.Declaration(decl.Name)
+				exported[v] = true
+			}
+		case *js.ClassDecl:
+			if decl.Name != nil {
+				v, _ := info.Declaration(decl.Name)
+				exported[v] = true
+			}
+		case *js.VarDecl:
+			js.Walk(exportVisitor{exported, info}, decl)
+		}
+	}
+	return exported
+}
+
+type exportVisitor struct {
+	exported map[*js.Var]bool
+	info     *js.ScopeInfo
+}
+
+func (e exportVisitor) Enter(n js.INode) js.IVisitor {
+	if v, ok := n.(*js.Var); ok {
+		v, _ = e.info.Declaration(v)
+		e.exported[v] = true
+	}
+	return e
+}
+
+func (e exportVisitor) Exit(n js.INode) {
+}
+
+// NoImplicitGlobals reports assignments to undeclared variables outside of with statements.
+var NoImplicitGlobals = &Rule{
+	Name:  "no-implicit-globals",
+	Doc:   "disallow assignments to undeclared variables",
+	Nodes: []js.INode{(*js.Var)(nil)},
+	Check: func(c *Context, p *js.Path) {
+		if _, s := c.Info.Declaration(p.Node.(*js.Var)); s != nil || !isAssignTarget(p) {
+			return
+		}
+		for q := p; q.Parent != nil; q = q.Parent {
+			if _, ok := q.Parent.Node.(*js.WithStmt); ok && q.Name == "Body" {
+				return
+			}
+		}
+		c.Report(p.Node, "assignment to undeclared variable '"+p.Node.String()+"'")
+	},
+}
+
+var assignOps = map[js.TokenType]bool{
+	js.EqToken:        true,
+	js.AddEqToken:     true,
+	js.SubEqToken:     true,
+	js.MulEqToken:     true,
+	js.DivEqToken:     true,
+	js.ModEqToken:     true,
+	js.ExpEqToken:     true,
+	js.LtLtEqToken:    true,
+	js.GtGtEqToken:    true,
+	js.GtGtGtEqToken:  true,
+	js.BitAndEqToken:  true,
+	js.BitOrEqToken:   true,
+	js.BitXorEqToken:  true,
+	js.AndEqToken:     true,
+	js.OrEqToken:      true,
+	js.NullishEqToken: true,
+}
+
+// isAssignTarget returns true if the node is assigned to by an assignment, increment, or decrement operator, or by a for-in or for-of statement.
+func isAssignTarget(p *js.Path) bool {
+	if p.Parent == nil {
+		return false
+	}
+	switch parent := p.Parent.Node.(type) {
+	case *js.BinaryExpr:
+		return p.Name == "X" && assignOps[parent.Op]
+	case *js.UnaryExpr:
+		return parent.Op == js.PreIncrToken || parent.Op == js.PreDecrToken || parent.Op == js.PostIncrToken || parent.Op == js.PostDecrToken
+	case *js.ForInStmt, *js.ForOfStmt:
+		return p.Name == "Init"
+	}
+	return false
+}
+
+// NoDebugger reports debugger statements, which are fixed by removing them.
+var NoDebugger = &Rule{
+	Name:  "no-debugger",
+	Doc:   "disallow debugger statements",
+	Nodes: []js.INode{(*js.DebuggerStmt)(nil)},
+	Check: func(c *Context, p *js.Path) {
+		start, end := p.Node.Offsets()
+		fix := Edit{js.Span{Start: start, End: end}, ""}
+		if p.Name != "List" || continuesStmt(nextStmt(p)) {
+			fix.Text = ";"
+		}
+		c.Report(p.Node, "unexpected debugger statement", fix)
+	},
+}
+
+// nextStmt returns the statement after the statement in a statement list, or nil if it is the last.
+func nextStmt(p *js.Path) js.IStmt {
+	var list []js.IStmt
+	switch parent := p.Parent.Node.(type) {
+	case *js.BlockStmt:
+		list = parent.List
+	case *js.CaseClause:
+		list = parent.List
+	}
+	if p.Index+1 < len(list) {
+		return list[p.Index+1]
+	}
+	return nil
+}
+
+// continuesStmt returns true if the statement starts with a token that would continue the previous statement when it is not terminated by a semicolon.
+func continuesStmt(stmt js.IStmt) bool {
+	if stmt, ok := stmt.(*js.ExprStmt); ok {
+		if s := stmt.Value.JS(); s != "" {
+			return strings.IndexByte("([`+-/", s[0]) != -1
+		}
+	}
+	return false
+}
+
+// NoDupeKeys reports duplicate property names in object literals, except for getter and setter pairs.
+var NoDupeKeys = &Rule{
+	Name:  "no-dupe-keys",
+	Doc:   "disallow duplicate keys in object literals",
+	Nodes: []js.INode{(*js.ObjectExpr)(nil)},
+	Check: func(c *Context, p *js.Path) {
+		const (
+			getter = 1 << iota
+			setter
+			value
+		)
+		kinds := map[string]int{}
+		for i, item := range p.Node.(*js.ObjectExpr).List {
+			key, kind := item.Name, value
+			if method, ok := item.Value.(*js.MethodDecl); ok {
+				key = &method.Name
+				if method.Get {
+					kind = getter
+				} else if method.Set {
+					kind = setter
+				}
+			}
+			if key == nil || key.IsComputed() {
+				continue
+			}
+			name := propertyName(key)
+			if prev := kinds[name]; prev&kind != 0 || prev != 0 && (prev|kind)&value != 0 {
+				c.Report(&p.Node.(*js.ObjectExpr).List[i], "duplicate key '"+name+"'")
+			}
+			kinds[name] |= kind
+		}
+	},
+}
+
+// propertyName returns the name of a property as a string, where string literals are decoded and numbers are converted to their canonical string.
+func propertyName(name *js.PropertyName) string {
+	switch name.Literal.TokenType {
+	case js.StringToken, js.DecimalToken, js.BinaryToken, js.OctalToken, js.HexadecimalToken:
+		if v, ok := js.Eval(&name.Literal); ok {
+			return v.ToString()
+		}
+	}
+	return string(js.DecodeIdentifier(name.Literal.Data))
+}
+
+// NoEqNull reports comparisons with null using the == and != operators, which also match undefined.
+var NoEqNull = &Rule{
+	Name:  "no-eq-null",
+	Doc:   "disallow null comparisons with == and !=",
+	Nodes: []js.INode{(*js.BinaryExpr)(nil)},
+	Check: func(c *Context, p *js.Path) {
+		n := p.Node.(*js.BinaryExpr)
+		if (n.Op == js.EqEqToken || n.Op == js.NotEqToken) && (isNull(n.X) || isNull(n.Y)) {
+			c.Report(n, "comparison with null using "+n.Op.String()+" also matches undefined")
+		}
+	},
+}
+
+func isNull(e js.IExpr) bool {
+	lit, ok := e.(*js.LiteralExpr)
+	return ok && lit.TokenType == js.NullToken
+}
@@ -0,0 +1,126 @@
+package js
+
+import (
+	"sort"
+)
+
+// MangleOptions are the options for Mangle.
+type MangleOptions struct {
+	Module bool // mangle top-level declarations that are not exported
+}
+
+// Mangle renames local variables to the shortest available names, where variables with more uses get shorter names. Global variables, exported names, and variables reachable from with statements or a direct eval keep their names.
+func Mangle(ast *AST, o MangleOptions) {
+	info := NewScopeInfo(ast)
+	global := &ast.BlockStmt.Scope
+
+	// scopes that are visible to a with statement or a direct call to eval must keep their names
+	frozen := map[*Scope]bool{}
+	freeze := func(s *Scope) {
+		for ; s != nil && !frozen[s]; s = s.Parent {
+			frozen[s] = true
+		}
+	}
+	for _, s := range info.scopes {
+		if s.Func != nil && s.Func.HasWith {
+			freeze(s)
+		}
+	}
+	exported := map[*Var]bool{}
+	for _, v := range info.order {
+		decl := info.decl[v]
+		for _, ref := range info.refs[v] {
+			if decl == nil && string(v.Data) == "eval" && ref.Name == "X" {
+				if _, ok := ref.Parent.Node.(*CallExpr); ok {
+					freeze(ref.Scope)
+				}
+			} else if decl == global && ref.Binding && isExported(ref.Path) {
+				exported[v] = true
+			}
+		}
+	}
+
+	renames := map[string][]byte{} // top-level variables by their old name
+	for _, s := range info.scopes {
+		if frozen[s] || s == global && !o.Module {
+			continue
+		}
+
+		// names of variables declared in parent scopes or undeclared that are used in this scope
+		used := map[string]bool{}
+		for _, v := range s.Undeclared {
+			used[string(resolveVar(v).Data)] = true
+		}
+
+		vars := VarArray{}
+		for _, v := range s.Declared {
+			if exported[v] || v.Decl == NoDecl {
+				used[string(v.Data)] = true
+			} else {
+				vars = append(vars, v)
+			}
+		}
+		sort.Stable(VarsByUses(vars))
+
+		i := 0
+		for _, v := range vars {
+			name := mangledName(i)
+			for used[name] || !isIdentifier(name) {
+				i++
+				name = mangledName(i)
+			}
+			used[name] = true
+			i++
+
+			data := []byte(name)
+			if s == global {
+				renames[string(v.Data)] = data
+			}
+			v.Data = data
+			for _, ref := range info.refs[v] {
+				ref.Var().Data = data
+			}
+		}
+	}
+
+	// local export clauses refer to top-level variables by name
+	renameAliases(ast.BlockStmt.List, renames)
+
+	// linked variables in the scopes that have no occurrences in the AST
+	for _, s := range info.scopes {
+		for _, v := range s.Undeclared {
+			v.Data = resolveVar(v).Data
+		}
+	}
+}
+
+// isExported returns true if the binding at the path is part of an export declaration.
+func isExported(p *Path) bool {
+	for ; p.Parent != nil; p = p.Parent {
+		if _, ok := p.Parent.Node.(*ExportStmt); ok {
+			return p.Name == "Decl"
+		}
+	}
+	return false
+}
+
+const mangleStart = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ_$"
+const mangleContinue = mangleStart + "0123456789"
+
+// mangledName returns the i-th shortest identifier name.
+func mangledName(i int) string {
+	n, length := len(mangleStart), 1
+	for n <= i {
+		i -= n
+		n *= len(mangleContinue)
+		length++
+	}
+	name := make([]byte, length)
+	name[0] = mangleStart[i%len(mangleStart)]
+	i /= len(mangleStart)
+	for j := 1; j < length; j++ {
+		name[j] = mangleContinue[i%len(mangleContinue)]
+		i /= len(mangleContinue)
+	}
+	return string(name)
+}
@@ -0,0 +1,76 @@
+package js
+
+import (
+	"testing"
+
+	"github.com/tdewolff/parse/v2"
+	"github.com/tdewolff/test"
+)
+
+func TestMangle(t *testing.T) {
+	var tests = []struct {
+		js       string
+		module   bool
+		expected string
+	}{
+		{"var foo = 1; function bar(x) { return x }", false, "var foo = 1; function bar (a) { return a; }; "},
+		{"function f(foo, bar) { bar; bar; return foo + bar }", false, "function f (b, a) { a; a; return b + a; }; "},
+		{"function f(foo) { var a = foo; return function(bar) { return a + bar + foo } }", false, "function f (a) { var b = a; return function (c) { return b + c + a; }; }; "},
+		{"function f(foo) { { let bar = 1; bar } { let baz = 2; baz } return foo }", false, "function f (a) { { let a = 1; a; }; { let a = 2; a; }; return a; }; "},
+		{"function f(foo) { return a + foo }", false, "function f (b) { return a + b; }; "},
+		{"function f(foo) { return () => arguments + foo }", false, "function f (a) { return () => { return arguments + a; }; }; "},
+		{"function f(foo) { with (o) { foo } }", false, "function f (foo) { with (o) { foo; }; }; "},
+		{"function f(foo) { function g(bar) { eval(bar) } }", false, "function f (foo) { function g (bar) { eval(bar); }; }; "},
+		{"function f(foo) { eval(foo); function g(bar) { return bar } }", false, "function f (foo) { eval(foo); function g (a) { return a; }; }; "},
+		{"try {} catch (foo) { foo }", false, "try { } catch(a) { a; }; "},
+		{"var foo = 1; let bar = 2; export var baz = foo + bar; export function qux(x) { return x }", true, "var a = 1; let b = 2; export var baz = a + b; export function qux (a) { return a; }; "},
+		{"let foo = 1; export {foo}", true, "let a = 1; export { a as foo }; "},
+		{"let a = 1, foo = 2; foo; foo; export {foo as bar, a as b}", true, "let b = 1, a = 2; a; a; export { a as bar , b }; "},
+	}
+	for _, tt := range tests {
+		t.Run(tt.js, func(t *testing.T) {
+			ast, err := ParseWithOptions(parse.NewInputString(tt.js), ParseOptions{SourceType: ModuleSource})
+			if !tt.module {
+				ast, err = Parse(parse.NewInputString(tt.js))
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			Mangle(ast, MangleOptions{Module: tt.module})
+			test.String(t, ast.JS(), tt.expected)
+		})
+	}
+}
+
+func TestMangleReserved(t *testing.T) {
+	// more variables than single letter names, where mangled names skip reserved words such as do, if, and in
+	js := "function f() { var "
+	for i := 0; i < 64*len(mangleStart); i++ {
+		if i != 0 {
+			js += ", "
+		}
+		js += "_" + mangledName(i)
+	}
+	js += " }"
+	ast, err := Parse(parse.NewInputString(js))
+	if err != nil {
+		t.Fatal(err)
+	}
+	Mangle(ast, MangleOptions{})
+
+	names := map[string]bool{}
+	for _, v := range ast.BlockStmt.List[0].(*FuncDecl).Body.Scope.Declared {
+		test.That(t, isIdentifier(string(v.Data)), string(v.Data))
+		test.That(t, !names[string(v.Data)], string(v.Data))
+		names[string(v.Data)] = true
+	}
+}
+
+func TestMangledName(t *testing.T) {
+	test.String(t, mangledName(0), "a")
+	test.String(t, mangledName(53), "$")
+	test.String(t, mangledName(54), "aa")
+	test.String(t, mangledName(55), "ba")
+	test.String(t, mangledName(54+54*64-1), "$9")
+	test.String(t, mangledName(54+54*64), "aaa")
+}
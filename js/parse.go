@@ -1,6 +1,7 @@
 package js
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"io"
@@ -9,10 +10,47 @@ import (
 	"github.com/tdewolff/parse/v2/buffer"
 )
 
+// SourceType is the goal symbol with which the source is parsed.
+type SourceType int
+
+// SourceType values.
+const (
+	AnySource    SourceType = iota // allows import and export statements and top-level await in non-strict code, where await is an identifier if the targeted version does not support top-level await
+	ScriptSource                   // a script, in which import and export statements are not allowed and await is an identifier
+	ModuleSource                   // a module, which is strict mode code and does not allow HTML-like comments
+)
+
+// AnnexB is a set of the additional syntax that web browsers support as described in Annex B of the ECMAScript specification.
+type AnnexB uint
+
+// AnnexB values.
+const (
+	HTMLComments      AnnexB = 1 << iota // <!-- and --> single-line comments
+	LabelledFunctions                    // labelled function declarations in non-strict code
+	IfFunctions                          // function declarations as the body of an if statement in non-strict code
+	ForInInitializers                    // an initializer for the var declaration of a for-in statement in non-strict code
+	RegExps                              // the web compatible syntax of regular expressions without the u or v flag, such as /]/ and quantified lookaheads
+	AllAnnexB         = HTMLComments | LabelledFunctions | IfFunctions | ForInInitializers | RegExps
+)
+
+// ParseOptions are the options for ParseWithOptions.
+type ParseOptions struct {
+	JSX        bool       // parse JSX elements in expressions
+	TS         bool       // parse TypeScript, whose types are skipped, see ts.go
+	Recover    bool       // recover from syntax errors by skipping the erroneous statement, all errors are returned in AST.Errors
+	SourceType SourceType // parse as a script or module
+	Version    int        // ECMAScript version as the year of the edition, such as 2015, or 5 for ES5, newer syntax is an error; zero allows all syntax
+	NoAnnexB   AnnexB     // Annex B syntax that is not allowed, all is allowed by default
+}
+
 // Parser is the state for the parser.
 type Parser struct {
-	l   *Lexer
-	err error
+	ParseOptions
+
+	l         *Lexer
+	err       error
+	errOffset int
+	errors    []*parse.Error // all errors when recovering
 
 	data                   []byte
 	tt                     TokenType
@@ -22,20 +60,52 @@ type Parser struct {
 	assumeArrowFunc        bool
 	allowDirectivePrologue bool
 
+	// TypeScript
+	ambient     bool    // in a declare statement
+	typedParams bool    // a parameter in a parenthesized expression has a type annotation or is optional, so that it must be an arrow function
+	paramProps  []*Var  // parameters of the last parsed parameter list that have modifiers and are class properties
+	enum        *tsEnum // members of the enum being parsed
+
+	// early errors
+	strict      bool        // in strict mode code
+	labels      []stmtLabel // labels of the enclosing labelled statements in the current function
+	labelled    int         // number of labels directly before the current statement
+	loops       int         // number of enclosing iteration statements in the current function
+	switches    int         // number of enclosing switch statements in the current function
+	protoDups   []int       // offsets of duplicate __proto__ properties in object literals that may still be assignment patterns
+	classInit   string      // "class static block" or "class field" when in a static block or field initializer outside of non-arrow functions, where arguments is not allowed
+	staticBlock bool        // in a class static block outside of functions, where return is not allowed
+	fn          funcContext // expressions allowed by the enclosing function
+	class       *classBody  // constructor and private names of the enclosing class body
+
 	stmtLevel int
 	exprLevel int
 
+	pos int // offset of the current token
+	end int // end offset of the previously consumed token
+
+	comments []Comment
+
 	scope *Scope
 }
 
 // Parse returns a JS AST tree of.
 func Parse(r *parse.Input) (*AST, error) {
+	return ParseWithOptions(r, ParseOptions{})
+}
+
+// ParseWithOptions returns a JS AST tree of the input using the given options.
+func ParseWithOptions(r *parse.Input, o ParseOptions) (*AST, error) {
 	ast := &AST{}
+	// await is an identifier outside of modules if the targeted version does not support top-level await
 	p := &Parser{
-		l:     NewLexer(r),
-		tt:    WhitespaceToken, // trick so that next() works
-		await: true,
+		ParseOptions: o,
+		l:            NewLexer(r),
+		tt:           WhitespaceToken, // trick so that next() works
+		await:        o.SourceType == ModuleSource || o.SourceType == AnySource && (o.Version == 0 || 2022 <= o.Version),
+		strict:       o.SourceType == ModuleSource,
 	}
+	p.l.noHTMLComments = o.SourceType == ModuleSource || o.NoAnnexB&HTMLComments != 0
 
 	// process shebang
 	if r.Peek(0) == '#' && r.Peek(1) == '!' {
@@ -54,19 +124,33 @@ func Parse(r *parse.Input) (*AST, error) {
 	}
 	if p.tt == WhitespaceToken || p.tt == LineTerminatorToken {
 		p.next()
+	} else {
+		p.pos = p.l.r.Offset() - len(p.data)
 	}
 	// prevLT may be wrong but that is not a problem
-	ast.BlockStmt = p.parseModule()
+	p.parseModule(&ast.BlockStmt)
 
+	if (p.err == nil || p.Recover) && 0 < len(p.comments) {
+		// comments inside skipped statements have been dropped while recovering
+		ast.CommentMap = attachComments(p.l.r.Bytes(), &ast.BlockStmt, p.comments)
+	}
 	if p.err == nil {
 		p.err = p.l.Err()
 	} else {
-		offset := p.l.r.Offset() - len(p.data)
-		p.err = parse.NewError(buffer.NewReader(p.l.r.Bytes()), offset, p.err.Error())
+		p.err = parse.NewError(buffer.NewReader(p.l.r.Bytes()), p.errOffset, p.err.Error())
 	}
 	if p.err == io.EOF {
 		p.err = nil
 	}
+	if p.Recover {
+		for i, err := range p.errors {
+			p.errors[i] = parse.NewError(buffer.NewReader(p.l.r.Bytes()), err.Offset, err.Message)
+		}
+		ast.Errors = p.errors
+		if 0 < len(p.errors) {
+			return ast, p.errors[0]
+		}
+	}
 	return ast, p.err
 }
 
@@ -74,19 +158,206 @@ func Parse(r *parse.Input) (*AST, error) {
 
 func (p *Parser) next() {
 	p.prevLT = false
+	p.end = p.l.r.Offset()
 	p.tt, p.data = p.l.Next()
 	for p.tt == WhitespaceToken || p.tt == LineTerminatorToken || p.tt == CommentToken || p.tt == CommentLineTerminatorToken {
 		if p.tt == LineTerminatorToken || p.tt == CommentLineTerminatorToken {
 			p.prevLT = true
 		}
+		if p.tt == CommentToken || p.tt == CommentLineTerminatorToken {
+			offset := p.l.r.Offset()
+			p.comments = append(p.comments, Comment{Data: p.data, Span: Span{offset - len(p.data), offset}})
+		}
 		p.tt, p.data = p.l.Next()
 	}
+	p.pos = p.l.r.Offset() - len(p.data)
+}
+
+// span returns the span from start till the end of the previously consumed token.
+func (p *Parser) span(start int) Span {
+	return Span{start, p.end}
+}
+
+// tokenSpan returns the span of the current token.
+func (p *Parser) tokenSpan() Span {
+	return Span{p.pos, p.pos + len(p.data)}
+}
+
+// setSpan sets the span of a node from start till the end of the previously consumed token.
+func (p *Parser) setSpan(n INode, start int) {
+	if node, ok := n.(interface{ setSpan(int, int) }); ok {
+		node.setSpan(start, p.end)
+	}
+}
+
+// use adds a variable use for the identifier at start and returns its occurrence.
+func (p *Parser) use(name []byte, start int) *Var {
+	p.checkEscapedKeyword(name, start)
+	if p.strict {
+		p.checkStrictIdentifier(name, start, false)
+	}
+	if p.classInit != "" {
+		p.checkClassInitIdentifier(name, start)
+	}
+	return p.at(p.scope.Use(name), start, len(name))
+}
+
+// declare declares a variable for the identifier at start and returns its occurrence.
+func (p *Parser) declare(decl DeclType, name []byte, start int) (*Var, bool) {
+	p.checkEscapedKeyword(name, start)
+	if p.strict {
+		// parameters of arrow functions are checked when the parentheses turn out to be parameters
+		p.checkStrictIdentifier(name, start, !p.assumeArrowFunc)
+	}
+	if p.classInit != "" {
+		p.checkClassInitIdentifier(name, start)
+	}
+	if decl == LexicalDecl && string(DecodeIdentifier(name)) == "let" {
+		p.failAt(start, "let not allowed as name of a lexical declaration")
+	}
+	v, ok := p.scope.Declare(decl, name)
+	if !ok {
+		return v, false
+	}
+	return p.at(v, start, len(name)), true
+}
+
+// at returns the occurrence of a variable at start, which is the variable itself for its first occurrence.
+func (p *Parser) at(v *Var, start, n int) *Var {
+	if v.End == 0 {
+		v.Span = Span{start, start + n}
+		return v
+	}
+	return v.occurrence(Span{start, start + n})
+}
+
+// checkEscapedKeyword fails for identifiers with unicode escape sequences that spell a reserved word.
+func (p *Parser) checkEscapedKeyword(name []byte, start int) {
+	if bytes.IndexByte(name, '\\') != -1 {
+		if tt, ok := Keywords[string(DecodeIdentifier(name))]; ok && IsReservedWord(tt) {
+			p.failAt(start, "keyword %s must not contain escape sequences", tt.String())
+		}
+	}
+}
+
+// parserState is a snapshot of the lexer and parser state that allows backtracking.
+type parserState struct {
+	lexer                  Lexer
+	offset                 int
+	tt                     TokenType
+	data                   []byte
+	prevLT                 bool
+	pos, end               int
+	comments, errors       int
+	err                    error
+	errOffset              int
+	scope                  *Scope
+	inFor, await, yield    bool
+	assumeArrowFunc        bool
+	allowDirectivePrologue bool
+	ambient, typedParams   bool
+	enum                   *tsEnum
+	strict                 bool
+	labels                 []stmtLabel
+	labelled               int
+	loops, switches        int
+	protoDups              []int
+	classInit              string
+	staticBlock            bool
+	fn                     funcContext
+	class                  *classBody
+	stmtLevel, exprLevel   int
+}
+
+// save returns the current state of the lexer and parser. Variables that are used or declared after saving are not undone by restoring the state.
+func (p *Parser) save() parserState {
+	state := parserState{
+		lexer:                  *p.l,
+		offset:                 p.l.r.Offset(),
+		tt:                     p.tt,
+		data:                   p.data,
+		prevLT:                 p.prevLT,
+		pos:                    p.pos,
+		end:                    p.end,
+		comments:               len(p.comments),
+		errors:                 len(p.errors),
+		err:                    p.err,
+		errOffset:              p.errOffset,
+		scope:                  p.scope,
+		inFor:                  p.inFor,
+		await:                  p.await,
+		yield:                  p.yield,
+		assumeArrowFunc:        p.assumeArrowFunc,
+		allowDirectivePrologue: p.allowDirectivePrologue,
+		ambient:                p.ambient,
+		typedParams:            p.typedParams,
+		enum:                   p.enum,
+		strict:                 p.strict,
+		labels:                 p.labels,
+		labelled:               p.labelled,
+		loops:                  p.loops,
+		switches:               p.switches,
+		protoDups:              p.protoDups,
+		classInit:              p.classInit,
+		staticBlock:            p.staticBlock,
+		fn:                     p.fn,
+		class:                  p.class,
+		stmtLevel:              p.stmtLevel,
+		exprLevel:              p.exprLevel,
+	}
+	state.lexer.templateLevels = append([]int{}, p.l.templateLevels...)
+	return state
+}
+
+// restore restores the state of the lexer and parser as returned by save.
+func (p *Parser) restore(state parserState) {
+	*p.l = state.lexer
+	p.l.templateLevels = append([]int{}, state.lexer.templateLevels...)
+	p.l.r.Move(state.offset - p.l.r.Offset())
+	p.l.r.Skip()
+	p.tt, p.data, p.prevLT = state.tt, state.data, state.prevLT
+	p.pos, p.end = state.pos, state.end
+	p.comments = p.comments[:state.comments]
+	p.errors = p.errors[:state.errors]
+	p.err, p.errOffset = state.err, state.errOffset
+	p.scope = state.scope
+	p.inFor, p.await, p.yield = state.inFor, state.await, state.yield
+	p.assumeArrowFunc, p.allowDirectivePrologue = state.assumeArrowFunc, state.allowDirectivePrologue
+	p.ambient, p.typedParams, p.enum = state.ambient, state.typedParams, state.enum
+	p.strict, p.labels, p.labelled, p.protoDups = state.strict, state.labels, state.labelled, state.protoDups
+	p.loops, p.switches = state.loops, state.switches
+	p.classInit, p.staticBlock, p.fn, p.class = state.classInit, state.staticBlock, state.fn, state.class
+	p.stmtLevel, p.exprLevel = state.stmtLevel, state.exprLevel
+}
+
+// dropComments removes the comments that were recorded since the n-th comment and that are before the end of the previously consumed token, so that comments inside skipped types or statements are not attached to nodes.
+func (p *Parser) dropComments(n int) {
+	i := n
+	for i < len(p.comments) && p.comments[i].End <= p.end {
+		i++
+	}
+	p.comments = append(p.comments[:n], p.comments[i:]...)
 }
 
 func (p *Parser) failMessage(msg string, args ...interface{}) {
 	if p.err == nil {
-		p.err = fmt.Errorf(msg, args...)
-		p.tt = ErrorToken
+		p.setErr(p.l.r.Offset()-len(p.data), fmt.Errorf(msg, args...))
+	}
+}
+
+// checkVersion fails at the given offset when the syntax of feature was introduced after the targeted ECMAScript version.
+func (p *Parser) checkVersion(offset, version int, feature string) bool {
+	if p.Version != 0 && p.Version < version {
+		p.failAt(offset, "%s requires ECMAScript %d", feature, version)
+		return false
+	}
+	return true
+}
+
+// failAt is like failMessage but sets the error at the given offset, which is used for errors that are found after the erroneous tokens have been consumed.
+func (p *Parser) failAt(offset int, msg string, args ...interface{}) {
+	if p.err == nil {
+		p.setErr(offset, fmt.Errorf(msg, args...))
 	}
 }
 
@@ -124,9 +395,99 @@ func (p *Parser) fail(in string, expected ...TokenType) {
 			msg += " in " + in
 		}
 
-		p.err = errors.New(msg)
-		p.tt = ErrorToken
+		p.setErr(p.l.r.Offset()-len(p.data), errors.New(msg))
+	}
+}
+
+// setErr sets the error at the given offset and stops parsing, when recovering the error is also added to the list of errors. The line and column of errors are determined after parsing.
+func (p *Parser) setErr(offset int, err error) {
+	p.err, p.errOffset = err, offset
+	p.tt = ErrorToken
+	if p.Recover {
+		p.errors = append(p.errors, &parse.Error{Message: err.Error(), Offset: offset})
+	}
+}
+
+// recover continues after an error in the statement that started at the given state. The statement is skipped up to a semicolon or line terminator following the error, or up to a closing brace of the enclosing block when inBlock is set, outside of any brackets opened in the statement. A line starting with a keyword that cannot start an expression ends the statement when the error is not inside a block.
+func (p *Parser) recover(state parserState, inBlock bool) {
+	errors := p.errors
+	offset := errors[len(errors)-1].Offset
+	p.restore(state)
+	p.errors = errors
+
+	brackets := []TokenType{}
+	for {
+		if p.tt == ErrorToken {
+			if p.l.Err() == io.EOF {
+				break
+			}
+			// skip the erroneous character
+			p.l.err = nil
+			if p.l.r.Pos() == 0 {
+				_, n := p.l.r.PeekRune(0)
+				p.l.r.Move(n)
+			}
+			p.l.r.Skip()
+		} else if offset <= p.pos && state.pos < p.pos {
+			if len(brackets) == 0 && p.tt == SemicolonToken {
+				p.next()
+				break
+			} else if len(brackets) == 0 && p.prevLT || p.prevLT && isStmtKeyword(p.tt) && !hasToken(brackets, OpenBraceToken) {
+				break
+			}
+		}
+
+		if open := openingToken(p.tt); open != ErrorToken {
+			if hasToken(brackets, open) {
+				for brackets[len(brackets)-1] != open {
+					brackets = brackets[:len(brackets)-1] // unclosed bracket
+				}
+				brackets = brackets[:len(brackets)-1]
+				if offset <= p.pos && p.tt == CloseBraceToken && !hasToken(brackets, OpenBraceToken) {
+					brackets = brackets[:0] // a block ends any unclosed brackets after the error
+				}
+			} else if inBlock && p.tt == CloseBraceToken {
+				break
+			}
+		} else if p.tt == OpenBraceToken || p.tt == OpenParenToken || p.tt == OpenBracketToken || p.tt == TemplateStartToken {
+			brackets = append(brackets, p.tt)
+		}
+		p.next()
+	}
+	p.dropComments(state.comments)
+}
+
+// isStmtKeyword returns true for keywords that start a statement and cannot start an expression.
+func isStmtKeyword(tt TokenType) bool {
+	switch tt {
+	case VarToken, ConstToken, IfToken, ForToken, WhileToken, DoToken, ReturnToken, SwitchToken, TryToken, ThrowToken, BreakToken, ContinueToken, DebuggerToken, WithToken, ExportToken:
+		return true
+	}
+	return false
+}
+
+// openingToken returns the opening bracket for a closing bracket, or ErrorToken otherwise.
+func openingToken(tt TokenType) TokenType {
+	switch tt {
+	case CloseBraceToken:
+		return OpenBraceToken
+	case CloseParenToken:
+		return OpenParenToken
+	case CloseBracketToken:
+		return OpenBracketToken
+	case TemplateEndToken:
+		return TemplateStartToken
+	}
+	return ErrorToken
+}
+
+func hasToken(list []TokenType, tt TokenType) bool {
+	for _, item := range list {
+		if item == tt {
+			return true
+		}
 	}
+	return false
 }
 
 func (p *Parser) consume(in string, tt TokenType) bool {
@@ -172,31 +533,113 @@ func (p *Parser) exitScope(parent *Scope) {
 	p.scope = parent
 }
 
-func (p *Parser) parseModule() (module BlockStmt) {
+func (p *Parser) parseModule(module *BlockStmt) {
 	p.enterScope(&module.Scope, true)
 	p.allowDirectivePrologue = true
+	module.Span = Span{0, p.l.r.Len()}
+	imported, exported := map[string]bool{}, map[string]bool{}
 	for {
+		var state parserState
+		if p.Recover {
+			state = p.save()
+		}
+		n := len(module.List)
+		start := p.pos
 		switch p.tt {
 		case ErrorToken:
-			return
+			if !p.Recover || p.l.Err() == io.EOF {
+				p.checkDirectives(module.List)
+				return
+			}
+			p.fail("")
 		case ImportToken:
+			importSpan := p.tokenSpan()
 			p.next()
-			if p.tt == OpenParenToken {
-				// could be an import call expression
-				left := &LiteralExpr{ImportToken, []byte("import")}
+			if p.tt == OpenParenToken || p.tt == DotToken {
+				// import call or import.meta expression
+				var left IExpr
+				precLeft := OpCall
+				if p.tt == OpenParenToken {
+					if !p.checkVersion(start, 2020, "import call") {
+						break
+					}
+					left = &LiteralExpr{ImportToken, []byte("import"), importSpan}
+				} else if left = p.parseImportMeta(start); left == nil {
+					break
+				} else {
+					precLeft = OpMember
+				}
 				p.exprLevel++
-				suffix := p.parseExpressionSuffix(left, OpExpr, OpCall)
+				suffix := p.parseExpressionSuffix(left, OpExpr, precLeft, start)
 				p.exprLevel--
-				module.List = append(module.List, &ExprStmt{suffix})
-			} else {
-				importStmt := p.parseImportStmt()
-				module.List = append(module.List, &importStmt)
+				if p.tt == SemicolonToken {
+					p.next()
+				}
+				module.List = append(module.List, &ExprStmt{suffix, p.span(start)})
+			} else if varDecl, ok := p.tryTSImportEquals(start); ok {
+				if varDecl != nil {
+					module.List = append(module.List, varDecl)
+				}
+			} else if p.SourceType == ScriptSource {
+				p.failAt(start, "import declaration not allowed in script")
+			} else if !p.checkVersion(start, 2015, "import declaration") {
+				break
+			} else if p.TS && p.skipTSImportType() {
+				p.parseImportStmt() // type-only import
+			} else if importStmt, ok := p.parseImportStmt(); ok {
+				importStmt.Span = p.span(start)
+				if p.checkImportBindings(&importStmt, imported) {
+					module.List = append(module.List, &importStmt)
+				}
 			}
 		case ExportToken:
-			exportStmt := p.parseExportStmt()
-			module.List = append(module.List, &exportStmt)
+			if exprStmt, ok := p.tryTSExportAssignment(start); ok {
+				if exprStmt != nil {
+					module.List = append(module.List, exprStmt)
+				}
+			} else if p.SourceType == ScriptSource {
+				p.failMessage("export declaration not allowed in script")
+			} else if !p.checkVersion(start, 2015, "export declaration") {
+				break
+			} else if exportStmt, ok := p.parseExportStmt(); ok {
+				exportStmt.Span = p.span(start)
+				if p.checkExportNames(&exportStmt, exported) {
+					module.List = append(module.List, &exportStmt)
+				}
+			}
+		case AtToken:
+			decorators := p.parseDecorators()
+			if p.tt != ExportToken {
+				if classDecl := p.parseDecoratedClass(decorators, false, start); classDecl != nil {
+					module.List = append(module.List, classDecl)
+				}
+			} else if p.SourceType == ScriptSource {
+				p.failMessage("export declaration not allowed in script")
+			} else if exportStmt, ok := p.parseExportStmt(); ok {
+				// decorators before export, as in @dec export class A {}
+				classDecl, ok := exportStmt.Decl.(*ClassDecl)
+				if !ok || classDecl.Decorators != nil {
+					p.failAt(start, "decorators before export require an undecorated class declaration")
+					break
+				}
+				classDecl.Decorators = decorators
+				exportStmt.Span = p.span(start)
+				if p.checkExportNames(&exportStmt, exported) {
+					module.List = append(module.List, &exportStmt)
+				}
+			}
 		default:
-			module.List = append(module.List, p.parseStmt(true))
+			if stmt := p.parseStmt(true); stmt != nil {
+				if varDecl, ok := stmt.(*VarDecl); ok && varDecl.TokenType == UsingToken && p.SourceType != ModuleSource {
+					p.failAt(start, "using declaration not allowed at the top level outside of a module")
+				}
+				module.List = append(module.List, stmt)
+			}
+		}
+		p.checkProtoDups(start)
+		if p.Recover && p.err != nil {
+			module.List = module.List[:n]
+			p.recover(state, false)
 		}
 	}
 }
@@ -208,16 +651,39 @@ func (p *Parser) parseStmt(allowDeclaration bool) (stmt IStmt) {
 		return nil
 	}
 
-	switch tt := p.tt; tt {
+	start := p.pos
+	labelled := p.labelled
+	p.labelled = 0
+	tt := p.tt
+	if tt == UsingToken || tt == AwaitToken && p.await {
+		if allowDeclaration && p.isUsingDeclaration() {
+			tt = UsingToken
+			if labelled != 0 {
+				p.failMessage("using declaration not allowed in labelled statement")
+				return
+			}
+		} else if tt == UsingToken {
+			tt = IdentifierToken // labelled statement or expression
+		}
+	}
+	switch tt {
 	case OpenBraceToken:
 		stmt = p.parseBlockStmt("block statement")
 	case ConstToken, VarToken:
 		if !allowDeclaration && tt == ConstToken {
 			p.fail("statement")
 			return
+		} else if tt == ConstToken && !p.checkVersion(start, 2015, "const declaration") {
+			return
 		}
 		p.next()
-		varDecl := p.parseVarDecl(tt)
+		if p.TS && tt == ConstToken && p.tt == EnumToken {
+			if enum := p.parseTSEnum(start); enum != nil {
+				stmt = enum
+			}
+			break
+		}
+		varDecl := p.parseVarDecl(tt, start)
 		stmt = &varDecl
 		if !p.prevLT && p.tt != SemicolonToken && p.tt != CloseBraceToken && p.tt != ErrorToken {
 			if tt == ConstToken {
@@ -231,7 +697,10 @@ func (p *Parser) parseStmt(allowDeclaration bool) (stmt IStmt) {
 		let := p.data
 		p.next()
 		if allowDeclaration && (IsIdentifier(p.tt) || p.tt == YieldToken || p.tt == AwaitToken || p.tt == OpenBracketToken || p.tt == OpenBraceToken) {
-			varDecl := p.parseVarDecl(tt)
+			if !p.checkVersion(start, 2015, "let declaration") {
+				return
+			}
+			varDecl := p.parseVarDecl(tt, start)
 			stmt = &varDecl
 			if !p.prevLT && p.tt != SemicolonToken && p.tt != CloseBraceToken && p.tt != ErrorToken {
 				p.fail("let declaration")
@@ -239,12 +708,28 @@ func (p *Parser) parseStmt(allowDeclaration bool) (stmt IStmt) {
 			}
 		} else {
 			// expression
-			stmt = &ExprStmt{p.parseIdentifierExpression(OpExpr, let)}
+			stmt = &ExprStmt{Value: p.parseIdentifierExpression(OpExpr, let, start)}
 			if !p.prevLT && p.tt != SemicolonToken && p.tt != CloseBraceToken && p.tt != ErrorToken {
 				p.fail("expression")
 				return
 			}
 		}
+	case UsingToken:
+		if !p.checkProposal(start, "using declaration") {
+			return
+		}
+		await := p.tt == AwaitToken
+		if await {
+			p.next()
+		}
+		p.next()
+		varDecl := p.parseVarDecl(UsingToken, start)
+		varDecl.Await = await
+		stmt = &varDecl
+		if !p.prevLT && p.tt != SemicolonToken && p.tt != CloseBraceToken && p.tt != ErrorToken {
+			p.fail("using declaration")
+			return
+		}
 	case IfToken:
 		p.next()
 		if !p.consume("if statement", OpenParenToken) {
@@ -254,31 +739,52 @@ func (p *Parser) parseStmt(allowDeclaration bool) (stmt IStmt) {
 		if !p.consume("if statement", CloseParenToken) {
 			return
 		}
-		body := p.parseStmt(false)
+		body := p.parseIfBody()
 
 		var elseBody IStmt
 		if p.tt == ElseToken {
 			p.next()
-			elseBody = p.parseStmt(false)
+			elseBody = p.parseIfBody()
 		}
-		stmt = &IfStmt{cond, body, elseBody}
+		stmt = &IfStmt{Cond: cond, Body: body, Else: elseBody}
 	case ContinueToken, BreakToken:
 		tt := p.tt
 		p.next()
 		var label []byte
 		if !p.prevLT && p.isIdentifierReference(p.tt) {
+			if target, ok := p.findLabel(p.data); !ok {
+				p.failMessage("undefined label %s", string(p.data))
+				return
+			} else if tt == ContinueToken && !target.loop {
+				p.failMessage("label %s does not denote an iteration statement", string(p.data))
+				return
+			}
 			label = p.data
 			p.next()
+		} else if tt == ContinueToken && p.loops == 0 {
+			p.failAt(start, "continue statement not allowed outside of a loop")
+			return
+		} else if tt == BreakToken && p.loops == 0 && p.switches == 0 {
+			p.failAt(start, "break statement not allowed outside of a loop or switch")
+			return
 		}
-		stmt = &BranchStmt{tt, label}
+		stmt = &BranchStmt{Type: tt, Label: label}
 	case ReturnToken:
+		if p.staticBlock {
+			p.failMessage("return statement not allowed in class static block")
+			return
+		}
 		p.next()
 		var value IExpr
 		if !p.prevLT && p.tt != SemicolonToken && p.tt != CloseBraceToken && p.tt != ErrorToken {
 			value = p.parseExpression(OpExpr)
 		}
-		stmt = &ReturnStmt{value}
+		stmt = &ReturnStmt{Value: value}
 	case WithToken:
+		if p.strict {
+			p.failMessage("with statement not allowed in strict mode")
+			return
+		}
 		p.next()
 		if !p.consume("with statement", OpenParenToken) {
 			return
@@ -289,22 +795,26 @@ func (p *Parser) parseStmt(allowDeclaration bool) (stmt IStmt) {
 		}
 
 		p.scope.Func.HasWith = true
-		stmt = &WithStmt{cond, p.parseStmt(false)}
+		stmt = &WithStmt{Cond: cond, Body: p.parseStmt(false)}
 	case DoToken:
 		stmt = &DoWhileStmt{}
+		p.markLoopLabels(labelled)
 		p.next()
+		p.loops++
 		body := p.parseStmt(false)
+		p.loops--
 		if !p.consume("do-while statement", WhileToken) {
 			return
 		}
 		if !p.consume("do-while statement", OpenParenToken) {
 			return
 		}
-		stmt = &DoWhileStmt{p.parseExpression(OpExpr), body}
+		stmt = &DoWhileStmt{Cond: p.parseExpression(OpExpr), Body: body}
 		if !p.consume("do-while statement", CloseParenToken) {
 			return
 		}
 	case WhileToken:
+		p.markLoopLabels(labelled)
 		p.next()
 		if !p.consume("while statement", OpenParenToken) {
 			return
@@ -313,11 +823,17 @@ func (p *Parser) parseStmt(allowDeclaration bool) (stmt IStmt) {
 		if !p.consume("while statement", CloseParenToken) {
 			return
 		}
-		stmt = &WhileStmt{cond, p.parseStmt(false)}
+		p.loops++
+		stmt = &WhileStmt{Cond: cond, Body: p.parseStmt(false)}
+		p.loops--
 	case ForToken:
+		p.markLoopLabels(labelled)
 		p.next()
 		await := p.await && p.tt == AwaitToken
 		if await {
+			if !p.checkVersion(p.pos, 2018, "for await") {
+				return
+			}
 			p.next()
 		}
 		if !p.consume("for statement", OpenParenToken) {
@@ -328,12 +844,28 @@ func (p *Parser) parseStmt(allowDeclaration bool) (stmt IStmt) {
 		parent := p.enterScope(&body.Scope, false)
 
 		var init IExpr
+		initStart := p.pos
 		p.inFor = true
-		if p.tt == VarToken || p.tt == LetToken || p.tt == ConstToken {
+		if p.tt == VarToken || p.tt == LetToken || p.tt == ConstToken || (p.tt == UsingToken || p.tt == AwaitToken && p.await) && p.isUsingDeclaration() {
 			tt := p.tt
+			declStart := p.pos
+			usingAwait := false
+			if tt == UsingToken || tt == AwaitToken {
+				if !p.checkProposal(declStart, "using declaration") {
+					return
+				} else if usingAwait = tt == AwaitToken; usingAwait {
+					p.next()
+				}
+				tt = UsingToken
+			} else if tt != VarToken && !p.checkVersion(declStart, 2015, "let and const declarations") {
+				return
+			}
 			p.next()
-			varDecl := p.parseVarDecl(tt)
-			if p.tt != SemicolonToken && (1 < len(varDecl.List) || varDecl.List[0].Default != nil) {
+			varDecl := p.parseVarDecl(tt, declStart)
+			varDecl.Await = usingAwait
+			if p.tt == InToken && p.isForInInitializer(&varDecl) {
+				// Annex B initializer, as in for (var a = b in c)
+			} else if p.tt != SemicolonToken && (1 < len(varDecl.List) || varDecl.List[0].Default != nil) {
 				p.fail("for statement")
 				return
 			} else if p.tt == SemicolonToken && varDecl.List[0].Default == nil {
@@ -369,42 +901,41 @@ func (p *Parser) parseStmt(allowDeclaration bool) (stmt IStmt) {
 				return
 			}
 			p.scope.MarkForInit()
-			if p.tt == OpenBraceToken {
-				body.List = p.parseStmtList("")
-			} else if p.tt != SemicolonToken {
-				body.List = []IStmt{p.parseStmt(false)}
-			}
-			stmt = &ForStmt{init, cond, post, body}
+			p.parseForBody(body)
+			stmt = &ForStmt{Init: init, Cond: cond, Post: post, Body: body}
 		} else if p.tt == InToken {
-			if await {
+			if varDecl, ok := init.(*VarDecl); await || ok && varDecl.TokenType == UsingToken {
 				p.fail("for statement", OfToken)
 				return
 			}
+			p.asPattern(init)
+			if !p.checkAssignTarget(init, initStart, true) {
+				return
+			}
 			p.next()
 			value := p.parseExpression(OpExpr)
 			if !p.consume("for statement", CloseParenToken) {
 				return
 			}
 			p.scope.MarkForInit()
-			if p.tt == OpenBraceToken {
-				body.List = p.parseStmtList("")
-			} else if p.tt != SemicolonToken {
-				body.List = []IStmt{p.parseStmt(false)}
-			}
-			stmt = &ForInStmt{init, value, body}
+			p.parseForBody(body)
+			stmt = &ForInStmt{Init: init, Value: value, Body: body}
 		} else if p.tt == OfToken {
+			if !p.checkVersion(p.pos, 2015, "for-of statement") {
+				return
+			}
+			p.asPattern(init)
+			if !p.checkAssignTarget(init, initStart, true) {
+				return
+			}
 			p.next()
 			value := p.parseExpression(OpAssign)
 			if !p.consume("for statement", CloseParenToken) {
 				return
 			}
 			p.scope.MarkForInit()
-			if p.tt == OpenBraceToken {
-				body.List = p.parseStmtList("")
-			} else if p.tt != SemicolonToken {
-				body.List = []IStmt{p.parseStmt(false)}
-			}
-			stmt = &ForOfStmt{await, init, value, body}
+			p.parseForBody(body)
+			stmt = &ForOfStmt{Await: await, Init: init, Value: value, Body: body}
 		} else {
 			p.fail("for statement", InToken, OfToken, SemicolonToken)
 			return
@@ -427,6 +958,7 @@ func (p *Parser) parseStmt(allowDeclaration bool) (stmt IStmt) {
 
 		switchStmt := &SwitchStmt{Init: init}
 		parent := p.enterScope(&switchStmt.Scope, false)
+		p.switches++
 		for {
 			if p.tt == ErrorToken {
 				p.fail("switch statement")
@@ -436,6 +968,7 @@ func (p *Parser) parseStmt(allowDeclaration bool) (stmt IStmt) {
 				break
 			}
 
+			clauseStart := p.pos
 			clause := p.tt
 			var list IExpr
 			if p.tt == CaseToken {
@@ -453,10 +986,26 @@ func (p *Parser) parseStmt(allowDeclaration bool) (stmt IStmt) {
 
 			var stmts []IStmt
 			for p.tt != CaseToken && p.tt != DefaultToken && p.tt != CloseBraceToken && p.tt != ErrorToken {
-				stmts = append(stmts, p.parseStmt(true))
+				var state parserState
+				if p.Recover {
+					state = p.save()
+				}
+				n := len(stmts)
+				stmtStart := p.pos
+				if stmt := p.parseStmt(true); stmt != nil {
+					if varDecl, ok := stmt.(*VarDecl); ok && varDecl.TokenType == UsingToken {
+						p.failAt(stmtStart, "using declaration not allowed in switch case")
+					}
+					stmts = append(stmts, stmt)
+				}
+				if p.Recover && p.err != nil {
+					stmts = stmts[:n]
+					p.recover(state, true)
+				}
 			}
-			switchStmt.List = append(switchStmt.List, CaseClause{clause, list, stmts})
+			switchStmt.List = append(switchStmt.List, CaseClause{clause, list, stmts, p.span(clauseStart)})
 		}
+		p.switches--
 		p.exitScope(parent)
 		stmt = switchStmt
 	case FunctionToken:
@@ -464,7 +1013,9 @@ func (p *Parser) parseStmt(allowDeclaration bool) (stmt IStmt) {
 			p.fail("statement")
 			return
 		}
-		stmt = p.parseFuncDecl()
+		if funcDecl := p.parseFuncDecl(); funcDecl != nil {
+			stmt = funcDecl
+		}
 	case AsyncToken: // async function
 		if !allowDeclaration {
 			p.fail("statement")
@@ -473,10 +1024,12 @@ func (p *Parser) parseStmt(allowDeclaration bool) (stmt IStmt) {
 		async := p.data
 		p.next()
 		if p.tt == FunctionToken && !p.prevLT {
-			stmt = p.parseAsyncFuncDecl()
+			if funcDecl := p.parseAsyncFuncDecl(start); funcDecl != nil {
+				stmt = funcDecl
+			}
 		} else {
 			// expression
-			stmt = &ExprStmt{p.parseAsyncExpression(OpExpr, async)}
+			stmt = &ExprStmt{Value: p.parseAsyncExpression(OpExpr, async, start)}
 			if !p.prevLT && p.tt != SemicolonToken && p.tt != CloseBraceToken && p.tt != ErrorToken {
 				p.fail("expression")
 				return
@@ -488,30 +1041,45 @@ func (p *Parser) parseStmt(allowDeclaration bool) (stmt IStmt) {
 			return
 		}
 		stmt = p.parseClassDecl()
+	case AtToken:
+		if !allowDeclaration {
+			p.fail("statement")
+			return
+		}
+		if classDecl := p.parseDecoratedClass(p.parseDecorators(), false, start); classDecl != nil {
+			stmt = classDecl
+		}
 	case ThrowToken:
 		p.next()
 		var value IExpr
 		if !p.prevLT {
 			value = p.parseExpression(OpExpr)
 		}
-		stmt = &ThrowStmt{value}
+		stmt = &ThrowStmt{Value: value}
 	case TryToken:
 		p.next()
 		body := p.parseBlockStmt("try statement")
 		var binding IBinding
 		var catch, finally *BlockStmt
 		if p.tt == CatchToken {
+			catchStart := p.pos
 			p.next()
 			catch = &BlockStmt{}
 			parent := p.enterScope(&catch.Scope, false)
-			if p.tt == OpenParenToken {
+			if p.tt != OpenParenToken && !p.checkVersion(catchStart, 2019, "optional catch binding") {
+				return
+			} else if p.tt == OpenParenToken {
 				p.next()
 				binding = p.parseBinding(CatchDecl) // local to block scope of catch
+				if p.TS && !p.skipTypeAnnotation() {
+					return
+				}
 				if !p.consume("try-catch statement", CloseParenToken) {
 					return
 				}
 			}
 			catch.List = p.parseStmtList("try-catch statement")
+			catch.Span = p.span(catchStart)
 			p.exitScope(parent)
 		} else if p.tt != FinallyToken {
 			p.fail("try statement", CatchToken, FinallyToken)
@@ -521,23 +1089,55 @@ func (p *Parser) parseStmt(allowDeclaration bool) (stmt IStmt) {
 			p.next()
 			finally = p.parseBlockStmt("try-finally statement")
 		}
-		stmt = &TryStmt{body, binding, catch, finally}
+		stmt = &TryStmt{Body: body, Binding: binding, Catch: catch, Finally: finally}
 	case DebuggerToken:
 		p.next()
 		stmt = &DebuggerStmt{}
 	case SemicolonToken, ErrorToken:
 		stmt = &EmptyStmt{}
 	default:
+		if p.TS && allowDeclaration {
+			if decl, ok := p.tryTSDecl(); ok {
+				stmt = decl
+				break
+			} else if p.tt == EnumToken {
+				if enum := p.parseTSEnum(start); enum != nil {
+					stmt = enum
+				}
+				break
+			}
+		}
 		if p.isIdentifierReference(p.tt) {
 			// labelled statement or expression
 			label := p.data
 			p.next()
 			if p.tt == ColonToken {
+				if p.strict && !p.checkStrictIdentifier(label, start, false) {
+					return
+				} else if _, ok := p.findLabel(label); ok {
+					p.failAt(start, "label %s has already been declared", string(label))
+					return
+				}
 				p.next()
-				stmt = &LabelledStmt{label, p.parseStmt(true)} // allows illegal async function, generator function, let, const, or class declarations
+				if p.tt == FunctionToken {
+					if p.strict {
+						p.failMessage("labelled function declaration not allowed in strict mode")
+						return
+					} else if p.NoAnnexB&LabelledFunctions != 0 {
+						p.failMessage("labelled function declaration not allowed")
+						return
+					}
+				}
+				p.labels = append(p.labels, stmtLabel{name: label})
+				p.labelled = labelled + 1
+				stmt = &LabelledStmt{Label: label, Value: p.parseStmt(true)} // allows illegal async function, generator function, let, const, or class declarations
+				p.labels = p.labels[:len(p.labels)-1]
+				if stmt.(*LabelledStmt).Value == nil {
+					stmt.(*LabelledStmt).Value = &EmptyStmt{}
+				}
 			} else {
 				// expression
-				stmt = &ExprStmt{p.parseIdentifierExpression(OpExpr, label)}
+				stmt = &ExprStmt{Value: p.parseIdentifierExpression(OpExpr, label, start)}
 				if !p.prevLT && p.tt != SemicolonToken && p.tt != CloseBraceToken && p.tt != ErrorToken {
 					p.fail("expression")
 					return
@@ -545,14 +1145,17 @@ func (p *Parser) parseStmt(allowDeclaration bool) (stmt IStmt) {
 			}
 		} else {
 			// expression
-			stmt = &ExprStmt{p.parseExpression(OpExpr)}
+			stmt = &ExprStmt{Value: p.parseExpression(OpExpr)}
 			if !p.prevLT && p.tt != SemicolonToken && p.tt != CloseBraceToken && p.tt != ErrorToken {
 				p.fail("expression")
 				return
 			}
 			if p.allowDirectivePrologue {
 				if lit, ok := stmt.(*ExprStmt).Value.(*LiteralExpr); ok && lit.TokenType == StringToken {
-					stmt = &DirectivePrologueStmt{lit.Data}
+					stmt = &DirectivePrologueStmt{Value: lit.Data}
+					if isUseStrict(lit.Data) {
+						p.strict = true
+					}
 				} else {
 					p.allowDirectivePrologue = false
 				}
@@ -562,40 +1165,103 @@ func (p *Parser) parseStmt(allowDeclaration bool) (stmt IStmt) {
 	if p.tt == SemicolonToken {
 		p.next()
 	}
+	switch stmt.(type) {
+	case nil, *BlockStmt, *FuncDecl, *ClassDecl:
+		// span is set by the parsing function and excludes a trailing semicolon, which is parsed as part of the statement
+	default:
+		p.setSpan(stmt, start)
+	}
+	p.checkProtoDups(start)
 	p.stmtLevel--
 	return
 }
 
+// parseIfBody parses the body of an if statement or its else clause. A function declaration is allowed in non-strict code by Annex B, and is parsed as if it were in a block statement.
+func (p *Parser) parseIfBody() IStmt {
+	if p.tt != FunctionToken || p.strict || p.NoAnnexB&IfFunctions != 0 {
+		return p.parseStmt(false)
+	}
+	block := &BlockStmt{}
+	parent := p.enterScope(&block.Scope, false)
+	if funcDecl := p.parseFuncDecl(); funcDecl != nil {
+		block.List = []IStmt{funcDecl}
+		block.Span = funcDecl.Span
+	}
+	p.exitScope(parent)
+	return block
+}
+
+// isForInInitializer returns true if the declaration of a for-in statement is a single var declaration of an identifier with an initializer, which is allowed in non-strict code by Annex B.
+func (p *Parser) isForInInitializer(varDecl *VarDecl) bool {
+	if varDecl.TokenType != VarToken || len(varDecl.List) != 1 || p.strict || p.NoAnnexB&ForInInitializers != 0 {
+		return false
+	}
+	_, ok := varDecl.List[0].Binding.(*Var)
+	return ok
+}
+
+// parseForBody parses the body of a for, for-in, or for-of statement into the block statement of the for scope.
+func (p *Parser) parseForBody(body *BlockStmt) {
+	start := p.pos
+	p.loops++
+	if p.tt == OpenBraceToken {
+		body.List = p.parseStmtList("")
+	} else if p.tt != SemicolonToken {
+		body.List = []IStmt{p.parseStmt(false)}
+	}
+	p.loops--
+	body.Span = p.span(start)
+}
+
 func (p *Parser) parseStmtList(in string) (list []IStmt) {
 	if !p.consume(in, OpenBraceToken) {
 		return
 	}
 	for {
+		var state parserState
+		if p.Recover {
+			state = p.save()
+		}
+		n := len(list)
 		if p.tt == ErrorToken {
 			p.fail("")
-			return
+			if !p.Recover || p.l.Err() == io.EOF {
+				return
+			}
 		} else if p.tt == CloseBraceToken {
 			p.next()
 			break
+		} else if stmt := p.parseStmt(true); stmt != nil {
+			list = append(list, stmt)
+		}
+		if p.Recover && p.err != nil {
+			list = list[:n]
+			p.recover(state, true)
 		}
-		list = append(list, p.parseStmt(true))
 	}
 	return
 }
 
 func (p *Parser) parseBlockStmt(in string) (blockStmt *BlockStmt) {
 	blockStmt = &BlockStmt{}
+	start := p.pos
 	parent := p.enterScope(&blockStmt.Scope, false)
 	blockStmt.List = p.parseStmtList(in)
+	blockStmt.Span = p.span(start)
 	p.exitScope(parent)
 	return
 }
 
-func (p *Parser) parseImportStmt() (importStmt ImportStmt) {
+func (p *Parser) parseImportStmt() (importStmt ImportStmt, ok bool) {
 	// assume we're passed import
 	if p.tt == StringToken {
 		importStmt.Module = p.data
 		p.next()
+		if p.tt == WithToken {
+			if importStmt.Attributes = p.parseImportAttributes("import statement"); importStmt.Attributes == nil {
+				return
+			}
+		}
 	} else {
 		if IsIdentifier(p.tt) || p.tt == YieldToken {
 			importStmt.Default = p.data
@@ -606,6 +1272,7 @@ func (p *Parser) parseImportStmt() (importStmt ImportStmt) {
 		}
 		if p.tt == MulToken {
 			star := p.data
+			start := p.pos
 			p.next()
 			if !p.consume("import statement", AsToken) {
 				return
@@ -614,12 +1281,19 @@ func (p *Parser) parseImportStmt() (importStmt ImportStmt) {
 				p.fail("import statement", IdentifierToken)
 				return
 			}
-			importStmt.List = []Alias{Alias{star, p.data}}
+			binding := p.data
 			p.next()
+			importStmt.List = []Alias{Alias{star, binding, p.span(start)}}
 		} else if p.tt == OpenBraceToken {
 			p.next()
+			typeOnly := false
 			for IsIdentifierName(p.tt) {
+				if p.TS && p.skipTSTypeSpecifier() {
+					typeOnly = true
+					continue
+				}
 				tt := p.tt
+				start := p.pos
 				var name, binding []byte = nil, p.data
 				p.next()
 				if p.tt == AsToken {
@@ -635,7 +1309,7 @@ func (p *Parser) parseImportStmt() (importStmt ImportStmt) {
 					p.fail("import statement", IdentifierToken)
 					return
 				}
-				importStmt.List = append(importStmt.List, Alias{name, binding})
+				importStmt.List = append(importStmt.List, Alias{name, binding, p.span(start)})
 				if p.tt == CommaToken {
 					p.next()
 					if p.tt == CloseBraceToken {
@@ -646,6 +1320,12 @@ func (p *Parser) parseImportStmt() (importStmt ImportStmt) {
 			}
 			if !p.consume("import statement", CloseBraceToken) {
 				return
+			} else if typeOnly && importStmt.Default == nil && len(importStmt.List) == 0 {
+				// all imports are types
+				if p.consume("import statement", FromToken) && p.consume("import statement", StringToken) && p.tt == SemicolonToken {
+					p.next()
+				}
+				return
 			}
 		}
 		if importStmt.Default == nil && len(importStmt.List) == 0 {
@@ -662,30 +1342,93 @@ func (p *Parser) parseImportStmt() (importStmt ImportStmt) {
 		}
 		importStmt.Module = p.data
 		p.next()
+		if p.tt == WithToken {
+			if importStmt.Attributes = p.parseImportAttributes("import statement"); importStmt.Attributes == nil {
+				return
+			}
+		}
 	}
 	if p.tt == SemicolonToken {
 		p.next()
 	}
-	return
+	return importStmt, true
 }
 
-func (p *Parser) parseExportStmt() (exportStmt ExportStmt) {
+// parseImportAttributes parses the import attributes that follow the module specifier of an import or export statement. It returns nil on error.
+func (p *Parser) parseImportAttributes(in string) (attributes []ImportAttribute) {
+	// assume we're at with
+	if !p.checkVersion(p.pos, 2025, "import attributes") {
+		return nil
+	}
+	p.next()
+	if !p.consume(in, OpenBraceToken) {
+		return nil
+	}
+	attributes = []ImportAttribute{}
+	for p.tt != CloseBraceToken {
+		start := p.pos
+		if !IsIdentifierName(p.tt) && p.tt != StringToken {
+			p.fail(in, IdentifierToken, StringToken, CloseBraceToken)
+			return nil
+		}
+		key := p.data
+		p.next()
+		if !p.consume(in, ColonToken) {
+			return nil
+		} else if p.tt != StringToken {
+			p.fail(in, StringToken)
+			return nil
+		}
+		for _, attribute := range attributes {
+			if importAttributeKey(attribute.Key) == importAttributeKey(key) {
+				p.failAt(start, "duplicate import attribute %s", string(key))
+				return nil
+			}
+		}
+		value := p.data
+		p.next()
+		attributes = append(attributes, ImportAttribute{key, value, p.span(start)})
+		if p.tt != CommaToken {
+			break
+		}
+		p.next()
+	}
+	if !p.consume(in, CloseBraceToken) {
+		return nil
+	}
+	return attributes
+}
+
+// importAttributeKey returns the key of an import attribute without quotes.
+func importAttributeKey(key []byte) string {
+	if 0 < len(key) && (key[0] == '"' || key[0] == '\'') {
+		return string(key[1 : len(key)-1])
+	}
+	return string(key)
+}
+
+func (p *Parser) parseExportStmt() (exportStmt ExportStmt, ok bool) {
 	// assume we're at export
 	p.next()
 	if p.tt == MulToken || p.tt == OpenBraceToken {
 		if p.tt == MulToken {
 			star := p.data
+			start := p.pos
 			p.next()
 			if p.tt == AsToken {
+				if !p.checkVersion(start, 2020, "export * as") {
+					return
+				}
 				p.next()
 				if !IsIdentifierName(p.tt) {
 					p.fail("export statement", IdentifierToken)
 					return
 				}
-				exportStmt.List = []Alias{Alias{star, p.data}}
+				binding := p.data
 				p.next()
+				exportStmt.List = []Alias{Alias{star, binding, p.span(start)}}
 			} else {
-				exportStmt.List = []Alias{Alias{nil, star}}
+				exportStmt.List = []Alias{Alias{nil, star, p.span(start)}}
 			}
 			if p.tt != FromToken {
 				p.fail("export statement", FromToken)
@@ -694,6 +1437,10 @@ func (p *Parser) parseExportStmt() (exportStmt ExportStmt) {
 		} else {
 			p.next()
 			for IsIdentifierName(p.tt) {
+				if p.TS && p.skipTSTypeSpecifier() {
+					continue
+				}
+				start := p.pos
 				var name, binding []byte = nil, p.data
 				p.next()
 				if p.tt == AsToken {
@@ -706,7 +1453,7 @@ func (p *Parser) parseExportStmt() (exportStmt ExportStmt) {
 					binding = p.data
 					p.next()
 				}
-				exportStmt.List = append(exportStmt.List, Alias{name, binding})
+				exportStmt.List = append(exportStmt.List, Alias{name, binding, p.span(start)})
 				if p.tt == CommaToken {
 					p.next()
 					if p.tt == CloseBraceToken {
@@ -727,23 +1474,68 @@ func (p *Parser) parseExportStmt() (exportStmt ExportStmt) {
 			}
 			exportStmt.Module = p.data
 			p.next()
+			if p.tt == WithToken {
+				if exportStmt.Attributes = p.parseImportAttributes("export statement"); exportStmt.Attributes == nil {
+					return
+				}
+			}
 		}
 	} else if p.tt == VarToken || p.tt == ConstToken || p.tt == LetToken {
 		tt := p.tt
+		start := p.pos
 		p.next()
-		varDecl := p.parseVarDecl(tt)
-		exportStmt.Decl = &varDecl
+		if p.TS && tt == ConstToken && p.tt == EnumToken {
+			if exportStmt.Decl = p.parseTSEnum(start); exportStmt.Decl == nil {
+				return
+			}
+		} else {
+			varDecl := p.parseVarDecl(tt, start)
+			exportStmt.Decl = &varDecl
+		}
 	} else if p.tt == FunctionToken {
-		exportStmt.Decl = p.parseFuncDecl()
+		funcDecl := p.parseFuncDecl()
+		if funcDecl == nil {
+			return // overload signature
+		}
+		exportStmt.Decl = funcDecl
 	} else if p.tt == AsyncToken { // async function
+		start := p.pos
 		p.next()
 		if p.tt != FunctionToken || p.prevLT {
 			p.fail("export statement", FunctionToken)
 			return
 		}
-		exportStmt.Decl = p.parseAsyncFuncDecl()
+		funcDecl := p.parseAsyncFuncDecl(start)
+		if funcDecl == nil {
+			return // overload signature
+		}
+		exportStmt.Decl = funcDecl
+	} else if p.TS && p.tt == EnumToken {
+		enum := p.parseTSEnum(p.pos)
+		if enum == nil {
+			return
+		}
+		exportStmt.Decl = enum
+	} else if p.TS && p.isTSKeyword("type") && p.tryTSExportType() {
+		return
+	} else if p.TS && IsIdentifier(p.tt) && p.tt != AsyncToken {
+		decl, isDecl := p.tryTSDecl()
+		if !isDecl {
+			p.fail("export statement")
+			return
+		} else if decl == nil {
+			return // type-only declaration
+		}
+		exportStmt.Decl = decl.(IExpr)
 	} else if p.tt == ClassToken {
 		exportStmt.Decl = p.parseClassDecl()
+	} else if p.tt == AtToken {
+		start := p.pos
+		classDecl := p.parseDecoratedClass(p.parseDecorators(), false, start)
+		if classDecl == nil {
+			return
+		}
+		exportStmt.Decl = classDecl
 	} else if p.tt == DefaultToken {
 		exportStmt.Default = true
 		p.next()
@@ -751,15 +1543,28 @@ func (p *Parser) parseExportStmt() (exportStmt ExportStmt) {
 			exportStmt.Decl = p.parseFuncExpr()
 		} else if p.tt == AsyncToken { // async function or async arrow function
 			async := p.data
+			start := p.pos
 			p.next()
 			if p.tt == FunctionToken && !p.prevLT {
-				exportStmt.Decl = p.parseAsyncFuncExpr()
+				exportStmt.Decl = p.parseAsyncFuncExpr(start)
 			} else {
 				// expression
-				exportStmt.Decl = p.parseAsyncExpression(OpExpr, async)
+				exportStmt.Decl = p.parseAsyncExpression(OpExpr, async, start)
 			}
 		} else if p.tt == ClassToken {
 			exportStmt.Decl = p.parseClassExpr()
+		} else if p.tt == AtToken {
+			start := p.pos
+			classDecl := p.parseDecoratedClass(p.parseDecorators(), true, start)
+			if classDecl == nil {
+				return
+			}
+			exportStmt.Decl = classDecl
+		} else if decl, isDecl := p.tryTSDefaultDecl(); isDecl {
+			if decl == nil {
+				return // type-only declaration
+			}
+			exportStmt.Decl = decl
 		} else {
 			exportStmt.Decl = p.parseExpression(OpAssign)
 		}
@@ -770,11 +1575,31 @@ func (p *Parser) parseExportStmt() (exportStmt ExportStmt) {
 	if p.tt == SemicolonToken {
 		p.next()
 	}
-	return
+	return exportStmt, true
+}
+
+// isUsingDeclaration returns true if the current using or await token starts a using declaration, which requires the binding identifier to be on the same line. In the initializer of a for statement, using of is a declaration only if followed by another of.
+func (p *Parser) isUsingDeclaration() bool {
+	state := p.save()
+	defer p.restore(state)
+	if p.tt == AwaitToken {
+		p.next()
+		if p.tt != UsingToken || p.prevLT {
+			return false
+		}
+	}
+	p.next()
+	if p.prevLT || !IsIdentifier(p.tt) && p.tt != YieldToken && p.tt != AwaitToken {
+		return false
+	} else if p.inFor && p.tt == OfToken {
+		p.next()
+		return p.tt == OfToken
+	}
+	return true
 }
 
-func (p *Parser) parseVarDecl(tt TokenType) (varDecl VarDecl) {
-	// assume we're past var, let or const
+func (p *Parser) parseVarDecl(tt TokenType, start int) (varDecl VarDecl) {
+	// assume we're past var, let, const, or using
 	varDecl.TokenType = tt
 	declType := LexicalDecl
 	if tt == VarToken {
@@ -784,17 +1609,34 @@ func (p *Parser) parseVarDecl(tt TokenType) (varDecl VarDecl) {
 	for {
 		// binding element, var declaration in for-in or for-of can never have a default
 		var bindingElement BindingElement
+		elemStart := p.pos
 		parentInFor := p.inFor
 		p.inFor = false
+		if tt == UsingToken && !IsIdentifier(p.tt) && p.tt != YieldToken && p.tt != AwaitToken {
+			p.fail("using declaration", IdentifierToken)
+			return
+		}
 		bindingElement.Binding = p.parseBinding(declType)
 		p.inFor = parentInFor
+		if p.TS {
+			if p.tt == NotToken {
+				p.next() // definite assignment
+			}
+			if !p.skipTypeAnnotation() {
+				return
+			}
+		}
 		if p.tt == EqToken {
 			p.next()
 			bindingElement.Default = p.parseExpression(OpAssign)
 		} else if _, ok := bindingElement.Binding.(*Var); !ok && (!p.inFor || 0 < len(varDecl.List)) {
 			p.fail("var statement", EqToken)
 			return
+		} else if tt == UsingToken && (!p.inFor || p.tt != OfToken && p.tt != InToken) {
+			p.fail("using declaration", EqToken)
+			return
 		}
+		bindingElement.Span = p.span(elemStart)
 
 		varDecl.List = append(varDecl.List, bindingElement)
 		if p.tt == CommaToken {
@@ -803,23 +1645,53 @@ func (p *Parser) parseVarDecl(tt TokenType) (varDecl VarDecl) {
 			break
 		}
 	}
+	varDecl.Span = p.span(start)
 	return
 }
 
 func (p *Parser) parseFuncParams(in string) (params Params) {
+	start := p.pos
 	if !p.consume(in, OpenParenToken) {
 		return
 	}
 
+	var props []*Var
 	for p.tt != CloseParenToken && p.tt != ErrorToken {
 		if p.tt == EllipsisToken {
 			// binding rest element
+			if !p.checkVersion(p.pos, 2015, "rest parameter") {
+				return
+			}
 			p.next()
 			params.Rest = p.parseBinding(ArgumentDecl)
+			if p.TS && !p.skipTypeAnnotation() {
+				return
+			}
 			p.consume(in, CloseParenToken)
+			params.Span = p.span(start)
+			if p.TS && p.tt == ColonToken {
+				p.next()
+				p.skipReturnType()
+			}
+			p.paramProps = props
 			return
+		} else if p.TS && p.tt == ThisToken && len(params.List) == 0 {
+			// type of this
+			p.next()
+			if !p.skipTypeAnnotation() {
+				return
+			} else if p.tt != CommaToken {
+				break
+			}
+			p.next()
+			continue
 		}
+
+		isProp := p.TS && p.skipTSParamModifiers()
 		params.List = append(params.List, p.parseBindingElement(ArgumentDecl))
+		if v, ok := params.List[len(params.List)-1].Binding.(*Var); ok && isProp {
+			props = append(props, v)
+		}
 		if p.tt != CommaToken {
 			break
 		}
@@ -830,6 +1702,14 @@ func (p *Parser) parseFuncParams(in string) (params Params) {
 		return
 	}
 	p.next()
+	params.Span = p.span(start)
+	if p.TS && p.tt == ColonToken {
+		p.next()
+		if !p.skipReturnType() {
+			return
+		}
+	}
+	p.paramProps = props
 
 	// mark undeclared vars as arguments in `function f(a=b){var b}` where the b's are different vars
 	p.scope.MarkArguments()
@@ -837,36 +1717,39 @@ func (p *Parser) parseFuncParams(in string) (params Params) {
 }
 
 func (p *Parser) parseFuncDecl() (funcDecl *FuncDecl) {
-	return p.parseAnyFunc(false, false)
+	return p.parseAnyFunc(false, false, p.pos)
 }
 
-func (p *Parser) parseAsyncFuncDecl() (funcDecl *FuncDecl) {
-	return p.parseAnyFunc(true, false)
+func (p *Parser) parseAsyncFuncDecl(start int) (funcDecl *FuncDecl) {
+	return p.parseAnyFunc(true, false, start)
 }
 
 func (p *Parser) parseFuncExpr() (funcDecl *FuncDecl) {
-	return p.parseAnyFunc(false, true)
+	return p.parseAnyFunc(false, true, p.pos)
 }
 
-func (p *Parser) parseAsyncFuncExpr() (funcDecl *FuncDecl) {
-	return p.parseAnyFunc(true, true)
+func (p *Parser) parseAsyncFuncExpr(start int) (funcDecl *FuncDecl) {
+	return p.parseAnyFunc(true, true, start)
 }
 
-func (p *Parser) parseAnyFunc(async, inExpr bool) (funcDecl *FuncDecl) {
+func (p *Parser) parseAnyFunc(async, inExpr bool, start int) (funcDecl *FuncDecl) {
 	// assume we're at function
 	p.next()
 	funcDecl = &FuncDecl{}
 	funcDecl.Async = async
 	funcDecl.Generator = p.tt == MulToken
-	if funcDecl.Generator {
+	if !p.checkFuncVersion(start, async, funcDecl.Generator) {
+		return
+	} else if funcDecl.Generator {
 		p.next()
 	}
 	var ok bool
 	var name []byte
+	nameStart := p.pos
 	if inExpr && (IsIdentifier(p.tt) || p.tt == YieldToken || p.tt == AwaitToken) || !inExpr && p.isIdentifierReference(p.tt) {
 		name = p.data
 		if !inExpr {
-			funcDecl.Name, ok = p.scope.Declare(FunctionDecl, p.data)
+			funcDecl.Name, ok = p.declare(FunctionDecl, p.data, p.pos)
 			if !ok {
 				p.failMessage("identifier %s has already been declared", string(p.data))
 				return
@@ -876,23 +1759,143 @@ func (p *Parser) parseAnyFunc(async, inExpr bool) (funcDecl *FuncDecl) {
 	} else if !inExpr {
 		p.fail("function declaration", IdentifierToken)
 		return
-	} else if p.tt != OpenParenToken {
+	} else if p.tt != OpenParenToken && (!p.TS || p.tt != LtToken) {
 		p.fail("function declaration", IdentifierToken, OpenParenToken)
 		return
 	}
-	parent := p.enterScope(&funcDecl.Body.Scope, true)
-	parentAwait, parentYield := p.await, p.yield
-	p.await, p.yield = funcDecl.Async, funcDecl.Generator
+	if p.TS && p.tt == LtToken && !p.skipTypeParams() {
+		return
+	}
+	parent := p.enterScope(&funcDecl.Body.Scope, true)
+	parentAwait, parentYield, parentClassInit, parentFn := p.await, p.yield, p.classInit, p.fn
+	p.await, p.yield, p.classInit, p.fn = funcDecl.Async, funcDecl.Generator, "", funcContext{newTarget: true}
+
+	if inExpr && name != nil {
+		funcDecl.Name, _ = p.declare(ExprDecl, name, nameStart) // cannot fail
+	}
+	funcDecl.Params = p.parseFuncParams("function declaration")
+	if p.TS && !inExpr && p.tt != OpenBraceToken && p.tt != ErrorToken {
+		// overload signature or ambient function without a body
+		p.await, p.yield, p.classInit, p.fn = parentAwait, parentYield, parentClassInit, parentFn
+		p.scope = parent // discard the scope of the function
+		if funcDecl.Name != nil {
+			funcDecl.Name.Uses--
+		}
+		if p.tt == SemicolonToken {
+			p.next()
+		} else if !p.prevLT && p.tt != CloseBraceToken {
+			p.fail("function declaration", OpenBraceToken)
+		}
+		return nil
+	}
+	bodyStart := p.pos
+	funcDecl.Body.List = p.parseFuncBody("function declaration", &funcDecl.Params, false)
+	funcDecl.Body.Span = p.span(bodyStart)
+	funcDecl.Span = p.span(start)
+	if name != nil && !p.strict && p.err == nil && hasUseStrict(funcDecl.Body.List) {
+		// the name is strict mode code when the body is
+		p.checkStrictIdentifier(name, nameStart, true)
+	}
+
+	p.await, p.yield, p.classInit, p.fn = parentAwait, parentYield, parentClassInit, parentFn
+	p.exitScope(parent)
+	return
+}
+
+// checkAwaitIdentifier fails when await is an identifier at the top level of any source type and is followed by an operand, as in await a, which is a top-level await that the targeted ECMAScript version does not support.
+func (p *Parser) checkAwaitIdentifier(start int) bool {
+	if p.SourceType == AnySource && p.scope.Func.Parent == nil && !p.prevLT && (IsIdentifier(p.tt) && p.tt != OfToken || IsNumeric(p.tt) || p.tt == StringToken || p.tt == ThisToken) {
+		return p.checkVersion(start, 2022, "top-level await")
+	}
+	return true
+}
+
+// checkFuncVersion fails when the targeted ECMAScript version does not support async functions or generators.
+func (p *Parser) checkFuncVersion(start int, async, generator bool) bool {
+	if async && generator {
+		return p.checkVersion(start, 2018, "async generator")
+	} else if async {
+		return p.checkVersion(start, 2017, "async function")
+	} else if generator {
+		return p.checkVersion(start, 2015, "generator")
+	}
+	return true
+}
+
+// checkProposal fails when a targeted ECMAScript version is set, as the feature is a proposal that is not part of any version.
+func (p *Parser) checkProposal(offset int, feature string) bool {
+	if p.Version != 0 {
+		p.failAt(offset, "%s is not part of ECMAScript %d", feature, p.Version)
+		return false
+	}
+	return true
+}
+
+// parseDecorators parses the decorators of a class or class element, which are a dotted identifier that is optionally called, or a parenthesized expression.
+func (p *Parser) parseDecorators() (decorators []IExpr) {
+	// assume we're at @
+	if !p.checkProposal(p.pos, "decorator") {
+		return nil
+	}
+	for p.tt == AtToken {
+		p.next()
+		start := p.pos
+		var decorator IExpr
+		if p.tt == OpenParenToken {
+			p.next()
+			parentInFor := p.inFor
+			p.inFor = false
+			group := &GroupExpr{X: p.parseExpression(OpExpr)}
+			p.inFor = parentInFor
+			if !p.consume("decorator", CloseParenToken) {
+				return nil
+			}
+			group.Span = p.span(start)
+			decorator = group
+		} else if p.isIdentifierReference(p.tt) {
+			decorator = p.use(p.data, p.pos)
+			p.next()
+			for p.tt == DotToken {
+				p.next()
+				if !IsIdentifierName(p.tt) && p.tt != PrivateIdentifierToken {
+					p.fail("decorator", IdentifierToken)
+					return nil
+				}
+				tt := IdentifierToken
+				if p.tt == PrivateIdentifierToken {
+					if !p.usePrivateName(p.data, p.pos) {
+						return nil
+					}
+					tt = PrivateIdentifierToken
+				}
+				name := LiteralExpr{tt, p.data, p.tokenSpan()}
+				p.next()
+				decorator = &DotExpr{decorator, name, OpMember, p.span(start)}
+			}
+			if p.tt == OpenParenToken {
+				decorator = &CallExpr{decorator, p.parseArguments(), p.span(start)}
+			}
+		} else {
+			p.fail("decorator", IdentifierToken, OpenParenToken)
+			return nil
+		}
+		decorators = append(decorators, decorator)
+	}
+	return
+}
 
-	if inExpr && name != nil {
-		funcDecl.Name, _ = p.scope.Declare(ExprDecl, name) // cannot fail
+// parseDecoratedClass parses a class declaration or expression after its decorators, where start is the position of the first decorator.
+func (p *Parser) parseDecoratedClass(decorators []IExpr, inExpr bool, start int) (classDecl *ClassDecl) {
+	if p.err != nil {
+		return nil
+	} else if p.tt != ClassToken {
+		p.fail("decorator", ClassToken)
+		return nil
+	}
+	if classDecl = p.parseAnyClass(inExpr); classDecl != nil {
+		classDecl.Decorators = decorators
+		classDecl.Span.Start = start
 	}
-	funcDecl.Params = p.parseFuncParams("function declaration")
-	p.allowDirectivePrologue = true
-	funcDecl.Body.List = p.parseStmtList("function declaration")
-
-	p.await, p.yield = parentAwait, parentYield
-	p.exitScope(parent)
 	return
 }
 
@@ -906,68 +1909,194 @@ func (p *Parser) parseClassExpr() (classDecl *ClassDecl) {
 
 func (p *Parser) parseAnyClass(inExpr bool) (classDecl *ClassDecl) {
 	// assume we're at class
+	start := p.pos
+	if !p.checkVersion(start, 2015, "class") {
+		return
+	}
 	p.next()
 	classDecl = &ClassDecl{}
+
+	// all parts of a class are strict mode code
+	parentStrict := p.strict
+	p.strict = true
 	if IsIdentifier(p.tt) || p.tt == YieldToken || p.tt == AwaitToken {
 		if !inExpr {
 			var ok bool
-			classDecl.Name, ok = p.scope.Declare(LexicalDecl, p.data)
+			classDecl.Name, ok = p.declare(LexicalDecl, p.data, p.pos)
 			if !ok {
 				p.failMessage("identifier %s has already been declared", string(p.data))
 				return
 			}
 		} else {
 			//classDecl.Name, ok = p.scope.Declare(ExprDecl, p.data) // classes do not register vars
-			classDecl.Name = &Var{p.data, nil, 1, ExprDecl}
+			if !p.checkStrictIdentifier(p.data, p.pos, true) {
+				return
+			}
+			classDecl.Name = NewVar(p.data, ExprDecl)
+			classDecl.Name.Uses, classDecl.Name.Span = 1, p.tokenSpan()
 		}
 		p.next()
 	} else if !inExpr {
 		p.fail("class declaration", IdentifierToken)
 		return
 	}
+	if p.TS && p.tt == LtToken && !p.skipTypeParams() {
+		return
+	}
 	if p.tt == ExtendsToken {
 		p.next()
 		classDecl.Extends = p.parseExpression(OpLHS)
+		if p.TS && p.tt == LtToken && !p.skipTypeArgs() {
+			return
+		}
+	}
+	if p.TS && p.tt == ImplementsToken {
+		p.next()
+		for {
+			if !p.skipTypeReference() {
+				return
+			} else if p.tt != CommaToken {
+				break
+			}
+			p.next()
+		}
 	}
 
 	if !p.consume("class declaration", OpenBraceToken) {
 		return
 	}
+	p.class = &classBody{parent: p.class, derived: classDecl.Extends != nil, names: map[string]privateKind{}}
 	for {
+		var state parserState
+		if p.Recover {
+			state = p.save()
+		}
+		nMethods, nDefinitions := len(classDecl.Methods), len(classDecl.Definitions)
 		if p.tt == ErrorToken {
 			p.fail("class declaration")
-			return
+			if !p.Recover || p.l.Err() == io.EOF {
+				return
+			}
 		} else if p.tt == SemicolonToken {
 			p.next()
 			continue
 		} else if p.tt == CloseBraceToken {
 			p.next()
 			break
-		}
-
-		method, definition := p.parseClassElement()
-		if method != nil {
-			classDecl.Methods = append(classDecl.Methods, method)
 		} else {
-			classDecl.Definitions = append(classDecl.Definitions, definition)
+			p.parseClassMember(classDecl)
+		}
+		if p.Recover && p.err != nil {
+			// skip the erroneous class element and continue with the next one
+			classDecl.Methods, classDecl.Definitions = classDecl.Methods[:nMethods], classDecl.Definitions[:nDefinitions]
+			p.recover(state, true)
 		}
 	}
+	p.exitClassBody()
+	p.strict = parentStrict
+	classDecl.Span = p.span(start)
 	return
 }
 
+// parseClassMember parses a class element with its decorators and adds it to the class.
+func (p *Parser) parseClassMember(classDecl *ClassDecl) {
+	var decorators []IExpr
+	decoratorsStart := p.pos
+	if p.tt == AtToken {
+		if decorators = p.parseDecorators(); decorators == nil {
+			return
+		}
+	}
+	method, definition := p.parseClassElement()
+	if method != nil {
+		if decorators != nil {
+			method.Decorators = decorators
+			method.Span.Start = decoratorsStart
+		}
+		classDecl.Methods = append(classDecl.Methods, method)
+	} else if definition.Body != nil {
+		if decorators != nil {
+			p.failAt(decoratorsStart, "decorator not allowed on class static block")
+			return
+		}
+		classDecl.Definitions = append(classDecl.Definitions, definition)
+	} else if definition.Name.IsSet() {
+		if decorators != nil {
+			definition.Decorators = decorators
+			definition.Span.Start = decoratorsStart
+		}
+		classDecl.Definitions = append(classDecl.Definitions, definition)
+	}
+}
+
 func (p *Parser) parseClassElement() (method *MethodDecl, definition FieldDefinition) {
 	method = &MethodDecl{}
+	start := p.pos
 	var data []byte
+	var dataSpan Span
+	var modifiers tsClassModifiers
+	if p.TS {
+		p.skipTSClassModifiers(&modifiers)
+	}
+	onlyStatic := false // data is the static keyword
 	if p.tt == StaticToken {
 		method.Static = true
-		data = p.data
+		data, dataSpan = p.data, p.tokenSpan()
+		p.next()
+		if p.TS && p.skipTSClassModifiers(&modifiers) {
+			data = nil
+		}
+		onlyStatic = data != nil
+		if onlyStatic && p.tt == OpenBraceToken {
+			// ClassStaticBlock
+			if !p.checkVersion(start, 2022, "class static block") {
+				return nil, FieldDefinition{}
+			}
+			definition.Static = true
+			definition.Body = &BlockStmt{}
+			parent := p.enterScope(&definition.Body.Scope, true)
+			parentAwait, parentYield, parentInFor := p.await, p.yield, p.inFor
+			parentLabels, parentLoops, parentSwitches := p.labels, p.loops, p.switches
+			parentClassInit, parentStaticBlock, parentFn := p.classInit, p.staticBlock, p.fn
+			p.await, p.yield, p.inFor = false, false, false
+			p.labels, p.loops, p.switches = nil, 0, 0
+			p.classInit, p.staticBlock, p.fn = "class static block", true, funcContext{newTarget: true, superProp: true}
+			bodyStart := p.pos
+			definition.Body.List = p.parseStmtList("class static block")
+			definition.Body.Span = p.span(bodyStart)
+			definition.Span = p.span(start)
+			p.await, p.yield, p.inFor = parentAwait, parentYield, parentInFor
+			p.labels, p.loops, p.switches = parentLabels, parentLoops, parentSwitches
+			p.classInit, p.staticBlock, p.fn = parentClassInit, parentStaticBlock, parentFn
+			p.exitScope(parent)
+			return nil, definition
+		}
+	}
+	if p.TS && p.tt == OpenBracketToken && p.skipTSIndexSignature() {
+		return nil, FieldDefinition{}
+	}
+	accessor := false
+	if p.tt == IdentifierToken && string(p.data) == "accessor" {
+		// accessor is a modifier only when followed by a class element name on the same line
+		state := p.save()
 		p.next()
+		if !p.prevLT && (IsIdentifierName(p.tt) || p.tt == StringToken || IsNumeric(p.tt) || p.tt == OpenBracketToken || p.tt == PrivateIdentifierToken) {
+			if !p.checkProposal(start, "auto-accessor") {
+				return nil, FieldDefinition{}
+			}
+			accessor = true
+			data, onlyStatic = nil, false
+		} else {
+			p.restore(state)
+		}
 	}
 	if p.tt == MulToken {
 		method.Generator = true
+		onlyStatic = false
 		p.next()
 	} else if p.tt == AsyncToken {
-		data = p.data
+		data, dataSpan = p.data, p.tokenSpan()
+		onlyStatic = false
 		p.next()
 		if !p.prevLT {
 			method.Async = true
@@ -979,17 +2108,20 @@ func (p *Parser) parseClassElement() (method *MethodDecl, definition FieldDefini
 		}
 	} else if p.tt == GetToken {
 		method.Get = true
-		data = p.data
+		data, dataSpan = p.data, p.tokenSpan()
+		onlyStatic = false
 		p.next()
 	} else if p.tt == SetToken {
 		method.Set = true
-		data = p.data
+		data, dataSpan = p.data, p.tokenSpan()
+		onlyStatic = false
 		p.next()
 	}
 
 	isFieldDefinition := false
-	if data != nil && p.tt == OpenParenToken {
-		method.Name.Literal = LiteralExpr{IdentifierToken, data}
+	if data != nil && (p.tt == OpenParenToken || p.TS && (p.tt == LtToken || p.tt == QuestionToken && p.isTSOptionalMethod())) {
+		method.Name.Literal = LiteralExpr{IdentifierToken, data, dataSpan}
+		method.Name.Span = dataSpan
 		if method.Async || method.Get || method.Set {
 			method.Async = false
 			method.Get = false
@@ -997,62 +2129,154 @@ func (p *Parser) parseClassElement() (method *MethodDecl, definition FieldDefini
 		} else {
 			method.Static = false
 		}
-	} else if data != nil && (p.tt == EqToken || p.tt == SemicolonToken || p.tt == CloseBraceToken) {
-		method.Name.Literal = LiteralExpr{IdentifierToken, data}
-		isFieldDefinition = true
-	} else if data == nil && p.tt == PrivateIdentifierToken {
-		method.Name.Literal = LiteralExpr{p.tt, p.data}
-		p.next()
+	} else if data != nil && (p.tt == EqToken || p.tt == SemicolonToken || p.tt == CloseBraceToken || p.TS && (p.tt == ColonToken || p.tt == QuestionToken || p.tt == NotToken)) {
+		method.Name.Literal = LiteralExpr{IdentifierToken, data, dataSpan}
+		method.Name.Span = dataSpan
+		if onlyStatic {
+			method.Static = false
+		}
 		isFieldDefinition = true
 	} else {
-		method.Name = p.parsePropertyName("method definition")
-		if data == nil && p.tt != OpenParenToken {
+		if p.tt == PrivateIdentifierToken {
+			method.Name.Literal = LiteralExpr{p.tt, p.data, p.tokenSpan()}
+			method.Name.Span = p.tokenSpan()
+			p.next()
+		} else {
+			method.Name = p.parsePropertyName("method definition")
+		}
+		if (data == nil || onlyStatic) && p.tt != OpenParenToken && (!p.TS || p.tt != LtToken && p.tt != QuestionToken && p.tt != NotToken) {
+			isFieldDefinition = true
+		}
+	}
+
+	if p.TS {
+		if p.tt == QuestionToken || p.tt == NotToken {
+			// optional member or definite assignment
+			p.next()
+			isFieldDefinition = isFieldDefinition || p.tt != OpenParenToken && p.tt != LtToken
+		}
+		if !isFieldDefinition && p.tt == LtToken && !p.skipTypeParams() {
+			return
+		} else if p.tt == ColonToken {
 			isFieldDefinition = true
+			if !p.skipTypeAnnotation() {
+				return
+			}
 		}
 	}
 
+	if isFieldDefinition && !p.checkVersion(method.Name.Start, 2022, "class field") {
+		return
+	} else if method.Name.Literal.TokenType == PrivateIdentifierToken && !p.checkVersion(method.Name.Start, 2022, "private class member") {
+		return
+	}
+	if !isFieldDefinition && !p.checkFuncVersion(start, method.Async, method.Generator) {
+		return
+	} else if accessor && !isFieldDefinition {
+		p.failAt(start, "accessor not allowed on method definition")
+		return
+	}
+
 	if isFieldDefinition {
 		// FieldDefinition
+		definition.Static = method.Static
+		definition.Accessor = accessor
 		definition.Name = method.Name
+		if method.Name.Literal.TokenType == PrivateIdentifierToken && !p.declarePrivateName(method.Name.Literal.Data, method.Name.Start, privateKind(0)) {
+			return
+		}
 		if p.tt == EqToken {
 			p.next()
+			parentClassInit, parentFn := p.classInit, p.fn
+			p.classInit, p.fn = "class field", funcContext{newTarget: true, superProp: true}
 			definition.Init = p.parseExpression(OpAssign)
+			p.classInit, p.fn = parentClassInit, parentFn
 		}
+		definition.Span = p.span(start)
 		method = nil
+		if modifiers.abstract || modifiers.declare {
+			definition = FieldDefinition{}
+		}
 		return
 	}
 
+	isConstructor := !method.Static && !method.Name.IsComputed() && method.Name.Literal.TokenType == IdentifierToken && string(method.Name.Literal.Data) == "constructor"
 	parent := p.enterScope(&method.Body.Scope, true)
-	parentAwait, parentYield := p.await, p.yield
-	p.await, p.yield = method.Async, method.Generator
+	parentAwait, parentYield, parentClassInit, parentFn := p.await, p.yield, p.classInit, p.fn
+	p.await, p.yield, p.classInit = method.Async, method.Generator, ""
+	p.fn = funcContext{newTarget: true, superProp: true, superCall: isConstructor && p.class.derived}
 
 	method.Params = p.parseFuncParams("method definition")
-	p.allowDirectivePrologue = true
-	method.Body.List = p.parseStmtList("method definition")
+	props := p.paramProps
+	if p.TS && p.tt != OpenBraceToken && p.tt != ErrorToken {
+		// overload signature or abstract method without a body
+		p.await, p.yield, p.classInit, p.fn = parentAwait, parentYield, parentClassInit, parentFn
+		p.scope = parent // discard the scope of the method
+		if p.tt == SemicolonToken {
+			p.next()
+		} else if !p.prevLT && p.tt != CloseBraceToken {
+			p.fail("method definition", OpenBraceToken)
+		}
+		return nil, FieldDefinition{}
+	}
+	bodyStart := p.pos
+	method.Body.List = p.parseFuncBody("method definition", &method.Params, true)
+	method.Body.Span = p.span(bodyStart)
+	method.Span = p.span(start)
+	if 0 < len(props) && isConstructor {
+		p.addTSParamProps(&method.Body, props)
+	}
 
-	p.await, p.yield = parentAwait, parentYield
+	p.await, p.yield, p.classInit, p.fn = parentAwait, parentYield, parentClassInit, parentFn
 	p.exitScope(parent)
+	if isConstructor && !p.declareConstructor(method.Name.Start) {
+		return
+	} else if method.Name.Literal.TokenType == PrivateIdentifierToken {
+		kind := privateKind(0)
+		if method.Get {
+			kind = privateGetter
+		} else if method.Set {
+			kind = privateSetter
+		}
+		if method.Static {
+			kind |= privateStatic
+		}
+		if !p.declarePrivateName(method.Name.Literal.Data, method.Name.Start, kind) {
+			return
+		}
+	}
 	return
 }
 
 func (p *Parser) parsePropertyName(in string) (propertyName PropertyName) {
+	start := p.pos
 	if IsIdentifierName(p.tt) {
-		propertyName.Literal = LiteralExpr{IdentifierToken, p.data}
+		propertyName.Literal = LiteralExpr{IdentifierToken, p.data, p.tokenSpan()}
 		p.next()
 	} else if p.tt == StringToken {
+		if !p.checkStrictString() {
+			return
+		}
 		// reinterpret string as identifier or number if we can, except for empty strings
+		inner := Span{p.pos + 1, p.pos + len(p.data) - 1}
 		if isIdent := AsIdentifierName(p.data[1 : len(p.data)-1]); isIdent {
-			propertyName.Literal = LiteralExpr{IdentifierToken, p.data[1 : len(p.data)-1]}
+			propertyName.Literal = LiteralExpr{IdentifierToken, p.data[1 : len(p.data)-1], inner}
 		} else if isNum := AsDecimalLiteral(p.data[1 : len(p.data)-1]); isNum {
-			propertyName.Literal = LiteralExpr{DecimalToken, p.data[1 : len(p.data)-1]}
+			propertyName.Literal = LiteralExpr{DecimalToken, p.data[1 : len(p.data)-1], inner}
 		} else {
-			propertyName.Literal = LiteralExpr{p.tt, p.data}
+			propertyName.Literal = LiteralExpr{p.tt, p.data, p.tokenSpan()}
 		}
 		p.next()
 	} else if IsNumeric(p.tt) {
-		propertyName.Literal = LiteralExpr{p.tt, p.data}
+		if !p.checkNumericVersion() {
+			return
+		}
+		propertyName.Literal = LiteralExpr{p.tt, p.data, p.tokenSpan()}
 		p.next()
 	} else if p.tt == OpenBracketToken {
+		if !p.checkVersion(start, 2015, "computed property name") {
+			return
+		}
 		p.next()
 		propertyName.Computed = p.parseExpression(OpAssign)
 		if !p.consume(in, CloseBracketToken) {
@@ -1062,29 +2286,46 @@ func (p *Parser) parsePropertyName(in string) (propertyName PropertyName) {
 		p.fail(in, IdentifierToken, StringToken, NumericToken, OpenBracketToken)
 		return
 	}
+	propertyName.Span = p.span(start)
 	return
 }
 
 func (p *Parser) parseBindingElement(decl DeclType) (bindingElement BindingElement) {
 	// binding element
+	start := p.pos
 	bindingElement.Binding = p.parseBinding(decl)
+	if p.TS && decl == ArgumentDecl {
+		if p.tt == QuestionToken {
+			p.next() // optional parameter
+		}
+		if !p.skipTypeAnnotation() {
+			return
+		}
+	}
 	if p.tt == EqToken {
+		if decl == ArgumentDecl && !p.checkVersion(p.pos, 2015, "default parameter") {
+			return
+		}
 		p.next()
 		bindingElement.Default = p.parseExpression(OpAssign)
 	}
+	bindingElement.Span = p.span(start)
 	return
 }
 
 func (p *Parser) parseBinding(decl DeclType) (binding IBinding) {
 	// binding identifier or binding pattern
+	start := p.pos
 	if p.isIdentifierReference(p.tt) {
 		var ok bool
-		binding, ok = p.scope.Declare(decl, p.data)
+		binding, ok = p.declare(decl, p.data, p.pos)
 		if !ok {
 			p.failMessage("identifier %s has already been declared", string(p.data))
 			return
 		}
 		p.next()
+	} else if (p.tt == OpenBracketToken || p.tt == OpenBraceToken) && !p.checkVersion(start, 2015, "destructuring") {
+		return
 	} else if p.tt == OpenBracketToken {
 		p.next()
 		array := BindingArray{}
@@ -1123,6 +2364,7 @@ func (p *Parser) parseBinding(decl DeclType) (binding IBinding) {
 			}
 		}
 		p.next() // always CloseBracketToken
+		array.Span = p.span(start)
 		binding = &array
 	} else if p.tt == OpenBraceToken {
 		p.next()
@@ -1130,13 +2372,16 @@ func (p *Parser) parseBinding(decl DeclType) (binding IBinding) {
 		for p.tt != CloseBraceToken {
 			// binding rest property
 			if p.tt == EllipsisToken {
+				if !p.checkVersion(p.pos, 2018, "object rest") {
+					return
+				}
 				p.next()
 				if !p.isIdentifierReference(p.tt) {
 					p.fail("object binding pattern", IdentifierToken)
 					return
 				}
 				var ok bool
-				object.Rest, ok = p.scope.Declare(decl, p.data)
+				object.Rest, ok = p.declare(decl, p.data, p.pos)
 				if !ok {
 					p.failMessage("identifier %s has already been declared", string(p.data))
 					return
@@ -1150,9 +2395,10 @@ func (p *Parser) parseBinding(decl DeclType) (binding IBinding) {
 			}
 
 			item := BindingObjectItem{}
+			itemStart := p.pos
 			if p.isIdentifierReference(p.tt) {
 				name := p.data
-				item.Key = &PropertyName{LiteralExpr{IdentifierToken, p.data}, nil}
+				item.Key = &PropertyName{LiteralExpr{IdentifierToken, p.data, p.tokenSpan()}, nil, p.tokenSpan()}
 				p.next()
 				if p.tt == ColonToken {
 					// property name + : + binding element
@@ -1162,7 +2408,7 @@ func (p *Parser) parseBinding(decl DeclType) (binding IBinding) {
 					// single name binding
 					var ok bool
 					item.Key.Literal.Data = parse.Copy(item.Key.Literal.Data) // copy so that renaming doesn't rename the key
-					item.Value.Binding, ok = p.scope.Declare(decl, name)
+					item.Value.Binding, ok = p.declare(decl, name, itemStart)
 					if !ok {
 						p.failMessage("identifier %s has already been declared", string(name))
 						return
@@ -1171,6 +2417,7 @@ func (p *Parser) parseBinding(decl DeclType) (binding IBinding) {
 						p.next()
 						item.Value.Default = p.parseExpression(OpAssign)
 					}
+					item.Value.Span = p.span(itemStart)
 				}
 			} else {
 				propertyName := p.parsePropertyName("object binding pattern")
@@ -1180,6 +2427,7 @@ func (p *Parser) parseBinding(decl DeclType) (binding IBinding) {
 				}
 				item.Value = p.parseBindingElement(decl)
 			}
+			item.Span = p.span(itemStart)
 			object.List = append(object.List, item)
 
 			if p.tt == CommaToken {
@@ -1190,6 +2438,7 @@ func (p *Parser) parseBinding(decl DeclType) (binding IBinding) {
 			}
 		}
 		p.next() // always CloseBracketToken
+		object.Span = p.span(start)
 		binding = &object
 	} else {
 		p.fail("binding")
@@ -1200,6 +2449,7 @@ func (p *Parser) parseBinding(decl DeclType) (binding IBinding) {
 
 func (p *Parser) parseArrayLiteral() (array ArrayExpr) {
 	// assume we're on [
+	start := p.pos
 	p.next()
 	prevComma := true
 	for {
@@ -1216,23 +2466,31 @@ func (p *Parser) parseArrayLiteral() (array ArrayExpr) {
 			prevComma = true
 			p.next()
 		} else {
+			elemStart := p.pos
 			spread := p.tt == EllipsisToken
 			if spread {
+				if !p.checkVersion(elemStart, 2015, "spread element") {
+					return
+				}
 				p.next()
 			}
-			array.List = append(array.List, Element{p.parseAssignmentExpression(), spread})
+			value := p.parseAssignmentExpression()
+			array.List = append(array.List, Element{value, spread, p.span(elemStart)})
 			prevComma = false
 			if spread && p.tt != CloseBracketToken {
 				p.assumeArrowFunc = false
 			}
 		}
 	}
+	array.Span = p.span(start)
 	return
 }
 
 func (p *Parser) parseObjectLiteral() (object ObjectExpr) {
 	// assume we're on {
+	start := p.pos
 	p.next()
+	hasProto := false
 	for {
 		if p.tt == ErrorToken {
 			p.fail("object literal", CloseBraceToken)
@@ -1243,7 +2501,11 @@ func (p *Parser) parseObjectLiteral() (object ObjectExpr) {
 		}
 
 		property := Property{}
+		propStart := p.pos
 		if p.tt == EllipsisToken {
+			if !p.checkVersion(propStart, 2018, "object spread") {
+				return
+			}
 			p.next()
 			property.Spread = true
 			property.Value = p.parseAssignmentExpression()
@@ -1253,12 +2515,13 @@ func (p *Parser) parseObjectLiteral() (object ObjectExpr) {
 		} else {
 			// try to parse as MethodDefinition, otherwise fall back to PropertyName:AssignExpr or IdentifierReference
 			var data []byte
+			var dataSpan Span
 			method := MethodDecl{}
 			if p.tt == MulToken {
 				p.next()
 				method.Generator = true
 			} else if p.tt == AsyncToken {
-				data = p.data
+				data, dataSpan = p.data, p.tokenSpan()
 				p.next()
 				if !p.prevLT {
 					method.Async = true
@@ -1268,22 +2531,24 @@ func (p *Parser) parseObjectLiteral() (object ObjectExpr) {
 						data = nil
 					}
 				} else {
-					method.Name.Literal = LiteralExpr{IdentifierToken, data}
+					method.Name.Literal = LiteralExpr{IdentifierToken, data, dataSpan}
+					method.Name.Span = dataSpan
 					data = nil
 				}
 			} else if p.tt == GetToken {
-				data = p.data
+				data, dataSpan = p.data, p.tokenSpan()
 				p.next()
 				method.Get = true
 			} else if p.tt == SetToken {
-				data = p.data
+				data, dataSpan = p.data, p.tokenSpan()
 				p.next()
 				method.Set = true
 			}
 
 			// PropertyName
 			if data != nil && !method.Generator && (p.tt == EqToken || p.tt == CommaToken || p.tt == CloseBraceToken || p.tt == ColonToken || p.tt == OpenParenToken) {
-				method.Name.Literal = LiteralExpr{IdentifierToken, data}
+				method.Name.Literal = LiteralExpr{IdentifierToken, data, dataSpan}
+				method.Name.Span = dataSpan
 				method.Async = false
 				method.Get = false
 				method.Set = false
@@ -1294,41 +2559,64 @@ func (p *Parser) parseObjectLiteral() (object ObjectExpr) {
 				}
 			}
 
+			if p.TS && p.tt == LtToken {
+				if !p.skipTypeParams() {
+					return
+				} else if p.tt != OpenParenToken {
+					p.fail("method definition", OpenParenToken)
+					return
+				}
+			}
 			if p.tt == OpenParenToken {
 				// MethodDefinition
+				if !method.Get && !method.Set && !p.checkVersion(propStart, 2015, "method definition") || !p.checkFuncVersion(propStart, method.Async, method.Generator) {
+					return
+				}
 				parent := p.enterScope(&method.Body.Scope, true)
-				parentAwait, parentYield := p.await, p.yield
-				p.await, p.yield = method.Async, method.Generator
+				parentAwait, parentYield, parentClassInit, parentFn := p.await, p.yield, p.classInit, p.fn
+				p.await, p.yield, p.classInit, p.fn = method.Async, method.Generator, "", funcContext{newTarget: true, superProp: true}
 
 				method.Params = p.parseFuncParams("method definition")
-				method.Body.List = p.parseStmtList("method definition")
+				bodyStart := p.pos
+				method.Body.List = p.parseFuncBody("method definition", &method.Params, true)
+				method.Body.Span = p.span(bodyStart)
+				method.Span = p.span(propStart)
 
-				p.await, p.yield = parentAwait, parentYield
+				p.await, p.yield, p.classInit, p.fn = parentAwait, parentYield, parentClassInit, parentFn
 				p.exitScope(parent)
 				property.Value = &method
 				p.assumeArrowFunc = false
 			} else if p.tt == ColonToken {
 				// PropertyName : AssignmentExpression
+				if isProto(&method.Name) {
+					if hasProto {
+						p.protoDups = append(p.protoDups, method.Name.Start)
+					}
+					hasProto = true
+				}
 				p.next()
 				property.Name = &method.Name
 				property.Value = p.parseAssignmentExpression()
 			} else if method.Name.IsComputed() || !p.isIdentifierReference(method.Name.Literal.TokenType) {
 				p.fail("object literal", ColonToken, OpenParenToken)
 				return
+			} else if !p.checkVersion(propStart, 2015, "shorthand property") {
+				return
 			} else {
 				// IdentifierReference (= AssignmentExpression)?
 				name := method.Name.Literal.Data
+				nameStart := method.Name.Start
 				method.Name.Literal.Data = parse.Copy(method.Name.Literal.Data) // copy so that renaming doesn't rename the key
 				property.Name = &method.Name                                    // set key explicitly so after renaming the original is still known
 				if p.assumeArrowFunc {
 					var ok bool
-					property.Value, ok = p.scope.Declare(ArgumentDecl, name)
+					property.Value, ok = p.declare(ArgumentDecl, name, nameStart)
 					if !ok {
-						property.Value = p.scope.Use(name)
+						property.Value = p.use(name, nameStart)
 						p.assumeArrowFunc = false
 					}
 				} else {
-					property.Value = p.scope.Use(name)
+					property.Value = p.use(name, nameStart)
 				}
 				if p.tt == EqToken {
 					p.next()
@@ -1339,6 +2627,7 @@ func (p *Parser) parseObjectLiteral() (object ObjectExpr) {
 				}
 			}
 		}
+		property.Span = p.span(propStart)
 		object.List = append(object.List, property)
 		if p.tt == CommaToken {
 			p.next()
@@ -1347,19 +2636,26 @@ func (p *Parser) parseObjectLiteral() (object ObjectExpr) {
 			return
 		}
 	}
+	object.Span = p.span(start)
 	return
 }
 
 func (p *Parser) parseTemplateLiteral(precLeft OpPrec) (template TemplateExpr) {
 	// assume we're on 'Template' or 'TemplateStart'
+	start := p.pos
+	if !p.checkVersion(start, 2015, "template literal") {
+		return
+	}
 	template.Prec = OpMember
 	if precLeft < OpMember {
 		template.Prec = OpCall
 	}
 	for p.tt == TemplateStartToken || p.tt == TemplateMiddleToken {
 		tpl := p.data
+		partStart := p.pos
 		p.next()
-		template.List = append(template.List, TemplatePart{tpl, p.parseExpression(OpExpr)})
+		expr := p.parseExpression(OpExpr)
+		template.List = append(template.List, TemplatePart{tpl, expr, p.span(partStart)})
 	}
 	if p.tt != TemplateToken && p.tt != TemplateEndToken {
 		p.fail("template literal", TemplateToken)
@@ -1367,35 +2663,44 @@ func (p *Parser) parseTemplateLiteral(precLeft OpPrec) (template TemplateExpr) {
 	}
 	template.Tail = p.data
 	p.next() // TemplateEndToken
+	template.Span = p.span(start)
 	return
 }
 
 func (p *Parser) parseArguments() (args Args) {
 	// assume we're on (
+	start := p.pos
 	p.next()
 	args.List = make([]Arg, 0, 4)
 	for {
+		argStart := p.pos
 		rest := p.tt == EllipsisToken
 		if rest {
+			if !p.checkVersion(argStart, 2015, "spread argument") {
+				return
+			}
 			p.next()
 		}
 
 		if p.tt == CloseParenToken || p.tt == ErrorToken {
 			break
 		}
+		value := p.parseExpression(OpAssign)
 		args.List = append(args.List, Arg{
-			Value: p.parseExpression(OpAssign),
+			Value: value,
 			Rest:  rest,
+			Span:  p.span(argStart),
 		})
 		if p.tt == CommaToken {
 			p.next()
 		}
 	}
 	p.consume("arguments", CloseParenToken)
+	args.Span = p.span(start)
 	return
 }
 
-func (p *Parser) parseAsyncArrowFunc() (arrowFunc *ArrowFunc) {
+func (p *Parser) parseAsyncArrowFunc(start int) (arrowFunc *ArrowFunc) {
 	// expect we're at Identifier or Yield or (
 	arrowFunc = &ArrowFunc{}
 	parent := p.enterScope(&arrowFunc.Body.Scope, true)
@@ -1403,9 +2708,11 @@ func (p *Parser) parseAsyncArrowFunc() (arrowFunc *ArrowFunc) {
 	p.await, p.yield = true, false
 
 	if IsIdentifier(p.tt) || !p.yield && p.tt == YieldToken {
-		ref, _ := p.scope.Declare(ArgumentDecl, p.data)
+		ref, _ := p.declare(ArgumentDecl, p.data, p.pos)
+		span := p.tokenSpan()
 		p.next()
-		arrowFunc.Params.List = []BindingElement{{Binding: ref}}
+		arrowFunc.Params.List = []BindingElement{{Binding: ref, Span: span}}
+		arrowFunc.Params.Span = span
 	} else {
 		arrowFunc.Params = p.parseFuncParams("arrow function")
 
@@ -1417,22 +2724,24 @@ func (p *Parser) parseAsyncArrowFunc() (arrowFunc *ArrowFunc) {
 
 	p.await, p.yield = true, parentYield
 	arrowFunc.Async = true
-	arrowFunc.Body.List = p.parseArrowFuncBody()
+	p.parseArrowFuncBody(arrowFunc)
+	arrowFunc.Span = p.span(start)
 
 	p.await, p.yield = parentAwait, parentYield
 	p.exitScope(parent)
 	return
 }
 
-func (p *Parser) parseIdentifierArrowFunc(v *Var) (arrowFunc *ArrowFunc) {
+func (p *Parser) parseIdentifierArrowFunc(v *Var, start int) (arrowFunc *ArrowFunc) {
 	// expect we're at =>
 	arrowFunc = &ArrowFunc{}
 	parent := p.enterScope(&arrowFunc.Body.Scope, true)
 	parentAwait, parentYield := p.await, p.yield
+	span := p.span(start)
 
 	if 1 < v.Uses {
 		v.Uses--
-		v, _ = p.scope.Declare(ArgumentDecl, v.Data) // cannot fail
+		v, _ = p.declare(ArgumentDecl, v.Data, start) // cannot fail
 	} else {
 		// if v.Uses==1 it must be undeclared and be the last added
 		p.scope.Parent.Undeclared = p.scope.Parent.Undeclared[:len(p.scope.Parent.Undeclared)-1]
@@ -1441,15 +2750,17 @@ func (p *Parser) parseIdentifierArrowFunc(v *Var) (arrowFunc *ArrowFunc) {
 	}
 
 	p.await = false
-	arrowFunc.Params.List = []BindingElement{{v, nil}}
-	arrowFunc.Body.List = p.parseArrowFuncBody()
+	arrowFunc.Params.List = []BindingElement{{v, nil, span}}
+	arrowFunc.Params.Span = span
+	p.parseArrowFuncBody(arrowFunc)
+	arrowFunc.Span = p.span(start)
 
 	p.await, p.yield = parentAwait, parentYield
 	p.exitScope(parent)
 	return
 }
 
-func (p *Parser) parseArrowFuncBody() (list []IStmt) {
+func (p *Parser) parseArrowFuncBody(arrowFunc *ArrowFunc) {
 	// expect we're at arrow
 	if p.tt != ArrowToken {
 		p.fail("arrow function", ArrowToken)
@@ -1457,52 +2768,65 @@ func (p *Parser) parseArrowFuncBody() (list []IStmt) {
 	} else if p.prevLT {
 		p.fail("expression")
 		return
+	} else if !p.checkVersion(p.pos, 2015, "arrow function") || arrowFunc.Async && !p.checkVersion(p.pos, 2017, "async arrow function") {
+		return
 	}
 	p.next()
 
 	// mark undeclared vars as arguments in `function f(a=b){var b}` where the b's are different vars
 	p.scope.MarkArguments()
 
+	start := p.pos
+	body := &arrowFunc.Body
 	if p.tt == OpenBraceToken {
 		parentInFor := p.inFor
 		p.inFor = false
 		p.yield = false
-		p.allowDirectivePrologue = true
-		list = p.parseStmtList("arrow function")
+		body.List = p.parseFuncBody("arrow function", &arrowFunc.Params, true)
 		p.inFor = parentInFor
 	} else {
-		list = []IStmt{&ReturnStmt{p.parseExpression(OpAssign)}}
+		value := p.parseExpression(OpAssign)
+		body.List = []IStmt{&ReturnStmt{value, p.span(start)}}
+		if p.err == nil {
+			p.checkParams(&arrowFunc.Params, nil, true)
+		}
 	}
-	return
+	body.Span = p.span(start)
 }
 
-func (p *Parser) parseIdentifierExpression(prec OpPrec, ident []byte) IExpr {
+func (p *Parser) parseIdentifierExpression(prec OpPrec, ident []byte, start int) IExpr {
 	var left IExpr
-	left = p.scope.Use(ident)
-	return p.parseExpressionSuffix(left, prec, OpPrimary)
+	left = p.use(ident, start)
+	if string(ident) == "await" && !p.checkAwaitIdentifier(start) {
+		return nil
+	}
+	return p.parseExpressionSuffix(left, prec, OpPrimary, start)
 }
 
-func (p *Parser) parseAsyncExpression(prec OpPrec, async []byte) IExpr {
+func (p *Parser) parseAsyncExpression(prec OpPrec, async []byte, start int) IExpr {
 	// assume we're at a token after async
 	var left IExpr
 	precLeft := OpPrimary
 	if !p.prevLT && p.tt == FunctionToken {
 		// primary expression
-		left = p.parseAsyncFuncExpr()
+		left = p.parseAsyncFuncExpr(start)
+	} else if p.TS && !p.prevLT && prec <= OpAssign && p.tt == LtToken && (!p.JSX || p.isTSArrowTypeParams()) && p.tryTypeParams() {
+		// generic async arrow function expression
+		return p.parseParenthesizedExpressionOrArrowFunc(prec, async, start)
 	} else if !p.prevLT && prec <= OpAssign && (p.tt == OpenParenToken || IsIdentifier(p.tt) || !p.yield && p.tt == YieldToken || p.tt == AwaitToken) {
 		// async arrow function expression
 		if p.tt == AwaitToken {
 			p.fail("arrow function")
 			return nil
 		} else if p.tt == OpenParenToken {
-			return p.parseParenthesizedExpressionOrArrowFunc(prec, async)
+			return p.parseParenthesizedExpressionOrArrowFunc(prec, async, start)
 		}
-		left = p.parseAsyncArrowFunc()
+		left = p.parseAsyncArrowFunc(start)
 		precLeft = OpAssign
 	} else {
-		left = p.scope.Use(async)
+		left = p.use(async, start)
 	}
-	return p.parseExpressionSuffix(left, prec, precLeft)
+	return p.parseExpressionSuffix(left, prec, precLeft, start)
 }
 
 // parseExpression parses an expression that has a precedence of prec or higher.
@@ -1524,24 +2848,35 @@ func (p *Parser) parseExpression(prec OpPrec) IExpr {
 
 	var left IExpr
 	precLeft := OpPrimary
+	start := p.pos
 
 	if IsIdentifier(p.tt) && p.tt != AsyncToken {
-		left = p.scope.Use(p.data)
-		p.next()
-		suffix := p.parseExpressionSuffix(left, prec, precLeft)
+		if member, ok := p.parseTSEnumMember(); ok {
+			left = member
+		} else {
+			left = p.use(p.data, p.pos)
+			p.next()
+		}
+		suffix := p.parseExpressionSuffix(left, prec, precLeft, start)
 		p.exprLevel--
 		return suffix
 	} else if IsNumeric(p.tt) {
-		left = &LiteralExpr{p.tt, p.data}
+		if !p.checkNumericVersion() {
+			return nil
+		}
+		left = &LiteralExpr{p.tt, p.data, p.tokenSpan()}
 		p.next()
-		suffix := p.parseExpressionSuffix(left, prec, precLeft)
+		suffix := p.parseExpressionSuffix(left, prec, precLeft, start)
 		p.exprLevel--
 		return suffix
 	}
 
 	switch tt := p.tt; tt {
 	case StringToken, ThisToken, NullToken, TrueToken, FalseToken, RegExpToken:
-		left = &LiteralExpr{p.tt, p.data}
+		if tt == StringToken && !p.checkStrictString() || tt == RegExpToken && !p.checkRegExp() {
+			return nil
+		}
+		left = &LiteralExpr{p.tt, p.data, p.tokenSpan()}
 		p.next()
 	case OpenBracketToken:
 		parentInFor := p.inFor
@@ -1562,14 +2897,16 @@ func (p *Parser) parseExpression(prec OpPrec) IExpr {
 			p.next()
 			parentInFor := p.inFor
 			p.inFor = false
-			left = &GroupExpr{p.parseExpression(OpExpr)}
+			group := &GroupExpr{X: p.parseExpression(OpExpr)}
 			p.inFor = parentInFor
 			if !p.consume("expression", CloseParenToken) {
 				return nil
 			}
+			group.Span = p.span(start)
+			left = group
 			break
 		}
-		suffix := p.parseParenthesizedExpressionOrArrowFunc(prec, nil)
+		suffix := p.parseParenthesizedExpressionOrArrowFunc(prec, nil, start)
 		p.exprLevel--
 		return suffix
 	case NotToken, BitNotToken, TypeofToken, VoidToken, DeleteToken:
@@ -1578,7 +2915,14 @@ func (p *Parser) parseExpression(prec OpPrec) IExpr {
 			return nil
 		}
 		p.next()
-		left = &UnaryExpr{tt, p.parseExpression(OpUnary)}
+		x := p.parseExpression(OpUnary)
+		if tt == DeleteToken && p.strict {
+			if v, ok := groupedVar(x); ok {
+				p.failAt(start, "delete of identifier %s not allowed in strict mode", string(v.Data))
+				return nil
+			}
+		}
+		left = &UnaryExpr{tt, x, p.span(start)}
 		precLeft = OpUnary
 	case AddToken:
 		if OpUnary < prec {
@@ -1586,7 +2930,8 @@ func (p *Parser) parseExpression(prec OpPrec) IExpr {
 			return nil
 		}
 		p.next()
-		left = &UnaryExpr{PosToken, p.parseExpression(OpUnary)}
+		x := p.parseExpression(OpUnary)
+		left = &UnaryExpr{PosToken, x, p.span(start)}
 		precLeft = OpUnary
 	case SubToken:
 		if OpUnary < prec {
@@ -1594,7 +2939,8 @@ func (p *Parser) parseExpression(prec OpPrec) IExpr {
 			return nil
 		}
 		p.next()
-		left = &UnaryExpr{NegToken, p.parseExpression(OpUnary)}
+		x := p.parseExpression(OpUnary)
+		left = &UnaryExpr{NegToken, x, p.span(start)}
 		precLeft = OpUnary
 	case IncrToken:
 		if OpUpdate < prec {
@@ -1602,7 +2948,12 @@ func (p *Parser) parseExpression(prec OpPrec) IExpr {
 			return nil
 		}
 		p.next()
-		left = &UnaryExpr{PreIncrToken, p.parseExpression(OpUnary)}
+		xStart := p.pos
+		x := p.parseExpression(OpUnary)
+		if !p.checkAssignTarget(x, xStart, false) {
+			return nil
+		}
+		left = &UnaryExpr{PreIncrToken, x, p.span(start)}
 		precLeft = OpUnary
 	case DecrToken:
 		if OpUpdate < prec {
@@ -1610,32 +2961,47 @@ func (p *Parser) parseExpression(prec OpPrec) IExpr {
 			return nil
 		}
 		p.next()
-		left = &UnaryExpr{PreDecrToken, p.parseExpression(OpUnary)}
+		xStart := p.pos
+		x := p.parseExpression(OpUnary)
+		if !p.checkAssignTarget(x, xStart, false) {
+			return nil
+		}
+		left = &UnaryExpr{PreDecrToken, x, p.span(start)}
 		precLeft = OpUnary
 	case AwaitToken:
 		// either accepted as IdentifierReference or as AwaitExpression
 		if p.await && prec <= OpUnary {
+			if p.scope.Func.Parent == nil && !p.checkVersion(start, 2022, "top-level await") {
+				return nil
+			}
 			p.next()
-			left = &UnaryExpr{tt, p.parseExpression(OpUnary)}
+			x := p.parseExpression(OpUnary)
+			left = &UnaryExpr{tt, x, p.span(start)}
 			precLeft = OpUnary
 		} else if p.await {
 			p.fail("expression")
 			return nil
 		} else {
-			left = p.scope.Use(p.data)
+			left = p.use(p.data, p.pos)
 			p.next()
+			if !p.checkAwaitIdentifier(start) {
+				return nil
+			}
 		}
 	case NewToken:
 		p.next()
 		if p.tt == DotToken {
 			p.next()
-			if !p.consume("new.target expression", TargetToken) {
+			if !p.consume("new.target expression", TargetToken) || !p.checkVersion(start, 2015, "new.target") {
+				return nil
+			} else if !p.fn.newTarget {
+				p.failAt(start, "new.target not allowed outside of a function")
 				return nil
 			}
-			left = &NewTargetExpr{}
+			left = &NewTargetExpr{p.span(start)}
 			precLeft = OpMember
 		} else {
-			newExpr := &NewExpr{p.parseExpression(OpNew), nil}
+			newExpr := &NewExpr{X: p.parseExpression(OpNew)}
 			if p.tt == OpenParenToken {
 				args := p.parseArguments()
 				if len(args.List) != 0 {
@@ -1645,18 +3011,17 @@ func (p *Parser) parseExpression(prec OpPrec) IExpr {
 			} else {
 				precLeft = OpNew
 			}
+			newExpr.Span = p.span(start)
 			left = newExpr
 		}
 	case ImportToken:
 		// OpMember < prec does never happen
-		left = &LiteralExpr{p.tt, p.data}
+		left = &LiteralExpr{p.tt, p.data, p.tokenSpan()}
 		p.next()
 		if p.tt == DotToken {
-			p.next()
-			if !p.consume("import.meta expression", MetaToken) {
+			if left = p.parseImportMeta(start); left == nil {
 				return nil
 			}
-			left = &ImportMetaExpr{}
 			precLeft = OpMember
 		} else if p.tt != OpenParenToken {
 			p.fail("import expression", OpenParenToken)
@@ -1664,12 +3029,14 @@ func (p *Parser) parseExpression(prec OpPrec) IExpr {
 		} else if OpCall < prec {
 			p.fail("expression")
 			return nil
+		} else if !p.checkVersion(start, 2020, "import call") {
+			return nil
 		} else {
 			precLeft = OpCall
 		}
 	case SuperToken:
 		// OpMember < prec does never happen
-		left = &LiteralExpr{p.tt, p.data}
+		left = &LiteralExpr{p.tt, p.data, p.tokenSpan()}
 		p.next()
 		if OpCall < prec && p.tt != DotToken && p.tt != OpenBracketToken {
 			p.fail("super expression", OpenBracketToken, DotToken)
@@ -1677,6 +3044,8 @@ func (p *Parser) parseExpression(prec OpPrec) IExpr {
 		} else if p.tt != DotToken && p.tt != OpenBracketToken && p.tt != OpenParenToken {
 			p.fail("super expression", OpenBracketToken, OpenParenToken, DotToken)
 			return nil
+		} else if !p.checkSuper(p.tt == OpenParenToken, start) {
+			return nil
 		}
 		if OpCall < prec {
 			precLeft = OpMember
@@ -1698,24 +3067,49 @@ func (p *Parser) parseExpression(prec OpPrec) IExpr {
 					yieldExpr.X = p.parseExpression(OpAssign)
 				}
 			}
+			yieldExpr.Span = p.span(start)
 			left = &yieldExpr
 			precLeft = OpAssign
 		} else if p.yield {
 			p.fail("expression")
 			return nil
 		} else {
-			left = p.scope.Use(p.data)
+			left = p.use(p.data, p.pos)
 			p.next()
 		}
 	case AsyncToken:
 		async := p.data
 		p.next()
-		left = p.parseAsyncExpression(prec, async)
+		left = p.parseAsyncExpression(prec, async, start)
 	case ClassToken:
 		parentInFor := p.inFor
 		p.inFor = false
 		left = p.parseClassExpr()
 		p.inFor = parentInFor
+	case AtToken:
+		parentInFor := p.inFor
+		p.inFor = false
+		classDecl := p.parseDecoratedClass(p.parseDecorators(), true, start)
+		p.inFor = parentInFor
+		if classDecl == nil {
+			return nil
+		}
+		left = classDecl
+	case PrivateIdentifierToken:
+		// private brand check, as in #x in obj
+		if OpCompare < prec || p.inFor {
+			p.fail("expression")
+			return nil
+		} else if !p.checkVersion(start, 2022, "private brand check") || !p.usePrivateName(p.data, start) {
+			return nil
+		}
+		left = &LiteralExpr{p.tt, p.data, p.tokenSpan()}
+		p.next()
+		if p.tt != InToken {
+			p.fail("private brand check", InToken)
+			return nil
+		}
+		precLeft = OpCompare
 	case FunctionToken:
 		parentInFor := p.inFor
 		p.inFor = false
@@ -1727,16 +3121,164 @@ func (p *Parser) parseExpression(prec OpPrec) IExpr {
 		template := p.parseTemplateLiteral(precLeft)
 		left = &template
 		p.inFor = parentInFor
+	case LtToken:
+		if p.TS && (!p.JSX || p.isTSArrowTypeParams()) {
+			// type assertion or generic arrow function
+			suffix := p.parseTSAngleBracketExpr(prec, start)
+			p.exprLevel--
+			return suffix
+		} else if !p.JSX {
+			p.fail("expression")
+			return nil
+		}
+		parentInFor := p.inFor
+		p.inFor = false
+		element := p.parseJSXElement()
+		if element == nil {
+			return nil
+		}
+		left = element
+		p.inFor = parentInFor
 	default:
 		p.fail("expression")
 		return nil
 	}
-	suffix := p.parseExpressionSuffix(left, prec, precLeft)
+	suffix := p.parseExpressionSuffix(left, prec, precLeft, start)
 	p.exprLevel--
 	return suffix
 }
 
-func (p *Parser) parseExpressionSuffix(left IExpr, prec, precLeft OpPrec) IExpr {
+// checkNumericVersion fails when the targeted ECMAScript version does not support the current numeric literal.
+func (p *Parser) checkNumericVersion() bool {
+	switch p.tt {
+	case BinaryToken, OctalToken:
+		return p.checkVersion(p.pos, 2015, "binary and octal literals")
+	case BigIntToken:
+		return p.checkVersion(p.pos, 2020, "BigInt literal")
+	}
+	return true
+}
+
+// checkRegExp fails for syntax errors in the current regular expression literal, and when the targeted ECMAScript version does not support its flags or syntax.
+func (p *Parser) checkRegExp() bool {
+	regExp, err := ParseRegExpWithOptions(p.data, RegExpOptions{NoAnnexB: p.NoAnnexB&RegExps != 0})
+	if err != nil {
+		perr := err.(*parse.Error)
+		p.failAt(p.pos+perr.Offset, "%s", perr.Message)
+		return false
+	} else if p.Version == 0 {
+		return true
+	}
+	end := bytes.LastIndexByte(p.data, '/')
+	for i := end + 1; i < len(p.data); i++ {
+		switch p.data[i] {
+		case 'u', 'y':
+			if !p.checkVersion(p.pos+i, 2015, "regular expression flag "+string(p.data[i])) {
+				return false
+			}
+		case 's':
+			if !p.checkVersion(p.pos+i, 2018, "regular expression flag s") {
+				return false
+			}
+		case 'd':
+			if !p.checkVersion(p.pos+i, 2022, "regular expression flag d") {
+				return false
+			}
+		case 'v':
+			if !p.checkVersion(p.pos+i, 2024, "regular expression flag v") {
+				return false
+			}
+		}
+	}
+	return p.checkRegExpNodeVersion(regExp.Pattern)
+}
+
+func (p *Parser) checkRegExpNodeVersion(n IRegExpNode) bool {
+	start, _ := n.Offsets()
+	start += p.pos
+	switch n := n.(type) {
+	case *RegExpAlt:
+		for _, item := range n.List {
+			if !p.checkRegExpNodeVersion(item) {
+				return false
+			}
+		}
+	case *RegExpSeq:
+		for _, item := range n.List {
+			if !p.checkRegExpNodeVersion(item) {
+				return false
+			}
+		}
+	case *RegExpClass:
+		for _, item := range n.List {
+			if !p.checkRegExpNodeVersion(item) {
+				return false
+			}
+		}
+	case *RegExpRange:
+		return p.checkRegExpNodeVersion(n.Min) && p.checkRegExpNodeVersion(n.Max)
+	case *RegExpQuantifier:
+		return p.checkRegExpNodeVersion(n.X)
+	case *RegExpGroup:
+		if n.Name != nil && !p.checkVersion(start, 2018, "named capture group") {
+			return false
+		} else if (n.Add != 0 || n.Remove != 0) && !p.checkVersion(start, 2025, "regular expression modifiers") {
+			return false
+		}
+		return p.checkRegExpNodeVersion(n.X)
+	case *RegExpLookaround:
+		if n.Behind && !p.checkVersion(start, 2018, "lookbehind assertion") {
+			return false
+		}
+		return p.checkRegExpNodeVersion(n.X)
+	case *RegExpBackref:
+		if n.Name != nil {
+			return p.checkVersion(start, 2018, "named backreference")
+		}
+	case *RegExpProperty:
+		return p.checkVersion(start, 2018, "unicode property escape")
+	case *RegExpChar:
+		if bytes.HasPrefix(n.Data, []byte("\\u{")) {
+			return p.checkVersion(start, 2015, "unicode code point escape")
+		}
+	}
+	return true
+}
+
+// checkAssignVersion fails when the targeted ECMAScript version does not support the assignment operator, or destructuring assignments.
+func (p *Parser) checkAssignVersion(tt TokenType, left IExpr) bool {
+	switch tt {
+	case EqToken:
+		switch left.(type) {
+		case *ObjectExpr, *ArrayExpr:
+			start, _ := left.Offsets()
+			return p.checkVersion(start, 2015, "destructuring assignment")
+		}
+	case ExpEqToken:
+		return p.checkVersion(p.pos, 2016, "exponentiation operator")
+	case AndEqToken, OrEqToken, NullishEqToken:
+		return p.checkVersion(p.pos, 2021, "logical assignment")
+	}
+	return true
+}
+
+func (p *Parser) parseImportMeta(start int) IExpr {
+	// assume we're at the dot after import
+	if p.SourceType == ScriptSource {
+		p.failAt(start, "import.meta not allowed in script")
+		return nil
+	} else if !p.checkVersion(start, 2020, "import.meta") {
+		return nil
+	}
+	p.next()
+	if !p.consume("import.meta expression", MetaToken) {
+		return nil
+	}
+	return &ImportMetaExpr{p.span(start)}
+}
+
+// parseExpressionSuffix parses the operators after the left-hand side of an expression that starts at start.
+func (p *Parser) parseExpressionSuffix(left IExpr, prec, precLeft OpPrec, start int) IExpr {
 	for i := 0; ; i++ {
 		if 1000 < p.exprLevel+i {
 			p.failMessage("too many nested expressions")
@@ -1751,11 +3293,20 @@ func (p *Parser) parseExpressionSuffix(left IExpr, prec, precLeft OpPrec) IExpr
 				p.fail("expression")
 				return nil
 			}
+			if !p.checkAssignVersion(tt, left) || !p.checkAssignTarget(left, start, tt == EqToken) {
+				return nil
+			} else if tt == EqToken {
+				p.asPattern(left)
+			}
 			p.next()
-			left = &BinaryExpr{tt, left, p.parseExpression(OpAssign)}
+			y := p.parseExpression(OpAssign)
+			left = &BinaryExpr{tt, left, y, p.span(start)}
 			precLeft = OpAssign
 		case LtToken, LtEqToken, GtToken, GtEqToken, InToken, InstanceofToken:
-			if OpCompare < prec || p.inFor && tt == InToken {
+			if p.TS && tt == LtToken && OpCall <= precLeft && p.tryTypeArgs() {
+				// type arguments of a call or instantiation expression
+				continue
+			} else if OpCompare < prec || p.inFor && tt == InToken {
 				return left
 			} else if precLeft < OpCompare {
 				// can only fail after a yield or arrow function expression
@@ -1763,7 +3314,8 @@ func (p *Parser) parseExpressionSuffix(left IExpr, prec, precLeft OpPrec) IExpr
 				return nil
 			}
 			p.next()
-			left = &BinaryExpr{tt, left, p.parseExpression(OpShift)}
+			y := p.parseExpression(OpShift)
+			left = &BinaryExpr{tt, left, y, p.span(start)}
 			precLeft = OpCompare
 		case EqEqToken, NotEqToken, EqEqEqToken, NotEqEqToken:
 			if OpEquals < prec {
@@ -1774,7 +3326,8 @@ func (p *Parser) parseExpressionSuffix(left IExpr, prec, precLeft OpPrec) IExpr
 				return nil
 			}
 			p.next()
-			left = &BinaryExpr{tt, left, p.parseExpression(OpCompare)}
+			y := p.parseExpression(OpCompare)
+			left = &BinaryExpr{tt, left, y, p.span(start)}
 			precLeft = OpEquals
 		case AndToken:
 			if OpAnd < prec {
@@ -1784,7 +3337,8 @@ func (p *Parser) parseExpressionSuffix(left IExpr, prec, precLeft OpPrec) IExpr
 				return nil
 			}
 			p.next()
-			left = &BinaryExpr{tt, left, p.parseExpression(OpBitOr)}
+			y := p.parseExpression(OpBitOr)
+			left = &BinaryExpr{tt, left, y, p.span(start)}
 			precLeft = OpAnd
 		case OrToken:
 			if OpOr < prec {
@@ -1794,7 +3348,8 @@ func (p *Parser) parseExpressionSuffix(left IExpr, prec, precLeft OpPrec) IExpr
 				return nil
 			}
 			p.next()
-			left = &BinaryExpr{tt, left, p.parseExpression(OpAnd)}
+			y := p.parseExpression(OpAnd)
+			left = &BinaryExpr{tt, left, y, p.span(start)}
 			precLeft = OpOr
 		case NullishToken:
 			if OpCoalesce < prec {
@@ -1802,9 +3357,12 @@ func (p *Parser) parseExpressionSuffix(left IExpr, prec, precLeft OpPrec) IExpr
 			} else if precLeft < OpBitOr && precLeft != OpCoalesce {
 				p.fail("expression")
 				return nil
+			} else if !p.checkVersion(p.pos, 2020, "nullish coalescing") {
+				return nil
 			}
 			p.next()
-			left = &BinaryExpr{tt, left, p.parseExpression(OpBitOr)}
+			y := p.parseExpression(OpBitOr)
+			left = &BinaryExpr{tt, left, y, p.span(start)}
 			precLeft = OpCoalesce
 		case DotToken:
 			// OpMember < prec does never happen
@@ -1823,9 +3381,12 @@ func (p *Parser) parseExpressionSuffix(left IExpr, prec, precLeft OpPrec) IExpr
 			}
 			if p.tt != PrivateIdentifierToken {
 				p.tt = IdentifierToken
+			} else if !p.usePrivateName(p.data, p.pos) {
+				return nil
 			}
-			left = &DotExpr{left, LiteralExpr{p.tt, p.data}, exprPrec}
+			y := LiteralExpr{p.tt, p.data, p.tokenSpan()}
 			p.next()
+			left = &DotExpr{left, y, exprPrec, p.span(start)}
 			if precLeft < OpMember {
 				precLeft = OpCall
 			} else {
@@ -1844,11 +3405,13 @@ func (p *Parser) parseExpressionSuffix(left IExpr, prec, precLeft OpPrec) IExpr
 			}
 			parentInFor := p.inFor
 			p.inFor = false
-			left = &IndexExpr{left, p.parseExpression(OpExpr), exprPrec}
+			index := &IndexExpr{left, p.parseExpression(OpExpr), exprPrec, Span{}}
 			p.inFor = parentInFor
 			if !p.consume("index expression", CloseBracketToken) {
 				return nil
 			}
+			index.Span = p.span(start)
+			left = index
 			if precLeft < OpMember {
 				precLeft = OpCall
 			} else {
@@ -1863,7 +3426,8 @@ func (p *Parser) parseExpressionSuffix(left IExpr, prec, precLeft OpPrec) IExpr
 			}
 			parentInFor := p.inFor
 			p.inFor = false
-			left = &CallExpr{left, p.parseArguments()}
+			args := p.parseArguments()
+			left = &CallExpr{left, args, p.span(start)}
 			precLeft = OpCall
 			p.inFor = parentInFor
 		case TemplateToken, TemplateStartToken:
@@ -1876,6 +3440,7 @@ func (p *Parser) parseExpressionSuffix(left IExpr, prec, precLeft OpPrec) IExpr
 			p.inFor = false
 			template := p.parseTemplateLiteral(precLeft)
 			template.Tag = left
+			template.Span = p.span(start)
 			left = &template
 			if precLeft < OpMember {
 				precLeft = OpCall
@@ -1886,29 +3451,39 @@ func (p *Parser) parseExpressionSuffix(left IExpr, prec, precLeft OpPrec) IExpr
 		case OptChainToken:
 			if OpCall < prec {
 				return left
+			} else if !p.checkVersion(p.pos, 2020, "optional chaining") {
+				return nil
 			}
 			p.next()
+			chainStart := p.pos
 			if p.tt == OpenParenToken {
-				left = &OptChainExpr{left, &CallExpr{nil, p.parseArguments()}}
+				args := p.parseArguments()
+				left = &OptChainExpr{left, &CallExpr{nil, args, p.span(chainStart)}, Span{}}
 			} else if p.tt == OpenBracketToken {
 				p.next()
-				left = &OptChainExpr{left, &IndexExpr{nil, p.parseExpression(OpExpr), OpCall}}
+				index := &IndexExpr{nil, p.parseExpression(OpExpr), OpCall, Span{}}
 				if !p.consume("optional chaining expression", CloseBracketToken) {
 					return nil
 				}
+				index.Span = p.span(chainStart)
+				left = &OptChainExpr{left, index, Span{}}
 			} else if p.tt == TemplateToken || p.tt == TemplateStartToken {
 				template := p.parseTemplateLiteral(precLeft)
-				left = &OptChainExpr{left, &template}
+				left = &OptChainExpr{left, &template, Span{}}
 			} else if IsIdentifierName(p.tt) {
-				left = &OptChainExpr{left, &LiteralExpr{IdentifierToken, p.data}}
+				left = &OptChainExpr{left, &LiteralExpr{IdentifierToken, p.data, p.tokenSpan()}, Span{}}
 				p.next()
 			} else if p.tt == PrivateIdentifierToken {
-				left = &OptChainExpr{left, &LiteralExpr{p.tt, p.data}}
+				if !p.usePrivateName(p.data, p.pos) {
+					return nil
+				}
+				left = &OptChainExpr{left, &LiteralExpr{p.tt, p.data, p.tokenSpan()}, Span{}}
 				p.next()
 			} else {
 				p.fail("optional chaining expression", IdentifierToken, OpenParenToken, OpenBracketToken, TemplateToken)
 				return nil
 			}
+			p.setSpan(left, start)
 			precLeft = OpCall
 		case IncrToken:
 			if p.prevLT || OpUpdate < prec {
@@ -1917,8 +3492,11 @@ func (p *Parser) parseExpressionSuffix(left IExpr, prec, precLeft OpPrec) IExpr
 				p.fail("expression")
 				return nil
 			}
+			if !p.checkAssignTarget(left, start, false) {
+				return nil
+			}
 			p.next()
-			left = &UnaryExpr{PostIncrToken, left}
+			left = &UnaryExpr{PostIncrToken, left, p.span(start)}
 			precLeft = OpUpdate
 		case DecrToken:
 			if p.prevLT || OpUpdate < prec {
@@ -1927,8 +3505,11 @@ func (p *Parser) parseExpressionSuffix(left IExpr, prec, precLeft OpPrec) IExpr
 				p.fail("expression")
 				return nil
 			}
+			if !p.checkAssignTarget(left, start, false) {
+				return nil
+			}
 			p.next()
-			left = &UnaryExpr{PostDecrToken, left}
+			left = &UnaryExpr{PostDecrToken, left, p.span(start)}
 			precLeft = OpUpdate
 		case ExpToken:
 			if OpExp < prec {
@@ -1936,9 +3517,12 @@ func (p *Parser) parseExpressionSuffix(left IExpr, prec, precLeft OpPrec) IExpr
 			} else if precLeft < OpUpdate {
 				p.fail("expression")
 				return nil
+			} else if !p.checkVersion(p.pos, 2016, "exponentiation operator") {
+				return nil
 			}
 			p.next()
-			left = &BinaryExpr{tt, left, p.parseExpression(OpExp)}
+			y := p.parseExpression(OpExp)
+			left = &BinaryExpr{tt, left, y, p.span(start)}
 			precLeft = OpExp
 		case MulToken, DivToken, ModToken:
 			if OpMul < prec {
@@ -1948,7 +3532,8 @@ func (p *Parser) parseExpressionSuffix(left IExpr, prec, precLeft OpPrec) IExpr
 				return nil
 			}
 			p.next()
-			left = &BinaryExpr{tt, left, p.parseExpression(OpExp)}
+			y := p.parseExpression(OpExp)
+			left = &BinaryExpr{tt, left, y, p.span(start)}
 			precLeft = OpMul
 		case AddToken, SubToken:
 			if OpAdd < prec {
@@ -1958,7 +3543,8 @@ func (p *Parser) parseExpressionSuffix(left IExpr, prec, precLeft OpPrec) IExpr
 				return nil
 			}
 			p.next()
-			left = &BinaryExpr{tt, left, p.parseExpression(OpMul)}
+			y := p.parseExpression(OpMul)
+			left = &BinaryExpr{tt, left, y, p.span(start)}
 			precLeft = OpAdd
 		case LtLtToken, GtGtToken, GtGtGtToken:
 			if OpShift < prec {
@@ -1968,7 +3554,8 @@ func (p *Parser) parseExpressionSuffix(left IExpr, prec, precLeft OpPrec) IExpr
 				return nil
 			}
 			p.next()
-			left = &BinaryExpr{tt, left, p.parseExpression(OpAdd)}
+			y := p.parseExpression(OpAdd)
+			left = &BinaryExpr{tt, left, y, p.span(start)}
 			precLeft = OpShift
 		case BitAndToken:
 			if OpBitAnd < prec {
@@ -1978,7 +3565,8 @@ func (p *Parser) parseExpressionSuffix(left IExpr, prec, precLeft OpPrec) IExpr
 				return nil
 			}
 			p.next()
-			left = &BinaryExpr{tt, left, p.parseExpression(OpEquals)}
+			y := p.parseExpression(OpEquals)
+			left = &BinaryExpr{tt, left, y, p.span(start)}
 			precLeft = OpBitAnd
 		case BitXorToken:
 			if OpBitXor < prec {
@@ -1988,7 +3576,8 @@ func (p *Parser) parseExpressionSuffix(left IExpr, prec, precLeft OpPrec) IExpr
 				return nil
 			}
 			p.next()
-			left = &BinaryExpr{tt, left, p.parseExpression(OpBitAnd)}
+			y := p.parseExpression(OpBitAnd)
+			left = &BinaryExpr{tt, left, y, p.span(start)}
 			precLeft = OpBitXor
 		case BitOrToken:
 			if OpBitOr < prec {
@@ -1998,7 +3587,8 @@ func (p *Parser) parseExpressionSuffix(left IExpr, prec, precLeft OpPrec) IExpr
 				return nil
 			}
 			p.next()
-			left = &BinaryExpr{tt, left, p.parseExpression(OpBitXor)}
+			y := p.parseExpression(OpBitXor)
+			left = &BinaryExpr{tt, left, y, p.span(start)}
 			precLeft = OpBitOr
 		case QuestionToken:
 			if OpAssign < prec {
@@ -2013,15 +3603,37 @@ func (p *Parser) parseExpressionSuffix(left IExpr, prec, precLeft OpPrec) IExpr
 				return nil
 			}
 			elseExpr := p.parseExpression(OpAssign)
-			left = &CondExpr{left, ifExpr, elseExpr}
+			left = &CondExpr{left, ifExpr, elseExpr, p.span(start)}
 			precLeft = OpAssign
 		case CommaToken:
 			if OpExpr < prec {
 				return left
 			}
 			p.next()
-			left = &BinaryExpr{tt, left, p.parseExpression(OpAssign)}
+			y := p.parseExpression(OpAssign)
+			left = &BinaryExpr{tt, left, y, p.span(start)}
 			precLeft = OpExpr
+		case AsToken, IdentifierToken:
+			// TypeScript type assertion or satisfies expression
+			if !p.TS || p.prevLT || tt == IdentifierToken && !p.isTSKeyword("satisfies") || OpCompare < prec {
+				return left
+			} else if precLeft < OpCompare {
+				p.fail("expression")
+				return nil
+			}
+			p.next()
+			if tt == AsToken && p.tt == ConstToken {
+				p.next()
+			} else if !p.skipType() {
+				return nil
+			}
+			precLeft = OpCompare
+		case NotToken:
+			// TypeScript non-null assertion
+			if !p.TS || p.prevLT || precLeft < OpLHS {
+				return left
+			}
+			p.next()
 		case ArrowToken:
 			// handle identifier => ..., where identifier could also be yield or await
 			if OpAssign < prec {
@@ -2037,7 +3649,7 @@ func (p *Parser) parseExpressionSuffix(left IExpr, prec, precLeft OpPrec) IExpr
 				return nil
 			}
 
-			left = p.parseIdentifierArrowFunc(v)
+			left = p.parseIdentifierArrowFunc(v, start)
 			precLeft = OpAssign
 		default:
 			return left
@@ -2050,45 +3662,59 @@ func (p *Parser) parseAssignmentExpression() IExpr {
 	if p.assumeArrowFunc && p.isIdentifierReference(p.tt) {
 		tt := p.tt
 		data := p.data
+		start := p.pos
 		p.next()
+		if p.TS && (p.tt == QuestionToken && p.isTSOptionalParam() || p.tt == ColonToken) {
+			// optional parameter or type annotation of an arrow function parameter
+			if p.tt == QuestionToken {
+				p.next()
+			}
+			if !p.skipTypeAnnotation() {
+				return nil
+			}
+			p.typedParams = true
+		}
 		if p.tt == EqToken || p.tt == CommaToken || p.tt == CloseParenToken || p.tt == CloseBraceToken || p.tt == CloseBracketToken {
 			var left IExpr
-			left, _ = p.scope.Declare(ArgumentDecl, data) // cannot fail
+			left, _ = p.declare(ArgumentDecl, data, start) // cannot fail
 			p.assumeArrowFunc = false
-			left = p.parseExpressionSuffix(left, OpAssign, OpPrimary)
+			left = p.parseExpressionSuffix(left, OpAssign, OpPrimary, start)
 			p.assumeArrowFunc = true
 			return left
 		}
 		p.assumeArrowFunc = false
 		if tt == AsyncToken {
-			return p.parseAsyncExpression(OpAssign, data)
+			return p.parseAsyncExpression(OpAssign, data, start)
 		}
-		return p.parseIdentifierExpression(OpAssign, data)
+		return p.parseIdentifierExpression(OpAssign, data, start)
 	} else if p.tt != OpenBracketToken && p.tt != OpenBraceToken {
 		p.assumeArrowFunc = false
 	}
 	return p.parseExpression(OpAssign)
 }
 
-func (p *Parser) parseParenthesizedExpressionOrArrowFunc(prec OpPrec, async []byte) IExpr {
+func (p *Parser) parseParenthesizedExpressionOrArrowFunc(prec OpPrec, async []byte, start int) IExpr {
 	var left IExpr
 	precLeft := OpPrimary
 
 	// expect to be at (
+	paramsStart := p.pos
 	p.next()
 
 	isAsync := async != nil
 	arrowFunc := &ArrowFunc{}
 	parent := p.enterScope(&arrowFunc.Body.Scope, true)
-	parentAssumeArrowFunc, parentInFor := p.assumeArrowFunc, p.inFor
-	p.assumeArrowFunc, p.inFor = true, false
+	parentAssumeArrowFunc, parentInFor, parentTypedParams := p.assumeArrowFunc, p.inFor, p.typedParams
+	p.assumeArrowFunc, p.inFor, p.typedParams = true, false, false
 
 	// parse a parenthesized expression but assume we might be parsing an (async) arrow function. If this is really an arrow function, parsing as a parenthesized expression cannot fail as AssignmentExpression, ArrayLiteral, and ObjectLiteral are supersets of SingleNameBinding, ArrayBindingPattern, and ObjectBindingPattern respectively. Any identifier that would be a BindingIdentifier in case of an arrow function, will be added as such. If finally this is not an arrow function, we will demote those variables an undeclared and merge them with the parent scope.
 
 	var list []IExpr
 	var rest IExpr
+	var restStart int
 	for p.tt != CloseParenToken && p.tt != ErrorToken {
 		if p.tt == EllipsisToken && p.assumeArrowFunc {
+			restStart = p.pos
 			p.next()
 			if isAsync {
 				rest = p.parseAssignmentExpression()
@@ -2096,7 +3722,7 @@ func (p *Parser) parseParenthesizedExpressionOrArrowFunc(prec OpPrec, async []by
 					p.next()
 				}
 			} else if p.isIdentifierReference(p.tt) {
-				rest, _ = p.scope.Declare(ArgumentDecl, p.data) // cannot fail
+				rest, _ = p.declare(ArgumentDecl, p.data, p.pos) // cannot fail
 				p.next()
 			} else if p.tt == OpenBracketToken {
 				array := p.parseArrayLiteral()
@@ -2108,10 +3734,32 @@ func (p *Parser) parseParenthesizedExpressionOrArrowFunc(prec OpPrec, async []by
 				p.fail("arrow function")
 				return nil
 			}
+			if p.TS && p.tt == ColonToken {
+				if !p.skipTypeAnnotation() {
+					return nil
+				}
+				p.typedParams = true
+			}
 			break
 		}
 
-		list = append(list, p.parseAssignmentExpression())
+		item := p.parseAssignmentExpression()
+		if p.TS && p.assumeArrowFunc && p.tt == ColonToken {
+			// type annotation of an arrow function parameter that is a binding pattern
+			if !p.skipTypeAnnotation() {
+				return nil
+			}
+			p.typedParams = true
+			if p.tt == EqToken {
+				itemStart, _ := item.Offsets()
+				p.next()
+				p.assumeArrowFunc = false
+				item = &BinaryExpr{EqToken, item, p.parseExpression(OpAssign), Span{}}
+				p.assumeArrowFunc = true
+				p.setSpan(item, itemStart)
+			}
+		}
+		list = append(list, item)
 		if p.tt != CommaToken {
 			break
 		}
@@ -2122,21 +3770,29 @@ func (p *Parser) parseParenthesizedExpressionOrArrowFunc(prec OpPrec, async []by
 		return nil
 	}
 	p.next()
+	if p.TS && p.tt == ColonToken && p.assumeArrowFunc && p.tryArrowReturnType() {
+		p.typedParams = true
+	}
 	isArrowFunc := p.tt == ArrowToken && p.assumeArrowFunc
-	p.assumeArrowFunc, p.inFor = parentAssumeArrowFunc, parentInFor
+	if p.typedParams && !isArrowFunc {
+		p.fail("arrow function", ArrowToken)
+		return nil
+	}
+	p.assumeArrowFunc, p.inFor, p.typedParams = parentAssumeArrowFunc, parentInFor, parentTypedParams
 
 	if isArrowFunc {
 		parentAwait, parentYield := p.await, p.yield
 		p.await = isAsync
 
 		// arrow function
-		arrowFunc.Params = Params{List: make([]BindingElement, len(list))}
+		arrowFunc.Params = Params{List: make([]BindingElement, len(list)), Span: p.span(paramsStart)}
 		for i, item := range list {
 			arrowFunc.Params.List[i] = p.exprToBindingElement(item) // can not fail when assumArrowFunc is set
 		}
 		arrowFunc.Async = isAsync
 		arrowFunc.Params.Rest = p.exprToBinding(rest)
-		arrowFunc.Body.List = p.parseArrowFuncBody()
+		p.parseArrowFuncBody(arrowFunc)
+		arrowFunc.Span = p.span(start)
 
 		p.await, p.yield = parentAwait, parentYield
 		p.exitScope(parent)
@@ -2155,35 +3811,40 @@ func (p *Parser) parseParenthesizedExpressionOrArrowFunc(prec OpPrec, async []by
 
 		if isAsync {
 			// call expression
-			args := Args{}
+			args := Args{Span: p.span(paramsStart)}
 			for _, item := range list {
-				args.List = append(args.List, Arg{Value: item, Rest: false})
+				itemStart, itemEnd := item.Offsets()
+				args.List = append(args.List, Arg{Value: item, Rest: false, Span: Span{itemStart, itemEnd}})
 			}
 			if rest != nil {
-				args.List = append(args.List, Arg{Value: rest, Rest: true})
+				_, restEnd := rest.Offsets()
+				args.List = append(args.List, Arg{Value: rest, Rest: true, Span: Span{restStart, restEnd}})
 			}
-			left = p.scope.Use(async)
-			left = &CallExpr{left, args}
+			left = p.use(async, start)
+			left = &CallExpr{left, args, p.span(start)}
 			precLeft = OpCall
 		} else {
 			// parenthesized expression
 			left = list[0]
+			listStart, _ := left.Offsets()
 			for _, item := range list[1:] {
-				left = &BinaryExpr{CommaToken, left, item}
+				_, itemEnd := item.Offsets()
+				left = &BinaryExpr{CommaToken, left, item, Span{listStart, itemEnd}}
 			}
-			left = &GroupExpr{left}
+			left = &GroupExpr{left, p.span(start)}
 		}
 	}
-	return p.parseExpressionSuffix(left, prec, precLeft)
+	return p.parseExpressionSuffix(left, prec, precLeft, start)
 }
 
 // exprToBinding converts a CoverParenthesizedExpressionAndArrowParameterList into FormalParameters
 // Any unbound variables of the parameters (Initializer, ComputedPropertyName) are kept in the parent scope
 func (p *Parser) exprToBinding(expr IExpr) (binding IBinding) {
+	p.asPattern(expr)
 	if v, ok := expr.(*Var); ok {
 		binding = v
 	} else if array, ok := expr.(*ArrayExpr); ok {
-		bindingArray := BindingArray{}
+		bindingArray := BindingArray{Span: array.Span}
 		for _, item := range array.List {
 			if item.Spread {
 				// can only BindingIdentifier or BindingPattern
@@ -2196,7 +3857,7 @@ func (p *Parser) exprToBinding(expr IExpr) (binding IBinding) {
 		}
 		binding = &bindingArray
 	} else if object, ok := expr.(*ObjectExpr); ok {
-		bindingObject := BindingObject{}
+		bindingObject := BindingObject{Span: object.Span}
 		for _, item := range object.List {
 			if item.Spread {
 				// can only be BindingIdentifier
@@ -2210,7 +3871,11 @@ func (p *Parser) exprToBinding(expr IExpr) (binding IBinding) {
 			} else if item.Init != nil {
 				bindingElement.Default = item.Init
 			}
-			bindingObject.List = append(bindingObject.List, BindingObjectItem{Key: item.Name, Value: bindingElement})
+			if bindingElement.Binding != nil {
+				start, _ := bindingElement.Binding.Offsets()
+				bindingElement.Span = Span{start, item.End}
+			}
+			bindingObject.List = append(bindingObject.List, BindingObjectItem{Key: item.Name, Value: bindingElement, Span: item.Span})
 		}
 		binding = &bindingObject
 	}
@@ -2224,6 +3889,9 @@ func (p *Parser) exprToBindingElement(expr IExpr) (bindingElement BindingElement
 	} else {
 		bindingElement.Binding = p.exprToBinding(expr)
 	}
+	if expr != nil {
+		bindingElement.Start, bindingElement.End = expr.Offsets()
+	}
 	return
 }
 
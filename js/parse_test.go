@@ -43,10 +43,14 @@ func TestParse(t *testing.T) {
 		{"{a=5}", "Stmt({ Stmt(a=5) })"},
 		{"return", "Stmt(return)"},
 		{"return 5*3", "Stmt(return (5*3))"},
-		{"break", "Stmt(break)"},
-		{"break LABEL", "Stmt(break LABEL)"},
-		{"continue", "Stmt(continue)"},
-		{"continue LABEL", "Stmt(continue LABEL)"},
+		{"for (;;) break", "Stmt(for ; ; Stmt({ Stmt(break) }))"},
+		{"LABEL: for (;;) break LABEL", "Stmt(LABEL : Stmt(for ; ; Stmt({ Stmt(break LABEL) })))"},
+		{"for (;;) continue", "Stmt(for ; ; Stmt({ Stmt(continue) }))"},
+		{"LABEL: for (;;) continue LABEL", "Stmt(LABEL : Stmt(for ; ; Stmt({ Stmt(continue LABEL) })))"},
+		{"while (a) { switch (b) { case 1: continue } }", "Stmt(while a Stmt({ Stmt(switch b Clause(case 1 Stmt(continue))) }))"},
+		{"var eval; eval++", "Decl(var Binding(eval)) Stmt(eval++)"},
+		{"var implements, let", "Decl(var Binding(implements) Binding(let))"},
+		{"'use strict'; a.eval = arguments", "Stmt('use strict') Stmt((a.eval)=arguments)"},
 		{"if (a == 5) return true", "Stmt(if (a==5) Stmt(return true))"},
 		{"if (a == 5) return true else return false", "Stmt(if (a==5) Stmt(return true) else Stmt(return false))"},
 		{"if (a) b; else if (c) d;", "Stmt(if a Stmt(b) else Stmt(if c Stmt(d)))"},
@@ -112,6 +116,27 @@ func TestParse(t *testing.T) {
 		{"class A { #field=5 }", "Decl(class A Definition(#field = 5))"},
 		{"class A { get }", "Decl(class A Definition(get))"},
 		{"class A { field static get method(){} }", "Decl(class A Definition(field) Method(static get method Params() Stmt({ })))"},
+		{"class A { static a; static #b = 1; static = 2; static get = 3 }", "Decl(class A Definition(static a) Definition(static #b = 1) Definition(static = 2) Definition(static get = 3))"},
+		{"class A { #a() {} static #b() {} get #c() {} }", "Decl(class A Method(#a Params() Stmt({ })) Method(static #b Params() Stmt({ })) Method(get #c Params() Stmt({ })))"},
+		{"class A { static { var a = this } b = 1; static {} }", "Decl(class A Definition(static Stmt({ Decl(var Binding(a = this)) })) Definition(b = 1) Definition(static Stmt({ })))"},
+		{"class A { #c; m(b) { return #c in b } }", "Decl(class A Definition(#c) Method(m Params(Binding(b)) Stmt({ Stmt(return (#c in b)) })))"},
+		{"@a @b.c @d.e(f) @(g) class A {}", "Decl(@a @(b.c) @((d.e)(f)) @(g) class A)"},
+		{"class A { @a b; @c static d() {} @e @f get g() {} }", "Decl(class A Definition(@a b) Method(@c static d Params() Stmt({ })) Method(@e @f get g Params() Stmt({ })))"},
+		{"a = @b class {}", "Stmt(a=Decl(@b class))"},
+		{"@a export class A {}", "Stmt(export Decl(@a class A))"},
+		{"export @a class A {}", "Stmt(export Decl(@a class A))"},
+		{"export default @a class {}", "Stmt(export default Decl(@a class))"},
+		{"class A { @c accessor z = 1; static accessor #y }", "Decl(class A Definition(@c accessor z = 1) Definition(static accessor #y))"},
+		{"class A { accessor\nb; accessor() {} static accessor }", "Decl(class A Definition(accessor) Definition(b) Definition(static accessor) Method(accessor Params() Stmt({ })))"},
+		{"class A { static { () => { return }; function f() { return arguments } } }", "Decl(class A Definition(static Stmt({ Stmt(Params() => Stmt({ Stmt(return) })) Decl(function f Params() Stmt({ Stmt(return arguments) })) })))"},
+		{"{ using a = b, c = d }", "Stmt({ Decl(using Binding(a = b) Binding(c = d)) })"},
+		{"using\na = b", "Stmt(using) Stmt(a=b)"},
+		{"using[a] = b", "Stmt((using[a])=b)"},
+		{"using: for (;;) break using", "Stmt(using : Stmt(for ; ; Stmt({ Stmt(break using) })))"},
+		{"for (using a of b) ;", "Stmt(for Decl(using Binding(a)) of b Stmt({ }))"},
+		{"for (using of of a) ;", "Stmt(for Decl(using Binding(of)) of a Stmt({ }))"},
+		{"for (using of a) ;", "Stmt(for using of a Stmt({ }))"},
+		{"async function f() { await using a = b; for (await using c of d) ; }", "Decl(async function f Params() Stmt({ Decl(await using Binding(a = b)) Stmt(for Decl(await using Binding(c)) of d Stmt({ })) }))"},
 		//{"class A { get get get(){} }", "Decl(class A Definition(get) Method(get get Params() Stmt({ })))"}, // doesn't look like this should be supported
 		{"`tmpl`", "Stmt(`tmpl`)"},
 		{"`tmpl${x}`", "Stmt(`tmpl${x}`)"},
@@ -119,11 +144,15 @@ func TestParse(t *testing.T) {
 		{"import \"pkg\";", "Stmt(import \"pkg\")"},
 		{"import yield from \"pkg\"", "Stmt(import yield from \"pkg\")"},
 		{"import * as yield from \"pkg\"", "Stmt(import * as yield from \"pkg\")"},
-		{"import {yield, for as yield,} from \"pkg\"", "Stmt(import { yield , for as yield , } from \"pkg\")"},
-		{"import yield, * as yield from \"pkg\"", "Stmt(import yield , * as yield from \"pkg\")"},
-		{"import yield, {yield} from \"pkg\"", "Stmt(import yield , { yield } from \"pkg\")"},
+		{"import {yield, for as a,} from \"pkg\"", "Stmt(import { yield , for as a , } from \"pkg\")"},
+		{"import yield, * as a from \"pkg\"", "Stmt(import yield , * as a from \"pkg\")"},
+		{"import a, {yield} from \"pkg\"", "Stmt(import a , { yield } from \"pkg\")"},
 		{"import {yield,} from \"pkg\"", "Stmt(import { yield , } from \"pkg\")"},
 		{"export * from \"pkg\";", "Stmt(export * from \"pkg\")"},
+		{"import a from \"pkg\" with { type: \"json\", \"b\": 'c', }", "Stmt(import a from \"pkg\" with { type: \"json\", \"b\": 'c' })"},
+		{"import \"pkg\" with {}", "Stmt(import \"pkg\")"},
+		{"export {a} from \"pkg\" with { type: \"json\" }", "Stmt(export { a } from \"pkg\" with { type: \"json\" })"},
+		{"import(\"pkg\", { with: { type: \"json\" } })", "Stmt(import(\"pkg\", {with: {type: \"json\"}}))"},
 		{"export * as for from \"pkg\"", "Stmt(export * as for from \"pkg\")"},
 		{"export {if, for as switch} from \"pkg\"", "Stmt(export { if , for as switch } from \"pkg\")"},
 		{"export {if, for as switch,}", "Stmt(export { if , for as switch , })"},
@@ -257,20 +286,22 @@ func TestParse(t *testing.T) {
 		{"function a(){ x = await => a++ }", "Decl(function a Params() Stmt({ Stmt(x=(Params(Binding(await)) => Stmt({ Stmt(return (a++)) }))) }))"},
 		{"x = a??b", "Stmt(x=(a??b))"},
 		{"x = a[b]", "Stmt(x=(a[b]))"},
-		{"x = a.#b", "Stmt(x=(a.#b))"},
+		{"class A { #b; m() { x = a.#b } }", "Decl(class A Definition(#b) Method(m Params() Stmt({ Stmt(x=(a.#b)) })))"},
 		{"x = a?.b?.c.d", "Stmt(x=(((a?.b)?.c).d))"},
 		{"x = a?.[b]?.`tpl`", "Stmt(x=((a?.[b])?.`tpl`))"},
 		{"x = a?.(b)", "Stmt(x=(a?.(b)))"},
-		{"x = a?.#b", "Stmt(x=(a?.#b))"},
-		{"x = super(a)", "Stmt(x=(super(a)))"},
+		{"class A { #b; m() { x = a?.#b } }", "Decl(class A Definition(#b) Method(m Params() Stmt({ Stmt(x=(a?.#b)) })))"},
+		{"class A { get #b() {} set #b(c) {} }", "Decl(class A Method(get #b Params() Stmt({ })) Method(set #b Params(Binding(c)) Stmt({ })))"},
+		{"class A extends B { constructor() { () => super() } }", "Decl(class A extends B Method(constructor Params() Stmt({ Stmt(Params() => Stmt({ Stmt(return (super())) })) })))"},
+		{"class A extends B { constructor() { x = super(a) } }", "Decl(class A extends B Method(constructor Params() Stmt({ Stmt(x=(super(a))) })))"},
 		{"x = a(a,b,...c,)", "Stmt(x=(a(a, b, ...c)))"},
 		{"x = a(...a,...b)", "Stmt(x=(a(...a, ...b)))"},
 		{"x = new a", "Stmt(x=(new a))"},
 		{"x = new a()", "Stmt(x=(new a))"},
 		{"x = new a(b)", "Stmt(x=(new a(b)))"},
 		{"x = new a().b(c)", "Stmt(x=(((new a).b)(c)))"},
-		{"x = new a().#b", "Stmt(x=((new a).#b))"},
-		{"x = new new.target", "Stmt(x=(new (new.target)))"},
+		{"class A { #b; m() { x = new a().#b } }", "Decl(class A Definition(#b) Method(m Params() Stmt({ Stmt(x=((new a).#b)) })))"},
+		{"function f() { x = new new.target }", "Decl(function f Params() Stmt({ Stmt(x=(new (new.target))) }))"},
 		{"x = new import.meta", "Stmt(x=(new (import.meta)))"},
 		{"x = import(a)", "Stmt(x=(import(a)))"},
 		{"import('module')", "Stmt(import('module'))"},
@@ -328,8 +359,8 @@ func TestParse(t *testing.T) {
 		{"a&&b&&c", "Stmt((a&&b)&&c)"},
 		{"a||b||c", "Stmt((a||b)||c)"},
 		{"new new a(b)", "Stmt(new (new a(b)))"},
-		{"new super.a(b)", "Stmt(new (super.a)(b))"},
-		{"new new.target(a)", "Stmt(new (new.target)(a))"},
+		{"({ m() { new super.a(b) } })", "Stmt({Method(m Params() Stmt({ Stmt(new (super.a)(b)) }))})"},
+		{"function f() { new new.target(a) }", "Decl(function f Params() Stmt({ Stmt(new (new.target)(a)) }))"},
 		{"new import.meta(a)", "Stmt(new (import.meta)(a))"},
 		{"a(b)[c]", "Stmt((a(b))[c])"},
 		{"a[b]`tmpl`", "Stmt((a[b])`tmpl`)"},
@@ -390,6 +421,21 @@ func TestParse(t *testing.T) {
 		{"return //comment\n a", "Stmt(return) Stmt(a)"},
 		{"a?.b\n`c`", "Stmt((a?.b)`c`)"},
 
+		// early errors that do not apply
+		{"function f(a, a) {}", "Decl(function f Params(Binding(a), Binding(a)) Stmt({ }))"},
+		{"'\\01'; delete a; with (a) b", "Stmt('\\01') Stmt(delete a) Stmt(with a Stmt(b))"},
+		{"function f() { 'use strict' } with (a) b", "Decl(function f Params() Stmt({ Stmt('use strict') })) Stmt(with a Stmt(b))"},
+		{"'use\\x20strict'; with (a) b", "Stmt('use\\x20strict') Stmt(with a Stmt(b))"},
+		{"'use strict'; a = '\\0'; delete a.b", "Stmt('use strict') Stmt(a='\\0') Stmt(delete (a.b))"},
+		{"({__proto__: a, __proto__: b} = c)", "Stmt(({__proto__: a, __proto__: b}=c))"},
+		{"[{__proto__: a, __proto__: b}] = c", "Stmt([{__proto__: a, __proto__: b}]=c)"},
+		{"({__proto__: a, __proto__: b}) => c", "Stmt(Params(Binding({ __proto__: Binding(a), __proto__: Binding(b) })) => Stmt({ Stmt(return c) }))"},
+		{"for ({__proto__: a, __proto__: b} of c) ;", "Stmt(for {__proto__: a, __proto__: b} of c Stmt({ }))"},
+		{"x = {__proto__: a, ['__proto__']: b, __proto__() {}, __proto__}", "Stmt(x={__proto__: a, ['__proto__']: b, Method(__proto__ Params() Stmt({ })), __proto__})"},
+		{"a: b: while (1) continue a", "Stmt(a : Stmt(b : Stmt(while 1 Stmt(continue a))))"},
+		{"a: while (1) { b: { continue a } }", "Stmt(a : Stmt(while 1 Stmt({ Stmt(b : Stmt({ Stmt(continue a) })) })))"},
+		{"a: { break a } a: ;", "Stmt(a : Stmt({ Stmt(break a) })) Stmt(a : Stmt(;))"},
+
 		{"() => { const v=6; x={v} }", "Stmt(Params() => Stmt({ Decl(const Binding(v = 6)) Stmt(x={v}) }))"},
 		{`([]=l=>{let{e}={e}})`, `Stmt(([]=(Params(Binding(l)) => Stmt({ Decl(let Binding({ Binding(e) } = {e})) }))))`}, // go-fuzz
 	}
@@ -516,9 +562,9 @@ func TestParseError(t *testing.T) {
 		{"x=new.bad", "expected target instead of bad in new.target expression"},
 		{"x=import.bad", "expected meta instead of bad in import.meta expression"},
 		{"x=super", "expected [, (, or . instead of EOF in super expression"},
-		{"x=super(a", "expected ) instead of EOF in arguments"},
-		{"x=super[a", "expected ] instead of EOF in index expression"},
-		{"x=super.", "expected Identifier instead of EOF in dot expression"},
+		{"class A extends B { constructor() { x=super(a", "expected ) instead of EOF in arguments"},
+		{"({ m() { x=super[a", "expected ] instead of EOF in index expression"},
+		{"({ m() { x=super.", "expected Identifier instead of EOF in dot expression"},
 		{"x=new super(b)", "expected [ or . instead of ( in super expression"},
 		{"x=import", "expected ( instead of EOF in import expression"},
 		{"x=import(5", "expected ) instead of EOF in arguments"},
@@ -545,7 +591,7 @@ func TestParseError(t *testing.T) {
 		{"export async", "expected function instead of EOF in export statement"},
 
 		// no declarations
-		{"if(a) function f(){}", "unexpected function in statement"},
+		{"'use strict'; if(a) function f(){}", "unexpected function in statement"},
 		{"if(a) async function f(){}", "unexpected async in statement"},
 		{"if(a) class c{}", "unexpected class in statement"},
 
@@ -674,13 +720,106 @@ func TestParseError(t *testing.T) {
 		{"for(let a in [0,1,2]){var a = 5}", "identifier a has already been declared"},
 		{"for(let a=0; a<10; a++){var a = 5}", "identifier a has already been declared"},
 
+		// early errors
+		{"'use strict'; function f(a, a) {}", "duplicate parameter name a"},
+		{"function f(a, a) { 'use strict' }", "duplicate parameter name a"},
+		{"function f(a, [a]) {}", "duplicate parameter name a"},
+		{"(a, a) => b", "duplicate parameter name a"},
+		{"x = {f(a, a) {}}", "duplicate parameter name a"},
+		{"class A { f(a, ...a) {} }", "duplicate parameter name a"},
+		{"function f(a = 1) { 'use strict' }", "\"use strict\" not allowed in function with non-simple parameters"},
+		{"'use strict'; with (a) b", "with statement not allowed in strict mode"},
+		{"class A { f() { with (a) b } }", "with statement not allowed in strict mode"},
+		{"'use strict'; a = '\\01'", "octal escape sequence not allowed in strict mode"},
+		{"'use strict'; a = {'\\8': 1}", "octal escape sequence not allowed in strict mode"},
+		{"function f() { '\\01'; 'use strict' }", "octal escape sequence not allowed in strict mode"},
+		{"var \\u0069f", "keyword if must not contain escape sequences"},
+		{"\\u{74}his", "keyword this must not contain escape sequences"},
+		{"'use strict'; delete a", "delete of identifier a not allowed in strict mode"},
+		{"'use strict'; delete ((a))", "delete of identifier a not allowed in strict mode"},
+		{"a = {__proto__: b, __proto__: c}", "duplicate __proto__ property in object literal"},
+		{"a = {'__proto__': b, __proto__: c}", "duplicate __proto__ property in object literal"},
+		{"f({__proto__: a, __proto__: b})", "duplicate __proto__ property in object literal"},
+		{"for (;;) { a = {__proto__: b, __proto__: c, f() { d } } }", "duplicate __proto__ property in object literal"},
+		{"break a", "undefined label a"},
+		{"a: { function f() { break a } }", "undefined label a"},
+		{"a: { continue a }", "label a does not denote an iteration statement"},
+		{"a: a: ;", "label a has already been declared"},
+		{"break", "break statement not allowed outside of a loop or switch"},
+		{"continue", "continue statement not allowed outside of a loop"},
+		{"switch (a) { case 1: continue }", "continue statement not allowed outside of a loop"},
+		{"while (a) { function f() { break } }", "break statement not allowed outside of a loop or switch"},
+		{"for (;;) { class A { static { break } } }", "break statement not allowed outside of a loop or switch"},
+		{"'use strict'; var eval", "eval not allowed as binding name in strict mode"},
+		{"'use strict'; try {} catch (arguments) {}", "arguments not allowed as binding name in strict mode"},
+		{"function f(eval) { 'use strict' }", "eval not allowed as binding name in strict mode"},
+		{"function eval() { 'use strict' }", "eval not allowed as binding name in strict mode"},
+		{"'use strict'; (a, arguments) => b", "arguments not allowed as binding name in strict mode"},
+		{"class eval {}", "eval not allowed as binding name in strict mode"},
+		{"'use strict'; eval = a", "assignment to eval not allowed in strict mode"},
+		{"'use strict'; arguments++", "assignment to arguments not allowed in strict mode"},
+		{"'use strict'; --(eval)", "assignment to eval not allowed in strict mode"},
+		{"'use strict'; [a, ...arguments] = b", "assignment to arguments not allowed in strict mode"},
+		{"'use strict'; ({a: eval = 1} = b)", "assignment to eval not allowed in strict mode"},
+		{"'use strict'; for (eval of a) ;", "assignment to eval not allowed in strict mode"},
+		{"'use strict'; var implements", "reserved word implements not allowed in strict mode"},
+		{"'use strict'; let yield", "reserved word yield not allowed in strict mode"},
+		{"'use strict'; a = static", "reserved word static not allowed in strict mode"},
+		{"'use strict'; package: a", "reserved word package not allowed in strict mode"},
+		{"function f(public) { 'use strict' }", "reserved word public not allowed in strict mode"},
+		{"class A { m() { var interface } }", "reserved word interface not allowed in strict mode"},
+		{"'use strict'; var \\u0070rivate", "reserved word private not allowed in strict mode"},
+		{"let let", "let not allowed as name of a lexical declaration"},
+		{"const [let] = a", "let not allowed as name of a lexical declaration"},
+
+		// decorators, private brand checks, import attributes, and using declarations
+		{"@", "expected Identifier or ( instead of EOF in decorator"},
+		{"@a function f() {}", "expected class instead of function in decorator"},
+		{"@1 class A {}", "expected Identifier or ( instead of 1 in decorator"},
+		{"class A { @a static {} }", "decorator not allowed on class static block"},
+		{"class A { accessor m() {} }", "accessor not allowed on method definition"},
+		{"class A { static { return } }", "return statement not allowed in class static block"},
+		{"function f() { class A { static { if (a) return } } }", "return statement not allowed in class static block"},
+		{"class A { static { arguments } }", "arguments not allowed in class static block"},
+		{"class A { static { () => arguments } }", "arguments not allowed in class static block"},
+		{"class A { a = () => arguments }", "arguments not allowed in class field"},
+		{"class A { static { var await } }", "await not allowed as identifier in class static block"},
+		{"class A { static { () => await } }", "await not allowed as identifier in class static block"},
+		{"@a export var b", "decorators before export require an undecorated class declaration"},
+		{"@a export @b class A {}", "decorators before export require an undecorated class declaration"},
+		{"a + #b in c", "unexpected #b in expression"},
+		{"class A { #a; m() { #a < b } }", "expected in instead of < in private brand check"},
+		{"import a from 'b' with { c: d }", "expected String instead of d in import statement"},
+		{"import a from 'b' with { 'c': 'd', c: 'e' }", "duplicate import attribute c"},
+		{"{ using a }", "expected = instead of } in using declaration"},
+		{"using a = b", "using declaration not allowed at the top level outside of a module"},
+		{"a: { b: using c = d }", "using declaration not allowed in labelled statement"},
+		{"x = #x in o", "private name #x not allowed outside of a class body"},
+		{"class A { m(o) { return #y in o } }", "private name #y is not declared in an enclosing class"},
+		{"class A extends (class { m(o) { return #y in o } }) { #y }", "private name #y is not declared in an enclosing class"},
+		{"class A { m(o) { return o.#y } }", "private name #y is not declared in an enclosing class"},
+		{"class A { m(o) { return o?.#y } }", "private name #y is not declared in an enclosing class"},
+		{"class A { #a; get #a() {} }", "private name #a has already been declared"},
+		{"class A { get #a() {} static set #a(b) {} }", "private name #a has already been declared"},
+		{"class A { constructor() {} 'constructor'() {} }", "duplicate constructor in class body"},
+		{"x = new.target", "new.target not allowed outside of a function"},
+		{"class A { constructor() { super() } }", "super call not allowed outside of a derived class constructor"},
+		{"class A extends B { m() { super() } }", "super call not allowed outside of a derived class constructor"},
+		{"function f() { super.a }", "super property not allowed outside of a method"},
+		{"export {a}; export {b as a}", "duplicate export a"},
+		{"export default a; export default b", "duplicate export default"},
+		{"import {a, a} from 'b'", "identifier a has already been declared"},
+		{"for (using a in b) ;", "expected of instead of in in for statement"},
+		{"switch (a) { case 1: using b = c }", "using declaration not allowed in switch case"},
+
 		// other
 		{"\x00", "unexpected 0x00"},
-		{"@", "unexpected @"},
 		{"\u200F", "unexpected U+200F"},
 		{"\u2010", "unexpected \u2010"},
 		{"a=\u2010", "unexpected \u2010 in expression"},
 		{"/", "unexpected EOF or newline in regular expression"},
+		{"x = /(a/", "unterminated group in regular expression"},
+		{"var \\u{1F600}", "unexpected \\ in binding"},
 		{"({...[]})=>a", "unexpected => in expression"}, // go-fuzz
 	}
 	for _, tt := range tests {
@@ -697,6 +836,372 @@ func TestParseError(t *testing.T) {
 	}
 }
 
+func TestParseJSX(t *testing.T) {
+	var tests = []struct {
+		js       string
+		expected string
+	}{
+		{"<div/>", "Stmt(JSX(<div />))"},
+		{"<div></div>", "Stmt(JSX(<div></div>))"},
+		{"<></>", "Stmt(JSX(<></>))"},
+		{"<a b c='d' e=\"f\\\ng\" />", "Stmt(JSX(<a b c='d' e=\"f\\\ng\" />))"},
+		{"<a b={c+d} {...e} f=<g/>/>", "Stmt(JSX(<a b={(c+d)} {...e} f=JSX(<g />) />))"},
+		{"<a data-b-c aria-d:e-f xlink:href />", "Stmt(JSX(<a data-b-c aria-d:e-f xlink:href />))"},
+		{"<svg:rect/>", "Stmt(JSX(<svg:rect />))"},
+		{"<custom-element></custom-element>", "Stmt(JSX(<custom-element></custom-element>))"},
+		{"<A.b.c></A.b.c>", "Stmt(JSX(<((A.b).c)></((A.b).c)>))"},
+		{"<this.a/>", "Stmt(JSX(<(this.a) />))"},
+		{"<a>b {c} &amp; d</a>", "Stmt(JSX(<a>Text(b ){c}Text( &amp; d)</a>))"},
+		{"<a>{}{/* b */}{...c}</a>", "Stmt(JSX(<a>{}{}{...c}</a>))"},
+		{"<a><b>c</b><></></a>", "Stmt(JSX(<a>JSX(<b>Text(c)</b>)JSX(<></>)</a>))"},
+		{"<a>{<b/>}</a>", "Stmt(JSX(<a>{JSX(<b />)}</a>))"},
+		{"x = <a/> >= <b></b>>c", "Stmt(x=((JSX(<a />)>=JSX(<b></b>))>c))"},
+		{"f(<a/>, <b/>)", "Stmt(f(JSX(<a />), JSX(<b />)))"},
+		{"a < b > c", "Stmt((a<b)>c)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.js, func(t *testing.T) {
+			ast, err := ParseWithOptions(parse.NewInputString(tt.js), ParseOptions{JSX: true})
+			test.Error(t, err)
+			test.String(t, ast.String(), tt.expected)
+		})
+	}
+
+	// errors
+	var errorTests = []struct {
+		js  string
+		err string
+	}{
+		{"<div/>", "unexpected < in expression"},
+		{"<a>", "unexpected EOF in JSX text"},
+		{"<a>b > c</a>", "unexpected > in JSX text"},
+		{"<a>}</a>", "unexpected } in JSX text"},
+		{"<a></b>", "expected closing tag </a> in JSX element"},
+		{"<></a>", "expected closing tag </> in JSX element"},
+		{"<a.b></a>", "expected closing tag </a.b> in JSX element"},
+		{"<a b=/>", "expected String, {, or < instead of / in JSX attribute"},
+		{"<a b={}/>", "expected String, {, or < instead of } in JSX attribute"},
+		{"<a {b}/>", "expected ... instead of b in JSX spread attribute"},
+		{"<a b='c/>", "unexpected EOF in JSX string"},
+		{"<a b=c/>", "expected String, {, or < instead of c in JSX attribute"},
+		{"<a.1/>", "expected Identifier instead of .1 in JSX attribute name"},
+		{"<a>{b c}</a>", "expected } instead of c in JSX expression container"},
+	}
+	for _, tt := range errorTests {
+		t.Run(tt.js, func(t *testing.T) {
+			o := ParseOptions{JSX: true}
+			if tt.js == "<div/>" {
+				o.JSX = false
+			}
+			_, err := ParseWithOptions(parse.NewInputString(tt.js), o)
+			test.That(t, err != io.EOF && err != nil)
+
+			e := err.Error()
+			if len(tt.err) < len(err.Error()) {
+				e = e[:len(tt.err)]
+			}
+			test.String(t, e, tt.err)
+		})
+	}
+
+	// components are variable references
+	ast, err := ParseWithOptions(parse.NewInputString("let A; <A><b.c/><D.e/></A>"), ParseOptions{JSX: true})
+	test.Error(t, err)
+	names := []string{}
+	for _, v := range append(ast.BlockStmt.Scope.Declared, ast.BlockStmt.Scope.Undeclared...) {
+		names = append(names, string(v.Data))
+	}
+	test.T(t, strings.Join(names, ","), "A,b,D")
+}
+
+func TestParseTS(t *testing.T) {
+	var tests = []struct {
+		ts string
+		js string
+	}{
+		{"let x: number = 5, y!: string", "let x = 5, y"},
+		{"function f<T extends U = V>(a: T, b?: string, ...c: number[]): Promise<T[]> { return a as any }", "function f(a, b, ...c) { return a }"},
+		{"f = <T,>(x: T): T => x!", "f = (x) => x"},
+		{"f = async <T>(x: T) => x", "f = async (x) => x"},
+		{"a = (x?: number, {y}: {y: string}): x is number => true", "a = (x, {y}) => true"},
+		{"a = ({y}: {y?: string} = {}) => y", "a = ({y} = {}) => y"},
+		{"a = b ? (c) : d", "a = b ? (c) : d"},
+		{"a = b ? (c): d => e : f", "a = b ? (c) => e : f"},
+		{"f<string>(x); new C<T>(); g<T>`t`; h<A<B>>(1)", "f(x); new C(); g`t`; h(1)"},
+		{"i < j > k", "i < j > k"},
+		{"if (a < b && c > d) {}", "if (a < b && c > d) {}"},
+		{"x = y satisfies Z; w = <any>v; u = t as const", "x = y; w = v; u = t"},
+		{"x!.y; x![0]; f!(); a?.b!.c", "x.y; x[0]; f(); a?.b.c"},
+		{"f = (): {a: number} => ({a: 1})", "f = () => ({a: 1})"},
+		{"g = function (this: Window, a: string): asserts a is string {}", "g = function (a) {}"},
+		{"let k: keyof typeof x, u: unique symbol, c: abstract new (...a: any[]) => object, r: readonly string[]", "let k, u, c, r"},
+		{"let f: (a: number) => void = (a) => {}, t: [a: string, b?: number], l: `a${B}c`, m: {[K in keyof T]?: T[K]}", "let f = (a) => {}, t, l, m"},
+		{"let a /* b */: /* c */ number = 1", "let a = 1"},
+		{"for (const x of y as Z[]) {} try {} catch (e: unknown) {}", "for (const x of y) {} try {} catch (e) {}"},
+		{"interface A<T> extends B, C<D> { a: string; b(): void }\ntype T<X> = X extends infer U ? U[] : never\nlet q", "let q"},
+		{"declare const x: number; declare function f(): void; declare module 'm' { export const y: string } declare global { interface W {} } declare namespace N {} declare enum E {A}", ""},
+		{"let type = 1, declare = 2, abstract = 3; type = 4", "let type = 1, declare = 2, abstract = 3; type = 4"},
+		{"function f(a: string): void;\nfunction f(a: any) {}", "function f(a) {}"},
+		{"x = {m<T>(a: T): T { return a }}", "x = {m(a) { return a }}"},
+		{"x = class<T> implements I { get a(): number { return 1 } set a(v: number) {} }", "x = class { get a() { return 1 } set a(v) {} }"},
+		{"abstract class A<T> extends B<T> implements I, J<K> { private readonly x: number = 1; y?: string; declare z: number; abstract m(): void; [key: string]: any; n(): void; n(x?: any) {} public static async *o<T>() {} readonly }", "class A extends B { x = 1; y; n(x) {} static async *o() {} readonly }"},
+		{"class A extends B { constructor(public a: number, private b = 2, c?: string) { super() } }", "class A extends B { constructor(a, b = 2, c) { super(); this.a = a; this.b = b } }"},
+		{"class A { constructor(readonly a) { 'use strict' } }", "class A { constructor(a) { 'use strict'; this.a = a } }"},
+		{"enum E { A, B = 5, C, D = 'd', F = B << 1, G }", "var E = (function (E) { E[E[\"A\"] = 0] = \"A\"; E[E[\"B\"] = 5] = \"B\"; E[E[\"C\"] = 6] = \"C\"; E[\"D\"] = 'd'; E[E[\"F\"] = E.B << 1] = \"F\"; E[E[\"G\"] = E[\"F\"] + 1] = \"G\"; return E })(E || {})"},
+		{"const enum E { A = -1, B }", "var E = (function (E) { E[E[\"A\"] = -1] = \"A\"; E[E[\"B\"] = 0] = \"B\"; return E })(E || {})"},
+		{"import type {A} from 'a'; import {type B, C} from 'b'; import {type D} from 'd'; import type E from 'e'", "import {C} from 'b'"},
+		{"export type {E}; export type F = G; export interface H {} export declare const k: number; export default interface M {}", ""},
+		{"export enum I {J} export abstract class L {} export function f(): void; export function f() {}", "export var I = (function (I) { I[I[\"J\"] = 0] = \"J\"; return I })(I || {}); export class L {} export function f() {}"},
+		{"class A { m?(): void; n?<T>(a: T) {} get?(): number; o?: string }", "class A { n(a) {} o }"},
+		{"import x = require('y'); import z = N.M.z", "const x = require('y'); const z = N.M.z"},
+		{"export = x", "module.exports = x"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ts, func(t *testing.T) {
+			ast, err := ParseWithOptions(parse.NewInputString(tt.ts), ParseOptions{TS: true})
+			test.Error(t, err)
+			expected, err := Parse(parse.NewInputString(tt.js))
+			test.Error(t, err)
+			test.String(t, ast.String(), expected.String())
+		})
+	}
+
+	// TSX
+	ast, err := ParseWithOptions(parse.NewInputString("f = <T,>(a: T) => <div>{a}</div>"), ParseOptions{TS: true, JSX: true})
+	test.Error(t, err)
+	test.String(t, ast.String(), "Stmt(f=(Params(Binding(a)) => Stmt({ Stmt(return JSX(<div>{a}</div>)) })))")
+
+	// errors
+	var errorTests = []struct {
+		ts  string
+		err string
+	}{
+		{"namespace N {}", "TypeScript namespaces are not supported"},
+		{"(a: number)", "expected => instead of EOF in arrow function"},
+		{"enum E { A = 'a', B }", "enum member B must have an initializer"},
+		{"let x: = 5", "unexpected = in type"},
+		{"import x = y + z", "unexpected + in import statement"},
+	}
+	for _, tt := range errorTests {
+		t.Run(tt.ts, func(t *testing.T) {
+			_, err := ParseWithOptions(parse.NewInputString(tt.ts), ParseOptions{TS: true})
+			test.That(t, err != io.EOF && err != nil)
+
+			e := err.Error()
+			if len(tt.err) < len(err.Error()) {
+				e = e[:len(tt.err)]
+			}
+			test.String(t, e, tt.err)
+		})
+	}
+
+	// types do not declare or use variables, enums and parameter properties do
+	ast, err = ParseWithOptions(parse.NewInputString("let a: T<U> = b as V; enum E {X = Y} class C { constructor(public p: P) {} }"), ParseOptions{TS: true})
+	test.Error(t, err)
+	names := []string{}
+	for _, v := range append(ast.BlockStmt.Scope.Declared, ast.BlockStmt.Scope.Undeclared...) {
+		names = append(names, string(v.Data))
+	}
+	test.T(t, strings.Join(names, ","), "a,E,C,b,Y")
+}
+
+func TestParseRecover(t *testing.T) {
+	var tests = []struct {
+		js       string
+		expected string
+		errs     string
+	}{
+		{"a = 1", "Stmt(a=1)", ""},
+		{"let x = ;\nf()", "Stmt(f())", "1:9 unexpected ; in expression"},
+		{"a b\nc", "Stmt(c)", "1:3 unexpected b in expression"},
+		{"x = {a: 1 +}; y", "Stmt(y)", "1:12 unexpected } in expression"},
+		{"}\na()", "Stmt(a())", "1:1 unexpected } in expression"},
+		{"a = 1;\n § \nb = 2", "Stmt(a=1) Stmt(b=2)", "2:2 unexpected §"},
+		{"a = ;\nb = ;\nc", "Stmt(c)", "1:5 unexpected ; in expression, 2:5 unexpected ; in expression"},
+		{"function f() {\n  let = 5 +;\n  return 1\n}\ng()", "Decl(function f Params() Stmt({ Stmt(return 1) })) Stmt(g())", "2:12 unexpected ; in expression"},
+		{"function f() { x = {a: 1 +}; y }\nz", "Decl(function f Params() Stmt({ Stmt(y) })) Stmt(z)", "1:27 unexpected } in expression"},
+		{"function f() {\n  g(\n  h()\n}\nw()", "Decl(function f Params() Stmt({ })) Stmt(w())", "4:1 unexpected } in expression"},
+		{"if (x {\n  y()\n}\nz()", "Stmt(z())", "1:7 expected ) instead of { in if statement"},
+		{"x = (\nif (a) b", "Stmt(if a Stmt(b))", "2:1 unexpected if in expression"},
+		{"x = `a${ b c }d`; y", "Stmt(y)", "1:12 expected Template instead of c in template literal"},
+		{"switch (x) { case 1: y z; case 2: w }\nv", "Stmt(switch x Clause(case 1) Clause(case 2 Stmt(w))) Stmt(v)", "1:24 unexpected z in expression"},
+		{"class A { m() { a b } n() {} }", "Decl(class A Method(m Params() Stmt({ })) Method(n Params() Stmt({ })))", "1:19 unexpected b in expression"},
+		{"class A { a = ; m() {} % } foo()", "Decl(class A Method(m Params() Stmt({ }))) Stmt(foo())", "1:15 unexpected ; in expression, 1:24 expected Identifier, String, Numeric, or [ instead of % in method definition"},
+		{"var a; class A { b c ! } foo()", "Decl(var Binding(a)) Decl(class A Definition(b) Definition(c)) Stmt(foo())", "1:22 expected Identifier, String, Numeric, or [ instead of ! in method definition"},
+		{"class A { @ } foo()", "Decl(class A) Stmt(foo())", "1:13 expected Identifier or ( instead of } in decorator"},
+		{"function f() {", "", "1:15 unexpected EOF"},
+		{"(a, a) => b\nc", "Stmt(c)", "1:5 duplicate parameter name a"},
+		{"function f() { 'use strict'; with (a) b; delete c }", "Decl(function f Params() Stmt({ Stmt('use strict') }))", "1:30 with statement not allowed in strict mode, 1:42 delete of identifier c not allowed in strict mode"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.js, func(t *testing.T) {
+			ast, err := ParseWithOptions(parse.NewInputString(tt.js), ParseOptions{Recover: true})
+			test.String(t, ast.String(), tt.expected)
+
+			errs := []string{}
+			for _, e := range ast.Errors {
+				errs = append(errs, fmt.Sprintf("%d:%d %s", e.Line, e.Column, e.Message))
+			}
+			test.String(t, strings.Join(errs, ", "), tt.errs)
+			if len(ast.Errors) == 0 {
+				test.Error(t, err)
+			} else {
+				test.T(t, err, error(ast.Errors[0]))
+			}
+		})
+	}
+
+	// offsets
+	ast, _ := ParseWithOptions(parse.NewInputString("a = ;\nb = ;"), ParseOptions{Recover: true})
+	test.T(t, len(ast.Errors), 2)
+	test.T(t, ast.Errors[0].Offset, 4)
+	test.T(t, ast.Errors[1].Offset, 10)
+
+	// errors while backtracking over TypeScript types are not reported
+	ast, _ = ParseWithOptions(parse.NewInputString("x = f<T>(a) + g<U; let y: = 1; z"), ParseOptions{TS: true, Recover: true})
+	test.String(t, ast.String(), "Stmt(x=(((f(a))+g)<U)) Stmt(z)")
+	test.T(t, len(ast.Errors), 1)
+
+	// comments in skipped statements are dropped
+	ast, _ = ParseWithOptions(parse.NewInputString("a /* b */ c; /* d */ e"), ParseOptions{Recover: true})
+	test.T(t, len(ast.CommentMap), 1)
+	test.String(t, string(ast.CommentMap[ast.List[0]].Leading[0].Data), "/* d */")
+
+	// comments are kept for errors found at the end of the module
+	ast, _ = ParseWithOptions(parse.NewInputString("'\\01'; 'use strict'; /* a */ b"), ParseOptions{Recover: true})
+	test.T(t, len(ast.Errors), 1)
+	test.T(t, len(ast.CommentMap), 1)
+	test.String(t, string(ast.CommentMap[ast.List[2]].Leading[0].Data), "/* a */")
+}
+
+func TestParseOptions(t *testing.T) {
+	var tests = []struct {
+		js       string
+		o        ParseOptions
+		expected string
+		err      string
+	}{
+		// source type
+		{"await = 1; var await", ParseOptions{SourceType: ScriptSource}, "Stmt(await=1) Decl(var Binding(await))", ""},
+		{"import('a'); x = import.meta.url", ParseOptions{}, "Stmt(import('a')) Stmt(x=((import.meta).url))", ""},
+		{"import a from 'b'", ParseOptions{SourceType: ScriptSource}, "", "import declaration not allowed in script"},
+		{"a\nexport {b}", ParseOptions{SourceType: ScriptSource}, "", "export declaration not allowed in script"},
+		{"x = import.meta", ParseOptions{SourceType: ScriptSource}, "", "import.meta not allowed in script"},
+		{"import('a')", ParseOptions{SourceType: ScriptSource}, "Stmt(import('a'))", ""},
+		{"await x", ParseOptions{SourceType: ModuleSource}, "Stmt(await x)", ""},
+		{"with (a) b", ParseOptions{SourceType: ModuleSource}, "", "with statement not allowed in strict mode"},
+		{"a <!-- b", ParseOptions{SourceType: ModuleSource}, "Stmt(a<(!(--b)))", ""},
+		{"using a = b", ParseOptions{SourceType: ScriptSource}, "", "using declaration not allowed at the top level outside of a module"},
+		{"using a = b", ParseOptions{SourceType: ModuleSource}, "Decl(using Binding(a = b))", ""},
+		{"{ using a = b }", ParseOptions{SourceType: ScriptSource}, "Stmt({ Decl(using Binding(a = b)) })", ""},
+
+		// version
+		{"a = {get b() {}, c: 0x1}; d = function () {}", ParseOptions{Version: 5}, "Stmt(a={Method(get b Params() Stmt({ })), c: 0x1}) Stmt(d=Decl(function Params() Stmt({ })))", ""},
+		{"let a", ParseOptions{Version: 5}, "", "let declaration requires ECMAScript 2015"},
+		{"const a = 1", ParseOptions{Version: 5}, "", "const declaration requires ECMAScript 2015"},
+		{"a = () => 1", ParseOptions{Version: 5}, "", "arrow function requires ECMAScript 2015"},
+		{"class A {}", ParseOptions{Version: 5}, "", "class requires ECMAScript 2015"},
+		{"a = `b`", ParseOptions{Version: 5}, "", "template literal requires ECMAScript 2015"},
+		{"var [a] = b", ParseOptions{Version: 5}, "", "destructuring requires ECMAScript 2015"},
+		{"[a, b] = c", ParseOptions{Version: 5}, "", "destructuring assignment requires ECMAScript 2015"},
+		{"function f(a = 1) {}", ParseOptions{Version: 5}, "", "default parameter requires ECMAScript 2015"},
+		{"function f(...a) {}", ParseOptions{Version: 5}, "", "rest parameter requires ECMAScript 2015"},
+		{"f(...a)", ParseOptions{Version: 5}, "", "spread argument requires ECMAScript 2015"},
+		{"a = [...b]", ParseOptions{Version: 5}, "", "spread element requires ECMAScript 2015"},
+		{"for (a of b) ;", ParseOptions{Version: 5}, "", "for-of statement requires ECMAScript 2015"},
+		{"function* f() {}", ParseOptions{Version: 5}, "", "generator requires ECMAScript 2015"},
+		{"a = {[b]: c}", ParseOptions{Version: 5}, "", "computed property name requires ECMAScript 2015"},
+		{"a = {b}", ParseOptions{Version: 5}, "", "shorthand property requires ECMAScript 2015"},
+		{"a = {b() {}}", ParseOptions{Version: 5}, "", "method definition requires ECMAScript 2015"},
+		{"a = 0b1", ParseOptions{Version: 5}, "", "binary and octal literals requires ECMAScript 2015"},
+		{"function f() { new.target }", ParseOptions{Version: 5}, "", "new.target requires ECMAScript 2015"},
+		{"import a from 'b'", ParseOptions{Version: 5}, "", "import declaration requires ECMAScript 2015"},
+		{"a = b ** c", ParseOptions{Version: 2015}, "", "exponentiation operator requires ECMAScript 2016"},
+		{"a **= b", ParseOptions{Version: 2015}, "", "exponentiation operator requires ECMAScript 2016"},
+		{"async function f() {}", ParseOptions{Version: 2016}, "", "async function requires ECMAScript 2017"},
+		{"a = async () => b", ParseOptions{Version: 2016}, "", "async arrow function requires ECMAScript 2017"},
+		{"a = {...b}", ParseOptions{Version: 2017}, "", "object spread requires ECMAScript 2018"},
+		{"var {...a} = b", ParseOptions{Version: 2017}, "", "object rest requires ECMAScript 2018"},
+		{"async function* f() {}", ParseOptions{Version: 2017}, "", "async generator requires ECMAScript 2018"},
+		{"async function f() { for await (a of b) ; }", ParseOptions{Version: 2017}, "", "for await requires ECMAScript 2018"},
+		{"try {} catch {}", ParseOptions{Version: 2018}, "", "optional catch binding requires ECMAScript 2019"},
+		{"a = b?.c", ParseOptions{Version: 2019}, "", "optional chaining requires ECMAScript 2020"},
+		{"a = b ?? c", ParseOptions{Version: 2019}, "", "nullish coalescing requires ECMAScript 2020"},
+		{"a = 1n", ParseOptions{Version: 2019}, "", "BigInt literal requires ECMAScript 2020"},
+		{"import('a')", ParseOptions{Version: 2019}, "", "import call requires ECMAScript 2020"},
+		{"export * as a from 'b'", ParseOptions{Version: 2019}, "", "export * as requires ECMAScript 2020"},
+		{"a ||= b", ParseOptions{Version: 2020}, "", "logical assignment requires ECMAScript 2021"},
+		{"class A { a = 1 }", ParseOptions{Version: 2021}, "", "class field requires ECMAScript 2022"},
+		{"await a", ParseOptions{SourceType: ModuleSource, Version: 2021}, "", "top-level await requires ECMAScript 2022"},
+		{"await a", ParseOptions{Version: 2021}, "", "top-level await requires ECMAScript 2022"},
+		{"await = 1; var await", ParseOptions{Version: 5}, "Stmt(await=1) Decl(var Binding(await))", ""},
+		{"await = 1; async function f() { await a }", ParseOptions{Version: 2021}, "Stmt(await=1) Decl(async function f Params() Stmt({ Stmt(await a) }))", ""},
+		{"class A { static {} }", ParseOptions{Version: 2021}, "", "class static block requires ECMAScript 2022"},
+		{"a = #b in c", ParseOptions{Version: 2021}, "", "private brand check requires ECMAScript 2022"},
+		{"a = /b/y", ParseOptions{Version: 5}, "", "regular expression flag y requires ECMAScript 2015"},
+		{"a = /b/s", ParseOptions{Version: 2017}, "", "regular expression flag s requires ECMAScript 2018"},
+		{"a = /b/d", ParseOptions{Version: 2021}, "", "regular expression flag d requires ECMAScript 2022"},
+		{"a = /[b]/v", ParseOptions{Version: 2023}, "", "regular expression flag v requires ECMAScript 2024"},
+		{"a = /(?<b>c)\\k<b>/", ParseOptions{Version: 2017}, "", "named capture group requires ECMAScript 2018"},
+		{"a = /(?<=b)c/", ParseOptions{Version: 2017}, "", "lookbehind assertion requires ECMAScript 2018"},
+		{"a = /[\\p{L}]/u", ParseOptions{Version: 2017}, "", "unicode property escape requires ECMAScript 2018"},
+		{"a = /(?i:b)/", ParseOptions{Version: 2024}, "", "regular expression modifiers requires ECMAScript 2025"},
+		{"a = /(?<b>c)|(?<=d)\\p{L}/su", ParseOptions{Version: 2018}, "Stmt(a=/(?<b>c)|(?<=d)\\p{L}/su)", ""},
+		{"import a from 'b' with { type: 'json' }", ParseOptions{Version: 2024}, "", "import attributes requires ECMAScript 2025"},
+		{"@a class A {}", ParseOptions{Version: 2025}, "", "decorator is not part of ECMAScript 2025"},
+		{"class A { accessor a }", ParseOptions{Version: 2025}, "", "auto-accessor is not part of ECMAScript 2025"},
+		{"{ using a = b }", ParseOptions{Version: 2025}, "", "using declaration is not part of ECMAScript 2025"},
+
+		// Annex B
+		{"if (a) function f() {} else function g() {}", ParseOptions{}, "Stmt(if a Stmt({ Decl(function f Params() Stmt({ })) }) else Stmt({ Decl(function g Params() Stmt({ })) }))", ""},
+		{"for (var a = b in c) ;", ParseOptions{}, "Stmt(for Decl(var Binding(a = b)) in c Stmt({ }))", ""},
+		{"a: function f() {}", ParseOptions{}, "Stmt(a : Decl(function f Params() Stmt({ })))", ""},
+		{"a <!-- b", ParseOptions{NoAnnexB: HTMLComments}, "Stmt(a<(!(--b)))", ""},
+		{"if (a) function f() {}", ParseOptions{NoAnnexB: IfFunctions}, "", "unexpected function in statement"},
+		{"for (var a = b in c) ;", ParseOptions{NoAnnexB: ForInInitializers}, "", "unexpected in in for statement"},
+		{"'use strict'; for (var a = b in c) ;", ParseOptions{}, "", "unexpected in in for statement"},
+		{"a: function f() {}", ParseOptions{NoAnnexB: LabelledFunctions}, "", "labelled function declaration not allowed"},
+		{"x = /{/", ParseOptions{}, "Stmt(x=/{/)", ""},
+		{"x = /{/", ParseOptions{NoAnnexB: RegExps}, "", "lone quantifier bracket in regular expression"},
+		{"x = /]/", ParseOptions{NoAnnexB: RegExps}, "", "lone quantifier bracket in regular expression"},
+		{"x = /(?=a)*/", ParseOptions{NoAnnexB: RegExps}, "", "nothing to repeat in regular expression"},
+		{"'use strict'; a: function f() {}", ParseOptions{}, "", "labelled function declaration not allowed in strict mode"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.js, func(t *testing.T) {
+			ast, err := ParseWithOptions(parse.NewInputString(tt.js), tt.o)
+			if tt.err == "" {
+				test.Error(t, err)
+				test.String(t, ast.String(), tt.expected)
+			} else {
+				test.That(t, err != nil)
+				if err != nil {
+					test.String(t, err.(*parse.Error).Message, tt.err)
+				}
+			}
+		})
+	}
+}
+
+func TestJSXText(t *testing.T) {
+	var tests = []struct {
+		text     string
+		expected string
+	}{
+		{"a", "a"},
+		{"  a  b  ", "  a  b  "},
+		{"\n  a\n  b  \n  ", "a b"},
+		{"a \r\n\t\n b", "a b"},
+		{"\n \n", ""},
+		{"&amp;&lt;&#65;&#x42;&#X43;&unknown;&amp &#;&#xZ;&euro;&nbsp;", "&<AB&#X43;&unknown;&amp &#;&#xZ;€\u00a0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			test.String(t, string(JSXText{Data: []byte(tt.text)}.Value()), tt.expected)
+		})
+	}
+}
+
 type ScopeVars struct {
 	bound, uses string
 	scopes      int
@@ -899,6 +1404,7 @@ func TestParseScope(t *testing.T) {
 		bound, uses string
 	}{
 		{"a; a", "", "a=1"},
+		{"var \\u0061; a; \\u{61}", "a=1", ""},
 		{"a;{a;{a}}", "//", "a=1/a=1/a=1*"},
 		{"var a; b", "a=1", "b=2"},
 		{"var {a:b, c=d, ...e} = z;", "b=1,c=2,e=3", "d=4,z=5"},
@@ -932,7 +1438,7 @@ func TestParseScope(t *testing.T) {
 		{"(a,b=a) => {}", "/a=1,b=2", "/"},
 		{"(a=b,b)=>{}", "/a=2,b=3", "b=1/b=1"},
 		{"a=>{var a}", "/a=1", "/"},
-		{"(a,a)=>{}", "/a=1", "/"},
+		{"function f(a,a){}", "f=1/a=2", "/"},
 		{"(a=b) => {var b}", "/a=2,b=3", "b=1/b=1"},
 		{"({[a+b]:c}) => {}", "/c=3", "a=1,b=2/a=1,b=2"},
 		{"({a:b, c=d, ...e}=f) => 5", "/b=3,c=4,e=5", "d=1,f=2/d=1,f=2"},
@@ -1042,3 +1548,68 @@ func TestParseInputError(t *testing.T) {
 	_, err = Parse(parse.NewInput(test.NewErrorReader(1)))
 	test.T(t, err, test.ErrPlain)
 }
+
+func TestParseSpan(t *testing.T) {
+	var tests = []struct {
+		js   string
+		stmt string
+		expr string
+	}{
+		{"a + b * c;", "a + b * c;", "a + b * c"},
+		{"  (a+b) * c", "(a+b) * c", "(a+b) * c"},
+		{"x = f(1, 2)\ny", "x = f(1, 2)", "x = f(1, 2)"},
+		{"a.b[c]?.(d)", "a.b[c]?.(d)", "a.b[c]?.(d)"},
+		{"x => x*2", "x => x*2", "x => x*2"},
+		{"async (x, y) => { return x }", "async (x, y) => { return x }", "async (x, y) => { return x }"},
+		{"new Foo(bar);", "new Foo(bar);", "new Foo(bar)"},
+		{"tag`a${b}c`", "tag`a${b}c`", "tag`a${b}c`"},
+		{"c ? d : e", "c ? d : e", "c ? d : e"},
+		{"!function(){}()", "!function(){}()", "!function(){}()"},
+		{"x++ ;", "x++ ;", "x++"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.js, func(t *testing.T) {
+			ast, err := Parse(parse.NewInputString(tt.js))
+			test.Error(t, err)
+
+			stmt := ast.List[0]
+			start, end := stmt.Offsets()
+			test.String(t, tt.js[start:end], tt.stmt, "statement")
+
+			expr := stmt.(*ExprStmt).Value
+			start, end = expr.Offsets()
+			test.String(t, tt.js[start:end], tt.expr, "expression")
+		})
+	}
+
+	js := "var a = 5;\nfunction f(b) {\n\treturn a + b\n}"
+	ast, err := Parse(parse.NewInputString(js))
+	test.Error(t, err)
+
+	varDecl := ast.List[0].(*VarDecl)
+	start, end := varDecl.Offsets()
+	test.String(t, js[start:end], "var a = 5;")
+	start, end = varDecl.List[0].Offsets()
+	test.String(t, js[start:end], "a = 5")
+
+	funcDecl := ast.List[1].(*FuncDecl)
+	start, end = funcDecl.Offsets()
+	test.String(t, js[start:end], "function f(b) {\n\treturn a + b\n}")
+	start, end = funcDecl.Params.Offsets()
+	test.String(t, js[start:end], "(b)")
+
+	ret := funcDecl.Body.List[0].(*ReturnStmt)
+	start, end = ret.Offsets()
+	test.String(t, js[start:end], "return a + b")
+
+	line, col, _ := NodePosition(parse.NewInputString(js), ret.Value)
+	test.T(t, line, 3, "line")
+	test.T(t, col, 9, "column")
+
+	// later occurrences of a variable share the span of the first occurrence
+	js = "f(); f(x) + 1"
+	ast, err = Parse(parse.NewInputString(js))
+	test.Error(t, err)
+	start, end = ast.List[1].(*ExprStmt).Value.Offsets()
+	test.String(t, js[start:end], "f(x) + 1")
+}
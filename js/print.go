@@ -0,0 +1,1591 @@
+package js
+
+import (
+	"bytes"
+	"io"
+	"unicode/utf8"
+
+	"github.com/tdewolff/parse/v2/sourcemap"
+)
+
+// QuoteStyle is the style of quotes for string literals.
+type QuoteStyle int
+
+// QuoteStyle values.
+const (
+	KeepQuotes   QuoteStyle = iota // keep the quotes of the source
+	DoubleQuotes                   // use double quotes, unless single quotes require fewer escapes
+	SingleQuotes                   // use single quotes, unless double quotes require fewer escapes
+)
+
+// tabWidth is the width of a tab when measuring lines for PrintOptions.MaxWidth.
+const tabWidth = 4
+
+// PrintOptions are the options for Print.
+type PrintOptions struct {
+	Compact bool // omit all optional whitespace and semicolons
+
+	// Formatting options, which are ignored in compact mode except for Quotes.
+	Indent         string     // indentation per level, which is a tab by default
+	Quotes         QuoteStyle // quotes of string literals, module specifiers, and directives
+	OmitSemicolons bool       // omit semicolons at the end of statements, except where the next statement would otherwise continue the statement, in which case it starts with a semicolon
+	TrailingCommas bool       // add a trailing comma to argument lists and to array and object literals that are broken over multiple lines
+	MaxWidth       int        // maximum line width, beyond which argument lists and array and object literals are broken over multiple lines with one item per line, where a tab counts as four columns; zero means no limit
+
+	// SourceMap receives mappings from the start of statements, expressions, and identifiers in the output to their offsets in the source at index Source, which must be the parse.Input of the AST. Renamed identifiers are mapped to their original name. Generated offsets are relative to the start of the output.
+	SourceMap *sourcemap.Generator
+	Source    int
+}
+
+// Print writes the node as JavaScript to w, inserting parentheses only where required by operator precedence. Comments of an AST are printed as well, or only the important comments in compact mode.
+func Print(w io.Writer, n INode, o PrintOptions) error {
+	p := &printer{PrintOptions: o, mapping: -1}
+	if p.Indent == "" {
+		p.Indent = "\t"
+	}
+	switch n := n.(type) {
+	case *AST:
+		p.comments = n.CommentMap
+		for _, data := range n.Comments {
+			c := Comment{Data: data, OwnLine: true}
+			if p.Compact && !c.IsImportant() && !bytes.HasPrefix(data, []byte("#!")) {
+				continue
+			}
+			p.printComment(c)
+			p.newline()
+		}
+		p.printStmtList(n.List)
+	case IStmt:
+		p.printStmt(n)
+	case IBinding:
+		p.printBinding(n)
+	case IExpr:
+		p.printExpr(n, OpExpr)
+	default:
+		p.writeString(n.JS())
+	}
+	if p.SourceMap != nil {
+		src := p.SourceMap.SourceContent(p.Source)
+		for _, m := range p.mappings {
+			name := -1
+			if m.name != nil && m.end <= len(src) {
+				if orig := DecodeIdentifier(src[m.offset:m.end]); !bytes.Equal(orig, m.name) {
+					name = p.SourceMap.AddName(string(orig))
+				}
+			}
+			p.SourceMap.Add(m.gen, p.Source, m.offset, name)
+		}
+	}
+	_, err := w.Write(p.buf)
+	return err
+}
+
+type printer struct {
+	PrintOptions
+	buf          []byte
+	indent       int
+	semicolon    bool // pending semicolon, only used in compact mode or when omitting semicolons
+	semicolonPos int  // offset of the pending semicolon when omitting semicolons
+	noIn         bool // inside a for initializer, where the in operator must be parenthesized
+	flat         int  // inside a list that is tried on a single line, where nested lists are not broken
+
+	comments    CommentMap
+	done        map[*Comment]bool
+	doneList    []*Comment // printed comments in order, to undo printing
+	lineComment bool       // the last token was a single-line comment that must be followed by a line terminator
+	mapping     int        // pending source offset for the source map, added at the next token
+	mappingVar  *Var       // variable at the pending source offset, if any
+	mappings    []printMapping
+}
+
+type printMapping struct {
+	gen, offset, end int
+	name             []byte // printed name of a variable, or nil
+}
+
+// printerState is the state of the printer to undo printing.
+type printerState struct {
+	buf, mappings, done int
+	semicolon           bool
+	semicolonPos        int
+	lineComment         bool
+	mapping             int
+	mappingVar          *Var
+}
+
+func (p *printer) save() printerState {
+	return printerState{len(p.buf), len(p.mappings), len(p.doneList), p.semicolon, p.semicolonPos, p.lineComment, p.mapping, p.mappingVar}
+}
+
+func (p *printer) restore(state printerState) {
+	for _, c := range p.doneList[state.done:] {
+		delete(p.done, c)
+	}
+	p.buf = p.buf[:state.buf]
+	p.mappings = p.mappings[:state.mappings]
+	p.doneList = p.doneList[:state.done]
+	p.semicolon, p.semicolonPos = state.semicolon, state.semicolonPos
+	p.lineComment = state.lineComment
+	p.mapping, p.mappingVar = state.mapping, state.mappingVar
+}
+
+// write writes a token and separates it with a space from the previous token if required.
+func (p *printer) write(b []byte) {
+	if p.lineComment {
+		p.lineComment = false
+		p.writeNewline()
+	}
+	if p.semicolon {
+		p.flushSemicolon(b)
+	}
+	if 0 < len(p.buf) && 0 < len(b) {
+		last, first := p.buf[len(p.buf)-1], b[0]
+		if isIdentifierByte(last) && isIdentifierByte(first) ||
+			(last == '+' || last == '-') && first == last || // a+ +b, a- --b
+			last == '/' && (first == '/' || first == '*') || // a/ /b/
+			last == '<' && (first == '!' || first == '<') || last == '-' && first == '>' { // HTML comments <!-- and -->, and a< <b/>
+			p.buf = append(p.buf, ' ')
+		}
+	}
+	if p.mapping != -1 && 0 < len(b) {
+		mapping := printMapping{gen: len(p.buf), offset: p.mapping}
+		if p.mappingVar != nil {
+			mapping.end, mapping.name = p.mappingVar.End, p.mappingVar.Data
+		}
+		p.mappings = append(p.mappings, mapping)
+		p.mapping, p.mappingVar = -1, nil
+	}
+	p.buf = append(p.buf, b...)
+}
+
+// insert inserts b at the offset pos of the output.
+func (p *printer) insert(pos int, b []byte) {
+	p.buf = append(p.buf, b...)
+	copy(p.buf[pos+len(b):], p.buf[pos:])
+	copy(p.buf[pos:], b)
+	for i := len(p.mappings) - 1; 0 <= i && pos < p.mappings[i].gen; i-- {
+		p.mappings[i].gen += len(b)
+	}
+}
+
+// flushSemicolon writes the pending semicolon before the token b. When omitting semicolons, it is written only when b is on the same line or when b would otherwise continue the statement, and it stays pending for comments and for unknown tokens on the next line.
+func (p *printer) flushSemicolon(b []byte) {
+	if p.Compact {
+		p.buf = append(p.buf, ';')
+		p.semicolon = false
+	} else if bytes.HasPrefix(b, []byte("//")) || bytes.HasPrefix(b, []byte("/*")) {
+		return
+	} else if bytes.IndexByte(p.buf[p.semicolonPos:], '\n') == -1 {
+		p.insert(p.semicolonPos, []byte(";"))
+		p.semicolon = false
+	} else if 0 < len(b) {
+		if continuesStmt(b) {
+			p.buf = append(p.buf, ';')
+		}
+		p.semicolon = false
+	}
+}
+
+// continuesStmt returns true if a statement that starts with b would continue the previous statement if that is not terminated by a semicolon.
+func continuesStmt(b []byte) bool {
+	for {
+		b = bytes.TrimLeft(b, " \t\n")
+		if bytes.HasPrefix(b, []byte("//")) {
+			if i := bytes.IndexByte(b, '\n'); i != -1 {
+				b = b[i:]
+				continue
+			}
+			return false
+		} else if bytes.HasPrefix(b, []byte("/*")) {
+			if i := bytes.Index(b, []byte("*/")); i != -1 {
+				b = b[i+2:]
+				continue
+			}
+			return false
+		}
+		break
+	}
+	if len(b) == 0 {
+		return false
+	}
+	switch b[0] {
+	case '(', '[', '`', '+', '-', '/', '*', '<':
+		return true
+	}
+	return startsWithKeyword(b, "in") || startsWithKeyword(b, "instanceof")
+}
+
+// addMapping maps the next token to the start of the node in the source map.
+func (p *printer) addMapping(n INode) {
+	if p.SourceMap != nil && p.mapping == -1 {
+		if start, end := n.Offsets(); end != 0 {
+			p.mapping = start
+		}
+	}
+}
+
+// printVar writes the name of the variable and maps it to the variable in the source map, so that it can be mapped to its original name.
+func (p *printer) printVar(v *Var) {
+	if p.SourceMap != nil && v.End != 0 {
+		p.mapping, p.mappingVar = v.Start, v
+	}
+	p.write(v.Data)
+}
+
+func (p *printer) writeString(s string) {
+	p.write([]byte(s))
+}
+
+// space writes optional whitespace.
+func (p *printer) space() {
+	if !p.Compact && !p.lineComment {
+		p.buf = append(p.buf, ' ')
+	}
+}
+
+// newline writes optional whitespace at the start of a new line.
+func (p *printer) newline() {
+	if !p.Compact {
+		p.lineComment = false
+		p.writeNewline()
+	}
+}
+
+func (p *printer) writeNewline() {
+	p.buf = append(p.buf, '\n')
+	for i := 0; i < p.indent; i++ {
+		p.buf = append(p.buf, p.Indent...)
+	}
+}
+
+// endStmt terminates a statement with a semicolon. In compact mode the semicolon is omitted when it is followed by a closing brace or the end of the input, and when omitting semicolons also when it is followed by a new line (see flushSemicolon).
+func (p *printer) endStmt() {
+	if p.Compact || p.OmitSemicolons {
+		p.semicolon = true
+		p.semicolonPos = len(p.buf)
+	} else {
+		p.writeString(";")
+	}
+}
+
+// quote returns the string literal with the quotes of the quote style, where escape sequences are kept except for escaped quotes that are no longer required. Other bytes, such as identifiers, are returned unchanged.
+func (p *printer) quote(b []byte) []byte {
+	if p.Quotes == KeepQuotes || len(b) < 2 || b[0] != '"' && b[0] != '\'' {
+		return b
+	}
+	quote, other := byte('"'), byte('\'')
+	if p.Quotes == SingleQuotes {
+		quote, other = other, quote
+	}
+	s := b[1 : len(b)-1]
+	if bytes.Count(s, []byte{other}) < bytes.Count(s, []byte{quote}) {
+		quote, other = other, quote
+	}
+	if b[0] == quote && bytes.IndexByte(s, '\\') == -1 {
+		return b
+	}
+
+	q := make([]byte, 0, len(b)+2)
+	q = append(q, quote)
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			if s[i+1] != other {
+				q = append(q, '\\')
+			}
+			q = append(q, s[i+1])
+			i++
+		} else if s[i] == quote {
+			q = append(q, '\\', quote)
+		} else {
+			q = append(q, s[i])
+		}
+	}
+	return append(q, quote)
+}
+
+// fits returns true if the output since start has no line breaks before the offset hug and if its first and last line are not wider than MaxWidth.
+func (p *printer) fits(start, hug int) bool {
+	if bytes.IndexByte(p.buf[start:hug], '\n') != -1 {
+		return false
+	}
+	first := p.buf[bytes.LastIndexByte(p.buf[:start], '\n')+1:]
+	if i := bytes.IndexByte(first, '\n'); i != -1 {
+		first = first[:i]
+	}
+	last := p.buf[bytes.LastIndexByte(p.buf, '\n')+1:]
+	return lineWidth(first) <= p.MaxWidth && lineWidth(last) <= p.MaxWidth
+}
+
+func lineWidth(b []byte) int {
+	return utf8.RuneCount(b) + (tabWidth-1)*bytes.Count(b, []byte{'\t'})
+}
+
+func isIdentifierByte(c byte) bool {
+	return identifierTable[c] || 0x80 <= c || c == '\\'
+}
+
+// startsWithKeyword returns true if b starts with the keyword kw.
+func startsWithKeyword(b []byte, kw string) bool {
+	return bytes.HasPrefix(b, []byte(kw)) && (len(b) == len(kw) || !isIdentifierByte(b[len(kw)]))
+}
+
+// parenthesize wraps the output written since start in parentheses.
+func (p *printer) parenthesize(start int) {
+	if start < len(p.buf) && p.buf[start] == ' ' {
+		start++
+	}
+	p.insert(start, []byte("("))
+	p.writeString(")")
+}
+
+func (p *printer) printComment(c Comment) {
+	p.write(c.Data)
+	if c.IsLine() {
+		p.lineComment = true
+	}
+}
+
+// skipComment returns true if the comment was already printed, or if it should be omitted in compact mode.
+func (p *printer) skipComment(c *Comment) bool {
+	if p.Compact && !c.IsImportant() || p.done[c] {
+		return true
+	} else if p.done == nil {
+		p.done = map[*Comment]bool{}
+	}
+	p.done[c] = true
+	p.doneList = append(p.doneList, c)
+	return false
+}
+
+// printLeading prints the comments attached before the node.
+func (p *printer) printLeading(n INode) {
+	if c, ok := p.comments[n]; ok {
+		for i := range c.Leading {
+			if p.skipComment(&c.Leading[i]) {
+				continue
+			}
+			p.printComment(c.Leading[i])
+			if c.Leading[i].OwnLine {
+				p.newline()
+			} else {
+				p.space()
+			}
+		}
+	}
+}
+
+// printTrailing prints the comments attached after the node, and any inner comments that were not printed yet.
+func (p *printer) printTrailing(n INode) {
+	if c, ok := p.comments[n]; ok {
+		for _, list := range [][]Comment{c.Inner, c.Trailing} {
+			for i := range list {
+				if p.skipComment(&list[i]) {
+					continue
+				}
+				if list[i].OwnLine {
+					p.newline()
+				} else {
+					p.space()
+				}
+				p.printComment(list[i])
+			}
+		}
+	}
+}
+
+// printInner prints the inner comments of a block on separate lines and returns true if any were printed.
+func (p *printer) printInner(n INode) bool {
+	printed := false
+	if c, ok := p.comments[n]; ok {
+		for i := range c.Inner {
+			if p.skipComment(&c.Inner[i]) {
+				continue
+			}
+			p.newline()
+			p.printComment(c.Inner[i])
+			printed = true
+		}
+	}
+	return printed
+}
+
+////////////////////////////////////////////////////////////////
+
+func (p *printer) printStmtList(list []IStmt) {
+	for i, item := range list {
+		if i != 0 {
+			p.newline()
+		}
+		p.printStmt(item)
+	}
+	p.semicolon = false
+}
+
+func (p *printer) printBlock(n *BlockStmt) {
+	p.printLeading(n)
+	p.addMapping(n)
+	parentFlat := p.flat
+	p.flat = 0
+	p.writeString("{")
+	p.indent++
+	for _, item := range n.List {
+		p.newline()
+		p.printStmt(item)
+	}
+	inner := p.printInner(n)
+	p.indent--
+	if len(n.List) != 0 || inner {
+		p.newline()
+	}
+	p.semicolon = false
+	p.writeString("}")
+	p.flat = parentFlat
+	p.printTrailing(n)
+}
+
+// printBody prints the body of a compound statement.
+func (p *printer) printBody(n IStmt) {
+	p.space()
+	if block, ok := n.(*BlockStmt); ok {
+		p.printBlock(block)
+	} else {
+		p.printStmt(n)
+	}
+}
+
+func (p *printer) printStmt(stmt IStmt) {
+	if block, ok := stmt.(*BlockStmt); ok {
+		p.printBlock(block)
+		return
+	}
+
+	p.printLeading(stmt)
+	p.addMapping(stmt)
+	switch n := stmt.(type) {
+	case *AST:
+		p.printBlock(&n.BlockStmt)
+	case *EmptyStmt:
+		p.writeString(";")
+	case *ExprStmt:
+		p.printExprStmt(n.Value)
+		p.endStmt()
+	case *VarDecl:
+		p.printVarDecl(n)
+		p.endStmt()
+	case *FuncDecl:
+		p.printFunc(n)
+	case *ClassDecl:
+		p.printClass(n)
+	case *IfStmt:
+		p.writeString("if")
+		p.space()
+		p.writeString("(")
+		p.printExpr(n.Cond, OpExpr)
+		p.writeString(")")
+		if n.Else != nil && hasDanglingIf(n.Body) {
+			// prevent the else from binding to the inner if statement
+			p.space()
+			p.printBlock(&BlockStmt{List: []IStmt{n.Body}})
+		} else {
+			p.printBody(n.Body)
+		}
+		if n.Else != nil {
+			p.space()
+			p.writeString("else")
+			if _, ok := n.Else.(*IfStmt); ok {
+				p.writeString(" ")
+				p.printStmt(n.Else)
+			} else {
+				p.printBody(n.Else)
+			}
+		}
+	case *DoWhileStmt:
+		p.writeString("do")
+		p.printBody(n.Body)
+		p.space()
+		p.writeString("while")
+		p.space()
+		p.writeString("(")
+		p.printExpr(n.Cond, OpExpr)
+		p.writeString(")")
+		p.endStmt()
+	case *WhileStmt:
+		p.writeString("while")
+		p.space()
+		p.writeString("(")
+		p.printExpr(n.Cond, OpExpr)
+		p.writeString(")")
+		p.printBody(n.Body)
+	case *ForStmt:
+		p.writeString("for")
+		p.space()
+		p.writeString("(")
+		if n.Init != nil {
+			p.printForInit(n.Init, OpExpr)
+		}
+		p.writeString(";")
+		if n.Cond != nil {
+			p.space()
+			p.printExpr(n.Cond, OpExpr)
+		}
+		p.writeString(";")
+		if n.Post != nil {
+			p.space()
+			p.printExpr(n.Post, OpExpr)
+		}
+		p.writeString(")")
+		p.space()
+		p.printBlock(n.Body)
+	case *ForInStmt:
+		p.writeString("for")
+		p.space()
+		p.writeString("(")
+		p.printForInit(n.Init, OpLHS)
+		p.writeString(" in ")
+		p.printExpr(n.Value, OpExpr)
+		p.writeString(")")
+		p.space()
+		p.printBlock(n.Body)
+	case *ForOfStmt:
+		p.writeString("for")
+		if n.Await {
+			p.writeString(" await")
+		}
+		p.space()
+		p.writeString("(")
+		p.printForInit(n.Init, OpLHS)
+		p.writeString(" of ")
+		p.printExpr(n.Value, OpAssign)
+		p.writeString(")")
+		p.space()
+		p.printBlock(n.Body)
+	case *SwitchStmt:
+		p.writeString("switch")
+		p.space()
+		p.writeString("(")
+		p.printExpr(n.Init, OpExpr)
+		p.writeString(")")
+		p.space()
+		p.writeString("{")
+		p.indent++
+		for i := range n.List {
+			clause := &n.List[i]
+			p.newline()
+			p.printLeading(clause)
+			if clause.Cond != nil {
+				p.writeString("case")
+				p.space()
+				p.printExpr(clause.Cond, OpExpr)
+			} else {
+				p.writeString("default")
+			}
+			p.writeString(":")
+			p.indent++
+			for _, item := range clause.List {
+				p.newline()
+				p.printStmt(item)
+			}
+			p.printTrailing(clause)
+			p.indent--
+		}
+		p.indent--
+		if len(n.List) != 0 {
+			p.newline()
+		}
+		p.semicolon = false
+		p.writeString("}")
+	case *BranchStmt:
+		p.write(n.Type.Bytes())
+		if n.Label != nil {
+			p.writeString(" ")
+			p.write(n.Label)
+		}
+		p.endStmt()
+	case *ReturnStmt:
+		p.writeString("return")
+		if n.Value != nil {
+			p.space()
+			p.printExpr(n.Value, OpExpr)
+		}
+		p.endStmt()
+	case *WithStmt:
+		p.writeString("with")
+		p.space()
+		p.writeString("(")
+		p.printExpr(n.Cond, OpExpr)
+		p.writeString(")")
+		p.printBody(n.Body)
+	case *LabelledStmt:
+		p.write(n.Label)
+		p.writeString(":")
+		p.space()
+		p.printStmt(n.Value)
+	case *ThrowStmt:
+		p.writeString("throw")
+		p.space()
+		p.printExpr(n.Value, OpExpr)
+		p.endStmt()
+	case *TryStmt:
+		p.writeString("try")
+		p.space()
+		p.printBlock(n.Body)
+		if n.Catch != nil {
+			p.space()
+			p.writeString("catch")
+			if n.Binding != nil {
+				p.space()
+				p.writeString("(")
+				p.printBinding(n.Binding)
+				p.writeString(")")
+			}
+			p.space()
+			p.printBlock(n.Catch)
+		}
+		if n.Finally != nil {
+			p.space()
+			p.writeString("finally")
+			p.space()
+			p.printBlock(n.Finally)
+		}
+	case *DebuggerStmt:
+		p.writeString("debugger")
+		p.endStmt()
+	case *ImportStmt:
+		p.printImport(n)
+		p.endStmt()
+	case *ExportStmt:
+		p.printExport(n)
+	case *DirectivePrologueStmt:
+		if bytes.IndexAny(n.Value[1:len(n.Value)-1], "\"'\\") == -1 {
+			p.write(p.quote(n.Value)) // only change the quotes when that doesn't change the value
+		} else {
+			p.write(n.Value)
+		}
+		p.endStmt()
+	default:
+		p.writeString(stmt.JS())
+	}
+	p.printTrailing(stmt)
+}
+
+// hasDanglingIf returns true if the statement ends in an if statement without an else clause.
+func hasDanglingIf(stmt IStmt) bool {
+	switch n := stmt.(type) {
+	case *IfStmt:
+		return n.Else == nil || hasDanglingIf(n.Else)
+	case *WhileStmt:
+		return hasDanglingIf(n.Body)
+	case *WithStmt:
+		return hasDanglingIf(n.Body)
+	case *LabelledStmt:
+		return hasDanglingIf(n.Value)
+	}
+	return false
+}
+
+// printExprStmt prints an expression statement and parenthesizes it when it would otherwise be parsed as a declaration, block, or directive.
+func (p *printer) printExprStmt(expr IExpr) {
+	if lit, ok := expr.(*LiteralExpr); ok && lit.TokenType == StringToken {
+		p.writeString("(")
+		p.printExpr(expr, OpExpr)
+		p.writeString(")")
+		return
+	}
+
+	p.write(nil) // flush pending semicolon
+
+	// the omitted semicolon of the previous statement is written depending on how the statement starts
+	omitted := p.semicolon && !p.Compact
+	p.semicolon = false
+	start := len(p.buf)
+	p.printExpr(expr, OpExpr)
+	b := bytes.TrimLeft(p.buf[start:], " ")
+	if 0 < len(b) && (b[0] == '{' || b[0] == '@') || startsWithKeyword(b, "function") || startsWithKeyword(b, "class") || bytes.HasPrefix(b, []byte("async function")) || startsWithKeyword(b, "let") && bytes.HasPrefix(bytes.TrimLeft(b[3:], " "), []byte("[")) {
+		p.parenthesize(start)
+	}
+	if omitted && continuesStmt(p.buf[start:]) {
+		if start < len(p.buf) && p.buf[start] == ' ' {
+			start++
+		}
+		p.insert(start, []byte(";"))
+	}
+}
+
+func (p *printer) printForInit(init IExpr, prec OpPrec) {
+	parentNoIn := p.noIn
+	p.noIn = true
+	if varDecl, ok := init.(*VarDecl); ok {
+		p.printLeading(varDecl)
+		p.printVarDecl(varDecl)
+		p.printTrailing(varDecl)
+	} else {
+		p.printExpr(init, prec)
+	}
+	p.noIn = parentNoIn
+}
+
+func (p *printer) printImport(n *ImportStmt) {
+	p.writeString("import")
+	if n.Default != nil || len(n.List) != 0 {
+		if n.Default != nil {
+			p.space()
+			p.write(n.Default)
+			if len(n.List) != 0 {
+				p.writeString(",")
+			}
+		}
+		if len(n.List) == 1 && len(n.List[0].Name) == 1 && n.List[0].Name[0] == '*' {
+			p.space()
+			p.printAlias(n.List[0])
+		} else if 0 < len(n.List) {
+			p.space()
+			p.printAliasList(n.List)
+		}
+		p.space()
+		p.writeString("from")
+	}
+	p.space()
+	p.write(p.quote(n.Module))
+	p.printImportAttributes(n.Attributes)
+}
+
+func (p *printer) printExport(n *ExportStmt) {
+	p.writeString("export")
+	if n.Decl != nil {
+		if n.Default {
+			p.writeString(" default")
+		}
+		p.space()
+		switch decl := n.Decl.(type) {
+		case *FuncDecl:
+			p.printFunc(decl)
+		case *ClassDecl:
+			p.printClass(decl)
+		case *VarDecl:
+			p.printLeading(decl)
+			p.printVarDecl(decl)
+			p.endStmt()
+			p.printTrailing(decl)
+		default:
+			p.write(nil) // flush pending semicolon
+			start := len(p.buf)
+			p.printExpr(n.Decl, OpAssign)
+			b := bytes.TrimLeft(p.buf[start:], " ")
+			if 0 < len(b) && b[0] == '@' || startsWithKeyword(b, "function") || startsWithKeyword(b, "class") || bytes.HasPrefix(b, []byte("async function")) {
+				p.parenthesize(start)
+			}
+			p.endStmt()
+		}
+		return
+	} else if len(n.List) == 1 && (len(n.List[0].Name) == 1 && n.List[0].Name[0] == '*' || n.List[0].Name == nil && len(n.List[0].Binding) == 1 && n.List[0].Binding[0] == '*') {
+		p.space()
+		p.printAlias(n.List[0])
+	} else {
+		p.space()
+		p.printAliasList(n.List)
+	}
+	if n.Module != nil {
+		p.space()
+		p.writeString("from")
+		p.space()
+		p.write(p.quote(n.Module))
+		p.printImportAttributes(n.Attributes)
+	}
+	p.endStmt()
+}
+
+func (p *printer) printImportAttributes(list []ImportAttribute) {
+	if len(list) == 0 {
+		return
+	}
+	p.space()
+	p.writeString("with")
+	p.space()
+	p.writeString("{")
+	for i, item := range list {
+		if i != 0 {
+			p.writeString(",")
+		}
+		p.space()
+		p.write(p.quote(item.Key))
+		p.writeString(":")
+		p.space()
+		p.write(p.quote(item.Value))
+	}
+	p.space()
+	p.writeString("}")
+}
+
+func (p *printer) printAliasList(list []Alias) {
+	p.writeString("{")
+	i := 0
+	for _, item := range list {
+		if item.Binding == nil {
+			continue
+		}
+		if i != 0 {
+			p.writeString(",")
+			p.space()
+		}
+		p.printAlias(item)
+		i++
+	}
+	p.writeString("}")
+}
+
+func (p *printer) printAlias(alias Alias) {
+	if alias.Name != nil {
+		p.write(p.quote(alias.Name))
+		p.space()
+		p.writeString("as")
+		p.space()
+	}
+	p.write(p.quote(alias.Binding))
+}
+
+////////////////////////////////////////////////////////////////
+
+func (p *printer) printVarDecl(n *VarDecl) {
+	if n.Await {
+		p.writeString("await ")
+	}
+	p.write(n.TokenType.Bytes())
+	for i := range n.List {
+		if i != 0 {
+			p.writeString(",")
+		}
+		p.space()
+		p.printBindingElement(&n.List[i])
+	}
+}
+
+func (p *printer) printBindingElement(n *BindingElement) {
+	if n.Binding == nil {
+		return
+	}
+	p.printLeading(n)
+	p.printBinding(n.Binding)
+	if n.Default != nil {
+		p.space()
+		p.writeString("=")
+		p.space()
+		p.printExpr(n.Default, OpAssign)
+	}
+	p.printTrailing(n)
+}
+
+func (p *printer) printBinding(binding IBinding) {
+	switch n := binding.(type) {
+	case *Var:
+		p.printVar(n)
+	case *BindingArray:
+		p.writeString("[")
+		for i, item := range n.List {
+			if i != 0 {
+				p.writeString(",")
+				if item.Binding != nil {
+					p.space()
+				}
+			}
+			p.printBindingElement(&n.List[i])
+		}
+		if n.Rest != nil {
+			if len(n.List) != 0 {
+				p.writeString(",")
+				p.space()
+			}
+			p.writeString("...")
+			p.printBinding(n.Rest)
+		} else if 0 < len(n.List) && n.List[len(n.List)-1].Binding == nil {
+			p.writeString(",")
+		}
+		p.writeString("]")
+	case *BindingObject:
+		p.writeString("{")
+		for i, item := range n.List {
+			if i != 0 {
+				p.writeString(",")
+				p.space()
+			}
+			if item.Key != nil {
+				if v, ok := item.Value.Binding.(*Var); !ok || !item.Key.IsIdent(v.Data) {
+					p.printPropertyName(*item.Key)
+					p.writeString(":")
+					p.space()
+				}
+			}
+			p.printBindingElement(&n.List[i].Value)
+		}
+		if n.Rest != nil {
+			if len(n.List) != 0 {
+				p.writeString(",")
+				p.space()
+			}
+			p.writeString("...")
+			p.write(n.Rest.Data)
+		}
+		p.writeString("}")
+	default:
+		p.writeString(binding.JS())
+	}
+}
+
+func (p *printer) printParams(n Params) {
+	p.writeString("(")
+	for i := range n.List {
+		if i != 0 {
+			p.writeString(",")
+			p.space()
+		}
+		p.printBindingElement(&n.List[i])
+	}
+	if n.Rest != nil {
+		if len(n.List) != 0 {
+			p.writeString(",")
+			p.space()
+		}
+		p.writeString("...")
+		p.printBinding(n.Rest)
+	}
+	p.writeString(")")
+}
+
+func (p *printer) printFunc(n *FuncDecl) {
+	p.printLeading(n)
+	parentNoIn := p.noIn
+	p.noIn = false
+	if n.Async {
+		p.writeString("async function")
+	} else {
+		p.writeString("function")
+	}
+	if n.Generator {
+		p.writeString("*")
+	}
+	if n.Name != nil {
+		if n.Generator {
+			p.space()
+		}
+		p.printVar(n.Name)
+	}
+	p.printParams(n.Params)
+	p.space()
+	p.printBlock(&n.Body)
+	p.noIn = parentNoIn
+	p.printTrailing(n)
+}
+
+func (p *printer) printMethod(n *MethodDecl) {
+	p.printLeading(n)
+	p.printDecorators(n.Decorators)
+	if n.Static {
+		p.writeString("static")
+		p.space()
+	}
+	if n.Async {
+		p.writeString("async")
+		p.space()
+	}
+	if n.Generator {
+		p.writeString("*")
+	}
+	if n.Get {
+		p.writeString("get")
+		p.space()
+	}
+	if n.Set {
+		p.writeString("set")
+		p.space()
+	}
+	p.printPropertyName(n.Name)
+	p.printParams(n.Params)
+	p.space()
+	p.printBlock(&n.Body)
+	p.printTrailing(n)
+}
+
+func (p *printer) printClass(n *ClassDecl) {
+	p.printLeading(n)
+	parentNoIn, parentFlat := p.noIn, p.flat
+	p.noIn, p.flat = false, 0
+	p.printDecorators(n.Decorators)
+	p.writeString("class")
+	if n.Name != nil {
+		p.printVar(n.Name)
+	}
+	if n.Extends != nil {
+		p.writeString(" extends")
+		p.space()
+		p.printExpr(n.Extends, OpLHS)
+	}
+	p.space()
+	p.writeString("{")
+	if len(n.Definitions) != 0 || len(n.Methods) != 0 {
+		p.indent++
+		// definitions and methods are interleaved in source order, where definitions go first for nodes without positions
+		i, j := 0, 0
+		for i < len(n.Definitions) || j < len(n.Methods) {
+			p.newline()
+			if j == len(n.Methods) || i < len(n.Definitions) && n.Definitions[i].Start <= n.Methods[j].Start {
+				p.printField(&n.Definitions[i])
+				i++
+			} else {
+				p.printMethod(n.Methods[j])
+				j++
+			}
+		}
+		p.indent--
+		p.newline()
+	}
+	p.semicolon = false
+	p.writeString("}")
+	p.noIn, p.flat = parentNoIn, parentFlat
+	p.printTrailing(n)
+}
+
+func (p *printer) printField(item *FieldDefinition) {
+	p.printLeading(item)
+	p.printDecorators(item.Decorators)
+	if item.Static {
+		p.writeString("static")
+		p.space()
+	}
+	if item.Body != nil {
+		p.printBlock(item.Body)
+		p.printTrailing(item)
+		return
+	}
+	if item.Accessor {
+		p.writeString("accessor ")
+	}
+	p.printPropertyName(item.Name)
+	if item.Init != nil {
+		p.space()
+		p.writeString("=")
+		p.space()
+		p.printExpr(item.Init, OpAssign)
+	}
+	if p.OmitSemicolons && !p.Compact && item.Init == nil && (item.Name.IsIdent([]byte("get")) || item.Name.IsIdent([]byte("set")) || item.Name.IsIdent([]byte("static"))) {
+		p.writeString(";") // would otherwise be a modifier of the next method
+	} else {
+		p.endStmt()
+	}
+	p.printTrailing(item)
+}
+
+func (p *printer) printDecorators(list []IExpr) {
+	for _, item := range list {
+		p.writeString("@")
+		if group, ok := item.(*GroupExpr); ok {
+			item = group.X
+		}
+		if isDecoratorExpr(item) {
+			p.printExpr(item, OpCall)
+		} else {
+			p.writeString("(")
+			p.printExpr(item, OpExpr)
+			p.writeString(")")
+		}
+		p.space()
+	}
+}
+
+// isDecoratorExpr returns true if the expression can follow @ without parentheses, which is a dotted identifier that is optionally called.
+func isDecoratorExpr(expr IExpr) bool {
+	if call, ok := expr.(*CallExpr); ok {
+		expr = call.X
+	}
+	for {
+		switch n := expr.(type) {
+		case *Var:
+			return true
+		case *DotExpr:
+			if n.Prec == OpMember {
+				expr = n.X
+				continue
+			}
+		}
+		return false
+	}
+}
+
+func (p *printer) printPropertyName(n PropertyName) {
+	if n.Computed != nil {
+		p.writeString("[")
+		p.printExpr(n.Computed, OpAssign)
+		p.writeString("]")
+		return
+	}
+	p.write(p.quote(n.Literal.Data))
+}
+
+func (p *printer) printArgs(n Args) {
+	p.printList("(", ")", len(n.List), false, true, func(i int, comma bool) {
+		p.printLeading(&n.List[i])
+		if n.List[i].Rest {
+			p.writeString("...")
+		}
+		p.printExpr(n.List[i].Value, OpAssign)
+		if comma {
+			p.writeString(",")
+		}
+		p.printTrailing(&n.List[i])
+	}, nil)
+}
+
+// printList prints the n items of a list between brackets on a single line, or on separate lines if multiline is set or if the line would be wider than MaxWidth. A list is also put on separate lines if an item spans multiple lines, except for the last item if hug is set, such as a function expression as the last argument. The item function prints the item at index i followed by a comma if set, and the optional hole function returns true for an empty item that is not preceded by a space.
+func (p *printer) printList(open, close string, n int, multiline, hug bool, item func(i int, comma bool), hole func(i int) bool) {
+	if !multiline && 0 < n && 0 < p.MaxWidth && !p.Compact && p.flat == 0 {
+		state := p.save()
+		p.flat++
+		last := p.printListItems(open, close, n, false, item, hole)
+		p.flat--
+		if !hug {
+			last = len(p.buf)
+		}
+		if p.fits(state.buf, last) {
+			return
+		}
+		p.restore(state)
+		multiline = true
+	}
+	p.printListItems(open, close, n, multiline, item, hole)
+}
+
+// printListItems prints the items of a list and returns the offset of the last item.
+func (p *printer) printListItems(open, close string, n int, multiline bool, item func(i int, comma bool), hole func(i int) bool) int {
+	p.writeString(open)
+	if multiline {
+		p.indent++
+	}
+	last := len(p.buf)
+	for i := 0; i < n; i++ {
+		if multiline {
+			p.newline()
+		} else if i != 0 && (hole == nil || !hole(i)) {
+			p.space()
+		}
+		last = len(p.buf)
+		item(i, i+1 < n || multiline && p.TrailingCommas)
+	}
+	if multiline {
+		p.indent--
+		p.newline()
+	}
+	p.writeString(close)
+	return last
+}
+
+func (p *printer) printProperty(n *Property) {
+	if n.Spread {
+		p.writeString("...")
+	} else if n.Name != nil {
+		if v, ok := n.Value.(*Var); !ok || !n.Name.IsIdent(v.Data) {
+			p.printPropertyName(*n.Name)
+			p.writeString(":")
+			p.space()
+		}
+	}
+	p.printExpr(n.Value, OpAssign)
+	if n.Init != nil {
+		p.space()
+		p.writeString("=")
+		p.space()
+		p.printExpr(n.Init, OpAssign)
+	}
+}
+
+func (p *printer) printTemplate(n *TemplateExpr) {
+	for _, item := range n.List {
+		p.write(item.Value)
+		p.printExpr(item.Expr, OpExpr)
+	}
+	p.write(n.Tail)
+}
+
+////////////////////////////////////////////////////////////////
+
+// binaryOpPrec returns the operator precedence of a binary operator.
+func binaryOpPrec(op TokenType) OpPrec {
+	switch op {
+	case CommaToken:
+		return OpExpr
+	case EqToken, MulEqToken, DivEqToken, ModEqToken, ExpEqToken, AddEqToken, SubEqToken, LtLtEqToken, GtGtEqToken, GtGtGtEqToken, BitAndEqToken, BitXorEqToken, BitOrEqToken, AndEqToken, OrEqToken, NullishEqToken:
+		return OpAssign
+	case NullishToken:
+		return OpCoalesce
+	case OrToken:
+		return OpOr
+	case AndToken:
+		return OpAnd
+	case BitOrToken:
+		return OpBitOr
+	case BitXorToken:
+		return OpBitXor
+	case BitAndToken:
+		return OpBitAnd
+	case EqEqToken, NotEqToken, EqEqEqToken, NotEqEqToken:
+		return OpEquals
+	case LtToken, LtEqToken, GtToken, GtEqToken, InToken, InstanceofToken:
+		return OpCompare
+	case LtLtToken, GtGtToken, GtGtGtToken:
+		return OpShift
+	case AddToken, SubToken:
+		return OpAdd
+	case MulToken, DivToken, ModToken:
+		return OpMul
+	case ExpToken:
+		return OpExp
+	}
+	return OpPrimary
+}
+
+// exprPrec returns the operator precedence of the expression when printed without parentheses.
+func exprPrec(expr IExpr) OpPrec {
+	switch n := expr.(type) {
+	case *BinaryExpr:
+		return binaryOpPrec(n.Op)
+	case *UnaryExpr:
+		if n.Op == PostIncrToken || n.Op == PostDecrToken {
+			return OpUpdate
+		}
+		return OpUnary
+	case *CondExpr, *YieldExpr, *ArrowFunc:
+		return OpAssign
+	case *CallExpr, *OptChainExpr:
+		return OpCall
+	case *DotExpr:
+		return memberPrec(n.X)
+	case *IndexExpr:
+		return memberPrec(n.X)
+	case *TemplateExpr:
+		if n.Tag == nil {
+			return OpPrimary
+		}
+		return memberPrec(n.Tag)
+	case *NewExpr, *NewTargetExpr, *ImportMetaExpr:
+		return OpMember
+	}
+	return OpPrimary
+}
+
+// memberPrec returns the precedence of a member expression with the given object, which is a call expression if the object is one.
+func memberPrec(x IExpr) OpPrec {
+	if exprPrec(x) == OpCall {
+		return OpCall
+	}
+	return OpMember
+}
+
+func (p *printer) printGroup(expr IExpr) {
+	parentNoIn := p.noIn
+	p.noIn = false
+	p.writeString("(")
+	p.printExpr(expr, OpExpr)
+	p.writeString(")")
+	p.noIn = parentNoIn
+}
+
+// printExpr prints an expression and parenthesizes it if its precedence is lower than prec.
+func (p *printer) printExpr(expr IExpr, prec OpPrec) {
+	if exprPrec(expr) < prec {
+		p.printGroup(expr)
+		return
+	}
+
+	p.printLeading(expr)
+	p.addMapping(expr)
+	switch n := expr.(type) {
+	case *Var:
+		p.printVar(n)
+	case *LiteralExpr:
+		if n.TokenType == StringToken {
+			p.write(p.quote(n.Data))
+		} else {
+			p.write(n.Data)
+		}
+	case *ArrayExpr:
+		p.printList("[", "]", len(n.List), false, false, func(i int, comma bool) {
+			item := &n.List[i]
+			p.printLeading(item)
+			if item.Value != nil {
+				if item.Spread {
+					p.writeString("...")
+				}
+				p.printExpr(item.Value, OpAssign)
+			} else if i+1 == len(n.List) {
+				comma = true // trailing elision
+			}
+			if comma {
+				p.writeString(",")
+			}
+			p.printTrailing(item)
+		}, func(i int) bool {
+			return n.List[i].Value == nil
+		})
+	case *ObjectExpr:
+		// put properties on separate lines when they have comments
+		multiline := false
+		if !p.Compact {
+			for i := range n.List {
+				if _, ok := p.comments[&n.List[i]]; ok {
+					multiline = true
+					break
+				}
+			}
+		}
+
+		p.printList("{", "}", len(n.List), multiline, false, func(i int, comma bool) {
+			item := &n.List[i]
+			p.printLeading(item)
+			if method, ok := item.Value.(*MethodDecl); ok {
+				p.printMethod(method)
+			} else {
+				p.printProperty(item)
+			}
+			if comma {
+				p.writeString(",")
+			}
+			p.printTrailing(item)
+		}, nil)
+	case *TemplateExpr:
+		if n.Tag != nil {
+			if _, ok := n.Tag.(*OptChainExpr); ok {
+				p.printGroup(n.Tag)
+			} else {
+				p.printExpr(n.Tag, OpCall)
+			}
+		}
+		p.printTemplate(n)
+	case *GroupExpr:
+		p.printGroup(n.X)
+	case *DotExpr:
+		p.printExpr(n.X, OpCall)
+		if lit, ok := n.X.(*LiteralExpr); ok && lit.TokenType == DecimalToken && bytes.IndexAny(lit.Data, ".eE") == -1 {
+			p.buf = append(p.buf, ' ') // 1 .toString()
+		}
+		p.writeString(".")
+		p.write(n.Y.Data)
+	case *IndexExpr:
+		p.printExpr(n.X, OpCall)
+		p.writeString("[")
+		p.printExpr(n.Y, OpExpr)
+		p.writeString("]")
+	case *NewTargetExpr:
+		p.writeString("new.target")
+	case *ImportMetaExpr:
+		p.writeString("import.meta")
+	case *NewExpr:
+		p.writeString("new")
+		p.writeString(" ")
+		p.printExpr(n.X, OpMember)
+		if n.Args != nil {
+			p.printArgs(*n.Args)
+		} else {
+			p.writeString("()")
+		}
+	case *CallExpr:
+		p.printExpr(n.X, OpCall)
+		p.printArgs(n.Args)
+	case *OptChainExpr:
+		p.printExpr(n.X, OpCall)
+		p.writeString("?.")
+		switch y := n.Y.(type) {
+		case *CallExpr:
+			p.printArgs(y.Args)
+		case *IndexExpr:
+			p.writeString("[")
+			p.printExpr(y.Y, OpExpr)
+			p.writeString("]")
+		case *TemplateExpr:
+			p.printTemplate(y)
+		default:
+			p.printExpr(y, OpPrimary)
+		}
+	case *UnaryExpr:
+		if n.Op == PostIncrToken || n.Op == PostDecrToken {
+			p.printExpr(n.X, OpLHS)
+			p.write(n.Op.Bytes())
+		} else {
+			p.write(n.Op.Bytes())
+			if IsIdentifierName(n.Op) {
+				p.space()
+			}
+			p.printExpr(n.X, OpUnary)
+		}
+	case *BinaryExpr:
+		if n.Op == InToken && p.noIn {
+			p.printGroup(n)
+			return
+		}
+		precLeft, precRight := binaryOpPrec(n.Op), binaryOpPrec(n.Op)+1
+		switch n.Op {
+		case CommaToken:
+			precRight = OpAssign
+		case EqToken, MulEqToken, DivEqToken, ModEqToken, ExpEqToken, AddEqToken, SubEqToken, LtLtEqToken, GtGtEqToken, GtGtGtEqToken, BitAndEqToken, BitXorEqToken, BitOrEqToken, AndEqToken, OrEqToken, NullishEqToken:
+			precLeft, precRight = OpLHS, OpAssign
+		case NullishToken:
+			// cannot be mixed with && or || without parentheses
+			precLeft, precRight = OpBitOr, OpBitOr
+			if x, ok := n.X.(*BinaryExpr); ok && x.Op == NullishToken {
+				precLeft = OpCoalesce
+			}
+		case ExpToken:
+			// right-associative and the left operand cannot be a unary expression
+			precLeft, precRight = OpUpdate, OpExp
+		}
+		p.printExpr(n.X, precLeft)
+		if n.Op != CommaToken {
+			p.space()
+		}
+		p.write(n.Op.Bytes())
+		p.space()
+		p.printExpr(n.Y, precRight)
+	case *CondExpr:
+		p.printExpr(n.Cond, OpCoalesce)
+		p.space()
+		p.writeString("?")
+		p.space()
+		p.printExpr(n.X, OpAssign)
+		p.space()
+		p.writeString(":")
+		p.space()
+		p.printExpr(n.Y, OpAssign)
+	case *YieldExpr:
+		p.writeString("yield")
+		if n.Generator {
+			p.writeString("*")
+		}
+		if n.X != nil {
+			p.space()
+			p.printExpr(n.X, OpAssign)
+		}
+	case *ArrowFunc:
+		if n.Async {
+			p.writeString("async")
+			p.space()
+		}
+		simple := false
+		if p.Compact && len(n.Params.List) == 1 && n.Params.Rest == nil && n.Params.List[0].Default == nil {
+			_, simple = n.Params.List[0].Binding.(*Var)
+		}
+		if simple {
+			p.printBinding(n.Params.List[0].Binding)
+		} else {
+			p.printParams(n.Params)
+		}
+		p.space()
+		p.writeString("=>")
+		p.space()
+		if ret, ok := arrowReturn(n); ok {
+			p.printLeading(&n.Body)
+			p.printLeading(n.Body.List[0])
+			p.write(nil) // flush pending semicolon
+			start := len(p.buf)
+			p.printExpr(ret, OpAssign)
+			if b := bytes.TrimLeft(p.buf[start:], " "); 0 < len(b) && b[0] == '{' {
+				p.parenthesize(start)
+			}
+			p.printTrailing(n.Body.List[0])
+			p.printTrailing(&n.Body)
+		} else {
+			parentNoIn := p.noIn
+			p.noIn = false
+			p.printBlock(&n.Body)
+			p.noIn = parentNoIn
+		}
+	case *FuncDecl:
+		p.printFunc(n)
+	case *ClassDecl:
+		p.printClass(n)
+	case *MethodDecl:
+		p.printMethod(n)
+	case *VarDecl:
+		p.printVarDecl(n)
+	case *JSXElement:
+		p.printJSXElement(n)
+	default:
+		p.writeString(expr.JS())
+	}
+	p.printTrailing(expr)
+}
+
+func (p *printer) printJSXElement(n *JSXElement) {
+	p.writeString("<")
+	if n.Name != nil {
+		p.printExpr(n.Name, OpMember)
+	}
+	for _, attr := range n.Attrs {
+		p.writeString(" ")
+		if attr.Name == nil {
+			p.writeString("{...")
+			p.printExpr(attr.Value, OpAssign)
+			p.writeString("}")
+			continue
+		}
+		p.write(attr.Name.Data)
+		if attr.Value != nil {
+			p.writeString("=")
+			if container, ok := attr.Value.(*JSXExprContainer); ok {
+				p.printJSXExprContainer(container)
+			} else if lit, ok := attr.Value.(*LiteralExpr); ok {
+				p.write(lit.Data) // JSX strings have no escape sequences
+			} else {
+				p.printExpr(attr.Value, OpPrimary)
+			}
+		}
+	}
+	if n.SelfClosing {
+		p.space()
+		p.writeString("/>")
+		return
+	}
+	p.writeString(">")
+	for _, child := range n.Children {
+		switch child := child.(type) {
+		case *JSXText:
+			p.write(child.Data)
+		case *JSXExprContainer:
+			p.printJSXExprContainer(child)
+		case *JSXElement:
+			// comments are not printed since they would become text
+			p.addMapping(child)
+			p.printJSXElement(child)
+		}
+	}
+	p.writeString("</")
+	if n.Name != nil {
+		p.printExpr(n.Name, OpMember)
+	}
+	p.writeString(">")
+}
+
+func (p *printer) printJSXExprContainer(n *JSXExprContainer) {
+	p.writeString("{")
+	if n.Spread {
+		p.writeString("...")
+	}
+	if n.X != nil {
+		parentNoIn := p.noIn
+		p.noIn = false
+		p.printExpr(n.X, OpExpr)
+		p.noIn = parentNoIn
+	} else if c, ok := p.comments[n]; ok {
+		for i := range c.Inner {
+			if !p.skipComment(&c.Inner[i]) {
+				p.printComment(c.Inner[i])
+			}
+		}
+	}
+	p.writeString("}")
+}
+
+// arrowReturn returns the expression of an arrow function with a concise body.
+func arrowReturn(n *ArrowFunc) (IExpr, bool) {
+	if len(n.Body.List) == 1 {
+		if ret, ok := n.Body.List[0].(*ReturnStmt); ok && ret.Value != nil {
+			return ret.Value, true
+		}
+	}
+	return nil, false
+}
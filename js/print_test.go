@@ -0,0 +1,300 @@
+package js
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/tdewolff/parse/v2"
+	"github.com/tdewolff/parse/v2/sourcemap"
+	"github.com/tdewolff/test"
+)
+
+func TestPrint(t *testing.T) {
+	var tests = []struct {
+		js       string
+		expected string
+		compact  string
+	}{
+		{"a+b*c", "a + b * c;", "a+b*c"},
+		{"(a+b)*c", "(a + b) * c;", "(a+b)*c"},
+		{"a-(b-c)", "a - (b - c);", "a-(b-c)"},
+		{"a+ +b", "a + +b;", "a+ +b"},
+		{"a- -b; a- --b", "a - -b;\na - --b;", "a- -b;a- --b"},
+		{"a++ + b", "a++ + b;", "a++ +b"},
+		{"(-a)**b; a**-b; (a**b)**c", "(-a) ** b;\na ** -b;\n(a ** b) ** c;", "(-a)**b;a**-b;(a**b)**c"},
+		{"a ?? (b || c)", "a ?? (b || c);", "a??(b||c)"},
+		{"a = b ? c : d", "a = b ? c : d;", "a=b?c:d"},
+		{"typeof a; void 0; delete a.b", "typeof a;\nvoid 0;\ndelete a.b;", "typeof a;void 0;delete a.b"},
+		{"new (a())(); new a.b(c); new a", "new (a())();\nnew a.b(c);\nnew a();", "new (a())();new a.b(c);new a()"},
+		{"a?.b.c(d)?.[e]", "a?.b.c(d)?.[e];", "a?.b.c(d)?.[e]"},
+		{"1..toString(); 1.5.toString(); 1 .toString()", "1..toString();\n1.5.toString();\n1 .toString();", "1..toString();1.5.toString();1 .toString()"},
+		{"x = a => ({})", "x = (a) => ({});", "x=a=>({})"},
+		{"x = async (a, b) => { a; b }", "x = async (a, b) => {\n\ta;\n\tb;\n};", "x=async(a,b)=>{a;b}"},
+		{"(function(){})()", "(function() {})();", "(function(){})()"},
+		{"for (var a = (b in c);;);", "for (var a = (b in c);;) {}", "for(var a=(b in c);;){}"},
+		{"if (a) { if (b) c } else d", "if (a) {\n\tif (b) c;\n} else d;", "if(a){if(b)c}else d"},
+		{"if (a) b; else if (c) d; else e", "if (a) b; else if (c) d; else e;", "if(a)b;else if(c)d;else e"},
+		{"do a; while (b)", "do a; while (b);", "do a;while(b)"},
+		{"switch (a) { case 1: b; default: c }", "switch (a) {\n\tcase 1:\n\t\tb;\n\tdefault:\n\t\tc;\n}", "switch(a){case 1:b;default:c}"},
+		{"try { a } catch (e) { b } finally { c }", "try {\n\ta;\n} catch (e) {\n\tb;\n} finally {\n\tc;\n}", "try{a}catch(e){b}finally{c}"},
+		{"var {a, b: [c, , d] = e, ...f} = g", "var {a, b: [c,, d] = e, ...f} = g;", "var{a,b:[c,,d]=e,...f}=g"},
+		{"class A extends B { c = 1; static async *d() {} get e() {} }", "class A extends B {\n\tc = 1;\n\tstatic async *d() {}\n\tget e() {}\n}", "class A extends B{c=1;static async*d(){}get e(){}}"},
+		{"x = {a, b: 1, [c]: 2, ...d, e() {}}", "x = {a, b: 1, [c]: 2, ...d, e() {}};", "x={a,b:1,[c]:2,...d,e(){}}"},
+		{"a`b${c}d`", "a`b${c}d`;", "a`b${c}d`"},
+		{"import a, {b as c, d} from 'e'", "import a, {b as c, d} from 'e';", "import a,{b as c,d}from'e'"},
+		{"import * as a from 'b'", "import * as a from 'b';", "import*as a from'b'"},
+		{"export {a as b}; export * from 'c'; export default a + b", "export {a as b};\nexport * from 'c';\nexport default a + b;", "export{a as b};export*from'c';export default a+b"},
+		{"a: for (;;) { break a }", "a: for (;;) {\n\tbreak a;\n}", "a:for(;;){break a}"},
+		{"function* a(b, ...c) { yield* b }", "function* a(b, ...c) {\n\tyield* b;\n}", "function*a(b,...c){yield*b}"},
+		{"'use strict'; ('a')", "'use strict';\n('a');", "'use strict';('a')"},
+		{"a / /b/g", "a / /b/g;", "a/ /b/g"},
+		{"a < !b; a-- > b", "a < !b;\na-- > b;", "a< !b;a-- >b"},
+		{"class A { static a = 1; static { b(this) } #c; m(d) { return #c in d } }", "class A {\n\tstatic a = 1;\n\tstatic {\n\t\tb(this);\n\t}\n\t#c;\n\tm(d) {\n\t\treturn #c in d;\n\t}\n}", "class A{static a=1;static{b(this)}#c;m(d){return#c in d}}"},
+		{"@a @b.c(d) @(e[f]) class A { @g h; @g static i() {} }", "@a @b.c(d) @(e[f]) class A {\n\t@g h;\n\t@g static i() {}\n}", "@a@b.c(d)@(e[f])class A{@g h;@g static i(){}}"},
+		{"class A { @a accessor b = 1; static accessor #c }", "class A {\n\t@a accessor b = 1;\n\tstatic accessor #c;\n}", "class A{@a accessor b=1;static accessor #c}"},
+		{"class A { [f()]() {} [g()] = 1; @h i() {} @j k }", "class A {\n\t[f()]() {}\n\t[g()] = 1;\n\t@h i() {}\n\t@j k;\n}", "class A{[f()](){}[g()]=1;@h i(){}@j k}"},
+		{"(@a class {}).b; export @c class C {}", "(@a class {}).b;\nexport @c class C {}", "(@a class{}).b;export@c class C{}"},
+		{"import a from 'b' with { type: 'json' }; export * from 'c' with { type: 'json' }", "import a from 'b' with { type: 'json' };\nexport * from 'c' with { type: 'json' };", "import a from'b'with{type:'json'};export*from'c'with{type:'json'}"},
+		{"{ using a = b } async function f() { await using c = d; for (using e of g); }", "{\n\tusing a = b;\n}\nasync function f() {\n\tawait using c = d;\n\tfor (using e of g) {}\n}", "{using a=b}async function f(){await using c=d;for(using e of g){}}"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.js, func(t *testing.T) {
+			ast, err := Parse(parse.NewInputString(tt.js))
+			test.Error(t, err)
+
+			buf := &bytes.Buffer{}
+			test.Error(t, Print(buf, ast, PrintOptions{}))
+			test.String(t, buf.String(), tt.expected)
+
+			buf.Reset()
+			test.Error(t, Print(buf, ast, PrintOptions{Compact: true}))
+			test.String(t, buf.String(), tt.compact)
+
+			// must parse to the same AST
+			ast2, err := Parse(parse.NewInputString(buf.String()))
+			test.Error(t, err)
+			test.String(t, ast2.String(), ast.String())
+		})
+	}
+}
+
+func TestPrintModified(t *testing.T) {
+	a, b, c := NewVar([]byte("a"), NoDecl), NewVar([]byte("b"), NoDecl), NewVar([]byte("c"), NoDecl)
+	var tests = []struct {
+		node     INode
+		expected string
+	}{
+		{&BinaryExpr{Op: MulToken, X: &BinaryExpr{Op: AddToken, X: a, Y: b}, Y: c}, "(a+b)*c"},
+		{&BinaryExpr{Op: SubToken, X: a, Y: &BinaryExpr{Op: AddToken, X: b, Y: c}}, "a-(b+c)"},
+		{&BinaryExpr{Op: ExpToken, X: &BinaryExpr{Op: ExpToken, X: a, Y: b}, Y: c}, "(a**b)**c"},
+		{&BinaryExpr{Op: OrToken, X: &BinaryExpr{Op: NullishToken, X: a, Y: b}, Y: c}, "(a??b)||c"},
+		{&BinaryExpr{Op: NullishToken, X: &BinaryExpr{Op: NullishToken, X: a, Y: b}, Y: c}, "a??b??c"},
+		{&UnaryExpr{Op: NegToken, X: &UnaryExpr{Op: PreDecrToken, X: a}}, "- --a"},
+		{&CallExpr{X: &ArrowFunc{Body: BlockStmt{List: []IStmt{&ReturnStmt{Value: a}}}}}, "(()=>a)()"},
+		{&DotExpr{X: &NewExpr{X: &CallExpr{X: a}}, Y: LiteralExpr{IdentifierToken, []byte("b"), Span{}}}, "new (a())().b"},
+		{&ExprStmt{Value: &ObjectExpr{}}, "({})"},
+		{&ExprStmt{Value: &CallExpr{X: &FuncDecl{}}}, "(function(){}())"},
+		{&ExprStmt{Value: &BinaryExpr{Op: EqToken, X: &ObjectExpr{}, Y: a}}, "({}=a)"},
+		{&ExprStmt{Value: &IndexExpr{X: NewVar([]byte("let"), NoDecl), Y: a}}, "(let[a])"},
+		{&IfStmt{Cond: a, Body: &IfStmt{Cond: b, Body: &ExprStmt{Value: c}}, Else: &ExprStmt{Value: a}}, "if(a){if(b)c}else a"},
+		{&ForStmt{Init: &BinaryExpr{Op: EqToken, X: a, Y: &BinaryExpr{Op: InToken, X: b, Y: c}}, Body: &BlockStmt{}}, "for(a=(b in c);;){}"},
+		{&ArrowFunc{Params: Params{List: []BindingElement{{Binding: a}}}, Body: BlockStmt{List: []IStmt{&ReturnStmt{Value: &BinaryExpr{Op: CommaToken, X: a, Y: b}}}}}, "a=>(a,b)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expected, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			test.Error(t, Print(buf, tt.node, PrintOptions{Compact: true}))
+			test.String(t, buf.String(), tt.expected)
+		})
+	}
+}
+
+func TestPrintComments(t *testing.T) {
+	var tests = []struct {
+		js       string
+		expected string
+		compact  string
+	}{
+		{"/*! a */\nb", "/*! a */\nb;", "/*! a */b"},
+		{"#!/bin/node\n// a\nb", "#!/bin/node\n// a\nb;", "#!/bin/node\nb"},
+		{"a\n/** b */\nfunction c(){}", "a;\n/** b */\nfunction c() {}", "a;function c(){}"},
+		{"a\n/* @license b */\nc", "a;\n/* @license b */\nc;", "a;/* @license b */c"},
+		{"a; // b\nc", "a; // b\nc;", "a;c"},
+		{"a // b\nc", "a; // b\nc;", "a;c"},
+		{"a\n// b\n// c\nd", "a;\n// b\n// c\nd;", "a;d"},
+		{"a\n{\n// b\n}", "a;\n{\n\t// b\n}", "a;{}"},
+		{"a\nif (b) {\n\tc\n\t// d\n}", "a;\nif (b) {\n\tc;\n\t// d\n}", "a;if(b){c}"},
+		{"a\nx = {\n\t// b\n\tc: 1, // d\n\te\n}", "a;\nx = {\n\t// b\n\tc: 1, // d\n\te\n};", "a;x={c:1,e}"},
+		{"a\nswitch (b) {\n// c\ncase 1: // d\n}", "a;\nswitch (b) {\n\t// c\n\tcase 1: // d\n}", "a;switch(b){case 1:}"},
+		{"a\nclass b {\n\t/** c */\n\td() {}\n}", "a;\nclass b {\n\t/** c */\n\td() {}\n}", "a;class b{d(){}}"},
+		{"a\nx = () => /* b */ c", "a;\nx = () => /* b */ c;", "a;x=()=>c"},
+		{"a\nx = /*! b */ c + d", "a;\nx = /*! b */ c + d;", "a;x=/*! b */c+d"},
+		{"a\nreturn /* b */ /* c */", "a;\nreturn; /* b */ /* c */", "a;return"},
+		{"a\ncall(b, /* c */ d)", "a;\ncall(b, /* c */ d);", "a;call(b,d)"},
+		{"a\nfunction f(/* b */ c) {}", "a;\nfunction f(/* b */ c) {}", "a;function f(c){}"},
+		{"a\nx = [b, /* c */ d]", "a;\nx = [b, /* c */ d];", "a;x=[b,d]"},
+		{"a = /* b */ c", "a = /* b */ c;", "a=c"},
+		{"x = a + /* b */ c", "x = a + /* b */ c;", "x=a+c"},
+		{"x = !/* a */ b", "x = !/* a */ b;", "x=!b"},
+		{"if (/* a */ b) c", "if (/* a */ b) c;", "if(b)c"},
+		{"a\nreturn b /* c */", "a;\nreturn b; /* c */", "a;return b"},
+		{"f(/* a */)", "f() /* a */;", "f()"},
+		{"x = <A>{/* b */}</A>", "x = <A>{/* b */}</A>;", "x=<A>{}</A>"},
+		{"x = <A b={/* c */ d}>{/* e */ f}</A>", "x = <A b={/* c */ d}>{/* e */ f}</A>;", "x=<A b={d}>{f}</A>"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.js, func(t *testing.T) {
+			ast, err := ParseWithOptions(parse.NewInputString(tt.js), ParseOptions{JSX: true})
+			test.Error(t, err)
+
+			buf := &bytes.Buffer{}
+			test.Error(t, Print(buf, ast, PrintOptions{}))
+			test.String(t, buf.String(), tt.expected)
+
+			// printing must be stable
+			ast2, err := ParseWithOptions(parse.NewInputString(buf.String()), ParseOptions{JSX: true})
+			test.Error(t, err)
+			buf2 := &bytes.Buffer{}
+			test.Error(t, Print(buf2, ast2, PrintOptions{}))
+			test.String(t, buf2.String(), tt.expected)
+
+			buf.Reset()
+			test.Error(t, Print(buf, ast, PrintOptions{Compact: true}))
+			test.String(t, buf.String(), tt.compact)
+		})
+	}
+}
+
+func TestPrintSourceMap(t *testing.T) {
+	src := "var a = 1;\nif (a) {\n  f(a,\n    b + c);\n}"
+	ast, err := Parse(parse.NewInputString(src))
+	test.Error(t, err)
+
+	g := sourcemap.NewGenerator("out.js")
+	source := g.AddSource("in.js", []byte(src))
+	buf := &bytes.Buffer{}
+	test.Error(t, Print(buf, ast, PrintOptions{Compact: true, SourceMap: g, Source: source}))
+	test.String(t, buf.String(), "var a=1;if(a){f(a,b+c)}")
+
+	m := g.SourceMap(buf.Bytes())
+	var tests = []struct {
+		col      int
+		expected string
+	}{
+		{0, "0:0"},  // var
+		{6, "0:8"},  // 1
+		{8, "1:0"},  // if
+		{13, "1:7"}, // {
+		{14, "2:2"}, // f(a,b+c)
+		{18, "3:4"}, // b+c
+	}
+	for _, tt := range tests {
+		mapping, ok := m.Find(0, tt.col)
+		test.That(t, ok)
+		test.String(t, fmt.Sprintf("%d:%d", mapping.Line, mapping.Col), tt.expected, buf.String()[tt.col:])
+	}
+}
+
+func TestPrintSourceMapNames(t *testing.T) {
+	src := "function f(long, \\u0062) { return long + b }"
+	ast, err := Parse(parse.NewInputString(src))
+	test.Error(t, err)
+	Mangle(ast, MangleOptions{})
+
+	g := sourcemap.NewGenerator("out.js")
+	source := g.AddSource("in.js", []byte(src))
+	buf := &bytes.Buffer{}
+	test.Error(t, Print(buf, ast, PrintOptions{Compact: true, SourceMap: g, Source: source}))
+	test.String(t, buf.String(), "function f(a,b){return a+b}")
+
+	m := g.SourceMap(buf.Bytes())
+	test.T(t, m.Names, []string{"long"})
+	names := []string{}
+	for _, mapping := range m.Mappings {
+		if mapping.Name != -1 {
+			names = append(names, fmt.Sprintf("%d:%s", mapping.GenCol, m.Names[mapping.Name]))
+		}
+	}
+	test.String(t, strings.Join(names, " "), "11:long 23:long")
+}
+
+func TestPrintJSX(t *testing.T) {
+	var tests = []struct {
+		js       string
+		expected string
+		compact  string
+	}{
+		{"<a/>", "<a />;", "<a/>"},
+		{"<a b='c' d={e+f} {...g} h=<i/>>j {k} &amp;<l></l></a>", "<a b='c' d={e + f} {...g} h=<i />>j {k} &amp;<l></l></a>;", "<a b='c' d={e+f} {...g} h=<i/>>j {k} &amp;<l></l></a>"},
+		{"<>{/* a */}{/*! b */}{...c}</>", "<>{/* a */}{/*! b */}{...c}</>;", "<>{}{/*! b */}{...c}</>"},
+		{"<A.b></A.b>", "<A.b></A.b>;", "<A.b></A.b>"},
+		{"x = a < <b/>", "x = a < <b />;", "x=a< <b/>"},
+		{"x = () => <a>\n\tb\n</a>", "x = () => <a>\n\tb\n</a>;", "x=()=><a>\n\tb\n</a>"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.js, func(t *testing.T) {
+			ast, err := ParseWithOptions(parse.NewInputString(tt.js), ParseOptions{JSX: true})
+			test.Error(t, err)
+
+			buf := &bytes.Buffer{}
+			test.Error(t, Print(buf, ast, PrintOptions{}))
+			test.String(t, buf.String(), tt.expected)
+
+			buf.Reset()
+			test.Error(t, Print(buf, ast, PrintOptions{Compact: true}))
+			test.String(t, buf.String(), tt.compact)
+
+			// must parse to the same AST
+			ast2, err := ParseWithOptions(parse.NewInputString(buf.String()), ParseOptions{JSX: true})
+			test.Error(t, err)
+			test.String(t, ast2.String(), ast.String())
+		})
+	}
+}
+
+func TestPrintFormat(t *testing.T) {
+	var tests = []struct {
+		js       string
+		o        PrintOptions
+		expected string
+	}{
+		{"if (a) { b }", PrintOptions{Indent: "  "}, "if (a) {\n  b;\n}"},
+		{"a = 'b'; c = \"d\"; import e from 'f'", PrintOptions{Quotes: DoubleQuotes}, "a = \"b\";\nc = \"d\";\nimport e from \"f\";"},
+		{"a = \"b\"; c = {\"d-e\": 1}; export {e as f} from \"g\"", PrintOptions{Quotes: SingleQuotes}, "a = 'b';\nc = {'d-e': 1};\nexport {e as f} from 'g';"},
+		{`a = 'it\'s'; b = "say \"hi\""; c = '\"\n'`, PrintOptions{Quotes: DoubleQuotes}, `a = "it's";` + "\n" + `b = 'say "hi"';` + "\n" + `c = '"\n';`},
+		{"'use strict'; 'a\\'b'", PrintOptions{Quotes: DoubleQuotes}, "\"use strict\";\n'a\\'b';"},
+		{"a; b;\n[c].d;\n(e);\n`f`; +g; /h/.i; if (j) k; else l; do m; while (n)", PrintOptions{OmitSemicolons: true}, "a\nb\n;[c].d\n;(e)\n;`f`\n;+g\n;/h/.i\nif (j) k; else l\ndo m; while (n)"},
+		{"a; ({b} = c); x = function() { y; (function() {})() }", PrintOptions{OmitSemicolons: true}, "a\n;({b} = c)\nx = function() {\n\ty\n\t;(function() {})()\n}"},
+		{"class A { a() {} b; [c]() {} }", PrintOptions{OmitSemicolons: true}, "class A {\n\ta() {}\n\tb\n\t;[c]() {}\n}"},
+		{"class A { get; x = 1; [y] = 2; *z() {} }", PrintOptions{OmitSemicolons: true}, "class A {\n\tget;\n\tx = 1\n\t;[y] = 2\n\t;*z() {}\n}"},
+		{"a; // b\nc", PrintOptions{OmitSemicolons: true}, "a // b\nc"},
+		{"f(a, b); x = [1, 2]; y = {a: 1}", PrintOptions{TrailingCommas: true}, "f(a, b);\nx = [1, 2];\ny = {a: 1};"},
+		{"f(aaaa, bbbb, cccc)", PrintOptions{MaxWidth: 10}, "f(\n\taaaa,\n\tbbbb,\n\tcccc\n);"},
+		{"f(aaaa, bbbb, cccc)", PrintOptions{MaxWidth: 10, TrailingCommas: true}, "f(\n\taaaa,\n\tbbbb,\n\tcccc,\n);"},
+		{"x = {a: [1, 2], b: {c: 3}}", PrintOptions{MaxWidth: 20}, "x = {\n\ta: [1, 2],\n\tb: {c: 3}\n};"},
+		{"x = [, a, , ]", PrintOptions{MaxWidth: 5}, "x = [\n\t,\n\ta,\n\t,\n];"},
+		{"f(a, () => { b })", PrintOptions{MaxWidth: 20}, "f(a, () => {\n\tb;\n});"},
+		{"x = {a: () => { b }}", PrintOptions{MaxWidth: 20}, "x = {\n\ta: () => {\n\t\tb;\n\t}\n};"},
+		{"f(aaaa, g(bbbb, cccc))", PrintOptions{MaxWidth: 20}, "f(\n\taaaa,\n\tg(bbbb, cccc)\n);"},
+		{"x = {\n// a\nb: 1, c: 2 // d\n}", PrintOptions{MaxWidth: 80, TrailingCommas: true}, "x = {\n\t// a\n\tb: 1,\n\tc: 2, // d\n};"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.js, func(t *testing.T) {
+			ast, err := Parse(parse.NewInputString(tt.js))
+			test.Error(t, err)
+
+			buf := &bytes.Buffer{}
+			test.Error(t, Print(buf, ast, tt.o))
+			test.String(t, buf.String(), tt.expected)
+
+			// formatting must be idempotent
+			ast2, err := Parse(parse.NewInputString(buf.String()))
+			test.Error(t, err)
+			buf2 := &bytes.Buffer{}
+			test.Error(t, Print(buf2, ast2, tt.o))
+			test.String(t, buf2.String(), tt.expected)
+		})
+	}
+}
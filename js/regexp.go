@@ -0,0 +1,1311 @@
+package js
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/tdewolff/parse/v2"
+	"github.com/tdewolff/parse/v2/buffer"
+)
+
+// RegExpFlags are the flags of a regular expression literal.
+type RegExpFlags uint8
+
+// RegExpFlags values.
+const (
+	RegExpHasIndices  RegExpFlags = 1 << iota // d
+	RegExpGlobal                              // g
+	RegExpIgnoreCase                          // i
+	RegExpMultiline                           // m
+	RegExpDotAll                              // s
+	RegExpUnicode                             // u
+	RegExpUnicodeSets                         // v
+	RegExpSticky                              // y
+)
+
+const regExpFlagChars = "dgimsuvy"
+
+func (flags RegExpFlags) String() string {
+	s := ""
+	for i := 0; i < len(regExpFlagChars); i++ {
+		if flags&(1<<i) != 0 {
+			s += regExpFlagChars[i : i+1]
+		}
+	}
+	return s
+}
+
+// RegExpSetOp is the operation on the operands of a character class in unicode sets mode.
+type RegExpSetOp uint8
+
+// RegExpSetOp values.
+const (
+	RegExpUnion        RegExpSetOp = iota // [ab]
+	RegExpIntersection                    // [a&&b]
+	RegExpSubtraction                     // [a--b]
+)
+
+// IRegExpNode is an interface for the nodes of a regular expression pattern. Offsets are relative to the start of the regular expression literal.
+type IRegExpNode interface {
+	String() string
+	Offsets() (int, int)
+	regExpNode()
+}
+
+// RegExp is a parsed regular expression literal.
+type RegExp struct {
+	Pattern IRegExpNode
+	Flags   RegExpFlags
+	Groups  int // number of capturing groups
+	Span
+}
+
+func (n RegExp) String() string {
+	return "/" + n.Pattern.String() + "/" + n.Flags.String()
+}
+
+// RegExpAlt is a disjunction of alternatives separated by |.
+type RegExpAlt struct {
+	List []IRegExpNode
+	Span
+}
+
+func (n RegExpAlt) String() string {
+	s := "Alt("
+	for i, item := range n.List {
+		if i != 0 {
+			s += " | "
+		}
+		s += item.String()
+	}
+	return s + ")"
+}
+
+// RegExpSeq is an alternative of multiple terms, or an empty alternative.
+type RegExpSeq struct {
+	List []IRegExpNode
+	Span
+}
+
+func (n RegExpSeq) String() string {
+	s := "Seq("
+	for i, item := range n.List {
+		if i != 0 {
+			s += " "
+		}
+		s += item.String()
+	}
+	return s + ")"
+}
+
+// RegExpChar is a literal character, or an escape sequence of a character where Value is the code point.
+type RegExpChar struct {
+	Value rune
+	Data  []byte
+	Span
+}
+
+func (n RegExpChar) String() string {
+	return string(n.Data)
+}
+
+// RegExpDot is the . that matches any character.
+type RegExpDot struct {
+	Span
+}
+
+func (n RegExpDot) String() string {
+	return "."
+}
+
+// RegExpAssertion is one of the assertions ^, $, \b, or \B, where Kind is ^, $, b, or B respectively.
+type RegExpAssertion struct {
+	Kind byte
+	Span
+}
+
+func (n RegExpAssertion) String() string {
+	if n.Kind == 'b' || n.Kind == 'B' {
+		return "\\" + string(n.Kind)
+	}
+	return string(n.Kind)
+}
+
+// RegExpLookaround is a lookahead or lookbehind assertion.
+type RegExpLookaround struct {
+	Behind bool
+	Negate bool
+	X      IRegExpNode
+	Span
+}
+
+func (n RegExpLookaround) String() string {
+	s := "(?"
+	if n.Behind {
+		s += "<"
+	}
+	if n.Negate {
+		s += "!"
+	} else {
+		s += "="
+	}
+	return s + n.X.String() + ")"
+}
+
+// RegExpGroup is a capturing group with a 1-based Index and an optional Name, or a non-capturing group that may add or remove the i, m, and s flags.
+type RegExpGroup struct {
+	Capture     bool
+	Index       int
+	Name        []byte
+	Add, Remove RegExpFlags
+	X           IRegExpNode
+	Span
+}
+
+func (n RegExpGroup) String() string {
+	s := "("
+	if n.Name != nil {
+		s += "?<" + string(n.Name) + ">"
+	} else if !n.Capture {
+		s += "?" + n.Add.String()
+		if n.Remove != 0 {
+			s += "-" + n.Remove.String()
+		}
+		s += ":"
+	}
+	return s + n.X.String() + ")"
+}
+
+// RegExpQuantifier is a repetition of X between Min and Max times, Max is -1 for no upper bound. Min and Max saturate for large bounds, while Data keeps the quantifier as written.
+type RegExpQuantifier struct {
+	X        IRegExpNode
+	Min, Max int
+	Lazy     bool
+	Data     []byte // can be nil
+	Span
+}
+
+func (n RegExpQuantifier) String() string {
+	s := n.X.String()
+	if n.Data != nil {
+		return s + string(n.Data)
+	} else if n.Min == 0 && n.Max == -1 {
+		s += "*"
+	} else if n.Min == 1 && n.Max == -1 {
+		s += "+"
+	} else if n.Min == 0 && n.Max == 1 {
+		s += "?"
+	} else if n.Min == n.Max {
+		s += "{" + strconv.Itoa(n.Min) + "}"
+	} else if n.Max == -1 {
+		s += "{" + strconv.Itoa(n.Min) + ",}"
+	} else {
+		s += "{" + strconv.Itoa(n.Min) + "," + strconv.Itoa(n.Max) + "}"
+	}
+	if n.Lazy {
+		s += "?"
+	}
+	return s
+}
+
+// RegExpBackref is a backreference to the capturing group with the given Index, or to the group with the given Name.
+type RegExpBackref struct {
+	Index int
+	Name  []byte
+	Span
+}
+
+func (n RegExpBackref) String() string {
+	if n.Name != nil {
+		return "\\k<" + string(n.Name) + ">"
+	}
+	return "\\" + strconv.Itoa(n.Index)
+}
+
+// RegExpClassEscape is one of the character class escapes \d, \D, \s, \S, \w, or \W, where Kind is the letter.
+type RegExpClassEscape struct {
+	Kind byte
+	Span
+}
+
+func (n RegExpClassEscape) String() string {
+	return "\\" + string(n.Kind)
+}
+
+// RegExpProperty is a unicode property escape \p{Name} or \p{Name=Value}, which is negated for \P.
+type RegExpProperty struct {
+	Negate bool
+	Name   []byte
+	Value  []byte
+	Span
+}
+
+func (n RegExpProperty) String() string {
+	s := "\\p{"
+	if n.Negate {
+		s = "\\P{"
+	}
+	s += string(n.Name)
+	if n.Value != nil {
+		s += "=" + string(n.Value)
+	}
+	return s + "}"
+}
+
+// RegExpClass is a character class of characters, ranges, class escapes, and in unicode sets mode nested classes and strings. In unicode sets mode the items may be combined by intersection or subtraction.
+type RegExpClass struct {
+	Negate bool
+	Op     RegExpSetOp
+	List   []IRegExpNode
+	Span
+}
+
+func (n RegExpClass) String() string {
+	s := "["
+	if n.Negate {
+		s += "^"
+	}
+	for i, item := range n.List {
+		if i != 0 && n.Op == RegExpIntersection {
+			s += "&&"
+		} else if i != 0 && n.Op == RegExpSubtraction {
+			s += "--"
+		}
+		s += item.String()
+	}
+	return s + "]"
+}
+
+// RegExpRange is a range of characters in a character class.
+type RegExpRange struct {
+	Min, Max *RegExpChar
+	Span
+}
+
+func (n RegExpRange) String() string {
+	return n.Min.String() + "-" + n.Max.String()
+}
+
+// RegExpStrings is a class string disjunction \q{...} in unicode sets mode, which matches any of the strings.
+type RegExpStrings struct {
+	List [][]rune
+	Data []byte
+	Span
+}
+
+func (n RegExpStrings) String() string {
+	return string(n.Data)
+}
+
+func (n RegExpAlt) regExpNode()         {}
+func (n RegExpSeq) regExpNode()         {}
+func (n RegExpChar) regExpNode()        {}
+func (n RegExpDot) regExpNode()         {}
+func (n RegExpAssertion) regExpNode()   {}
+func (n RegExpLookaround) regExpNode()  {}
+func (n RegExpGroup) regExpNode()       {}
+func (n RegExpQuantifier) regExpNode()  {}
+func (n RegExpBackref) regExpNode()     {}
+func (n RegExpClassEscape) regExpNode() {}
+func (n RegExpProperty) regExpNode()    {}
+func (n RegExpClass) regExpNode()       {}
+func (n RegExpRange) regExpNode()       {}
+func (n RegExpStrings) regExpNode()     {}
+
+////////////////////////////////////////////////////////////////
+
+// regExpAlt identifies an alternative of a disjunction, and is used to allow duplicate group names in different alternatives.
+type regExpAlt struct {
+	disjunction, alt int
+}
+
+// regExpName is a group name with the alternatives it is nested in.
+type regExpName struct {
+	name []byte
+	path []regExpAlt
+}
+
+type regExpParser struct {
+	data    []byte
+	pos     int
+	end     int  // offset of the closing slash
+	unicode bool // u or v flag
+	sets    bool // v flag
+	annexB  bool // web compatible syntax of Annex B, without the u or v flag
+
+	namedGroups  bool // the pattern has named groups, so that \k is a named backreference
+	numGroups    int  // number of capturing groups in the pattern
+	groups       int  // number of capturing groups parsed so far
+	disjunctions int
+	path         []regExpAlt
+	names        []regExpName
+	refs         []*RegExpBackref // named backreferences
+
+	level int
+	err   error
+}
+
+// RegExpOptions are the options for ParseRegExpWithOptions.
+type RegExpOptions struct {
+	NoAnnexB bool // disallow the web compatible syntax of Annex B, such as /]/ and quantified lookaheads
+}
+
+// ParseRegExp parses a regular expression literal as returned by Lexer.RegExp, including the slashes and flags. The literal is parsed with the web compatible syntax of Annex B of the specification unless the u or v flag is set. Offsets of nodes and errors are relative to the start of data, the start of the LiteralExpr must be added to obtain offsets in the source. A syntax error is returned as a *parse.Error.
+func ParseRegExp(data []byte) (*RegExp, error) {
+	return ParseRegExpWithOptions(data, RegExpOptions{})
+}
+
+// ParseRegExpWithOptions parses a regular expression literal as ParseRegExp, with options.
+func ParseRegExpWithOptions(data []byte, o RegExpOptions) (*RegExp, error) {
+	p := &regExpParser{
+		data: data,
+		pos:  1,
+		end:  bytes.LastIndexByte(data, '/'),
+	}
+	if len(data) == 0 || data[0] != '/' || p.end < 1 {
+		return nil, parse.NewError(buffer.NewReader(data), 0, "invalid regular expression literal")
+	}
+
+	regExp := &RegExp{Span: Span{0, len(data)}}
+	regExp.Flags = p.parseFlags()
+	p.annexB = !p.unicode && !o.NoAnnexB
+	if p.err == nil {
+		p.scanGroups()
+		regExp.Pattern = p.parseDisjunction()
+		if p.err == nil && p.pos < p.end {
+			p.fail(p.pos, "unmatched ) in regular expression")
+		}
+		if p.err == nil {
+			p.checkRefs()
+		}
+	}
+	if p.err != nil {
+		return nil, p.err
+	}
+	regExp.Groups = p.groups
+	return regExp, nil
+}
+
+func (p *regExpParser) fail(offset int, msg string, args ...interface{}) {
+	if p.err == nil {
+		p.err = parse.NewError(buffer.NewReader(p.data), offset, fmt.Sprintf(msg, args...))
+	}
+}
+
+func (p *regExpParser) peek(i int) byte {
+	if p.pos+i < p.end {
+		return p.data[p.pos+i]
+	}
+	return 0
+}
+
+func (p *regExpParser) parseFlags() (flags RegExpFlags) {
+	for i := p.end + 1; i < len(p.data); i++ {
+		j := strings.IndexByte(regExpFlagChars, p.data[i])
+		if j == -1 {
+			p.fail(i, "invalid regular expression flag %c", p.data[i])
+			return
+		} else if flags&(1<<j) != 0 {
+			p.fail(i, "duplicate regular expression flag %c", p.data[i])
+			return
+		}
+		flags |= 1 << j
+	}
+	if flags&RegExpUnicode != 0 && flags&RegExpUnicodeSets != 0 {
+		p.fail(p.end+1, "regular expression flags u and v cannot be combined")
+	}
+	p.unicode = flags&(RegExpUnicode|RegExpUnicodeSets) != 0
+	p.sets = flags&RegExpUnicodeSets != 0
+	return
+}
+
+// scanGroups counts the capturing groups before parsing, as a backreference may precede its group and \k is only a named backreference if there are named groups.
+func (p *regExpParser) scanGroups() {
+	class := 0
+	for i := 1; i < p.end; i++ {
+		switch p.data[i] {
+		case '\\':
+			i++
+		case '[':
+			if p.sets {
+				class++
+			} else {
+				class = 1
+			}
+		case ']':
+			if 0 < class {
+				class--
+			}
+		case '(':
+			if class != 0 {
+				break
+			} else if i+1 == p.end || p.data[i+1] != '?' {
+				p.numGroups++
+			} else if i+3 < p.end && p.data[i+2] == '<' && p.data[i+3] != '=' && p.data[i+3] != '!' {
+				p.numGroups++
+				p.namedGroups = true
+			}
+		}
+	}
+	if p.unicode {
+		p.namedGroups = true
+	}
+}
+
+// checkRefs fails for named backreferences to names that do not exist.
+func (p *regExpParser) checkRefs() {
+	for _, ref := range p.refs {
+		found := false
+		for _, name := range p.names {
+			if bytes.Equal(name.name, ref.Name) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			p.fail(ref.Start, "undefined group name %s in regular expression", string(ref.Name))
+			return
+		}
+	}
+}
+
+func (p *regExpParser) parseDisjunction() IRegExpNode {
+	p.level++
+	if 1000 < p.level {
+		p.fail(p.pos, "too many nested groups in regular expression")
+		return nil
+	}
+
+	start := p.pos
+	p.path = append(p.path, regExpAlt{p.disjunctions, 0})
+	p.disjunctions++
+	list := []IRegExpNode{p.parseAlternative()}
+	for p.err == nil && p.peek(0) == '|' {
+		p.pos++
+		p.path[len(p.path)-1].alt++
+		list = append(list, p.parseAlternative())
+	}
+	p.path = p.path[:len(p.path)-1]
+	p.level--
+	if len(list) == 1 {
+		return list[0]
+	}
+	return &RegExpAlt{list, Span{start, p.pos}}
+}
+
+func (p *regExpParser) parseAlternative() IRegExpNode {
+	start := p.pos
+	list := []IRegExpNode{}
+	for p.err == nil && p.pos < p.end && p.data[p.pos] != '|' && p.data[p.pos] != ')' {
+		if term := p.parseTerm(); term != nil {
+			list = append(list, term)
+		}
+	}
+	if len(list) == 1 {
+		return list[0]
+	}
+	return &RegExpSeq{list, Span{start, p.pos}}
+}
+
+func (p *regExpParser) parseTerm() IRegExpNode {
+	start := p.pos
+	var atom IRegExpNode
+	quantifiable := true
+	switch c := p.data[p.pos]; c {
+	case '^', '$':
+		p.pos++
+		return &RegExpAssertion{c, Span{start, p.pos}}
+	case '\\':
+		if c := p.peek(1); c == 'b' || c == 'B' {
+			p.pos += 2
+			return &RegExpAssertion{c, Span{start, p.pos}}
+		}
+		atom = p.parseAtomEscape()
+	case '(':
+		atom, quantifiable = p.parseGroup()
+	case '.':
+		p.pos++
+		atom = &RegExpDot{Span{start, p.pos}}
+	case '[':
+		atom = p.parseClass()
+	case '*', '+', '?':
+		p.fail(start, "nothing to repeat in regular expression")
+		return nil
+	case '{', '}', ']':
+		if _, _, n := p.parseBraces(); 0 < n {
+			p.fail(start, "nothing to repeat in regular expression")
+			return nil
+		} else if !p.annexB {
+			p.fail(start, "lone quantifier bracket in regular expression")
+			return nil
+		}
+		atom = p.parseChar()
+	default:
+		atom = p.parseChar()
+	}
+	if p.err != nil {
+		return nil
+	}
+	return p.parseQuantifier(atom, start, quantifiable)
+}
+
+func (p *regExpParser) parseQuantifier(atom IRegExpNode, start int, quantifiable bool) IRegExpNode {
+	quantStart := p.pos
+	min, max := 0, -1
+	switch p.peek(0) {
+	case '*':
+		p.pos++
+	case '+':
+		min = 1
+		p.pos++
+	case '?':
+		max = 1
+		p.pos++
+	case '{':
+		var n int
+		if min, max, n = p.parseBraces(); n == 0 {
+			if !p.annexB {
+				p.fail(quantStart, "incomplete quantifier in regular expression")
+				return nil
+			}
+			return atom // literal { in Annex B
+		}
+		p.pos += n
+	default:
+		return atom
+	}
+	if !quantifiable {
+		p.fail(quantStart, "nothing to repeat in regular expression")
+		return nil
+	} else if max != -1 && max < min {
+		p.fail(quantStart, "numbers out of order in quantifier in regular expression")
+		return nil
+	}
+	quantifier := &RegExpQuantifier{X: atom, Min: min, Max: max}
+	if p.peek(0) == '?' {
+		quantifier.Lazy = true
+		p.pos++
+	}
+	quantifier.Data = p.data[quantStart:p.pos]
+	quantifier.Span = Span{start, p.pos}
+	return quantifier
+}
+
+// parseBraces parses a {n}, {n,}, or {n,m} quantifier and returns its bounds and length, or zero for the length if it is not a quantifier.
+func (p *regExpParser) parseBraces() (int, int, int) {
+	if p.peek(0) != '{' {
+		return 0, 0, 0
+	}
+	i := 1
+	min, n := p.parseDecimal(p.pos + i)
+	if n == 0 {
+		return 0, 0, 0
+	}
+	i += n
+	max := min
+	if p.peek(i) == ',' {
+		i++
+		max, n = p.parseDecimal(p.pos + i)
+		if n == 0 {
+			max = -1
+		}
+		i += n
+	}
+	if p.peek(i) != '}' {
+		return 0, 0, 0
+	}
+	return min, max, i + 1
+}
+
+// parseDecimal parses the digits at the offset and returns its value, which saturates, and length.
+func (p *regExpParser) parseDecimal(offset int) (int, int) {
+	v, i := 0, offset
+	for i < p.end && '0' <= p.data[i] && p.data[i] <= '9' {
+		if v < 1<<30 {
+			v = v*10 + int(p.data[i]-'0')
+		}
+		i++
+	}
+	return v, i - offset
+}
+
+// parseChar parses a literal character.
+func (p *regExpParser) parseChar() *RegExpChar {
+	start := p.pos
+	r, n := utf8.DecodeRune(p.data[p.pos:p.end])
+	p.pos += n
+	return &RegExpChar{r, p.data[start:p.pos], Span{start, p.pos}}
+}
+
+func (p *regExpParser) parseGroup() (IRegExpNode, bool) {
+	// assume we're at (
+	start := p.pos
+	p.pos++
+	var group *RegExpGroup
+	var lookaround *RegExpLookaround
+	if p.peek(0) != '?' {
+		p.groups++
+		group = &RegExpGroup{Capture: true, Index: p.groups}
+	} else if c := p.peek(1); c == '=' || c == '!' {
+		p.pos += 2
+		lookaround = &RegExpLookaround{Negate: c == '!'}
+	} else if c == '<' && (p.peek(2) == '=' || p.peek(2) == '!') {
+		lookaround = &RegExpLookaround{Behind: true, Negate: p.peek(2) == '!'}
+		p.pos += 3
+	} else if c == '<' {
+		p.pos += 2
+		name := p.parseGroupName()
+		if name == nil {
+			return nil, false
+		}
+		p.groups++
+		group = &RegExpGroup{Capture: true, Index: p.groups, Name: name}
+	} else {
+		p.pos++
+		group = &RegExpGroup{}
+		if !p.parseModifiers(group) {
+			return nil, false
+		}
+	}
+
+	x := p.parseDisjunction()
+	if p.err != nil {
+		return nil, false
+	} else if p.peek(0) != ')' {
+		p.fail(start, "unterminated group in regular expression")
+		return nil, false
+	}
+	p.pos++
+	if lookaround != nil {
+		lookaround.X = x
+		lookaround.Span = Span{start, p.pos}
+		return lookaround, !lookaround.Behind && p.annexB // Annex B allows quantified lookaheads
+	}
+	group.X = x
+	group.Span = Span{start, p.pos}
+	return group, true
+}
+
+// parseModifiers parses the flags of a non-capturing group, as in (?i-m:...), up to and including the colon.
+func (p *regExpParser) parseModifiers(group *RegExpGroup) bool {
+	start := p.pos - 2
+	flags, remove := &group.Add, false
+	for p.pos < p.end && p.data[p.pos] != ':' {
+		c := p.data[p.pos]
+		if c == '-' && !remove {
+			flags, remove = &group.Remove, true
+			p.pos++
+			continue
+		} else if c != 'i' && c != 'm' && c != 's' {
+			p.fail(start, "invalid group in regular expression")
+			return false
+		}
+		flag := RegExpFlags(1 << strings.IndexByte(regExpFlagChars, c))
+		if (group.Add|group.Remove)&flag != 0 {
+			p.fail(p.pos, "duplicate flag %c in regular expression group", c)
+			return false
+		}
+		*flags |= flag
+		p.pos++
+	}
+	if p.pos == p.end || remove && group.Add == 0 && group.Remove == 0 {
+		p.fail(start, "invalid group in regular expression")
+		return false
+	}
+	p.pos++
+	return true
+}
+
+// parseGroupName parses a group name up to and including the closing >, and returns the name with its escape sequences decoded.
+func (p *regExpParser) parseGroupName() []byte {
+	start := p.pos
+	name := []byte{}
+	for p.pos < p.end && p.data[p.pos] != '>' {
+		var r rune
+		if p.data[p.pos] == '\\' {
+			if p.peek(1) != 'u' {
+				p.fail(start, "invalid group name in regular expression")
+				return nil
+			}
+			p.pos++
+			var ok bool
+			if r, ok = p.parseUnicodeEscape(true); !ok {
+				p.fail(start, "invalid group name in regular expression")
+				return nil
+			}
+		} else {
+			var n int
+			r, n = utf8.DecodeRune(p.data[p.pos:p.end])
+			p.pos += n
+		}
+		if len(name) == 0 && r != '$' && r != '_' && !unicode.IsOneOf(identifierStart, r) || len(name) != 0 && r != '$' && r != '\u200C' && r != '\u200D' && !unicode.IsOneOf(identifierContinue, r) {
+			p.fail(start, "invalid group name in regular expression")
+			return nil
+		}
+		name = append(name, string(r)...)
+	}
+	if p.pos == p.end || len(name) == 0 {
+		p.fail(start, "invalid group name in regular expression")
+		return nil
+	}
+	p.pos++
+
+	// duplicate names are only allowed in different alternatives
+	path := append([]regExpAlt{}, p.path...)
+	for _, other := range p.names {
+		if bytes.Equal(other.name, name) && !regExpDisjoint(other.path, path) {
+			p.fail(start, "duplicate group name %s in regular expression", string(name))
+			return nil
+		}
+	}
+	p.names = append(p.names, regExpName{name, path})
+	return name
+}
+
+// regExpDisjoint returns true if the paths go through different alternatives of a disjunction.
+func regExpDisjoint(a, b []regExpAlt) bool {
+	for i := 0; i < len(a) && i < len(b) && a[i].disjunction == b[i].disjunction; i++ {
+		if a[i].alt != b[i].alt {
+			return true
+		}
+	}
+	return false
+}
+
+// parseAtomEscape parses an escape sequence outside of a character class.
+func (p *regExpParser) parseAtomEscape() IRegExpNode {
+	// assume we're at \
+	start := p.pos
+	p.pos++
+	if p.pos == p.end {
+		p.fail(start, "\\ at end of regular expression")
+		return nil
+	}
+	switch c := p.data[p.pos]; c {
+	case '1', '2', '3', '4', '5', '6', '7', '8', '9':
+		index, n := p.parseDecimal(p.pos)
+		if index <= p.numGroups {
+			p.pos += n
+			return &RegExpBackref{Index: index, Span: Span{start, p.pos}}
+		} else if !p.annexB {
+			p.fail(start, "invalid backreference in regular expression")
+			return nil
+		}
+		return p.parseCharEscape(start, false) // Annex B
+	case 'k':
+		if !p.namedGroups {
+			return p.parseCharEscape(start, false) // Annex B
+		} else if p.peek(1) != '<' {
+			p.fail(start, "invalid named backreference in regular expression")
+			return nil
+		}
+		p.pos += 2
+		nameStart := p.pos
+		for p.pos < p.end && p.data[p.pos] != '>' {
+			p.pos++
+		}
+		if p.pos == p.end || p.pos == nameStart {
+			p.fail(start, "invalid named backreference in regular expression")
+			return nil
+		}
+		p.pos++
+		ref := &RegExpBackref{Name: p.data[nameStart : p.pos-1], Span: Span{start, p.pos}}
+		p.refs = append(p.refs, ref)
+		return ref
+	case 'd', 'D', 's', 'S', 'w', 'W':
+		p.pos++
+		return &RegExpClassEscape{c, Span{start, p.pos}}
+	case 'p', 'P':
+		if p.unicode {
+			return p.parseProperty(start)
+		}
+	}
+	return p.parseCharEscape(start, false)
+}
+
+// parseCharEscape parses a character escape sequence, we're after the \ at start.
+func (p *regExpParser) parseCharEscape(start int, inClass bool) *RegExpChar {
+	var r rune
+	switch c := p.data[p.pos]; c {
+	case 'f':
+		r = '\f'
+	case 'n':
+		r = '\n'
+	case 'r':
+		r = '\r'
+	case 't':
+		r = '\t'
+	case 'v':
+		r = '\v'
+	case 'c':
+		if c := p.peek(1); 'a' <= c|0x20 && c|0x20 <= 'z' || inClass && p.annexB && ('0' <= c && c <= '9' || c == '_') {
+			p.pos += 2
+			return &RegExpChar{rune(c % 32), p.data[start:p.pos], Span{start, p.pos}}
+		} else if !p.annexB {
+			p.fail(start, "invalid escape in regular expression")
+			return nil
+		}
+		return &RegExpChar{'\\', p.data[start:p.pos], Span{start, p.pos}} // Annex B, the backslash is a literal and c is the next character
+	case '0', '1', '2', '3', '4', '5', '6', '7':
+		if c == '0' && (p.peek(1) < '0' || '9' < p.peek(1)) {
+			break
+		} else if !p.annexB {
+			p.fail(start, "invalid escape in regular expression")
+			return nil
+		}
+		// Annex B legacy octal escape
+		r = rune(c - '0')
+		p.pos++
+		for i := 0; i < 2 && '0' <= p.peek(0) && p.peek(0) <= '7' && (c <= '3' || i == 0); i++ {
+			r = r*8 + rune(p.peek(0)-'0')
+			p.pos++
+		}
+		return &RegExpChar{r, p.data[start:p.pos], Span{start, p.pos}}
+	case 'x':
+		if hi, lo := hexDigit(p.peek(1)), hexDigit(p.peek(2)); hi != -1 && lo != -1 {
+			p.pos += 3
+			return &RegExpChar{rune(hi<<4 | lo), p.data[start:p.pos], Span{start, p.pos}}
+		} else if !p.annexB {
+			p.fail(start, "invalid escape in regular expression")
+			return nil
+		}
+		r = 'x'
+	case 'u':
+		if r, ok := p.parseUnicodeEscape(p.unicode); ok {
+			return &RegExpChar{r, p.data[start:p.pos], Span{start, p.pos}}
+		} else if !p.annexB {
+			p.fail(start, "invalid unicode escape in regular expression")
+			return nil
+		}
+		r = 'u'
+	default:
+		var n int
+		r, n = utf8.DecodeRune(p.data[p.pos:p.end])
+		if p.unicode && !strings.ContainsRune("^$\\.*+?()[]{}|/", r) && (!inClass || r != '-') || !p.unicode && c == 'k' && p.namedGroups || !p.unicode && !p.annexB && unicode.IsOneOf(identifierContinue, r) {
+			p.fail(start, "invalid escape in regular expression")
+			return nil
+		}
+		p.pos += n
+		return &RegExpChar{r, p.data[start:p.pos], Span{start, p.pos}}
+	}
+	p.pos++
+	return &RegExpChar{r, p.data[start:p.pos], Span{start, p.pos}}
+}
+
+// parseUnicodeEscape parses \uXXXX, and in unicode mode \u{X...} and surrogate pairs of two escapes, we're at the u.
+func (p *regExpParser) parseUnicodeEscape(unicodeMode bool) (rune, bool) {
+	if unicodeMode && p.peek(1) == '{' {
+		var r rune
+		i := 2
+		for ; hexDigit(p.peek(i)) != -1; i++ {
+			if r = r<<4 | rune(hexDigit(p.peek(i))); unicode.MaxRune < r {
+				return 0, false
+			}
+		}
+		if i == 2 || p.peek(i) != '}' {
+			return 0, false
+		}
+		p.pos += i + 1
+		return r, true
+	}
+	r, ok := p.parseHex4(1)
+	if !ok {
+		return 0, false
+	}
+	p.pos += 5
+	if unicodeMode && 0xD800 <= r && r <= 0xDBFF && p.peek(0) == '\\' && p.peek(1) == 'u' {
+		if lo, ok := p.parseHex4(2); ok && 0xDC00 <= lo && lo <= 0xDFFF {
+			r = (r-0xD800)<<10 + (lo - 0xDC00) + 0x10000
+			p.pos += 6
+		}
+	}
+	return r, true
+}
+
+func (p *regExpParser) parseHex4(i int) (rune, bool) {
+	var r rune
+	for j := 0; j < 4; j++ {
+		d := hexDigit(p.peek(i + j))
+		if d == -1 {
+			return 0, false
+		}
+		r = r<<4 | rune(d)
+	}
+	return r, true
+}
+
+func hexDigit(c byte) int {
+	if '0' <= c && c <= '9' {
+		return int(c - '0')
+	} else if 'a' <= c|0x20 && c|0x20 <= 'f' {
+		return int(c|0x20-'a') + 10
+	}
+	return -1
+}
+
+// parseProperty parses a unicode property escape, we're at the p or P after the \ at start.
+func (p *regExpParser) parseProperty(start int) IRegExpNode {
+	property := &RegExpProperty{Negate: p.data[p.pos] == 'P'}
+	if p.peek(1) != '{' {
+		p.fail(start, "invalid property name in regular expression")
+		return nil
+	}
+	p.pos += 2
+	nameStart := p.pos
+	for p.pos < p.end && p.data[p.pos] != '}' {
+		p.pos++
+	}
+	if p.pos == p.end {
+		p.fail(start, "invalid property name in regular expression")
+		return nil
+	}
+	name := p.data[nameStart:p.pos]
+	p.pos++
+
+	valid := false
+	if i := bytes.IndexByte(name, '='); i != -1 {
+		property.Name, property.Value = name[:i], name[i+1:]
+		switch string(property.Name) {
+		case "General_Category", "gc":
+			valid = regExpCategories[string(property.Value)]
+		case "Script", "sc", "Script_Extensions", "scx":
+			valid = isRegExpScript(property.Value)
+		}
+	} else {
+		property.Name = name
+		valid = regExpCategories[string(name)] || regExpBinaryProperties[string(name)] || p.sets && !property.Negate && regExpStringProperties[string(name)]
+	}
+	if !valid {
+		p.fail(start, "invalid property name in regular expression")
+		return nil
+	}
+	property.Span = Span{start, p.pos}
+	return property
+}
+
+// parseClass parses a character class, we're at [.
+func (p *regExpParser) parseClass() IRegExpNode {
+	start := p.pos
+	p.pos++
+	class := &RegExpClass{}
+	if p.peek(0) == '^' {
+		class.Negate = true
+		p.pos++
+	}
+	if p.sets {
+		return p.parseClassSet(start, class)
+	}
+
+	for p.pos < p.end && p.data[p.pos] != ']' {
+		itemStart := p.pos
+		item := p.parseClassAtom()
+		if p.err != nil {
+			return nil
+		} else if p.peek(0) != '-' || p.peek(1) == ']' || p.pos+1 == p.end {
+			class.List = append(class.List, item)
+			continue
+		}
+
+		// range
+		dash := p.parseChar()
+		max := p.parseClassAtom()
+		if p.err != nil {
+			return nil
+		}
+		minChar, ok := item.(*RegExpChar)
+		maxChar, ok2 := max.(*RegExpChar)
+		if !ok || !ok2 {
+			if !p.annexB {
+				p.fail(itemStart, "invalid character class range in regular expression")
+				return nil
+			}
+			class.List = append(class.List, item, dash, max) // Annex B
+			continue
+		}
+		lo, hi := minChar.Value, maxChar.Value
+		if !p.unicode {
+			// without the u flag a character outside the BMP is a surrogate pair, so that the range is between the low surrogate of the minimum and the high surrogate of the maximum
+			if 0xFFFF < lo {
+				_, lo = utf16.EncodeRune(lo)
+			}
+			if 0xFFFF < hi {
+				hi, _ = utf16.EncodeRune(hi)
+			}
+		}
+		if hi < lo {
+			p.fail(itemStart, "range out of order in character class in regular expression")
+			return nil
+		}
+		class.List = append(class.List, &RegExpRange{minChar, maxChar, Span{itemStart, p.pos}})
+	}
+	if p.pos == p.end {
+		p.fail(start, "unterminated character class in regular expression")
+		return nil
+	}
+	p.pos++
+	class.Span = Span{start, p.pos}
+	return class
+}
+
+// parseClassAtom parses a character or class escape in a character class without the v flag.
+func (p *regExpParser) parseClassAtom() IRegExpNode {
+	if p.data[p.pos] != '\\' {
+		return p.parseChar()
+	}
+	start := p.pos
+	p.pos++
+	if p.pos == p.end {
+		p.fail(start, "\\ at end of regular expression")
+		return nil
+	}
+	switch c := p.data[p.pos]; c {
+	case 'b':
+		p.pos++
+		return &RegExpChar{'\b', p.data[start:p.pos], Span{start, p.pos}}
+	case 'd', 'D', 's', 'S', 'w', 'W':
+		p.pos++
+		return &RegExpClassEscape{c, Span{start, p.pos}}
+	case 'p', 'P':
+		if p.unicode {
+			return p.parseProperty(start)
+		}
+	case '8', '9':
+		if p.annexB {
+			p.pos++
+			return &RegExpChar{rune(c), p.data[start:p.pos], Span{start, p.pos}} // Annex B
+		}
+	}
+	return p.parseCharEscape(start, true)
+}
+
+// parseClassSet parses the contents of a character class with the v flag, we're after the [ or [^.
+func (p *regExpParser) parseClassSet(start int, class *RegExpClass) IRegExpNode {
+	p.level++
+	if 1000 < p.level {
+		p.fail(start, "too many nested character classes in regular expression")
+		return nil
+	}
+	for {
+		if p.pos == p.end {
+			p.fail(start, "unterminated character class in regular expression")
+			return nil
+		} else if p.data[p.pos] == ']' {
+			p.pos++
+			break
+		}
+
+		opStart := p.pos
+		op := RegExpUnion
+		if 0 < len(class.List) && p.data[p.pos] == '&' && p.peek(1) == '&' {
+			op = RegExpIntersection
+		} else if 0 < len(class.List) && p.data[p.pos] == '-' && p.peek(1) == '-' {
+			op = RegExpSubtraction
+		}
+		if op != RegExpUnion {
+			if _, isRange := class.List[0].(*RegExpRange); class.Op == RegExpUnion && (1 < len(class.List) || isRange) || class.Op != RegExpUnion && class.Op != op {
+				p.fail(opStart, "invalid set operation in character class in regular expression")
+				return nil
+			}
+			class.Op = op
+			p.pos += 2
+			if op == RegExpIntersection && p.peek(0) == '&' {
+				p.fail(p.pos, "invalid set operation in character class in regular expression")
+				return nil
+			}
+		} else if class.Op != RegExpUnion {
+			p.fail(opStart, "invalid set operation in character class in regular expression")
+			return nil
+		}
+
+		item := p.parseClassSetOperand()
+		if p.err != nil {
+			return nil
+		}
+		if min, ok := item.(*RegExpChar); ok && op == RegExpUnion && class.Op == RegExpUnion && p.peek(0) == '-' && p.peek(1) != '-' {
+			// range
+			p.pos++
+			max, ok := p.parseClassSetOperand().(*RegExpChar)
+			if p.err != nil {
+				return nil
+			} else if !ok {
+				p.fail(opStart, "invalid character class range in regular expression")
+				return nil
+			} else if max.Value < min.Value {
+				p.fail(opStart, "range out of order in character class in regular expression")
+				return nil
+			}
+			item = &RegExpRange{min, max, Span{opStart, p.pos}}
+		}
+		class.List = append(class.List, item)
+	}
+	class.Span = Span{start, p.pos}
+	if class.Negate && regExpMayContainStrings(class.List, class.Op) {
+		p.fail(start, "negated character class may contain strings in regular expression")
+		return nil
+	}
+	p.level--
+	return class
+}
+
+// parseClassSetOperand parses a nested class, a string disjunction, a class escape, or a character in a character class with the v flag.
+func (p *regExpParser) parseClassSetOperand() IRegExpNode {
+	start := p.pos
+	if p.data[p.pos] == '[' {
+		p.pos++
+		class := &RegExpClass{}
+		if p.peek(0) == '^' {
+			class.Negate = true
+			p.pos++
+		}
+		return p.parseClassSet(start, class)
+	} else if p.data[p.pos] == '\\' {
+		switch c := p.peek(1); c {
+		case 'q':
+			return p.parseClassStrings()
+		case 'd', 'D', 's', 'S', 'w', 'W':
+			p.pos += 2
+			return &RegExpClassEscape{c, Span{start, p.pos}}
+		case 'p', 'P':
+			p.pos++
+			return p.parseProperty(start)
+		}
+	}
+	return p.parseClassSetChar()
+}
+
+// parseClassSetChar parses a character in a character class with the v flag.
+func (p *regExpParser) parseClassSetChar() *RegExpChar {
+	start := p.pos
+	c := p.data[p.pos]
+	if c == '\\' {
+		p.pos++
+		if p.pos == p.end {
+			p.fail(start, "\\ at end of regular expression")
+			return nil
+		} else if c := p.data[p.pos]; c == 'b' {
+			p.pos++
+			return &RegExpChar{'\b', p.data[start:p.pos], Span{start, p.pos}}
+		} else if strings.IndexByte("&-!#%,:;<=>@`~", c) != -1 {
+			p.pos++
+			return &RegExpChar{rune(c), p.data[start:p.pos], Span{start, p.pos}}
+		}
+		return p.parseCharEscape(start, true)
+	} else if strings.IndexByte("()[]{}/-\\|", c) != -1 {
+		p.fail(start, "invalid character in character class in regular expression")
+		return nil
+	} else if c == p.peek(1) && strings.IndexByte("&!#$%*+,.:;<=>?@^`~", c) != -1 {
+		p.fail(start, "invalid set operation in character class in regular expression")
+		return nil
+	}
+	return p.parseChar()
+}
+
+// parseClassStrings parses a class string disjunction \q{...}, we're at the \.
+func (p *regExpParser) parseClassStrings() IRegExpNode {
+	start := p.pos
+	if p.peek(2) != '{' {
+		p.fail(start, "invalid escape in regular expression")
+		return nil
+	}
+	p.pos += 3
+	strs := &RegExpStrings{List: [][]rune{{}}}
+	for {
+		if p.pos == p.end {
+			p.fail(start, "unterminated class string disjunction in regular expression")
+			return nil
+		} else if p.data[p.pos] == '}' {
+			p.pos++
+			break
+		} else if p.data[p.pos] == '|' {
+			strs.List = append(strs.List, []rune{})
+			p.pos++
+			continue
+		}
+		char := p.parseClassSetChar()
+		if char == nil {
+			return nil
+		}
+		strs.List[len(strs.List)-1] = append(strs.List[len(strs.List)-1], char.Value)
+	}
+	strs.Data = p.data[start:p.pos]
+	strs.Span = Span{start, p.pos}
+	return strs
+}
+
+// regExpMayContainStrings returns true if the class contents may match strings that are not a single character, which is not allowed for negated classes.
+func regExpMayContainStrings(list []IRegExpNode, op RegExpSetOp) bool {
+	for i, item := range list {
+		strings := false
+		switch item := item.(type) {
+		case *RegExpStrings:
+			for _, str := range item.List {
+				if len(str) != 1 {
+					strings = true
+				}
+			}
+		case *RegExpProperty:
+			strings = regExpStringProperties[string(item.Name)]
+		case *RegExpClass:
+			strings = !item.Negate && regExpMayContainStrings(item.List, item.Op)
+		}
+		if op == RegExpUnion && strings || op == RegExpSubtraction && i == 0 {
+			return strings
+		} else if op == RegExpIntersection && !strings {
+			return false
+		}
+	}
+	return op == RegExpIntersection && 0 < len(list)
+}
+
+////////////////////////////////////////////////////////////////
+
+// isRegExpScript returns true for the names and four-letter aliases of scripts.
+func isRegExpScript(name []byte) bool {
+	if _, ok := unicode.Scripts[string(name)]; ok {
+		return true
+	} else if len(name) != 4 || name[0] < 'A' || 'Z' < name[0] {
+		return false
+	}
+	for _, c := range name[1:] {
+		if c < 'a' || 'z' < c {
+			return false
+		}
+	}
+	return true
+}
+
+// regExpCategories are the values and aliases of the General_Category property.
+var regExpCategories = map[string]bool{
+	"C": true, "Other": true, "Cc": true, "Control": true, "cntrl": true, "Cf": true, "Format": true, "Cn": true, "Unassigned": true, "Co": true, "Private_Use": true, "Cs": true, "Surrogate": true,
+	"L": true, "Letter": true, "LC": true, "Cased_Letter": true, "Ll": true, "Lowercase_Letter": true, "Lm": true, "Modifier_Letter": true, "Lo": true, "Other_Letter": true, "Lt": true, "Titlecase_Letter": true, "Lu": true, "Uppercase_Letter": true,
+	"M": true, "Mark": true, "Combining_Mark": true, "Mc": true, "Spacing_Mark": true, "Me": true, "Enclosing_Mark": true, "Mn": true, "Nonspacing_Mark": true,
+	"N": true, "Number": true, "Nd": true, "Decimal_Number": true, "digit": true, "Nl": true, "Letter_Number": true, "No": true, "Other_Number": true,
+	"P": true, "Punctuation": true, "punct": true, "Pc": true, "Connector_Punctuation": true, "Pd": true, "Dash_Punctuation": true, "Pe": true, "Close_Punctuation": true, "Pf": true, "Final_Punctuation": true, "Pi": true, "Initial_Punctuation": true, "Po": true, "Other_Punctuation": true, "Ps": true, "Open_Punctuation": true,
+	"S": true, "Symbol": true, "Sc": true, "Currency_Symbol": true, "Sk": true, "Modifier_Symbol": true, "Sm": true, "Math_Symbol": true, "So": true, "Other_Symbol": true,
+	"Z": true, "Separator": true, "Zl": true, "Line_Separator": true, "Zp": true, "Paragraph_Separator": true, "Zs": true, "Space_Separator": true,
+}
+
+// regExpBinaryProperties are the names and aliases of the binary unicode properties.
+var regExpBinaryProperties = map[string]bool{
+	"ASCII": true, "ASCII_Hex_Digit": true, "AHex": true, "Alphabetic": true, "Alpha": true, "Any": true, "Assigned": true,
+	"Bidi_Control": true, "Bidi_C": true, "Bidi_Mirrored": true, "Bidi_M": true, "Case_Ignorable": true, "CI": true, "Cased": true,
+	"Changes_When_Casefolded": true, "CWCF": true, "Changes_When_Casemapped": true, "CWCM": true, "Changes_When_Lowercased": true, "CWL": true, "Changes_When_NFKC_Casefolded": true, "CWKCF": true, "Changes_When_Titlecased": true, "CWT": true, "Changes_When_Uppercased": true, "CWU": true,
+	"Dash": true, "Default_Ignorable_Code_Point": true, "DI": true, "Deprecated": true, "Dep": true, "Diacritic": true, "Dia": true,
+	"Emoji": true, "Emoji_Component": true, "EComp": true, "Emoji_Modifier": true, "EMod": true, "Emoji_Modifier_Base": true, "EBase": true, "Emoji_Presentation": true, "EPres": true, "Extended_Pictographic": true, "ExtPict": true, "Extender": true, "Ext": true,
+	"Grapheme_Base": true, "Gr_Base": true, "Grapheme_Extend": true, "Gr_Ext": true, "Hex_Digit": true, "Hex": true,
+	"IDS_Binary_Operator": true, "IDSB": true, "IDS_Trinary_Operator": true, "IDST": true, "ID_Continue": true, "IDC": true, "ID_Start": true, "IDS": true, "Ideographic": true, "Ideo": true,
+	"Join_Control": true, "Join_C": true, "Logical_Order_Exception": true, "LOE": true, "Lowercase": true, "Lower": true, "Math": true, "Noncharacter_Code_Point": true, "NChar": true,
+	"Pattern_Syntax": true, "Pat_Syn": true, "Pattern_White_Space": true, "Pat_WS": true, "Quotation_Mark": true, "QMark": true, "Radical": true, "Regional_Indicator": true, "RI": true,
+	"Sentence_Terminal": true, "STerm": true, "Soft_Dotted": true, "SD": true, "Terminal_Punctuation": true, "Term": true, "Unified_Ideograph": true, "UIdeo": true, "Uppercase": true, "Upper": true,
+	"Variation_Selector": true, "VS": true, "White_Space": true, "space": true, "XID_Continue": true, "XIDC": true, "XID_Start": true, "XIDS": true,
+}
+
+// regExpStringProperties are the properties of strings, which are only allowed with the v flag.
+var regExpStringProperties = map[string]bool{
+	"Basic_Emoji": true, "Emoji_Keycap_Sequence": true, "RGI_Emoji_Modifier_Sequence": true, "RGI_Emoji_Flag_Sequence": true, "RGI_Emoji_Tag_Sequence": true, "RGI_Emoji_ZWJ_Sequence": true, "RGI_Emoji": true,
+}
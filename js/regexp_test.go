@@ -0,0 +1,160 @@
+package js
+
+import (
+	"testing"
+
+	"github.com/tdewolff/parse/v2"
+	"github.com/tdewolff/test"
+)
+
+func TestParseRegExp(t *testing.T) {
+	var tests = []struct {
+		regexp   string
+		expected string
+	}{
+		{"/abc/", "/Seq(a b c)/"},
+		{"/a|b|/gimsyd", "/Alt(a | b | Seq())/dgimsy"},
+		{"/(a)(?:b)(?<n>c)\\1\\k<n>/", "/Seq((a) (?:b) (?<n>c) \\1 \\k<n>)/"},
+		{"/a*b+?c{2,3}d{2}e{2,}?f??/", "/Seq(a* b+? c{2,3} d{2} e{2,}? f??)/"},
+		{"/a{99999999999999999999}b{0,}c{01,2}/", "/Seq(a{99999999999999999999} b{0,} c{01,2})/"},
+		{"/^(?<=a)(?<!b)(?=c)(?!d)\\b\\B$/", "/Seq(^ (?<=a) (?<!b) (?=c) (?!d) \\b \\B $)/"},
+		{"/(?i-m:a)(?s:b)/", "/Seq((?i-m:a) (?s:b))/"},
+		{"/.\\d\\W[a-z\\s\\-]/", "/Seq(. \\d \\W [a-z\\s\\-])/"},
+		{"/[^]|[]]/", "/Alt([^] | Seq([] ]))/"},
+		{"/(?<a>x)|(?<a>y)/", "/Alt((?<a>x) | (?<a>y))/"},
+		{"/\\2(a)/", "/Seq(\\2 (a))/"},
+
+		// Annex B
+		{"/a{|}|]/", "/Alt(Seq(a {) | } | ])/"},
+		{"/\\8\\07\\400\\a\\k/", "/Seq(\\8 \\07 \\40 0 \\a \\k)/"},
+		{"/\\c[\\c1]/", "/Seq(\\ c [\\c1])/"},
+		{"/[\\d-z](?=a)*/", "/Seq([\\d-z] (?=a)*)/"},
+		{"/\\p{L}/", "/Seq(\\p { L })/"},
+		{"/[a-\U0001F600][\\uD83D-\U0001F600]/", "/Seq([a-\U0001F600] [\\uD83D-\U0001F600])/"},
+
+		// unicode
+		{"/[\U0001F600-\U0001F601]/u", "/[\U0001F600-\U0001F601]/u"},
+		{"/\\u{1F600}\\uD83D\\uDE00\\p{L}\\P{Script=Greek}\\p{sc=Latn}/u", "/Seq(\\u{1F600} \\uD83D\\uDE00 \\p{L} \\P{Script=Greek} \\p{sc=Latn})/u"},
+		{"/[\\p{L}--[a-z]][[a-z]&&\\q{a|bc}][\\(\\&]/v", "/Seq([\\p{L}--[a-z]] [[a-z]&&\\q{a|bc}] [\\(\\&])/v"},
+		{"/[^\\q{a|b}]\\p{RGI_Emoji}/v", "/Seq([^\\q{a|b}] \\p{RGI_Emoji})/v"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.regexp, func(t *testing.T) {
+			regExp, err := ParseRegExp([]byte(tt.regexp))
+			test.Error(t, err)
+			test.String(t, regExp.String(), tt.expected)
+		})
+	}
+
+	regExp, _ := ParseRegExp([]byte("/(a)(?<b>(c))/"))
+	test.T(t, regExp.Groups, 3)
+	group := regExp.Pattern.(*RegExpSeq).List[1].(*RegExpGroup)
+	test.T(t, group.Index, 2)
+	test.T(t, group.Span, Span{4, 13})
+	test.T(t, group.X.(*RegExpGroup).Index, 3)
+
+	regExp, _ = ParseRegExp([]byte("/a{99999999999999999999}/"))
+	quantifier := regExp.Pattern.(*RegExpQuantifier)
+	test.T(t, quantifier.Min, quantifier.Max)
+	test.String(t, string(quantifier.Data), "{99999999999999999999}")
+
+	regExp, _ = ParseRegExp([]byte("/[\\x41-\\u{5A}]/u"))
+	r := regExp.Pattern.(*RegExpClass).List[0].(*RegExpRange)
+	test.T(t, r.Min.Value, 'A')
+	test.T(t, r.Max.Value, 'Z')
+}
+
+func TestParseRegExpError(t *testing.T) {
+	var tests = []struct {
+		regexp string
+		err    string
+		offset int
+	}{
+		{"/a/gg", "duplicate regular expression flag g", 4},
+		{"/a/x", "invalid regular expression flag x", 3},
+		{"/a/uv", "regular expression flags u and v cannot be combined", 3},
+		{"/a)/", "unmatched ) in regular expression", 2},
+		{"/(a/", "unterminated group in regular expression", 1},
+		{"/[a/", "unterminated character class in regular expression", 1},
+		{"/*/", "nothing to repeat in regular expression", 1},
+		{"/a**/", "nothing to repeat in regular expression", 3},
+		{"/{1}/", "nothing to repeat in regular expression", 1},
+		{"/(?<=a)*/", "nothing to repeat in regular expression", 7},
+		{"/(?=a)*/u", "nothing to repeat in regular expression", 6},
+		{"/a{2,1}/", "numbers out of order in quantifier in regular expression", 2},
+		{"/a{2/u", "incomplete quantifier in regular expression", 2},
+		{"/}/u", "lone quantifier bracket in regular expression", 1},
+		{"/[b-a]/", "range out of order in character class in regular expression", 2},
+		{"/[\U0001F600-\U0001F601]/", "range out of order in character class in regular expression", 2},
+		{"/[\uFFFF-\U0001F600]/", "range out of order in character class in regular expression", 2},
+		{"/[\\d-z]/u", "invalid character class range in regular expression", 2},
+		{"/(?<a>x)(?<a>y)/", "duplicate group name a in regular expression", 11},
+		{"/(?<1>x)/", "invalid group name in regular expression", 4},
+		{"/\\k<a>(?<b>)/", "undefined group name a in regular expression", 1},
+		{"/(?<b>)\\k/", "invalid named backreference in regular expression", 7},
+		{"/\\2(a)/u", "invalid backreference in regular expression", 1},
+		{"/(?i-i:a)/", "duplicate flag i in regular expression group", 5},
+		{"/(?-:a)/", "invalid group in regular expression", 1},
+		{"/(?x)/", "invalid group in regular expression", 1},
+		{"/\\a/u", "invalid escape in regular expression", 1},
+		{"/\\c/u", "invalid escape in regular expression", 1},
+		{"/\\u{110000}/u", "invalid unicode escape in regular expression", 1},
+		{"/a\\p{Foo}/u", "invalid property name in regular expression", 2},
+		{"/\\p{gc=Greek}/u", "invalid property name in regular expression", 1},
+		{"/\\P{RGI_Emoji}/v", "invalid property name in regular expression", 1},
+		{"/\\p{RGI_Emoji}/u", "invalid property name in regular expression", 1},
+		{"/[(]/v", "invalid character in character class in regular expression", 2},
+		{"/[a&&&b]/v", "invalid set operation in character class in regular expression", 5},
+		{"/[a-z&&b]/v", "invalid set operation in character class in regular expression", 5},
+		{"/[ab&&c]/v", "invalid set operation in character class in regular expression", 4},
+		{"/[a&&b--c]/v", "invalid set operation in character class in regular expression", 6},
+		{"/[a!!b]/v", "invalid set operation in character class in regular expression", 3},
+		{"/[^\\q{ab}]/v", "negated character class may contain strings in regular expression", 1},
+		{"/[^[\\p{RGI_Emoji}--\\q{x}]]/v", "negated character class may contain strings in regular expression", 1},
+		{"a", "invalid regular expression literal", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.regexp, func(t *testing.T) {
+			_, err := ParseRegExp([]byte(tt.regexp))
+			test.That(t, err != nil)
+			if err != nil {
+				test.String(t, err.(*parse.Error).Message, tt.err)
+				test.T(t, err.(*parse.Error).Offset, tt.offset)
+			}
+		})
+	}
+}
+
+func TestParseRegExpNoAnnexB(t *testing.T) {
+	var tests = []struct {
+		regexp string
+		err    string
+		offset int
+	}{
+		{"/{/", "lone quantifier bracket in regular expression", 1},
+		{"/]/", "lone quantifier bracket in regular expression", 1},
+		{"/a{2/", "incomplete quantifier in regular expression", 2},
+		{"/(?=a)*/", "nothing to repeat in regular expression", 6},
+		{"/\\1/", "invalid backreference in regular expression", 1},
+		{"/\\a/", "invalid escape in regular expression", 1},
+		{"/\\01/", "invalid escape in regular expression", 1},
+		{"/\\x4/", "invalid escape in regular expression", 1},
+		{"/[\\c1]/", "invalid escape in regular expression", 2},
+		{"/[\\w-a]/", "invalid character class range in regular expression", 2},
+		{"/\\$\\-(a)\\1/", "", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.regexp, func(t *testing.T) {
+			_, err := ParseRegExpWithOptions([]byte(tt.regexp), RegExpOptions{NoAnnexB: true})
+			if tt.err == "" {
+				test.Error(t, err)
+			} else if test.That(t, err != nil); err != nil {
+				test.String(t, err.(*parse.Error).Message, tt.err)
+				test.T(t, err.(*parse.Error).Offset, tt.offset)
+			}
+
+			_, err = ParseRegExp([]byte(tt.regexp))
+			test.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,694 @@
+package js
+
+// IRewriter represents an AST rewriter, which is like IVisitor but is passed a Cursor that allows to replace, remove, or insert nodes
+// Each INode encountered by `Rewrite` is passed to `Enter`, children nodes will be ignored if the returned IRewriter is nil
+// `Exit` is called upon the exit of a node, after its children have been rewritten
+type IRewriter interface {
+	Enter(c *Cursor) IRewriter
+	Exit(c *Cursor)
+}
+
+// Cursor describes a node encountered by Rewrite and its position in the parent node.
+type Cursor struct {
+	r             *rewriter
+	parent        INode
+	name          string
+	index         int
+	node          INode
+	before, after []INode
+}
+
+// Node returns the current node, which may have been replaced and is nil when it has been removed.
+func (c *Cursor) Node() INode {
+	return c.node
+}
+
+// Parent returns the parent node of the current node, or nil for the root node.
+func (c *Cursor) Parent() INode {
+	return c.parent
+}
+
+// Name returns the name of the field of the parent node that holds the current node, such as Body or List.
+func (c *Cursor) Name() string {
+	return c.name
+}
+
+// Index returns the index of the current node in the list of the parent node, or -1 if it is not in a list.
+func (c *Cursor) Index() int {
+	return c.index
+}
+
+// Scope returns the innermost scope that encloses the current node, or nil if it is not known.
+func (c *Cursor) Scope() *Scope {
+	if len(c.r.scopes) == 0 {
+		return nil
+	}
+	return c.r.scopes[len(c.r.scopes)-1]
+}
+
+// Replace replaces the current node. The node must fit the field of the parent node, such as an IExpr for an expression, and must be of the same type for struct fields and lists of structs, such as FuncDecl.Body or Args.List. When called from Enter, the children of the replacement are rewritten instead.
+func (c *Cursor) Replace(n INode) {
+	if n == nil {
+		panic("js: Replace with nil node, use Delete instead")
+	}
+	c.node = n
+}
+
+// Delete removes the current node from its list. A node that is not in a list is replaced by an EmptyStmt if it is a statement, or otherwise its field is set to nil, which is only valid for optional fields.
+func (c *Cursor) Delete() {
+	c.node = nil
+}
+
+// InsertBefore inserts a node before the current node in its list, the node is not rewritten.
+func (c *Cursor) InsertBefore(n INode) {
+	if c.index < 0 {
+		panic("js: InsertBefore of node that is not in a list")
+	}
+	c.before = append(c.before, n)
+}
+
+// InsertAfter inserts a node after the current node in its list, after nodes inserted previously. The node is not rewritten.
+func (c *Cursor) InsertAfter(n INode) {
+	if c.index < 0 {
+		panic("js: InsertAfter of node that is not in a list")
+	}
+	c.after = append(c.after, n)
+}
+
+// Rewrite traverses an AST in depth-first and source order and allows the IRewriter to modify the AST, and returns the root node or its replacement. Var.Uses is updated for the variables of removed and inserted nodes, and variables that are no longer used are removed from the scopes of the rewritten nodes. New variables must be added to a scope by the caller, and declarations that are moved to another scope keep their scope information.
+func Rewrite(v IRewriter, n INode) INode {
+	if n == nil {
+		return nil
+	}
+
+	r := &rewriter{
+		visited: map[*Scope]bool{},
+		removed: map[*Var]bool{},
+	}
+	nodes, changed := r.rewrite(v, nil, "", -1, n)
+	r.prune()
+	if !changed {
+		return n
+	} else if len(nodes) == 0 {
+		return nil
+	}
+	return nodes[0]
+}
+
+type rewriter struct {
+	scopes  []*Scope
+	visited map[*Scope]bool
+	removed map[*Var]bool // variables with a decreased number of uses
+}
+
+// rewrite rewrites a node and returns the nodes that replace it, if changed.
+func (r *rewriter) rewrite(v IRewriter, parent INode, name string, index int, n INode) ([]INode, bool) {
+	c := &Cursor{r, parent, name, index, n, nil, nil}
+	if w := v.Enter(c); w != nil && c.node != nil {
+		r.children(w, c.node)
+		w.Exit(c)
+	}
+	if c.node == n && len(c.before) == 0 && len(c.after) == 0 {
+		return nil, false
+	}
+
+	nodes := make([]INode, 0, len(c.before)+1+len(c.after))
+	nodes = append(nodes, c.before...)
+	if c.node != nil {
+		nodes = append(nodes, c.node)
+	}
+	nodes = append(nodes, c.after...)
+	for _, node := range nodes {
+		if node != n {
+			r.count(node, 1)
+		}
+	}
+	if c.node != n {
+		r.count(n, -1)
+	}
+	return nodes, true
+}
+
+// count adds delta to the uses of all variables in the node.
+func (r *rewriter) count(n INode, delta int) {
+	if v, ok := n.(*Var); ok {
+		for ; v != nil; v = v.Link {
+			if delta < 0 {
+				if 0 < v.Uses {
+					v.Uses--
+				}
+				r.removed[v.first] = true
+			} else {
+				v.Uses++
+			}
+		}
+		return
+	}
+	for _, child := range Children(n) {
+		r.count(child, delta)
+	}
+}
+
+// prune removes the variables that are no longer used from the visited scopes.
+func (r *rewriter) prune() {
+	if len(r.removed) == 0 {
+		return
+	}
+	for s := range r.visited {
+		s.Declared = r.pruneVars(s.Declared, &s.NumForInit)
+		s.Undeclared = r.pruneVars(s.Undeclared, &s.NumArguments)
+	}
+}
+
+// pruneVars removes the unused variables from the list and updates the offset into the list.
+func (r *rewriter) pruneVars(vs VarArray, offset *uint16) VarArray {
+	j := 0
+	for i, v := range vs {
+		if v.Uses == 0 && r.removed[v] {
+			if i < int(*offset) {
+				(*offset)--
+			}
+			continue
+		}
+		vs[j] = v
+		j++
+	}
+	return vs[:j]
+}
+
+func (r *rewriter) pushScope(s *Scope) {
+	r.scopes = append(r.scopes, s)
+	r.visited[s] = true
+}
+
+func (r *rewriter) popScope() {
+	r.scopes = r.scopes[:len(r.scopes)-1]
+}
+
+func (r *rewriter) expr(v IRewriter, parent INode, name string, x IExpr) IExpr {
+	if x == nil {
+		return nil
+	} else if nodes, changed := r.rewrite(v, parent, name, -1, x); !changed {
+		return x
+	} else if len(nodes) == 0 {
+		return nil
+	} else {
+		return nodes[0].(IExpr)
+	}
+}
+
+func (r *rewriter) stmt(v IRewriter, parent INode, name string, x IStmt) IStmt {
+	if x == nil {
+		return nil
+	} else if nodes, changed := r.rewrite(v, parent, name, -1, x); !changed {
+		return x
+	} else if len(nodes) == 0 {
+		return &EmptyStmt{}
+	} else {
+		return nodes[0].(IStmt)
+	}
+}
+
+func (r *rewriter) binding(v IRewriter, parent INode, name string, x IBinding) IBinding {
+	if x == nil {
+		return nil
+	} else if nodes, changed := r.rewrite(v, parent, name, -1, x); !changed {
+		return x
+	} else if len(nodes) == 0 {
+		return nil
+	} else {
+		return nodes[0].(IBinding)
+	}
+}
+
+func (r *rewriter) block(v IRewriter, parent INode, name string, x *BlockStmt) *BlockStmt {
+	if x == nil {
+		return nil
+	} else if nodes, changed := r.rewrite(v, parent, name, -1, x); !changed {
+		return x
+	} else if len(nodes) == 0 {
+		return nil
+	} else {
+		return nodes[0].(*BlockStmt)
+	}
+}
+
+func (r *rewriter) varRef(v IRewriter, parent INode, name string, x *Var) *Var {
+	if x == nil {
+		return nil
+	} else if nodes, changed := r.rewrite(v, parent, name, -1, x); !changed {
+		return x
+	} else if len(nodes) == 0 {
+		return nil
+	} else {
+		return nodes[0].(*Var)
+	}
+}
+
+func (r *rewriter) propertyName(v IRewriter, parent INode, name string, x *PropertyName) *PropertyName {
+	if x == nil {
+		return nil
+	} else if nodes, changed := r.rewrite(v, parent, name, -1, x); !changed {
+		return x
+	} else if len(nodes) == 0 {
+		return nil
+	} else {
+		return nodes[0].(*PropertyName)
+	}
+}
+
+func (r *rewriter) args(v IRewriter, parent INode, name string, x *Args) *Args {
+	if x == nil {
+		return nil
+	} else if nodes, changed := r.rewrite(v, parent, name, -1, x); !changed {
+		return x
+	} else if len(nodes) == 0 {
+		return nil
+	} else {
+		return nodes[0].(*Args)
+	}
+}
+
+func (r *rewriter) jsxName(v IRewriter, parent INode, name string, x *JSXName) *JSXName {
+	if x == nil {
+		return nil
+	} else if nodes, changed := r.rewrite(v, parent, name, -1, x); !changed {
+		return x
+	} else if len(nodes) == 0 {
+		return nil
+	} else {
+		return nodes[0].(*JSXName)
+	}
+}
+
+// value rewrites a field of a struct type, which cannot be removed.
+func (r *rewriter) value(v IRewriter, parent INode, name string, x INode) {
+	if nodes, changed := r.rewrite(v, parent, name, -1, x); changed {
+		if len(nodes) == 0 {
+			panic("js: Delete of required field " + name)
+		}
+		switch x := x.(type) {
+		case *BlockStmt:
+			*x = *nodes[0].(*BlockStmt)
+		case *Params:
+			*x = *nodes[0].(*Params)
+		case *PropertyName:
+			*x = *nodes[0].(*PropertyName)
+		case *BindingElement:
+			*x = *nodes[0].(*BindingElement)
+		case *LiteralExpr:
+			*x = *nodes[0].(*LiteralExpr)
+		case *Args:
+			*x = *nodes[0].(*Args)
+		}
+	}
+}
+
+// item rewrites the element at index i of a list, where splice replaces the element by the given nodes. It returns the number of nodes that replace the element.
+func (r *rewriter) item(v IRewriter, parent INode, name string, i int, x INode, splice func([]INode)) int {
+	nodes, changed := r.rewrite(v, parent, name, i, x)
+	if !changed {
+		return 1
+	}
+	splice(nodes)
+	return len(nodes)
+}
+
+// children rewrites the child nodes in source order.
+func (r *rewriter) children(v IRewriter, n INode) {
+	switch n := n.(type) {
+	case *AST:
+		r.value(v, n, "BlockStmt", &n.BlockStmt)
+	case *BlockStmt:
+		r.pushScope(&n.Scope)
+		for i := 0; i < len(n.List); {
+			i += r.item(v, n, "List", i, n.List[i], func(nodes []INode) { n.List = spliceStmts(n.List, i, nodes) })
+		}
+		r.popScope()
+	case *ExprStmt:
+		n.Value = r.expr(v, n, "Value", n.Value)
+	case *IfStmt:
+		n.Cond = r.expr(v, n, "Cond", n.Cond)
+		n.Body = r.stmt(v, n, "Body", n.Body)
+		n.Else = r.stmt(v, n, "Else", n.Else)
+	case *DoWhileStmt:
+		n.Body = r.stmt(v, n, "Body", n.Body)
+		n.Cond = r.expr(v, n, "Cond", n.Cond)
+	case *WhileStmt:
+		n.Cond = r.expr(v, n, "Cond", n.Cond)
+		n.Body = r.stmt(v, n, "Body", n.Body)
+	case *ForStmt:
+		if n.Body != nil {
+			r.pushScope(&n.Body.Scope)
+		}
+		n.Init = r.expr(v, n, "Init", n.Init)
+		n.Cond = r.expr(v, n, "Cond", n.Cond)
+		n.Post = r.expr(v, n, "Post", n.Post)
+		if n.Body != nil {
+			r.popScope()
+			n.Body = r.block(v, n, "Body", n.Body)
+		}
+	case *ForInStmt:
+		if n.Body != nil {
+			r.pushScope(&n.Body.Scope)
+		}
+		n.Init = r.expr(v, n, "Init", n.Init)
+		n.Value = r.expr(v, n, "Value", n.Value)
+		if n.Body != nil {
+			r.popScope()
+			n.Body = r.block(v, n, "Body", n.Body)
+		}
+	case *ForOfStmt:
+		if n.Body != nil {
+			r.pushScope(&n.Body.Scope)
+		}
+		n.Init = r.expr(v, n, "Init", n.Init)
+		n.Value = r.expr(v, n, "Value", n.Value)
+		if n.Body != nil {
+			r.popScope()
+			n.Body = r.block(v, n, "Body", n.Body)
+		}
+	case *CaseClause:
+		n.Cond = r.expr(v, n, "Cond", n.Cond)
+		for i := 0; i < len(n.List); {
+			i += r.item(v, n, "List", i, n.List[i], func(nodes []INode) { n.List = spliceStmts(n.List, i, nodes) })
+		}
+	case *SwitchStmt:
+		n.Init = r.expr(v, n, "Init", n.Init)
+		r.pushScope(&n.Scope)
+		for i := 0; i < len(n.List); {
+			i += r.item(v, n, "List", i, &n.List[i], func(nodes []INode) { n.List = spliceCaseClauses(n.List, i, nodes) })
+		}
+		r.popScope()
+	case *ReturnStmt:
+		n.Value = r.expr(v, n, "Value", n.Value)
+	case *WithStmt:
+		n.Cond = r.expr(v, n, "Cond", n.Cond)
+		n.Body = r.stmt(v, n, "Body", n.Body)
+	case *LabelledStmt:
+		n.Value = r.stmt(v, n, "Value", n.Value)
+	case *ThrowStmt:
+		n.Value = r.expr(v, n, "Value", n.Value)
+	case *TryStmt:
+		if n.Body != nil {
+			n.Body = r.block(v, n, "Body", n.Body)
+		}
+		if n.Catch != nil {
+			r.pushScope(&n.Catch.Scope)
+			n.Binding = r.binding(v, n, "Binding", n.Binding)
+			r.popScope()
+			n.Catch = r.block(v, n, "Catch", n.Catch)
+		} else {
+			n.Binding = r.binding(v, n, "Binding", n.Binding)
+		}
+		if n.Finally != nil {
+			n.Finally = r.block(v, n, "Finally", n.Finally)
+		}
+	case *ImportStmt:
+		for i := 0; i < len(n.List); {
+			i += r.item(v, n, "List", i, &n.List[i], func(nodes []INode) { n.List = spliceAliases(n.List, i, nodes) })
+		}
+		for i := 0; i < len(n.Attributes); {
+			i += r.item(v, n, "Attributes", i, &n.Attributes[i], func(nodes []INode) { n.Attributes = spliceImportAttributes(n.Attributes, i, nodes) })
+		}
+	case *ExportStmt:
+		for i := 0; i < len(n.List); {
+			i += r.item(v, n, "List", i, &n.List[i], func(nodes []INode) { n.List = spliceAliases(n.List, i, nodes) })
+		}
+		n.Decl = r.expr(v, n, "Decl", n.Decl)
+		for i := 0; i < len(n.Attributes); {
+			i += r.item(v, n, "Attributes", i, &n.Attributes[i], func(nodes []INode) { n.Attributes = spliceImportAttributes(n.Attributes, i, nodes) })
+		}
+	case *PropertyName:
+		if n.Computed != nil {
+			n.Computed = r.expr(v, n, "Computed", n.Computed)
+		} else {
+			r.value(v, n, "Literal", &n.Literal)
+		}
+	case *BindingArray:
+		for i := 0; i < len(n.List); {
+			i += r.item(v, n, "List", i, &n.List[i], func(nodes []INode) { n.List = spliceBindingElements(n.List, i, nodes) })
+		}
+		n.Rest = r.binding(v, n, "Rest", n.Rest)
+	case *BindingObjectItem:
+		if n.Key != nil {
+			n.Key = r.propertyName(v, n, "Key", n.Key)
+		}
+		r.value(v, n, "Value", &n.Value)
+	case *BindingObject:
+		for i := 0; i < len(n.List); {
+			i += r.item(v, n, "List", i, &n.List[i], func(nodes []INode) { n.List = spliceBindingObjectItems(n.List, i, nodes) })
+		}
+		if n.Rest != nil {
+			n.Rest = r.varRef(v, n, "Rest", n.Rest)
+		}
+	case *BindingElement:
+		n.Binding = r.binding(v, n, "Binding", n.Binding)
+		n.Default = r.expr(v, n, "Default", n.Default)
+	case *VarDecl:
+		for i := 0; i < len(n.List); {
+			i += r.item(v, n, "List", i, &n.List[i], func(nodes []INode) { n.List = spliceBindingElements(n.List, i, nodes) })
+		}
+	case *Params:
+		for i := 0; i < len(n.List); {
+			i += r.item(v, n, "List", i, &n.List[i], func(nodes []INode) { n.List = spliceBindingElements(n.List, i, nodes) })
+		}
+		n.Rest = r.binding(v, n, "Rest", n.Rest)
+	case *FuncDecl:
+		if n.Name != nil {
+			n.Name = r.varRef(v, n, "Name", n.Name)
+		}
+		r.pushScope(&n.Body.Scope)
+		r.value(v, n, "Params", &n.Params)
+		r.popScope()
+		r.value(v, n, "Body", &n.Body)
+	case *MethodDecl:
+		for i := 0; i < len(n.Decorators); {
+			i += r.item(v, n, "Decorators", i, n.Decorators[i], func(nodes []INode) { n.Decorators = spliceExprs(n.Decorators, i, nodes) })
+		}
+		r.value(v, n, "Name", &n.Name)
+		r.pushScope(&n.Body.Scope)
+		r.value(v, n, "Params", &n.Params)
+		r.popScope()
+		r.value(v, n, "Body", &n.Body)
+	case *FieldDefinition:
+		for i := 0; i < len(n.Decorators); {
+			i += r.item(v, n, "Decorators", i, n.Decorators[i], func(nodes []INode) { n.Decorators = spliceExprs(n.Decorators, i, nodes) })
+		}
+		if n.Body != nil {
+			n.Body = r.block(v, n, "Body", n.Body)
+		} else {
+			r.value(v, n, "Name", &n.Name)
+			n.Init = r.expr(v, n, "Init", n.Init)
+		}
+	case *ClassDecl:
+		for i := 0; i < len(n.Decorators); {
+			i += r.item(v, n, "Decorators", i, n.Decorators[i], func(nodes []INode) { n.Decorators = spliceExprs(n.Decorators, i, nodes) })
+		}
+		if n.Name != nil {
+			n.Name = r.varRef(v, n, "Name", n.Name)
+		}
+		n.Extends = r.expr(v, n, "Extends", n.Extends)
+
+		// definitions and methods are interleaved in source order
+		i, j := 0, 0
+		for i < len(n.Definitions) || j < len(n.Methods) {
+			if j == len(n.Methods) || i < len(n.Definitions) && n.Definitions[i].Start < n.Methods[j].Start {
+				i += r.item(v, n, "Definitions", i, &n.Definitions[i], func(nodes []INode) { n.Definitions = spliceFieldDefinitions(n.Definitions, i, nodes) })
+			} else {
+				j += r.item(v, n, "Methods", j, n.Methods[j], func(nodes []INode) { n.Methods = spliceMethodDecls(n.Methods, j, nodes) })
+			}
+		}
+	case *Element:
+		n.Value = r.expr(v, n, "Value", n.Value)
+	case *ArrayExpr:
+		for i := 0; i < len(n.List); {
+			i += r.item(v, n, "List", i, &n.List[i], func(nodes []INode) { n.List = spliceElements(n.List, i, nodes) })
+		}
+	case *Property:
+		if n.Name != nil {
+			n.Name = r.propertyName(v, n, "Name", n.Name)
+		}
+		n.Value = r.expr(v, n, "Value", n.Value)
+		n.Init = r.expr(v, n, "Init", n.Init)
+	case *ObjectExpr:
+		for i := 0; i < len(n.List); {
+			i += r.item(v, n, "List", i, &n.List[i], func(nodes []INode) { n.List = spliceProperties(n.List, i, nodes) })
+		}
+	case *TemplatePart:
+		n.Expr = r.expr(v, n, "Expr", n.Expr)
+	case *TemplateExpr:
+		n.Tag = r.expr(v, n, "Tag", n.Tag)
+		for i := 0; i < len(n.List); {
+			i += r.item(v, n, "List", i, &n.List[i], func(nodes []INode) { n.List = spliceTemplateParts(n.List, i, nodes) })
+		}
+	case *GroupExpr:
+		n.X = r.expr(v, n, "X", n.X)
+	case *IndexExpr:
+		n.X = r.expr(v, n, "X", n.X)
+		n.Y = r.expr(v, n, "Y", n.Y)
+	case *DotExpr:
+		n.X = r.expr(v, n, "X", n.X)
+		r.value(v, n, "Y", &n.Y)
+	case *Arg:
+		n.Value = r.expr(v, n, "Value", n.Value)
+	case *Args:
+		for i := 0; i < len(n.List); {
+			i += r.item(v, n, "List", i, &n.List[i], func(nodes []INode) { n.List = spliceArgs(n.List, i, nodes) })
+		}
+	case *NewExpr:
+		n.X = r.expr(v, n, "X", n.X)
+		if n.Args != nil {
+			n.Args = r.args(v, n, "Args", n.Args)
+		}
+	case *CallExpr:
+		n.X = r.expr(v, n, "X", n.X)
+		r.value(v, n, "Args", &n.Args)
+	case *OptChainExpr:
+		n.X = r.expr(v, n, "X", n.X)
+		n.Y = r.expr(v, n, "Y", n.Y)
+	case *UnaryExpr:
+		n.X = r.expr(v, n, "X", n.X)
+	case *BinaryExpr:
+		n.X = r.expr(v, n, "X", n.X)
+		n.Y = r.expr(v, n, "Y", n.Y)
+	case *CondExpr:
+		n.Cond = r.expr(v, n, "Cond", n.Cond)
+		n.X = r.expr(v, n, "X", n.X)
+		n.Y = r.expr(v, n, "Y", n.Y)
+	case *YieldExpr:
+		n.X = r.expr(v, n, "X", n.X)
+	case *ArrowFunc:
+		r.pushScope(&n.Body.Scope)
+		r.value(v, n, "Params", &n.Params)
+		r.popScope()
+		r.value(v, n, "Body", &n.Body)
+	case *JSXElement:
+		n.Name = r.expr(v, n, "Name", n.Name)
+		for i := 0; i < len(n.Attrs); {
+			i += r.item(v, n, "Attrs", i, &n.Attrs[i], func(nodes []INode) { n.Attrs = spliceJSXAttributes(n.Attrs, i, nodes) })
+		}
+		for i := 0; i < len(n.Children); {
+			i += r.item(v, n, "Children", i, n.Children[i], func(nodes []INode) { n.Children = spliceExprs(n.Children, i, nodes) })
+		}
+	case *JSXAttribute:
+		if n.Name != nil {
+			n.Name = r.jsxName(v, n, "Name", n.Name)
+		}
+		n.Value = r.expr(v, n, "Value", n.Value)
+	case *JSXExprContainer:
+		n.X = r.expr(v, n, "X", n.X)
+	}
+}
+
+func spliceStmts(list []IStmt, i int, nodes []INode) []IStmt {
+	stmts := append(make([]IStmt, 0, len(list)+len(nodes)-1), list[:i]...)
+	for _, node := range nodes {
+		stmts = append(stmts, node.(IStmt))
+	}
+	return append(stmts, list[i+1:]...)
+}
+
+func spliceAliases(list []Alias, i int, nodes []INode) []Alias {
+	aliases := append(make([]Alias, 0, len(list)+len(nodes)-1), list[:i]...)
+	for _, node := range nodes {
+		aliases = append(aliases, *node.(*Alias))
+	}
+	return append(aliases, list[i+1:]...)
+}
+
+func spliceImportAttributes(list []ImportAttribute, i int, nodes []INode) []ImportAttribute {
+	items := append(make([]ImportAttribute, 0, len(list)+len(nodes)-1), list[:i]...)
+	for _, node := range nodes {
+		items = append(items, *node.(*ImportAttribute))
+	}
+	return append(items, list[i+1:]...)
+}
+
+func spliceBindingElements(list []BindingElement, i int, nodes []INode) []BindingElement {
+	elements := append(make([]BindingElement, 0, len(list)+len(nodes)-1), list[:i]...)
+	for _, node := range nodes {
+		elements = append(elements, *node.(*BindingElement))
+	}
+	return append(elements, list[i+1:]...)
+}
+
+func spliceCaseClauses(list []CaseClause, i int, nodes []INode) []CaseClause {
+	items := append(make([]CaseClause, 0, len(list)+len(nodes)-1), list[:i]...)
+	for _, node := range nodes {
+		items = append(items, *node.(*CaseClause))
+	}
+	return append(items, list[i+1:]...)
+}
+
+func spliceBindingObjectItems(list []BindingObjectItem, i int, nodes []INode) []BindingObjectItem {
+	items := append(make([]BindingObjectItem, 0, len(list)+len(nodes)-1), list[:i]...)
+	for _, node := range nodes {
+		items = append(items, *node.(*BindingObjectItem))
+	}
+	return append(items, list[i+1:]...)
+}
+
+func spliceFieldDefinitions(list []FieldDefinition, i int, nodes []INode) []FieldDefinition {
+	items := append(make([]FieldDefinition, 0, len(list)+len(nodes)-1), list[:i]...)
+	for _, node := range nodes {
+		items = append(items, *node.(*FieldDefinition))
+	}
+	return append(items, list[i+1:]...)
+}
+
+func spliceMethodDecls(list []*MethodDecl, i int, nodes []INode) []*MethodDecl {
+	items := append(make([]*MethodDecl, 0, len(list)+len(nodes)-1), list[:i]...)
+	for _, node := range nodes {
+		items = append(items, node.(*MethodDecl))
+	}
+	return append(items, list[i+1:]...)
+}
+
+func spliceElements(list []Element, i int, nodes []INode) []Element {
+	items := append(make([]Element, 0, len(list)+len(nodes)-1), list[:i]...)
+	for _, node := range nodes {
+		items = append(items, *node.(*Element))
+	}
+	return append(items, list[i+1:]...)
+}
+
+func spliceProperties(list []Property, i int, nodes []INode) []Property {
+	items := append(make([]Property, 0, len(list)+len(nodes)-1), list[:i]...)
+	for _, node := range nodes {
+		items = append(items, *node.(*Property))
+	}
+	return append(items, list[i+1:]...)
+}
+
+func spliceTemplateParts(list []TemplatePart, i int, nodes []INode) []TemplatePart {
+	items := append(make([]TemplatePart, 0, len(list)+len(nodes)-1), list[:i]...)
+	for _, node := range nodes {
+		items = append(items, *node.(*TemplatePart))
+	}
+	return append(items, list[i+1:]...)
+}
+
+func spliceArgs(list []Arg, i int, nodes []INode) []Arg {
+	items := append(make([]Arg, 0, len(list)+len(nodes)-1), list[:i]...)
+	for _, node := range nodes {
+		items = append(items, *node.(*Arg))
+	}
+	return append(items, list[i+1:]...)
+}
+
+func spliceJSXAttributes(list []JSXAttribute, i int, nodes []INode) []JSXAttribute {
+	items := append(make([]JSXAttribute, 0, len(list)+len(nodes)-1), list[:i]...)
+	for _, node := range nodes {
+		items = append(items, *node.(*JSXAttribute))
+	}
+	return append(items, list[i+1:]...)
+}
+
+func spliceExprs(list []IExpr, i int, nodes []INode) []IExpr {
+	items := append(make([]IExpr, 0, len(list)+len(nodes)-1), list[:i]...)
+	for _, node := range nodes {
+		items = append(items, node.(IExpr))
+	}
+	return append(items, list[i+1:]...)
+}
@@ -0,0 +1,177 @@
+package js
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/tdewolff/parse/v2"
+	"github.com/tdewolff/test"
+)
+
+type funcRewriter struct {
+	enter, exit func(*Cursor)
+}
+
+func (r funcRewriter) Enter(c *Cursor) IRewriter {
+	if r.enter != nil {
+		r.enter(c)
+	}
+	return r
+}
+
+func (r funcRewriter) Exit(c *Cursor) {
+	if r.exit != nil {
+		r.exit(c)
+	}
+}
+
+func isVar(n INode, name string) bool {
+	v, ok := n.(*Var)
+	return ok && string(v.Data) == name
+}
+
+func TestRewrite(t *testing.T) {
+	var tests = []struct {
+		js       string
+		exit     func(*Cursor)
+		expected string
+	}{
+		{"a + b", func(c *Cursor) {
+			if isVar(c.Node(), "a") {
+				c.Replace(&LiteralExpr{NumericToken, []byte("1"), Span{}})
+			}
+		}, "1 + b; "},
+		{"(a) * (b + c)", func(c *Cursor) {
+			if group, ok := c.Node().(*GroupExpr); ok {
+				if _, ok := group.X.(*Var); ok {
+					c.Replace(group.X)
+				}
+			}
+		}, "a * (b + c); "},
+		{"a; debugger; b", func(c *Cursor) {
+			if _, ok := c.Node().(*DebuggerStmt); ok {
+				c.Delete()
+			}
+		}, "a; b; "},
+		{"if (a) debugger", func(c *Cursor) {
+			if _, ok := c.Node().(*DebuggerStmt); ok {
+				c.Delete()
+			}
+		}, "if (a) { ; }; "},
+		{"a; b", func(c *Cursor) {
+			if stmt, ok := c.Node().(*ExprStmt); ok && isVar(stmt.Value, "a") {
+				c.InsertBefore(&DebuggerStmt{})
+				c.InsertAfter(&ExprStmt{Value: &LiteralExpr{NumericToken, []byte("1"), Span{}}})
+				c.InsertAfter(&ExprStmt{Value: &LiteralExpr{NumericToken, []byte("2"), Span{}}})
+			}
+		}, "debugger; a; 1; 2; b; "},
+		{"switch (a) { case 1: b; case 2: c }", func(c *Cursor) {
+			if clause, ok := c.Node().(*CaseClause); ok && len(clause.List) == 1 {
+				if stmt := clause.List[0].(*ExprStmt); isVar(stmt.Value, "b") {
+					c.Delete()
+				}
+			}
+		}, "switch (a) { case 2: c; }; "},
+		{"f(a, b, c)", func(c *Cursor) {
+			if arg, ok := c.Node().(*Arg); ok && isVar(arg.Value, "b") {
+				c.Delete()
+			}
+		}, "f(a, c); "},
+		{"var [a, b] = c", func(c *Cursor) {
+			if element, ok := c.Node().(*BindingElement); ok && isVar(element.Binding, "a") {
+				c.InsertAfter(&BindingElement{Binding: element.Binding, Default: &LiteralExpr{NumericToken, []byte("1"), Span{}}})
+				c.Delete()
+			}
+		}, "var [a = 1,b] = c; "},
+		{"class A { a = 1; b() {} c = 2 }", func(c *Cursor) {
+			if _, ok := c.Node().(*MethodDecl); ok {
+				c.Delete()
+			}
+		}, "class A { a = 1; c = 2; }; "},
+		{"function f(a) { return a }", func(c *Cursor) {
+			if ret, ok := c.Node().(*ReturnStmt); ok {
+				c.Replace(&ThrowStmt{Value: ret.Value})
+			}
+		}, "function f (a) { throw a; }; "},
+	}
+	for _, tt := range tests {
+		t.Run(tt.js, func(t *testing.T) {
+			ast, err := Parse(parse.NewInputString(tt.js))
+			if err != nil {
+				t.Fatal(err)
+			}
+			Rewrite(funcRewriter{exit: tt.exit}, ast)
+			test.String(t, ast.JS(), tt.expected)
+		})
+	}
+}
+
+func TestRewriteCursor(t *testing.T) {
+	ast, err := Parse(parse.NewInputString("if (a) b; else c(d, e)"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	visited := []string{}
+	Rewrite(funcRewriter{enter: func(c *Cursor) {
+		if v, ok := c.Node().(*Var); ok {
+			visited = append(visited, fmt.Sprintf("%s:%T.%s[%d]", v.Data, c.Parent(), c.Name(), c.Index()))
+		}
+	}}, ast)
+	test.T(t, visited, []string{"a:*js.IfStmt.Cond[-1]", "b:*js.ExprStmt.Value[-1]", "c:*js.CallExpr.X[-1]", "d:*js.Arg.Value[-1]", "e:*js.Arg.Value[-1]"})
+
+	// skip children and rewrite replacements from Enter
+	ast, err = Parse(parse.NewInputString("a(b); c(d)"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	Rewrite(funcRewriter{enter: func(c *Cursor) {
+		if isVar(c.Node(), "d") {
+			c.Replace(NewVar([]byte("e"), NoDecl))
+		} else if call, ok := c.Node().(*CallExpr); ok && isVar(call.X, "a") && c.Name() == "Value" {
+			c.Replace(&UnaryExpr{Op: VoidToken, X: call})
+		}
+	}}, ast)
+	test.String(t, ast.JS(), "void a(b); c(e); ")
+}
+
+func TestRewriteScope(t *testing.T) {
+	ast, err := Parse(parse.NewInputString("var a = b; a; function f(c) { a; return c }"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var scope *Scope
+	a := ast.BlockStmt.Scope.Declared[0]
+	test.T(t, a.Uses, uint16(3))
+
+	// remove a from the function
+	Rewrite(funcRewriter{exit: func(c *Cursor) {
+		if stmt, ok := c.Node().(*ExprStmt); ok && isVar(stmt.Value, "a") {
+			if _, ok := c.Parent().(*BlockStmt); ok && c.Parent() != &ast.BlockStmt {
+				scope = c.Scope()
+				c.Delete()
+			}
+		}
+	}}, ast)
+	test.T(t, a.Uses, uint16(2))
+	test.T(t, len(scope.Undeclared), 1) // a is still used in the parent scope
+
+	// remove all uses of a
+	Rewrite(funcRewriter{exit: func(c *Cursor) {
+		switch n := c.Node().(type) {
+		case *VarDecl:
+			c.Delete()
+		case *ExprStmt:
+			if isVar(n.Value, "a") {
+				c.Delete()
+			}
+		}
+	}}, ast)
+	test.String(t, ast.JS(), "function f (c) { return c; }; ")
+	test.T(t, a.Uses, uint16(0))
+	test.T(t, len(scope.Undeclared), 0)
+	test.String(t, ast.BlockStmt.Scope.Declared.String(), "[Var{FunctionDecl f 0 1}]")
+	test.String(t, ast.BlockStmt.Scope.Undeclared.String(), "[]")
+}
@@ -0,0 +1,437 @@
+package js
+
+import (
+	"bytes"
+	"fmt"
+	"unicode/utf8"
+)
+
+// Reference is an occurrence of a variable in the AST.
+type Reference struct {
+	*Path          // position of the Var in the AST
+	Scope   *Scope // innermost scope that contains the occurrence
+	Binding bool   // occurrence is a binding in a declaration, parameter, or catch clause
+}
+
+// Var returns the variable of the occurrence as found in the AST, which may be linked to the variable it resolves to.
+func (r Reference) Var() *Var {
+	return r.Node.(*Var)
+}
+
+// Offsets returns the offsets of the occurrence.
+func (r Reference) Offsets() (int, int) {
+	return r.Var().Offsets()
+}
+
+// ScopeInfo is the scope analysis of an AST, which resolves the occurrences of identifiers to their variables and declaring scopes.
+type ScopeInfo struct {
+	decl   map[*Var]*Scope
+	refs   map[*Var][]Reference
+	order  []*Var // resolved variables in order of their first occurrence
+	scopes []*Scope
+}
+
+// NewScopeInfo analyzes the scopes and variable occurrences of the AST. It must be created again after modifying the AST.
+func NewScopeInfo(ast *AST) *ScopeInfo {
+	info := &ScopeInfo{
+		decl: map[*Var]*Scope{},
+		refs: map[*Var][]Reference{},
+	}
+	WalkPath(&scopeVisitor{info: info}, ast)
+	return info
+}
+
+type scopeVisitor struct {
+	info   *ScopeInfo
+	scopes []*Scope
+	pushed []INode
+}
+
+func (v *scopeVisitor) Enter(p *Path) IPathVisitor {
+	if s := pathScope(p); s != nil {
+		v.info.addScope(s)
+		v.scopes = append(v.scopes, s)
+		v.pushed = append(v.pushed, p.Node)
+	}
+	if _, ok := p.Node.(*Var); ok {
+		var s *Scope
+		if 0 < len(v.scopes) {
+			s = v.scopes[len(v.scopes)-1]
+		}
+		v.info.addReference(Reference{p, s, isBinding(p)})
+	}
+	return v
+}
+
+func (v *scopeVisitor) Exit(p *Path) {
+	if 0 < len(v.pushed) && v.pushed[len(v.pushed)-1] == p.Node {
+		v.scopes = v.scopes[:len(v.scopes)-1]
+		v.pushed = v.pushed[:len(v.pushed)-1]
+	}
+}
+
+// pathScope returns the scope that the node introduces for itself and its children, which is not always the scope of the node itself such as for function parameters.
+func pathScope(p *Path) *Scope {
+	switch n := p.Node.(type) {
+	case *BlockStmt:
+		return &n.Scope
+	case *Params:
+		if p.Parent != nil {
+			switch parent := p.Parent.Node.(type) {
+			case *FuncDecl:
+				return &parent.Body.Scope
+			case *MethodDecl:
+				return &parent.Body.Scope
+			case *ArrowFunc:
+				return &parent.Body.Scope
+			}
+		}
+	}
+	if p.Parent == nil {
+		return nil
+	}
+	switch parent := p.Parent.Node.(type) {
+	case *SwitchStmt:
+		if p.Name == "List" {
+			return &parent.Scope
+		}
+	case *TryStmt:
+		if p.Name == "Binding" && parent.Catch != nil {
+			return &parent.Catch.Scope
+		}
+	case *ForStmt:
+		if p.Name != "Body" && parent.Body != nil {
+			return &parent.Body.Scope
+		}
+	case *ForInStmt:
+		if p.Name != "Body" && parent.Body != nil {
+			return &parent.Body.Scope
+		}
+	case *ForOfStmt:
+		if p.Name != "Body" && parent.Body != nil {
+			return &parent.Body.Scope
+		}
+	}
+	return nil
+}
+
+// Scope returns the innermost scope that contains the node, which is the scope of the AST for top-level nodes. Nodes that introduce a scope, such as a BlockStmt or the Params of a function, are contained in their own scope. It returns nil if there is no scope.
+func (p *Path) Scope() *Scope {
+	for ; p != nil; p = p.Parent {
+		if s := pathScope(p); s != nil {
+			return s
+		} else if ast, ok := p.Node.(*AST); ok {
+			return &ast.BlockStmt.Scope
+		}
+	}
+	return nil
+}
+
+// isBinding returns true if the Var at the path is a binding identifier.
+func isBinding(p *Path) bool {
+	if p.Parent == nil {
+		return false
+	}
+	switch p.Parent.Node.(type) {
+	case *BindingElement:
+		return p.Name == "Binding"
+	case *BindingArray, *BindingObject, *Params:
+		return p.Name == "Rest"
+	case *FuncDecl, *ClassDecl:
+		return p.Name == "Name"
+	case *TryStmt:
+		return p.Name == "Binding"
+	}
+	return false
+}
+
+func (info *ScopeInfo) addScope(s *Scope) {
+	for _, scope := range info.scopes {
+		if scope == s {
+			return
+		}
+	}
+	for _, v := range s.Declared {
+		if _, ok := info.decl[v]; !ok {
+			info.decl[v] = s
+		}
+	}
+	info.scopes = append(info.scopes, s)
+}
+
+func (info *ScopeInfo) addReference(ref Reference) {
+	v := resolveVar(ref.Var())
+	if _, ok := info.refs[v]; !ok {
+		info.order = append(info.order, v)
+	}
+	info.refs[v] = append(info.refs[v], ref)
+}
+
+// resolveVar follows the links of a variable to the variable it resolves to, and returns its first occurrence.
+func resolveVar(v *Var) *Var {
+	for v.Link != nil {
+		v = v.Link
+	}
+	return v.first
+}
+
+// Declaration returns the variable that an occurrence of a variable resolves to, and the scope that declares it. The scope is nil for undeclared variables, which are implicit globals.
+func (info *ScopeInfo) Declaration(v *Var) (*Var, *Scope) {
+	v = resolveVar(v)
+	return v, info.decl[v]
+}
+
+// References returns all occurrences of the variable that the given variable resolves to, in source order.
+func (info *ScopeInfo) References(v *Var) []Reference {
+	return info.refs[resolveVar(v)]
+}
+
+// Vars returns all variables that occur in the AST, in order of their first occurrence.
+func (info *ScopeInfo) Vars() []*Var {
+	return info.order
+}
+
+// FreeVars returns the variables that are used in the function, method, or arrow function but that are declared outside of it or are undeclared, in order of their first occurrence. This excludes the name of a function declaration and the implicit arguments variable.
+func (info *ScopeInfo) FreeVars(fn INode) []*Var {
+	var fs *Scope
+	switch fn := fn.(type) {
+	case *FuncDecl:
+		fs = &fn.Body.Scope
+	case *MethodDecl:
+		fs = &fn.Body.Scope
+	case *ArrowFunc:
+		fs = &fn.Body.Scope
+	default:
+		return nil
+	}
+
+	free := []*Var{}
+	seen := map[*Var]bool{}
+	var visit func(INode, bool)
+	visit = func(n INode, arguments bool) {
+		switch n := n.(type) {
+		case *Var:
+			v, s := info.Declaration(n)
+			if !seen[v] && (s == nil || !withinScope(s, fs)) && (s != nil || !arguments || string(v.Data) != "arguments") {
+				seen[v] = true
+				free = append(free, v)
+			}
+			return
+		case *FuncDecl, *MethodDecl:
+			arguments = true
+		}
+		for _, child := range Children(n) {
+			visit(child, arguments)
+		}
+	}
+
+	_, arrow := fn.(*ArrowFunc)
+	if funcDecl, ok := fn.(*FuncDecl); ok && funcDecl.Name != nil {
+		if _, s := info.Declaration(funcDecl.Name); s != fs {
+			seen[resolveVar(funcDecl.Name)] = true // function declaration name is declared in the parent scope
+		}
+	}
+	for _, child := range Children(fn) {
+		visit(child, !arrow)
+	}
+	return free
+}
+
+// Shadows returns the variable of an outer scope that the declared variable shadows, which may be an undeclared global variable, or nil if it shadows no variable.
+func (info *ScopeInfo) Shadows(v *Var) *Var {
+	v, s := info.Declaration(v)
+	if s == nil {
+		return nil
+	}
+	for s = s.Parent; s != nil; s = s.Parent {
+		if w := s.findDeclared(v.Data, false); w != nil {
+			return w
+		}
+	}
+	for _, w := range info.order {
+		if info.decl[w] == nil && string(w.Data) == string(v.Data) {
+			return w
+		}
+	}
+	return nil
+}
+
+// withinScope returns true if scope s is equal to or contained in scope parent, where a nil parent is the global scope.
+func withinScope(s, parent *Scope) bool {
+	if parent == nil {
+		return true
+	}
+	for ; s != nil; s = s.Parent {
+		if s == parent {
+			return true
+		}
+	}
+	return false
+}
+
+// Rename renames a variable and all its occurrences. It refuses to rename if the new name is not a valid identifier, if it is already declared in the same scope, if an occurrence would be captured by a declaration of an inner scope, if the variable would shadow occurrences of another variable, or if occurrences are in a with statement or in a scope with a direct call to eval. Import and export clauses keep the names that are imported or exported, and variables that are exported by their declaration cannot be renamed.
+func Rename(ast *AST, v *Var, name string) error {
+	if !isIdentifier(name) {
+		return fmt.Errorf("invalid identifier name %s", name)
+	}
+	info := NewScopeInfo(ast)
+	v, decl := info.Declaration(v)
+	if string(v.Data) == name {
+		return nil
+	}
+
+	if decl != nil {
+		if w := decl.findDeclared([]byte(name), false); w != nil {
+			return fmt.Errorf("cannot rename %s to %s: %s is already declared in the same scope", v.Data, name, name)
+		}
+	}
+	for _, w := range info.order {
+		if w != v && info.decl[w] == nil && decl == nil && string(w.Data) == name {
+			return fmt.Errorf("cannot rename %s to %s: %s is already used as a global variable", v.Data, name, name)
+		}
+	}
+
+	// occurrences of the variable must not be captured by declarations between the occurrence and its declaration
+	for _, ref := range info.refs[v] {
+		s := ref.Scope
+		if s == nil || !withinScope(s, decl) {
+			s = decl // function expression names are declared in the scope of the function
+		}
+		for ; s != nil && s != decl; s = s.Parent {
+			if s.HasWith {
+				return fmt.Errorf("cannot rename %s to %s: %s is used in a with statement", v.Data, name, v.Data)
+			} else if w := s.findDeclared([]byte(name), false); w != nil {
+				return fmt.Errorf("cannot rename %s to %s: %s would be captured by the declaration of %s in an inner scope", v.Data, name, v.Data, name)
+			}
+		}
+		if s != nil && s.HasWith {
+			return fmt.Errorf("cannot rename %s to %s: %s is used in a with statement", v.Data, name, v.Data)
+		}
+	}
+
+	// occurrences of other variables with the new name must not resolve to the renamed variable, and no direct call to eval may access it
+	for _, w := range info.order {
+		wdecl := info.decl[w]
+		isEval := wdecl == nil && string(w.Data) == "eval"
+		if w == v || string(w.Data) != name && !isEval {
+			continue
+		} else if !isEval && withinScope(wdecl, decl) && wdecl != nil {
+			continue // declared inside the scope of the renamed variable, which takes precedence
+		}
+		for _, ref := range info.refs[w] {
+			if ref.Scope != nil && withinScope(ref.Scope, decl) {
+				if !isEval {
+					return fmt.Errorf("cannot rename %s to %s: %s would shadow another variable %s", v.Data, name, v.Data, name)
+				} else if _, ok := ref.Parent.Node.(*CallExpr); ok && ref.Name == "X" {
+					return fmt.Errorf("cannot rename %s to %s: %s is in a scope with a direct call to eval", v.Data, name, v.Data)
+				}
+			}
+		}
+	}
+
+	if decl == nil || decl == &ast.BlockStmt.Scope { // imports are undeclared
+		for _, item := range ast.BlockStmt.List {
+			if exportStmt, ok := item.(*ExportStmt); ok && !exportStmt.Default && exportStmt.Decl != nil && declares(exportStmt.Decl, v) {
+				return fmt.Errorf("cannot rename %s to %s: %s is exported by its declaration", v.Data, name, v.Data)
+			}
+		}
+		renameAliases(ast.BlockStmt.List, map[string][]byte{string(v.Data): []byte(name)})
+	}
+	info.rename(v, []byte(name))
+	return nil
+}
+
+// declares returns true if the declaration of an export statement declares the variable.
+func declares(decl IExpr, v *Var) bool {
+	var vars []*Var
+	switch decl := decl.(type) {
+	case *VarDecl:
+		vars = bindingVars(nil, stmtBindings(decl)...)
+	case *FuncDecl:
+		vars = append(vars, decl.Name)
+	case *ClassDecl:
+		vars = append(vars, decl.Name)
+	}
+	for _, w := range vars {
+		if w != nil && resolveVar(w) == v {
+			return true
+		}
+	}
+	return false
+}
+
+// renameAliases renames the local names of the imports and local exports of a module by their old names, where the imported and exported names are kept.
+func renameAliases(list []IStmt, renames map[string][]byte) {
+	for _, item := range list {
+		switch stmt := item.(type) {
+		case *ImportStmt:
+			if data, ok := renames[string(stmt.Default)]; ok && stmt.Default != nil {
+				stmt.Default = data
+			}
+			for i, alias := range stmt.List {
+				data, ok := renames[string(alias.Binding)]
+				if !ok || alias.Binding == nil {
+					continue
+				} else if alias.Name == nil {
+					stmt.List[i].Name = alias.Binding
+				} else if bytes.Equal(alias.Name, data) {
+					stmt.List[i].Name = nil
+				}
+				stmt.List[i].Binding = data
+			}
+		case *ExportStmt:
+			if stmt.Module != nil || stmt.Decl != nil {
+				continue
+			}
+			for i, alias := range stmt.List {
+				local := alias.Binding
+				if alias.Name != nil {
+					local = alias.Name
+				}
+				if data, ok := renames[string(local)]; ok {
+					stmt.List[i].Name = data
+					if bytes.Equal(alias.Binding, data) {
+						stmt.List[i].Name = nil
+					}
+				}
+			}
+		}
+	}
+}
+
+// rename sets the name of a variable and all its occurrences, including the linked variables of scopes without occurrences in the AST.
+func (info *ScopeInfo) rename(v *Var, data []byte) {
+	v.Data = data
+	for _, ref := range info.refs[v] {
+		ref.Var().Data = data
+	}
+	for _, s := range info.scopes {
+		for _, w := range s.Undeclared {
+			if w != v && resolveVar(w) == v {
+				w.Data = data
+			}
+		}
+	}
+}
+
+// isIdentifier returns true if the name is a valid identifier without escape sequences that is not a reserved word, also in strict mode code.
+func isIdentifier(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, r := range name {
+		if r == utf8.RuneError || r == '\\' {
+			return false
+		} else if i == 0 && !IsIdentifierStart([]byte(name)) || 0 < i && !IsIdentifierContinue([]byte(name[i:])) {
+			return false
+		}
+	}
+	if tt, ok := Keywords[name]; ok && (IsReservedWord(tt) || tt == YieldToken) {
+		return false
+	}
+	switch name {
+	case "implements", "interface", "let", "package", "private", "protected", "public", "static", "arguments", "eval":
+		return false
+	}
+	return true
+}
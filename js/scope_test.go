@@ -0,0 +1,202 @@
+package js
+
+import (
+	"testing"
+
+	"github.com/tdewolff/parse/v2"
+	"github.com/tdewolff/test"
+)
+
+func varNames(vs []*Var) []string {
+	names := []string{}
+	for _, v := range vs {
+		names = append(names, string(v.Data))
+	}
+	return names
+}
+
+func TestScopeInfoReferences(t *testing.T) {
+	ast, err := Parse(parse.NewInputString("var a = 1; function f(b) { return a + b }; a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	info := NewScopeInfo(ast)
+	test.T(t, varNames(info.Vars()), []string{"a", "f", "b"})
+
+	a := ast.BlockStmt.Scope.Declared[0]
+	refs := info.References(a)
+	test.T(t, len(refs), 3)
+	test.T(t, refs[0].Binding, true)
+	test.T(t, refs[1].Binding, false)
+	test.String(t, refs[1].Path.String(), "AST.BlockStmt.List[1].Body.List[0].Value.X")
+	test.T(t, refs[1].Scope, &ast.BlockStmt.List[1].(*FuncDecl).Body.Scope)
+	test.String(t, refs[2].Path.String(), "AST.BlockStmt.List[2].Value")
+	for i, offset := range []int{4, 34, 43} {
+		start, end := refs[i].Offsets()
+		test.T(t, start, offset)
+		test.T(t, end, offset+1)
+	}
+
+	v, s := info.Declaration(refs[1].Var())
+	test.T(t, v, a)
+	test.T(t, s, &ast.BlockStmt.Scope)
+	_, s = info.Declaration(info.Vars()[2])
+	test.T(t, s, &ast.BlockStmt.List[1].(*FuncDecl).Body.Scope)
+}
+
+func TestScopeInfoFreeVars(t *testing.T) {
+	var tests = []struct {
+		js       string
+		expected []string
+	}{
+		{"function f(a) { var b; return a + b + c + d(arguments) + f } var c", []string{"c", "d"}},
+		{"!function f(a) { return f(a, b) }", []string{"b"}},
+		{"() => arguments + x", []string{"arguments", "x"}},
+		{"() => { let a; function g(b) { return a + b + arguments } }", []string{}},
+		{"class A { m(a) { return a + this.b + c } }", []string{"c"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.js, func(t *testing.T) {
+			ast, err := Parse(parse.NewInputString(tt.js))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var fn INode
+			switch n := ast.BlockStmt.List[0].(type) {
+			case *FuncDecl:
+				fn = n
+			case *ExprStmt:
+				switch x := n.Value.(type) {
+				case *ArrowFunc:
+					fn = x
+				case *UnaryExpr:
+					fn = x.X
+				}
+			case *ClassDecl:
+				fn = n.Methods[0]
+			}
+			test.T(t, varNames(NewScopeInfo(ast).FreeVars(fn)), tt.expected)
+		})
+	}
+}
+
+func TestPathScope(t *testing.T) {
+	ast, err := Parse(parse.NewInputString("var a; function f(b = a) { for (let c of b) { c } } try {} catch (d) { d }"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	info := NewScopeInfo(ast)
+	for _, v := range info.Vars() {
+		for _, ref := range info.References(v) {
+			test.T(t, ref.Path.Scope(), ref.Scope, ref.Path.String())
+		}
+	}
+	f := ast.BlockStmt.List[1].(*FuncDecl)
+	test.T(t, (&Path{ast, nil, "", -1}).Scope(), &ast.BlockStmt.Scope)
+	test.T(t, (&Path{f, nil, "", -1}).Scope(), (*Scope)(nil))
+}
+
+func TestScopeInfoShadows(t *testing.T) {
+	ast, err := Parse(parse.NewInputString("var a; function f(b) { let a; { let a; b } } function g() { var c } c"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	info := NewScopeInfo(ast)
+	outer := ast.BlockStmt.Scope.Declared[0]
+	f := ast.BlockStmt.List[1].(*FuncDecl)
+	middle := f.Body.Scope.Declared[1]
+	inner := f.Body.List[1].(*BlockStmt).Scope.Declared[0]
+	test.T(t, info.Shadows(outer), (*Var)(nil))
+	test.T(t, info.Shadows(middle), outer)
+	test.T(t, info.Shadows(inner), middle)
+	test.T(t, info.Shadows(f.Body.Scope.Declared[0]), (*Var)(nil))
+
+	c := ast.BlockStmt.List[2].(*FuncDecl).Body.Scope.Declared[0]
+	test.String(t, string(info.Shadows(c).Data), "c")
+	test.T(t, info.Shadows(c).Decl, NoDecl)
+}
+
+func TestRename(t *testing.T) {
+	var tests = []struct {
+		js       string
+		name     string
+		expected string
+	}{
+		{"var a = 1; function f(b) { return a + b }; a", "c", "var c = 1; function f (b) { return c + b; }; c; "},
+		{"var b = function a() { a }", "b", "var b = function b () { b; }; "},
+		{"function f() { let a; { a } } let b", "b", "function f () { let b; { b; }; }; let b; "},
+		{"for (let a of b) { a }", "c", "for (let c of b) { c; }; "},
+		{"try {} catch (a) { a }", "b", "try { } catch(b) { b; }; "},
+		{"a = 1; a", "b", "b = 1; b; "},
+		{"let a = 1; export {a}", "b", "let b = 1; export { b as a }; "},
+		{"let a = 1; export {a as c, a as b}", "b", "let b = 1; export { b as c , b }; "},
+		{"import {a} from 'x'; a()", "b", "import { a as b } from 'x'; b(); "},
+		{"import {b as a} from 'x'; a()", "b", "import { b } from 'x'; b(); "},
+		{"import a, * as c from 'x'; a()", "b", "import b , * as c from 'x'; b(); "},
+		{"export function f() { return a } let a", "b", "export function f () { return b; }; let b; "},
+	}
+	for _, tt := range tests {
+		t.Run(tt.js, func(t *testing.T) {
+			ast, err := Parse(parse.NewInputString(tt.js))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var v *Var
+			for _, w := range NewScopeInfo(ast).Vars() {
+				if string(w.Data) == "a" {
+					v = w
+				}
+			}
+			test.Error(t, Rename(ast, v, tt.name))
+			test.String(t, ast.JS(), tt.expected)
+		})
+	}
+}
+
+func TestRenameError(t *testing.T) {
+	var tests = []struct {
+		js   string
+		name string
+		err  string
+	}{
+		{"var a", "1a", "invalid identifier name 1a"},
+		{"var a", "if", "invalid identifier name if"},
+		{"var a", "let", "invalid identifier name let"},
+		{"var a", "\\u0061", "invalid identifier name \\u0061"},
+		{"var a, b", "b", "cannot rename a to b: b is already declared in the same scope"},
+		{"a; b", "b", "cannot rename a to b: b is already used as a global variable"},
+		{"var a; function f(b) { return a + b }", "b", "cannot rename a to b: a would be captured by the declaration of b in an inner scope"},
+		{"{ let b; var a }", "b", "cannot rename a to b: a would be captured by the declaration of b in an inner scope"},
+		{"function f() { var a; return b } var b", "b", "cannot rename a to b: a would shadow another variable b"},
+		{"function f() { var a; return b }", "b", "cannot rename a to b: a would shadow another variable b"},
+		{"var a; with (o) { a }", "b", "cannot rename a to b: a is used in a with statement"},
+		{"var a; eval('a')", "b", "cannot rename a to b: a is in a scope with a direct call to eval"},
+		{"export let a = 1; a", "b", "cannot rename a to b: a is exported by its declaration"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.js, func(t *testing.T) {
+			ast, err := Parse(parse.NewInputString(tt.js))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			info := NewScopeInfo(ast)
+			var v *Var
+			for _, w := range info.Vars() {
+				if string(w.Data) == "a" {
+					v = w
+				}
+			}
+			err = Rename(ast, v, tt.name)
+			test.That(t, err != nil)
+			if err != nil {
+				test.String(t, err.Error(), tt.err)
+			}
+		})
+	}
+}
@@ -139,4 +139,5 @@ var Keywords = map[string]TokenType{
 	"of":     OfToken,
 	"set":    SetToken,
 	"target": TargetToken,
+	"using":  UsingToken,
 }
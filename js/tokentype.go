@@ -19,6 +19,7 @@ const (
 	TemplateEndToken
 	RegExpToken
 	PrivateIdentifierToken
+	JSXTextToken
 )
 
 // Numeric token values.
@@ -47,6 +48,7 @@ const (
 	ColonToken                  // :
 	ArrowToken                  // =>
 	EllipsisToken               // ...
+	AtToken                     // @
 )
 
 // Operator token values.
@@ -168,6 +170,7 @@ const (
 	SetToken
 	StaticToken
 	TargetToken
+	UsingToken
 )
 
 // IsNumeric return true if token is numeric.
@@ -322,6 +325,7 @@ var identifierBytes = [][]byte{
 	[]byte("set"),
 	[]byte("static"),
 	[]byte("target"),
+	[]byte("using"),
 }
 
 // Bytes returns the string representation of a TokenType.
@@ -359,6 +363,8 @@ func (tt TokenType) Bytes() []byte {
 		return []byte("RegExp")
 	case PrivateIdentifierToken:
 		return []byte("PrivateIdentifier")
+	case JSXTextToken:
+		return []byte("JSXText")
 	case NumericToken:
 		return []byte("Numeric")
 	case DecimalToken:
@@ -399,6 +405,8 @@ func (tt TokenType) Bytes() []byte {
 		return []byte("=>")
 	case EllipsisToken:
 		return []byte("...")
+	case AtToken:
+		return []byte("@")
 	}
 	return nil
 }
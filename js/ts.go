@@ -0,0 +1,960 @@
+package js
+
+import (
+	"bytes"
+	"strconv"
+
+	"github.com/tdewolff/parse/v2"
+)
+
+// TypeScript syntax is parsed when ParseOptions.TS is set. Type annotations, type arguments and parameters, and type-only declarations such as interfaces, type aliases, overloads, and ambient (declare) declarations are skipped, so that the resulting AST contains JavaScript only. Enums, parameter properties, import aliases, and export assignments are lowered to their JavaScript equivalents, and namespaces are not supported.
+
+func (p *Parser) isTSKeyword(name string) bool {
+	return p.tt == IdentifierToken && string(p.data) == name
+}
+
+////////////////////////////////////////////////////////////////
+
+// skipType skips a type.
+func (p *Parser) skipType() bool {
+	n := len(p.comments)
+	ok := p.skipConditionalType(false)
+	p.dropComments(n)
+	return ok
+}
+
+// skipTypeAnnotation skips a colon followed by a type, if present.
+func (p *Parser) skipTypeAnnotation() bool {
+	if p.tt != ColonToken {
+		return true
+	}
+	p.next()
+	return p.skipType()
+}
+
+// skipReturnType skips the type after a colon of a function's return type, which may be a type predicate such as x is T or asserts x.
+func (p *Parser) skipReturnType() bool {
+	n := len(p.comments)
+	defer p.dropComments(n)
+	if p.isTSKeyword("asserts") {
+		state := p.save()
+		p.next()
+		if !p.prevLT && (IsIdentifier(p.tt) || p.tt == ThisToken) {
+			p.next()
+			if !p.isTSKeyword("is") || p.prevLT {
+				return true
+			}
+			p.next()
+			return p.skipConditionalType(false)
+		}
+		p.restore(state)
+	}
+	if IsIdentifier(p.tt) || p.tt == ThisToken {
+		state := p.save()
+		p.next()
+		if p.isTSKeyword("is") && !p.prevLT {
+			p.next()
+			return p.skipConditionalType(false)
+		}
+		p.restore(state)
+	}
+	return p.skipConditionalType(false)
+}
+
+func (p *Parser) skipConditionalType(noConditional bool) bool {
+	p.exprLevel++
+	defer func() { p.exprLevel-- }()
+	if 1000 < p.exprLevel {
+		p.failMessage("too many nested types")
+		return false
+	}
+
+	if p.tt == NewToken || p.isTSKeyword("abstract") {
+		// constructor type
+		if p.tt != NewToken {
+			p.next()
+			if p.tt != NewToken {
+				p.fail("type", NewToken)
+				return false
+			}
+		}
+		p.next()
+		return p.skipFunctionType()
+	} else if p.tt == LtToken {
+		// generic function type
+		return p.skipFunctionType()
+	} else if p.tt == OpenParenToken {
+		// function type or parenthesized type
+		state := p.save()
+		if p.skipBalanced() && p.tt == ArrowToken {
+			p.restore(state)
+			return p.skipFunctionType()
+		}
+		p.restore(state)
+	}
+
+	if !p.skipUnionType(noConditional) {
+		return false
+	}
+	if !noConditional && p.tt == ExtendsToken && !p.prevLT {
+		p.next()
+		if !p.skipUnionType(true) || !p.consume("conditional type", QuestionToken) || !p.skipConditionalType(false) || !p.consume("conditional type", ColonToken) {
+			return false
+		}
+		return p.skipConditionalType(false)
+	}
+	return true
+}
+
+// skipFunctionType skips a function type after new, starting at its type parameters or parameters.
+func (p *Parser) skipFunctionType() bool {
+	if p.tt == LtToken && !p.skipTypeParams() {
+		return false
+	} else if p.tt != OpenParenToken {
+		p.fail("function type", OpenParenToken)
+		return false
+	} else if !p.skipBalanced() || !p.consume("function type", ArrowToken) {
+		return false
+	}
+	return p.skipReturnType()
+}
+
+func (p *Parser) skipUnionType(noConditional bool) bool {
+	if p.tt == BitOrToken || p.tt == BitAndToken {
+		p.next()
+	}
+	for {
+		if !p.skipTypeOperator(noConditional) {
+			return false
+		} else if p.tt != BitOrToken && p.tt != BitAndToken {
+			return true
+		}
+		p.next()
+	}
+}
+
+func (p *Parser) skipTypeOperator(noConditional bool) bool {
+	if p.isTSKeyword("keyof") || p.isTSKeyword("unique") || p.isTSKeyword("readonly") {
+		state := p.save()
+		p.next()
+		if !p.prevLT && p.isTypeStart() {
+			return p.skipTypeOperator(noConditional)
+		}
+		p.restore(state)
+	} else if p.isTSKeyword("infer") {
+		state := p.save()
+		p.next()
+		if IsIdentifier(p.tt) {
+			p.next()
+			if noConditional && p.tt == ExtendsToken {
+				// constraint of an inferred type in the extends clause of a conditional type
+				p.next()
+				return p.skipConditionalType(true)
+			}
+			return true
+		}
+		p.restore(state)
+	}
+
+	if !p.skipPrimaryType() {
+		return false
+	}
+	for p.tt == OpenBracketToken && !p.prevLT {
+		// array or indexed access type
+		p.next()
+		if p.tt != CloseBracketToken && !p.skipType() {
+			return false
+		} else if !p.consume("type", CloseBracketToken) {
+			return false
+		}
+	}
+	return true
+}
+
+func (p *Parser) skipPrimaryType() bool {
+	switch p.tt {
+	case OpenParenToken, OpenBracketToken, OpenBraceToken:
+		// parenthesized, tuple, object, or mapped type
+		return p.skipBalanced()
+	case StringToken, TrueToken, FalseToken, NullToken, ThisToken, VoidToken, TemplateToken:
+		p.next()
+		return true
+	case SubToken:
+		p.next()
+		if !IsNumeric(p.tt) {
+			p.fail("type", NumericToken)
+			return false
+		}
+		p.next()
+		return true
+	case TemplateStartToken:
+		for p.tt == TemplateStartToken || p.tt == TemplateMiddleToken {
+			p.next()
+			if !p.skipType() {
+				return false
+			}
+		}
+		if p.tt != TemplateEndToken {
+			p.fail("template literal type", TemplateToken)
+			return false
+		}
+		p.next()
+		return true
+	case TypeofToken:
+		p.next()
+		if p.tt == ImportToken {
+			return p.skipPrimaryType()
+		}
+		return p.skipTypeReference()
+	case ImportToken:
+		// import("module").Name<T>
+		p.next()
+		if !p.consume("import type", OpenParenToken) {
+			return false
+		} else if p.tt != StringToken {
+			p.fail("import type", StringToken)
+			return false
+		}
+		p.next()
+		if !p.consume("import type", CloseParenToken) {
+			return false
+		}
+		if p.tt == DotToken {
+			p.next()
+			return p.skipTypeReference()
+		}
+		return true
+	}
+	if IsNumeric(p.tt) {
+		p.next()
+		return true
+	} else if IsIdentifierName(p.tt) {
+		return p.skipTypeReference()
+	}
+	p.fail("type")
+	return false
+}
+
+// skipTypeReference skips a possibly qualified type name with its type arguments.
+func (p *Parser) skipTypeReference() bool {
+	if !IsIdentifierName(p.tt) {
+		p.fail("type", IdentifierToken)
+		return false
+	}
+	p.next()
+	for p.tt == DotToken {
+		p.next()
+		if !IsIdentifierName(p.tt) {
+			p.fail("type", IdentifierToken)
+			return false
+		}
+		p.next()
+	}
+	if p.tt == LtToken && !p.prevLT {
+		return p.skipTypeArgs()
+	}
+	return true
+}
+
+// skipBalanced skips from an opening parenthesis, bracket, or brace up to and including its closing counterpart.
+func (p *Parser) skipBalanced() bool {
+	level := 0
+	for {
+		switch p.tt {
+		case OpenParenToken, OpenBracketToken, OpenBraceToken:
+			level++
+		case CloseParenToken, CloseBracketToken, CloseBraceToken:
+			level--
+		case ErrorToken:
+			p.fail("type")
+			return false
+		}
+		p.next()
+		if level == 0 {
+			return true
+		}
+	}
+}
+
+// skipTypeArgs skips type arguments such as <T, U>.
+func (p *Parser) skipTypeArgs() bool {
+	// assume we're at <
+	p.next()
+	for {
+		if !p.skipType() {
+			return false
+		} else if p.tt != CommaToken {
+			break
+		}
+		p.next()
+	}
+	if !p.isGt() {
+		p.fail("type arguments", GtToken)
+		return false
+	}
+	p.next()
+	return true
+}
+
+// skipTypeParams skips type parameters such as <T extends U = V>.
+func (p *Parser) skipTypeParams() bool {
+	// assume we're at <
+	n := len(p.comments)
+	defer p.dropComments(n)
+	p.next()
+	for !p.isGt() {
+		for p.tt == ConstToken || p.tt == InToken || p.isTSKeyword("out") {
+			state := p.save()
+			p.next()
+			if !IsIdentifier(p.tt) {
+				p.restore(state)
+				break
+			}
+		}
+		if !IsIdentifier(p.tt) {
+			p.fail("type parameters", IdentifierToken)
+			return false
+		}
+		p.next()
+		if p.tt == ExtendsToken {
+			p.next()
+			if !p.skipType() {
+				return false
+			}
+		}
+		if p.tt == EqToken {
+			p.next()
+			if !p.skipType() {
+				return false
+			}
+		}
+		if p.tt != CommaToken {
+			if !p.isGt() {
+				p.fail("type parameters", CommaToken, GtToken)
+				return false
+			}
+			break
+		}
+		p.next()
+	}
+	p.next()
+	return true
+}
+
+// isTypeStart returns true if the current token can start a type.
+func (p *Parser) isTypeStart() bool {
+	switch p.tt {
+	case OpenParenToken, OpenBracketToken, OpenBraceToken, LtToken, SubToken, BitOrToken, BitAndToken, StringToken, TemplateToken, TemplateStartToken, TypeofToken, ImportToken, NewToken:
+		return true
+	}
+	return IsNumeric(p.tt) || IsIdentifierName(p.tt)
+}
+
+// isExprStart returns true if the current token can start an expression.
+func (p *Parser) isExprStart() bool {
+	switch p.tt {
+	case OpenParenToken, OpenBracketToken, OpenBraceToken, LtToken, NotToken, BitNotToken, AddToken, SubToken, IncrToken, DecrToken, DivToken, DivEqToken, StringToken, TemplateToken, TemplateStartToken, RegExpToken, PrivateIdentifierToken, TypeofToken, VoidToken, DeleteToken, AwaitToken, YieldToken, NewToken, FunctionToken, ClassToken, ThisToken, NullToken, TrueToken, FalseToken, SuperToken, ImportToken:
+		return true
+	}
+	return IsNumeric(p.tt) || IsIdentifier(p.tt)
+}
+
+// tryTypeArgs skips the type arguments of a call, tagged template, or instantiation expression, such as f<T>(x). It returns false and leaves the state unchanged if the < is a less-than operator instead.
+func (p *Parser) tryTypeArgs() bool {
+	state := p.save()
+	if p.skipTypeArgs() {
+		switch p.tt {
+		case OpenParenToken, TemplateToken, TemplateStartToken:
+			return true
+		case LtToken, GtToken, AddToken, SubToken:
+		default:
+			if p.prevLT || !p.isExprStart() {
+				return true
+			}
+		}
+	}
+	p.restore(state)
+	return false
+}
+
+// tryTypeParams skips the type parameters of a generic arrow function, such as <T>(x: T) => x. It returns false and leaves the state unchanged if they are not followed by a parenthesis.
+func (p *Parser) tryTypeParams() bool {
+	state := p.save()
+	if p.skipTypeParams() && p.tt == OpenParenToken {
+		return true
+	}
+	p.restore(state)
+	return false
+}
+
+// isTSArrowTypeParams returns true if < starts the type parameters of a generic arrow function instead of a JSX element, which must be written as <T,>(x) => x or <T extends U>(x) => x.
+func (p *Parser) isTSArrowTypeParams() bool {
+	state := p.save()
+	defer p.restore(state)
+	p.next()
+	if !IsIdentifier(p.tt) {
+		return false
+	}
+	p.next()
+	return p.tt == CommaToken || p.tt == ExtendsToken
+}
+
+// isTSOptionalParam returns true if the current ? marks an optional parameter in (x?: T) => x, instead of a conditional expression.
+func (p *Parser) isTSOptionalParam() bool {
+	state := p.save()
+	defer p.restore(state)
+	p.next()
+	return p.tt == ColonToken || p.tt == CommaToken || p.tt == CloseParenToken || p.tt == EqToken
+}
+
+// isTSOptionalMethod returns true if the current ? marks an optional method in class A { m?(): void }, instead of an optional field.
+func (p *Parser) isTSOptionalMethod() bool {
+	state := p.save()
+	defer p.restore(state)
+	p.next()
+	return p.tt == OpenParenToken || p.tt == LtToken
+}
+
+// tryArrowReturnType skips the return type of an arrow function, as in (x): T => x. It returns false and leaves the state unchanged if the colon belongs to a conditional expression instead.
+func (p *Parser) tryArrowReturnType() bool {
+	state := p.save()
+	p.next()
+	if p.skipReturnType() && p.tt == ArrowToken && !p.prevLT {
+		return true
+	}
+	p.restore(state)
+	return false
+}
+
+// parseTSAngleBracketExpr parses a type assertion <T>x or a generic arrow function <T>(x: T) => x, including its suffix.
+func (p *Parser) parseTSAngleBracketExpr(prec OpPrec, start int) IExpr {
+	// assume we're at <
+	if prec <= OpAssign && p.tryTypeParams() {
+		return p.parseParenthesizedExpressionOrArrowFunc(prec, nil, start)
+	} else if p.JSX {
+		p.fail("arrow function")
+		return nil
+	} else if OpUnary < prec {
+		p.fail("expression")
+		return nil
+	} else if !p.skipTypeArgs() {
+		return nil
+	}
+	exprStart := p.pos
+	return p.parseExpressionSuffix(p.parseExpression(OpUnary), prec, OpUnary, exprStart)
+}
+
+// skipTSParamModifiers skips the accessibility and readonly modifiers of a constructor parameter, and returns true if any was present, in which case the parameter is also a class property.
+func (p *Parser) skipTSParamModifiers() bool {
+	modifiers := false
+	for p.tt == PublicToken || p.tt == PrivateToken || p.tt == ProtectedToken || p.isTSKeyword("readonly") || p.isTSKeyword("override") {
+		state := p.save()
+		p.next()
+		if !p.isIdentifierReference(p.tt) && p.tt != OpenBracketToken && p.tt != OpenBraceToken {
+			p.restore(state)
+			break
+		}
+		modifiers = true
+	}
+	return modifiers
+}
+
+// tsClassModifiers are the TypeScript modifiers of a class member.
+type tsClassModifiers struct {
+	abstract, declare bool
+}
+
+// skipTSClassModifiers skips the TypeScript modifiers of a class member. Modifiers that are followed by a parenthesis, equal sign, colon, or the end of the member are the names of the member instead.
+func (p *Parser) skipTSClassModifiers(modifiers *tsClassModifiers) bool {
+	skipped := false
+	for p.tt == PublicToken || p.tt == PrivateToken || p.tt == ProtectedToken || p.tt == IdentifierToken {
+		name := string(p.data)
+		if p.tt == IdentifierToken && name != "readonly" && name != "abstract" && name != "override" && name != "declare" {
+			break
+		}
+		state := p.save()
+		p.next()
+		switch p.tt {
+		case OpenParenToken, EqToken, ColonToken, QuestionToken, NotToken, LtToken, SemicolonToken, CloseBraceToken:
+			p.restore(state)
+			return skipped
+		}
+		if p.prevLT {
+			p.restore(state)
+			return skipped
+		}
+		modifiers.abstract = modifiers.abstract || name == "abstract"
+		modifiers.declare = modifiers.declare || name == "declare"
+		skipped = true
+	}
+	return skipped
+}
+
+// skipTSIndexSignature skips an index signature such as [key: string]: T in a class body, and returns false and leaves the state unchanged if the bracket starts a computed property name instead.
+func (p *Parser) skipTSIndexSignature() bool {
+	state := p.save()
+	p.next()
+	if IsIdentifier(p.tt) {
+		p.next()
+		if p.tt == ColonToken {
+			p.restore(state)
+			n := len(p.comments)
+			if p.skipBalanced() && p.skipTypeAnnotation() && p.tt == SemicolonToken {
+				p.next()
+			}
+			p.dropComments(n)
+			return true
+		}
+	}
+	p.restore(state)
+	return false
+}
+
+// addTSParamProps assigns the parameters of a constructor that have modifiers to the class properties of the same name, as in this.x = x. They are inserted after the call to super, or else at the start of the body.
+func (p *Parser) addTSParamProps(body *BlockStmt, props []*Var) {
+	i := 0
+	for j, stmt := range body.List {
+		if exprStmt, ok := stmt.(*ExprStmt); ok {
+			if call, ok := exprStmt.Value.(*CallExpr); ok {
+				if super, ok := call.X.(*LiteralExpr); ok && super.TokenType == SuperToken {
+					i = j + 1
+					break
+				}
+			}
+		}
+	}
+	if i == 0 {
+		for i < len(body.List) {
+			if _, ok := body.List[i].(*DirectivePrologueStmt); !ok {
+				break
+			}
+			i++
+		}
+	}
+
+	list := make([]IStmt, 0, len(body.List)+len(props))
+	list = append(list, body.List[:i]...)
+	for _, v := range props {
+		v.Uses++
+		this := &LiteralExpr{ThisToken, []byte("this"), Span{}}
+		name := LiteralExpr{IdentifierToken, parse.Copy(v.Data), Span{}} // copy so that renaming doesn't rename the property
+		list = append(list, &ExprStmt{&BinaryExpr{EqToken, &DotExpr{this, name, OpMember, Span{}}, v, Span{}}, Span{}})
+	}
+	body.List = append(list, body.List[i:]...)
+}
+
+////////////////////////////////////////////////////////////////
+
+// tryTSDecl parses a statement that starts with a contextual keyword of TypeScript, such as type, interface, declare, or abstract. It returns false and leaves the state unchanged if the statement is not a TypeScript declaration. A nil statement is returned for type-only declarations.
+func (p *Parser) tryTSDecl() (IStmt, bool) {
+	if !IsIdentifier(p.tt) {
+		return nil, false
+	}
+	name := string(p.data)
+	state := p.save()
+	p.next()
+	if !p.prevLT {
+		switch name {
+		case "type":
+			if IsIdentifier(p.tt) {
+				p.skipTSTypeAlias()
+				return nil, true
+			}
+		case "interface":
+			if IsIdentifier(p.tt) {
+				p.skipTSInterface()
+				return nil, true
+			}
+		case "abstract":
+			if p.tt == ClassToken {
+				return p.parseClassDecl(), true
+			}
+		case "declare":
+			if p.tt == VarToken || p.tt == LetToken || p.tt == ConstToken || p.tt == FunctionToken || p.tt == ClassToken || p.tt == EnumToken || IsIdentifier(p.tt) && p.tt != AsToken {
+				p.skipTSAmbientDecl()
+				return nil, true
+			}
+		case "namespace", "module", "global":
+			if p.ambient && (IsIdentifier(p.tt) || p.tt == StringToken || p.tt == OpenBraceToken) {
+				p.skipTSNamespace()
+				return nil, true
+			} else if IsIdentifier(p.tt) && name != "global" {
+				p.failMessage("TypeScript namespaces are not supported")
+				return nil, true
+			}
+		}
+	}
+	p.restore(state)
+	return nil, false
+}
+
+// skipTSTypeAlias skips a type alias declaration after type.
+func (p *Parser) skipTSTypeAlias() {
+	n := len(p.comments)
+	defer p.dropComments(n)
+	p.next()
+	if p.tt == LtToken && !p.skipTypeParams() {
+		return
+	} else if !p.consume("type alias", EqToken) || !p.skipType() {
+		return
+	}
+	if p.tt == SemicolonToken {
+		p.next()
+	}
+}
+
+// skipTSInterface skips an interface declaration after interface.
+func (p *Parser) skipTSInterface() {
+	n := len(p.comments)
+	defer p.dropComments(n)
+	p.next()
+	if p.tt == LtToken && !p.skipTypeParams() {
+		return
+	}
+	if p.tt == ExtendsToken {
+		p.next()
+		for {
+			if !p.skipTypeReference() {
+				return
+			} else if p.tt != CommaToken {
+				break
+			}
+			p.next()
+		}
+	}
+	if p.tt != OpenBraceToken {
+		p.fail("interface declaration", OpenBraceToken)
+		return
+	}
+	p.skipBalanced()
+}
+
+// skipTSNamespace skips an ambient namespace, module, or global declaration after its keyword.
+func (p *Parser) skipTSNamespace() {
+	n := len(p.comments)
+	defer p.dropComments(n)
+	if p.tt == StringToken {
+		p.next()
+	} else if p.tt != OpenBraceToken {
+		if !p.skipTypeReference() {
+			return
+		}
+	}
+	if p.tt == OpenBraceToken {
+		p.skipBalanced()
+	} else if p.tt == SemicolonToken {
+		p.next()
+	}
+}
+
+// skipTSAmbientDecl skips an ambient declaration after declare. The declaration is parsed in a scope that is discarded afterwards, so that it doesn't declare any variables.
+func (p *Parser) skipTSAmbientDecl() {
+	n := len(p.comments)
+	defer p.dropComments(n)
+
+	parent := p.enterScope(&Scope{}, true)
+	parentAmbient := p.ambient
+	p.ambient = true
+	p.parseStmt(true)
+	p.ambient = parentAmbient
+	p.scope = parent
+}
+
+// skipTSImportType skips the type keyword and returns true if the import declaration imports types only, as in import type {T} from "module".
+func (p *Parser) skipTSImportType() bool {
+	if !p.isTSKeyword("type") {
+		return false
+	}
+	state := p.save()
+	p.next()
+	if p.tt == OpenBraceToken || p.tt == MulToken || IsIdentifier(p.tt) && p.tt != FromToken {
+		return true
+	}
+	p.restore(state)
+	return false
+}
+
+// skipTSTypeSpecifier skips an import or export specifier that is marked as type-only, as in import {type T} from "module".
+func (p *Parser) skipTSTypeSpecifier() bool {
+	if !p.isTSKeyword("type") {
+		return false
+	}
+	state := p.save()
+	p.next()
+	if !IsIdentifierName(p.tt) || p.tt == AsToken {
+		p.restore(state)
+		return false
+	}
+	p.next()
+	if p.tt == AsToken {
+		p.next()
+		if !IsIdentifierName(p.tt) {
+			p.fail("type specifier", IdentifierToken)
+			return true
+		}
+		p.next()
+	}
+	if p.tt == CommaToken {
+		p.next()
+	}
+	return true
+}
+
+// tryTSImportEquals parses an import alias after import, as in import x = require("module") or import x = N.x, and lowers it to a const declaration. It returns false and leaves the state unchanged if it is not an import alias.
+func (p *Parser) tryTSImportEquals(start int) (*VarDecl, bool) {
+	if !p.TS || !IsIdentifier(p.tt) && p.tt != YieldToken {
+		return nil, false
+	}
+	state := p.save()
+	name, namePos := p.data, p.pos
+	p.next()
+	if p.tt != EqToken {
+		p.restore(state)
+		return nil, false
+	}
+	p.next()
+	v, ok := p.declare(LexicalDecl, name, namePos)
+	if !ok {
+		p.failAt(namePos, "identifier %s has already been declared", string(name))
+		return nil, true
+	}
+	init := p.parseExpression(OpCall)
+	if !p.prevLT && p.tt != SemicolonToken && p.tt != CloseBraceToken && p.tt != ErrorToken {
+		p.fail("import statement")
+		return nil, true
+	}
+	varDecl := &VarDecl{TokenType: ConstToken, List: []BindingElement{{v, init, p.span(namePos)}}, Span: p.span(start)}
+	if p.tt == SemicolonToken {
+		p.next()
+	}
+	return varDecl, true
+}
+
+// tryTSExportAssignment parses an export assignment at export, as in export = x, and lowers it to module.exports = x. It returns false and leaves the state unchanged if it is not an export assignment.
+func (p *Parser) tryTSExportAssignment(start int) (*ExprStmt, bool) {
+	if !p.TS {
+		return nil, false
+	}
+	state := p.save()
+	p.next()
+	if p.tt != EqToken {
+		p.restore(state)
+		return nil, false
+	}
+	p.next()
+	module := p.use([]byte("module"), start)
+	target := &DotExpr{module, LiteralExpr{IdentifierToken, []byte("exports"), Span{}}, OpMember, module.Span}
+	value := p.parseExpression(OpAssign)
+	if !p.prevLT && p.tt != SemicolonToken && p.tt != CloseBraceToken && p.tt != ErrorToken {
+		p.fail("export statement")
+		return nil, true
+	}
+	stmt := &ExprStmt{&BinaryExpr{EqToken, target, value, p.span(start)}, p.span(start)}
+	if p.tt == SemicolonToken {
+		p.next()
+	}
+	return stmt, true
+}
+
+// tryTSExportType skips a type-only export, as in export type {T} from "module", and returns false and leaves the state unchanged if type is not followed by a brace or asterisk.
+func (p *Parser) tryTSExportType() bool {
+	// assume we're at type
+	state := p.save()
+	p.next()
+	if p.tt != OpenBraceToken && p.tt != MulToken {
+		p.restore(state)
+		return false
+	}
+	if p.tt == MulToken {
+		p.next()
+		if p.tt == AsToken {
+			p.next()
+			p.next()
+		}
+	} else if !p.skipBalanced() {
+		return true
+	}
+	if p.tt == FromToken {
+		p.next()
+		if p.tt != StringToken {
+			p.fail("export statement", StringToken)
+			return true
+		}
+		p.next()
+	}
+	if p.tt == SemicolonToken {
+		p.next()
+	}
+	return true
+}
+
+// tryTSDefaultDecl parses a TypeScript declaration after export default, which is either an abstract class or an interface. It returns false and leaves the state unchanged if there is no such declaration, and returns a nil expression for interfaces.
+func (p *Parser) tryTSDefaultDecl() (IExpr, bool) {
+	if !p.TS || p.tt != InterfaceToken && !p.isTSKeyword("abstract") {
+		return nil, false
+	}
+	interfaceDecl := p.tt == InterfaceToken
+	state := p.save()
+	p.next()
+	if !p.prevLT {
+		if !interfaceDecl && p.tt == ClassToken {
+			return p.parseClassExpr(), true
+		} else if interfaceDecl && IsIdentifier(p.tt) {
+			p.skipTSInterface()
+			return nil, true
+		}
+	}
+	p.restore(state)
+	return nil, false
+}
+
+////////////////////////////////////////////////////////////////
+
+// tsEnum is the state while parsing the members of an enum, whose names can be referenced in the initializers of subsequent members.
+type tsEnum struct {
+	name    []byte
+	pos     int
+	members map[string]bool
+}
+
+// parseTSEnum parses an enum declaration at enum, and lowers it to a var declaration of an object that maps the member names to values and vice versa:
+//
+//	var E = (function (E) { E[E["A"] = 0] = "A"; return E; })(E || {});
+func (p *Parser) parseTSEnum(start int) *VarDecl {
+	// assume we're at enum
+	p.next()
+	if !IsIdentifier(p.tt) {
+		p.fail("enum declaration", IdentifierToken)
+		return nil
+	}
+	name, namePos := p.data, p.pos
+	v, ok := p.declare(VariableDecl, name, namePos)
+	if !ok {
+		p.failMessage("identifier %s has already been declared", string(name))
+		return nil
+	}
+	p.scope.Func.NumVarDecls++
+	p.next()
+	if !p.consume("enum declaration", OpenBraceToken) {
+		return nil
+	}
+
+	fn := &FuncDecl{}
+	parent := p.enterScope(&fn.Body.Scope, true)
+	param, _ := p.declare(ArgumentDecl, name, namePos) // cannot fail
+	fn.Params.List = []BindingElement{{Binding: param}}
+	parentEnum := p.enum
+	p.enum = &tsEnum{name, namePos, map[string]bool{}}
+
+	// the value of the next member without initializer is either a number or the previous member plus one
+	next, isNumber := 0.0, true
+	var prev []byte // key of the previous member
+	for p.tt != CloseBraceToken {
+		var key []byte
+		if IsIdentifierName(p.tt) {
+			key = []byte("\"" + string(p.data) + "\"")
+		} else if p.tt == StringToken {
+			key = p.data
+		} else {
+			p.fail("enum declaration", IdentifierToken, StringToken, CloseBraceToken)
+			return nil
+		}
+		member := key[1 : len(key)-1]
+		p.next()
+
+		var value IExpr
+		isString := false
+		if p.tt == EqToken {
+			p.next()
+			value = p.parseExpression(OpAssign)
+			if value == nil {
+				return nil
+			}
+			next, isNumber = tsEnumNumber(value)
+			next++
+			if lit, ok := value.(*LiteralExpr); ok && lit.TokenType == StringToken {
+				isString = true
+			} else if tpl, ok := value.(*TemplateExpr); ok && tpl.Tag == nil && len(tpl.List) == 0 {
+				isString = true
+			}
+		} else if isNumber {
+			value = &LiteralExpr{DecimalToken, []byte(strconv.FormatFloat(next, 'f', -1, 64)), Span{}}
+			next++
+		} else if prev != nil {
+			prevMember := &IndexExpr{p.use(name, namePos), &LiteralExpr{StringToken, prev, Span{}}, OpMember, Span{}}
+			value = &BinaryExpr{AddToken, prevMember, &LiteralExpr{DecimalToken, []byte("1"), Span{}}, Span{}}
+		} else {
+			p.failMessage("enum member %s must have an initializer", string(member))
+			return nil
+		}
+		if isString {
+			isNumber, prev = false, nil
+		} else {
+			prev = key
+		}
+		p.enum.members[string(member)] = true
+
+		// E["A"] = value for strings, and E[E["A"] = value] = "A" otherwise
+		var assign IExpr = &BinaryExpr{EqToken, &IndexExpr{p.use(name, namePos), &LiteralExpr{StringToken, key, Span{}}, OpMember, Span{}}, value, Span{}}
+		if !isString {
+			assign = &BinaryExpr{EqToken, &IndexExpr{p.use(name, namePos), assign, OpMember, Span{}}, &LiteralExpr{StringToken, key, Span{}}, Span{}}
+		}
+		fn.Body.List = append(fn.Body.List, &ExprStmt{assign, Span{}})
+
+		if p.tt == CommaToken {
+			p.next()
+		} else if p.tt != CloseBraceToken {
+			p.fail("enum declaration", CommaToken, CloseBraceToken)
+			return nil
+		}
+	}
+	fn.Body.List = append(fn.Body.List, &ReturnStmt{p.use(name, namePos), Span{}})
+	p.enum = parentEnum
+	p.exitScope(parent)
+	p.next()
+
+	arg := &BinaryExpr{OrToken, p.use(name, namePos), &ObjectExpr{}, Span{}}
+	call := &CallExpr{&GroupExpr{fn, Span{}}, Args{[]Arg{{Value: arg}}, Span{}}, Span{}}
+	return &VarDecl{TokenType: VarToken, List: []BindingElement{{Binding: v, Default: call}}, Span: p.span(start)}
+}
+
+// parseTSEnumMember parses a reference to a previously defined member of the enclosing enum, as in enum E { A = 1, B = A << 1 }, and returns false if the current identifier is not a member.
+func (p *Parser) parseTSEnumMember() (IExpr, bool) {
+	if p.enum == nil || !p.enum.members[string(p.data)] {
+		return nil, false
+	}
+	member := LiteralExpr{IdentifierToken, p.data, p.tokenSpan()}
+	p.next()
+	return &DotExpr{p.use(p.enum.name, p.enum.pos), member, OpMember, Span{}}, true
+}
+
+// tsEnumNumber returns the value of an enum member's initializer if it is a numeric literal.
+func tsEnumNumber(expr IExpr) (float64, bool) {
+	neg := false
+	if unary, ok := expr.(*UnaryExpr); ok && unary.Op == NegToken {
+		neg = true
+		expr = unary.X
+	}
+	lit, ok := expr.(*LiteralExpr)
+	if !ok {
+		return 0, false
+	}
+	var f float64
+	var err error
+	switch lit.TokenType {
+	case DecimalToken:
+		f, err = strconv.ParseFloat(string(bytes.ReplaceAll(lit.Data, []byte("_"), nil)), 64)
+	case BinaryToken, OctalToken, HexadecimalToken:
+		var i int64
+		i, err = strconv.ParseInt(string(bytes.ReplaceAll(lit.Data, []byte("_"), nil)), 0, 64)
+		f = float64(i)
+	default:
+		return 0, false
+	}
+	if err != nil {
+		return 0, false
+	} else if neg {
+		f = -f
+	}
+	return f, true
+}
@@ -1,5 +1,18 @@
 package js
 
+import (
+	"bytes"
+	"unicode/utf8"
+
+	"github.com/tdewolff/parse/v2"
+)
+
+// NodePosition returns the line and column (both 1-based) of the start of the node in the input, as well as the contents of that line.
+func NodePosition(r *parse.Input, n INode) (line, col int, context string) {
+	start, _ := n.Offsets()
+	return parse.Position(bytes.NewReader(r.Bytes()), start)
+}
+
 // AsIdentifierName returns true if a valid identifier name is given.
 func AsIdentifierName(b []byte) bool {
 	if len(b) == 0 || !identifierStartTable[b[0]] {
@@ -36,3 +49,228 @@ func AsDecimalLiteral(b []byte) bool {
 	}
 	return i == len(b)
 }
+
+const hexDigits = "0123456789ABCDEF"
+
+// DecodeString returns the cooked value of a string literal including its quotes, such as LiteralExpr.Data for a StringToken. Escape sequences are decoded and line continuations are removed, and the result is encoded in UTF-8. JS strings are sequences of UTF-16 code units and may contain lone surrogates that have no UTF-8 encoding, these are encoded using the three-byte sequence of their code point as in WTF-8, which EncodeString will escape again. Surrogate pairs are always combined, also when written as two escape sequences. Errors contain the offset into the literal.
+func DecodeString(b []byte) ([]byte, error) {
+	if len(b) < 2 || b[0] != '"' && b[0] != '\'' || b[len(b)-1] != b[0] {
+		return nil, parse.NewError(bytes.NewBuffer(b), 0, "invalid string literal")
+	}
+	return decodeEscapes(b, 1, len(b)-1, false)
+}
+
+// DecodeTemplate returns the cooked value of a template literal part, such as TemplatePart.Value or TemplateExpr.Tail, which start with ` or } and end with ` or ${. Besides the escape sequences of DecodeString, carriage returns and CRLF are normalized to line feeds. Template literals do not allow legacy octal escape sequences, and any invalid escape sequence is an error. For tagged templates this is not a syntax error, the cooked value is then undefined.
+func DecodeTemplate(b []byte) ([]byte, error) {
+	if len(b) < 2 || b[0] != '`' && b[0] != '}' {
+		return nil, parse.NewError(bytes.NewBuffer(b), 0, "invalid template literal")
+	}
+	end := len(b) - 1
+	if b[end] == '{' && b[end-1] == '$' {
+		end--
+	} else if b[end] != '`' {
+		return nil, parse.NewError(bytes.NewBuffer(b), 0, "invalid template literal")
+	}
+	return decodeEscapes(b, 1, end, true)
+}
+
+func decodeEscapes(b []byte, start, end int, template bool) ([]byte, error) {
+	if bytes.IndexByte(b[start:end], '\\') == -1 && (!template || bytes.IndexByte(b[start:end], '\r') == -1) {
+		return b[start:end], nil
+	}
+
+	buf := make([]byte, 0, end-start)
+	for i := start; i < end; i++ {
+		c := b[i]
+		if c == '\r' && template {
+			buf = append(buf, '\n')
+			if i+1 < end && b[i+1] == '\n' {
+				i++
+			}
+			continue
+		} else if c != '\\' {
+			buf = append(buf, c)
+			continue
+		} else if i+1 == end {
+			return nil, parse.NewError(bytes.NewBuffer(b), i, "invalid escape sequence")
+		}
+
+		i++
+		switch c = b[i]; c {
+		case 'b':
+			buf = append(buf, '\b')
+		case 'f':
+			buf = append(buf, '\f')
+		case 'n':
+			buf = append(buf, '\n')
+		case 'r':
+			buf = append(buf, '\r')
+		case 't':
+			buf = append(buf, '\t')
+		case 'v':
+			buf = append(buf, '\v')
+		case '\n':
+			// line continuation
+		case '\r':
+			// line continuation
+			if i+1 < end && b[i+1] == '\n' {
+				i++
+			}
+		case 'x':
+			if end <= i+2 || hexDigit(b[i+1]) == -1 || hexDigit(b[i+2]) == -1 {
+				return nil, parse.NewError(bytes.NewBuffer(b), i-1, "invalid hexadecimal escape sequence")
+			}
+			buf = appendCodePoint(buf, rune(hexDigit(b[i+1])<<4|hexDigit(b[i+2])))
+			i += 2
+		case 'u':
+			r, n := decodeUnicodeEscape(b[i-1 : end])
+			if n == 0 {
+				return nil, parse.NewError(bytes.NewBuffer(b), i-1, "invalid unicode escape sequence")
+			}
+			buf = appendCodePoint(buf, r)
+			i += n - 2
+		case '0', '1', '2', '3', '4', '5', '6', '7':
+			if c == '0' && (end <= i+1 || b[i+1] < '0' || '9' < b[i+1]) {
+				buf = append(buf, 0)
+				break
+			} else if template {
+				return nil, parse.NewError(bytes.NewBuffer(b), i-1, "octal escape sequence not allowed in template")
+			}
+
+			// legacy octal escape sequence, at most \377
+			r := rune(c - '0')
+			if i+1 < end && '0' <= b[i+1] && b[i+1] <= '7' {
+				i++
+				r = r<<3 | rune(b[i]-'0')
+				if c <= '3' && i+1 < end && '0' <= b[i+1] && b[i+1] <= '7' {
+					i++
+					r = r<<3 | rune(b[i]-'0')
+				}
+			}
+			buf = appendCodePoint(buf, r)
+		case '8', '9':
+			if template {
+				return nil, parse.NewError(bytes.NewBuffer(b), i-1, "invalid escape sequence")
+			}
+			buf = append(buf, c)
+		default:
+			if c == 0xE2 && i+2 < end && b[i+1] == 0x80 && (b[i+2] == 0xA8 || b[i+2] == 0xA9) {
+				// line continuation of LS or PS
+				i += 2
+			} else {
+				buf = append(buf, c)
+			}
+		}
+	}
+	return buf, nil
+}
+
+// decodeUnicodeEscape decodes a \uXXXX or \u{X...} escape sequence at the start of b, and returns the code point and the length of the escape sequence, or zero if it is invalid.
+func decodeUnicodeEscape(b []byte) (rune, int) {
+	if len(b) < 3 || b[0] != '\\' || b[1] != 'u' {
+		return 0, 0
+	} else if b[2] == '{' {
+		r := rune(0)
+		i := 3
+		for i < len(b) && hexDigit(b[i]) != -1 {
+			r = r<<4 | rune(hexDigit(b[i]))
+			if utf8.MaxRune < r {
+				return 0, 0
+			}
+			i++
+		}
+		if i == 3 || len(b) <= i || b[i] != '}' {
+			return 0, 0
+		}
+		return r, i + 1
+	} else if len(b) < 6 {
+		return 0, 0
+	}
+	r := rune(0)
+	for _, c := range b[2:6] {
+		if hexDigit(c) == -1 {
+			return 0, 0
+		}
+		r = r<<4 | rune(hexDigit(c))
+	}
+	return r, 6
+}
+
+// appendCodePoint appends the code point as UTF-8, lone surrogates are appended as in WTF-8 and a low surrogate that follows a high surrogate forms a surrogate pair.
+func appendCodePoint(b []byte, r rune) []byte {
+	if r < 0xD800 || 0xDFFF < r {
+		n := len(b)
+		b = append(b, 0, 0, 0, 0)
+		return b[:n+utf8.EncodeRune(b[n:], r)]
+	} else if 0xDC00 <= r {
+		if n := len(b); 3 <= n && b[n-3] == 0xED && 0xA0 <= b[n-2] && b[n-2] <= 0xAF {
+			hi := rune(b[n-2]&0x0F)<<6 | rune(b[n-1]&0x3F)
+			return appendCodePoint(b[:n-3], 0x10000+(hi<<10|(r-0xDC00)))
+		}
+	}
+	return append(b, 0xED, byte(0x80|(r>>6)&0x3F), byte(0x80|r&0x3F))
+}
+
+// EncodeString returns a string literal for the cooked string value, choosing the quote that requires the fewest escapes and preferring double quotes. Only the quote, the backslash, NUL, line feeds, carriage returns, LS, and PS are escaped, as well as lone surrogates that are encoded as in WTF-8 (see DecodeString).
+func EncodeString(b []byte) []byte {
+	quote := byte('"')
+	if bytes.Count(b, []byte{'\''}) < bytes.Count(b, []byte{'"'}) {
+		quote = '\''
+	}
+
+	buf := make([]byte, 0, len(b)+2)
+	buf = append(buf, quote)
+	for i := 0; i < len(b); i++ {
+		switch c := b[i]; c {
+		case quote, '\\':
+			buf = append(buf, '\\', c)
+		case '\n':
+			buf = append(buf, '\\', 'n')
+		case '\r':
+			buf = append(buf, '\\', 'r')
+		case 0:
+			if i+1 < len(b) && '0' <= b[i+1] && b[i+1] <= '9' {
+				buf = append(buf, '\\', 'x', '0', '0')
+			} else {
+				buf = append(buf, '\\', '0')
+			}
+		case 0xE2:
+			if i+2 < len(b) && b[i+1] == 0x80 && (b[i+2] == 0xA8 || b[i+2] == 0xA9) {
+				buf = append(buf, '\\', 'u', '2', '0', '2', hexDigits[b[i+2]-0xA0])
+				i += 2
+				break
+			}
+			buf = append(buf, c)
+		case 0xED:
+			if i+2 < len(b) && 0xA0 <= b[i+1] && b[i+1] <= 0xBF {
+				r := 0xD000 | uint16(b[i+1]&0x3F)<<6 | uint16(b[i+2]&0x3F)
+				buf = append(buf, '\\', 'u', hexDigits[r>>12], hexDigits[r>>8&0xF], hexDigits[r>>4&0xF], hexDigits[r&0xF])
+				i += 2
+				break
+			}
+			buf = append(buf, c)
+		default:
+			buf = append(buf, c)
+		}
+	}
+	return append(buf, quote)
+}
+
+// DecodeIdentifier returns the identifier name with its unicode escape sequences decoded, so that `\u0061` and `a` are the same name. The input is returned when it has no escape sequences.
+func DecodeIdentifier(b []byte) []byte {
+	if bytes.IndexByte(b, '\\') == -1 {
+		return b
+	}
+	buf := make([]byte, 0, len(b))
+	for i := 0; i < len(b); i++ {
+		if b[i] == '\\' {
+			if r, n := decodeUnicodeEscape(b[i:]); n != 0 {
+				buf = appendCodePoint(buf, r)
+				i += n - 1
+				continue
+			}
+		}
+		buf = append(buf, b[i])
+	}
+	return buf
+}
@@ -3,6 +3,8 @@ package js
 import (
 	"testing"
 
+	"github.com/tdewolff/parse/v2"
+
 	"github.com/tdewolff/test"
 )
 
@@ -24,3 +26,116 @@ func TestAsDecimalLiteral(t *testing.T) {
 	test.That(t, AsDecimalLiteral([]byte("0")))
 	test.That(t, !AsDecimalLiteral([]byte("00")))
 }
+
+func TestDecodeString(t *testing.T) {
+	var tests = []struct {
+		str      string
+		expected string
+	}{
+		{`""`, ""},
+		{`'abc'`, "abc"},
+		{`"a\"b\'c"`, "a\"b'c"},
+		{`"\b\f\n\r\t\v\\\a"`, "\b\f\n\r\t\v\\a"},
+		{`"\x41\u0042\u{43}\u{1F600}"`, "ABC\U0001F600"},
+		{`"\uD83D\uDE00\uD83D\u{DE00}"`, "\U0001F600\U0001F600"},
+		{`"\uD83D\uD83D"`, "\xED\xA0\xBD\xED\xA0\xBD"},
+		{`"\uDE00"`, "\xED\xB8\x80"},
+		{`"\0\00\1\01\101\400\8\9"`, "\x00\x00\x01\x01A\x200\x38\x39"},
+		{"'a\\\nb\\\r\nc\\\u2028d'", "abcd"},
+		{"'\u2028'", "\u2028"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.str, func(t *testing.T) {
+			str, err := DecodeString([]byte(tt.str))
+			test.Error(t, err)
+			test.String(t, string(str), tt.expected)
+		})
+	}
+}
+
+func TestDecodeTemplate(t *testing.T) {
+	var tests = []struct {
+		tmpl     string
+		expected string
+	}{
+		{"``", ""},
+		{"`a${", "a"},
+		{"}b${", "b"},
+		{"}c`", "c"},
+		{"`a\r\nb\rc`", "a\nb\nc"},
+		{"`\\r\\\r\n\\0\\u{61}`", "\r\x00a"},
+		{"`$\\${`", "$${"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.tmpl, func(t *testing.T) {
+			tmpl, err := DecodeTemplate([]byte(tt.tmpl))
+			test.Error(t, err)
+			test.String(t, string(tmpl), tt.expected)
+		})
+	}
+}
+
+func TestDecodeStringError(t *testing.T) {
+	var tests = []struct {
+		str    string
+		err    string
+		offset int
+	}{
+		{`"a'`, "invalid string literal", 0},
+		{`"\x4"`, "invalid hexadecimal escape sequence", 1},
+		{`"a\u004"`, "invalid unicode escape sequence", 2},
+		{`"\u{}"`, "invalid unicode escape sequence", 1},
+		{`"\u{110000}"`, "invalid unicode escape sequence", 1},
+		{"`\\01`", "octal escape sequence not allowed in template", 1},
+		{"`\\8`", "invalid escape sequence", 1},
+		{"`a", "invalid template literal", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.str, func(t *testing.T) {
+			var err error
+			if tt.str[0] == '`' {
+				_, err = DecodeTemplate([]byte(tt.str))
+			} else {
+				_, err = DecodeString([]byte(tt.str))
+			}
+			test.That(t, err != nil)
+			if err != nil {
+				test.String(t, err.(*parse.Error).Message, tt.err)
+				test.T(t, err.(*parse.Error).Offset, tt.offset)
+			}
+		})
+	}
+}
+
+func TestEncodeString(t *testing.T) {
+	var tests = []struct {
+		str      string
+		expected string
+	}{
+		{"", `""`},
+		{"abc", `"abc"`},
+		{"a'b", `"a'b"`},
+		{"a\"b", `'a"b'`},
+		{"a\"b'c", `"a\"b'c"`},
+		{"a\"\"b'c", `'a""b\'c'`},
+		{"\\\n\r\t\x00\u2028\u2029", "\"\\\\\\n\\r\t\\0\\u2028\\u2029\""},
+		{"\x001\x00a", `"\x001\0a"`},
+		{"\xE2\x80\xA7", "\"\xE2\x80\xA7\""},
+		{"\xED\xA0\xBD\U0001F600", "\"\\uD83D\U0001F600\""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.str, func(t *testing.T) {
+			test.String(t, string(EncodeString([]byte(tt.str))), tt.expected)
+		})
+	}
+
+	// round trip of lone surrogates
+	str, _ := DecodeString([]byte(`'\uDE00a\uD83D'`))
+	test.String(t, string(EncodeString(str)), `"\uDE00a\uD83D"`)
+}
+
+func TestDecodeIdentifier(t *testing.T) {
+	test.String(t, string(DecodeIdentifier([]byte("abc"))), "abc")
+	test.String(t, string(DecodeIdentifier([]byte("\\u0061b\\u{63}"))), "abc")
+	test.String(t, string(DecodeIdentifier([]byte("\\u{1D400}"))), "\U0001D400")
+}
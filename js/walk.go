@@ -1,5 +1,11 @@
 package js
 
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
 // IVisitor represents the AST Visitor
 // Each INode encountered by `Walk` is passed to `Enter`, children nodes will be ignored if the returned IVisitor is nil
 // `Exit` is called upon the exit of a node
@@ -8,7 +14,7 @@ type IVisitor interface {
 	Exit(n INode)
 }
 
-// Walk traverses an AST in depth-first order
+// Walk traverses an AST in depth-first order, see WalkPath for a traversal in source order
 func Walk(v IVisitor, n INode) {
 	if n == nil {
 		return
@@ -112,12 +118,20 @@ func Walk(v IVisitor, n INode) {
 		return
 	case *Alias:
 		return
+	case *ImportAttribute:
+		return
 	case *ImportStmt:
 		if n.List != nil {
 			for i := 0; i < len(n.List); i++ {
 				Walk(v, &n.List[i])
 			}
 		}
+
+		if n.Attributes != nil {
+			for i := 0; i < len(n.Attributes); i++ {
+				Walk(v, &n.Attributes[i])
+			}
+		}
 	case *ExportStmt:
 		if n.List != nil {
 			for i := 0; i < len(n.List); i++ {
@@ -126,6 +140,12 @@ func Walk(v IVisitor, n INode) {
 		}
 
 		Walk(v, n.Decl)
+
+		if n.Attributes != nil {
+			for i := 0; i < len(n.Attributes); i++ {
+				Walk(v, &n.Attributes[i])
+			}
+		}
 	case *DirectivePrologueStmt:
 		return
 	case *PropertyName:
@@ -183,10 +203,32 @@ func Walk(v IVisitor, n INode) {
 		Walk(v, &n.Body)
 		Walk(v, &n.Params)
 		Walk(v, &n.Name)
+
+		if n.Decorators != nil {
+			for i := 0; i < len(n.Decorators); i++ {
+				Walk(v, n.Decorators[i])
+			}
+		}
 	case *FieldDefinition:
-		Walk(v, &n.Name)
-		Walk(v, n.Init)
+		if n.Body != nil {
+			Walk(v, n.Body)
+		} else {
+			Walk(v, &n.Name)
+			Walk(v, n.Init)
+		}
+
+		if n.Decorators != nil {
+			for i := 0; i < len(n.Decorators); i++ {
+				Walk(v, n.Decorators[i])
+			}
+		}
 	case *ClassDecl:
+		if n.Decorators != nil {
+			for i := 0; i < len(n.Decorators); i++ {
+				Walk(v, n.Decorators[i])
+			}
+		}
+
 		if n.Name != nil {
 			Walk(v, n.Name)
 		}
@@ -283,7 +325,353 @@ func Walk(v IVisitor, n INode) {
 	case *ArrowFunc:
 		Walk(v, &n.Body)
 		Walk(v, &n.Params)
+	case *JSXElement:
+		Walk(v, n.Name)
+		for i := 0; i < len(n.Attrs); i++ {
+			Walk(v, &n.Attrs[i])
+		}
+		for i := 0; i < len(n.Children); i++ {
+			Walk(v, n.Children[i])
+		}
+	case *JSXAttribute:
+		if n.Name != nil {
+			Walk(v, n.Name)
+		}
+		Walk(v, n.Value)
+	case *JSXExprContainer:
+		Walk(v, n.X)
 	default:
 		return
 	}
 }
+
+// Path is a node and its position in the AST, which is the field of the parent node that holds it and the index if that field is a list
+type Path struct {
+	Node   INode
+	Parent *Path  // nil for the root node
+	Name   string // field of the parent node, such as Body or List
+	Index  int    // index in the list of the parent node, or -1 if not in a list
+}
+
+// String returns the type of the root node followed by the field names and indices up to the node, such as IfStmt.Body.List[0]
+func (p *Path) String() string {
+	if p.Parent == nil {
+		return strings.TrimPrefix(fmt.Sprintf("%T", p.Node), "*js.")
+	}
+	s := p.Parent.String() + "." + p.Name
+	if p.Index != -1 {
+		s += "[" + strconv.Itoa(p.Index) + "]"
+	}
+	return s
+}
+
+// Ancestor returns the n-th ancestor of the node, where 1 is the parent node, or nil if it doesn't exist
+func (p *Path) Ancestor(n int) INode {
+	for ; p != nil && 0 < n; n-- {
+		p = p.Parent
+	}
+	if p == nil {
+		return nil
+	}
+	return p.Node
+}
+
+// IPathVisitor represents an AST visitor that receives the path of each node
+// Each INode encountered by `WalkPath` is passed to `Enter`, children nodes will be ignored if the returned IPathVisitor is nil
+// `Exit` is called upon the exit of a node
+type IPathVisitor interface {
+	Enter(p *Path) IPathVisitor
+	Exit(p *Path)
+}
+
+// WalkPath traverses an AST in depth-first and source order and passes the path of each node, which includes its ancestors and its field in the parent node
+func WalkPath(v IPathVisitor, n INode) {
+	if n == nil {
+		return
+	}
+	walkPath(v, &Path{n, nil, "", -1})
+}
+
+func walkPath(v IPathVisitor, p *Path) {
+	if v = v.Enter(p); v == nil {
+		return
+	}
+	eachChild(p.Node, func(child INode, name string, index int) {
+		walkPath(v, &Path{child, p, name, index})
+	})
+	v.Exit(p)
+}
+
+// Children returns the child nodes in source order
+func Children(n INode) []INode {
+	var list []INode
+	eachChild(n, func(child INode, name string, index int) {
+		list = append(list, child)
+	})
+	return list
+}
+
+// eachChild calls f for the child nodes in source order, with the name of the field and the index in the list or -1. Fields that are nil are skipped.
+func eachChild(n INode, f func(INode, string, int)) {
+	stmt := func(child IStmt, name string) {
+		if child != nil {
+			f(child, name, -1)
+		}
+	}
+	expr := func(child IExpr, name string) {
+		if child != nil {
+			f(child, name, -1)
+		}
+	}
+	binding := func(child IBinding, name string) {
+		if child != nil {
+			f(child, name, -1)
+		}
+	}
+
+	switch n := n.(type) {
+	case *AST:
+		f(&n.BlockStmt, "BlockStmt", -1)
+	case *BlockStmt:
+		for i, item := range n.List {
+			if item != nil {
+				f(item, "List", i)
+			}
+		}
+	case *ExprStmt:
+		expr(n.Value, "Value")
+	case *IfStmt:
+		expr(n.Cond, "Cond")
+		stmt(n.Body, "Body")
+		stmt(n.Else, "Else")
+	case *DoWhileStmt:
+		stmt(n.Body, "Body")
+		expr(n.Cond, "Cond")
+	case *WhileStmt:
+		expr(n.Cond, "Cond")
+		stmt(n.Body, "Body")
+	case *ForStmt:
+		expr(n.Init, "Init")
+		expr(n.Cond, "Cond")
+		expr(n.Post, "Post")
+		if n.Body != nil {
+			f(n.Body, "Body", -1)
+		}
+	case *ForInStmt:
+		expr(n.Init, "Init")
+		expr(n.Value, "Value")
+		if n.Body != nil {
+			f(n.Body, "Body", -1)
+		}
+	case *ForOfStmt:
+		expr(n.Init, "Init")
+		expr(n.Value, "Value")
+		if n.Body != nil {
+			f(n.Body, "Body", -1)
+		}
+	case *CaseClause:
+		expr(n.Cond, "Cond")
+		for i, item := range n.List {
+			if item != nil {
+				f(item, "List", i)
+			}
+		}
+	case *SwitchStmt:
+		expr(n.Init, "Init")
+		for i := range n.List {
+			f(&n.List[i], "List", i)
+		}
+	case *ReturnStmt:
+		expr(n.Value, "Value")
+	case *WithStmt:
+		expr(n.Cond, "Cond")
+		stmt(n.Body, "Body")
+	case *LabelledStmt:
+		stmt(n.Value, "Value")
+	case *ThrowStmt:
+		expr(n.Value, "Value")
+	case *TryStmt:
+		if n.Body != nil {
+			f(n.Body, "Body", -1)
+		}
+		binding(n.Binding, "Binding")
+		if n.Catch != nil {
+			f(n.Catch, "Catch", -1)
+		}
+		if n.Finally != nil {
+			f(n.Finally, "Finally", -1)
+		}
+	case *ImportStmt:
+		for i := range n.List {
+			f(&n.List[i], "List", i)
+		}
+		for i := range n.Attributes {
+			f(&n.Attributes[i], "Attributes", i)
+		}
+	case *ExportStmt:
+		for i := range n.List {
+			f(&n.List[i], "List", i)
+		}
+		expr(n.Decl, "Decl")
+		for i := range n.Attributes {
+			f(&n.Attributes[i], "Attributes", i)
+		}
+	case *PropertyName:
+		if n.Computed != nil {
+			f(n.Computed, "Computed", -1)
+		} else {
+			f(&n.Literal, "Literal", -1)
+		}
+	case *BindingArray:
+		for i := range n.List {
+			f(&n.List[i], "List", i)
+		}
+		binding(n.Rest, "Rest")
+	case *BindingObjectItem:
+		if n.Key != nil {
+			f(n.Key, "Key", -1)
+		}
+		f(&n.Value, "Value", -1)
+	case *BindingObject:
+		for i := range n.List {
+			f(&n.List[i], "List", i)
+		}
+		if n.Rest != nil {
+			f(n.Rest, "Rest", -1)
+		}
+	case *BindingElement:
+		binding(n.Binding, "Binding")
+		expr(n.Default, "Default")
+	case *VarDecl:
+		for i := range n.List {
+			f(&n.List[i], "List", i)
+		}
+	case *Params:
+		for i := range n.List {
+			f(&n.List[i], "List", i)
+		}
+		binding(n.Rest, "Rest")
+	case *FuncDecl:
+		if n.Name != nil {
+			f(n.Name, "Name", -1)
+		}
+		f(&n.Params, "Params", -1)
+		f(&n.Body, "Body", -1)
+	case *MethodDecl:
+		for i, item := range n.Decorators {
+			f(item, "Decorators", i)
+		}
+		f(&n.Name, "Name", -1)
+		f(&n.Params, "Params", -1)
+		f(&n.Body, "Body", -1)
+	case *FieldDefinition:
+		for i, item := range n.Decorators {
+			f(item, "Decorators", i)
+		}
+		if n.Body != nil {
+			f(n.Body, "Body", -1)
+		} else {
+			f(&n.Name, "Name", -1)
+			expr(n.Init, "Init")
+		}
+	case *ClassDecl:
+		for i, item := range n.Decorators {
+			f(item, "Decorators", i)
+		}
+		if n.Name != nil {
+			f(n.Name, "Name", -1)
+		}
+		expr(n.Extends, "Extends")
+
+		// definitions and methods are interleaved in source order, or in declaration order if their spans are not set
+		i, j := 0, 0
+		for i < len(n.Definitions) || j < len(n.Methods) {
+			if j == len(n.Methods) || i < len(n.Definitions) && (n.Definitions[i].Start <= n.Methods[j].Start || n.Methods[j].End == 0) {
+				f(&n.Definitions[i], "Definitions", i)
+				i++
+			} else {
+				f(n.Methods[j], "Methods", j)
+				j++
+			}
+		}
+	case *Element:
+		expr(n.Value, "Value")
+	case *ArrayExpr:
+		for i := range n.List {
+			f(&n.List[i], "List", i)
+		}
+	case *Property:
+		if n.Name != nil {
+			f(n.Name, "Name", -1)
+		}
+		expr(n.Value, "Value")
+		expr(n.Init, "Init")
+	case *ObjectExpr:
+		for i := range n.List {
+			f(&n.List[i], "List", i)
+		}
+	case *TemplatePart:
+		expr(n.Expr, "Expr")
+	case *TemplateExpr:
+		expr(n.Tag, "Tag")
+		for i := range n.List {
+			f(&n.List[i], "List", i)
+		}
+	case *GroupExpr:
+		expr(n.X, "X")
+	case *IndexExpr:
+		expr(n.X, "X")
+		expr(n.Y, "Y")
+	case *DotExpr:
+		expr(n.X, "X")
+		f(&n.Y, "Y", -1)
+	case *Arg:
+		expr(n.Value, "Value")
+	case *Args:
+		for i := range n.List {
+			f(&n.List[i], "List", i)
+		}
+	case *NewExpr:
+		expr(n.X, "X")
+		if n.Args != nil {
+			f(n.Args, "Args", -1)
+		}
+	case *CallExpr:
+		expr(n.X, "X")
+		f(&n.Args, "Args", -1)
+	case *OptChainExpr:
+		expr(n.X, "X")
+		expr(n.Y, "Y")
+	case *UnaryExpr:
+		expr(n.X, "X")
+	case *BinaryExpr:
+		expr(n.X, "X")
+		expr(n.Y, "Y")
+	case *CondExpr:
+		expr(n.Cond, "Cond")
+		expr(n.X, "X")
+		expr(n.Y, "Y")
+	case *YieldExpr:
+		expr(n.X, "X")
+	case *ArrowFunc:
+		f(&n.Params, "Params", -1)
+		f(&n.Body, "Body", -1)
+	case *JSXElement:
+		expr(n.Name, "Name")
+		for i := range n.Attrs {
+			f(&n.Attrs[i], "Attrs", i)
+		}
+		for i, item := range n.Children {
+			if item != nil {
+				f(item, "Children", i)
+			}
+		}
+	case *JSXAttribute:
+		if n.Name != nil {
+			f(n.Name, "Name", -1)
+		}
+		expr(n.Value, "Value")
+	case *JSXExprContainer:
+		expr(n.X, "X")
+	}
+}
@@ -43,10 +43,30 @@ func TestWalk(t *testing.T) {
 	})
 }
 
+func TestWalkDecorators(t *testing.T) {
+	ast, err := Parse(parse.NewInputString("@x class A { static { x } @x.y c; @x(x) b() {} }"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	Walk(&walker{}, ast)
+	test.String(t, ast.JS(), "@obj class A { static { obj; }; @obj.y c; @obj(obj) b () { }; }; ")
+}
+
+func TestWalkJSX(t *testing.T) {
+	ast, err := ParseWithOptions(parse.NewInputString("<a b={x}>{x.c}<X.d {...x}/></a>"), ParseOptions{JSX: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	Walk(&walker{}, ast)
+	test.String(t, ast.JS(), "<a b={obj}>{obj.c}<X.d {...obj} /></a>; ")
+}
+
 func TestWalkNilNode(t *testing.T) {
 	nodes := []INode{
 		&AST{},
-		&Var{},
+		NewVar(nil, NoDecl),
 		&BlockStmt{},
 		&EmptyStmt{},
 		&ExprStmt{},
@@ -66,6 +86,7 @@ func TestWalkNilNode(t *testing.T) {
 		&TryStmt{},
 		&DebuggerStmt{},
 		&Alias{},
+		&ImportAttribute{},
 		&ImportStmt{},
 		&ExportStmt{},
 		&DirectivePrologueStmt{},
@@ -102,11 +123,113 @@ func TestWalkNilNode(t *testing.T) {
 		&CondExpr{},
 		&YieldExpr{},
 		&ArrowFunc{},
+		&JSXName{},
+		&JSXAttribute{},
+		&JSXExprContainer{},
+		&JSXText{},
+		&JSXElement{},
 	}
 
 	t.Run("TestWalkNilNode", func(t *testing.T) {
 		for _, n := range nodes {
 			Walk(&walker{}, n)
+			WalkPath(&pathWalker{}, n)
+			Children(n)
 		}
 	})
 }
+
+type pathWalker struct {
+	paths []string
+}
+
+func (w *pathWalker) Enter(p *Path) IPathVisitor {
+	if _, ok := p.Node.(*Var); ok {
+		w.paths = append(w.paths, p.String())
+	}
+	return w
+}
+
+func (w *pathWalker) Exit(p *Path) {}
+
+func TestWalkPath(t *testing.T) {
+	var tests = []struct {
+		js       string
+		expected []string
+	}{
+		{"if (a) b; else c", []string{"AST.BlockStmt.List[0].Cond", "AST.BlockStmt.List[0].Body.Value", "AST.BlockStmt.List[0].Else.Value"}},
+		{"for (a; b; c) d", []string{"AST.BlockStmt.List[0].Init", "AST.BlockStmt.List[0].Cond", "AST.BlockStmt.List[0].Post", "AST.BlockStmt.List[0].Body.List[0].Value"}},
+		{"a(b, ...c)", []string{"AST.BlockStmt.List[0].Value.X", "AST.BlockStmt.List[0].Value.Args.List[0].Value", "AST.BlockStmt.List[0].Value.Args.List[1].Value"}},
+		{"class a { b() { c } d = e }", []string{"AST.BlockStmt.List[0].Name", "AST.BlockStmt.List[0].Methods[0].Body.List[0].Value", "AST.BlockStmt.List[0].Definitions[0].Init"}},
+		{"@a class b { static { c } @d e = f }", []string{"AST.BlockStmt.List[0].Decorators[0]", "AST.BlockStmt.List[0].Name", "AST.BlockStmt.List[0].Definitions[0].Body.List[0].Value", "AST.BlockStmt.List[0].Definitions[1].Decorators[0]", "AST.BlockStmt.List[0].Definitions[1].Init"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.js, func(t *testing.T) {
+			ast, err := Parse(parse.NewInputString(tt.js))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			w := &pathWalker{}
+			WalkPath(w, ast)
+			test.T(t, w.paths, tt.expected)
+		})
+	}
+}
+
+type calleeWalker struct {
+	callees []string
+}
+
+func (w *calleeWalker) Enter(p *Path) IPathVisitor {
+	if v, ok := p.Node.(*Var); ok && p.Name == "X" {
+		if _, ok := p.Ancestor(1).(*CallExpr); ok {
+			w.callees = append(w.callees, string(v.Data))
+		}
+	}
+	return w
+}
+
+func (w *calleeWalker) Exit(p *Path) {}
+
+func TestWalkPathAncestor(t *testing.T) {
+	ast, err := Parse(parse.NewInputString("a(b(c), d.e(f))"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w := &calleeWalker{}
+	WalkPath(w, ast)
+	test.T(t, w.callees, []string{"a", "b"})
+
+	path := &Path{&Var{}, &Path{&CallExpr{}, nil, "", -1}, "X", -1}
+	test.T(t, path.Ancestor(0), INode(path.Node))
+	test.T(t, path.Ancestor(1), INode(path.Parent.Node))
+	test.T(t, path.Ancestor(2), nil)
+}
+
+func TestChildren(t *testing.T) {
+	ast, err := Parse(parse.NewInputString("while (a) b"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stmt := ast.BlockStmt.List[0].(*WhileStmt)
+	children := Children(stmt)
+	test.T(t, len(children), 2)
+	test.T(t, children[0], INode(stmt.Cond))
+	test.T(t, children[1], INode(stmt.Body))
+	test.T(t, len(Children(&DebuggerStmt{})), 0)
+}
+
+func TestChildrenClassOrder(t *testing.T) {
+	method := &MethodDecl{Name: PropertyName{Literal: LiteralExpr{IdentifierToken, []byte("m"), Span{}}}}
+	classDecl := &ClassDecl{
+		Definitions: []FieldDefinition{{Name: PropertyName{Literal: LiteralExpr{IdentifierToken, []byte("a"), Span{}}}}},
+		Methods:     []*MethodDecl{method},
+	}
+	children := Children(classDecl)
+	test.T(t, len(children), 2)
+	test.T(t, children[0], INode(&classDecl.Definitions[0]))
+	test.T(t, children[1], INode(method))
+}
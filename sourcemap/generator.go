@@ -0,0 +1,139 @@
+package sourcemap
+
+import (
+	"sort"
+	"unicode/utf8"
+)
+
+type generatorSource struct {
+	name    string
+	content []byte
+	lines   []int // start offsets of the lines
+}
+
+type offsetMapping struct {
+	gen    int
+	source int
+	offset int
+	name   int
+}
+
+// Generator builds a source map from byte offsets in the generated file to byte offsets in the sources, such as the offsets of the nodes and tokens of a parse.Input.
+type Generator struct {
+	File           string
+	IncludeContent bool // include the source contents in the source map
+
+	sources   []generatorSource
+	names     []string
+	nameIndex map[string]int
+	mappings  []offsetMapping
+}
+
+// NewGenerator returns a new Generator for the given generated file name.
+func NewGenerator(file string) *Generator {
+	return &Generator{
+		File:      file,
+		nameIndex: map[string]int{},
+	}
+}
+
+// AddSource adds a source with its contents and returns its index, which is to be passed to Add.
+func (g *Generator) AddSource(name string, content []byte) int {
+	g.sources = append(g.sources, generatorSource{
+		name:    name,
+		content: content,
+		lines:   lineOffsets(content),
+	})
+	return len(g.sources) - 1
+}
+
+// SourceContent returns the contents of the source at the given index.
+func (g *Generator) SourceContent(source int) []byte {
+	return g.sources[source].content
+}
+
+// AddName adds a name for identifiers and returns its index, which is to be passed to Add. Adding the same name twice returns the same index.
+func (g *Generator) AddName(name string) int {
+	if i, ok := g.nameIndex[name]; ok {
+		return i
+	}
+	g.names = append(g.names, name)
+	g.nameIndex[name] = len(g.names) - 1
+	return len(g.names) - 1
+}
+
+// Add maps the byte offset gen in the generated file to the byte offset in the source. The name may be -1.
+func (g *Generator) Add(gen, source, offset, name int) {
+	g.mappings = append(g.mappings, offsetMapping{gen, source, offset, name})
+}
+
+// Len returns the number of added mappings.
+func (g *Generator) Len() int {
+	return len(g.mappings)
+}
+
+// SourceMap returns the source map for the generated file.
+func (g *Generator) SourceMap(generated []byte) *SourceMap {
+	m := &SourceMap{
+		File:     g.File,
+		Sources:  make([]string, len(g.sources)),
+		Names:    append([]string{}, g.names...),
+		Mappings: make([]Mapping, 0, len(g.mappings)),
+	}
+	if g.IncludeContent {
+		m.SourcesContent = make([]string, len(g.sources))
+	}
+	for i, source := range g.sources {
+		m.Sources[i] = source.name
+		if g.IncludeContent {
+			m.SourcesContent[i] = string(source.content)
+		}
+	}
+
+	mappings := append([]offsetMapping{}, g.mappings...)
+	sort.SliceStable(mappings, func(i, j int) bool {
+		return mappings[i].gen < mappings[j].gen
+	})
+	genLines := lineOffsets(generated)
+	for i, mapping := range mappings {
+		if 0 < i && mappings[i-1].gen == mapping.gen {
+			continue // keep the first mapping for a generated position
+		}
+		genLine, genCol := position(generated, genLines, mapping.gen)
+		source := g.sources[mapping.source]
+		line, col := position(source.content, source.lines, mapping.offset)
+		m.Mappings = append(m.Mappings, Mapping{genLine, genCol, mapping.source, line, col, mapping.name})
+	}
+	return m
+}
+
+// lineOffsets returns the start offsets of all lines, where lines are terminated by \n, \r, \r\n, \u2028, or \u2029.
+func lineOffsets(b []byte) []int {
+	lines := []int{0}
+	for i := 0; i < len(b); i++ {
+		if b[i] == '\n' || b[i] == '\r' && (i+1 == len(b) || b[i+1] != '\n') {
+			lines = append(lines, i+1)
+		} else if b[i] == 0xE2 && i+2 < len(b) && b[i+1] == 0x80 && (b[i+2] == 0xA8 || b[i+2] == 0xA9) {
+			lines = append(lines, i+3)
+			i += 2
+		}
+	}
+	return lines
+}
+
+// position returns the zero-based line and column in UTF-16 code units for the byte offset.
+func position(b []byte, lines []int, offset int) (int, int) {
+	if len(b) < offset {
+		offset = len(b)
+	}
+	line := sort.SearchInts(lines, offset+1) - 1
+	col := 0
+	for _, r := range string(b[lines[line]:offset]) {
+		if utf8.RuneLen(r) == 4 {
+			col += 2 // surrogate pair
+		} else {
+			col++
+		}
+	}
+	return line, col
+}
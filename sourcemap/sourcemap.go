@@ -0,0 +1,359 @@
+// Package sourcemap encodes and decodes source maps following the Source Map Revision 3 proposal.
+package sourcemap
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/tdewolff/parse/v2"
+)
+
+// Mapping maps a position in the generated file to a position in one of the sources. Lines and columns are zero-based and columns count UTF-16 code units, as required by the specification.
+type Mapping struct {
+	GenLine int
+	GenCol  int
+	Source  int // index into Sources, or -1 when the generated position has no source
+	Line    int
+	Col     int
+	Name    int // index into Names, or -1
+}
+
+// SourceMap is a decoded source map.
+type SourceMap struct {
+	File           string
+	SourceRoot     string
+	Sources        []string
+	SourcesContent []string // same length as Sources when set, empty strings denote unknown content
+	Names          []string
+	Mappings       []Mapping // ordered by generated position
+}
+
+type sourceMapJSON struct {
+	Version        int           `json:"version"`
+	File           string        `json:"file,omitempty"`
+	SourceRoot     string        `json:"sourceRoot,omitempty"`
+	Sources        []string      `json:"sources"`
+	SourcesContent []*string     `json:"sourcesContent,omitempty"`
+	Names          []string      `json:"names"`
+	Mappings       string        `json:"mappings"`
+	Sections       []sectionJSON `json:"sections,omitempty"`
+}
+
+type sectionJSON struct {
+	Offset struct {
+		Line   int `json:"line"`
+		Column int `json:"column"`
+	} `json:"offset"`
+	Map *sourceMapJSON `json:"map"`
+}
+
+// Parse decodes a source map in JSON format. Index maps with sections are flattened into a single source map.
+func Parse(b []byte) (*SourceMap, error) {
+	if bytes.HasPrefix(b, []byte(")]}")) {
+		// skip XSSI protection prefix
+		if i := bytes.IndexByte(b, '\n'); i != -1 {
+			b = b[i+1:]
+		} else {
+			b = b[:0]
+		}
+	}
+
+	j := &sourceMapJSON{}
+	if err := json.Unmarshal(b, j); err != nil {
+		return nil, err
+	}
+	m := &SourceMap{}
+	if err := m.decode(j, 0, 0); err != nil {
+		return nil, err
+	}
+	for _, content := range m.SourcesContent {
+		if content != "" {
+			return m, nil
+		}
+	}
+	m.SourcesContent = nil
+	return m, nil
+}
+
+func (m *SourceMap) decode(j *sourceMapJSON, line, col int) error {
+	if j.Version != 3 {
+		return fmt.Errorf("unsupported source map version %d", j.Version)
+	}
+	if m.File == "" {
+		m.File = j.File
+	}
+	if j.Sections != nil {
+		for i, section := range j.Sections {
+			if section.Map == nil {
+				return fmt.Errorf("missing map in section %d", i)
+			}
+			if err := m.decode(section.Map, section.Offset.Line, section.Offset.Column); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	// sections add their sources and names after the ones already present, with their source root applied
+	sourceRoot := j.SourceRoot
+	if line == 0 && col == 0 && len(m.Sources) == 0 {
+		m.SourceRoot = sourceRoot
+		sourceRoot = ""
+	}
+	sourceOffset := len(m.Sources)
+	for i, source := range j.Sources {
+		m.Sources = append(m.Sources, joinPath(sourceRoot, source))
+		content := ""
+		if i < len(j.SourcesContent) && j.SourcesContent[i] != nil {
+			content = *j.SourcesContent[i]
+		}
+		m.SourcesContent = append(m.SourcesContent, content)
+	}
+	nameOffset := len(m.Names)
+	m.Names = append(m.Names, j.Names...)
+
+	mappings, err := DecodeMappings([]byte(j.Mappings))
+	if err != nil {
+		return err
+	}
+	for _, mapping := range mappings {
+		if len(j.Sources) <= mapping.Source || len(j.Names) <= mapping.Name {
+			return fmt.Errorf("mapping at line %d and column %d refers to a non-existing source or name", mapping.GenLine+1, mapping.GenCol+1)
+		}
+		if mapping.GenLine == 0 {
+			mapping.GenCol += col
+		}
+		mapping.GenLine += line
+		if mapping.Source != -1 {
+			mapping.Source += sourceOffset
+		}
+		if mapping.Name != -1 {
+			mapping.Name += nameOffset
+		}
+		m.Mappings = append(m.Mappings, mapping)
+	}
+	return nil
+}
+
+// Bytes encodes the source map in JSON format.
+func (m *SourceMap) Bytes() []byte {
+	j := sourceMapJSON{
+		Version:    3,
+		File:       m.File,
+		SourceRoot: m.SourceRoot,
+		Sources:    m.Sources,
+		Names:      m.Names,
+		Mappings:   string(EncodeMappings(m.Mappings)),
+	}
+	if j.Sources == nil {
+		j.Sources = []string{}
+	}
+	if j.Names == nil {
+		j.Names = []string{}
+	}
+	if len(m.SourcesContent) != 0 {
+		j.SourcesContent = make([]*string, len(m.Sources))
+		for i := range m.SourcesContent {
+			if i < len(j.SourcesContent) && m.SourcesContent[i] != "" {
+				j.SourcesContent[i] = &m.SourcesContent[i]
+			}
+		}
+	}
+
+	buf := &bytes.Buffer{}
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(j); err != nil {
+		panic(err) // cannot happen
+	}
+	return bytes.TrimRight(buf.Bytes(), "\n")
+}
+
+// DataURL returns the source map as a base64 encoded data URL, which can be used to inline the source map into the generated file.
+func (m *SourceMap) DataURL() string {
+	return "data:application/json;charset=utf-8;base64," + base64.StdEncoding.EncodeToString(m.Bytes())
+}
+
+// Find returns the mapping for the given zero-based line and column in the generated file, that is the last mapping on that line that starts at or before the column. It returns false when there is no such mapping or when it has no source.
+func (m *SourceMap) Find(line, col int) (Mapping, bool) {
+	i := sort.Search(len(m.Mappings), func(i int) bool {
+		return line < m.Mappings[i].GenLine || line == m.Mappings[i].GenLine && col < m.Mappings[i].GenCol
+	})
+	if i == 0 || m.Mappings[i-1].GenLine != line || m.Mappings[i-1].Source == -1 {
+		return Mapping{}, false
+	}
+	return m.Mappings[i-1], true
+}
+
+// Compose returns a source map from the generated file of m to the sources of inner, where inner is the source map of the source at index source in m. This chains the source maps of consecutive transformations. Mappings to other sources of m are kept, and mappings that cannot be traced through inner lose their source.
+func (m *SourceMap) Compose(source int, inner *SourceMap) *SourceMap {
+	c := &SourceMap{
+		File: m.File,
+	}
+	sourceIndex := map[string]int{}
+	addSource := func(sm *SourceMap, i int) int {
+		name := joinPath(sm.SourceRoot, sm.Sources[i])
+		if j, ok := sourceIndex[name]; ok {
+			return j
+		}
+		j := len(c.Sources)
+		sourceIndex[name] = j
+		c.Sources = append(c.Sources, name)
+		if i < len(sm.SourcesContent) && sm.SourcesContent[i] != "" {
+			c.SourcesContent = append(c.SourcesContent, make([]string, j+1-len(c.SourcesContent))...)
+			c.SourcesContent[j] = sm.SourcesContent[i]
+		}
+		return j
+	}
+	nameIndex := map[string]int{}
+	addName := func(name string) int {
+		if j, ok := nameIndex[name]; ok {
+			return j
+		}
+		j := len(c.Names)
+		nameIndex[name] = j
+		c.Names = append(c.Names, name)
+		return j
+	}
+
+	for _, mapping := range m.Mappings {
+		name := -1
+		if mapping.Name != -1 {
+			name = addName(m.Names[mapping.Name])
+		}
+		if mapping.Source == -1 || mapping.Source != source {
+			if mapping.Source != -1 {
+				mapping.Source = addSource(m, mapping.Source)
+			}
+			mapping.Name = name
+			c.Mappings = append(c.Mappings, mapping)
+			continue
+		}
+
+		orig, ok := inner.Find(mapping.Line, mapping.Col)
+		if !ok {
+			c.Mappings = append(c.Mappings, Mapping{mapping.GenLine, mapping.GenCol, -1, 0, 0, -1})
+			continue
+		}
+		if orig.Name != -1 {
+			name = addName(inner.Names[orig.Name])
+		}
+		c.Mappings = append(c.Mappings, Mapping{mapping.GenLine, mapping.GenCol, addSource(inner, orig.Source), orig.Line, orig.Col, name})
+	}
+	if len(c.SourcesContent) != 0 && len(c.SourcesContent) < len(c.Sources) {
+		c.SourcesContent = append(c.SourcesContent, make([]string, len(c.Sources)-len(c.SourcesContent))...)
+	}
+	return c
+}
+
+// Chain composes the source maps of consecutive transformations, where the first source map maps the output of the first transformation to the original sources, and every next source map has the generated file of the previous source map as its first source. It returns the source map from the output of the last transformation to the original sources.
+func Chain(maps ...*SourceMap) *SourceMap {
+	if len(maps) == 0 {
+		return &SourceMap{}
+	}
+	m := maps[0]
+	for _, outer := range maps[1:] {
+		m = outer.Compose(0, m)
+	}
+	return m
+}
+
+// EncodeMappings encodes mappings into the VLQ mappings format. The mappings must be ordered by generated position.
+func EncodeMappings(mappings []Mapping) []byte {
+	b := []byte{}
+	line, genCol, source, origLine, origCol, name := 0, 0, 0, 0, 0, 0
+	for i, m := range mappings {
+		if line < m.GenLine {
+			for line < m.GenLine {
+				b = append(b, ';')
+				line++
+			}
+			genCol = 0
+		} else if i != 0 {
+			b = append(b, ',')
+		}
+		b = AppendVLQ(b, m.GenCol-genCol)
+		genCol = m.GenCol
+		if m.Source != -1 {
+			b = AppendVLQ(b, m.Source-source)
+			b = AppendVLQ(b, m.Line-origLine)
+			b = AppendVLQ(b, m.Col-origCol)
+			source, origLine, origCol = m.Source, m.Line, m.Col
+			if m.Name != -1 {
+				b = AppendVLQ(b, m.Name-name)
+				name = m.Name
+			}
+		}
+	}
+	return b
+}
+
+// DecodeMappings decodes the VLQ mappings format.
+func DecodeMappings(b []byte) ([]Mapping, error) {
+	mappings := []Mapping{}
+	line, genCol, source, origLine, origCol, name := 0, 0, 0, 0, 0, 0
+	for i := 0; i < len(b); {
+		if b[i] == ';' {
+			line++
+			genCol = 0
+			i++
+			continue
+		} else if b[i] == ',' {
+			i++
+			continue
+		}
+
+		var fields [5]int
+		nFields := 0
+		for i < len(b) && b[i] != ',' && b[i] != ';' {
+			if nFields == len(fields) {
+				return nil, parse.NewError(bytes.NewReader(b), i, "too many fields in mapping")
+			}
+			n, m := DecodeVLQ(b[i:])
+			if m == 0 {
+				return nil, parse.NewError(bytes.NewReader(b), i, "invalid VLQ value")
+			}
+			fields[nFields] = n
+			nFields++
+			i += m
+		}
+		if nFields != 1 && nFields != 4 && nFields != 5 {
+			return nil, parse.NewError(bytes.NewReader(b), i, "mapping must have 1, 4, or 5 fields")
+		}
+
+		genCol += fields[0]
+		mapping := Mapping{line, genCol, -1, 0, 0, -1}
+		if 1 < nFields {
+			source += fields[1]
+			origLine += fields[2]
+			origCol += fields[3]
+			mapping.Source, mapping.Line, mapping.Col = source, origLine, origCol
+			if nFields == 5 {
+				name += fields[4]
+				mapping.Name = name
+			}
+		}
+		if genCol < 0 || source < 0 || origLine < 0 || origCol < 0 || name < 0 {
+			return nil, parse.NewError(bytes.NewReader(b), i, "negative index in mapping")
+		}
+		mappings = append(mappings, mapping)
+	}
+
+	// mappings on a line are not required to be ordered
+	sort.SliceStable(mappings, func(i, j int) bool {
+		return mappings[i].GenLine < mappings[j].GenLine || mappings[i].GenLine == mappings[j].GenLine && mappings[i].GenCol < mappings[j].GenCol
+	})
+	return mappings, nil
+}
+
+func joinPath(root, path string) string {
+	if root == "" {
+		return path
+	} else if root[len(root)-1] != '/' {
+		root += "/"
+	}
+	return root + path
+}
@@ -0,0 +1,199 @@
+package sourcemap
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/tdewolff/test"
+)
+
+func TestVLQ(t *testing.T) {
+	var tests = []struct {
+		n   int
+		vlq string
+	}{
+		{0, "A"},
+		{1, "C"},
+		{-1, "D"},
+		{15, "e"},
+		{16, "gB"},
+		{-16, "hB"},
+		{123456789, "qxmvrH"},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.n), func(t *testing.T) {
+			test.String(t, string(AppendVLQ(nil, tt.n)), tt.vlq)
+			n, m := DecodeVLQ([]byte(tt.vlq + "A"))
+			test.T(t, n, tt.n)
+			test.T(t, m, len(tt.vlq))
+		})
+	}
+
+	_, m := DecodeVLQ([]byte("g"))
+	test.T(t, m, 0, "truncated")
+	_, m = DecodeVLQ([]byte("!"))
+	test.T(t, m, 0, "invalid")
+}
+
+func TestMappings(t *testing.T) {
+	var tests = []struct {
+		mappings string
+		expected string
+	}{
+		{"", "[]"},
+		{"AAAA", "[{0 0 0 0 0 -1}]"},
+		{"AAAA,EAAE,CCAAA", "[{0 0 0 0 0 -1} {0 2 0 0 2 -1} {0 3 1 0 2 0}]"},
+		{";;AACA;A", "[{2 0 0 1 0 -1} {3 0 -1 0 0 -1}]"},
+		{"KAAK;EAAA", "[{0 5 0 0 5 -1} {1 2 0 0 5 -1}]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mappings, func(t *testing.T) {
+			mappings, err := DecodeMappings([]byte(tt.mappings))
+			test.Error(t, err)
+			test.String(t, fmt.Sprint(mappings), tt.expected)
+			test.String(t, string(EncodeMappings(mappings)), tt.mappings)
+		})
+	}
+
+	var errorTests = []string{"A!", "AA", "AAAAAA", "D"}
+	for _, tt := range errorTests {
+		t.Run(tt, func(t *testing.T) {
+			_, err := DecodeMappings([]byte(tt))
+			test.That(t, err != nil)
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	src := `{"version":3,"file":"out.js","sourceRoot":"src","sources":["a.js","b.js"],"sourcesContent":[null,"b"],"names":["x"],"mappings":"AAAA,EAAEA;ACAA"}`
+	m, err := Parse([]byte(src))
+	test.Error(t, err)
+	test.T(t, m.File, "out.js")
+	test.T(t, m.SourceRoot, "src")
+	test.T(t, fmt.Sprint(m.Sources), "[a.js b.js]")
+	test.T(t, fmt.Sprint(m.SourcesContent), "[ b]")
+	test.T(t, fmt.Sprint(m.Mappings), "[{0 0 0 0 0 -1} {0 2 0 0 2 0} {1 0 1 0 2 -1}]")
+	test.String(t, string(m.Bytes()), src)
+
+	m, err = Parse([]byte(")]}'\n" + `{"version":3,"sources":[],"names":[],"mappings":""}`))
+	test.Error(t, err)
+	test.String(t, string(m.Bytes()), `{"version":3,"sources":[],"names":[],"mappings":""}`)
+
+	_, err = Parse([]byte(`{"version":2,"sources":[],"names":[],"mappings":""}`))
+	test.That(t, err != nil, "bad version")
+	_, err = Parse([]byte(`{"version":3,"sources":["a.js"],"names":[],"mappings":"ACAA"}`))
+	test.That(t, err != nil, "bad source index")
+}
+
+func TestParseSections(t *testing.T) {
+	src := `{"version":3,"sections":[
+		{"offset":{"line":0,"column":0},"map":{"version":3,"sources":["a.js"],"names":["x"],"mappings":"AAAAA"}},
+		{"offset":{"line":1,"column":4},"map":{"version":3,"sourceRoot":"lib","sources":["b.js"],"names":["y"],"mappings":"AACAA;AAAA"}}
+	]}`
+	m, err := Parse([]byte(src))
+	test.Error(t, err)
+	test.T(t, fmt.Sprint(m.Sources), "[a.js lib/b.js]")
+	test.T(t, fmt.Sprint(m.Names), "[x y]")
+	test.T(t, fmt.Sprint(m.Mappings), "[{0 0 0 0 0 0} {1 4 1 1 0 1} {2 0 1 1 0 -1}]")
+}
+
+func TestFind(t *testing.T) {
+	m := &SourceMap{
+		Sources:  []string{"a.js"},
+		Mappings: []Mapping{{0, 2, 0, 3, 4, -1}, {0, 6, -1, 0, 0, -1}, {1, 0, 0, 5, 0, -1}},
+	}
+	var tests = []struct {
+		line, col int
+		expected  string
+	}{
+		{0, 0, "{0 0 0 0 0 0} false"},
+		{0, 2, "{0 2 0 3 4 -1} true"},
+		{0, 5, "{0 2 0 3 4 -1} true"},
+		{0, 6, "{0 0 0 0 0 0} false"},
+		{1, 10, "{1 0 0 5 0 -1} true"},
+		{2, 0, "{0 0 0 0 0 0} false"},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.line, ":", tt.col), func(t *testing.T) {
+			mapping, ok := m.Find(tt.line, tt.col)
+			test.T(t, fmt.Sprint(mapping, " ", ok), tt.expected)
+		})
+	}
+}
+
+func TestCompose(t *testing.T) {
+	// original.ts -> step1.js -> out.js
+	inner := &SourceMap{
+		File:           "step1.js",
+		Sources:        []string{"original.ts"},
+		SourcesContent: []string{"let x: number = 1"},
+		Names:          []string{"x"},
+		Mappings:       []Mapping{{0, 0, 0, 0, 0, -1}, {0, 4, 0, 0, 4, 0}, {1, 0, 0, 1, 0, -1}},
+	}
+	outer := &SourceMap{
+		File:     "out.js",
+		Sources:  []string{"other.js", "step1.js"},
+		Mappings: []Mapping{{0, 0, 0, 7, 0, -1}, {1, 0, 1, 0, 0, -1}, {1, 2, 1, 0, 5, -1}, {1, 6, 1, 3, 0, -1}},
+	}
+	m := outer.Compose(1, inner)
+	test.T(t, m.File, "out.js")
+	test.T(t, fmt.Sprint(m.Sources), "[other.js original.ts]")
+	test.T(t, fmt.Sprint(m.SourcesContent), "[ let x: number = 1]")
+	test.T(t, fmt.Sprint(m.Names), "[x]")
+	test.T(t, fmt.Sprint(m.Mappings), "[{0 0 0 7 0 -1} {1 0 1 0 0 -1} {1 2 1 0 4 0} {1 6 -1 0 0 -1}]")
+
+	// chain with a single source
+	outer.Sources = outer.Sources[1:]
+	outer.Mappings = []Mapping{{0, 0, 0, 1, 0, -1}}
+	m = Chain(inner, outer)
+	test.T(t, fmt.Sprint(m.Sources), "[original.ts]")
+	test.T(t, fmt.Sprint(m.Mappings), "[{0 0 0 1 0 -1}]")
+}
+
+func TestGenerator(t *testing.T) {
+	g := NewGenerator("out.js")
+	g.IncludeContent = true
+	a := g.AddSource("a.js", []byte("var x = 1;\r\nx++"))
+	b := g.AddSource("b.js", []byte("/* ü */ f(\U0001F600, y)"))
+	y := g.AddName("y")
+	test.T(t, g.AddName("y"), y)
+
+	gen := []byte("var x=1;x++;\nf(\U0001F600,y)")
+	g.Add(0, a, 0, -1)
+	g.Add(4, a, 4, -1)
+	g.Add(8, a, 12, -1)
+	g.Add(15, b, 11, -1)
+	g.Add(13, b, 9, -1)
+	g.Add(13, b, 11, -1) // ignored
+	g.Add(20, b, 17, y)
+	test.T(t, g.Len(), 7)
+
+	m := g.SourceMap(gen)
+	test.T(t, fmt.Sprint(m.Sources), "[a.js b.js]")
+	test.T(t, m.SourcesContent[1], "/* ü */ f(\U0001F600, y)")
+	test.T(t, fmt.Sprint(m.Mappings), "[{0 0 0 0 0 -1} {0 4 0 0 4 -1} {0 8 0 1 0 -1} {1 0 1 0 8 -1} {1 2 1 0 10 -1} {1 5 1 0 14 0}]")
+}
+
+func TestURL(t *testing.T) {
+	var tests = []struct {
+		src string
+		url string
+	}{
+		{"a()\n//# sourceMappingURL=a.js.map", "a.js.map"},
+		{"a()\n//@ sourceMappingURL=a.js.map\n\n", "a.js.map"},
+		{"a()\n//# sourceMappingURL=a.js.map\r\n// comment\n", "a.js.map"},
+		{"a{b:c}\n/*# sourceMappingURL=a.css.map */", "a.css.map"},
+		{"//# sourceMappingURL=a.js.map\nb()", ""},
+		{"a() //# sourceMappingURL=a.js.map", ""},
+		{"a()\n// sourceMappingURL=a.js.map", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.src, func(t *testing.T) {
+			test.String(t, string(URL([]byte(tt.src))), tt.url)
+		})
+	}
+
+	test.String(t, string(AppendURL([]byte("a()"), "a.js.map", false)), "a()\n//# sourceMappingURL=a.js.map")
+	test.String(t, string(AppendURL([]byte("a{}\n"), "a.css.map", true)), "a{}\n/*# sourceMappingURL=a.css.map */")
+}
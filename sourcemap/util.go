@@ -0,0 +1,48 @@
+package sourcemap
+
+import (
+	"bytes"
+)
+
+// URL returns the URL of the source map from the last sourceMappingURL comment in JS or CSS input, that is //# sourceMappingURL=... or /*# sourceMappingURL=... */, or nil if there is none. Only whitespace and other comments may follow the comment.
+func URL(b []byte) []byte {
+	for end := len(b); 0 < end; {
+		start := bytes.LastIndexAny(b[:end], "\n\r") + 1
+		line := bytes.TrimSpace(b[start:end])
+		end = start - 1
+		if len(line) == 0 {
+			continue
+		} else if len(line) < 2 || line[0] != '/' || line[1] != '/' && line[1] != '*' {
+			return nil
+		}
+
+		isBlock := line[1] == '*'
+		if isBlock {
+			if !bytes.HasSuffix(line, []byte("*/")) {
+				return nil
+			}
+			line = line[:len(line)-2]
+		}
+		line = bytes.TrimSpace(line[2:])
+		if 0 < len(line) && (line[0] == '#' || line[0] == '@') {
+			if url := bytes.TrimPrefix(line[1:], []byte(" sourceMappingURL=")); len(url) < len(line)-1 {
+				if i := bytes.IndexAny(url, " \t"); i != -1 {
+					url = url[:i]
+				}
+				return url
+			}
+		}
+	}
+	return nil
+}
+
+// AppendURL appends a sourceMappingURL comment to the generated JS or CSS output in b.
+func AppendURL(b []byte, url string, css bool) []byte {
+	if 0 < len(b) && b[len(b)-1] != '\n' {
+		b = append(b, '\n')
+	}
+	if css {
+		return append(append(append(b, "/*# sourceMappingURL="...), url...), " */"...)
+	}
+	return append(append(b, "//# sourceMappingURL="...), url...)
+}
@@ -0,0 +1,56 @@
+package sourcemap
+
+const base64Chars = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/"
+
+var base64Values [256]int8
+
+func init() {
+	for i := range base64Values {
+		base64Values[i] = -1
+	}
+	for i := 0; i < len(base64Chars); i++ {
+		base64Values[base64Chars[i]] = int8(i)
+	}
+}
+
+// AppendVLQ appends the base64 VLQ encoding of n to b.
+func AppendVLQ(b []byte, n int) []byte {
+	// the sign is stored in the least significant bit
+	u := uint64(n) << 1
+	if n < 0 {
+		u = uint64(-n)<<1 | 1
+	}
+	for {
+		digit := u & 0x1F
+		u >>= 5
+		if u != 0 {
+			digit |= 0x20 // continuation bit
+		}
+		b = append(b, base64Chars[digit])
+		if u == 0 {
+			return b
+		}
+	}
+}
+
+// DecodeVLQ decodes a base64 VLQ value at the start of b and returns it with the number of bytes read. It returns zero bytes read for an invalid or truncated value.
+func DecodeVLQ(b []byte) (int, int) {
+	u := uint64(0)
+	shift := uint(0)
+	for i, c := range b {
+		digit := base64Values[c]
+		if digit < 0 || 60 < shift {
+			return 0, 0
+		}
+		u |= uint64(digit&0x1F) << shift
+		if digit&0x20 == 0 {
+			n := int(u >> 1)
+			if u&1 == 1 {
+				n = -n
+			}
+			return n, i + 1
+		}
+		shift += 5
+	}
+	return 0, 0
+}